@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/crypto"
+	"github.com/lirancohen/dex/internal/toolbelt"
+	"github.com/lirancohen/dex/internal/worker"
+)
+
+// Free space thresholds for the data directory's filesystem. Objectives clone
+// repositories into the data dir, so a nearly full disk fails them mid-run.
+const (
+	doctorMinFreeBytes  = 1 << 30 // Below this the check fails
+	doctorWarnFreeBytes = 5 << 30 // Below this the check warns
+)
+
+// checkStatus is the outcome of a single doctor check
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "ok"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+	checkSkip checkStatus = "skip"
+)
+
+// checkResult is one line of the doctor report
+type checkResult struct {
+	Name   string
+	Status checkStatus
+	Detail string
+}
+
+// doctorOptions configures which checks the doctor runs
+type doctorOptions struct {
+	DataDir      string
+	AnthropicKey string
+	GitHubToken  string
+	MaxDBSize    int64         // Local DB size limit in bytes (0 = unlimited)
+	Timeout      time.Duration // Per network check
+}
+
+// runDoctor checks that this machine can run a worker and prints a report.
+// Returns an error if any check failed.
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	dataDir := fs.String("data-dir", "", "Worker data directory (default: ~/.dex-worker)")
+	anthropicKey := fs.String("anthropic-key", os.Getenv("ANTHROPIC_API_KEY"), "Anthropic API key to test (default: $ANTHROPIC_API_KEY)")
+	githubToken := fs.String("github-token", os.Getenv("GITHUB_TOKEN"), "GitHub token to test (default: $GITHUB_TOKEN)")
+	maxDBSizeMB := fs.Int64("max-db-size-mb", worker.DefaultRetentionPolicy().MaxSizeBytes>>20, "Local database size limit the worker runs with (0 = unlimited)")
+	timeout := fs.Duration("timeout", 15*time.Second, "Timeout for each network check")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dex-worker doctor [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Check that this machine is healthy enough to run a worker.\n")
+		fmt.Fprintf(os.Stderr, "Exits non-zero if any check fails.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dataDir == "" {
+		home, _ := os.UserHomeDir()
+		*dataDir = filepath.Join(home, ".dex-worker")
+	}
+
+	results := runDoctorChecks(context.Background(), doctorOptions{
+		DataDir:      *dataDir,
+		AnthropicKey: *anthropicKey,
+		GitHubToken:  *githubToken,
+		MaxDBSize:    *maxDBSizeMB << 20,
+		Timeout:      *timeout,
+	})
+
+	failed := printDoctorReport(os.Stdout, *dataDir, results)
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// runDoctorChecks runs every check in order. Checks that depend on an earlier
+// one (the local DB needs the master key) are skipped when it failed.
+func runDoctorChecks(ctx context.Context, opts doctorOptions) []checkResult {
+	results := []checkResult{
+		checkDataDir(opts.DataDir),
+		checkIdentity(filepath.Join(opts.DataDir, "identity.json")),
+	}
+
+	keyResult, masterKey := checkMasterKey(filepath.Join(opts.DataDir, "master.key"))
+	results = append(results, keyResult)
+	results = append(results, checkLocalDB(filepath.Join(opts.DataDir, "worker.db"), masterKey, opts.MaxDBSize))
+
+	results = append(results,
+		checkGit(),
+		checkDiskSpace(opts.DataDir),
+		checkService(ctx, "anthropic", opts.AnthropicKey != "", opts.Timeout, func(ctx context.Context) error {
+			return toolbelt.NewAnthropicClient(&toolbelt.AnthropicConfig{APIKey: opts.AnthropicKey}).Ping(ctx)
+		}),
+		checkService(ctx, "github", opts.GitHubToken != "", opts.Timeout, func(ctx context.Context) error {
+			return toolbelt.NewGitHubClient(&toolbelt.GitHubConfig{Token: opts.GitHubToken}).Ping(ctx)
+		}),
+	)
+	return results
+}
+
+// printDoctorReport writes the report and returns the number of failed checks
+func printDoctorReport(w io.Writer, dataDir string, results []checkResult) int {
+	_, _ = fmt.Fprintf(w, "dex-worker v%s doctor\n", version)
+	_, _ = fmt.Fprintf(w, "Data dir: %s\n\n", dataDir)
+
+	failed, warned := 0, 0
+	for _, r := range results {
+		_, _ = fmt.Fprintf(w, "  [%-4s] %-10s %s\n", r.Status, r.Name, r.Detail)
+		switch r.Status {
+		case checkFail:
+			failed++
+		case checkWarn:
+			warned++
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	switch {
+	case failed > 0:
+		_, _ = fmt.Fprintf(w, "%d check(s) failed, %d warning(s)\n", failed, warned)
+	case warned > 0:
+		_, _ = fmt.Fprintf(w, "Healthy with %d warning(s)\n", warned)
+	default:
+		_, _ = fmt.Fprintln(w, "Healthy")
+	}
+	return failed
+}
+
+// checkDataDir verifies the data directory exists and is writable
+func checkDataDir(dataDir string) checkResult {
+	r := checkResult{Name: "data-dir"}
+
+	info, err := os.Stat(dataDir)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+	if !info.IsDir() {
+		r.Status, r.Detail = checkFail, "not a directory"
+		return r
+	}
+
+	f, err := os.CreateTemp(dataDir, ".doctor-*")
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("not writable: %v", err)
+		return r
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	r.Status, r.Detail = checkOK, "writable"
+	return r
+}
+
+// checkIdentity verifies the worker identity loads and can sign messages for HQ
+func checkIdentity(path string) checkResult {
+	r := checkResult{Name: "identity"}
+
+	identity, err := crypto.LoadWorkerIdentity(path)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+	if identity.ID == "" {
+		r.Status, r.Detail = checkFail, "identity file has no worker ID"
+		return r
+	}
+	if identity.PrivateKeyStr == "" {
+		r.Status, r.Detail = checkFail, "identity file has no private key"
+		return r
+	}
+
+	message := []byte("dex-worker doctor")
+	signature, err := identity.Sign(message)
+	if err == nil {
+		err = crypto.VerifySignature(identity.SigningPublicKey(), message, signature)
+	}
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("signing key unusable: %v", err)
+		return r
+	}
+
+	r.Status, r.Detail = checkOK, fmt.Sprintf("%s (%s)", identity.ID, identity.PublicKey())
+	return r
+}
+
+// checkMasterKey verifies the master key exists and can encrypt and decrypt.
+// Unlike startup, it never creates a missing key.
+func checkMasterKey(path string) (checkResult, *crypto.MasterKey) {
+	r := checkResult{Name: "master-key"}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r, nil
+	}
+
+	masterKey, err := crypto.EnsureMasterKey(path)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r, nil
+	}
+
+	encrypted, err := masterKey.Encrypt([]byte("dex-worker doctor"))
+	if err == nil {
+		_, err = masterKey.Decrypt(encrypted)
+	}
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("key unusable: %v", err)
+		return r, nil
+	}
+
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		r.Status, r.Detail = checkWarn, fmt.Sprintf("permissions %#o, expected 0600", perm)
+		return r, masterKey
+	}
+
+	r.Status, r.Detail = checkOK, "present"
+	return r, masterKey
+}
+
+// checkLocalDB verifies the local database opens and passes an integrity check, and
+// reports its size. A missing database is only a warning; the worker creates it on first start.
+func checkLocalDB(path string, masterKey *crypto.MasterKey, maxSize int64) checkResult {
+	r := checkResult{Name: "local-db"}
+
+	if masterKey == nil {
+		r.Status, r.Detail = checkSkip, "master key unavailable"
+		return r
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		r.Status, r.Detail = checkWarn, "not created yet (created on first start)"
+		return r
+	}
+
+	localDB, err := worker.OpenLocalDB(path, masterKey)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+	defer func() { _ = localDB.Close() }()
+
+	if err := localDB.IntegrityCheck(); err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+
+	size, err := localDB.Size()
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+
+	detail := fmt.Sprintf("%s (%.1f MiB)", path, float64(size)/(1<<20))
+	if maxSize > 0 && float64(size) >= float64(maxSize)*worker.AggressivePruneRatio {
+		r.Status, r.Detail = checkWarn, fmt.Sprintf("%s, near the %d MiB limit", detail, maxSize>>20)
+		return r
+	}
+
+	r.Status, r.Detail = checkOK, detail
+	return r
+}
+
+// checkGit verifies git is installed
+func checkGit() checkResult {
+	r := checkResult{Name: "git"}
+
+	out, err := exec.Command("git", "--version").Output()
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("git not usable: %v", err)
+		return r
+	}
+
+	r.Status, r.Detail = checkOK, strings.TrimSpace(string(out))
+	return r
+}
+
+// checkDiskSpace verifies the data directory's filesystem has room for repositories
+func checkDiskSpace(dataDir string) checkResult {
+	r := checkResult{Name: "disk"}
+
+	free, err := worker.FreeDiskSpace(dataDir)
+	if err != nil {
+		r.Status, r.Detail = checkSkip, err.Error()
+		return r
+	}
+
+	detail := fmt.Sprintf("%.1f GiB free", float64(free)/(1<<30))
+	switch {
+	case free < doctorMinFreeBytes:
+		r.Status, r.Detail = checkFail, detail
+	case free < doctorWarnFreeBytes:
+		r.Status, r.Detail = checkWarn, detail
+	default:
+		r.Status, r.Detail = checkOK, detail
+	}
+	return r
+}
+
+// checkService pings an external service if credentials were provided
+func checkService(ctx context.Context, name string, configured bool, timeout time.Duration, ping func(context.Context) error) checkResult {
+	r := checkResult{Name: name}
+
+	if !configured {
+		r.Status, r.Detail = checkSkip, "no credentials provided"
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := ping(ctx); err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+
+	r.Status, r.Detail = checkOK, fmt.Sprintf("reachable (%dms)", time.Since(start).Milliseconds())
+	return r
+}
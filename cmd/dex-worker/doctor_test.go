@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/crypto"
+	"github.com/lirancohen/dex/internal/worker"
+)
+
+func findCheck(t *testing.T, results []checkResult, name string) checkResult {
+	t.Helper()
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no %q check in results", name)
+	return checkResult{}
+}
+
+func TestDoctor_EmptyDataDir(t *testing.T) {
+	dataDir := t.TempDir()
+
+	results := runDoctorChecks(context.Background(), doctorOptions{DataDir: dataDir})
+
+	for name, want := range map[string]checkStatus{
+		"data-dir":   checkOK,
+		"identity":   checkFail,
+		"master-key": checkFail,
+		"local-db":   checkSkip,
+		"anthropic":  checkSkip,
+		"github":     checkSkip,
+	} {
+		if got := findCheck(t, results, name).Status; got != want {
+			t.Errorf("%s status = %q, want %q", name, got, want)
+		}
+	}
+
+	// The doctor must not create anything it checks for
+	if _, err := os.Stat(filepath.Join(dataDir, "master.key")); !os.IsNotExist(err) {
+		t.Error("doctor created a master key")
+	}
+
+	var out bytes.Buffer
+	if failed := printDoctorReport(&out, dataDir, results); failed < 2 {
+		t.Errorf("failed = %d, want at least 2", failed)
+	}
+	if !strings.Contains(out.String(), "check(s) failed") {
+		t.Errorf("report missing failure summary:\n%s", out.String())
+	}
+}
+
+func TestDoctor_InitializedDataDir(t *testing.T) {
+	dataDir := t.TempDir()
+
+	if _, err := crypto.EnsureWorkerIdentity(filepath.Join(dataDir, "identity.json"), "worker-test"); err != nil {
+		t.Fatal(err)
+	}
+	masterKey, err := crypto.EnsureMasterKey(filepath.Join(dataDir, "master.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	localDB, err := worker.OpenLocalDB(filepath.Join(dataDir, "worker.db"), masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = localDB.Close()
+
+	results := runDoctorChecks(context.Background(), doctorOptions{DataDir: dataDir})
+
+	for _, name := range []string{"data-dir", "identity", "master-key", "local-db"} {
+		if r := findCheck(t, results, name); r.Status != checkOK {
+			t.Errorf("%s status = %q (%s), want ok", name, r.Status, r.Detail)
+		}
+	}
+}
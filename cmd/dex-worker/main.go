@@ -2,15 +2,21 @@
 // It can run in two modes:
 //   - subprocess: Spawned by HQ, communicates via stdin/stdout
 //   - standalone: Connects to HQ via mesh network
+//
+// Run 'dex-worker doctor' to check a worker machine's health without connecting to HQ.
+// Run 'dex-worker run --objective-file <json>' to execute one objective without HQ.
 package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -24,14 +30,50 @@ import (
 const version = "0.1.0-dev"
 
 func main() {
+	// Handle subcommands
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		if err := runDoctor(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		if err := runObjective(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		if err := runPrune(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Define flags
 	mode := flag.String("mode", "subprocess", "Worker mode: subprocess (stdin/stdout) or mesh (network)")
 	id := flag.String("id", "", "Worker ID (auto-generated if not provided)")
 	dataDir := flag.String("data-dir", "", "Worker data directory (for local database, identity)")
 	hqPublicKey := flag.String("hq-public-key", "", "HQ's public key for encrypting responses")
+	hqSigningKey := flag.String("hq-signing-key", "", "HQ's signing key for authenticating HQ messages")
 	meshControlURL := flag.String("mesh-control-url", "https://central.enbox.id", "Mesh control server URL (mesh mode only)")
 	meshAuthKey := flag.String("mesh-auth-key", "", "Mesh auth key (mesh mode only)")
 	hqAddress := flag.String("hq-address", "", "HQ mesh address to connect to (mesh mode only)")
+	defaultRetention := worker.DefaultRetentionPolicy()
+	activityRetention := flag.Duration("activity-retention", defaultRetention.ActivityMaxAge, "How long to keep activity already synced to HQ (0 = forever)")
+	maxFinishedObjectives := flag.Int("max-finished-objectives", defaultRetention.MaxFinishedObjectives, "Finished objectives to keep in the local database (0 = unlimited)")
+	objectiveRetention := flag.Duration("objective-retention", defaultRetention.ObjectiveMaxAge, "How long to keep finished objectives in the local database (0 = forever)")
+	maxDBSizeMB := flag.Int64("max-db-size-mb", defaultRetention.MaxSizeBytes>>20, "Local database size that triggers pruning of all synced history (0 = unlimited)")
+	maxDebugDirs := flag.Int("max-debug-dirs", defaultRetention.MaxDebugDirs, "Project directories kept for debugging after failed or cancelled objectives, newest first (0 = unlimited)")
+	debugDirRetention := flag.Duration("debug-dir-retention", defaultRetention.DebugDirMaxAge, "How long to keep project directories of failed or cancelled objectives (0 = forever)")
+	warmRepos := flag.String("warm-repos", "", "Comma-separated clone URLs to keep pre-cloned for instant project setup")
+	warmRefresh := flag.Duration("warm-refresh", worker.DefaultWarmRefreshInterval, "How often to fetch updates into pre-cloned repos")
+	streamLogs := flag.Bool("stream-logs", false, "Stream this worker's stderr to HQ for live debugging")
+	logStreamRate := flag.Int("log-stream-rate", worker.DefaultLogStreamRate, "Log lines per second streamed to HQ; excess lines are dropped (with --stream-logs)")
+	resumeSecretRetries := flag.Int("resume-secret-retries", 2, "Times to ask HQ to re-send a crashed session's secrets when they can't be decrypted with this worker's key")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 
 	flag.Parse()
@@ -85,7 +127,32 @@ func main() {
 	// Run in appropriate mode
 	switch *mode {
 	case "subprocess":
-		runSubprocessMode(ctx, identity, *dataDir, *hqPublicKey)
+		retention := worker.RetentionPolicy{
+			ActivityMaxAge:        *activityRetention,
+			MaxFinishedObjectives: *maxFinishedObjectives,
+			ObjectiveMaxAge:       *objectiveRetention,
+			MaxSizeBytes:          *maxDBSizeMB << 20,
+			MaxDebugDirs:          *maxDebugDirs,
+			DebugDirMaxAge:        *debugDirRetention,
+		}
+		var warmPool *worker.WarmPool
+		if urls := splitList(*warmRepos); len(urls) > 0 {
+			warmPool = worker.NewWarmPool(*dataDir, urls)
+			go warmPool.Run(ctx, *warmRefresh)
+		}
+		var logStream *worker.LogStreamConfig
+		if *streamLogs {
+			if *logStreamRate <= 0 {
+				fmt.Fprintf(os.Stderr, "--log-stream-rate must be positive\n")
+				os.Exit(1)
+			}
+			logStream = &worker.LogStreamConfig{RatePerSecond: *logStreamRate}
+		}
+		if *resumeSecretRetries < 0 {
+			fmt.Fprintf(os.Stderr, "--resume-secret-retries must not be negative\n")
+			os.Exit(1)
+		}
+		runSubprocessMode(ctx, identity, *dataDir, *hqPublicKey, *hqSigningKey, retention, warmPool, logStream, *resumeSecretRetries)
 	case "mesh":
 		runMeshMode(ctx, identity, *dataDir, *meshControlURL, *meshAuthKey, *hqAddress)
 	default:
@@ -95,7 +162,8 @@ func main() {
 }
 
 // runSubprocessMode runs the worker in subprocess mode, communicating via stdin/stdout.
-func runSubprocessMode(ctx context.Context, identity *crypto.WorkerIdentity, dataDir, hqPublicKey string) {
+// A non-nil logStream streams stderr to HQ once the handshake is done.
+func runSubprocessMode(ctx context.Context, identity *crypto.WorkerIdentity, dataDir, hqPublicKey, hqSigningKey string, retention worker.RetentionPolicy, warmPool *worker.WarmPool, logStream *worker.LogStreamConfig, resumeSecretRetries int) {
 	// Create protocol connection over stdin/stdout
 	conn := worker.NewConn(os.Stdin, os.Stdout)
 
@@ -129,6 +197,9 @@ func runSubprocessMode(ctx context.Context, identity *crypto.WorkerIdentity, dat
 
 	// Create project manager
 	projectManager := worker.NewProjectManager(dataDir)
+	if warmPool != nil {
+		projectManager.SetWarmPool(warmPool)
+	}
 
 	// Create worker runner
 	runner := &workerRunner{
@@ -139,8 +210,13 @@ func runSubprocessMode(ctx context.Context, identity *crypto.WorkerIdentity, dat
 		hqPublicKey:    hqPublicKey,
 		dataDir:        dataDir,
 		promptLoader:   promptLoader,
+		promptCache:    worker.NewPromptCache(filepath.Join(dataDir, "prompts"), promptLoader),
 		projectManager: projectManager,
+		retention:      retention,
 		startedAt:      time.Now(),
+
+		resumeSecretRetries: resumeSecretRetries,
+		resumeTimeout:       defaultResumeTimeout,
 	}
 
 	// Check for incomplete sessions from previous run
@@ -168,11 +244,30 @@ func runSubprocessMode(ctx context.Context, identity *crypto.WorkerIdentity, dat
 		runner.crashedSession = crashedSession
 	}
 
-	// Send ready message
-	if err := conn.SendReady(identity.ID, version, identity.PublicKey()); err != nil {
+	// Send ready message and authenticate HQ
+	if hqSigningKey == "" {
+		fmt.Fprintf(os.Stderr, "Warning: no HQ signing key configured, HQ messages will not be authenticated\n")
+	}
+	objectiveID, sessionID := runner.currentWork()
+	challenge, err := conn.SendAuthenticatedReady(identity, version, objectiveID, sessionID)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to send ready: %v\n", err)
 		os.Exit(1)
 	}
+	if err := conn.AwaitHQChallenge(identity, hqSigningKey, challenge); err != nil {
+		fmt.Fprintf(os.Stderr, "Handshake failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if logStream != nil {
+		runner.logStreamer = worker.NewLogStreamer(conn, identity.ID, *logStream)
+		if err := teeStderr(runner.logStreamer); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to stream logs to HQ: %v\n", err)
+			runner.logStreamer = nil
+		} else {
+			go runner.logStreamer.Run(ctx)
+		}
+	}
 
 	fmt.Fprintf(os.Stderr, "Worker ready, waiting for objectives...\n")
 
@@ -183,6 +278,29 @@ func runSubprocessMode(ctx context.Context, identity *crypto.WorkerIdentity, dat
 	}
 }
 
+// teeStderr copies everything written to os.Stderr to w as well.
+func teeStderr(w io.Writer) error {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stderr := os.Stderr
+	os.Stderr = pw
+	go func() { _, _ = io.Copy(io.MultiWriter(stderr, w), r) }()
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // runMeshMode runs the worker in mesh mode, connecting to HQ over the network.
 func runMeshMode(ctx context.Context, identity *crypto.WorkerIdentity, dataDir, controlURL, authKey, hqAddress string) {
 	// TODO: Implement mesh mode
@@ -197,9 +315,16 @@ func runMeshMode(ctx context.Context, identity *crypto.WorkerIdentity, dataDir,
 
 // Heartbeat configuration
 const (
-	heartbeatInterval = 10 * time.Second
+	heartbeatInterval = worker.DefaultHeartbeatInterval
 )
 
+// compactionInterval is how often the local database is pruned and vacuumed while idle
+const compactionInterval = time.Hour
+
+// defaultResumeTimeout is how long a crashed session waits for HQ to answer a
+// crash report or secrets re-send request before it is failed
+const defaultResumeTimeout = 5 * time.Minute
+
 // workerRunner handles the main worker loop.
 type workerRunner struct {
 	conn        *worker.Conn
@@ -210,8 +335,11 @@ type workerRunner struct {
 	dataDir     string
 
 	// Components for execution
-	promptLoader   *worker.WorkerPromptLoader
+	promptLoader   *worker.WorkerPromptLoader // Compiled-in prompts, used for resumed sessions
+	promptCache    *worker.PromptCache        // Prompt sets shipped by HQ
 	projectManager *worker.ProjectManager
+	retention      worker.RetentionPolicy
+	logStreamer    *worker.LogStreamer // Streams stderr to HQ (nil = disabled)
 
 	// Worker state
 	startedAt time.Time
@@ -219,6 +347,10 @@ type workerRunner struct {
 	// Recovery state
 	pendingRecoveryEvents []*worker.ActivityEvent
 	crashedSession        *worker.SessionState
+	resumeSecretRetries   int // Re-send requests allowed when resume secrets don't decrypt
+	resumeSecretAttempts  int // Re-send requests made for the current crashed session
+	resumeTimeout         time.Duration
+	resumeTimer           *time.Timer // Fails the crashed session if HQ doesn't answer
 
 	// Current execution state
 	mu               sync.Mutex
@@ -233,6 +365,9 @@ func (r *workerRunner) run(ctx context.Context) error {
 	// Start heartbeat goroutine
 	go r.heartbeatLoop(ctx)
 
+	// Start local DB compaction goroutine
+	go r.compactionLoop(ctx)
+
 	// Recover unsynced activity from previous run
 	if len(r.pendingRecoveryEvents) > 0 {
 		r.recoverActivity()
@@ -251,6 +386,10 @@ func (r *workerRunner) run(ctx context.Context) error {
 		}
 
 		msg, err := r.conn.Receive()
+		if worker.IsRejectedMessage(err) {
+			fmt.Fprintf(os.Stderr, "Dropped message from HQ: %v\n", err)
+			continue
+		}
 		if err != nil {
 			return fmt.Errorf("receive error: %w", err)
 		}
@@ -262,6 +401,17 @@ func (r *workerRunner) run(ctx context.Context) error {
 	}
 }
 
+// currentWork returns the objective and session the worker is running, if any.
+func (r *workerRunner) currentWork() (objectiveID, sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.currentObjective != nil {
+		objectiveID = r.currentObjective.Objective.ID
+	}
+	return objectiveID, r.currentSessionID
+}
+
 // heartbeatLoop sends periodic heartbeats to HQ.
 func (r *workerRunner) heartbeatLoop(ctx context.Context) {
 	ticker := time.NewTicker(heartbeatInterval)
@@ -277,6 +427,55 @@ func (r *workerRunner) heartbeatLoop(ctx context.Context) {
 	}
 }
 
+// compactionLoop prunes and vacuums the local database at startup and then periodically.
+// Compaction is skipped while an objective is running so it never blocks the session.
+func (r *workerRunner) compactionLoop(ctx context.Context) {
+	ticker := time.NewTicker(compactionInterval)
+	defer ticker.Stop()
+
+	for {
+		r.mu.Lock()
+		busy := r.currentObjective != nil
+		r.mu.Unlock()
+
+		if !busy {
+			r.compactLocalDB()
+			r.pruneDebugDirs()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// compactLocalDB applies the retention policy to the local database.
+func (r *workerRunner) compactLocalDB() {
+	result, err := r.localDB.Compact(r.retention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: local database compaction failed: %v\n", err)
+		return
+	}
+	if result.ActivityPruned > 0 || result.ObjectivesPruned > 0 || result.Aggressive {
+		fmt.Fprintf(os.Stderr, "Compacted local database: pruned %d activity events and %d objectives (aggressive: %v), %d -> %d bytes\n",
+			result.ActivityPruned, result.ObjectivesPruned, result.Aggressive, result.SizeBefore, result.SizeAfter)
+	}
+}
+
+// pruneDebugDirs removes project directories of failed or cancelled objectives
+// the retention policy no longer keeps.
+func (r *workerRunner) pruneDebugDirs() {
+	removed, err := r.projectManager.PruneDebugDirs(r.retention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to prune debug directories: %v\n", err)
+	}
+	if len(removed) > 0 {
+		fmt.Fprintf(os.Stderr, "Pruned %d project directories kept for debugging\n", len(removed))
+	}
+}
+
 // reportCrashedSession sends a crash report to HQ for a session that didn't complete.
 func (r *workerRunner) reportCrashedSession() {
 	session := r.crashedSession
@@ -304,13 +503,73 @@ func (r *workerRunner) reportCrashedSession() {
 		fmt.Fprintf(os.Stderr, "Warning: failed to send crash report: %v\n", err)
 		// Mark as crashed locally since we couldn't report
 		_ = r.localDB.MarkSessionComplete(session.SessionID, "crashed")
-		r.crashedSession = nil
+		r.clearCrashedSession()
 		return
 	}
 
 	fmt.Fprintf(os.Stderr, "Crash report sent, waiting for HQ decision...\n")
 	// Note: HQ will either send a Resume message or a new Dispatch
 	// The crashed session state is kept until then
+	r.awaitResume(session)
+}
+
+// awaitResume (re)starts the timer that fails the crashed session if HQ
+// doesn't answer within resumeTimeout.
+func (r *workerRunner) awaitResume(session *worker.SessionState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.resumeTimer != nil {
+		r.resumeTimer.Stop()
+	}
+	r.resumeTimer = time.AfterFunc(r.resumeTimeout, func() {
+		r.expireResume(session.SessionID)
+	})
+}
+
+// expireResume fails the crashed session HQ never answered for.
+func (r *workerRunner) expireResume(sessionID string) {
+	r.mu.Lock()
+	if r.crashedSession == nil || r.crashedSession.SessionID != sessionID {
+		r.mu.Unlock()
+		return
+	}
+	r.crashedSession = nil
+	r.resumeSecretAttempts = 0
+	r.resumeTimer = nil
+	r.mu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "HQ did not answer for crashed session %s within %s, giving up on resuming it\n", sessionID, r.resumeTimeout)
+	_ = r.localDB.MarkSessionComplete(sessionID, "resume_timeout")
+}
+
+// takeCrashedSession returns the crashed session HQ answered for and stops
+// its resume timer. It returns nil if sessionID isn't the crashed session.
+func (r *workerRunner) takeCrashedSession(sessionID string) *worker.SessionState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.crashedSession == nil || r.crashedSession.SessionID != sessionID {
+		return nil
+	}
+	if r.resumeTimer != nil {
+		r.resumeTimer.Stop()
+		r.resumeTimer = nil
+	}
+	return r.crashedSession
+}
+
+// clearCrashedSession forgets the crashed session once it is resumed or failed.
+func (r *workerRunner) clearCrashedSession() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.resumeTimer != nil {
+		r.resumeTimer.Stop()
+		r.resumeTimer = nil
+	}
+	r.crashedSession = nil
+	r.resumeSecretAttempts = 0
 }
 
 // recoverActivity sends unsynced activity from previous runs to HQ.
@@ -380,6 +639,7 @@ func (r *workerRunner) sendHeartbeat() {
 	r.mu.Unlock()
 
 	uptime := int64(time.Since(r.startedAt).Seconds())
+	dbSize, _ := r.localDB.Size()
 
 	_ = r.conn.SendHeartbeat(&worker.HeartbeatPayload{
 		WorkerID:     r.identity.ID,
@@ -390,6 +650,7 @@ func (r *workerRunner) sendHeartbeat() {
 		TokensInput:  tokensInput,
 		TokensOutput: tokensOutput,
 		Uptime:       uptime,
+		DBSizeBytes:  dbSize,
 	})
 }
 
@@ -423,10 +684,35 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 	r.mu.Lock()
 	r.currentObjective = objective
 	r.mu.Unlock()
+	if r.logStreamer != nil {
+		r.logStreamer.SetObjective(objective.Objective.ID)
+	}
 
 	fmt.Fprintf(os.Stderr, "Received objective: %s\n", objective.Objective.Title)
 	fmt.Fprintf(os.Stderr, "  ID: %s\n", objective.Objective.ID)
 	fmt.Fprintf(os.Stderr, "  Hat: %s\n", objective.Objective.Hat)
+	fmt.Fprintf(os.Stderr, "  Model: %s\n", objective.Objective.ModelID())
+
+	// Reject objectives this machine can't fulfill rather than fail them partway through
+	if unmet := objective.Objective.Acceptance.Check(ctx, r.dataDir); len(unmet) > 0 {
+		fmt.Fprintf(os.Stderr, "  Rejecting objective, unmet acceptance criteria:\n")
+		for _, u := range unmet {
+			fmt.Fprintf(os.Stderr, "    - %s\n", u)
+		}
+		r.clearCurrentExecution()
+		if err := r.conn.SendRejected(objective.Objective.ID, unmet); err != nil {
+			return fmt.Errorf("failed to send rejected: %w", err)
+		}
+		return nil
+	}
+
+	// HQ decides the model; never fall back to another one it didn't ask for
+	if err := worker.ValidateModel(objective.Objective.Model); err != nil {
+		fmt.Fprintf(os.Stderr, "  Failing objective: %v\n", err)
+		_ = r.conn.SendFailed(objective.Objective.ID, "", err.Error(), 0)
+		r.clearCurrentExecution()
+		return nil
+	}
 
 	// 2. Decrypt secrets
 	secrets, err := r.receiver.DecryptPayload(objective)
@@ -437,6 +723,13 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 	fmt.Fprintf(os.Stderr, "  Secrets decrypted: anthropic_key=%v, github_token=%v\n",
 		secrets.AnthropicKey != "", secrets.GitHubToken != "")
 
+	// Objectives dispatched for a plan preview stop once the plan is sent;
+	// HQ dispatches them again with the approved plan
+	if objective.Objective.PreviewPlan {
+		r.previewPlan(ctx, objective, secrets)
+		return nil
+	}
+
 	// 3. Store objective in local DB
 	if err := r.localDB.StoreObjective(objective); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to store objective locally: %v\n", err)
@@ -453,7 +746,60 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 		return fmt.Errorf("failed to send accepted: %w", err)
 	}
 
-	// 6. Setup project
+	// 6. Resolve the prompt set HQ expects
+	promptLoader, err := r.promptCache.Resolve(objective.PromptVersion, objective.Prompts)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to load prompts: %v", err)
+		fmt.Fprintf(os.Stderr, "  %s\n", errMsg)
+		_ = r.conn.SendFailed(objective.Objective.ID, sessionID, errMsg, 0)
+		r.clearCurrentExecution()
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "  Using prompt set %s\n", promptLoader.Version())
+
+	// 7. Set up the project and run the objective
+	run, err := r.executeObjective(ctx, objective, secrets, sessionID, promptLoader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  %s\n", err)
+		_ = r.conn.SendFailed(objective.Objective.ID, sessionID, err.Error(), 0)
+		r.clearCurrentExecution()
+		return nil
+	}
+
+	// 8. Send completion or failure
+	switch run.status {
+	case "timed_out":
+		_ = r.conn.SendTimedOut(objective.Objective.ID, sessionID, run.timeout, run.iterations)
+	case "cancelled":
+		_ = r.conn.Send(worker.MsgTypeCancelled, nil)
+	case "failed":
+		_ = r.conn.SendFailed(objective.Objective.ID, sessionID, run.err.Error(), run.iterations)
+	default:
+		if err := r.conn.SendCompleted(run.report); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to send completion: %v\n", err)
+		}
+	}
+
+	r.finishObjective(objective.Objective.ID, run)
+	r.clearCurrentExecution()
+
+	return nil
+}
+
+// objectiveRun is the outcome of running an objective's Ralph loop.
+type objectiveRun struct {
+	status     string                   // completed, failed, cancelled, or timed_out
+	report     *worker.CompletionReport // Set when completed
+	err        error                    // Why the loop stopped, when not completed
+	timeout    time.Duration
+	iterations int
+	workDir    string
+}
+
+// executeObjective sets up the objective's project and runs its Ralph loop to
+// the end, delivering outputs on success. It returns an error only if setup
+// failed before the loop ran. Reporting the outcome is left to the caller.
+func (r *workerRunner) executeObjective(ctx context.Context, objective *worker.ObjectivePayload, secrets *worker.WorkerSecrets, sessionID string, promptLoader *worker.WorkerPromptLoader) (*objectiveRun, error) {
 	fmt.Fprintf(os.Stderr, "Setting up project %s/%s...\n", objective.Project.GitHubOwner, objective.Project.GitHubRepo)
 
 	// Use authenticated clone URL if we have a token
@@ -468,15 +814,11 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 
 	workDir, err := r.projectManager.SetupProject(projectWithAuth, objective.Objective.BaseBranch)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to setup project: %v", err)
-		fmt.Fprintf(os.Stderr, "  %s\n", errMsg)
-		_ = r.conn.SendFailed(objective.Objective.ID, sessionID, errMsg, 0)
-		r.clearCurrentExecution()
-		return nil
+		return nil, fmt.Errorf("Failed to setup project: %v", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Project ready at %s\n", workDir)
 
-	// 7. Create work branch if specified
+	// Create work branch if specified
 	branchName := objective.Objective.BaseBranch
 	if branchName == "" {
 		branchName = fmt.Sprintf("dex/%s", objective.Objective.ID[:8])
@@ -485,33 +827,36 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 		fmt.Fprintf(os.Stderr, "Warning: failed to create branch %s: %v\n", branchName, err)
 	}
 
-	// 8. Create session
+	// Create session
 	session := worker.NewWorkerSession(sessionID, objective.Objective.ID, objective.Objective.Hat, workDir)
 	if objective.Objective.TokenBudget > 0 {
 		session.SetBudgets(objective.Objective.TokenBudget, 0, 0)
 	}
 
-	// 9. Create execution context with cancellation
-	execCtx, cancel := context.WithCancel(ctx)
+	// Create execution context with cancellation, bounded by the objective timeout
+	timeout := objective.Objective.Timeout()
+	var execCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		execCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		execCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
 	r.mu.Lock()
 	r.currentCancel = cancel
 	r.currentSession = session
 	r.mu.Unlock()
 
-	// 10. Create Anthropic client
+	// Create Anthropic client
 	anthropicClient := toolbelt.NewAnthropicClient(&toolbelt.AnthropicConfig{
 		APIKey: secrets.AnthropicKey,
 	})
 	if anthropicClient == nil {
-		errMsg := "Failed to create Anthropic client - no API key"
-		fmt.Fprintf(os.Stderr, "  %s\n", errMsg)
-		_ = r.conn.SendFailed(objective.Objective.ID, sessionID, errMsg, 0)
-		cancel()
-		r.clearCurrentExecution()
-		return nil
+		return nil, errors.New("Failed to create Anthropic client - no API key")
 	}
 
-	// 11. Create activity recorder
+	// Create activity recorder
 	syncInterval := objective.Sync.ActivityIntervalSec
 	if syncInterval <= 0 {
 		syncInterval = 30
@@ -519,10 +864,18 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 	activityRecorder := worker.NewWorkerActivityRecorder(r.localDB, r.conn, session, syncInterval)
 	go activityRecorder.StartSyncLoop(execCtx)
 
-	// 12. Create tool executor
+	// Create tool executor
 	executor := worker.NewWorkerToolExecutor(workDir, objective.Project.GitHubOwner, objective.Project.GitHubRepo, secrets.GitHubToken)
+	executor.SetBaseBranch(objective.Objective.BaseBranch)
+	if err := executor.SetNetworkPolicy(objective.Objective.Network, objective.Project.CloneURL); err != nil {
+		activityRecorder.StopSyncLoop()
+		return nil, fmt.Errorf("Failed to apply network policy: %v", err)
+	}
+	if objective.Objective.Network.Restricted() {
+		fmt.Fprintf(os.Stderr, "  Network policy: %s\n", objective.Objective.Network.Mode)
+	}
 
-	// 13. Create and run the Ralph loop
+	// Create and run the Ralph loop
 	fmt.Fprintf(os.Stderr, "Starting Ralph loop for hat '%s'...\n", session.Hat)
 
 	loop := worker.NewWorkerRalphLoop(
@@ -530,7 +883,7 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 		anthropicClient,
 		activityRecorder,
 		r.conn,
-		r.promptLoader,
+		promptLoader,
 		executor,
 		&objective.Objective,
 		&objective.Project,
@@ -556,16 +909,25 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 		fmt.Fprintf(os.Stderr, "Warning: final activity flush failed: %v\n", flushErr)
 	}
 
-	// 14. Send completion or failure
-	if err != nil {
-		if err == worker.ErrCancelled {
-			fmt.Fprintf(os.Stderr, "Objective cancelled\n")
-			_ = r.conn.Send(worker.MsgTypeCancelled, nil)
-		} else {
-			fmt.Fprintf(os.Stderr, "Objective failed: %v\n", err)
-			_ = r.conn.SendFailed(objective.Objective.ID, sessionID, err.Error(), session.GetIteration())
-		}
-	} else {
+	run := &objectiveRun{
+		status:     "completed",
+		err:        err,
+		timeout:    timeout,
+		iterations: session.GetIteration(),
+		workDir:    workDir,
+	}
+	timedOut := err == worker.ErrCancelled && errors.Is(execCtx.Err(), context.DeadlineExceeded)
+	switch {
+	case timedOut:
+		run.status = "timed_out"
+		fmt.Fprintf(os.Stderr, "Objective timed out after %s\n", timeout)
+	case err == worker.ErrCancelled:
+		run.status = "cancelled"
+		fmt.Fprintf(os.Stderr, "Objective cancelled\n")
+	case err != nil:
+		run.status = "failed"
+		fmt.Fprintf(os.Stderr, "Objective failed: %v\n", err)
+	default:
 		fmt.Fprintf(os.Stderr, "Objective completed: %s\n", report.Status)
 		fmt.Fprintf(os.Stderr, "  Summary: %s\n", report.Summary)
 		fmt.Fprintf(os.Stderr, "  Iterations: %d, Tokens: %d\n", report.Iterations, report.TotalTokens)
@@ -575,37 +937,119 @@ func (r *workerRunner) handleDispatch(ctx context.Context, msg *worker.Message)
 			report.CompletedAt = time.Now()
 		}
 
-		if err := r.conn.SendCompleted(report); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to send completion: %v\n", err)
-		}
+		r.deliverOutputs(ctx, &objective.Objective, report, secrets.GitHubToken)
+		run.report = report
 	}
+	return run, nil
+}
 
-	// Update objective status in local DB
-	status := "completed"
-	if err != nil {
-		if err == worker.ErrCancelled {
-			status = "cancelled"
-		} else {
-			status = "failed"
-		}
-	}
-	_ = r.localDB.UpdateObjectiveStatus(objective.Objective.ID, status)
+// finishObjective records the objective's final status locally and cleans up
+// its project directory once the outcome has been reported.
+func (r *workerRunner) finishObjective(objectiveID string, run *objectiveRun) {
+	_ = r.localDB.UpdateObjectiveStatus(objectiveID, run.status)
 
 	// Cleanup project directory to save disk space
 	// Keep it around for a bit in case we need to debug
-	if status == "completed" {
+	if run.status == "completed" {
 		// Only cleanup on successful completion
 		// Failed/cancelled objectives might need debugging
-		fmt.Fprintf(os.Stderr, "Cleaning up project directory: %s\n", workDir)
-		if cleanupErr := r.projectManager.Cleanup(workDir); cleanupErr != nil {
+		fmt.Fprintf(os.Stderr, "Cleaning up project directory: %s\n", run.workDir)
+		if cleanupErr := r.projectManager.Cleanup(run.workDir); cleanupErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to cleanup project: %v\n", cleanupErr)
 		}
+	} else if keepErr := r.projectManager.KeepForDebugging(run.workDir); keepErr != nil {
+		// Pruned per the retention policy once it's old enough
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark project kept for debugging: %v\n", keepErr)
 	}
+}
 
-	cancel()
-	r.clearCurrentExecution()
+// deliverOutputs sends a completed objective's result to its output
+// destinations, recording any failures on the report sent to HQ.
+func (r *workerRunner) deliverOutputs(ctx context.Context, objective *worker.Objective, report *worker.CompletionReport, githubToken string) {
+	if len(objective.Outputs) == 0 {
+		return
+	}
 
-	return nil
+	deliverer := worker.NewOutputDeliverer(filepath.Join(r.dataDir, "outputs"), githubToken)
+	report.OutputErrors = deliverer.Deliver(ctx, objective.Outputs, worker.NewOutputResult(objective, report))
+	for _, failure := range report.OutputErrors {
+		fmt.Fprintf(os.Stderr, "Warning: failed to deliver output: %s\n", failure)
+	}
+	fmt.Fprintf(os.Stderr, "  Delivered to %d of %d output destinations\n",
+		len(objective.Outputs)-len(report.OutputErrors), len(objective.Outputs))
+}
+
+// previewPlan sets up the project, asks the model how it would carry out the
+// objective, and sends that plan to HQ for approval instead of running it.
+func (r *workerRunner) previewPlan(ctx context.Context, objective *worker.ObjectivePayload, secrets *worker.WorkerSecrets) {
+	defer r.clearCurrentExecution()
+	objectiveID := objective.Objective.ID
+
+	fmt.Fprintf(os.Stderr, "Previewing plan for objective %s...\n", objectiveID)
+
+	cloneURL := objective.Project.CloneURL
+	if secrets.GitHubToken != "" {
+		cloneURL = worker.SetupAuthenticatedCloneURL(cloneURL, secrets.GitHubToken)
+	}
+	projectWithAuth := objective.Project
+	projectWithAuth.CloneURL = cloneURL
+
+	// Without the repo the plan is drawn from the objective alone
+	workDir, err := r.projectManager.SetupProject(projectWithAuth, objective.Objective.BaseBranch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to setup project for plan preview: %v\n", err)
+		workDir = ""
+	}
+
+	anthropicClient := toolbelt.NewAnthropicClient(&toolbelt.AnthropicConfig{
+		APIKey: secrets.AnthropicKey,
+	})
+	if anthropicClient == nil {
+		errMsg := "Failed to create Anthropic client - no API key"
+		fmt.Fprintf(os.Stderr, "  %s\n", errMsg)
+		_ = r.conn.SendFailed(objectiveID, "", errMsg, 0)
+		return
+	}
+
+	preview, err := worker.PreviewPlan(ctx, anthropicClient, &objective.Objective, &objective.Project, workDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Plan preview failed: %v\n", err)
+		_ = r.conn.SendFailed(objectiveID, "", err.Error(), 0)
+		return
+	}
+
+	if err := r.conn.SendPlanPreview(preview); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send plan preview: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  Plan sent to HQ for approval\n")
+}
+
+// handleResumeDecryptFailure handles secrets in a resume message that can't be
+// decrypted. A key mismatch usually means keys rotated between the crash and
+// the resume, so HQ is asked to encrypt the secrets again, up to
+// resumeSecretRetries times. A corrupt payload fails the session right away.
+func (r *workerRunner) handleResumeDecryptFailure(session *worker.SessionState, decryptErr error) error {
+	if errors.Is(decryptErr, worker.ErrSecretsKeyMismatch) && r.resumeSecretAttempts < r.resumeSecretRetries {
+		r.resumeSecretAttempts++
+		fmt.Fprintf(os.Stderr, "Could not decrypt secrets for session %s (%v), asking HQ to re-send them (attempt %d/%d)\n",
+			session.SessionID, decryptErr, r.resumeSecretAttempts, r.resumeSecretRetries)
+		err := r.conn.SendSecretsResendRequest(r.identity.ID, session.ObjectiveID, session.SessionID, r.resumeSecretAttempts)
+		if err == nil {
+			r.awaitResume(session)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Warning: failed to request secrets from HQ: %v\n", err)
+	}
+
+	r.clearCrashedSession()
+
+	reason := "decrypt_failed"
+	if errors.Is(decryptErr, worker.ErrSecretsCorrupt) {
+		reason = "secrets_corrupt"
+	}
+	_ = r.localDB.MarkSessionComplete(session.SessionID, reason)
+	return fmt.Errorf("failed to decrypt secrets for resumption: %w", decryptErr)
 }
 
 // handleResume handles a resume message from HQ to continue a crashed session.
@@ -615,34 +1059,33 @@ func (r *workerRunner) handleResume(ctx context.Context, msg *worker.Message) er
 		return fmt.Errorf("failed to parse resume payload: %w", err)
 	}
 
+	// Verify we have the crashed session; HQ answered, so stop waiting on it
+	crashedSession := r.takeCrashedSession(payload.SessionID)
+
 	// Check if HQ approved the resumption
 	if !payload.Approved {
 		fmt.Fprintf(os.Stderr, "HQ declined resumption: %s\n", payload.Reason)
 		// Mark the crashed session as failed
-		if r.crashedSession != nil {
-			_ = r.localDB.MarkSessionComplete(r.crashedSession.SessionID, "declined")
-			r.crashedSession = nil
+		if crashedSession != nil {
+			_ = r.localDB.MarkSessionComplete(crashedSession.SessionID, "declined")
+			r.clearCrashedSession()
 		}
 		return nil
 	}
 
-	// Verify we have the crashed session
-	if r.crashedSession == nil || r.crashedSession.SessionID != payload.SessionID {
+	if crashedSession == nil {
 		return fmt.Errorf("no matching crashed session for resumption: %s", payload.SessionID)
 	}
 
-	crashedSession := r.crashedSession
-	r.crashedSession = nil
-
-	fmt.Fprintf(os.Stderr, "Resuming session %s (objective: %s, iteration: %d)\n",
-		crashedSession.SessionID, crashedSession.ObjectiveID, crashedSession.Iteration)
-
-	// Decrypt secrets
+	// Decrypt secrets; the crashed session is kept while HQ may still re-send them
 	secrets, err := r.receiver.DecryptSecrets(payload.EncryptedSecrets)
 	if err != nil {
-		_ = r.localDB.MarkSessionComplete(crashedSession.SessionID, "decrypt_failed")
-		return fmt.Errorf("failed to decrypt secrets for resumption: %w", err)
+		return r.handleResumeDecryptFailure(crashedSession, err)
 	}
+	r.clearCrashedSession()
+
+	fmt.Fprintf(os.Stderr, "Resuming session %s (objective: %s, iteration: %d)\n",
+		crashedSession.SessionID, crashedSession.ObjectiveID, crashedSession.Iteration)
 
 	// Get the original objective from local DB
 	objective, err := r.localDB.GetObjective(crashedSession.ObjectiveID)
@@ -693,6 +1136,13 @@ func (r *workerRunner) handleResume(ctx context.Context, msg *worker.Message) er
 		"", "", // GitHub owner/repo from objective if needed
 		secrets.GitHubToken,
 	)
+	executor.SetBaseBranch(objective.BaseBranch)
+	if err := executor.SetNetworkPolicy(objective.Network, ""); err != nil {
+		cancel()
+		activityRecorder.StopSyncLoop()
+		_ = r.localDB.MarkSessionComplete(crashedSession.SessionID, "network_policy_failed")
+		return fmt.Errorf("failed to apply network policy for resumption: %w", err)
+	}
 
 	// Create Ralph loop
 	loop := worker.NewWorkerRalphLoop(
@@ -741,6 +1191,7 @@ func (r *workerRunner) handleResume(ctx context.Context, msg *worker.Message) er
 		}
 		_ = r.localDB.MarkSessionComplete(crashedSession.SessionID, "failed")
 	} else {
+		r.deliverOutputs(ctx, objective, report, secrets.GitHubToken)
 		_ = r.conn.SendCompleted(report)
 		_ = r.localDB.MarkSessionComplete(crashedSession.SessionID, "completed")
 	}
@@ -813,9 +1264,13 @@ func (r *workerRunner) handleShutdown(ctx context.Context) error {
 // clearCurrentExecution resets the current execution state.
 func (r *workerRunner) clearCurrentExecution() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	r.currentObjective = nil
 	r.currentSession = nil
 	r.currentSessionID = ""
 	r.currentCancel = nil
+	r.mu.Unlock()
+
+	if r.logStreamer != nil {
+		r.logStreamer.SetObjective("")
+	}
 }
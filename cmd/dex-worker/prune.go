@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/lirancohen/dex/internal/crypto"
+	"github.com/lirancohen/dex/internal/worker"
+)
+
+// runPrune applies a retention policy to the local database on demand and
+// prints what was removed. Only history already synced to HQ is pruned.
+func runPrune(args []string) error {
+	defaults := worker.DefaultRetentionPolicy()
+
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	dataDir := fs.String("data-dir", "", "Worker data directory (default: ~/.dex-worker)")
+	activityRetention := fs.Duration("activity-retention", defaults.ActivityMaxAge, "Prune activity already synced to HQ older than this (0 = keep)")
+	objectiveRetention := fs.Duration("objective-retention", defaults.ObjectiveMaxAge, "Prune finished objectives older than this, with their sessions and activity (0 = keep)")
+	maxFinishedObjectives := fs.Int("max-finished-objectives", defaults.MaxFinishedObjectives, "Finished objectives to keep, newest first (0 = unlimited)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dex-worker prune [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Prune synced history from the worker's local database and vacuum it.\n")
+		fmt.Fprintf(os.Stderr, "Running objectives and activity not yet synced to HQ are always kept.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *activityRetention < 0 || *objectiveRetention < 0 || *maxFinishedObjectives < 0 {
+		return fmt.Errorf("retention limits can't be negative")
+	}
+
+	if *dataDir == "" {
+		home, _ := os.UserHomeDir()
+		*dataDir = filepath.Join(home, ".dex-worker")
+	}
+
+	return pruneLocalDB(os.Stdout, *dataDir, worker.RetentionPolicy{
+		ActivityMaxAge:        *activityRetention,
+		ObjectiveMaxAge:       *objectiveRetention,
+		MaxFinishedObjectives: *maxFinishedObjectives,
+	})
+}
+
+// pruneLocalDB compacts the local database in dataDir with the policy.
+// Unlike startup, it never creates a missing key or database.
+func pruneLocalDB(w io.Writer, dataDir string, policy worker.RetentionPolicy) error {
+	dbPath := filepath.Join(dataDir, "worker.db")
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no local database at %s", dbPath)
+	}
+
+	masterKeyPath := filepath.Join(dataDir, "master.key")
+	if _, err := os.Stat(masterKeyPath); err != nil {
+		return fmt.Errorf("master key unavailable: %w", err)
+	}
+	masterKey, err := crypto.EnsureMasterKey(masterKeyPath)
+	if err != nil {
+		return fmt.Errorf("master key unavailable: %w", err)
+	}
+
+	localDB, err := worker.OpenLocalDB(dbPath, masterKey)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = localDB.Close() }()
+
+	result, err := localDB.Compact(policy)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "Pruned %d activity events and %d objectives\n", result.ActivityPruned, result.ObjectivesPruned)
+	_, _ = fmt.Fprintf(w, "Database size: %.1f MiB -> %.1f MiB\n",
+		float64(result.SizeBefore)/(1<<20), float64(result.SizeAfter)/(1<<20))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/worker"
+)
+
+func TestAwaitResume_FailsSessionWhenHQNeverAnswers(t *testing.T) {
+	localDB, err := worker.OpenLocalDB(filepath.Join(t.TempDir(), "worker.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = localDB.Close() }()
+
+	session := &worker.SessionState{SessionID: "sess-1", ObjectiveID: "obj-1", Hat: "creator", Status: "running"}
+	if err := localDB.SaveSessionState(session); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &workerRunner{localDB: localDB, crashedSession: session, resumeTimeout: 10 * time.Millisecond}
+	r.awaitResume(session)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		incomplete, err := localDB.GetIncompleteSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if incomplete == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("crashed session still running after the resume timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.crashedSession != nil {
+		t.Error("crashed session should be forgotten after the resume timeout")
+	}
+}
+
+func TestAwaitResume_AnsweredSessionIsNotFailed(t *testing.T) {
+	localDB, err := worker.OpenLocalDB(filepath.Join(t.TempDir(), "worker.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = localDB.Close() }()
+
+	session := &worker.SessionState{SessionID: "sess-1", ObjectiveID: "obj-1", Hat: "creator", Status: "running"}
+	if err := localDB.SaveSessionState(session); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &workerRunner{localDB: localDB, crashedSession: session, resumeTimeout: 10 * time.Millisecond}
+	r.awaitResume(session)
+	if r.takeCrashedSession("sess-1") == nil {
+		t.Fatal("expected the crashed session")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	incomplete, err := localDB.GetIncompleteSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incomplete == nil {
+		t.Error("session HQ answered for should not be failed by the resume timeout")
+	}
+}
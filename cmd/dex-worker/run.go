@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lirancohen/dex/internal/crypto"
+	"github.com/lirancohen/dex/internal/worker"
+)
+
+// runObjective executes one objective from a local JSON file without HQ and
+// prints its completion report to stdout. Progress is logged to stderr.
+func runObjective(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	objectiveFile := fs.String("objective-file", "", "JSON objective payload to run (required)")
+	dataDir := fs.String("data-dir", "", "Worker data directory (default: ~/.dex-worker)")
+	anthropicKey := fs.String("anthropic-key", "", "Anthropic API key (default: $ANTHROPIC_API_KEY)")
+	githubToken := fs.String("github-token", "", "GitHub token for cloning and pull requests (default: $GITHUB_TOKEN)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dex-worker run --objective-file <json> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Run one objective to completion without connecting to HQ, print its\n")
+		fmt.Fprintf(os.Stderr, "report as JSON, and exit non-zero unless it completed.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *objectiveFile == "" {
+		fs.Usage()
+		return fmt.Errorf("--objective-file is required")
+	}
+
+	objective, err := loadObjectiveFile(*objectiveFile)
+	if err != nil {
+		return err
+	}
+
+	secrets := &worker.WorkerSecrets{
+		AnthropicKey: *anthropicKey,
+		GitHubToken:  *githubToken,
+	}
+	if secrets.AnthropicKey == "" {
+		secrets.AnthropicKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if secrets.GitHubToken == "" {
+		secrets.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	}
+	if secrets.AnthropicKey == "" {
+		return fmt.Errorf("an Anthropic API key is required (--anthropic-key or $ANTHROPIC_API_KEY)")
+	}
+
+	if *dataDir == "" {
+		home, _ := os.UserHomeDir()
+		*dataDir = filepath.Join(home, ".dex-worker")
+	}
+	if err := os.MkdirAll(*dataDir, 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	report, err := runStandalone(ctx, *dataDir, objective, secrets)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	return err
+}
+
+// loadObjectiveFile reads an objective payload from path, filling in an ID
+// and the creator hat when the file leaves them out.
+func loadObjectiveFile(path string) (*worker.ObjectivePayload, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objective file: %w", err)
+	}
+
+	var objective worker.ObjectivePayload
+	if err := json.Unmarshal(data, &objective); err != nil {
+		return nil, fmt.Errorf("invalid objective file %s: %w", path, err)
+	}
+
+	if strings.TrimSpace(objective.Objective.Title) == "" {
+		return nil, fmt.Errorf("objective file %s: objective.title is required", path)
+	}
+	if objective.Objective.PreviewPlan {
+		return nil, fmt.Errorf("objective file %s: plan previews need HQ to approve them", path)
+	}
+	if err := worker.ValidateModel(objective.Objective.Model); err != nil {
+		return nil, fmt.Errorf("objective file %s: %w", path, err)
+	}
+	if objective.Objective.ID == "" {
+		objective.Objective.ID = "local-" + uuid.New().String()
+	}
+	if len(objective.Objective.ID) < 8 {
+		return nil, fmt.Errorf("objective file %s: objective.id must be at least 8 characters", path)
+	}
+	if objective.Objective.Hat == "" {
+		objective.Objective.Hat = "creator"
+	}
+	return &objective, nil
+}
+
+// runStandalone runs the objective the way a dispatch from HQ would, with
+// messages meant for HQ discarded. It returns the completion report, or one
+// describing the failure, and an error unless the objective completed.
+func runStandalone(ctx context.Context, dataDir string, objective *worker.ObjectivePayload, secrets *worker.WorkerSecrets) (*worker.CompletionReport, error) {
+	masterKey, err := crypto.EnsureMasterKey(filepath.Join(dataDir, "master.key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize master key: %w", err)
+	}
+	localDB, err := worker.OpenLocalDB(filepath.Join(dataDir, "worker.db"), masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local database: %w", err)
+	}
+	defer func() { _ = localDB.Close() }()
+
+	promptLoader := worker.NewWorkerPromptLoader()
+	if err := promptLoader.LoadAll(); err != nil {
+		return nil, fmt.Errorf("failed to load prompts: %w", err)
+	}
+
+	r := &workerRunner{
+		conn:           worker.NewConn(strings.NewReader(""), io.Discard),
+		localDB:        localDB,
+		dataDir:        dataDir,
+		promptLoader:   promptLoader,
+		promptCache:    worker.NewPromptCache(filepath.Join(dataDir, "prompts"), promptLoader),
+		projectManager: worker.NewProjectManager(dataDir),
+		startedAt:      time.Now(),
+	}
+
+	objectiveID := objective.Objective.ID
+	fmt.Fprintf(os.Stderr, "Running objective: %s\n", objective.Objective.Title)
+	fmt.Fprintf(os.Stderr, "  ID: %s\n", objectiveID)
+	fmt.Fprintf(os.Stderr, "  Hat: %s\n", objective.Objective.Hat)
+
+	if unmet := objective.Objective.Acceptance.Check(ctx, dataDir); len(unmet) > 0 {
+		return nil, fmt.Errorf("this machine doesn't meet the objective's acceptance criteria: %s", strings.Join(unmet, "; "))
+	}
+
+	loader, err := r.promptCache.Resolve(objective.PromptVersion, objective.Prompts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompts: %w", err)
+	}
+
+	if err := localDB.StoreObjective(objective); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to store objective locally: %v\n", err)
+	}
+
+	sessionID := fmt.Sprintf("sess-%s", uuid.New().String()[:8])
+	r.currentObjective = objective
+	r.currentSessionID = sessionID
+
+	run, err := r.executeObjective(ctx, objective, secrets, sessionID, loader)
+	if err != nil {
+		_ = localDB.UpdateObjectiveStatus(objectiveID, "failed")
+		return nil, err
+	}
+	r.finishObjective(objectiveID, run)
+
+	if run.status == "completed" {
+		return run.report, nil
+	}
+
+	report := &worker.CompletionReport{
+		ObjectiveID: objectiveID,
+		SessionID:   sessionID,
+		Status:      run.status,
+		Iterations:  run.iterations,
+		CompletedAt: time.Now(),
+	}
+	if run.err != nil && !errors.Is(run.err, worker.ErrCancelled) {
+		report.Errors = []string{run.err.Error()}
+	}
+	return report, fmt.Errorf("objective %s: %s", run.status, objectiveID)
+}
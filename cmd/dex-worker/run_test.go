@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadObjectiveFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	objective, err := loadObjectiveFile(write("minimal.json", `{
+		"objective": {"title": "Add a README"},
+		"project": {"clone_url": "https://github.com/example/repo.git"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(objective.Objective.ID, "local-") {
+		t.Errorf("ID = %q, want a generated local- ID", objective.Objective.ID)
+	}
+	if objective.Objective.Hat != "creator" {
+		t.Errorf("Hat = %q, want creator", objective.Objective.Hat)
+	}
+
+	for name, content := range map[string]string{
+		"no-title.json": `{"objective": {"hat": "explorer"}}`,
+		"preview.json":  `{"objective": {"title": "Plan it", "preview_plan": true}}`,
+		"short-id.json": `{"objective": {"id": "abc", "title": "Short"}}`,
+		"model.json":    `{"objective": {"title": "Cheap", "model": "haiku"}}`,
+		"invalid.json":  `{"objective":`,
+	} {
+		if _, err := loadObjectiveFile(write(name, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
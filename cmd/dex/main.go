@@ -7,16 +7,26 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/lirancohen/dex/internal/api"
+	"github.com/lirancohen/dex/internal/api/middleware"
 	"github.com/lirancohen/dex/internal/auth"
 	"github.com/lirancohen/dex/internal/crypto"
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/forgejo"
+	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/gitprovider"
 	"github.com/lirancohen/dex/internal/mesh"
+	"github.com/lirancohen/dex/internal/orchestrator"
+	"github.com/lirancohen/dex/internal/quest"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/session"
 	"github.com/lirancohen/dex/internal/toolbelt"
+	"github.com/lirancohen/dex/internal/worker"
 )
 
 // version is set at build time via ldflags
@@ -78,6 +88,53 @@ func main() {
 	toolbeltConfig := flag.String("toolbelt", "", "Path to toolbelt.yaml config file (optional)")
 	baseDir := flag.String("base-dir", "", "Base Dex directory (default: /opt/dex). Repos at {base-dir}/repos/, worktrees at {base-dir}/worktrees/")
 	showVersion := flag.Bool("version", false, "Show version and exit")
+	llmTimeout := flag.Duration("llm-request-timeout", session.DefaultRequestTimeout, "Deadline for each LLM request in a session; timed-out requests are retried")
+	signalPrefixes := flag.String("signal-prefixes", "", "Override session signal markers as name=prefix pairs (e.g. event=DEX_EVENT:,checklist_done=DEX_DONE:)")
+	stopSequences := flag.String("stop-sequences", "", "Comma-separated stop sequences sent with each session LLM request")
+	maxSessionMessages := flag.Int("max-session-messages", session.DefaultMaxMessages, "Hard cap on messages in a session's history; going over forces compaction, then drops the oldest messages (negative disables)")
+	questHistoryWindow := flag.Int("quest-history-window", quest.DefaultHistoryWindow, "Recent quest messages sent to the model as-is; older ones are replaced by a summary (negative sends the full history)")
+	hatMaxTokens := flag.String("hat-max-tokens", "", "Override per-hat output token limits as hat=tokens pairs (e.g. planner=24000,editor=2048); other hats keep their defaults")
+	budgetWarnings := flag.String("budget-warnings", "75,90", "Comma-separated percentages of a session's token or dollar budget at which it warns and asks to raise the budget (empty disables)")
+	workerModel := flag.String("worker-model", "", "Model every objective dispatched to a worker must run with, sonnet or opus, whatever its task or the worker is set to (default: each task's model)")
+	maxTaskCost := flag.Float64("max-task-cost", 0, "Dollars a task may spend across all its sessions, hats, and retries; no new session starts once it's spent (0 = no ceiling)")
+	activityLevel := flag.String("activity-level", db.ActivityLevelStandard, "Session activity recording level for projects and tasks that don't set one: standard, or debug to also record debug logs")
+	activityBroadcast := flag.String("activity-broadcast-level", db.ActivityLevelStandard, "Session activity sent to clients over WebSocket: minimal (tool calls, tool results, completions, hat transitions, and security events), standard, or debug; never more than is recorded")
+	gitRetryAttempts := flag.Int("git-retry-attempts", gitprovider.DefaultRetryAttempts, "Attempts for git pushes and PR creation that fail on network or server errors (rejected pushes aren't retried)")
+	gitRetryBackoff := flag.Duration("git-retry-backoff", gitprovider.DefaultRetryBackoff, "Delay before the first git push or PR creation retry; doubles after each attempt")
+	secretScanConfig := flag.String("secret-scan-config", "", "Path to a YAML file with extra secret patterns and an allowlist for pre-commit secret scanning (optional)")
+	handoffModel := flag.String("handoff-model", "", "Summary model that writes handoffs between hats: haiku or sonnet (optional, rule-based if empty)")
+	handoffSections := flag.String("handoff-sections", "", "Comma-separated handoff sections: done, state, next_steps, blockers, key_files, verify (optional, tailored to the next hat if empty)")
+	injectionConfig := flag.String("injection-config", "", "Path to a YAML file with prompt-injection patterns and what to do when tool output matches them: flag, quarantine, and whether to pause for approval (optional, flags built-in patterns if empty)")
+	promptSensitivity := flag.String("prompt-sensitivity", string(security.SensitivityNormal), "How aggressively untrusted content (tool output, restored messages, memories) is neutralized for projects and tasks that don't set a level: off, normal (strip invisible unicode), or strict (also fence it off as data the model must not obey)")
+	gitAuthor := flag.String("git-author", "", "Identity sessions commit as in projects that don't set their own git_author, as \"Name <email>\" (e.g. \"dex-bot <dex@myorg.com>\"; default: git's own)")
+	commitTrailer := flag.String("commit-trailer", "", "Trailer added to commits sessions make through git_commit, as \"Token: value\" (e.g. \"Assisted-by: Dex\"; optional)")
+	promptPreamble := flag.String("prompt-preamble", "", "Path to a file of guidance prepended to every hat's system prompt, such as coding standards or security policy (optional)")
+
+	// Rate limiting of public endpoints, per client IP
+	rateLimit := flag.Int("public-rate-limit", 60, "Requests per minute each IP may make to public auth, setup, and toolbelt endpoints; signed-in requests are exempt (0 disables)")
+	rateBurst := flag.Int("public-rate-burst", 20, "Requests an IP may make at once to public endpoints before --public-rate-limit applies")
+	trustedProxies := flag.String("trusted-proxies", "", "Comma-separated IPs or CIDRs of reverse proxies whose X-Forwarded-For header gives the client IP (default: none, the connection's address is used)")
+
+	// Tasks running at once against the same repo; more are queued
+	maxTasksPerRepo := flag.Int("max-tasks-per-repo", 0, "Tasks allowed to run at once against the same repo, unless a project sets its own limit; more are queued (0 = unlimited)")
+
+	// Anthropic requests across all sessions, planning, and quests; more are queued
+	anthropicMaxConcurrent := flag.Int("anthropic-max-concurrent", toolbelt.DefaultAnthropicMaxConcurrent, "Anthropic API requests allowed in flight at once across all sessions; more queue until one finishes. A streaming request holds its slot, so this also caps concurrent sessions (0 = unlimited)")
+	anthropicRPM := flag.Int("anthropic-requests-per-minute", 0, "Anthropic API requests allowed to start per minute across all sessions; more queue (0 = unlimited)")
+
+	// Background toolbelt connection tests
+	toolbeltCheckInterval := flag.Duration("toolbelt-check-interval", api.DefaultToolbeltCheckInterval, "How often to test toolbelt connections in the background, recording results for /api/v1/toolbelt/history (0 disables)")
+
+	// Worktrees of cancelled and timed-out tasks kept for debugging
+	defaultDebugRetention := git.DefaultDebugRetention()
+	debugWorktreesKeep := flag.Int("debug-worktrees-keep", defaultDebugRetention.Keep, "Most recent worktrees of cancelled and timed-out tasks to keep for debugging; older ones are pruned hourly (0 = no count limit)")
+	debugWorktreesMaxAge := flag.Duration("debug-worktrees-max-age", defaultDebugRetention.MaxAge, "Prune worktrees of cancelled and timed-out tasks that finished longer ago than this (0 = no age limit)")
+
+	// CORS flags (same-origin only unless origins are given)
+	corsOrigins := flag.String("cors-origins", "", "Comma-separated origins allowed to call the API and open WebSocket connections from a browser (e.g. https://dash.example.com), or * for any")
+	corsMethods := flag.String("cors-methods", "GET,HEAD,POST,PUT,PATCH,DELETE", "Comma-separated methods allowed on cross-origin requests")
+	corsHeaders := flag.String("cors-headers", "Authorization,Content-Type", "Comma-separated request headers allowed on cross-origin requests")
+	corsCredentials := flag.Bool("cors-credentials", false, "Allow cross-origin requests to send credentials (not allowed with --cors-origins=*)")
 
 	// Mesh networking flags
 	meshEnabled := flag.Bool("mesh", false, "Enable mesh networking")
@@ -92,6 +149,7 @@ func main() {
 	forgejoBinary := flag.String("forgejo-binary", "", "Path to Forgejo binary (default: auto-download)")
 	forgejoPort := flag.Int("forgejo-port", 3000, "HTTP port for Forgejo")
 	forgejoUser := flag.String("forgejo-user", "", "User to run Forgejo as when dex runs as root (default: nobody)")
+	forgejoBotUser := flag.String("forgejo-bot-user", forgejo.BotUsername, "Forgejo account Dex uses for repos, issues, and PRs (created if missing)")
 
 	flag.Parse()
 
@@ -103,6 +161,168 @@ func main() {
 	fmt.Println("Poindexter (dex) - AI Orchestration System")
 	fmt.Printf("Version: %s\n", version)
 
+	signals, err := session.ParseSignalPrefixes(*signalPrefixes)
+	if err == nil && *stopSequences != "" {
+		signals.StopSequences = strings.Split(*stopSequences, ",")
+		err = signals.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in signal configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := db.ValidateActivityLevel(*activityLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := security.ParseSensitivity(*promptSensitivity); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := db.ValidateBroadcastLevel(*activityBroadcast); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *rateLimit < 0 || *rateBurst < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --public-rate-limit and --public-rate-burst can't be negative\n")
+		os.Exit(1)
+	}
+	proxies, err := middleware.ParseTrustedProxies(*trustedProxies)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --trusted-proxies: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *maxTasksPerRepo < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --max-tasks-per-repo can't be negative\n")
+		os.Exit(1)
+	}
+
+	if *anthropicMaxConcurrent < 0 || *anthropicRPM < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --anthropic-max-concurrent and --anthropic-requests-per-minute can't be negative\n")
+		os.Exit(1)
+	}
+	if *anthropicMaxConcurrent > 0 && *anthropicMaxConcurrent < orchestrator.DefaultMaxParallel {
+		fmt.Fprintf(os.Stderr, "Warning: --anthropic-max-concurrent=%d is below the scheduler's %d parallel sessions; streaming requests hold their slot, so at most %d sessions will make progress at once\n",
+			*anthropicMaxConcurrent, orchestrator.DefaultMaxParallel, *anthropicMaxConcurrent)
+	}
+	toolbelt.SetAnthropicRequestLimits(*anthropicMaxConcurrent, *anthropicRPM)
+
+	if *toolbeltCheckInterval < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --toolbelt-check-interval can't be negative\n")
+		os.Exit(1)
+	}
+
+	if *debugWorktreesKeep < 0 || *debugWorktreesMaxAge < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --debug-worktrees-keep and --debug-worktrees-max-age can't be negative\n")
+		os.Exit(1)
+	}
+
+	if *gitRetryAttempts < 1 {
+		fmt.Fprintf(os.Stderr, "Error: --git-retry-attempts must be at least 1\n")
+		os.Exit(1)
+	}
+
+	if *maxSessionMessages >= 0 && *maxSessionMessages < session.MinMaxMessages {
+		fmt.Fprintf(os.Stderr, "Error: --max-session-messages must be at least %d (or negative to disable)\n", session.MinMaxMessages)
+		os.Exit(1)
+	}
+
+	if *questHistoryWindow == 0 {
+		fmt.Fprintf(os.Stderr, "Error: --quest-history-window must not be 0 (negative sends the full history)\n")
+		os.Exit(1)
+	}
+
+	if *maxTaskCost < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --max-task-cost must not be negative\n")
+		os.Exit(1)
+	}
+
+	budgetWarns, err := session.ParseBudgetWarnings(*budgetWarnings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --budget-warnings: %v\n", err)
+		os.Exit(1)
+	}
+
+	hatTokens, err := session.ParseHatMaxTokens(*hatMaxTokens)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --hat-max-tokens: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := worker.ValidateModel(*workerModel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --worker-model: %v\n", err)
+		os.Exit(1)
+	}
+
+	var handoff session.HandoffOptions
+	if handoff.Model, err = session.ResolveHandoffModel(*handoffModel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --handoff-model: %v\n", err)
+		os.Exit(1)
+	}
+	if handoff.Sections, err = session.ParseHandoffSections(*handoffSections); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --handoff-sections: %v\n", err)
+		os.Exit(1)
+	}
+
+	cors := api.CORSConfig{
+		AllowOrigins:     splitFlagList(*corsOrigins),
+		AllowMethods:     splitFlagList(*corsMethods),
+		AllowHeaders:     splitFlagList(*corsHeaders),
+		AllowCredentials: *corsCredentials,
+	}
+	if cors.AllowCredentials && slices.Contains(cors.AllowOrigins, "*") {
+		fmt.Fprintf(os.Stderr, "Error: --cors-credentials can't be used with --cors-origins=*; list the origins instead\n")
+		os.Exit(1)
+	}
+
+	var secretScanner *security.SecretScanner
+	if *secretScanConfig != "" {
+		secretScanner, err = security.LoadSecretScanner(*secretScanConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading secret scan config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var injectionDetector *security.InjectionDetector
+	if *injectionConfig != "" {
+		injectionDetector, err = security.LoadInjectionDetector(*injectionConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading injection config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var preamble string
+	if *promptPreamble != "" {
+		data, err := os.ReadFile(*promptPreamble)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading prompt preamble: %v\n", err)
+			os.Exit(1)
+		}
+		preamble = session.NormalizePreamble(string(data))
+		if err := session.ValidatePreamble(preamble); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --prompt-preamble: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	defaultGitAuthor, err := db.ParseGitAuthor(*gitAuthor)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --git-author: %v\n", err)
+		os.Exit(1)
+	}
+	if *commitTrailer != "" {
+		if err := git.ValidateTrailer(*commitTrailer); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --commit-trailer: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Initialize database
 	fmt.Printf("Opening database: %s\n", *dbPath)
 	database, err := db.Open(*dbPath)
@@ -205,8 +425,16 @@ func main() {
 		tb, err = toolbelt.NewFromFile(*toolbeltConfig)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to load toolbelt config: %v\n", err)
-			// Continue without toolbelt - it's optional
-		} else {
+			// Fall back to the environment if it has credentials; otherwise
+			// continue without toolbelt - it's optional
+			if toolbelt.HasEnvConfig() {
+				fmt.Printf("Loading toolbelt from environment (%s, %s)\n", toolbelt.EnvAnthropicAPIKey, toolbelt.EnvGitHubToken)
+				if tb, err = toolbelt.NewFromEnv(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Failed to create toolbelt: %v\n", err)
+				}
+			}
+		}
+		if tb != nil {
 			status := tb.Status()
 			configured := 0
 			for _, s := range status {
@@ -217,20 +445,21 @@ func main() {
 			fmt.Printf("Toolbelt loaded: %d/%d services configured\n", configured, len(status))
 		}
 	} else {
-		// Try loading from encrypted database (primary storage after onboarding)
+		// Load from the environment (container and CI deployments) and the
+		// encrypted database (primary storage after onboarding), the environment first
 		secrets, err := secretsStore.GetAllSecrets()
-		if err == nil && len(secrets) > 0 {
-			fmt.Printf("Loading toolbelt from database (%d secrets)\n", len(secrets))
-			config := &toolbelt.Config{}
-			if token := secrets[db.SecretKeyGitHubToken]; token != "" {
-				config.GitHub = &toolbelt.GitHubConfig{Token: token}
-			}
-			if key := secrets[db.SecretKeyAnthropicKey]; key != "" {
-				config.Anthropic = &toolbelt.AnthropicConfig{APIKey: key}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load database secrets: %v\n", err)
+		}
+		if config := toolbelt.ResolveConfig(secrets[db.SecretKeyAnthropicKey], secrets[db.SecretKeyGitHubToken]); config.Anthropic != nil || config.GitHub != nil {
+			if toolbelt.HasEnvConfig() {
+				fmt.Printf("Loading toolbelt from environment (%s, %s) and database\n", toolbelt.EnvAnthropicAPIKey, toolbelt.EnvGitHubToken)
+			} else {
+				fmt.Printf("Loading toolbelt from database (%d secrets)\n", len(secrets))
 			}
 			tb, err = toolbelt.New(config)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to create toolbelt from database secrets: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Warning: Failed to create toolbelt: %v\n", err)
 			}
 		}
 
@@ -244,7 +473,7 @@ func main() {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to load secrets file: %v\n", err)
 				}
 			} else {
-				fmt.Println("No secrets configured yet (database empty, no secrets.json)")
+				fmt.Println("No secrets configured yet (no environment variables, database empty, no secrets.json)")
 			}
 		}
 
@@ -371,6 +600,7 @@ func main() {
 		cfg := forgejo.DefaultConfig(dataDir)
 		cfg.HTTPPort = *forgejoPort
 		cfg.RunUser = *forgejoUser
+		cfg.BotUsername = *forgejoBotUser
 		if *forgejoBinary != "" {
 			cfg.BinaryPath = *forgejoBinary
 		}
@@ -449,10 +679,35 @@ func main() {
 		Mesh:        meshConfig,
 		Encryption:  encConfig,
 		Forgejo:     forgejoConfig,
+		LLMTimeout:  *llmTimeout,
+		Signals:     &signals,
+		Secrets:     secretScanner,
+		Injection:   injectionDetector,
+		Handoff:     handoff,
+		Preamble:    preamble,
+		GitAuthor:   defaultGitAuthor,
+		Trailer:     *commitTrailer,
+		Activity:    *activityLevel,
+		Broadcast:   *activityBroadcast,
+		Sensitivity: *promptSensitivity,
+		MaxMessages: *maxSessionMessages,
+		QuestWindow: *questHistoryWindow,
+		BudgetWarns: budgetWarns,
+		HatTokens:   hatTokens,
+		MaxTaskCost: *maxTaskCost,
+		WorkerModel: *workerModel,
 		PublicURL:   publicURL,
 		Namespace:   namespace,
 		TunnelToken: tunnelToken,
 		CentralURL:  centralURL,
+		Version:     version,
+		CORS:        cors,
+		RateLimit:   middleware.RateLimitConfig{PerMinute: *rateLimit, Burst: *rateBurst, TrustedProxies: proxies},
+		GitRetry:    &gitprovider.RetryPolicy{Attempts: *gitRetryAttempts, Backoff: *gitRetryBackoff},
+		RepoLimit:   *maxTasksPerRepo,
+
+		ToolbeltCheckInterval: *toolbeltCheckInterval,
+		DebugRetention:        &git.DebugRetention{Keep: *debugWorktreesKeep, MaxAge: *debugWorktreesMaxAge},
 	})
 
 	// Start server in goroutine
@@ -484,3 +739,14 @@ func main() {
 
 	fmt.Println("Server stopped gracefully")
 }
+
+// splitFlagList splits a comma-separated flag value, dropping empty entries
+func splitFlagList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -159,9 +159,10 @@ export function useWebSocket(): UseWebSocketReturn {
     setConnectionState('reconnecting');
     setSubscribedChannels(new Set());
 
-    // Build WebSocket URL for Centrifuge
+    // Build WebSocket URL for Centrifuge; the upgrade is authenticated by the
+    // token query param, since browsers can't set headers on WebSocket requests
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const wsUrl = `${protocol}//${window.location.host}/api/v1/realtime`;
+    const wsUrl = `${protocol}//${window.location.host}/api/v1/realtime?token=${encodeURIComponent(token)}`;
 
     try {
       // Create Centrifuge client with auth token
@@ -3,6 +3,7 @@ package core
 
 import (
 	"context"
+	"errors"
 
 	"github.com/lirancohen/dex/internal/auth"
 	"github.com/lirancohen/dex/internal/db"
@@ -18,6 +19,10 @@ import (
 	"github.com/lirancohen/dex/internal/worker"
 )
 
+// ErrWorktreeReuse is returned when a task set to reuse a predecessor's
+// worktree can't start because that worktree is unavailable
+var ErrWorktreeReuse = errors.New("worktree to reuse is unavailable")
+
 // StartTaskResult contains the result of starting a task
 type StartTaskResult struct {
 	Task         *db.Task
@@ -54,6 +59,8 @@ type Deps struct {
 	StartTaskWithInheritance   func(ctx context.Context, taskID string, inheritedWorktree string, predecessorHandoff string) (*StartTaskResult, error)
 	HandleTaskUnblocking       func(ctx context.Context, completedTaskID string)
 	GeneratePredecessorHandoff func(task *db.Task) string
+	AutoCompleteQuest          func(questID string)       // Completes the quest if it auto-completes and its tasks are done
+	ResolveStartingHat         func(task *db.Task) string // Hat the task's session starts in if started now
 
 	// Validation helpers
 	IsValidGitRepo     func(path string) bool
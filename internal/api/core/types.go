@@ -20,6 +20,7 @@ type TaskResponse struct {
 	ParentID          *string  `json:"ParentID"`
 	Type              string   `json:"Type"`
 	Hat               *string  `json:"Hat"`
+	Model             *string  `json:"Model"`
 	Priority          int      `json:"Priority"`
 	AutonomyLevel     int      `json:"AutonomyLevel"`
 	Status            string   `json:"Status"`
@@ -41,6 +42,24 @@ type TaskResponse struct {
 	// Derived blocking info - computed from dependencies
 	IsBlocked bool     `json:"IsBlocked"`
 	BlockedBy []string `json:"BlockedBy,omitempty"`
+	// Task-level completion policy override (nil inherits from the project)
+	CompletionPolicy *db.CompletionPolicy `json:"CompletionPolicy,omitempty"`
+	// Task-level activity level override (empty inherits from the project)
+	ActivityLevel string `json:"ActivityLevel,omitempty"`
+	// Task-level prompt sensitivity override (empty inherits from the project)
+	PromptSensitivity string `json:"PromptSensitivity,omitempty"`
+	// Task-level extended thinking budget (nil uses each hat's default, 0 is off)
+	ThinkingBudget *int `json:"ThinkingBudget,omitempty"`
+	// Task-level model fallback chain, or "off" (empty inherits from the project)
+	ModelFallback string `json:"ModelFallback,omitempty"`
+	// Free-form labels for organizing tasks across projects and quests
+	Tags []string `json:"Tags,omitempty"`
+	// Why the task's session reported being blocked (nil if it isn't)
+	BlockedReason *db.BlockedReason `json:"BlockedReason,omitempty"`
+	// ID of the task this one was cloned from
+	ClonedFrom string `json:"ClonedFrom,omitempty"`
+	// ID of the task whose worktree and branch this one continues in
+	ReuseWorktreeFrom string `json:"ReuseWorktreeFrom,omitempty"`
 }
 
 // ToTaskResponse converts a db.Task to TaskResponse for clean JSON.
@@ -77,6 +96,9 @@ func ToTaskResponse(t *db.Task) TaskResponse {
 	if t.Hat.Valid {
 		resp.Hat = &t.Hat.String
 	}
+	if t.Model.Valid {
+		resp.Model = &t.Model.String
+	}
 	if t.WorktreePath.Valid {
 		resp.WorktreePath = &t.WorktreePath.String
 	}
@@ -179,6 +201,56 @@ type SessionResponse struct {
 	DollarsBudget  *float64 `json:"dollars_budget,omitempty"`
 	StartedAt      string   `json:"started_at,omitempty"`
 	LastActivity   string   `json:"last_activity,omitempty"`
+	EndedAt        string   `json:"ended_at,omitempty"`
+	// Where the session's requests queue behind the shared request gate (active sessions only)
+	Priority string `json:"priority,omitempty"`
+	// Why the session ended, empty while it's running
+	TerminationReason      string `json:"termination_reason,omitempty"`
+	TerminationExplanation string `json:"termination_explanation,omitempty"`
+	TerminationCategory    string `json:"termination_category,omitempty"`
+}
+
+// setTermination fills in why the session ended from its termination reason
+func (r *SessionResponse) setTermination(reason string) {
+	if reason == "" {
+		return
+	}
+	t := session.TerminationReason(reason)
+	r.TerminationReason = reason
+	r.TerminationExplanation = t.String()
+	r.TerminationCategory = string(t.Category())
+}
+
+// ToSessionHistoryResponse converts a stored session to SessionResponse, with
+// its token counts from activity.
+func ToSessionHistoryResponse(s *db.Session, inputTokens, outputTokens int64) SessionResponse {
+	resp := SessionResponse{
+		ID:             s.ID,
+		TaskID:         s.TaskID,
+		Hat:            s.Hat,
+		State:          s.Status,
+		WorktreePath:   s.WorktreePath,
+		IterationCount: s.IterationCount,
+		MaxIterations:  s.MaxIterations,
+		InputTokens:    inputTokens,
+		OutputTokens:   outputTokens,
+		TokensUsed:     inputTokens + outputTokens,
+		DollarsUsed:    (float64(inputTokens)*s.InputRate + float64(outputTokens)*s.OutputRate) / 1_000_000,
+	}
+	if s.TokensBudget.Valid {
+		resp.TokensBudget = &s.TokensBudget.Int64
+	}
+	if s.DollarsBudget.Valid {
+		resp.DollarsBudget = &s.DollarsBudget.Float64
+	}
+	if s.StartedAt.Valid {
+		resp.StartedAt = s.StartedAt.Time.Format(time.RFC3339)
+	}
+	if s.EndedAt.Valid {
+		resp.EndedAt = s.EndedAt.Time.Format(time.RFC3339)
+	}
+	resp.setTermination(s.TerminationReason.String)
+	return resp
 }
 
 // ToSessionResponse converts an ActiveSession to SessionResponse for clean JSON.
@@ -197,6 +269,7 @@ func ToSessionResponse(s *session.ActiveSession) SessionResponse {
 		TokensBudget:   s.TokensBudget,
 		DollarsUsed:    s.Cost(),
 		DollarsBudget:  s.DollarsBudget,
+		Priority:       s.Priority,
 	}
 	if !s.StartedAt.IsZero() {
 		resp.StartedAt = s.StartedAt.Format(time.RFC3339)
@@ -204,6 +277,7 @@ func ToSessionResponse(s *session.ActiveSession) SessionResponse {
 	if !s.LastActivity.IsZero() {
 		resp.LastActivity = s.LastActivity.Format(time.RFC3339)
 	}
+	resp.setTermination(s.TerminationReason)
 	return resp
 }
 
@@ -293,6 +367,36 @@ type ProjectResponse struct {
 	RemoteUpstream *string `json:"RemoteUpstream"`
 	DefaultBranch  string  `json:"DefaultBranch"`
 	CreatedAt      string  `json:"CreatedAt"`
+	// Project-wide completion policy (nil means the strict default)
+	CompletionPolicy *db.CompletionPolicy `json:"CompletionPolicy,omitempty"`
+	// Project-wide activity level (empty means the server default)
+	ActivityLevel string `json:"ActivityLevel,omitempty"`
+	// Project-wide prompt sensitivity (empty means the server default)
+	PromptSensitivity string `json:"PromptSensitivity,omitempty"`
+	// Limits on budget top-ups when resuming paused tasks (nil means uncapped)
+	BudgetCap *db.BudgetCap `json:"BudgetCap,omitempty"`
+	// Model new quests start on when none is picked (empty means sonnet)
+	DefaultQuestModel string `json:"DefaultQuestModel,omitempty"`
+	// Model new tasks use unless they set one (empty means sonnet)
+	DefaultModel string `json:"DefaultModel,omitempty"`
+	// Autonomy level new tasks use unless they set one (nil means level 1)
+	DefaultAutonomy *int `json:"DefaultAutonomy,omitempty"`
+	// Tasks allowed to run at once against the repo (0 means the server default)
+	MaxConcurrentTasks int `json:"MaxConcurrentTasks,omitempty"`
+	// Read-only tool results each session may cache (0 means caching is off)
+	ToolCacheSize int `json:"ToolCacheSize,omitempty"`
+	// Review approvals are rejected until the session has run the tests
+	CriticRequiresTests bool `json:"CriticRequiresTests,omitempty"`
+	// Guidance prepended to every hat's system prompt, after the server's
+	PromptPreamble string `json:"PromptPreamble,omitempty"`
+	// Which hat a failed task restarts at instead of pausing (nil means it pauses)
+	PipelineRetry *db.PipelineRetryPolicy `json:"PipelineRetry,omitempty"`
+	// Identity sessions commit as (nil means git's default)
+	GitAuthor *db.GitAuthor `json:"GitAuthor,omitempty"`
+	// Hat new task sessions start in, or "auto" to pick one per task
+	StartingHat string `json:"StartingHat,omitempty"`
+	// Models tasks fall back to when theirs is over capacity (empty means no fallback)
+	ModelFallback string `json:"ModelFallback,omitempty"`
 }
 
 // ToProjectResponse converts a db.Project to ProjectResponse for clean JSON.
@@ -337,15 +441,18 @@ func ToProjectResponses(projects []*db.Project) []ProjectResponse {
 
 // QuestResponse is the JSON response format for quests.
 type QuestResponse struct {
-	ID               string                `json:"id"`
-	ProjectID        string                `json:"project_id"`
-	Title            string                `json:"title,omitempty"`
-	Status           string                `json:"status"`
-	Model            string                `json:"model"`
-	AutoStartDefault bool                  `json:"auto_start_default"`
-	CreatedAt        time.Time             `json:"created_at"`
-	CompletedAt      *time.Time            `json:"completed_at,omitempty"`
-	Summary          *QuestSummaryResponse `json:"summary,omitempty"`
+	ID                      string                `json:"id"`
+	ProjectID               string                `json:"project_id"`
+	Title                   string                `json:"title,omitempty"`
+	Status                  string                `json:"status"`
+	Model                   string                `json:"model"`
+	AutoStartDefault        bool                  `json:"auto_start_default"`
+	AutoComplete            bool                  `json:"auto_complete_on_tasks_done"`
+	MaxConcurrentObjectives int                   `json:"max_concurrent_objectives,omitempty"`
+	ObjectiveStartMode      string                `json:"objective_start_mode"`
+	CreatedAt               time.Time             `json:"created_at"`
+	CompletedAt             *time.Time            `json:"completed_at,omitempty"`
+	Summary                 *QuestSummaryResponse `json:"summary,omitempty"`
 }
 
 // QuestSummaryResponse is the summary of a quest's task progress.
@@ -357,6 +464,8 @@ type QuestSummaryResponse struct {
 	BlockedTasks     int     `json:"blocked_tasks"`
 	PendingTasks     int     `json:"pending_tasks"`
 	TotalDollarsUsed float64 `json:"total_dollars_used"`
+
+	ConversationDollarsUsed float64 `json:"conversation_dollars_used"` // Spent on the quest conversation itself
 }
 
 // QuestToolCallResponse is the JSON response format for quest tool calls.
@@ -376,18 +485,29 @@ type QuestMessageResponse struct {
 	Content   string                  `json:"content"`
 	ToolCalls []QuestToolCallResponse `json:"tool_calls,omitempty"`
 	CreatedAt time.Time               `json:"created_at"`
+
+	// Model usage behind an assistant message
+	InputTokens  int64   `json:"input_tokens,omitempty"`
+	OutputTokens int64   `json:"output_tokens,omitempty"`
+	DollarsUsed  float64 `json:"dollars_used,omitempty"`
 }
 
 // ToQuestResponse converts a db.Quest and optional summary to QuestResponse.
 func ToQuestResponse(q *db.Quest, summary *db.QuestSummary) QuestResponse {
 	resp := QuestResponse{
-		ID:               q.ID,
-		ProjectID:        q.ProjectID,
-		Title:            q.GetTitle(),
-		Status:           q.Status,
-		Model:            q.Model,
-		AutoStartDefault: q.AutoStartDefault,
-		CreatedAt:        q.CreatedAt,
+		ID:                      q.ID,
+		ProjectID:               q.ProjectID,
+		Title:                   q.GetTitle(),
+		Status:                  q.Status,
+		Model:                   q.Model,
+		AutoStartDefault:        q.AutoStartDefault,
+		AutoComplete:            q.AutoComplete,
+		MaxConcurrentObjectives: q.MaxConcurrentObjectives,
+		ObjectiveStartMode:      q.ObjectiveStartMode,
+		CreatedAt:               q.CreatedAt,
+	}
+	if resp.ObjectiveStartMode == "" {
+		resp.ObjectiveStartMode = db.ObjectiveStartModeDependencyOrder
 	}
 	if q.CompletedAt.Valid {
 		resp.CompletedAt = &q.CompletedAt.Time
@@ -401,6 +521,8 @@ func ToQuestResponse(q *db.Quest, summary *db.QuestSummary) QuestResponse {
 			BlockedTasks:     summary.BlockedTasks,
 			PendingTasks:     summary.PendingTasks,
 			TotalDollarsUsed: summary.TotalDollarsUsed,
+
+			ConversationDollarsUsed: summary.ConversationDollarsUsed,
 		}
 	}
 	return resp
@@ -414,6 +536,10 @@ func ToQuestMessageResponse(m *db.QuestMessage) QuestMessageResponse {
 		Role:      m.Role,
 		Content:   m.Content,
 		CreatedAt: m.CreatedAt,
+
+		InputTokens:  m.InputTokens,
+		OutputTokens: m.OutputTokens,
+		DollarsUsed:  m.DollarsUsed,
 	}
 
 	if len(m.ToolCalls) > 0 {
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+)
+
+// CORSConfig lets browsers on other origins call the API, for dashboards and
+// tools hosted apart from the bundled frontend. With no AllowOrigins the API
+// stays same-origin only.
+type CORSConfig struct {
+	AllowOrigins     []string // Origins allowed to call the API ("*" allows any)
+	AllowMethods     []string // Methods allowed on cross-origin requests (echo's defaults if empty)
+	AllowHeaders     []string // Request headers allowed on cross-origin requests (any requested if empty)
+	AllowCredentials bool     // Whether cross-origin requests may send cookies and auth headers
+}
+
+// Enabled reports whether any cross-origin access is configured
+func (c CORSConfig) Enabled() bool {
+	return len(c.AllowOrigins) > 0
+}
+
+// corsMiddleware returns CORS middleware for cfg. WebSocket upgrades are left
+// to the realtime node's own origin check, since CORS doesn't apply to them.
+func corsMiddleware(cfg CORSConfig) echo.MiddlewareFunc {
+	return echomw.CORSWithConfig(echomw.CORSConfig{
+		Skipper: func(c echo.Context) bool {
+			return strings.EqualFold(c.Request().Header.Get(echo.HeaderUpgrade), "websocket")
+		},
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+	})
+}
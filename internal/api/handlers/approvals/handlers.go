@@ -2,12 +2,17 @@
 package approvals
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/api/handlers/workers"
+	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/session"
 )
 
 // Handler handles approval-related HTTP requests.
@@ -125,6 +130,34 @@ func (h *Handler) HandleApprove(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	// Approving a held completion finishes the paused task
+	if approval.Type == db.ApprovalTypeTaskCompletion && approval.TaskID.Valid && h.deps.SessionManager != nil {
+		if err := h.deps.SessionManager.CompleteApprovedTask(approval.TaskID.String); err != nil {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("approval recorded but task could not be completed: %v", err))
+		}
+	}
+
+	// Approving a worker's plan dispatches the objective to run it
+	if approval.Type == db.ApprovalTypeObjectivePlan && approval.TaskID.Valid {
+		if err := workers.DispatchApprovedPlan(c.Request().Context(), h.deps, approval); err != nil {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("approval recorded but objective could not be dispatched: %v", err))
+		}
+	}
+
+	// A running session raises its own budget; one already paused by it resumes with the increase
+	if approval.Type == db.ApprovalTypeBudgetIncrease && approval.TaskID.Valid && h.deps.SessionManager != nil {
+		if err := h.resumeWithBudgetIncrease(approval); err != nil {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("approval recorded but task could not be resumed: %v", err))
+		}
+	}
+
+	// Approving a suspected prompt injection resumes the session it paused
+	if approval.Type == db.ApprovalTypePromptInjection && approval.TaskID.Valid && h.deps.SessionManager != nil {
+		if err := h.resumeAfterInjectionReview(approval); err != nil {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("approval recorded but task could not be resumed: %v", err))
+		}
+	}
+
 	// Broadcast WebSocket event with routing info
 	if h.deps.Broadcaster != nil {
 		payload := map[string]any{
@@ -152,6 +185,69 @@ func (h *Handler) HandleApprove(c echo.Context) error {
 	})
 }
 
+// resumeWithBudgetIncrease resumes the approval's task with the budget
+// increase it offered if the task is paused because a budget ran out.
+// Tasks paused for any other reason stay paused.
+func (h *Handler) resumeWithBudgetIncrease(approval *db.Approval) error {
+	task, err := h.deps.DB.GetTaskByID(approval.TaskID.String)
+	if err != nil {
+		return err
+	}
+	if task == nil || task.Status != db.TaskStatusPaused {
+		return nil
+	}
+	sessions, err := h.deps.DB.ListSessionsByTask(task.ID)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+	switch sessions[0].TerminationReason.String { // Most recent first
+	case string(session.TerminationMaxTokens), string(session.TerminationMaxCost):
+	default:
+		return nil
+	}
+
+	var data session.BudgetIncreaseData
+	if err := json.Unmarshal(approval.Data, &data); err != nil {
+		return fmt.Errorf("invalid budget increase: %w", err)
+	}
+	unlock, err := h.deps.TaskService.LockLifecycle(task.ID, "resume")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = h.deps.SessionManager.Resume(task.ID, data.Extension())
+	return err
+}
+
+// resumeAfterInjectionReview resumes the approval's task if it is still
+// paused by the review. Rejected reviews leave the task paused.
+func (h *Handler) resumeAfterInjectionReview(approval *db.Approval) error {
+	task, err := h.deps.DB.GetTaskByID(approval.TaskID.String)
+	if err != nil {
+		return err
+	}
+	if task == nil || task.Status != db.TaskStatusPaused {
+		return nil
+	}
+	sessions, err := h.deps.DB.ListSessionsByTask(task.ID)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 || sessions[0].ID != approval.SessionID.String { // Most recent first
+		return nil
+	}
+	unlock, err := h.deps.TaskService.LockLifecycle(task.ID, "resume")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = h.deps.SessionManager.Resume(task.ID, session.BudgetExtension{})
+	return err
+}
+
 // HandleReject marks an approval as rejected.
 // POST /api/v1/approvals/:id/reject
 func (h *Handler) HandleReject(c echo.Context) error {
@@ -176,6 +272,13 @@ func (h *Handler) HandleReject(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	// Rejecting a worker's plan drops the objective without running it
+	if approval.Type == db.ApprovalTypeObjectivePlan && approval.TaskID.Valid {
+		if err := workers.RejectPlan(h.deps, approval); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("approval recorded but task could not be cancelled: %v", err))
+		}
+	}
+
 	// Broadcast WebSocket event with routing info
 	if h.deps.Broadcaster != nil {
 		payload := map[string]any{
@@ -2,12 +2,19 @@
 package forgejo
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/forgejo"
 )
 
+// defaultLogTail is how many recent log lines GetLogs returns by default
+const defaultLogTail = 200
+
 // Handler handles Forgejo-related HTTP requests.
 type Handler struct {
 	deps *core.Deps
@@ -23,6 +30,12 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 	fg := g.Group("/forgejo")
 	fg.GET("/access", h.GetAccess)
 	fg.GET("/status", h.GetStatus)
+
+	// Diagnostics for the embedded process
+	sys := g.Group("/system/forgejo")
+	sys.GET("/status", h.GetProcessStatus)
+	sys.GET("/logs", h.GetLogs)
+	sys.POST("/restart", h.Restart)
 }
 
 // GetAccess returns the Forgejo web UI URL and login credentials.
@@ -71,3 +84,99 @@ func (h *Handler) GetStatus(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, resp)
 }
+
+// GetProcessStatus reports whether the Forgejo process is running, its port,
+// and whether it responds to HTTP.
+// GET /api/v1/system/forgejo/status
+func (h *Handler) GetProcessStatus(c echo.Context) error {
+	mgr := h.deps.ForgejoManager
+	if mgr == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Forgejo is not configured",
+		})
+	}
+
+	return c.JSON(http.StatusOK, mgr.Status(c.Request().Context()))
+}
+
+// GetLogs returns recent Forgejo process output as plain text, one
+// timestamped line each. With follow=true it keeps streaming new lines until
+// the client disconnects.
+// Query params: tail (default 200), follow.
+// GET /api/v1/system/forgejo/logs
+func (h *Handler) GetLogs(c echo.Context) error {
+	mgr := h.deps.ForgejoManager
+	if mgr == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Forgejo is not configured",
+		})
+	}
+
+	tail := defaultLogTail
+	if v := c.QueryParam("tail"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "tail must be a non-negative number",
+			})
+		}
+		tail = n
+	}
+	follow, _ := strconv.ParseBool(c.QueryParam("follow"))
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	resp.Header().Set("Cache-Control", "no-cache")
+
+	if !follow {
+		resp.WriteHeader(http.StatusOK)
+		for _, line := range mgr.Logs().Tail(tail) {
+			writeLogLine(resp, line)
+		}
+		return nil
+	}
+
+	recent, lines, cancel := mgr.Logs().Follow(tail)
+	defer cancel()
+
+	resp.WriteHeader(http.StatusOK)
+	for _, line := range recent {
+		writeLogLine(resp, line)
+	}
+	resp.Flush()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line := <-lines:
+			writeLogLine(resp, line)
+			resp.Flush()
+		}
+	}
+}
+
+// writeLogLine writes a log line prefixed with its time
+func writeLogLine(resp *echo.Response, line forgejo.LogLine) {
+	_, _ = fmt.Fprintf(resp, "%s %s\n", line.Time.UTC().Format(time.RFC3339), line.Text)
+}
+
+// Restart stops Forgejo and starts it again, waiting until it is healthy.
+// POST /api/v1/system/forgejo/restart
+func (h *Handler) Restart(c echo.Context) error {
+	mgr := h.deps.ForgejoManager
+	if mgr == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Forgejo is not configured",
+		})
+	}
+
+	if err := mgr.Restart(c.Request().Context()); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": fmt.Sprintf("failed to restart Forgejo: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mgr.Status(c.Request().Context()))
+}
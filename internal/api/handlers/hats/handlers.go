@@ -0,0 +1,87 @@
+// Package hats provides HTTP handlers for inspecting and validating hat policies
+// and managing the preamble shared by every hat's prompt.
+package hats
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/session"
+)
+
+// Handler handles hat policy HTTP requests.
+type Handler struct {
+	deps *core.Deps
+}
+
+// New creates a new hats handler.
+func New(deps *core.Deps) *Handler {
+	return &Handler{deps: deps}
+}
+
+// RegisterRoutes registers the hat policy and prompt preamble routes on the
+// given group.
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("/admin/hat-policy/validate", h.HandleValidate)
+	g.GET("/projects/:id/hat-policy", h.HandleGetProjectPolicy)
+	g.GET("/admin/preamble", h.HandleGetPreamble)
+	g.PUT("/admin/preamble", h.HandleUpdatePreamble)
+}
+
+// HandleValidate checks a proposed hat policy without applying it.
+// POST /api/v1/admin/hat-policy/validate
+func (h *Handler) HandleValidate(c echo.Context) error {
+	var policy session.HatPolicy
+	if err := c.Bind(&policy); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid policy: "+err.Error())
+	}
+
+	events, err := h.promptEvents()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, session.ValidateHatPolicy(&policy, events))
+}
+
+// HandleGetProjectPolicy returns the hat policy sessions in a project run.
+// Policies aren't configurable per project yet, so this is always the default.
+// GET /api/v1/projects/:id/hat-policy
+func (h *Handler) HandleGetProjectPolicy(c echo.Context) error {
+	id := c.Param("id")
+	project, err := h.deps.DB.GetProjectByID(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if project == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "project not found")
+	}
+
+	// Show the output token limits sessions actually get, including --hat-max-tokens
+	policy := session.DefaultHatPolicy()
+	if h.deps.SessionManager != nil {
+		for i := range policy.Hats {
+			policy.Hats[i].MaxTokens = h.deps.SessionManager.HatMaxTokens(policy.Hats[i].Name)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"project_id": project.ID,
+		"source":     "default",
+		"policy":     policy,
+	})
+}
+
+// promptEvents returns the events the loaded hat prompts reference, or nil
+// if prompts aren't available
+func (h *Handler) promptEvents() (map[string][]string, error) {
+	if h.deps.SessionManager == nil {
+		return nil, nil
+	}
+	loader := h.deps.SessionManager.GetPromptLoader()
+	if loader == nil {
+		return nil, nil
+	}
+	return loader.ReferencedEvents()
+}
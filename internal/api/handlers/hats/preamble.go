@@ -0,0 +1,57 @@
+package hats
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/session"
+)
+
+// preambleResponse describes the server-wide prompt preamble
+type preambleResponse struct {
+	Preamble  string `json:"preamble"`
+	MaxTokens int    `json:"max_tokens"`
+}
+
+// HandleGetPreamble returns the guidance prepended to every hat's system prompt.
+// GET /api/v1/admin/preamble
+func (h *Handler) HandleGetPreamble(c echo.Context) error {
+	if h.deps.SessionManager == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "session manager not configured")
+	}
+
+	return c.JSON(http.StatusOK, preambleResponse{
+		Preamble:  h.deps.SessionManager.Preamble(),
+		MaxTokens: session.MaxPreambleTokens,
+	})
+}
+
+// HandleUpdatePreamble replaces the guidance prepended to every hat's system
+// prompt (empty clears it). Sessions started afterwards use it, including the
+// next hat of a running task. It isn't persisted: a restart goes back to
+// --prompt-preamble.
+// PUT /api/v1/admin/preamble
+func (h *Handler) HandleUpdatePreamble(c echo.Context) error {
+	if h.deps.SessionManager == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "session manager not configured")
+	}
+
+	var req struct {
+		Preamble *string `json:"preamble"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.Preamble == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "preamble is required")
+	}
+
+	if err := h.deps.SessionManager.SetPreamble(*req.Preamble); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, preambleResponse{
+		Preamble:  h.deps.SessionManager.Preamble(),
+		MaxTokens: session.MaxPreambleTokens,
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/gitprovider"
 	forgejoclient "github.com/lirancohen/dex/internal/gitprovider/forgejo"
+	"github.com/lirancohen/dex/internal/orchestrator"
 	"github.com/lirancohen/dex/internal/realtime"
 )
 
@@ -348,6 +349,10 @@ func (s *SyncService) handleTaskUnblocking(ctx context.Context, completedTaskID
 			broadcaster := s.deps.Broadcaster
 			go func() {
 				startResult, err := s.deps.StartTaskWithInheritance(context.Background(), taskID, inheritedWorktree, handoff)
+				if orchestrator.IsQueued(err) {
+					fmt.Printf("handleTaskUnblocking: %v\n", err)
+					return
+				}
 				if err != nil {
 					fmt.Printf("handleTaskUnblocking: auto-start failed for task %s: %v\n", taskID, err)
 					if broadcaster != nil {
@@ -71,8 +71,8 @@ type MemoryResponse struct {
 	UseCount           int      `json:"use_count"`
 }
 
-// toResponse converts a db.Memory to MemoryResponse.
-func toResponse(m *db.Memory) MemoryResponse {
+// ToResponse converts a db.Memory to MemoryResponse.
+func ToResponse(m *db.Memory) MemoryResponse {
 	resp := MemoryResponse{
 		ID:           m.ID,
 		ProjectID:    m.ProjectID,
@@ -131,7 +131,7 @@ func (h *Handler) HandleList(c echo.Context) error {
 
 	responses := make([]MemoryResponse, len(memories))
 	for i, m := range memories {
-		responses[i] = toResponse(&m)
+		responses[i] = ToResponse(&m)
 	}
 
 	return c.JSON(http.StatusOK, responses)
@@ -185,7 +185,7 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusCreated, toResponse(memory))
+	return c.JSON(http.StatusCreated, ToResponse(memory))
 }
 
 // HandleGet returns a single memory by ID.
@@ -205,7 +205,7 @@ func (h *Handler) HandleGet(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, toResponse(memory))
+	return c.JSON(http.StatusOK, ToResponse(memory))
 }
 
 // HandleUpdate updates an existing memory.
@@ -256,7 +256,7 @@ func (h *Handler) HandleUpdate(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, toResponse(memory))
+	return c.JSON(http.StatusOK, ToResponse(memory))
 }
 
 // HandleDelete deletes a memory.
@@ -323,7 +323,7 @@ func (h *Handler) HandleSearch(c echo.Context) error {
 
 	responses := make([]MemoryResponse, len(memories))
 	for i, m := range memories {
-		responses[i] = toResponse(&m)
+		responses[i] = ToResponse(&m)
 	}
 
 	return c.JSON(http.StatusOK, responses)
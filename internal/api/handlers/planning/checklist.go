@@ -9,6 +9,7 @@ import (
 	"github.com/lirancohen/dex/internal/api/core"
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/security"
 	"github.com/lirancohen/dex/internal/task"
 )
 
@@ -34,12 +35,16 @@ func (h *ChecklistHandler) getTaskProjectID(taskID string) string {
 // RegisterRoutes registers all checklist routes on the given group.
 // All routes require authentication.
 //   - GET /tasks/:id/checklist
+//   - POST /tasks/:id/checklist/items
 //   - PUT /tasks/:id/checklist/items/:itemId
+//   - PUT /tasks/:id/checklist/reorder
 //   - POST /tasks/:id/checklist/accept
 //   - POST /tasks/:id/remediate
 func (h *ChecklistHandler) RegisterRoutes(g *echo.Group) {
 	g.GET("/tasks/:id/checklist", h.HandleGet)
+	g.POST("/tasks/:id/checklist/items", h.HandleCreateItems)
 	g.PUT("/tasks/:id/checklist/items/:itemId", h.HandleUpdateItem)
+	g.PUT("/tasks/:id/checklist/reorder", h.HandleReorder)
 	g.POST("/tasks/:id/checklist/accept", h.HandleAccept)
 	g.POST("/tasks/:id/remediate", h.HandleCreateRemediation)
 }
@@ -157,6 +162,117 @@ func (h *ChecklistHandler) HandleUpdateItem(c echo.Context) error {
 	return c.JSON(http.StatusOK, core.ToChecklistItemResponse(updatedItem))
 }
 
+// HandleCreateItems adds items to the end of a task's checklist in one batch,
+// creating the checklist if the task has none.
+// POST /api/v1/tasks/:id/checklist/items
+func (h *ChecklistHandler) HandleCreateItems(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		Items []string `json:"items"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	descriptions := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		item = strings.TrimSpace(security.SanitizeForPrompt(item))
+		if item == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "checklist items must not be empty")
+		}
+		descriptions = append(descriptions, item)
+	}
+	if len(descriptions) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "items is required")
+	}
+
+	if _, err := h.deps.TaskService.Get(taskID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	checklist, err := h.deps.DB.GetChecklistByTaskID(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if checklist == nil {
+		if checklist, err = h.deps.DB.CreateTaskChecklist(taskID); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	items, err := h.deps.DB.CreateChecklistItems(checklist.ID, descriptions)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	itemResponses := make([]core.ChecklistItemResponse, len(items))
+	for i, item := range items {
+		itemResponses[i] = core.ToChecklistItemResponse(item)
+	}
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishTaskEvent(realtime.EventChecklistUpdated, taskID, map[string]any{
+			"checklist_id": checklist.ID,
+			"items":        itemResponses,
+			"project_id":   h.getTaskProjectID(taskID),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]any{
+		"checklist_id": checklist.ID,
+		"items":        itemResponses,
+	})
+}
+
+// HandleReorder sets the order of a task's checklist items. The request lists
+// every item ID once, in the new order.
+// PUT /api/v1/tasks/:id/checklist/reorder
+func (h *ChecklistHandler) HandleReorder(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		ItemIDs []string `json:"item_ids"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	checklist, err := h.deps.DB.GetChecklistByTaskID(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if checklist == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "no checklist for task")
+	}
+
+	if err := h.deps.DB.ReorderChecklistItems(checklist.ID, req.ItemIDs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	items, err := h.deps.DB.GetChecklistItems(checklist.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	itemResponses := make([]core.ChecklistItemResponse, len(items))
+	for i, item := range items {
+		itemResponses[i] = core.ToChecklistItemResponse(item)
+	}
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishTaskEvent(realtime.EventChecklistUpdated, taskID, map[string]any{
+			"checklist_id": checklist.ID,
+			"items":        itemResponses,
+			"reordered":    true,
+			"project_id":   h.getTaskProjectID(taskID),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"checklist_id": checklist.ID,
+		"items":        itemResponses,
+	})
+}
+
 // HandleAccept creates checklist items from pending checklist and transitions task to ready.
 // POST /api/v1/tasks/:id/checklist/accept
 func (h *ChecklistHandler) HandleAccept(c echo.Context) error {
@@ -206,25 +322,16 @@ func (h *ChecklistHandler) HandleAccept(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	sortOrder := 0
-
-	// Add all must-have items
-	for _, desc := range pendingChecklist.MustHave {
-		if _, err := h.deps.DB.CreateChecklistItem(checklist.ID, desc, sortOrder); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-		}
-		sortOrder++
-	}
-
-	// Add selected optional items
+	// Add all must-have items, then the selected optional ones
+	descriptions := append([]string{}, pendingChecklist.MustHave...)
 	for idx, desc := range pendingChecklist.Optional {
 		if selectedOptionalSet[idx] {
-			if _, err := h.deps.DB.CreateChecklistItem(checklist.ID, desc, sortOrder); err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-			}
-			sortOrder++
+			descriptions = append(descriptions, desc)
 		}
 	}
+	if _, err := h.deps.DB.CreateChecklistItems(checklist.ID, descriptions); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
 
 	// Transition task to ready
 	if err := h.deps.TaskService.UpdateStatus(taskID, db.TaskStatusReady); err != nil {
@@ -242,15 +349,24 @@ func (h *ChecklistHandler) HandleAccept(c echo.Context) error {
 		"message":      "checklist accepted",
 		"task_id":      taskID,
 		"checklist_id": checklist.ID,
-		"items_count":  sortOrder,
+		"items_count":  len(descriptions),
 	})
 }
 
 // HandleCreateRemediation creates a new task to remediate failed checklist items.
+// With reuse_worktree set, the remediation task continues in the original
+// task's worktree and branch instead of starting from a fresh one.
 // POST /api/v1/tasks/:id/remediate
 func (h *ChecklistHandler) HandleCreateRemediation(c echo.Context) error {
 	taskID := c.Param("id")
 
+	var req struct {
+		ReuseWorktree bool `json:"reuse_worktree"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
 	originalTask, err := h.deps.TaskService.Get(taskID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, err.Error())
@@ -271,6 +387,9 @@ func (h *ChecklistHandler) HandleCreateRemediation(c echo.Context) error {
 	if len(issues) == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "no issues to remediate")
 	}
+	if req.ReuseWorktree && originalTask.GetWorktreePath() == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "task has no worktree to reuse")
+	}
 
 	// Build remediation description
 	var sb strings.Builder
@@ -298,9 +417,17 @@ func (h *ChecklistHandler) HandleCreateRemediation(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	resp := core.ToTaskResponse(newTask)
+	if req.ReuseWorktree {
+		if err := h.deps.DB.SetTaskReuseWorktreeFrom(newTask.ID, taskID); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		resp.ReuseWorktreeFrom = taskID
+	}
+
 	return c.JSON(http.StatusCreated, map[string]any{
 		"message":          "remediation task created",
-		"task":             core.ToTaskResponse(newTask),
+		"task":             resp,
 		"original_task_id": taskID,
 		"issues_count":     len(issues),
 	})
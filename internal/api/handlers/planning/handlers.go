@@ -2,7 +2,9 @@
 package planning
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +12,7 @@ import (
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/planning"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/security"
 	"github.com/lirancohen/dex/internal/task"
 )
 
@@ -29,11 +32,15 @@ func New(deps *core.Deps) *Handler {
 //   - POST /tasks/:id/planning/respond
 //   - POST /tasks/:id/planning/accept
 //   - POST /tasks/:id/planning/skip
+//   - PUT /tasks/:id/planning/checklist
+//   - POST /tasks/:id/planning/checklist/reclassify
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/tasks/:id/planning", h.HandleGet)
 	g.POST("/tasks/:id/planning/respond", h.HandleRespond)
 	g.POST("/tasks/:id/planning/accept", h.HandleAccept)
 	g.POST("/tasks/:id/planning/skip", h.HandleSkip)
+	g.PUT("/tasks/:id/planning/checklist", h.HandleUpdateChecklist)
+	g.POST("/tasks/:id/planning/checklist/reclassify", h.HandleReclassifyChecklist)
 }
 
 // planner returns the planning service or nil if not configured.
@@ -251,3 +258,152 @@ func (h *Handler) HandleSkip(c echo.Context) error {
 		"task_id": taskID,
 	})
 }
+
+// HandleUpdateChecklist replaces the pending checklist with the user's edits
+// before the plan is accepted. The body carries the full must_have and
+// optional lists, so items are added, removed, and reworded in one request.
+// PUT /api/v1/tasks/:id/planning/checklist
+func (h *Handler) HandleUpdateChecklist(c echo.Context) error {
+	taskID := c.Param("id")
+
+	if h.planner() == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "planning not available")
+	}
+
+	var req struct {
+		MustHave []string `json:"must_have"`
+		Optional []string `json:"optional"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	mustHave, err := sanitizeChecklistItems(req.MustHave)
+	if err != nil {
+		return err
+	}
+	optional, err := sanitizeChecklistItems(req.Optional)
+	if err != nil {
+		return err
+	}
+	if len(mustHave) == 0 && len(optional) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "checklist must have at least one item")
+	}
+
+	session, err := h.editablePlanningSession(taskID)
+	if err != nil {
+		return err
+	}
+
+	updatedSession, err := h.planner().UpdatePendingChecklist(session.ID, &planning.ParsedChecklist{
+		MustHave: mustHave,
+		Optional: optional,
+	})
+	if errors.Is(err, planning.ErrChecklistNotEditable) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"session": checklistSessionResponse(updatedSession),
+	})
+}
+
+// HandleReclassifyChecklist promotes optional items to must-haves and demotes
+// must-haves to optional before the plan is accepted. Must-haves block task
+// completion, so this is how the accepted checklist reflects what really matters.
+// Indices refer to the current pending checklist; moved items go to the end of
+// their new list, and the response holds the indices to accept with.
+// POST /api/v1/tasks/:id/planning/checklist/reclassify
+func (h *Handler) HandleReclassifyChecklist(c echo.Context) error {
+	taskID := c.Param("id")
+
+	if h.planner() == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "planning not available")
+	}
+
+	var req struct {
+		Promote []int `json:"promote"` // Optional item indices to make must-haves
+		Demote  []int `json:"demote"`  // Must-have indices to make optional
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Promote) == 0 && len(req.Demote) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "promote or demote is required")
+	}
+
+	session, err := h.editablePlanningSession(taskID)
+	if err != nil {
+		return err
+	}
+
+	updatedSession, err := h.planner().ReclassifyPendingChecklist(session.ID, req.Promote, req.Demote)
+	switch {
+	case errors.Is(err, planning.ErrInvalidChecklistIndex):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, planning.ErrNoPendingChecklist):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, planning.ErrChecklistNotEditable):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"session": checklistSessionResponse(updatedSession),
+	})
+}
+
+// editablePlanningSession returns the task's planning session if its plan
+// hasn't been accepted yet, or an HTTP error to return
+func (h *Handler) editablePlanningSession(taskID string) (*db.PlanningSession, error) {
+	t, err := h.deps.DB.GetTaskByID(taskID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if t == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "task not found")
+	}
+	if t.Status != db.TaskStatusPlanning {
+		return nil, echo.NewHTTPError(http.StatusConflict, "plan has already been accepted")
+	}
+
+	session, _, err := h.planner().GetSessionByTask(taskID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if session == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "no planning session for task")
+	}
+	return session, nil
+}
+
+// checklistSessionResponse formats a planning session after a checklist edit
+func checklistSessionResponse(session *db.PlanningSession) map[string]any {
+	return map[string]any{
+		"id":                session.ID,
+		"task_id":           session.TaskID,
+		"status":            session.Status,
+		"original_prompt":   session.OriginalPrompt,
+		"refined_prompt":    session.RefinedPrompt.String,
+		"created_at":        session.CreatedAt.Format(time.RFC3339),
+		"pending_checklist": session.GetPendingChecklist(),
+	}
+}
+
+// sanitizeChecklistItems strips invisible characters and surrounding
+// whitespace from edited checklist items, rejecting any left empty
+func sanitizeChecklistItems(items []string) ([]string, error) {
+	sanitized := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(security.SanitizeForPrompt(item))
+		if item == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "checklist items must not be empty")
+		}
+		sanitized = append(sanitized, item)
+	}
+	return sanitized, nil
+}
@@ -0,0 +1,103 @@
+package projects
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// gitCredentialsStore returns the encrypted store project git credentials
+// live in, or an error if encryption isn't configured
+func (h *Handler) gitCredentialsStore() (*db.EncryptedSecretsStore, error) {
+	if h.deps.SecretsStore == nil {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable, "encryption is not configured; project git credentials are unavailable")
+	}
+	return h.deps.SecretsStore, nil
+}
+
+// HandleGetGitCredentials reports which git credentials a project uses.
+// The token itself is never returned.
+// GET /api/v1/projects/:id/git-credentials
+func (h *Handler) HandleGetGitCredentials(c echo.Context) error {
+	id := c.Param("id")
+
+	store, err := h.gitCredentialsStore()
+	if err != nil {
+		return err
+	}
+
+	creds, err := store.GetProjectGitCredentials(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	source := "global"
+	installation := ""
+	if creds != nil {
+		installation = creds.Installation
+		source = "token"
+		if installation != "" {
+			source = "installation"
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"project_id":   id,
+		"source":       source,
+		"installation": installation,
+	})
+}
+
+// HandleSetGitCredentials sets a project's git credentials: either a token or
+// the account login of a GitHub App installation.
+// PUT /api/v1/projects/:id/git-credentials
+func (h *Handler) HandleSetGitCredentials(c echo.Context) error {
+	id := c.Param("id")
+
+	store, err := h.gitCredentialsStore()
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Token        string `json:"token"`
+		Installation string `json:"installation"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	creds := &db.ProjectGitCredentials{Token: req.Token, Installation: req.Installation}
+	if err := creds.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := store.SetProjectGitCredentials(id, creds); err != nil {
+		if errors.Is(err, db.ErrProjectNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return h.HandleGetGitCredentials(c)
+}
+
+// HandleDeleteGitCredentials removes a project's git credentials, so its
+// sessions use the global ones.
+// DELETE /api/v1/projects/:id/git-credentials
+func (h *Handler) HandleDeleteGitCredentials(c echo.Context) error {
+	id := c.Param("id")
+
+	store, err := h.gitCredentialsStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.DeleteProjectGitCredentials(id); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
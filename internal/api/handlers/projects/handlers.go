@@ -2,14 +2,21 @@
 package projects
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
 	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/forgejo"
 	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/session"
+	"github.com/lirancohen/dex/internal/task"
 	"github.com/lirancohen/dex/internal/toolbelt"
+	"github.com/lirancohen/dex/internal/tools"
 )
 
 // Handler handles project-related HTTP requests.
@@ -29,12 +36,18 @@ func New(deps *core.Deps) *Handler {
 //   - GET /projects/:id
 //   - PUT /projects/:id
 //   - DELETE /projects/:id
+//   - GET /projects/:id/git-credentials
+//   - PUT /projects/:id/git-credentials
+//   - DELETE /projects/:id/git-credentials
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/projects", h.HandleList)
 	g.POST("/projects", h.HandleCreate)
 	g.GET("/projects/:id", h.HandleGet)
 	g.PUT("/projects/:id", h.HandleUpdate)
 	g.DELETE("/projects/:id", h.HandleDelete)
+	g.GET("/projects/:id/git-credentials", h.HandleGetGitCredentials)
+	g.PUT("/projects/:id/git-credentials", h.HandleSetGitCredentials)
+	g.DELETE("/projects/:id/git-credentials", h.HandleDeleteGitCredentials)
 }
 
 // HandleList returns all projects.
@@ -87,29 +100,16 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 	var repoPath string
 
 	if req.CreateRepo {
+		// Forgejo creation path: create repo via Forgejo API, use bare repo path
+		if req.GitProvider == db.GitProviderForgejo {
+			return h.createForgejoProject(c, req.GitOwner, req.Name, req.Description)
+		}
+
 		// Create new local repository
 		if h.deps.GitService == nil {
 			return echo.NewHTTPError(http.StatusServiceUnavailable, "git service not configured")
 		}
 
-		// Forgejo creation path: create repo via Forgejo API, use bare repo path
-		if req.GitProvider == db.GitProviderForgejo && h.deps.ForgejoManager != nil {
-			org := req.GitOwner
-			if org == "" {
-				org = "dex" // default org
-			}
-			if err := h.deps.ForgejoManager.CreateRepo(c.Request().Context(), org, req.Name); err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to create Forgejo repo: %v", err))
-			}
-			repoPath = h.deps.ForgejoManager.RepoPath(org, req.Name)
-
-			project, err := h.deps.DB.GetOrCreateProjectByForgejo(org, req.Name, repoPath)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-			}
-			return c.JSON(http.StatusCreated, core.ToProjectResponse(project))
-		}
-
 		var err error
 		repoPath, err = h.deps.GitService.CreateRepo(git.CreateOptions{
 			Name:          req.Name,
@@ -171,6 +171,44 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 	return c.JSON(http.StatusCreated, core.ToProjectResponse(project))
 }
 
+// createForgejoProject creates a repo on the embedded Forgejo server and a
+// project working in its bare repo, with the Forgejo clone URL as its remote.
+// The repo goes in org, or Forgejo's default organization if empty.
+func (h *Handler) createForgejoProject(c echo.Context, org, name, description string) error {
+	mgr := h.deps.ForgejoManager
+	if mgr == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Forgejo is not enabled")
+	}
+	if !mgr.IsRunning() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Forgejo is not running")
+	}
+	if org == "" {
+		cfg := mgr.Config()
+		org = cfg.GetDefaultOrgName()
+	}
+
+	if err := mgr.CreateProjectRepo(c.Request().Context(), org, name, description); err != nil {
+		if errors.Is(err, forgejo.ErrRepoExists) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to create Forgejo repo: %v", err))
+	}
+
+	project, err := h.deps.DB.GetOrCreateProjectByForgejo(org, name, mgr.RepoPath(org, name))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	cloneURL := mgr.CloneURL(org, name)
+	if err := h.deps.DB.UpdateProjectRemotes(project.ID, cloneURL, ""); err != nil {
+		fmt.Printf("warning: failed to set Forgejo remote: %v\n", err)
+	} else {
+		project.RemoteOrigin = sql.NullString{String: cloneURL, Valid: true}
+	}
+
+	return c.JSON(http.StatusCreated, core.ToProjectResponse(project))
+}
+
 // HandleGet returns a single project by ID.
 // GET /api/v1/projects/:id
 func (h *Handler) HandleGet(c echo.Context) error {
@@ -184,7 +222,24 @@ func (h *Handler) HandleGet(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "project not found")
 	}
 
-	return c.JSON(http.StatusOK, core.ToProjectResponse(project))
+	resp := core.ToProjectResponse(project)
+	resp.CompletionPolicy, _ = h.deps.DB.GetProjectCompletionPolicy(id)
+	resp.ActivityLevel, _ = h.deps.DB.GetProjectActivityLevel(id)
+	resp.PromptSensitivity, _ = h.deps.DB.GetProjectPromptSensitivity(id)
+	resp.BudgetCap, _ = h.deps.DB.GetProjectBudgetCap(id)
+	resp.DefaultQuestModel, _ = h.deps.DB.GetProjectDefaultQuestModel(id)
+	resp.DefaultModel, _ = h.deps.DB.GetProjectDefaultModel(id)
+	resp.DefaultAutonomy, _ = h.deps.DB.GetProjectDefaultAutonomy(id)
+	resp.MaxConcurrentTasks, _ = h.deps.DB.GetProjectMaxConcurrentTasks(id)
+	resp.ToolCacheSize, _ = h.deps.DB.GetProjectToolCacheSize(id)
+	resp.CriticRequiresTests, _ = h.deps.DB.GetProjectCriticRequiresTests(id)
+	resp.PromptPreamble, _ = h.deps.DB.GetProjectPromptPreamble(id)
+	resp.PipelineRetry, _ = h.deps.DB.GetProjectPipelineRetry(id)
+	resp.GitAuthor, _ = h.deps.DB.GetProjectGitAuthor(id)
+	resp.StartingHat, _ = h.deps.DB.GetProjectStartingHat(id)
+	resp.ModelFallback, _ = h.deps.DB.GetProjectModelFallback(id)
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 // HandleUpdate updates a project.
@@ -211,10 +266,118 @@ func (h *Handler) HandleUpdate(c echo.Context) error {
 		GitHubOwner   *string             `json:"github_owner"`
 		GitHubRepo    *string             `json:"github_repo"`
 		Services      *db.ProjectServices `json:"services"`
+
+		// Completion verification; an empty strictness clears the policy
+		CompletionPolicy *db.CompletionPolicy `json:"completion_policy"`
+
+		// Defaults inherited by new tasks; an empty model or negative autonomy clears them
+		DefaultModel    *string `json:"default_model"`
+		DefaultAutonomy *int    `json:"default_autonomy"`
+
+		// Which activity events sessions record ("standard" or "debug"); empty clears it
+		ActivityLevel *string `json:"activity_level"`
+
+		// How aggressively untrusted content is neutralized ("off", "normal", or "strict"); empty clears it
+		PromptSensitivity *string `json:"prompt_sensitivity"`
+
+		// Limits on budget top-ups when resuming paused tasks; all zero clears it
+		BudgetCap *db.BudgetCap `json:"budget_cap"`
+
+		// Model new quests start on when none is picked ("sonnet" or "opus"); empty clears it
+		DefaultQuestModel *string `json:"default_quest_model"`
+
+		// Tasks allowed to run at once against the repo; 0 clears it (server default)
+		MaxConcurrentTasks *int `json:"max_concurrent_tasks"`
+
+		// Read-only tool results each session may cache; 0 turns caching off
+		ToolCacheSize *int `json:"tool_cache_size"`
+
+		// Reject review approvals until the session has run the tests
+		CriticRequiresTests *bool `json:"critic_requires_tests"`
+
+		// Guidance prepended to every hat's system prompt in the project; empty clears it
+		PromptPreamble *string `json:"prompt_preamble"`
+
+		// Which hat a failed task restarts at, per failed hat; no rules clears it
+		PipelineRetry *db.PipelineRetryPolicy `json:"pipeline_retry"`
+
+		// Identity sessions commit as (user.name and user.email); both empty clears it
+		GitAuthor *db.GitAuthor `json:"git_author"`
+
+		// Hat new task sessions start in ("explorer", "planner", "designer", or
+		// "creator"), or "auto" to pick one per task; empty clears it (auto)
+		StartingHat *string `json:"starting_hat"`
+
+		// Models tasks fall back to, in order, when theirs is over capacity
+		// (e.g. "sonnet"); empty or "off" turns fallback off
+		ModelFallback *string `json:"model_fallback"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
+	if req.CompletionPolicy != nil && req.CompletionPolicy.Strictness != "" {
+		if err := req.CompletionPolicy.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.DefaultModel != nil && *req.DefaultModel != "" && !task.IsValidModel(*req.DefaultModel) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid default_model %q (must be sonnet or opus)", *req.DefaultModel))
+	}
+	if req.DefaultAutonomy != nil && *req.DefaultAutonomy >= 0 && !task.IsValidAutonomyLevel(*req.DefaultAutonomy) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid default_autonomy %d (must be 0-%d)", *req.DefaultAutonomy, task.MaxAutonomyLevel))
+	}
+	if req.ActivityLevel != nil && *req.ActivityLevel != "" {
+		if err := db.ValidateActivityLevel(*req.ActivityLevel); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.PromptSensitivity != nil && *req.PromptSensitivity != "" {
+		if _, err := security.ParseSensitivity(*req.PromptSensitivity); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.BudgetCap != nil {
+		if err := req.BudgetCap.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.DefaultQuestModel != nil && *req.DefaultQuestModel != "" {
+		if err := db.ValidateQuestModel(*req.DefaultQuestModel); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.MaxConcurrentTasks != nil && *req.MaxConcurrentTasks < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "max_concurrent_tasks must not be negative")
+	}
+	if req.ToolCacheSize != nil && (*req.ToolCacheSize < 0 || *req.ToolCacheSize > tools.MaxResultCacheSize) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("tool_cache_size must be between 0 and %d", tools.MaxResultCacheSize))
+	}
+	if req.PromptPreamble != nil {
+		*req.PromptPreamble = session.NormalizePreamble(*req.PromptPreamble)
+		if err := session.ValidatePreamble(*req.PromptPreamble); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "prompt_preamble: "+err.Error())
+		}
+	}
+	if req.PipelineRetry != nil {
+		if err := session.ValidatePipelineRetryPolicy(req.PipelineRetry); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "pipeline_retry: "+err.Error())
+		}
+	}
+	if !req.GitAuthor.IsZero() {
+		if err := req.GitAuthor.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.StartingHat != nil && *req.StartingHat != "" {
+		if err := db.ValidateStartingHat(*req.StartingHat); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.ModelFallback != nil {
+		if _, err := db.ParseModelFallback(*req.ModelFallback); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
 
 	// Update basic fields (use existing values if not provided)
 	name := existing.Name
@@ -289,13 +452,141 @@ func (h *Handler) HandleUpdate(c echo.Context) error {
 		}
 	}
 
+	// Update completion policy if provided
+	if req.CompletionPolicy != nil {
+		policy := req.CompletionPolicy
+		if policy.Strictness == "" {
+			policy = nil
+		}
+		if err := h.deps.DB.SetProjectCompletionPolicy(id, policy); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update task defaults if provided
+	if req.DefaultModel != nil {
+		if err := h.deps.DB.SetProjectDefaultModel(id, *req.DefaultModel); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	if req.DefaultAutonomy != nil {
+		level := req.DefaultAutonomy
+		if *level < 0 {
+			level = nil
+		}
+		if err := h.deps.DB.SetProjectDefaultAutonomy(id, level); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update activity level if provided
+	if req.ActivityLevel != nil {
+		if err := h.deps.DB.SetProjectActivityLevel(id, *req.ActivityLevel); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update prompt sensitivity if provided
+	if req.PromptSensitivity != nil {
+		if err := h.deps.DB.SetProjectPromptSensitivity(id, *req.PromptSensitivity); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update budget cap if provided
+	if req.BudgetCap != nil {
+		if err := h.deps.DB.SetProjectBudgetCap(id, req.BudgetCap); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update default quest model if provided
+	if req.DefaultQuestModel != nil {
+		if err := h.deps.DB.SetProjectDefaultQuestModel(id, *req.DefaultQuestModel); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update concurrent task limit if provided
+	if req.MaxConcurrentTasks != nil {
+		if err := h.deps.DB.SetProjectMaxConcurrentTasks(id, *req.MaxConcurrentTasks); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update tool result cache size if provided
+	if req.ToolCacheSize != nil {
+		if err := h.deps.DB.SetProjectToolCacheSize(id, *req.ToolCacheSize); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update critic test requirement if provided
+	if req.CriticRequiresTests != nil {
+		if err := h.deps.DB.SetProjectCriticRequiresTests(id, *req.CriticRequiresTests); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update prompt preamble if provided
+	if req.PromptPreamble != nil {
+		if err := h.deps.DB.SetProjectPromptPreamble(id, *req.PromptPreamble); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update pipeline retry policy if provided
+	if req.PipelineRetry != nil {
+		if err := h.deps.DB.SetProjectPipelineRetry(id, req.PipelineRetry); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update git author if provided
+	if req.GitAuthor != nil {
+		if err := h.deps.DB.SetProjectGitAuthor(id, req.GitAuthor); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update starting hat if provided
+	if req.StartingHat != nil {
+		if err := h.deps.DB.SetProjectStartingHat(id, *req.StartingHat); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Update model fallback if provided
+	if req.ModelFallback != nil {
+		if err := h.deps.DB.SetProjectModelFallback(id, *req.ModelFallback); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	// Return updated project
 	updated, err := h.deps.DB.GetProjectByID(id)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, core.ToProjectResponse(updated))
+	resp := core.ToProjectResponse(updated)
+	resp.CompletionPolicy, _ = h.deps.DB.GetProjectCompletionPolicy(id)
+	resp.ActivityLevel, _ = h.deps.DB.GetProjectActivityLevel(id)
+	resp.PromptSensitivity, _ = h.deps.DB.GetProjectPromptSensitivity(id)
+	resp.BudgetCap, _ = h.deps.DB.GetProjectBudgetCap(id)
+	resp.DefaultQuestModel, _ = h.deps.DB.GetProjectDefaultQuestModel(id)
+	resp.DefaultModel, _ = h.deps.DB.GetProjectDefaultModel(id)
+	resp.DefaultAutonomy, _ = h.deps.DB.GetProjectDefaultAutonomy(id)
+	resp.MaxConcurrentTasks, _ = h.deps.DB.GetProjectMaxConcurrentTasks(id)
+	resp.ToolCacheSize, _ = h.deps.DB.GetProjectToolCacheSize(id)
+	resp.CriticRequiresTests, _ = h.deps.DB.GetProjectCriticRequiresTests(id)
+	resp.PromptPreamble, _ = h.deps.DB.GetProjectPromptPreamble(id)
+	resp.PipelineRetry, _ = h.deps.DB.GetProjectPipelineRetry(id)
+	resp.GitAuthor, _ = h.deps.DB.GetProjectGitAuthor(id)
+	resp.StartingHat, _ = h.deps.DB.GetProjectStartingHat(id)
+	resp.ModelFallback, _ = h.deps.DB.GetProjectModelFallback(id)
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 // HandleDelete removes a project.
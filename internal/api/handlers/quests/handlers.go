@@ -9,6 +9,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/api/handlers/memory"
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/realtime"
 	"github.com/lirancohen/dex/internal/toolbelt"
@@ -42,8 +43,12 @@ func New(deps *core.Deps) *Handler {
 //   - POST /quests/:id/complete
 //   - POST /quests/:id/reopen
 //   - PUT /quests/:id/model
+//   - PUT /quests/:id/auto-complete
+//   - PUT /quests/:id/auto-start
 //   - GET /quests/:id/tasks
+//   - GET /quests/:id/tree
 //   - GET /quests/:id/preflight
+//   - POST /quests/:id/promote-memories
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/projects/:id/quests", h.HandleList)
 	g.POST("/projects/:id/quests", h.HandleCreate)
@@ -55,8 +60,12 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.POST("/quests/:id/complete", h.HandleComplete)
 	g.POST("/quests/:id/reopen", h.HandleReopen)
 	g.PUT("/quests/:id/model", h.HandleUpdateModel)
+	g.PUT("/quests/:id/auto-complete", h.HandleUpdateAutoComplete)
+	g.PUT("/quests/:id/auto-start", h.HandleUpdateAutoStart)
 	g.GET("/quests/:id/tasks", h.HandleGetTasks)
+	g.GET("/quests/:id/tree", h.HandleGetTree)
 	g.GET("/quests/:id/preflight", h.HandleGetPreflight)
+	g.POST("/quests/:id/promote-memories", h.HandlePromoteMemories)
 }
 
 // ensureDefaultProject creates the default project if it doesn't exist.
@@ -128,14 +137,30 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 
 	var req struct {
 		Model string `json:"model"`
+
+		// Complete the quest once every task it spawns is done
+		AutoComplete bool `json:"auto_complete_on_tasks_done"`
+
+		// How auto-started objectives start: how many of the quest's tasks may
+		// run at once (0 = no limit), and "dependency_order" or "all"
+		MaxConcurrentObjectives int    `json:"max_concurrent_objectives"`
+		ObjectiveStartMode      string `json:"objective_start_mode"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	if req.MaxConcurrentObjectives < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "max_concurrent_objectives must not be negative")
+	}
+	if req.ObjectiveStartMode != "" {
+		if err := db.ValidateObjectiveStartMode(req.ObjectiveStartMode); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
 
-	model := req.Model
-	if model == "" {
-		model = db.QuestModelSonnet
+	model, err := h.deps.DB.ResolveQuestModel(projectID, req.Model)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	if model != db.QuestModelSonnet && model != db.QuestModelOpus {
 		return echo.NewHTTPError(http.StatusBadRequest, "model must be 'sonnet' or 'opus'")
@@ -146,6 +171,27 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	if req.AutoComplete {
+		if err := h.deps.DB.SetQuestAutoComplete(quest.ID, true); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		quest.AutoComplete = true
+	}
+
+	if req.MaxConcurrentObjectives > 0 {
+		if err := h.deps.DB.SetQuestMaxConcurrentObjectives(quest.ID, req.MaxConcurrentObjectives); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		quest.MaxConcurrentObjectives = req.MaxConcurrentObjectives
+	}
+
+	if req.ObjectiveStartMode != "" {
+		if err := h.deps.DB.SetQuestObjectiveStartMode(quest.ID, req.ObjectiveStartMode); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		quest.ObjectiveStartMode = req.ObjectiveStartMode
+	}
+
 	if h.deps.Broadcaster != nil {
 		h.deps.Broadcaster.PublishQuestEvent(realtime.EventQuestCreated, quest.ID, map[string]any{
 			"project_id": projectID,
@@ -416,6 +462,111 @@ func (h *Handler) HandleUpdateModel(c echo.Context) error {
 	return c.JSON(http.StatusOK, core.ToQuestResponse(quest, summary))
 }
 
+// HandleUpdateAutoComplete sets whether a quest completes on its own once every
+// task it spawned is done. Turning it on completes the quest right away if its
+// tasks are already done.
+// PUT /api/v1/quests/:id/auto-complete
+func (h *Handler) HandleUpdateAutoComplete(c echo.Context) error {
+	questID := c.Param("id")
+
+	quest, err := h.deps.DB.GetQuestByID(questID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if quest == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "quest not found")
+	}
+
+	var req struct {
+		AutoComplete *bool `json:"auto_complete_on_tasks_done"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.AutoComplete == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "auto_complete_on_tasks_done is required")
+	}
+
+	if err := h.deps.DB.SetQuestAutoComplete(questID, *req.AutoComplete); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishQuestEvent(realtime.EventQuestUpdated, questID, map[string]any{
+			"auto_complete_on_tasks_done": *req.AutoComplete,
+		})
+	}
+
+	if *req.AutoComplete && h.deps.AutoCompleteQuest != nil {
+		h.deps.AutoCompleteQuest(questID)
+	}
+
+	quest, _ = h.deps.DB.GetQuestByID(questID)
+	summary, _ := h.deps.DB.GetQuestSummary(questID)
+
+	return c.JSON(http.StatusOK, core.ToQuestResponse(quest, summary))
+}
+
+// HandleUpdateAutoStart sets how a quest's auto-started objectives start: how
+// many of its tasks may run at once, and whether blocked objectives wait for
+// their blockers. Starts over the limit queue until one of the quest's tasks
+// stops.
+// PUT /api/v1/quests/:id/auto-start
+func (h *Handler) HandleUpdateAutoStart(c echo.Context) error {
+	questID := c.Param("id")
+
+	quest, err := h.deps.DB.GetQuestByID(questID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if quest == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "quest not found")
+	}
+
+	var req struct {
+		MaxConcurrentObjectives *int    `json:"max_concurrent_objectives"` // 0 clears the limit
+		ObjectiveStartMode      *string `json:"objective_start_mode"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.MaxConcurrentObjectives == nil && req.ObjectiveStartMode == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "max_concurrent_objectives or objective_start_mode is required")
+	}
+	if req.MaxConcurrentObjectives != nil && *req.MaxConcurrentObjectives < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "max_concurrent_objectives must not be negative")
+	}
+	if req.ObjectiveStartMode != nil {
+		if err := db.ValidateObjectiveStartMode(*req.ObjectiveStartMode); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	if req.MaxConcurrentObjectives != nil {
+		if err := h.deps.DB.SetQuestMaxConcurrentObjectives(questID, *req.MaxConcurrentObjectives); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	if req.ObjectiveStartMode != nil {
+		if err := h.deps.DB.SetQuestObjectiveStartMode(questID, *req.ObjectiveStartMode); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	quest, _ = h.deps.DB.GetQuestByID(questID)
+	summary, _ := h.deps.DB.GetQuestSummary(questID)
+	resp := core.ToQuestResponse(quest, summary)
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishQuestEvent(realtime.EventQuestUpdated, questID, map[string]any{
+			"max_concurrent_objectives": resp.MaxConcurrentObjectives,
+			"objective_start_mode":      resp.ObjectiveStartMode,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 // HandleGetTasks returns all tasks spawned by a quest.
 // GET /api/v1/quests/:id/tasks
 func (h *Handler) HandleGetTasks(c echo.Context) error {
@@ -447,6 +598,28 @@ func (h *Handler) HandleGetTasks(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// HandleGetTree returns the tasks a quest spawned as a tree of subtasks, with
+// their statuses, costs, dependencies, and PRs, and the quest's totals.
+// GET /api/v1/quests/:id/tree
+func (h *Handler) HandleGetTree(c echo.Context) error {
+	questID := c.Param("id")
+
+	quest, err := h.deps.DB.GetQuestByID(questID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if quest == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "quest not found")
+	}
+
+	tree, err := h.deps.DB.GetQuestTaskTree(questID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, tree)
+}
+
 // HandleGetPreflight returns pre-flight check results for a quest's project.
 // GET /api/v1/quests/:id/preflight
 func (h *Handler) HandleGetPreflight(c echo.Context) error {
@@ -546,3 +719,37 @@ func (h *Handler) HandleCancelSession(c echo.Context) error {
 		"status": "cancelled",
 	})
 }
+
+// HandlePromoteMemories saves the durable learnings in a quest's conversation
+// as memories of its project, so future tasks start with them.
+// POST /api/v1/quests/:id/promote-memories
+func (h *Handler) HandlePromoteMemories(c echo.Context) error {
+	questID := c.Param("id")
+
+	quest, err := h.deps.DB.GetQuestByID(questID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if quest == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "quest not found")
+	}
+
+	if h.deps.QuestHandler == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "quest handler not configured (missing Anthropic API key)")
+	}
+
+	memories, err := h.deps.QuestHandler.PromoteMemories(c.Request().Context(), questID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to promote memories: %v", err))
+	}
+
+	responses := make([]memory.MemoryResponse, len(memories))
+	for i, m := range memories {
+		responses[i] = memory.ToResponse(m)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"memories": responses,
+		"count":    len(responses),
+	})
+}
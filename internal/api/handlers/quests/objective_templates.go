@@ -0,0 +1,197 @@
+package quests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/session"
+)
+
+// ObjectiveTemplatesHandler handles objective template-related HTTP requests.
+type ObjectiveTemplatesHandler struct {
+	deps *core.Deps
+}
+
+// NewObjectiveTemplatesHandler creates a new objective templates handler.
+func NewObjectiveTemplatesHandler(deps *core.Deps) *ObjectiveTemplatesHandler {
+	return &ObjectiveTemplatesHandler{deps: deps}
+}
+
+// RegisterRoutes registers all objective template routes on the given group.
+// All routes require authentication.
+//   - GET /projects/:id/objective-templates
+//   - POST /projects/:id/objective-templates
+//   - GET /objective-templates/:id
+//   - PUT /objective-templates/:id
+//   - DELETE /objective-templates/:id
+func (h *ObjectiveTemplatesHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/projects/:id/objective-templates", h.HandleList)
+	g.POST("/projects/:id/objective-templates", h.HandleCreate)
+	g.GET("/objective-templates/:id", h.HandleGet)
+	g.PUT("/objective-templates/:id", h.HandleUpdate)
+	g.DELETE("/objective-templates/:id", h.HandleDelete)
+}
+
+// objectiveTemplateRequest is the body for creating or updating an objective template
+type objectiveTemplateRequest struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Hat         string   `json:"hat"`
+	Checklist   []string `json:"checklist"`
+}
+
+// validate checks required fields and drops blank checklist items
+func (r *objectiveTemplateRequest) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if r.Hat != "" && !session.IsValidHat(r.Hat) {
+		return fmt.Errorf("invalid hat: %s", r.Hat)
+	}
+
+	items := make([]string, 0, len(r.Checklist))
+	for _, item := range r.Checklist {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	r.Checklist = items
+	return nil
+}
+
+// HandleList returns all objective templates for a project.
+// GET /api/v1/projects/:id/objective-templates
+func (h *ObjectiveTemplatesHandler) HandleList(c echo.Context) error {
+	projectID := c.Param("id")
+
+	templates, err := h.deps.DB.GetObjectiveTemplatesByProjectID(projectID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	result := make([]map[string]any, len(templates))
+	for i, t := range templates {
+		result[i] = objectiveTemplateResponse(t)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// HandleCreate creates a new objective template.
+// POST /api/v1/projects/:id/objective-templates
+func (h *ObjectiveTemplatesHandler) HandleCreate(c echo.Context) error {
+	projectID := c.Param("id")
+
+	var req objectiveTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	project, err := h.deps.DB.GetProjectByID(projectID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if project == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "project not found")
+	}
+
+	template, err := h.deps.DB.CreateObjectiveTemplate(projectID, req.Name, req.Title, req.Description, req.Hat, req.Checklist)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, objectiveTemplateResponse(template))
+}
+
+// HandleGet returns an objective template by ID.
+// GET /api/v1/objective-templates/:id
+func (h *ObjectiveTemplatesHandler) HandleGet(c echo.Context) error {
+	templateID := c.Param("id")
+
+	template, err := h.deps.DB.GetObjectiveTemplateByID(templateID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if template == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "template not found")
+	}
+
+	return c.JSON(http.StatusOK, objectiveTemplateResponse(template))
+}
+
+// HandleUpdate updates an objective template.
+// PUT /api/v1/objective-templates/:id
+func (h *ObjectiveTemplatesHandler) HandleUpdate(c echo.Context) error {
+	templateID := c.Param("id")
+
+	var req objectiveTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	existing, err := h.deps.DB.GetObjectiveTemplateByID(templateID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if existing == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "template not found")
+	}
+
+	err = h.deps.DB.UpdateObjectiveTemplate(templateID, req.Name, req.Title, req.Description, req.Hat, req.Checklist)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	template, err := h.deps.DB.GetObjectiveTemplateByID(templateID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, objectiveTemplateResponse(template))
+}
+
+// HandleDelete deletes an objective template.
+// DELETE /api/v1/objective-templates/:id
+func (h *ObjectiveTemplatesHandler) HandleDelete(c echo.Context) error {
+	templateID := c.Param("id")
+
+	err := h.deps.DB.DeleteObjectiveTemplate(templateID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "template deleted",
+	})
+}
+
+// objectiveTemplateResponse converts a template to its JSON representation
+func objectiveTemplateResponse(t *db.ObjectiveTemplate) map[string]any {
+	checklist := t.Checklist
+	if checklist == nil {
+		checklist = []string{}
+	}
+	return map[string]any{
+		"id":          t.ID,
+		"project_id":  t.ProjectID,
+		"name":        t.Name,
+		"title":       t.Title,
+		"description": t.Description.String,
+		"hat":         t.Hat.String,
+		"checklist":   checklist,
+		"created_at":  t.CreatedAt,
+	}
+}
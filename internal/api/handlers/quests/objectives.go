@@ -8,9 +8,11 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
 	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/orchestrator"
 	"github.com/lirancohen/dex/internal/quest"
 	"github.com/lirancohen/dex/internal/realtime"
 	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/session"
 )
 
 // ObjectivesHandler handles objective-related HTTP requests.
@@ -132,11 +134,16 @@ func (h *ObjectivesHandler) HandleCreate(c echo.Context) error {
 		"task":    core.ToTaskResponseWithBlocking(createdTask, blockerIDs),
 	}
 
-	// Auto-start if requested and not blocked (derived from dependencies)
+	// Auto-start if requested and not blocked (derived from dependencies),
+	// unless the quest starts every accepted objective regardless
 	isBlocked := len(blockerIDs) > 0
-	if req.AutoStart && !isBlocked {
+	if req.AutoStart && (!isBlocked || startsAllObjectives(questObj)) {
 		startResult, err := h.deps.StartTaskInternal(context.Background(), createdTask.ID, "")
-		if err != nil {
+		if orchestrator.IsQueued(err) {
+			// The quest or repo is at its limit; the scheduler starts it later
+			response["auto_start_queued"] = true
+			response["auto_start_message"] = err.Error()
+		} else if err != nil {
 			response["auto_start_error"] = err.Error()
 			fmt.Printf("auto-start failed for task %s: %v\n", createdTask.ID, err)
 		} else {
@@ -261,9 +268,12 @@ func (h *ObjectivesHandler) HandleCreateBatch(c echo.Context) error {
 		taskResults[i]["task"] = core.ToTaskResponseWithBlocking(task, blockerIDs)
 	}
 
-	// Phase 3: Sync to GitHub and auto-start tasks that are not blocked
+	// Phase 3: Sync to GitHub and auto-start tasks that are not blocked. Starts
+	// beyond the quest's objective limit queue rather than fail.
 	var autoStarted []string
+	var autoStartQueued []string
 	var autoStartErrors []string
+	startAll := startsAllObjectives(questObj)
 
 	for i, task := range createdTasks {
 		// Sync to GitHub Issue (async)
@@ -275,9 +285,14 @@ func (h *ObjectivesHandler) HandleCreateBatch(c echo.Context) error {
 		if req.Drafts[i].AutoStart {
 			blockerIDs, _ := h.deps.DB.GetIncompleteBlockerIDs(task.ID)
 			isBlocked := len(blockerIDs) > 0
-			if !isBlocked {
-				startResult, err := h.deps.StartTaskInternal(context.Background(), task.ID, "")
-				if err != nil {
+			if !isBlocked || startAll {
+				// Batch-accepted objectives yield to sessions someone is watching
+				ctx := session.WithStartPriority(context.Background(), db.SessionPriorityLow)
+				startResult, err := h.deps.StartTaskInternal(ctx, task.ID, "")
+				if orchestrator.IsQueued(err) {
+					taskResults[i]["auto_start_queued"] = true
+					autoStartQueued = append(autoStartQueued, task.ID)
+				} else if err != nil {
 					autoStartErrors = append(autoStartErrors, fmt.Sprintf("%s: %v", task.Title, err))
 					fmt.Printf("auto-start failed for task %s: %v\n", task.ID, err)
 				} else {
@@ -311,9 +326,18 @@ func (h *ObjectivesHandler) HandleCreateBatch(c echo.Context) error {
 	if len(autoStarted) > 0 {
 		response["auto_started"] = autoStarted
 	}
+	if len(autoStartQueued) > 0 {
+		response["auto_start_queued"] = autoStartQueued
+	}
 	if len(autoStartErrors) > 0 {
 		response["auto_start_errors"] = autoStartErrors
 	}
 
 	return c.JSON(http.StatusCreated, response)
 }
+
+// startsAllObjectives reports whether the quest auto-starts accepted objectives
+// even while their blockers are incomplete, rather than in dependency order
+func startsAllObjectives(questObj *db.Quest) bool {
+	return questObj.ObjectiveStartMode == db.ObjectiveStartModeAll
+}
@@ -0,0 +1,78 @@
+// Package search provides the HTTP handler for searching across tasks, quests, and memories.
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// Handler handles search HTTP requests.
+type Handler struct {
+	deps *core.Deps
+}
+
+// New creates a new search handler.
+func New(deps *core.Deps) *Handler {
+	return &Handler{deps: deps}
+}
+
+// RegisterRoutes registers search routes on the given group.
+// All routes require authentication.
+//   - GET /search
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/search", h.HandleSearch)
+}
+
+// HandleSearch searches task titles and descriptions, quest messages, and memories.
+// GET /api/v1/search?q=...&kind=task,memory&project_id=...&limit=...
+// kind may be repeated or comma-separated and defaults to every kind.
+func (h *Handler) HandleSearch(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "query parameter 'q' is required")
+	}
+
+	opts := db.SearchOptions{ProjectID: c.QueryParam("project_id")}
+
+	for _, param := range c.QueryParams()["kind"] {
+		for _, kind := range strings.Split(param, ",") {
+			kind = strings.TrimSpace(kind)
+			switch kind {
+			case "":
+				continue
+			case db.SearchKindTask, db.SearchKindQuestMessage, db.SearchKindMemory:
+				opts.Kinds = append(opts.Kinds, kind)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+					"invalid kind %q (must be %s, %s, or %s)",
+					kind, db.SearchKindTask, db.SearchKindQuestMessage, db.SearchKindMemory))
+			}
+		}
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > db.MaxSearchLimit {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("limit must be between 1 and %d", db.MaxSearchLimit))
+		}
+		opts.Limit = l
+	}
+
+	results, err := h.deps.DB.Search(query, opts)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"query":   query,
+		"results": results,
+		"count":   len(results),
+	})
+}
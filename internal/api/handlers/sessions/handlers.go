@@ -2,11 +2,17 @@
 package sessions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/api/middleware"
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/realtime"
 	"github.com/lirancohen/dex/internal/session"
@@ -35,20 +41,37 @@ func (h *Handler) getTaskProjectID(taskID string) string {
 // RegisterRoutes registers all session routes on the given group.
 // All routes require authentication.
 //   - GET /sessions
+//   - GET /sessions/history
 //   - GET /sessions/:id
 //   - POST /sessions/:id/kill
+//   - PUT /sessions/:id/priority
+//   - GET /sessions/:id/inspect
+//   - POST /sessions/:id/checkpoint
 //   - GET /sessions/:id/activity
+//   - GET /sessions/:id/tool-metrics
+//   - GET /sessions/:id/checkpoints/:a/diff/:b
+//   - GET /metrics
 //   - POST /tasks/:id/pause
 //   - POST /tasks/:id/resume
 //   - POST /tasks/:id/cancel
 //   - GET /tasks/:id/logs
 //   - GET /tasks/:id/activity
+//   - GET /tasks/:id/hat-breakdown
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	// Session management
 	g.GET("/sessions", h.HandleList)
+	g.GET("/sessions/history", h.HandleHistory)
 	g.GET("/sessions/:id", h.HandleGet)
 	g.POST("/sessions/:id/kill", h.HandleKill)
+	g.PUT("/sessions/:id/priority", h.HandleSetPriority)
+	g.GET("/sessions/:id/inspect", h.HandleInspect)
+	g.POST("/sessions/:id/checkpoint", h.HandleCheckpoint)
 	g.GET("/sessions/:id/activity", h.HandleGetActivity)
+	g.GET("/sessions/:id/tool-metrics", h.HandleGetToolMetrics)
+	g.GET("/sessions/:id/checkpoints/:a/diff/:b", h.HandleCheckpointDiff)
+
+	// Metrics
+	g.GET("/metrics", h.HandleMetrics)
 
 	// Task session control
 	g.POST("/tasks/:id/pause", h.HandlePauseTask)
@@ -56,6 +79,7 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.POST("/tasks/:id/cancel", h.HandleCancelTask)
 	g.GET("/tasks/:id/logs", h.HandleTaskLogs)
 	g.GET("/tasks/:id/activity", h.HandleGetTaskActivity)
+	g.GET("/tasks/:id/hat-breakdown", h.HandleGetTaskHatBreakdown)
 }
 
 // HandleList returns all active sessions.
@@ -74,6 +98,64 @@ func (h *Handler) HandleList(c echo.Context) error {
 	})
 }
 
+// Limits on how many sessions HandleHistory returns
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// HandleHistory returns sessions that have ended, newest first, with why each
+// ended: its termination reason, an explanation, and the reason's category
+// (success, budget, blocked, error, or user).
+// Query params: task_id, category and reason (repeatable, either matches),
+// limit (default 100, max 1000).
+// GET /api/v1/sessions/history
+func (h *Handler) HandleHistory(c echo.Context) error {
+	limit := defaultHistoryLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxHistoryLimit {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("limit must be between 1 and %d", maxHistoryLimit))
+		}
+		limit = n
+	}
+
+	filter := db.SessionHistoryFilter{
+		TaskID:  c.QueryParam("task_id"),
+		Reasons: c.QueryParams()["reason"],
+		Limit:   limit,
+	}
+	for _, v := range c.QueryParams()["category"] {
+		category := session.TerminationCategory(v)
+		if !category.IsValid() {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown termination category: %s", v))
+		}
+		for _, reason := range category.Reasons() {
+			filter.Reasons = append(filter.Reasons, string(reason))
+		}
+	}
+
+	sessions, err := h.deps.DB.ListSessionHistory(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	responses := make([]core.SessionResponse, len(sessions))
+	categories := make(map[string]int)
+	for i, sess := range sessions {
+		inputTokens, outputTokens, _ := h.deps.DB.GetSessionTokensFromActivity(sess.ID)
+		responses[i] = core.ToSessionHistoryResponse(sess, inputTokens, outputTokens)
+		categories[responses[i].TerminationCategory]++
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"sessions":   responses,
+		"count":      len(responses),
+		"categories": categories,
+	})
+}
+
 // HandleGet returns a single session by ID.
 // GET /api/v1/sessions/:id
 func (h *Handler) HandleGet(c echo.Context) error {
@@ -87,6 +169,116 @@ func (h *Handler) HandleGet(c echo.Context) error {
 	return c.JSON(http.StatusOK, core.ToSessionResponse(sess))
 }
 
+// HandleSetPriority changes where a running session's API requests queue
+// behind the shared request gate: low, normal, or high. The next request the
+// session makes uses the new priority.
+// PUT /api/v1/sessions/:id/priority
+func (h *Handler) HandleSetPriority(c echo.Context) error {
+	sessionID := c.Param("id")
+
+	var req struct {
+		Priority string `json:"priority"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if err := db.ValidateSessionPriority(req.Priority); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	sess := h.deps.SessionManager.Get(sessionID)
+	if sess == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "session not found")
+	}
+
+	if err := h.deps.SessionManager.SetPriority(sessionID, req.Priority); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, core.ToSessionResponse(sess))
+}
+
+// HandleInspect returns a snapshot of a running session for debugging: its
+// recent messages, tools, last tool call, health, context usage, scratchpad,
+// and remaining budget. The loop publishes its state before each request to
+// the model and after each round of tool calls.
+// GET /api/v1/sessions/:id/inspect
+func (h *Handler) HandleInspect(c echo.Context) error {
+	sessionID := c.Param("id")
+
+	inspection := h.deps.SessionManager.Inspect(sessionID)
+	if inspection == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "session not found")
+	}
+
+	return c.JSON(http.StatusOK, inspection)
+}
+
+// checkpointWait is how long HandleCheckpoint waits for the session to reach
+// a safe point; an iteration's model request and tool calls can take minutes
+const checkpointWait = 2 * time.Minute
+
+// HandleCheckpoint makes a running session checkpoint at its next safe point,
+// between iterations, instead of waiting for the interval, and returns the
+// checkpoint. If the session doesn't get there within checkpointWait it
+// answers 202: the checkpoint is still taken, and shows up in its checkpoints.
+// POST /api/v1/sessions/:id/checkpoint
+func (h *Handler) HandleCheckpoint(c echo.Context) error {
+	sessionID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), checkpointWait)
+	defer cancel()
+
+	checkpoint, err := h.deps.SessionManager.RequestCheckpoint(ctx, sessionID)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return c.JSON(http.StatusAccepted, map[string]any{
+			"session_id": sessionID,
+			"status":     "pending",
+		})
+	case errors.Is(err, session.ErrSessionNotRunning), errors.Is(err, session.ErrSessionEnded):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case err != nil && strings.Contains(err.Error(), "not found"):
+		return echo.NewHTTPError(http.StatusNotFound, "session not found")
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"session_id":    sessionID,
+		"status":        "saved",
+		"checkpoint_id": checkpoint.ID,
+		"iteration":     checkpoint.Iteration,
+		"created_at":    checkpoint.CreatedAt,
+	})
+}
+
+// HandleGetToolMetrics returns the per-tool breakdown for a session.
+// GET /api/v1/sessions/:id/tool-metrics
+func (h *Handler) HandleGetToolMetrics(c echo.Context) error {
+	sessionID := c.Param("id")
+
+	tools := h.deps.SessionManager.ToolMetrics().SessionSnapshot(sessionID)
+	if tools == nil {
+		tools = []session.ToolStats{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"session_id": sessionID,
+		"tools":      tools,
+	})
+}
+
+// HandleMetrics returns aggregated tool metrics across all sessions.
+// Tools are ordered by total time spent, so the slowest show first.
+// GET /api/v1/metrics
+func (h *Handler) HandleMetrics(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"tools":                  h.deps.SessionManager.ToolMetrics().Snapshot(),
+		"slow_tool_threshold_ms": session.SlowToolThreshold.Milliseconds(),
+	})
+}
+
 // HandleKill forcefully stops a session.
 // POST /api/v1/sessions/:id/kill
 func (h *Handler) HandleKill(c echo.Context) error {
@@ -119,6 +311,12 @@ func (h *Handler) HandleKill(c echo.Context) error {
 func (h *Handler) HandlePauseTask(c echo.Context) error {
 	taskID := c.Param("id")
 
+	unlock, err := h.deps.TaskService.LockLifecycle(taskID, "pause")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	defer unlock()
+
 	sess := h.deps.SessionManager.GetByTask(taskID)
 	if sess == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "no active session for task")
@@ -146,62 +344,68 @@ func (h *Handler) HandlePauseTask(c echo.Context) error {
 	})
 }
 
-// HandleResumeTask resumes a paused session for a task.
+// HandleResumeTask resumes a paused task, whatever paused it. Budgets can be
+// topped up in the same request; topping up the exhausted one is required when
+// a budget caused the pause, and no top-up may exceed the project's budget cap.
+// Top-ups are recorded in the session's activity and the audit log.
 // POST /api/v1/tasks/:id/resume
 func (h *Handler) HandleResumeTask(c echo.Context) error {
 	taskID := c.Param("id")
 
-	// First check if there's an active session in memory
-	sess := h.deps.SessionManager.GetByTask(taskID)
-
-	if sess != nil {
-		// Active session exists - check that it's paused
-		if sess.State != session.StatePaused {
-			return echo.NewHTTPError(http.StatusBadRequest, "session is not paused")
-		}
-
-		if err := h.deps.SessionManager.Start(c.Request().Context(), sess.ID); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-		}
-	} else {
-		// No active session - check if task is paused and recreate session
-		task, err := h.deps.DB.GetTaskByID(taskID)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get task: %v", err))
-		}
-		if task == nil {
-			return echo.NewHTTPError(http.StatusNotFound, "task not found")
-		}
-		if task.Status != db.TaskStatusPaused {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("task is not paused (status: %s)", task.Status))
-		}
-
-		// Get the last session for this task to restore hat and worktree path
-		sessions, err := h.deps.DB.ListSessionsByTask(taskID)
-		if err != nil || len(sessions) == 0 {
-			return echo.NewHTTPError(http.StatusBadRequest, "no previous session found for task")
-		}
-		lastSession := sessions[0] // Most recent first
-
-		// Create a new session with the same parameters
-		newSess, err := h.deps.SessionManager.CreateSession(taskID, lastSession.Hat, lastSession.WorktreePath)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to create session: %v", err))
-		}
+	// Top-ups for the budget that paused the task; the resumed session's
+	// limits are raised by these amounts past what it has already used
+	var req struct {
+		AdditionalIterations     int     `json:"additional_iterations"`
+		AdditionalTokens         int64   `json:"additional_tokens"`
+		AdditionalDollars        float64 `json:"additional_dollars"`
+		AdditionalRuntimeMinutes int     `json:"additional_runtime_minutes"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.AdditionalIterations < 0 || req.AdditionalTokens < 0 || req.AdditionalDollars < 0 || req.AdditionalRuntimeMinutes < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "budget top-ups must not be negative")
+	}
 
-		// Set the session to restore from the previous session's checkpoint
-		newSess.RestoreFromSessionID = lastSession.ID
+	unlock, err := h.deps.TaskService.LockLifecycle(taskID, "resume")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	defer unlock()
 
-		if err := h.deps.SessionManager.Start(c.Request().Context(), newSess.ID); err != nil {
+	ext := session.BudgetExtension{
+		Iterations: req.AdditionalIterations,
+		Tokens:     req.AdditionalTokens,
+		Dollars:    req.AdditionalDollars,
+		Runtime:    time.Duration(req.AdditionalRuntimeMinutes) * time.Minute,
+	}
+	sess, err := h.deps.SessionManager.Resume(taskID, ext)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, session.ErrNotPaused), errors.Is(err, session.ErrBudgetExtensionRequired),
+			errors.Is(err, session.ErrBudgetCapExceeded):
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, session.ErrNoCheckpoint), errors.Is(err, session.ErrTaskCostCeiling),
+			errors.Is(err, session.ErrSessionStopping), errors.Is(err, session.ErrWorktreeCleanedUp),
+			errors.Is(err, session.ErrResumeRaced):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
-
-		sess = newSess
 	}
 
-	// Update task status in database
-	if err := h.deps.TaskService.UpdateStatus(taskID, "running"); err != nil {
-		fmt.Printf("warning: failed to update task status to running: %v\n", err)
+	if !ext.IsZero() {
+		if _, err := h.deps.DB.CreateAuditEntry(middleware.GetUserID(c), db.AuditActionBudgetTopUp, "task", taskID, map[string]any{
+			"session_id":                 sess.ID,
+			"additional_iterations":      req.AdditionalIterations,
+			"additional_tokens":          req.AdditionalTokens,
+			"additional_dollars":         req.AdditionalDollars,
+			"additional_runtime_minutes": req.AdditionalRuntimeMinutes,
+		}); err != nil {
+			fmt.Printf("warning: failed to audit budget top-up for task %s: %v\n", taskID, err)
+		}
 	}
 
 	if h.deps.Broadcaster != nil {
@@ -212,8 +416,9 @@ func (h *Handler) HandleResumeTask(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, map[string]any{
-		"message": "task resumed",
-		"task_id": taskID,
+		"message":    "task resumed",
+		"task_id":    taskID,
+		"session_id": sess.ID,
 	})
 }
 
@@ -222,6 +427,12 @@ func (h *Handler) HandleResumeTask(c echo.Context) error {
 func (h *Handler) HandleCancelTask(c echo.Context) error {
 	taskID := c.Param("id")
 
+	unlock, err := h.deps.TaskService.LockLifecycle(taskID, "cancel")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	defer unlock()
+
 	sess := h.deps.SessionManager.GetByTask(taskID)
 	if sess == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "no active session for task")
@@ -242,6 +453,11 @@ func (h *Handler) HandleCancelTask(c echo.Context) error {
 		})
 	}
 
+	// Cancelling a quest's last unfinished task can complete the quest
+	if t, err := h.deps.DB.GetTaskByID(taskID); err == nil && t != nil && t.QuestID.Valid && h.deps.AutoCompleteQuest != nil {
+		h.deps.AutoCompleteQuest(t.QuestID.String)
+	}
+
 	return c.JSON(http.StatusOK, map[string]any{
 		"message": "task cancelled",
 		"task_id": taskID,
@@ -310,6 +526,55 @@ func (h *Handler) HandleGetActivity(c echo.Context) error {
 	})
 }
 
+// HandleCheckpointDiff returns what changed in a session between two checkpoints.
+// Checkpoints are given by ID or by iteration number.
+// GET /api/v1/sessions/:id/checkpoints/:a/diff/:b
+func (h *Handler) HandleCheckpointDiff(c echo.Context) error {
+	sessionID := c.Param("id")
+
+	sess, err := h.deps.DB.GetSessionByID(sessionID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if sess == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "session not found")
+	}
+
+	from, err := h.getCheckpoint(sessionID, c.Param("a"))
+	if err != nil {
+		return err
+	}
+	to, err := h.getCheckpoint(sessionID, c.Param("b"))
+	if err != nil {
+		return err
+	}
+
+	diff, err := session.DiffCheckpoints(from, to)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// getCheckpoint looks up a session checkpoint by ID, or by iteration if ref is a number
+func (h *Handler) getCheckpoint(sessionID, ref string) (*db.SessionCheckpoint, error) {
+	var checkpoint *db.SessionCheckpoint
+	var err error
+	if iteration, convErr := strconv.Atoi(ref); convErr == nil {
+		checkpoint, err = h.deps.DB.GetSessionCheckpointByIteration(sessionID, iteration)
+	} else {
+		checkpoint, err = h.deps.DB.GetSessionCheckpoint(sessionID, ref)
+	}
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if checkpoint == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("checkpoint %s not found", ref))
+	}
+	return checkpoint, nil
+}
+
 // HandleGetTaskActivity returns all activity for all sessions of a task.
 // GET /api/v1/tasks/:id/activity
 func (h *Handler) HandleGetTaskActivity(c echo.Context) error {
@@ -359,3 +624,31 @@ func (h *Handler) HandleGetTaskActivity(c echo.Context) error {
 		},
 	})
 }
+
+// HandleGetTaskHatBreakdown returns the tokens, cost, iterations, and time a
+// task spent under each hat, across all its sessions.
+// GET /api/v1/tasks/:id/hat-breakdown
+func (h *Handler) HandleGetTaskHatBreakdown(c echo.Context) error {
+	taskID := c.Param("id")
+
+	task, err := h.deps.TaskService.Get(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if task == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "task not found")
+	}
+
+	hats, err := h.deps.DB.GetTaskHatBreakdown(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if hats == nil {
+		hats = []*db.HatUsage{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"task_id": taskID,
+		"hats":    hats,
+	})
+}
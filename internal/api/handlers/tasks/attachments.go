@@ -0,0 +1,115 @@
+package tasks
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// HandleUploadAttachment attaches a file to a task, sent as the "file" field of
+// a multipart form. A file with the same name replaces the existing attachment.
+// Sessions started afterwards find it in .dex/context/ in their worktree.
+// POST /api/v1/tasks/:id/attachments
+func (h *Handler) HandleUploadAttachment(c echo.Context) error {
+	taskID := c.Param("id")
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "file is required")
+	}
+	if header.Size > db.MaxAttachmentSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("attachment is larger than %d bytes", db.MaxAttachmentSize))
+	}
+	name, err := db.NormalizeAttachmentName(header.Filename)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read file")
+	}
+	defer func() { _ = file.Close() }()
+	content, err := io.ReadAll(io.LimitReader(file, db.MaxAttachmentSize+1))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read file")
+	}
+	if len(content) > db.MaxAttachmentSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("attachment is larger than %d bytes", db.MaxAttachmentSize))
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(content)
+	}
+
+	attachment, err := h.deps.DB.SaveTaskAttachment(taskID, name, contentType, content)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, attachment)
+}
+
+// HandleListAttachments returns a task's attachments, without their content.
+// GET /api/v1/tasks/:id/attachments
+func (h *Handler) HandleListAttachments(c echo.Context) error {
+	taskID := c.Param("id")
+
+	t, err := h.deps.DB.GetTaskByID(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if t == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "task not found")
+	}
+
+	attachments, err := h.deps.DB.ListTaskAttachments(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"task_id":     taskID,
+		"attachments": attachments,
+		"count":       len(attachments),
+	})
+}
+
+// HandleGetAttachment downloads an attachment's content.
+// GET /api/v1/tasks/:id/attachments/:attachmentId
+func (h *Handler) HandleGetAttachment(c echo.Context) error {
+	attachment, err := h.deps.DB.GetTaskAttachment(c.Param("id"), c.Param("attachmentId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.Name))
+	return c.Blob(http.StatusOK, attachment.ContentType, attachment.Content)
+}
+
+// HandleDeleteAttachment removes an attachment from a task. Sessions already
+// running keep their copy.
+// DELETE /api/v1/tasks/:id/attachments/:attachmentId
+func (h *Handler) HandleDeleteAttachment(c echo.Context) error {
+	taskID := c.Param("id")
+
+	if err := h.deps.DB.DeleteTaskAttachment(taskID, c.Param("attachmentId")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
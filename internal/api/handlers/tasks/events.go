@@ -0,0 +1,131 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/task"
+)
+
+// eventStreamKeepalive is how often an idle event stream sends a comment so
+// proxies and clients don't time it out. The task's status is checked again
+// each time, in case the event that finished it was dropped.
+const eventStreamKeepalive = 15 * time.Second
+
+// eventTaskStatus is the stream's own event carrying the task's current status.
+// It's sent first, and again as the last event once the task is done.
+const eventTaskStatus = "task.status"
+
+// HandleEvents streams one task's lifecycle and activity events as
+// server-sent events, closing the stream once the task reaches a terminal
+// status. It carries the same events the WebSocket publishes for the task.
+// GET /api/v1/tasks/:id/events
+func (h *Handler) HandleEvents(c echo.Context) error {
+	id := c.Param("id")
+
+	if h.deps.Broadcaster == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "realtime events not configured")
+	}
+
+	// Subscribe before reading the status so no transition falls in between
+	events, cancel := h.deps.Broadcaster.Subscribe(realtime.TaskEvents(id))
+	defer cancel()
+
+	t, err := h.deps.TaskService.Get(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("Connection", "keep-alive")
+	resp.Header().Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+
+	if err := writeTaskStatus(resp, id, t.Status); err != nil || task.IsTerminalStatus(t.Status) {
+		return nil
+	}
+
+	keepalive := time.NewTicker(eventStreamKeepalive)
+	defer keepalive.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-keepalive.C:
+			// A slow client can miss the event that finished the task
+			if h.taskFinished(resp, id) {
+				return nil
+			}
+			if _, err := fmt.Fprint(resp, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+			resp.Flush()
+
+		case event := <-events:
+			if err := writeEvent(resp, event.Type, event.Payload); err != nil {
+				return nil
+			}
+			if changesTaskStatus(event.Type) && h.taskFinished(resp, id) {
+				return nil
+			}
+		}
+	}
+}
+
+// taskFinished reads the task's status back, since events don't all carry it,
+// and reports whether the stream is done: the task reached a terminal status,
+// which is sent as the last event, or was deleted while we were watching.
+func (h *Handler) taskFinished(resp *echo.Response, id string) bool {
+	t, err := h.deps.TaskService.Get(id)
+	if err != nil {
+		return true
+	}
+	if task.IsTerminalStatus(t.Status) {
+		_ = writeTaskStatus(resp, id, t.Status)
+		return true
+	}
+	return false
+}
+
+// changesTaskStatus reports whether an event may follow a change to the task's status
+func changesTaskStatus(eventType string) bool {
+	switch eventType {
+	case realtime.EventSessionCompleted, realtime.EventSessionKilled,
+		realtime.EventWorkerCompleted, realtime.EventWorkerFailed,
+		realtime.EventWorkerTimedOut, realtime.EventWorkerRejected:
+		return true
+	}
+	return strings.HasPrefix(eventType, "task.")
+}
+
+// writeTaskStatus sends the stream's task.status event
+func writeTaskStatus(resp *echo.Response, taskID, status string) error {
+	return writeEvent(resp, eventTaskStatus, map[string]any{
+		"task_id":  taskID,
+		"status":   status,
+		"terminal": task.IsTerminalStatus(status),
+	})
+}
+
+// writeEvent writes one server-sent event and flushes it to the client
+func writeEvent(resp *echo.Response, eventType string, payload map[string]any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", eventType, data); err != nil {
+		return err
+	}
+	resp.Flush()
+	return nil
+}
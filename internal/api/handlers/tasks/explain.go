@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/session"
+)
+
+// HandleExplain returns a short explanation of how Dex would approach a task,
+// with a confidence and the risks, written by Haiku from the prompt a session
+// would start with. No session starts and no tools run. The explanation is
+// cached until the prompt changes; ?refresh=true writes a new one. ?hat= explains
+// a session in another hat than the one the task would start in.
+// POST /api/v1/tasks/:id/explain?hat=...&refresh=true
+func (h *Handler) HandleExplain(c echo.Context) error {
+	if h.deps.SessionManager == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "session manager not configured")
+	}
+
+	var refresh bool
+	if param := c.QueryParam("refresh"); param != "" {
+		var err error
+		if refresh, err = strconv.ParseBool(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid refresh %q", param))
+		}
+	}
+
+	hat := c.QueryParam("hat")
+	if hat != "" && !slices.Contains(session.ValidHats, hat) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"unknown hat %q (must be one of %s)", hat, strings.Join(session.ValidHats, ", ")))
+	}
+
+	taskID := c.Param("id")
+	if hat == "" {
+		t, err := h.deps.TaskService.Get(taskID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		hat = h.deps.ResolveStartingHat(t)
+	}
+
+	explanation, err := h.deps.SessionManager.ExplainTask(c.Request().Context(), taskID, hat, refresh)
+	if err != nil {
+		if errors.Is(err, session.ErrNoAnthropicClient) {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, explanation)
+}
@@ -3,14 +3,20 @@ package tasks
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
 	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/orchestrator"
+	"github.com/lirancohen/dex/internal/realtime"
 	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/session"
 	"github.com/lirancohen/dex/internal/task"
 )
 
@@ -32,7 +38,23 @@ func New(deps *core.Deps) *Handler {
 //   - PUT /tasks/:id
 //   - DELETE /tasks/:id
 //   - POST /tasks/:id/start
+//   - POST /tasks/:id/address-review
+//   - POST /tasks/:id/clone
+//   - GET /tasks/:id/clones
+//   - POST /tasks/:id/move
+//   - GET /tasks/:id/events
+//   - GET /tasks/:id/report
+//   - POST /tasks/:id/explain
+//   - POST /tasks/:id/tags
+//   - DELETE /tasks/:id/tags/:tag
+//   - GET /tasks/:id/attachments
+//   - POST /tasks/:id/attachments
+//   - GET /tasks/:id/attachments/:attachmentId
+//   - DELETE /tasks/:id/attachments/:attachmentId
 //   - GET /tasks/:id/worktree/status
+//   - GET /tasks/:id/worktree/diff
+//   - POST /tasks/:id/worktree/revert
+//   - GET /tags
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/tasks", h.HandleList)
 	g.POST("/tasks", h.HandleCreate)
@@ -40,16 +62,47 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.PUT("/tasks/:id", h.HandleUpdate)
 	g.DELETE("/tasks/:id", h.HandleDelete)
 	g.POST("/tasks/:id/start", h.HandleStart)
+	g.POST("/tasks/:id/address-review", h.HandleAddressReview)
+	g.POST("/tasks/:id/clone", h.HandleClone)
+	g.GET("/tasks/:id/clones", h.HandleListClones)
+	g.POST("/tasks/:id/move", h.HandleMove)
+	g.GET("/tasks/:id/events", h.HandleEvents)
+	g.GET("/tasks/:id/report", h.HandleReport)
+	g.POST("/tasks/:id/explain", h.HandleExplain)
+	g.POST("/tasks/:id/tags", h.HandleAddTags)
+	g.DELETE("/tasks/:id/tags/:tag", h.HandleRemoveTag)
+	g.GET("/tasks/:id/attachments", h.HandleListAttachments)
+	g.POST("/tasks/:id/attachments", h.HandleUploadAttachment)
+	g.GET("/tasks/:id/attachments/:attachmentId", h.HandleGetAttachment)
+	g.DELETE("/tasks/:id/attachments/:attachmentId", h.HandleDeleteAttachment)
 	g.GET("/tasks/:id/worktree/status", h.HandleWorktreeStatus)
+	g.GET("/tasks/:id/worktree/diff", h.HandleWorktreeDiff)
+	g.POST("/tasks/:id/worktree/revert", h.HandleWorktreeRevert)
+	g.GET("/tags", h.HandleListTags)
 }
 
-// HandleList returns tasks with optional filters.
-// GET /api/v1/tasks?project_id=...&status=...
+// HandleList returns tasks with optional filters. Tags can be repeated or
+// comma-separated; tasks must have all of them.
+// GET /api/v1/tasks?project_id=...&status=...&tag=...&blocked_reason=...
 func (h *Handler) HandleList(c echo.Context) error {
 	filters := task.ListFilters{
 		ProjectID: c.QueryParam("project_id"),
 		Status:    c.QueryParam("status"),
 	}
+	if reason := c.QueryParam("blocked_reason"); reason != "" {
+		if !db.IsValidBlockedReason(reason) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid blocked_reason %q (must be one of %s)", reason, strings.Join(db.BlockedReasonCategories, ", ")))
+		}
+		filters.BlockedReason = reason
+	}
+	for _, param := range c.QueryParams()["tag"] {
+		for _, tag := range strings.Split(param, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filters.Tags = append(filters.Tags, tag)
+			}
+		}
+	}
 
 	tasks, err := h.deps.TaskService.List(filters)
 	if err != nil {
@@ -65,6 +118,8 @@ func (h *Handler) HandleList(c echo.Context) error {
 		if inputTokens, outputTokens, err := h.deps.DB.GetTaskTokensFromActivity(t.ID); err == nil {
 			taskResponses[i].SetTokensFromActivity(inputTokens, outputTokens)
 		}
+		taskResponses[i].Tags, _ = h.deps.DB.GetTaskTags(t.ID)
+		taskResponses[i].BlockedReason, _ = h.deps.DB.GetTaskBlockedReason(t.ID)
 	}
 
 	return c.JSON(http.StatusOK, map[string]any{
@@ -74,7 +129,11 @@ func (h *Handler) HandleList(c echo.Context) error {
 }
 
 // HandleCreate creates a new task.
-// POST /api/v1/tasks?skip_planning=true
+// With auto_start set and planning skipped, the task is started in the same call
+// and the response includes its session_id.
+// An open task in the project that looks like the same work fails the create
+// with 409 and the likely duplicates, unless force is set.
+// POST /api/v1/tasks?skip_planning=true&force=true
 func (h *Handler) HandleCreate(c echo.Context) error {
 	var req struct {
 		ProjectID   any    `json:"project_id"`
@@ -82,10 +141,70 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		Description string `json:"description"`
 		Type        string `json:"type"`
 		Priority    int    `json:"priority"`
+
+		// Optional completion verification override (defaults to the project's policy)
+		CompletionPolicy *db.CompletionPolicy `json:"completion_policy"`
+
+		// Optional activity level override, "standard" or "debug" (defaults to the project's level)
+		ActivityLevel string `json:"activity_level"`
+
+		// Optional prompt sensitivity override, "off", "normal", or "strict" (defaults to the project's level)
+		PromptSensitivity string `json:"prompt_sensitivity"`
+
+		// Optional extended thinking budget in tokens, 0 to turn thinking off (defaults to each hat's budget)
+		ThinkingBudget *int `json:"thinking_budget"`
+
+		// Optional models to fall back to when the task's is over capacity, e.g.
+		// "sonnet", or "off" (defaults to the project's chain)
+		ModelFallback string `json:"model_fallback"`
+
+		// Optional labels, e.g. "flaky" or "customer-123"
+		Tags []string `json:"tags"`
+
+		// Optional predecessor task whose worktree and branch this task continues in
+		ReuseWorktreeFrom string `json:"reuse_worktree_from"`
+
+		// Optional objective template supplying defaults for title, description, hat, and checklist
+		TemplateID string `json:"template_id"`
+
+		// Start the task immediately when it does not enter planning
+		AutoStart bool `json:"auto_start"`
+
+		// Optional overrides of the project's default model and autonomy level
+		Model         string `json:"model"`
+		AutonomyLevel *int   `json:"autonomy_level"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
+	if req.CompletionPolicy != nil {
+		if err := req.CompletionPolicy.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.ActivityLevel != "" {
+		if err := db.ValidateActivityLevel(req.ActivityLevel); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.PromptSensitivity != "" {
+		if _, err := security.ParseSensitivity(req.PromptSensitivity); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if req.ThinkingBudget != nil {
+		if err := db.ValidateThinkingBudget(*req.ThinkingBudget); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if _, err := db.ParseModelFallback(req.ModelFallback); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	for _, tag := range req.Tags {
+		if _, err := db.NormalizeTag(tag); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
 
 	skipPlanning := c.QueryParam("skip_planning") == "true"
 
@@ -95,6 +214,34 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		projectID = fmt.Sprintf("%v", req.ProjectID)
 	}
 
+	var template *db.ObjectiveTemplate
+	if req.TemplateID != "" {
+		var err error
+		template, err = h.deps.DB.GetObjectiveTemplateByID(req.TemplateID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if template == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "objective template not found")
+		}
+		if projectID == "" || projectID == "0" || projectID == "1" {
+			projectID = template.ProjectID
+		} else if projectID != template.ProjectID {
+			return echo.NewHTTPError(http.StatusBadRequest, "objective template belongs to a different project")
+		}
+
+		if req.Title == "" {
+			req.Title = template.Title
+		}
+		if req.Description == "" {
+			req.Description = template.Description.String
+		}
+		// A template's checklist replaces the one planning would produce
+		if len(template.Checklist) > 0 {
+			skipPlanning = true
+		}
+	}
+
 	if projectID == "" || projectID == "0" || projectID == "1" {
 		project, err := h.deps.DB.GetOrCreateDefaultProject()
 		if err != nil {
@@ -103,11 +250,44 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		projectID = project.ID
 	}
 
+	if req.ReuseWorktreeFrom != "" {
+		predecessor, err := h.deps.DB.GetTaskByID(req.ReuseWorktreeFrom)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if predecessor == nil || predecessor.ProjectID != projectID {
+			return echo.NewHTTPError(http.StatusBadRequest, "task to reuse worktree from not found in project")
+		}
+	}
+
 	// Sanitize user input
 	sanitizedTitle := security.SanitizeForPrompt(req.Title)
 	sanitizedDescription := security.SanitizeForPrompt(req.Description)
 
-	t, err := h.deps.TaskService.Create(projectID, sanitizedTitle, req.Type, req.Priority)
+	if c.QueryParam("force") != "true" {
+		duplicates, err := h.deps.TaskService.FindDuplicates(projectID, sanitizedTitle, sanitizedDescription)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if len(duplicates) > 0 {
+			candidates := make([]map[string]any, len(duplicates))
+			for i, d := range duplicates {
+				candidates[i] = map[string]any{
+					"task":       core.ToTaskResponse(d.Task),
+					"similarity": d.Similarity,
+				}
+			}
+			return c.JSON(http.StatusConflict, map[string]any{
+				"error":      "a similar open task already exists; retry with ?force=true to create it anyway",
+				"duplicates": candidates,
+			})
+		}
+	}
+
+	t, err := h.deps.TaskService.CreateWithOptions(projectID, sanitizedTitle, req.Type, req.Priority, task.CreateOptions{
+		Model:         req.Model,
+		AutonomyLevel: req.AutonomyLevel,
+	})
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
@@ -121,6 +301,54 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		}
 	}
 
+	if req.CompletionPolicy != nil {
+		if err := h.deps.DB.SetTaskCompletionPolicy(t.ID, req.CompletionPolicy); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set completion policy")
+		}
+	}
+
+	if req.ActivityLevel != "" {
+		if err := h.deps.DB.SetTaskActivityLevel(t.ID, req.ActivityLevel); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set activity level")
+		}
+	}
+
+	if req.PromptSensitivity != "" {
+		if err := h.deps.DB.SetTaskPromptSensitivity(t.ID, req.PromptSensitivity); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set prompt sensitivity")
+		}
+	}
+
+	if req.ThinkingBudget != nil {
+		if err := h.deps.DB.SetTaskThinkingBudget(t.ID, req.ThinkingBudget); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set thinking budget")
+		}
+	}
+
+	if req.ModelFallback != "" {
+		if err := h.deps.DB.SetTaskModelFallback(t.ID, req.ModelFallback); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set model fallback")
+		}
+	}
+
+	if len(req.Tags) > 0 {
+		if err := h.deps.DB.AddTaskTags(t.ID, req.Tags); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set tags")
+		}
+	}
+
+	if req.ReuseWorktreeFrom != "" {
+		if err := h.deps.DB.SetTaskReuseWorktreeFrom(t.ID, req.ReuseWorktreeFrom); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to set worktree reuse")
+		}
+	}
+
+	if template != nil {
+		if t, err = h.applyObjectiveTemplate(t, template); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	// Start planning phase if planner is available and skip_planning is not set
 	if h.deps.Planner != nil && !skipPlanning {
 		planningPrompt := sanitizedDescription
@@ -141,7 +369,72 @@ func (h *Handler) HandleCreate(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusCreated, core.ToTaskResponse(t))
+	resp := core.ToTaskResponse(t)
+	resp.CompletionPolicy = req.CompletionPolicy
+	resp.ActivityLevel = req.ActivityLevel
+	resp.PromptSensitivity = req.PromptSensitivity
+	resp.ThinkingBudget = req.ThinkingBudget
+	resp.ModelFallback, _ = h.deps.DB.GetTaskModelFallback(t.ID)
+	resp.Tags, _ = h.deps.DB.GetTaskTags(t.ID)
+	resp.ReuseWorktreeFrom = req.ReuseWorktreeFrom
+
+	if !req.AutoStart {
+		return c.JSON(http.StatusCreated, resp)
+	}
+
+	// Auto-start: respond like objective creation, with the session alongside the task
+	response := map[string]any{
+		"message": "task created",
+		"task":    resp,
+	}
+	if t.Status == db.TaskStatusPlanning {
+		response["auto_start_error"] = "task entered planning; start it once planning completes"
+	} else if startResult, err := h.deps.StartTaskInternal(context.Background(), t.ID, ""); err != nil {
+		response["auto_start_error"] = err.Error()
+		fmt.Printf("auto-start failed for task %s: %v\n", t.ID, err)
+	} else {
+		started := core.ToTaskResponse(startResult.Task)
+		started.CompletionPolicy = req.CompletionPolicy
+		started.ActivityLevel = req.ActivityLevel
+		started.PromptSensitivity = req.PromptSensitivity
+		started.ThinkingBudget = req.ThinkingBudget
+		started.Tags = resp.Tags
+		started.ReuseWorktreeFrom = req.ReuseWorktreeFrom
+		response["task"] = started
+		response["worktree_path"] = startResult.WorktreePath
+		response["session_id"] = startResult.SessionID
+		response["auto_started"] = true
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// applyObjectiveTemplate sets the template's hat and creates its checklist on a new task.
+func (h *Handler) applyObjectiveTemplate(t *db.Task, template *db.ObjectiveTemplate) (*db.Task, error) {
+	if template.Hat.Valid && template.Hat.String != "" {
+		hat := template.Hat.String
+		updated, err := h.deps.TaskService.Update(t.ID, task.TaskUpdates{Hat: &hat})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set hat: %w", err)
+		}
+		t = updated
+	}
+
+	if len(template.Checklist) == 0 {
+		return t, nil
+	}
+
+	checklist, err := h.deps.DB.CreateTaskChecklist(t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checklist: %w", err)
+	}
+	for sortOrder, item := range template.Checklist {
+		if _, err := h.deps.DB.CreateChecklistItem(checklist.ID, item, sortOrder); err != nil {
+			return nil, fmt.Errorf("failed to create checklist item: %w", err)
+		}
+	}
+
+	return t, nil
 }
 
 // HandleGet returns a single task by ID.
@@ -162,6 +455,15 @@ func (h *Handler) HandleGet(c echo.Context) error {
 	if inputTokens, outputTokens, err := h.deps.DB.GetTaskTokensFromActivity(t.ID); err == nil {
 		resp.SetTokensFromActivity(inputTokens, outputTokens)
 	}
+	resp.CompletionPolicy, _ = h.deps.DB.GetTaskCompletionPolicy(t.ID)
+	resp.ActivityLevel, _ = h.deps.DB.GetTaskActivityLevel(t.ID)
+	resp.PromptSensitivity, _ = h.deps.DB.GetTaskPromptSensitivity(t.ID)
+	resp.ThinkingBudget, _ = h.deps.DB.GetTaskThinkingBudget(t.ID)
+	resp.ModelFallback, _ = h.deps.DB.GetTaskModelFallback(t.ID)
+	resp.Tags, _ = h.deps.DB.GetTaskTags(t.ID)
+	resp.BlockedReason, _ = h.deps.DB.GetTaskBlockedReason(t.ID)
+	resp.ClonedFrom, _ = h.deps.DB.GetTaskClonedFrom(t.ID)
+	resp.ReuseWorktreeFrom, _ = h.deps.DB.GetTaskReuseWorktreeFrom(t.ID)
 
 	return c.JSON(http.StatusOK, resp)
 }
@@ -181,7 +483,10 @@ func (h *Handler) HandleUpdate(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, core.ToTaskResponse(updated))
+	resp := core.ToTaskResponse(updated)
+	resp.CompletionPolicy, _ = h.deps.DB.GetTaskCompletionPolicy(id)
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 // HandleDelete removes a task.
@@ -197,12 +502,20 @@ func (h *Handler) HandleDelete(c echo.Context) error {
 }
 
 // HandleStart transitions a task to running and sets up its worktree.
+// While the scheduler is paused, or the task's repo is at its concurrent task
+// limit, the task is queued instead (202 Accepted). A task set to reuse a
+// predecessor's worktree continues in it, or fails with 409 Conflict if
+// another unfinished task is working there. With ?interactive=true the
+// session's API requests go ahead of other sessions' at the request gate.
 // POST /api/v1/tasks/:id/start
 func (h *Handler) HandleStart(c echo.Context) error {
 	taskID := c.Param("id")
 
 	var req struct {
 		BaseBranch string `json:"base_branch"`
+
+		// Optional predecessor task whose worktree and branch to continue in
+		ReuseWorktreeFrom string `json:"reuse_worktree_from"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
@@ -217,12 +530,35 @@ func (h *Handler) HandleStart(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("task is blocked by incomplete dependencies: %v", blockerIDs))
 	}
 
-	result, err := h.deps.StartTaskInternal(context.Background(), taskID, req.BaseBranch)
+	if req.ReuseWorktreeFrom != "" {
+		if err := h.deps.DB.SetTaskReuseWorktreeFrom(taskID, req.ReuseWorktreeFrom); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Someone watching an interactive task gets its requests ahead of batch work
+	ctx := context.Background()
+	if c.QueryParam("interactive") == "true" {
+		ctx = session.WithStartPriority(ctx, db.SessionPriorityHigh)
+	}
+
+	result, err := h.deps.StartTaskInternal(ctx, taskID, req.BaseBranch)
 	if err != nil {
+		if orchestrator.IsQueued(err) {
+			return c.JSON(http.StatusAccepted, map[string]any{
+				"message": err.Error(),
+				"task_id": taskID,
+				"queued":  true,
+			})
+		}
 		if strings.Contains(err.Error(), "not found") {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
-		if strings.Contains(err.Error(), "already has a worktree") {
+		if strings.Contains(err.Error(), "already has a worktree") || errors.Is(err, session.ErrTaskCostCeiling) ||
+			errors.Is(err, db.ErrWorktreeInUse) || errors.Is(err, task.ErrTaskBusy) || errors.Is(err, core.ErrWorktreeReuse) {
 			return echo.NewHTTPError(http.StatusConflict, err.Error())
 		}
 		if strings.Contains(err.Error(), "not configured") {
@@ -238,6 +574,303 @@ func (h *Handler) HandleStart(c echo.Context) error {
 	})
 }
 
+// HandleAddressReview resumes a task to address the review feedback on its PR.
+// POST /api/v1/tasks/:id/address-review
+func (h *Handler) HandleAddressReview(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		Hat string `json:"hat"` // "creator" (default) or "editor"
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	unlock, err := h.deps.TaskService.LockLifecycle(taskID, "resume")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	defer unlock()
+
+	sess, err := h.deps.SessionManager.AddressReview(c.Request().Context(), taskID, req.Hat)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, session.ErrInvalidReviewHat):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, session.ErrNoPullRequest), errors.Is(err, session.ErrPRNotOpen),
+			errors.Is(err, session.ErrNoReviewFeedback), errors.Is(err, session.ErrTaskHasActiveSession),
+			errors.Is(err, session.ErrWorktreeCleanedUp), errors.Is(err, session.ErrTaskCostCeiling):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case errors.Is(err, session.ErrReviewProviderUnsupported):
+			return echo.NewHTTPError(http.StatusNotImplemented, err.Error())
+		case errors.Is(err, session.ErrReviewProviderNotConfigured):
+			return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishTaskEvent(realtime.EventTaskResumed, taskID, map[string]any{
+			"session_id": sess.ID,
+			"project_id": sess.ProjectID,
+			"reason":     "address_review",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":    "addressing review feedback",
+		"task_id":    taskID,
+		"session_id": sess.ID,
+		"hat":        sess.Hat,
+	})
+}
+
+// HandleClone creates a pending copy of a task to re-run it or try a variant,
+// optionally with a new title or description. The copy keeps the original's
+// settings, budgets, and checklist (reset to pending), but not its worktree or
+// sessions, and links back to it through ClonedFrom.
+// POST /api/v1/tasks/:id/clone
+func (h *Handler) HandleClone(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		Title       string  `json:"title"`
+		Description *string `json:"description"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	opts := db.CloneTaskOptions{Title: security.SanitizeForPrompt(req.Title)}
+	if req.Description != nil {
+		description := security.SanitizeForPrompt(*req.Description)
+		opts.Description = &description
+	}
+
+	t, err := h.deps.TaskService.Clone(taskID, opts)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := core.ToTaskResponse(t)
+	resp.CompletionPolicy, _ = h.deps.DB.GetTaskCompletionPolicy(t.ID)
+	resp.ActivityLevel, _ = h.deps.DB.GetTaskActivityLevel(t.ID)
+	resp.PromptSensitivity, _ = h.deps.DB.GetTaskPromptSensitivity(t.ID)
+	resp.ThinkingBudget, _ = h.deps.DB.GetTaskThinkingBudget(t.ID)
+	resp.ModelFallback, _ = h.deps.DB.GetTaskModelFallback(t.ID)
+	resp.ClonedFrom = taskID
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishTaskEvent(realtime.EventTaskCreated, t.ID, map[string]any{
+			"project_id":  t.ProjectID,
+			"title":       t.Title,
+			"cloned_from": taskID,
+		})
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// HandleMove reassigns a task to another project, keeping its sessions and
+// activity. The task can't be running; its worktree in the old project's repo
+// is removed, but the branch is kept there.
+// POST /api/v1/tasks/:id/move
+func (h *Handler) HandleMove(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		ProjectID string `json:"project_id"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.ProjectID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "project_id is required")
+	}
+
+	t, err := h.deps.TaskService.Get(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if t.ProjectID == req.ProjectID {
+		return echo.NewHTTPError(http.StatusBadRequest, "task is already in that project")
+	}
+	target, err := h.deps.DB.GetProjectByID(req.ProjectID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if target == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "project not found")
+	}
+	if h.deps.SessionManager != nil && h.deps.SessionManager.GetByTask(taskID) != nil {
+		return echo.NewHTTPError(http.StatusConflict, "task has an active session; pause or cancel it first")
+	}
+	if t.Status == db.TaskStatusRunning || t.Status == db.TaskStatusPlanning {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("cannot move a %s task; pause or cancel it first", t.Status))
+	}
+	// Checked before the worktree is removed, so a rejected move changes nothing
+	if err := h.deps.DB.CheckTaskLinksForMove(taskID, req.ProjectID); err != nil {
+		if errors.Is(err, db.ErrTaskLinkedAcrossProjects) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The worktree belongs to the old project's repo
+	if path := t.GetWorktreePath(); path != "" && h.deps.GitService != nil && h.deps.GitService.WorktreeExists(path) {
+		source, err := h.deps.DB.GetProjectByID(t.ProjectID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if source != nil {
+			if err := h.deps.GitService.CleanupTaskWorktree(source.RepoPath, taskID, false); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to remove worktree: %v", err))
+			}
+			if err := h.deps.DB.MarkTaskWorktreeCleaned(taskID); err != nil {
+				fmt.Printf("warning: failed to mark task %s worktree as cleaned: %v\n", taskID, err)
+			}
+		}
+	}
+
+	moved, err := h.deps.TaskService.Move(taskID, req.ProjectID)
+	if err != nil {
+		if errors.Is(err, task.ErrTaskNotMovable) || errors.Is(err, db.ErrTaskLinkedAcrossProjects) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	fmt.Printf("HandleMove: moved task %s from project %s to %s\n", taskID, t.ProjectID, req.ProjectID)
+
+	resp := core.ToTaskResponse(moved)
+	resp.CompletionPolicy, _ = h.deps.DB.GetTaskCompletionPolicy(moved.ID)
+	resp.ActivityLevel, _ = h.deps.DB.GetTaskActivityLevel(moved.ID)
+	resp.PromptSensitivity, _ = h.deps.DB.GetTaskPromptSensitivity(moved.ID)
+	resp.ThinkingBudget, _ = h.deps.DB.GetTaskThinkingBudget(moved.ID)
+	resp.ModelFallback, _ = h.deps.DB.GetTaskModelFallback(moved.ID)
+	resp.Tags, _ = h.deps.DB.GetTaskTags(moved.ID)
+	resp.ClonedFrom, _ = h.deps.DB.GetTaskClonedFrom(moved.ID)
+
+	if h.deps.Broadcaster != nil {
+		h.deps.Broadcaster.PublishTaskEvent(realtime.EventTaskUpdated, moved.ID, map[string]any{
+			"project_id":      moved.ProjectID,
+			"from_project_id": t.ProjectID,
+			"status":          moved.Status,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// HandleListClones returns the tasks cloned from a task, oldest first.
+// GET /api/v1/tasks/:id/clones
+func (h *Handler) HandleListClones(c echo.Context) error {
+	taskID := c.Param("id")
+
+	if _, err := h.deps.TaskService.Get(taskID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	clones, err := h.deps.DB.ListTaskClones(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	responses := make([]core.TaskResponse, len(clones))
+	for i, t := range clones {
+		responses[i] = core.ToTaskResponse(t)
+		if inputTokens, outputTokens, err := h.deps.DB.GetTaskTokensFromActivity(t.ID); err == nil {
+			responses[i].SetTokensFromActivity(inputTokens, outputTokens)
+		}
+		responses[i].ClonedFrom = taskID
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"tasks": responses,
+		"count": len(responses),
+	})
+}
+
+// HandleAddTags adds tags to a task. Tags are lowercased; ones the task
+// already has are ignored.
+// POST /api/v1/tasks/:id/tags
+func (h *Handler) HandleAddTags(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Tags) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "tags is required")
+	}
+	for _, tag := range req.Tags {
+		if _, err := db.NormalizeTag(tag); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	if err := h.deps.DB.AddTaskTags(taskID, req.Tags); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	tags, err := h.deps.DB.GetTaskTags(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"task_id": taskID,
+		"tags":    tags,
+	})
+}
+
+// HandleRemoveTag removes a tag from a task.
+// DELETE /api/v1/tasks/:id/tags/:tag
+func (h *Handler) HandleRemoveTag(c echo.Context) error {
+	taskID := c.Param("id")
+
+	if err := h.deps.DB.RemoveTaskTag(taskID, c.Param("tag")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	tags, err := h.deps.DB.GetTaskTags(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"task_id": taskID,
+		"tags":    tags,
+	})
+}
+
+// HandleListTags returns every tag in use with its task count.
+// GET /api/v1/tags
+func (h *Handler) HandleListTags(c echo.Context) error {
+	tags, err := h.deps.DB.ListTaskTagCounts()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"tags":  tags,
+		"count": len(tags),
+	})
+}
+
 // HandleWorktreeStatus returns the git status of a task's worktree.
 // GET /api/v1/tasks/:id/worktree/status
 func (h *Handler) HandleWorktreeStatus(c echo.Context) error {
@@ -254,3 +887,83 @@ func (h *Handler) HandleWorktreeStatus(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, status)
 }
+
+// HandleWorktreeDiff returns the uncommitted changes in a task's worktree, so
+// a running session's work can be watched before it commits. With
+// staged=true only changes staged for the next commit are included.
+// GET /api/v1/tasks/:id/worktree/diff?staged=true
+func (h *Handler) HandleWorktreeDiff(c echo.Context) error {
+	taskID := c.Param("id")
+
+	staged := false
+	if v := c.QueryParam("staged"); v != "" {
+		var err error
+		if staged, err = strconv.ParseBool(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "staged must be true or false")
+		}
+	}
+
+	if h.deps.GitService == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "git service not configured")
+	}
+
+	diff, err := h.deps.GitService.GetTaskWorktreeDiff(taskID, staged)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") || errors.Is(err, git.ErrNoWorktree) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// HandleWorktreeRevert undoes the last commit in a task's worktree, either by
+// soft reset (the default, keeping its changes staged) or by a revert commit.
+// Refused while the task has an active session, which may be committing.
+// POST /api/v1/tasks/:id/worktree/revert
+func (h *Handler) HandleWorktreeRevert(c echo.Context) error {
+	taskID := c.Param("id")
+
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Mode == "" {
+		req.Mode = string(git.UndoSoft)
+	}
+	if !git.IsValidUndoMode(req.Mode) {
+		return echo.NewHTTPError(http.StatusBadRequest, "mode must be soft or revert")
+	}
+
+	if h.deps.GitService == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "git service not configured")
+	}
+
+	t, err := h.deps.DB.GetTaskByID(taskID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if t == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "task not found")
+	}
+	if t.GetWorktreePath() == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "task has no worktree")
+	}
+	if h.deps.SessionManager != nil && h.deps.SessionManager.GetByTask(taskID) != nil {
+		return echo.NewHTTPError(http.StatusConflict, "task has an active session; pause it first")
+	}
+
+	result, err := h.deps.GitService.UndoTaskCommit(taskID, git.UndoMode(req.Mode))
+	if err != nil {
+		if errors.Is(err, git.ErrUndoRefused) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	fmt.Printf("HandleWorktreeRevert: task %s undid commit %s (%s)\n", taskID, result.UndoneCommit, result.Mode)
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,38 @@
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleReport returns a structured completion report for a task: its status,
+// hats with time and tokens, checklist outcome, quality gate runs, PR, cost,
+// memories created, and a summary. ?format=markdown returns it as a markdown
+// document for sharing.
+// GET /api/v1/tasks/:id/report?format=json|markdown
+func (h *Handler) HandleReport(c echo.Context) error {
+	if h.deps.SessionManager == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "session manager not configured")
+	}
+
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "markdown" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid format %q (must be json or markdown)", format))
+	}
+
+	report, err := h.deps.SessionManager.BuildTaskReport(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if format == "markdown" {
+		return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", []byte(report.Markdown()))
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -24,11 +24,13 @@ func NewWorktreeHandler(deps *core.Deps) *WorktreeHandler {
 //   - GET /worktrees/stale
 //   - DELETE /worktrees/:task_id
 //   - POST /worktrees/cleanup-merged
+//   - POST /worktrees/prune
 func (h *WorktreeHandler) RegisterRoutes(g *echo.Group) {
 	g.GET("/worktrees", h.HandleList)
 	g.GET("/worktrees/stale", h.HandleListStale)
 	g.DELETE("/worktrees/:task_id", h.HandleDelete)
 	g.POST("/worktrees/cleanup-merged", h.HandleCleanupMerged)
+	g.POST("/worktrees/prune", h.HandlePrune)
 }
 
 // HandleList returns all worktrees for a project.
@@ -161,6 +163,14 @@ func (h *WorktreeHandler) HandleDelete(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// mergedCleanup summarizes a pass over worktrees of merged task branches
+type mergedCleanup struct {
+	Cleaned int      `json:"cleaned"`
+	Skipped int      `json:"skipped"`
+	Failed  int      `json:"failed"`
+	Errors  []string `json:"errors"`
+}
+
 // HandleCleanupMerged cleans up all worktrees for tasks whose branches have been merged.
 // POST /api/v1/worktrees/cleanup-merged
 // This is safe to run - it checks git directly to verify branches are merged before cleanup.
@@ -169,50 +179,85 @@ func (h *WorktreeHandler) HandleCleanupMerged(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusServiceUnavailable, "git service not configured")
 	}
 
-	// Get all stale worktrees (completed/cancelled tasks with worktrees)
-	tasks, err := h.deps.DB.GetTasksWithStaleWorktrees()
+	result, err := h.cleanupMerged()
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	var cleaned, skipped, failed int
-	var errors []string
+	return c.JSON(http.StatusOK, result)
+}
+
+// HandlePrune cleans up worktrees of merged task branches and, with
+// include_failed, the worktrees of cancelled and timed-out tasks that the
+// debug retention policy no longer keeps.
+// POST /api/v1/worktrees/prune?include_failed=true
+func (h *WorktreeHandler) HandlePrune(c echo.Context) error {
+	if h.deps.GitService == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "git service not configured")
+	}
+
+	merged, err := h.cleanupMerged()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	response := map[string]any{"merged": merged}
 
+	if c.QueryParam("include_failed") == "true" {
+		debug, err := h.deps.GitService.PruneDebugWorktrees()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		response["failed"] = debug
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// cleanupMerged removes the worktrees and branches of completed and cancelled
+// tasks whose branches git reports as merged
+func (h *WorktreeHandler) cleanupMerged() (*mergedCleanup, error) {
+	// Get all stale worktrees (completed/cancelled tasks with worktrees)
+	tasks, err := h.deps.DB.GetTasksWithStaleWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mergedCleanup{}
 	for _, task := range tasks {
 		if !task.WorktreePath.Valid || task.WorktreePath.String == "" {
 			continue
 		}
 
 		if !task.BranchName.Valid || task.BranchName.String == "" {
-			skipped++
+			result.Skipped++
 			continue
 		}
 
 		// Check if branch is merged using git
 		merged, err := h.deps.GitService.IsTaskBranchMerged(task.ID)
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("task %s: failed to check merge status: %v", task.ID, err))
-			failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("task %s: failed to check merge status: %v", task.ID, err))
+			result.Failed++
 			continue
 		}
 
 		if !merged {
-			skipped++ // Branch not merged yet, skip
+			result.Skipped++ // Branch not merged yet, skip
 			continue
 		}
 
 		// Get project path
 		project, err := h.deps.DB.GetProjectByID(task.ProjectID)
 		if err != nil || project == nil {
-			errors = append(errors, fmt.Sprintf("task %s: failed to get project", task.ID))
-			failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("task %s: failed to get project", task.ID))
+			result.Failed++
 			continue
 		}
 
 		// Clean up the worktree (also delete the branch since it's merged)
 		if err := h.deps.GitService.CleanupTaskWorktree(project.RepoPath, task.ID, true); err != nil {
-			errors = append(errors, fmt.Sprintf("task %s: %v", task.ID, err))
-			failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("task %s: %v", task.ID, err))
+			result.Failed++
 			continue
 		}
 
@@ -221,13 +266,8 @@ func (h *WorktreeHandler) HandleCleanupMerged(c echo.Context) error {
 			fmt.Printf("warning: failed to mark task %s worktree as cleaned: %v\n", task.ID, err)
 		}
 
-		cleaned++
+		result.Cleaned++
 	}
 
-	return c.JSON(http.StatusOK, map[string]any{
-		"cleaned": cleaned,
-		"skipped": skipped,
-		"failed":  failed,
-		"errors":  errors,
-	})
+	return result, nil
 }
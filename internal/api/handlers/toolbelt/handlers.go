@@ -2,7 +2,9 @@
 package toolbelt
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
@@ -27,6 +29,7 @@ func New(deps *core.Deps) *Handler {
 //
 // Protected routes (auth required):
 //   - GET /me
+//   - GET /toolbelt/history
 func (h *Handler) RegisterPublicRoutes(g *echo.Group) {
 	g.GET("/toolbelt/status", h.HandleStatus)
 	g.POST("/toolbelt/test", h.HandleTest)
@@ -35,8 +38,15 @@ func (h *Handler) RegisterPublicRoutes(g *echo.Group) {
 // RegisterProtectedRoutes registers protected toolbelt routes.
 func (h *Handler) RegisterProtectedRoutes(g *echo.Group) {
 	g.GET("/me", h.HandleMe)
+	g.GET("/toolbelt/history", h.HandleHistory)
 }
 
+// Limits on how many checks HandleHistory returns
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
 // HandleStatus returns the configuration status of all toolbelt services.
 // GET /api/v1/toolbelt/status
 func (h *Handler) HandleStatus(c echo.Context) error {
@@ -85,6 +95,31 @@ func (h *Handler) HandleTest(c echo.Context) error {
 	})
 }
 
+// HandleHistory returns recorded background connection tests, newest first.
+// Query params: service (optional), limit (default 100, max 1000).
+// GET /api/v1/toolbelt/history
+func (h *Handler) HandleHistory(c echo.Context) error {
+	limit := defaultHistoryLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxHistoryLimit {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("limit must be between 1 and %d", maxHistoryLimit))
+		}
+		limit = n
+	}
+
+	checks, err := h.deps.DB.ListToolbeltChecks(c.QueryParam("service"), limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"checks": checks,
+		"count":  len(checks),
+	})
+}
+
 // HandleMe returns the authenticated user info.
 // GET /api/v1/me
 func (h *Handler) HandleMe(c echo.Context) error {
@@ -2,12 +2,16 @@
 package workers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/db"
+	taskpkg "github.com/lirancohen/dex/internal/task"
+	"github.com/lirancohen/dex/internal/toolbelt"
 	"github.com/lirancohen/dex/internal/worker"
 )
 
@@ -26,8 +30,10 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 	workers := g.Group("/workers")
 	workers.GET("", h.handleList)
 	workers.GET("/status", h.handleStatus)
+	workers.GET("/fleet", h.handleFleet)
 	workers.POST("/dispatch", h.handleDispatch)
 	workers.POST("/:id/cancel", h.handleCancel)
+	workers.POST("/:id/cancel-all", h.handleCancelAll)
 }
 
 // WorkerStatusResponse represents the response for worker status.
@@ -52,6 +58,24 @@ type WorkerInfoResponse struct {
 // DispatchRequest represents a request to dispatch an objective to a worker.
 type DispatchRequest struct {
 	ObjectiveID string `json:"objective_id"`
+
+	// Network restricts the objective's network access on the worker (optional)
+	Network worker.NetworkPolicy `json:"network"`
+
+	// Acceptance lists tools, runtimes, and disk space the worker must have (optional)
+	Acceptance worker.AcceptanceCriteria `json:"acceptance"`
+
+	// PreviewPlan has the worker send its plan for approval before running the
+	// objective (optional; defaults to on for tasks at the lowest autonomy levels)
+	PreviewPlan *bool `json:"preview_plan"`
+
+	// Outputs are extra destinations (file, webhook, github_issue) the worker
+	// delivers the result to when the objective completes (optional)
+	Outputs []worker.OutputDestination `json:"outputs"`
+
+	// Model the worker must run the objective with, sonnet or opus (optional;
+	// defaults to the task's model). HQ's --worker-model overrides it.
+	Model string `json:"model"`
 }
 
 // DispatchResponse represents the response from dispatching an objective.
@@ -120,6 +144,19 @@ func (h *Handler) handleStatus(c echo.Context) error {
 	})
 }
 
+// handleFleet returns every worker's last reported state, its running
+// objectives, and fleet-wide totals. Workers that miss three heartbeats are
+// offline.
+func (h *Handler) handleFleet(c echo.Context) error {
+	if h.deps.WorkerManager == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "worker manager not configured",
+		})
+	}
+
+	return c.JSON(http.StatusOK, h.deps.WorkerManager.Registry().Fleet())
+}
+
 // handleDispatch dispatches an objective to an available worker.
 func (h *Handler) handleDispatch(c echo.Context) error {
 	if h.deps.WorkerManager == nil {
@@ -140,6 +177,26 @@ func (h *Handler) handleDispatch(c echo.Context) error {
 			"error": "objective_id is required",
 		})
 	}
+	if err := req.Network.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := req.Acceptance.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := worker.ValidateOutputs(req.Outputs); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := worker.ValidateModel(req.Model); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
 
 	ctx := c.Request().Context()
 
@@ -184,10 +241,35 @@ func (h *Handler) handleDispatch(c echo.Context) error {
 		Description: task.GetDescription(),
 		Hat:         task.Hat.String,
 		BaseBranch:  task.BaseBranch,
+		Model:       req.Model,
+		Network:     req.Network,
+		Acceptance:  req.Acceptance,
+		PreviewPlan: task.AutonomyLevel <= taskpkg.PlanPreviewAutonomyLevel,
+	}
+	if req.PreviewPlan != nil {
+		objective.PreviewPlan = *req.PreviewPlan
+	}
+	// Name the model explicitly so the worker never picks one itself
+	if objective.Model == "" {
+		objective.Model = worker.DefaultModel
+		if task.Model.Valid && worker.ValidateModel(task.Model.String) == nil {
+			objective.Model = task.Model.String
+		}
+	}
+	// Issues default to the project's repo so the worker needs nothing else to open them
+	for _, output := range req.Outputs {
+		if output.Type == worker.OutputGitHubIssue && output.Owner == "" {
+			output.Owner = project.GetOwner()
+			output.Repo = project.GetRepo()
+		}
+		objective.Outputs = append(objective.Outputs, output)
 	}
 	if task.TokenBudget.Valid {
 		objective.TokenBudget = int(task.TokenBudget.Int64)
 	}
+	if task.TimeBudgetMin.Valid {
+		objective.TimeoutSec = int(task.TimeBudgetMin.Int64) * 60
+	}
 
 	// Build project info
 	projectInfo := worker.Project{
@@ -229,11 +311,18 @@ func (h *Handler) handleDispatch(c echo.Context) error {
 
 // getWorkerSecrets retrieves the secrets needed for worker execution.
 func (h *Handler) getWorkerSecrets() (worker.WorkerSecrets, error) {
+	return GetWorkerSecrets(h.deps)
+}
+
+// GetWorkerSecrets retrieves the secrets workers need: the Anthropic key and
+// GitHub token from the environment or the encrypted secrets store, the
+// environment first, or the toolbelt if there is no store.
+func GetWorkerSecrets(deps *core.Deps) (worker.WorkerSecrets, error) {
 	var secrets worker.WorkerSecrets
 
-	if h.deps.SecretsStore == nil {
+	if deps.SecretsStore == nil {
 		// Fallback to toolbelt if secrets store not configured
-		tb := h.deps.GetToolbelt()
+		tb := deps.GetToolbelt()
 		if tb != nil && tb.Anthropic != nil {
 			secrets.AnthropicKey = tb.Anthropic.GetAPIKey()
 		}
@@ -243,22 +332,26 @@ func (h *Handler) getWorkerSecrets() (worker.WorkerSecrets, error) {
 		return secrets, nil
 	}
 
-	// Get from encrypted secrets store
-	key, err := h.deps.SecretsStore.GetSecret("anthropic_api_key")
+	// Get from encrypted secrets store, unless set in the environment
+	key, err := deps.SecretsStore.GetSecret(db.SecretKeyAnthropicKey)
 	if err != nil {
 		return secrets, fmt.Errorf("failed to get anthropic key: %w", err)
 	}
-	secrets.AnthropicKey = key
-
-	token, err := h.deps.SecretsStore.GetSecret("github_token")
+	token, err := deps.SecretsStore.GetSecret(db.SecretKeyGitHubToken)
 	if err != nil {
 		return secrets, fmt.Errorf("failed to get github token: %w", err)
 	}
-	secrets.GitHubToken = token
+	config := toolbelt.ResolveConfig(key, token)
+	if config.Anthropic != nil {
+		secrets.AnthropicKey = config.Anthropic.APIKey
+	}
+	if config.GitHub != nil {
+		secrets.GitHubToken = config.GitHub.Token
+	}
 
 	// Optional secrets
-	secrets.FlyToken, _ = h.deps.SecretsStore.GetSecret("fly_token")
-	secrets.CloudflareToken, _ = h.deps.SecretsStore.GetSecret("cloudflare_token")
+	secrets.FlyToken, _ = deps.SecretsStore.GetSecret("fly_token")
+	secrets.CloudflareToken, _ = deps.SecretsStore.GetSecret("cloudflare_token")
 
 	return secrets, nil
 }
@@ -289,3 +382,34 @@ func (h *Handler) handleCancel(c echo.Context) error {
 		"status": "cancelled",
 	})
 }
+
+// handleCancelAll cancels every objective running on a worker, e.g. before
+// taking it out of service.
+func (h *Handler) handleCancelAll(c echo.Context) error {
+	if h.deps.WorkerManager == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "worker manager not configured",
+		})
+	}
+
+	workerID := c.Param("id")
+	cancelled, err := h.deps.WorkerManager.CancelWorkerObjectives(c.Request().Context(), workerID)
+	if errors.Is(err, worker.ErrWorkerNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]any{
+			"error":     err.Error(),
+			"worker_id": workerID,
+			"cancelled": cancelled,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"status":    "cancelled",
+		"worker_id": workerID,
+		"cancelled": cancelled,
+	})
+}
@@ -0,0 +1,86 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/api/core"
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/worker"
+)
+
+// PlanApprovalData is the data stored with an objective plan approval
+type PlanApprovalData struct {
+	WorkerID     string `json:"worker_id"`
+	Plan         string `json:"plan"`
+	TokensInput  int    `json:"tokens_input,omitempty"`
+	TokensOutput int    `json:"tokens_output,omitempty"`
+}
+
+// RequestPlanApproval creates an approval for the plan a worker sent for an
+// objective dispatched with a plan preview.
+func RequestPlanApproval(deps *core.Deps, workerID string, preview *worker.PlanPreviewPayload) (*db.Approval, error) {
+	data, err := json.Marshal(PlanApprovalData{
+		WorkerID:     workerID,
+		Plan:         preview.Plan,
+		TokensInput:  preview.TokensInput,
+		TokensOutput: preview.TokensOutput,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal approval data: %w", err)
+	}
+
+	taskID := preview.ObjectiveID
+	title := "Approve plan"
+	projectID := ""
+	if task, err := deps.DB.GetTaskByID(taskID); err == nil && task != nil {
+		title = fmt.Sprintf("Approve plan: %s", task.Title)
+		projectID = task.ProjectID
+	}
+	description := fmt.Sprintf("Worker %s proposed this plan and is waiting for approval before making any changes.", workerID)
+
+	approval, err := deps.DB.CreateApproval(&taskID, nil, db.ApprovalTypeObjectivePlan, title, &description, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if deps.Broadcaster != nil {
+		deps.Broadcaster.Publish(realtime.EventApprovalRequired, map[string]any{
+			"task_id":     taskID,
+			"project_id":  projectID,
+			"approval_id": approval.ID,
+			"reason":      "plan preview",
+		})
+	}
+	return approval, nil
+}
+
+// DispatchApprovedPlan dispatches the objective behind an approved plan, so a
+// worker runs it following that plan.
+func DispatchApprovedPlan(ctx context.Context, deps *core.Deps, approval *db.Approval) error {
+	if deps.WorkerManager == nil {
+		return fmt.Errorf("worker manager not configured")
+	}
+
+	var data PlanApprovalData
+	if err := json.Unmarshal(approval.Data, &data); err != nil {
+		return fmt.Errorf("failed to parse approval data: %w", err)
+	}
+
+	secrets, err := GetWorkerSecrets(deps)
+	if err != nil {
+		return fmt.Errorf("failed to get secrets: %w", err)
+	}
+
+	return deps.WorkerManager.DispatchApprovedPlan(ctx, approval.TaskID.String, data.Plan, &secrets)
+}
+
+// RejectPlan drops the objective behind a rejected plan and cancels its task.
+func RejectPlan(deps *core.Deps, approval *db.Approval) error {
+	if deps.WorkerManager != nil {
+		deps.WorkerManager.RejectPlan(approval.TaskID.String, "plan rejected")
+	}
+	return deps.DB.UpdateTaskStatus(approval.TaskID.String, db.TaskStatusCancelled)
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+	"github.com/lirancohen/dex/internal/auth"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig configures per-IP rate limiting of public endpoints
+type RateLimitConfig struct {
+	PerMinute int // Sustained requests allowed per IP each minute (0 disables limiting)
+	Burst     int // Requests an IP can make at once before the limit applies
+
+	// Proxies whose X-Forwarded-For is believed (none: the connection's address is the client's)
+	TrustedProxies []*net.IPNet
+}
+
+// Enabled reports whether rate limiting is configured
+func (c RateLimitConfig) Enabled() bool {
+	return c.PerMinute > 0
+}
+
+// RateLimit creates middleware that limits requests per client IP, answering
+// requests over the limit with 429 and a Retry-After header. Requests with a
+// valid JWT aren't limited, so signed-in users never hit it.
+func RateLimit(cfg RateLimitConfig, tokenConfig *auth.TokenConfig) echo.MiddlewareFunc {
+	burst := max(cfg.Burst, 1)
+	retryAfter := strconv.Itoa(int(math.Ceil(60 / float64(cfg.PerMinute))))
+
+	return echomw.RateLimiterWithConfig(echomw.RateLimiterConfig{
+		Skipper: func(c echo.Context) bool {
+			return hasValidToken(c, tokenConfig)
+		},
+		Store: echomw.NewRateLimiterMemoryStoreWithConfig(echomw.RateLimiterMemoryStoreConfig{
+			Rate:  rate.Limit(float64(cfg.PerMinute) / 60),
+			Burst: burst,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			c.Response().Header().Set("Retry-After", retryAfter)
+			return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests")
+		},
+	})
+}
+
+// IPExtractor returns how the server finds a request's client IP. Without
+// trusted proxies it's the connection's address, since any client can send
+// X-Forwarded-For; with them, X-Forwarded-For is read back through those
+// proxies only.
+func IPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// ParseTrustedProxies parses a comma-separated list of proxy IPs and CIDRs,
+// e.g. "10.0.0.0/8,127.0.0.1"
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+			if v4 := ip.To4(); v4 != nil {
+				proxies = append(proxies, &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)})
+			} else {
+				proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)})
+			}
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// hasValidToken reports whether the request carries a valid bearer token
+func hasValidToken(c echo.Context, tokenConfig *auth.TokenConfig) bool {
+	if tokenConfig == nil {
+		return false
+	}
+	parts := strings.SplitN(c.Request().Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return false
+	}
+	_, err := auth.ValidateToken(parts[1], tokenConfig)
+	return err == nil
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/auth"
+)
+
+func TestRateLimit(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	tokenConfig := &auth.TokenConfig{Issuer: "test", ExpiryHours: 1, SigningKey: priv, VerifyingKey: pub}
+	token, err := auth.GenerateToken("user-1", tokenConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(RateLimit(RateLimitConfig{PerMinute: 1, Burst: 2}, tokenConfig))
+	e.POST("/auth/verify", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	request := func(ip, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/verify", nil)
+		req.RemoteAddr = ip + ":1234"
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The burst passes, then the IP is limited
+	for i := 0; i < 2; i++ {
+		if rec := request("10.0.0.1", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := request("10.0.0.1", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+
+	// Other IPs have their own allowance
+	if rec := request("10.0.0.2", ""); rec.Code != http.StatusOK {
+		t.Errorf("other IP: status %d, want 200", rec.Code)
+	}
+
+	// Signed-in requests aren't limited, but forged tokens don't help
+	if rec := request("10.0.0.1", "Bearer "+token); rec.Code != http.StatusOK {
+		t.Errorf("authenticated: status %d, want 200", rec.Code)
+	}
+	if rec := request("10.0.0.1", "Bearer forged"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("forged token: status %d, want 429", rec.Code)
+	}
+}
+
+func TestRateLimit_IgnoresForwardedForFromClients(t *testing.T) {
+	newServer := func(trusted []*net.IPNet) *echo.Echo {
+		e := echo.New()
+		e.IPExtractor = IPExtractor(trusted)
+		e.Use(RateLimit(RateLimitConfig{PerMinute: 1, Burst: 1}, nil))
+		e.POST("/auth/verify", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+		return e
+	}
+	request := func(e *echo.Echo, remoteIP, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/verify", nil)
+		req.RemoteAddr = remoteIP + ":1234"
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A client rotating X-Forwarded-For still shares one bucket
+	e := newServer(nil)
+	if code := request(e, "203.0.113.7", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", code)
+	}
+	for i := 2; i <= 4; i++ {
+		if code := request(e, "203.0.113.7", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusTooManyRequests {
+			t.Errorf("rotated X-Forwarded-For %d: status %d, want 429", i, code)
+		}
+	}
+
+	// Behind a trusted proxy, each forwarded client gets its own bucket
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = newServer(proxies)
+	for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+		if code := request(e, "10.1.2.3", client); code != http.StatusOK {
+			t.Errorf("client %s via trusted proxy: status %d, want 200", client, code)
+		}
+	}
+	if code := request(e, "10.1.2.3", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("repeat client via trusted proxy: status %d, want 429", code)
+	}
+	// An untrusted hop can't pick its IP
+	if code := request(e, "203.0.113.7", "198.51.100.9"); code != http.StatusOK {
+		t.Errorf("untrusted hop: status %d, want 200", code)
+	}
+	if code := request(e, "203.0.113.7", "198.51.100.10"); code != http.StatusTooManyRequests {
+		t.Errorf("untrusted hop rotating X-Forwarded-For: status %d, want 429", code)
+	}
+
+	for _, invalid := range []string{"not-an-ip", "10.0.0.0/99"} {
+		if _, err := ParseTrustedProxies(invalid); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) expected error", invalid)
+		}
+	}
+}
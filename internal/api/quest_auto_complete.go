@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/realtime"
+)
+
+// autoCompleteTaskQuest completes the quest that spawned a task, if the task
+// was its last one still going and the quest has auto-complete on
+func (s *Server) autoCompleteTaskQuest(taskID string) {
+	task, err := s.db.GetTaskByID(taskID)
+	if err != nil || task == nil || !task.QuestID.Valid {
+		return
+	}
+	s.autoCompleteQuest(task.QuestID.String)
+}
+
+// autoCompleteQuest completes a quest with auto-complete on once every task it
+// spawned is done, broadcasting quest.completed and closing its issue just as
+// completing it by hand would
+func (s *Server) autoCompleteQuest(questID string) {
+	completed, err := s.db.CompleteQuestIfTasksDone(questID)
+	if err != nil {
+		fmt.Printf("autoCompleteQuest: warning - %v\n", err)
+		return
+	}
+	if !completed {
+		return
+	}
+	fmt.Printf("autoCompleteQuest: every task of quest %s is done, completed it\n", questID)
+
+	quest, err := s.db.GetQuestByID(questID)
+	if err != nil || quest == nil {
+		return
+	}
+	summary, _ := s.db.GetQuestSummary(questID)
+
+	if s.broadcaster != nil {
+		s.broadcaster.PublishQuestEvent(realtime.EventQuestCompleted, questID, map[string]any{
+			"project_id":     quest.ProjectID,
+			"auto_completed": true,
+		})
+	}
+
+	if s.handlersSyncSvc != nil {
+		go s.handlersSyncSvc.CloseQuestIssue(questID, summary)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// questHasCapacityLocked reports whether another of the task's quest's tasks
+// can start, counting running tasks and starts in progress. Tasks outside a
+// quest, and quests without a limit, always have room.
+// Must be called with questStartingMu held.
+func (s *Server) questHasCapacityLocked(t *db.Task) bool {
+	if !t.QuestID.Valid || t.QuestID.String == "" {
+		return true
+	}
+	questID := t.QuestID.String
+
+	quest, err := s.db.GetQuestByID(questID)
+	if err != nil || quest == nil {
+		// Don't strand tasks in the queue over a missing quest
+		return true
+	}
+	if quest.MaxConcurrentObjectives <= 0 {
+		return true
+	}
+
+	running, err := s.db.CountRunningQuestTasks(questID)
+	if err != nil {
+		fmt.Printf("questHasCapacity: warning - failed to count running tasks for quest %s: %v\n", questID, err)
+		return true
+	}
+	return running+s.questStarting[questID] < quest.MaxConcurrentObjectives
+}
+
+// reserveQuestSlot claims a slot in the task's quest while it starts, like
+// reserveRepoSlot does for its repo. It returns false if the quest is full.
+// Otherwise release must be called once the task is marked running (or fails
+// to start).
+func (s *Server) reserveQuestSlot(t *db.Task) (release func(), ok bool) {
+	s.questStartingMu.Lock()
+	defer s.questStartingMu.Unlock()
+
+	if !s.questHasCapacityLocked(t) {
+		return nil, false
+	}
+	if !t.QuestID.Valid || t.QuestID.String == "" {
+		return func() {}, true
+	}
+
+	questID := t.QuestID.String
+	s.questStarting[questID]++
+	return func() {
+		s.questStartingMu.Lock()
+		defer s.questStartingMu.Unlock()
+		if s.questStarting[questID]--; s.questStarting[questID] <= 0 {
+			delete(s.questStarting, questID)
+		}
+	}, true
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// repoKey identifies the repo a project's tasks work in. Projects sharing a
+// repo path share its limit.
+func repoKey(project *db.Project) string {
+	if project.RepoPath != "" {
+		return project.RepoPath
+	}
+	return project.ID
+}
+
+// repoTaskLimit returns how many tasks may run at once in the project's repo:
+// the project's own limit if it sets one, otherwise the server default (0 means
+// unlimited)
+func (s *Server) repoTaskLimit(project *db.Project) int {
+	limit, err := s.db.GetProjectMaxConcurrentTasks(project.ID)
+	if err != nil {
+		fmt.Printf("repoTaskLimit: warning - failed to get limit for project %s: %v\n", project.ID, err)
+	}
+	if limit > 0 {
+		return limit
+	}
+	return s.maxTasksPerRepo
+}
+
+// repoHasCapacityLocked reports whether another task can start in the
+// project's repo, counting running tasks and starts in progress.
+// Must be called with repoStartingMu held.
+func (s *Server) repoHasCapacityLocked(project *db.Project) bool {
+	limit := s.repoTaskLimit(project)
+	if limit <= 0 {
+		return true
+	}
+
+	running, err := s.db.CountRunningTasksInRepo(project.ID)
+	if err != nil {
+		// Don't strand tasks in the queue over a failed count
+		fmt.Printf("repoHasCapacity: warning - failed to count running tasks for project %s: %v\n", project.ID, err)
+		return true
+	}
+	return running+s.repoStarting[repoKey(project)] < limit
+}
+
+// reserveRepoSlot claims a slot in the project's repo for a task that is
+// starting, so concurrent starts can't both take the last one. It returns false
+// if the repo is full. Otherwise release must be called once the task is marked
+// running (or fails to start).
+func (s *Server) reserveRepoSlot(project *db.Project) (release func(), ok bool) {
+	s.repoStartingMu.Lock()
+	defer s.repoStartingMu.Unlock()
+
+	if !s.repoHasCapacityLocked(project) {
+		return nil, false
+	}
+
+	key := repoKey(project)
+	s.repoStarting[key]++
+	return func() {
+		s.repoStartingMu.Lock()
+		defer s.repoStartingMu.Unlock()
+		if s.repoStarting[key]--; s.repoStarting[key] <= 0 {
+			delete(s.repoStarting, key)
+		}
+	}, true
+}
+
+// canStartQueuedTask reports whether a queued task's repo and quest have room
+// for it
+func (s *Server) canStartQueuedTask(taskID string) bool {
+	t, err := s.db.GetTaskByID(taskID)
+	if err != nil || t == nil {
+		// Let startTask report the problem
+		return true
+	}
+	project, err := s.db.GetProjectByID(t.ProjectID)
+	if err != nil || project == nil {
+		return true
+	}
+
+	s.repoStartingMu.Lock()
+	hasRoom := s.repoHasCapacityLocked(project)
+	s.repoStartingMu.Unlock()
+	if !hasRoom {
+		return false
+	}
+
+	s.questStartingMu.Lock()
+	defer s.questStartingMu.Unlock()
+	return s.questHasCapacityLocked(t)
+}
@@ -18,6 +18,7 @@ import (
 	authhandlers "github.com/lirancohen/dex/internal/api/handlers/auth"
 	deviceshandlers "github.com/lirancohen/dex/internal/api/handlers/devices"
 	forgejohandlers "github.com/lirancohen/dex/internal/api/handlers/forgejo"
+	hatshandlers "github.com/lirancohen/dex/internal/api/handlers/hats"
 	"github.com/lirancohen/dex/internal/api/handlers/issuesync"
 	mailhandlers "github.com/lirancohen/dex/internal/api/handlers/mail"
 	"github.com/lirancohen/dex/internal/api/handlers/memory"
@@ -25,6 +26,7 @@ import (
 	planninghandlers "github.com/lirancohen/dex/internal/api/handlers/planning"
 	"github.com/lirancohen/dex/internal/api/handlers/projects"
 	"github.com/lirancohen/dex/internal/api/handlers/quests"
+	searchhandlers "github.com/lirancohen/dex/internal/api/handlers/search"
 	sessionshandlers "github.com/lirancohen/dex/internal/api/handlers/sessions"
 	"github.com/lirancohen/dex/internal/api/handlers/tasks"
 	toolbelthandlers "github.com/lirancohen/dex/internal/api/handlers/toolbelt"
@@ -37,11 +39,13 @@ import (
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/forgejo"
 	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/gitprovider"
 	"github.com/lirancohen/dex/internal/mesh"
 	"github.com/lirancohen/dex/internal/orchestrator"
 	"github.com/lirancohen/dex/internal/planning"
 	"github.com/lirancohen/dex/internal/quest"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/security"
 	"github.com/lirancohen/dex/internal/session"
 	"github.com/lirancohen/dex/internal/task"
 	"github.com/lirancohen/dex/internal/toolbelt"
@@ -56,6 +60,7 @@ type Server struct {
 	taskService      *task.Service
 	gitService       *git.Service
 	sessionManager   *session.Manager
+	scheduler        *orchestrator.Scheduler
 	planner          *planning.Planner
 	questHandler     *quest.Handler
 	handlersSyncSvc  *issuesync.SyncService // Handler-level sync service wrapper
@@ -75,28 +80,83 @@ type Server struct {
 	keyFile          string
 	tokenConfig      *auth.TokenConfig
 	staticDir        string
-	baseDir          string       // Base Dex directory (e.g., /opt/dex)
-	publicURL        string       // Public URL for OIDC issuer (e.g., https://hq.alice.enbox.id)
-	namespace        string       // Account namespace (from enrollment)
-	tunnelToken      string       // Token for Central API
-	centralURL       string       // Central server URL
-	toolbeltMu       sync.RWMutex // Protects toolbelt updates
+	baseDir          string                     // Base Dex directory (e.g., /opt/dex)
+	publicURL        string                     // Public URL for OIDC issuer (e.g., https://hq.alice.enbox.id)
+	namespace        string                     // Account namespace (from enrollment)
+	tunnelToken      string                     // Token for Central API
+	centralURL       string                     // Central server URL
+	rateLimit        middleware.RateLimitConfig // Per-IP limit on public endpoints
+	version          string                     // Server version reported by status endpoints
+	questWindow      int                        // Quest history window, kept for handlers created on toolbelt reload
+	toolbeltMu       sync.RWMutex               // Protects toolbelt updates
+
+	// Start options of tasks queued while the scheduler is paused or their
+	// repo is busy, by task ID. Only kept in memory: after a restart, queued
+	// tasks are left ready and start with default options.
+	queuedStarts   map[string]startTaskOptions
+	queuedStartsMu sync.Mutex
+
+	// Tasks allowed to run at once per repo (0 = unlimited), and starts in
+	// progress by repo, which count against it until the task is running
+	maxTasksPerRepo int
+	repoStarting    map[string]int
+	repoStartingMu  sync.Mutex
+
+	// Starts in progress by quest, which count against the quest's objective
+	// limit until the task is running
+	questStarting   map[string]int
+	questStartingMu sync.Mutex
+
+	// Background toolbelt connection tests (interval 0 = off)
+	toolbeltCheckInterval time.Duration
+	stopToolbeltMonitor   context.CancelFunc
+
+	// Background pruning of debug worktrees for cancelled and timed-out tasks
+	stopWorktreePruner context.CancelFunc
 }
 
 // Config holds server configuration
 type Config struct {
-	Addr        string                   // e.g., ":8443" or "0.0.0.0:8443"
-	CertFile    string                   // Path to TLS certificate (optional for dev)
-	KeyFile     string                   // Path to TLS key (optional for dev)
-	TokenConfig *auth.TokenConfig        // JWT configuration (optional for dev)
-	StaticDir   string                   // Path to frontend static files (e.g., "./frontend/dist")
-	Toolbelt    *toolbelt.Toolbelt       // Toolbelt for external service integrations (optional)
-	BaseDir     string                   // Base Dex directory (default: /opt/dex). Derived: {BaseDir}/repos/, {BaseDir}/worktrees/
-	Mesh        *mesh.Config             // Mesh networking configuration (optional)
-	Encryption  *crypto.EncryptionConfig // Encryption configuration for secrets at rest and worker payloads
-	Worker      *worker.ManagerConfig    // Worker pool configuration (optional)
-	Forgejo     *forgejo.Config          // Embedded Forgejo configuration (optional)
-	PublicURL   string                   // Public URL for OIDC issuer (e.g., https://hq.alice.enbox.id)
+	Addr        string                      // e.g., ":8443" or "0.0.0.0:8443"
+	CertFile    string                      // Path to TLS certificate (optional for dev)
+	KeyFile     string                      // Path to TLS key (optional for dev)
+	TokenConfig *auth.TokenConfig           // JWT configuration (optional for dev)
+	StaticDir   string                      // Path to frontend static files (e.g., "./frontend/dist")
+	Toolbelt    *toolbelt.Toolbelt          // Toolbelt for external service integrations (optional)
+	BaseDir     string                      // Base Dex directory (default: /opt/dex). Derived: {BaseDir}/repos/, {BaseDir}/worktrees/
+	Mesh        *mesh.Config                // Mesh networking configuration (optional)
+	Encryption  *crypto.EncryptionConfig    // Encryption configuration for secrets at rest and worker payloads
+	Worker      *worker.ManagerConfig       // Worker pool configuration (optional)
+	Forgejo     *forgejo.Config             // Embedded Forgejo configuration (optional)
+	LLMTimeout  time.Duration               // Per-request deadline for session LLM calls (0 = session default)
+	Signals     *session.SignalConfig       // Session signal markers and stop sequences (optional)
+	Secrets     *security.SecretScanner     // Pre-commit secret scanning for sessions (optional, default patterns if nil)
+	Injection   *security.InjectionDetector // Prompt-injection detection in tool output (optional, default patterns if nil)
+	Handoff     session.HandoffOptions      // Handoff summary model and sections (optional, rule-based and tailored to the next hat if empty)
+	Preamble    string                      // Guidance prepended to every hat's system prompt (optional)
+	GitAuthor   *db.GitAuthor               // Commit identity for projects that don't set one (optional, git's own if nil)
+	Trailer     string                      // Trailer added to commits sessions make through git_commit, e.g. "Assisted-by: Dex" (optional)
+	Activity    string                      // Default session activity level (optional, standard if empty)
+	Broadcast   string                      // Session activity level broadcast to clients (optional, standard if empty)
+	Sensitivity string                      // Default prompt sensitivity for untrusted content (optional, normal if empty)
+	MaxMessages int                         // Hard cap on session message history (0 = session default, negative disables)
+	QuestWindow int                         // Recent quest messages sent as-is, older ones summarized (0 = quest default, negative sends all)
+	BudgetWarns []float64                   // Budget fractions at which sessions warn (nil = session default, empty disables)
+	HatTokens   map[string]int              // Output token limit per response, by hat (nil = session defaults)
+	MaxTaskCost float64                     // Dollars a task may spend across all its sessions (0 = no ceiling)
+	WorkerModel string                      // Model every worker objective must run with, sonnet or opus (optional, each task's own if empty)
+	PublicURL   string                      // Public URL for OIDC issuer (e.g., https://hq.alice.enbox.id)
+	Version     string                      // Server version (optional, 0.1.0-dev if empty)
+	CORS        CORSConfig                  // Cross-origin API access (optional, same-origin only if empty)
+	GitRetry    *gitprovider.RetryPolicy    // Retries for transient push and PR failures (optional, defaults if nil)
+	RateLimit   middleware.RateLimitConfig  // Per-IP limit on public auth, setup, and toolbelt endpoints (optional, off if zero)
+	RepoLimit   int                         // Tasks allowed to run at once per repo unless a project sets its own (0 = unlimited)
+
+	// Interval between background toolbelt connection tests (0 = off)
+	ToolbeltCheckInterval time.Duration
+
+	// Worktrees kept for debugging cancelled and timed-out tasks (nil = git.DefaultDebugRetention, zero keeps all)
+	DebugRetention *git.DebugRetention
 
 	// Enrollment configuration (from config.json, for device management)
 	Namespace   string // Account namespace (e.g., "alice")
@@ -109,11 +169,21 @@ func NewServer(database *db.DB, cfg Config) *Server {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	// Client IPs key rate limits, so X-Forwarded-For is only believed from trusted proxies
+	e.IPExtractor = middleware.IPExtractor(cfg.RateLimit.TrustedProxies)
 
 	// Middleware
-	e.Use(echomw.Logger())
+	e.Use(echomw.LoggerWithConfig(echomw.LoggerConfig{
+		// WebSocket upgrades carry the JWT in the query string; keep it out of the logs
+		Skipper: func(c echo.Context) bool {
+			return c.Request().URL.Query().Has("token")
+		},
+	}))
 	e.Use(echomw.Recover())
 	e.Use(echomw.RequestID())
+	if cfg.CORS.Enabled() {
+		e.Use(corsMiddleware(cfg.CORS))
+	}
 
 	// Create Centrifuge realtime node with JWT validation if configured
 	var tokenValidator realtime.TokenValidator
@@ -125,6 +195,7 @@ func NewServer(database *db.DB, cfg Config) *Server {
 		ClientQueueMaxSize: 2 * 1024 * 1024, // 2MB per client
 		ClientChannelLimit: 128,
 		TokenValidator:     tokenValidator,
+		AllowedOrigins:     cfg.CORS.AllowOrigins,
 	})
 	if err != nil {
 		fmt.Printf("Warning: failed to create realtime node: %v\n", err)
@@ -160,6 +231,9 @@ func NewServer(database *db.DB, cfg Config) *Server {
 	var forgejoMgr *forgejo.Manager
 	if cfg.Forgejo != nil {
 		forgejoMgr = forgejo.NewManager(*cfg.Forgejo, database)
+		if secretsStore != nil {
+			forgejoMgr.SetSecretStore(secretsStore)
+		}
 	}
 
 	s := &Server{
@@ -183,6 +257,14 @@ func NewServer(database *db.DB, cfg Config) *Server {
 		namespace:      cfg.Namespace,
 		tunnelToken:    cfg.TunnelToken,
 		centralURL:     cfg.CentralURL,
+		version:        cfg.Version,
+		rateLimit:      cfg.RateLimit,
+		questWindow:    cfg.QuestWindow,
+
+		toolbeltCheckInterval: cfg.ToolbeltCheckInterval,
+	}
+	if s.version == "" {
+		s.version = "0.1.0-dev"
 	}
 
 	// Setup git service with derived paths from base directory
@@ -190,10 +272,18 @@ func NewServer(database *db.DB, cfg Config) *Server {
 		worktreeDir := filepath.Join(cfg.BaseDir, "worktrees")
 		reposDir := filepath.Join(cfg.BaseDir, "repos")
 		s.gitService = git.NewService(database, worktreeDir, reposDir)
+		if cfg.DebugRetention != nil {
+			s.gitService.SetDebugRetention(*cfg.DebugRetention)
+		}
 	}
 
 	// Create scheduler for session management
-	scheduler := orchestrator.NewScheduler(database, s.taskService, 25) // Max 25 parallel sessions
+	scheduler := orchestrator.NewScheduler(database, s.taskService, orchestrator.DefaultMaxParallel)
+	s.scheduler = scheduler
+	s.queuedStarts = make(map[string]startTaskOptions)
+	s.maxTasksPerRepo = cfg.RepoLimit
+	s.repoStarting = make(map[string]int)
+	s.questStarting = make(map[string]int)
 
 	// Create session manager
 	sessionMgr := session.NewManager(database, scheduler, "prompts")
@@ -213,6 +303,93 @@ func NewServer(database *db.DB, cfg Config) *Server {
 		sessionMgr.SetAnthropicClient(cfg.Toolbelt.Anthropic)
 	}
 
+	// Wire up git credentials: per-project first, then the global GitHub client
+	if cfg.Toolbelt != nil {
+		sessionMgr.SetGitHubClient(cfg.Toolbelt.GitHub)
+	}
+	if secretsStore != nil {
+		sessionMgr.SetGitCredentialStore(secretsStore)
+	}
+
+	if cfg.LLMTimeout > 0 {
+		sessionMgr.SetRequestTimeout(cfg.LLMTimeout)
+	}
+
+	if cfg.MaxMessages != 0 {
+		sessionMgr.SetMaxMessages(cfg.MaxMessages)
+	}
+
+	if cfg.MaxTaskCost > 0 {
+		sessionMgr.SetMaxTaskCost(cfg.MaxTaskCost)
+	}
+
+	if cfg.BudgetWarns != nil {
+		sessionMgr.SetBudgetWarnings(cfg.BudgetWarns)
+	}
+
+	if cfg.HatTokens != nil {
+		sessionMgr.SetHatMaxTokens(cfg.HatTokens)
+	}
+
+	if cfg.GitRetry != nil {
+		if s.gitService != nil {
+			s.gitService.Operations().SetPushRetry(*cfg.GitRetry)
+		}
+		sessionMgr.SetGitRetry(*cfg.GitRetry)
+	}
+
+	if cfg.Secrets != nil {
+		sessionMgr.SetSecretScanner(cfg.Secrets)
+	}
+
+	if cfg.Injection != nil {
+		sessionMgr.SetInjectionDetector(cfg.Injection)
+	}
+
+	sessionMgr.SetHandoffOptions(cfg.Handoff)
+
+	if cfg.Preamble != "" {
+		if err := sessionMgr.SetPreamble(cfg.Preamble); err != nil {
+			fmt.Printf("Warning: failed to apply prompt preamble: %v\n", err)
+		}
+	}
+
+	if cfg.GitAuthor != nil {
+		if err := sessionMgr.SetDefaultGitAuthor(cfg.GitAuthor); err != nil {
+			fmt.Printf("Warning: failed to apply git author: %v\n", err)
+		}
+	}
+
+	if cfg.Trailer != "" {
+		if err := sessionMgr.SetCommitTrailer(cfg.Trailer); err != nil {
+			fmt.Printf("Warning: failed to apply commit trailer: %v\n", err)
+		}
+	}
+
+	if cfg.Activity != "" {
+		if err := sessionMgr.SetActivityLevel(cfg.Activity); err != nil {
+			fmt.Printf("Warning: failed to apply activity level: %v\n", err)
+		}
+	}
+
+	if cfg.Sensitivity != "" {
+		if err := sessionMgr.SetPromptSensitivity(cfg.Sensitivity); err != nil {
+			fmt.Printf("Warning: failed to apply prompt sensitivity: %v\n", err)
+		}
+	}
+
+	if cfg.Broadcast != "" {
+		if err := sessionMgr.SetActivityBroadcastLevel(cfg.Broadcast); err != nil {
+			fmt.Printf("Warning: failed to apply activity broadcast level: %v\n", err)
+		}
+	}
+
+	if cfg.Signals != nil && (!cfg.Signals.IsDefault() || len(cfg.Signals.StopSequences) > 0) {
+		if err := sessionMgr.SetSignals(*cfg.Signals); err != nil {
+			fmt.Printf("Warning: failed to apply signal configuration: %v\n", err)
+		}
+	}
+
 	// Wire up Central mail/calendar config for AI sessions
 	if cfg.CentralURL != "" && cfg.TunnelToken != "" {
 		sessionMgr.SetMailConfig(cfg.CentralURL, cfg.TunnelToken)
@@ -220,6 +397,14 @@ func NewServer(database *db.DB, cfg Config) *Server {
 
 	s.sessionManager = sessionMgr
 
+	// Ship HQ's prompts with dispatched objectives so workers run the same hat prompts
+	if workerMgr != nil {
+		workerMgr.SetPromptSet(sessionMgr.GetPromptLoader().PromptSet())
+		if err := workerMgr.SetObjectiveModel(cfg.WorkerModel); err != nil {
+			fmt.Printf("Warning: failed to apply worker model: %v\n", err)
+		}
+	}
+
 	// Create planner for task planning phase
 	if cfg.Toolbelt != nil && cfg.Toolbelt.Anthropic != nil {
 		s.planner = planning.NewPlanner(database, cfg.Toolbelt.Anthropic, broadcaster)
@@ -227,6 +412,7 @@ func NewServer(database *db.DB, cfg Config) *Server {
 		s.questHandler = quest.NewHandler(database, cfg.Toolbelt.Anthropic, broadcaster)
 		s.questHandler.SetPromptLoader(sessionMgr.GetPromptLoader())
 		s.questHandler.SetBaseDir(cfg.BaseDir)
+		s.questHandler.SetHistoryWindow(s.questWindow)
 	}
 
 	// Initialize setup handler
@@ -310,6 +496,8 @@ func NewServer(database *db.DB, cfg Config) *Server {
 		GeneratePredecessorHandoff: func(t *db.Task) string {
 			return s.generatePredecessorHandoff(t)
 		},
+		AutoCompleteQuest:  s.autoCompleteQuest,
+		ResolveStartingHat: s.resolveStartingHat,
 		IsValidGitRepo:     s.isValidGitRepo,
 		IsValidProjectPath: s.isValidProjectPath,
 	}
@@ -320,6 +508,7 @@ func NewServer(database *db.DB, cfg Config) *Server {
 	// Wire up GitHub sync callbacks now that handlersSyncSvc exists
 	sessionMgr.SetOnTaskCompleted(func(taskID string) {
 		s.handlersSyncSvc.OnTaskCompleted(taskID)
+		s.autoCompleteTaskQuest(taskID)
 	})
 	sessionMgr.SetOnTaskFailed(func(taskID string, reason string) {
 		s.handlersSyncSvc.OnTaskFailed(taskID, reason)
@@ -333,6 +522,12 @@ func NewServer(database *db.DB, cfg Config) *Server {
 	sessionMgr.SetOnTaskStatus(func(taskID string, status string) {
 		s.handlersSyncSvc.UpdateObjectiveStatusSync(taskID, status)
 	})
+	// A stopped session may free a slot in its repo for a queued task
+	sessionMgr.SetOnSessionEnded(func(taskID string) {
+		if s.scheduler.QueueSize() > 0 {
+			s.startQueuedTasks()
+		}
+	})
 
 	// Wire up worker manager callbacks for realtime updates
 	if workerMgr != nil {
@@ -371,6 +566,7 @@ func NewServer(database *db.DB, cfg Config) *Server {
 			func(report *worker.CompletionReport) {
 				// Update task status
 				_ = database.UpdateTaskStatus(report.ObjectiveID, report.Status)
+				s.autoCompleteTaskQuest(report.ObjectiveID)
 
 				// Broadcast completion
 				if broadcaster != nil {
@@ -389,6 +585,7 @@ func NewServer(database *db.DB, cfg Config) *Server {
 			// onFailed: handle task failure
 			func(objectiveID, sessionID, errMsg string) {
 				_ = database.UpdateTaskStatus(objectiveID, "failed")
+				s.autoCompleteTaskQuest(objectiveID)
 
 				if broadcaster != nil {
 					broadcaster.PublishWorkerFailed(objectiveID, map[string]any{
@@ -399,6 +596,60 @@ func NewServer(database *db.DB, cfg Config) *Server {
 				}
 			},
 		)
+		// onTimedOut: surface timeouts so misbehaving workers are visible
+		workerMgr.SetOnTimedOut(func(objectiveID, workerID, reason string, requeued bool) {
+			if !requeued {
+				_ = database.UpdateTaskStatus(objectiveID, db.TaskStatusTimedOut)
+				s.autoCompleteTaskQuest(objectiveID)
+			}
+
+			if broadcaster != nil {
+				broadcaster.PublishWorkerTimedOut(objectiveID, map[string]any{
+					"objective_id": objectiveID,
+					"worker_id":    workerID,
+					"reason":       reason,
+					"requeued":     requeued,
+				})
+			}
+		})
+		// onRejected: report acceptance criteria no worker could meet
+		workerMgr.SetOnRejected(func(objectiveID, workerID string, unmet []string, requeued bool) {
+			if !requeued {
+				_ = database.UpdateTaskStatus(objectiveID, "failed")
+				s.autoCompleteTaskQuest(objectiveID)
+			}
+
+			if broadcaster != nil {
+				broadcaster.PublishWorkerRejected(objectiveID, map[string]any{
+					"objective_id": objectiveID,
+					"worker_id":    workerID,
+					"unmet":        unmet,
+					"requeued":     requeued,
+				})
+			}
+		})
+		// Secrets aren't persisted with the dispatch queue; objectives recovered
+		// from it after a restart are dispatched with fresh ones
+		workerMgr.SetSecretsSource(func() (*worker.WorkerSecrets, error) {
+			secrets, err := workershandlers.GetWorkerSecrets(s.deps)
+			return &secrets, err
+		})
+		// onPlanPreview: hold objectives until someone approves the worker's plan
+		workerMgr.SetOnPlanPreview(func(workerID string, preview *worker.PlanPreviewPayload) {
+			if _, err := workershandlers.RequestPlanApproval(s.deps, workerID, preview); err != nil {
+				fmt.Printf("Warning: failed to request plan approval for %s: %v\n", preview.ObjectiveID, err)
+			}
+		})
+		// onLog: relay worker stderr to operators watching HQ
+		workerMgr.SetOnLog(func(workerID string, payload *worker.LogPayload) {
+			if broadcaster != nil {
+				broadcaster.PublishWorkerLog(workerID, map[string]any{
+					"objective_id": payload.ObjectiveID,
+					"lines":        payload.Lines,
+					"dropped":      payload.Dropped,
+				})
+			}
+		})
 	}
 
 	// Initialize OIDC handler if public URL is configured (for SSO)
@@ -437,6 +688,7 @@ func (s *Server) registerRoutes() {
 	tasksHandler := tasks.New(s.deps)
 	projectsHandler := projects.New(s.deps)
 	memoryHandler := memory.New(s.deps)
+	searchHandler := searchhandlers.New(s.deps)
 	approvalsHandler := approvals.New(s.deps)
 	sessionsHandler := sessionshandlers.New(s.deps)
 	planningHandler := planninghandlers.New(s.deps)
@@ -444,9 +696,11 @@ func (s *Server) registerRoutes() {
 	questsHandler := quests.New(s.deps)
 	objectivesHandler := quests.NewObjectivesHandler(s.deps)
 	templatesHandler := quests.NewTemplatesHandler(s.deps)
+	objectiveTemplatesHandler := quests.NewObjectiveTemplatesHandler(s.deps)
 	meshHandler := meshhandlers.New(s.deps)
 	workersHandler := workershandlers.New(s.deps)
 	forgejoHandler := forgejohandlers.New(s.deps)
+	hatsHandler := hatshandlers.New(s.deps)
 	devicesHandler := deviceshandlers.New(s.deps, deviceshandlers.Config{
 		Namespace:   s.namespace,
 		TunnelToken: s.tunnelToken,
@@ -466,29 +720,35 @@ func (s *Server) registerRoutes() {
 
 	// Public endpoints (no auth required)
 	v1.GET("/system/status", s.handleHealthCheck)
+	v1.GET("/system/info", s.handleSystemInfo)
 
-	// Register public routes
-	toolbeltHandler.RegisterPublicRoutes(v1)
-	passkeyHandler.RegisterRoutes(v1)
+	// Register public routes. Auth, setup, and toolbelt tests are rate limited
+	// per IP when configured, since HQ may be reachable from the internet.
+	public := v1.Group("")
+	if s.rateLimit.Enabled() {
+		public.Use(middleware.RateLimit(s.rateLimit, s.tokenConfig))
+	}
+	toolbeltHandler.RegisterPublicRoutes(public)
+	passkeyHandler.RegisterRoutes(public)
 
 	// Setup endpoints (for onboarding flow - public during initial setup)
-	v1.GET("/setup/status", s.setupHandler.HandleStatus)
-	v1.POST("/setup/anthropic-key", s.setupHandler.HandleSetAnthropicKey)
-	v1.POST("/setup/complete", s.setupHandler.HandleComplete)
-	v1.POST("/setup/workspace", s.setupHandler.HandleWorkspaceSetup)
+	public.GET("/setup/status", s.setupHandler.HandleStatus)
+	public.POST("/setup/anthropic-key", s.setupHandler.HandleSetAnthropicKey)
+	public.POST("/setup/complete", s.setupHandler.HandleComplete)
+	public.POST("/setup/workspace", s.setupHandler.HandleWorkspaceSetup)
 
 	// New onboarding step endpoints
-	v1.POST("/setup/steps/welcome", s.setupHandler.HandleAdvanceWelcome)
-	v1.POST("/setup/steps/passkey", s.setupHandler.HandleCompletePasskey)
-	v1.POST("/setup/steps/anthropic", s.setupHandler.HandleSetAnthropicKey)
+	public.POST("/setup/steps/welcome", s.setupHandler.HandleAdvanceWelcome)
+	public.POST("/setup/steps/passkey", s.setupHandler.HandleCompletePasskey)
+	public.POST("/setup/steps/anthropic", s.setupHandler.HandleSetAnthropicKey)
 
 	// Validation endpoints
-	v1.POST("/setup/validate/anthropic-key", s.setupHandler.HandleValidateAnthropicKey)
+	public.POST("/setup/validate/anthropic-key", s.setupHandler.HandleValidateAnthropicKey)
 
 	// Dex profile bootstrap (tray → HQ, pre-auth, one-time use)
-	v1.POST("/setup/dex-profile", s.setupHandler.HandleBootstrapDexProfile)
-	v1.GET("/setup/dex-profile", s.setupHandler.HandleGetDexProfile)
-	v1.GET("/setup/dex-avatar", s.setupHandler.HandleGetDexAvatar)
+	public.POST("/setup/dex-profile", s.setupHandler.HandleBootstrapDexProfile)
+	public.GET("/setup/dex-profile", s.setupHandler.HandleGetDexProfile)
+	public.GET("/setup/dex-avatar", s.setupHandler.HandleGetDexAvatar)
 
 	// Protected endpoints (require JWT auth)
 	// Use middleware if token config is available, otherwise allow all (dev mode)
@@ -497,13 +757,21 @@ func (s *Server) registerRoutes() {
 		protected.Use(middleware.JWTAuth(s.tokenConfig))
 	}
 
-	// User info
-	protected.GET("/me", toolbeltHandler.HandleMe)
+	// User info and toolbelt check history
+	toolbeltHandler.RegisterProtectedRoutes(protected)
+
+	// Stop or restart new tasks starting (running sessions are unaffected)
+	protected.POST("/system/pause", s.handlePauseScheduler)
+	protected.POST("/system/resume", s.handleResumeScheduler)
+
+	// Anthropic API rate limits and request throttling
+	protected.GET("/system/rate-limit", s.handleRateLimitStatus)
 
 	// Register protected routes from handlers
 	tasksHandler.RegisterRoutes(protected)
 	projectsHandler.RegisterRoutes(protected)
 	memoryHandler.RegisterRoutes(protected)
+	searchHandler.RegisterRoutes(protected)
 	approvalsHandler.RegisterRoutes(protected)
 	sessionsHandler.RegisterRoutes(protected)
 	planningHandler.RegisterRoutes(protected)
@@ -511,15 +779,18 @@ func (s *Server) registerRoutes() {
 	questsHandler.RegisterRoutes(protected)
 	objectivesHandler.RegisterRoutes(protected)
 	templatesHandler.RegisterRoutes(protected)
+	objectiveTemplatesHandler.RegisterRoutes(protected)
 	meshHandler.RegisterRoutes(protected)
 	workersHandler.RegisterRoutes(protected)
 	forgejoHandler.RegisterRoutes(protected)
+	hatsHandler.RegisterRoutes(protected)
 	devicesHandler.RegisterRoutes(protected)
 	mailHandler.RegisterRoutes(protected)
 	meshOnboardHandler.RegisterRoutes(protected)
 
 	// Centrifuge WebSocket endpoint for real-time updates
-	// Auth is handled via Centrifuge protocol in Node.OnConnecting, not HTTP middleware
+	// Auth is checked on the upgrade (?token= query param) by the node's own handler,
+	// then in Node.OnConnecting, rather than by the JWT middleware
 	if s.realtime != nil {
 		v1.GET("/realtime", echo.WrapHandler(s.realtime.WebSocketHandler()))
 	}
@@ -532,15 +803,79 @@ func (s *Server) registerRoutes() {
 	}
 }
 
+// handlePauseScheduler stops new tasks from starting. Running sessions carry
+// on, and tasks started while paused are queued until the scheduler resumes.
+// POST /api/v1/system/pause
+func (s *Server) handlePauseScheduler(c echo.Context) error {
+	s.scheduler.Pause()
+	_, pausedAt := s.scheduler.IsPaused()
+	fmt.Printf("Scheduler paused: new tasks will queue until resumed\n")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"paused":    true,
+		"paused_at": pausedAt.UTC().Format(time.RFC3339),
+		"queued":    s.scheduler.QueueSize(),
+	})
+}
+
+// handleResumeScheduler lets tasks start again and starts the ones that
+// queued while paused, highest priority first.
+// POST /api/v1/system/resume
+func (s *Server) handleResumeScheduler(c echo.Context) error {
+	s.scheduler.Resume()
+	fmt.Printf("Scheduler resumed: starting %d queued tasks\n", s.scheduler.QueueSize())
+
+	started, failed := s.startQueuedTasks()
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"paused":  false,
+		"started": started,
+		"failed":  failed,
+	})
+}
+
+// handleRateLimitStatus reports the Anthropic rate limits from the latest API
+// response and whether requests are being delayed to stay under them, along
+// with the load on the shared request gate
+// GET /api/v1/system/rate-limit
+func (s *Server) handleRateLimitStatus(c echo.Context) error {
+	s.toolbeltMu.RLock()
+	var anthropic *toolbelt.AnthropicClient
+	if s.toolbelt != nil {
+		anthropic = s.toolbelt.Anthropic
+	}
+	s.toolbeltMu.RUnlock()
+
+	gate := toolbelt.AnthropicRequestGateStatus()
+	if anthropic == nil {
+		return c.JSON(http.StatusOK, map[string]any{"configured": false, "gate": gate})
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"configured": true,
+		"anthropic":  anthropic.RateLimitStatus(),
+		"gate":       gate,
+	})
+}
+
 // handleHealthCheck returns system health status
 func (s *Server) handleHealthCheck(c echo.Context) error {
 	status := map[string]any{
 		"status":    "healthy",
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
-		"version":   "0.1.0-dev",
+		"version":   s.version,
 		"database":  "connected",
 	}
 
+	paused, pausedAt := s.scheduler.IsPaused()
+	scheduler := map[string]any{
+		"paused": paused,
+		"queued": s.scheduler.QueueSize(),
+	}
+	if paused {
+		scheduler["paused_at"] = pausedAt.UTC().Format(time.RFC3339)
+	}
+	status["scheduler"] = scheduler
+
 	// Verify database connection
 	if err := s.db.Ping(); err != nil {
 		status["status"] = "unhealthy"
@@ -646,6 +981,12 @@ func (s *Server) Start() error {
 		}
 	}
 
+	// Start periodic toolbelt connection tests
+	s.startToolbeltMonitor()
+
+	// Start periodic pruning of debug worktrees
+	s.startWorktreePruner()
+
 	// Start HTTP server FIRST in a goroutine, before mesh/tunnel
 	// This ensures the local services are listening before the tunnel starts routing traffic
 	httpErr := make(chan error, 1)
@@ -760,6 +1101,16 @@ func (s *Server) Start() error {
 
 // Shutdown gracefully stops the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	// Stop toolbelt monitor
+	if s.stopToolbeltMonitor != nil {
+		s.stopToolbeltMonitor()
+	}
+
+	// Stop worktree pruner
+	if s.stopWorktreePruner != nil {
+		s.stopWorktreePruner()
+	}
+
 	// Stop worker manager
 	if s.workerManager != nil {
 		if err := s.workerManager.Stop(ctx); err != nil {
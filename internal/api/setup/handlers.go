@@ -84,7 +84,7 @@ func (h *Handler) HandleStatus(c echo.Context) error {
 
 	// Check actual state for reconciliation
 	hasPasskey, _ := h.db.HasAnyCredentials()
-	hasAnthropicKey := h.db.HasSecret(db.SecretKeyAnthropicKey)
+	hasAnthropicKey := h.hasAnthropicKey()
 
 	// Determine current step based on actual state
 	actualStep := DetermineCurrentStep(progress, hasPasskey, hasAnthropicKey)
@@ -239,6 +239,13 @@ func (h *Handler) HandleValidateAnthropicKey(c echo.Context) error {
 	})
 }
 
+// hasAnthropicKey reports whether an Anthropic key is set, in the environment
+// or entered during setup
+func (h *Handler) hasAnthropicKey() bool {
+	stored, _ := h.db.GetSecret(db.SecretKeyAnthropicKey)
+	return toolbelt.ResolveConfig(stored, "").Anthropic != nil
+}
+
 // HandleComplete finalizes the setup process
 func (h *Handler) HandleComplete(c echo.Context) error {
 	dataDir := h.getDataDir()
@@ -249,7 +256,7 @@ func (h *Handler) HandleComplete(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "passkey not registered")
 	}
 
-	if !h.db.HasSecret(db.SecretKeyAnthropicKey) {
+	if !h.hasAnthropicKey() {
 		return echo.NewHTTPError(http.StatusBadRequest, "Anthropic API key not set")
 	}
 
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/task"
+)
+
+// autonomyProfile describes what a task autonomy level lets sessions do
+type autonomyProfile struct {
+	Level       int    `json:"level"`
+	Description string `json:"description"`
+}
+
+// autonomyProfiles lists the autonomy levels a task or project can use
+var autonomyProfiles = []autonomyProfile{
+	{Level: 0, Description: "Every PR waits for manual approval before merging"},
+	{Level: 1, Description: "PRs are merged automatically"},
+	{Level: 2, Description: "PRs are merged automatically"},
+	{Level: 3, Description: "PRs are merged automatically"},
+}
+
+// handleSystemInfo reports what this server can do, so the frontend can adapt
+// its controls without probing other endpoints. None of it is sensitive.
+// GET /api/v1/system/info
+func (s *Server) handleSystemInfo(c echo.Context) error {
+	s.toolbeltMu.RLock()
+	anthropic := s.toolbelt != nil && s.toolbelt.Anthropic != nil
+	s.toolbeltMu.RUnlock()
+
+	githubApp, err := s.db.HasGitHubApp()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	forgejoRunning := s.forgejoManager != nil && s.forgejoManager.IsRunning()
+
+	var accessMethod string
+	if data, err := os.ReadFile(filepath.Join(s.getDataDir(), "access-method")); err == nil {
+		accessMethod = strings.TrimSpace(string(data))
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"version": s.version,
+		"features": map[string]any{
+			"mesh":       s.meshClient != nil,
+			"forgejo":    forgejoRunning,
+			"github_app": githubApp,
+			"planning":   anthropic,
+			"quests":     anthropic,
+			"workers":    s.workerManager != nil,
+		},
+		"access_method": accessMethod,
+		"models": map[string]any{
+			"task":          []string{db.TaskModelSonnet, db.TaskModelOpus},
+			"quest":         []string{db.QuestModelSonnet, db.QuestModelOpus},
+			"default_task":  db.TaskModelSonnet,
+			"default_quest": db.QuestModelSonnet,
+		},
+		"autonomy": map[string]any{
+			"default":  task.DefaultAutonomyLevel,
+			"profiles": autonomyProfiles,
+		},
+	})
+}
@@ -2,15 +2,20 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/lirancohen/dex/internal/api/core"
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/orchestrator"
 	"github.com/lirancohen/dex/internal/pathutil"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/session"
+	"github.com/lirancohen/dex/internal/task"
 )
 
 // startTaskResult contains the result of starting a task
@@ -24,12 +29,22 @@ type startTaskResult struct {
 type startTaskOptions struct {
 	BaseBranch         string // Base branch for worktree creation
 	InheritedWorktree  string // Worktree to inherit from predecessor
+	InheritedBranch    string // Branch checked out in the inherited worktree
+	ReuseWorktree      bool   // The task asked to reuse the worktree, so it may not fall back to a new one
 	PredecessorHandoff string // Context from predecessor task
+	Priority           string // Session priority behind the request gate (empty = normal)
 }
 
 // startTask starts a task with the given options
 // This is the single entry point for all task starting logic
 func (s *Server) startTask(ctx context.Context, taskID string, opts startTaskOptions) (*startTaskResult, error) {
+	// Manual, quest, and dependency starts can race for the same task
+	unlock, err := s.taskService.LockLifecycle(taskID, "start")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	// Get the task
 	t, err := s.taskService.Get(taskID)
 	if err != nil {
@@ -50,22 +65,57 @@ func (s *Server) startTask(ctx context.Context, taskID string, opts startTaskOpt
 		return nil, fmt.Errorf("task already has a worktree")
 	}
 
+	// A task that has spent its cost ceiling can't start another session
+	if err := s.sessionManager.CheckTaskCost(taskID); err != nil {
+		return nil, err
+	}
+
+	// While the scheduler is paused, new tasks queue instead of starting
+	if paused, _ := s.scheduler.IsPaused(); paused {
+		return nil, s.queueTask(taskID, t.Status, opts, orchestrator.ErrPaused, "until it resumes")
+	}
+
+	// So do tasks whose repo already has as many running tasks as it allows
+	release, ok := s.reserveRepoSlot(project)
+	if !ok {
+		return nil, s.queueTask(taskID, t.Status, opts, orchestrator.ErrRepoBusy, "until another task in the repo stops")
+	}
+
+	// And tasks whose quest already has as many running objectives as it allows
+	releaseQuest, ok := s.reserveQuestSlot(t)
+	if !ok {
+		release()
+		return nil, s.queueTask(taskID, t.Status, opts, orchestrator.ErrQuestBusy, "until another of the quest's tasks stops")
+	}
+
 	// Resolve the worktree path
 	worktreePath, err := s.resolveWorktreePath(taskID, project, opts)
 	if err != nil {
+		releaseQuest()
+		release()
 		return nil, err
 	}
 
-	// Transition to running status
-	if err := s.transitionTaskToRunning(taskID, t.Status); err != nil {
+	// Transition to running status; from here the task counts against its repo
+	// and quest itself
+	err = s.transitionTaskToRunning(taskID, t.Status)
+	releaseQuest()
+	release()
+	if err != nil {
 		return nil, err
 	}
 
+	// A queued task started again directly no longer waits in the queue
+	s.scheduler.Dequeue(taskID)
+	s.queuedStartsMu.Lock()
+	delete(s.queuedStarts, taskID)
+	s.queuedStartsMu.Unlock()
+
 	// Broadcast task started
 	s.broadcastTaskUpdated(taskID, "running")
 
 	// Create and start session
-	sess, err := s.createAndStartSession(ctx, taskID, t, worktreePath, opts.PredecessorHandoff)
+	sess, err := s.createAndStartSession(ctx, taskID, t, worktreePath, opts.PredecessorHandoff, opts.Priority)
 	if err != nil {
 		return nil, err
 	}
@@ -80,18 +130,84 @@ func (s *Server) startTask(ctx context.Context, taskID string, opts startTaskOpt
 	}, nil
 }
 
+// queueTask holds a task in the scheduler's queue until it can start. The
+// returned error wraps reason (orchestrator.ErrPaused, ErrRepoBusy, or
+// ErrQuestBusy) so callers can tell the task was queued rather than failed.
+func (s *Server) queueTask(taskID, status string, opts startTaskOptions, reason error, until string) error {
+	if status == db.TaskStatusPending || status == db.TaskStatusBlocked {
+		if err := s.taskService.UpdateStatus(taskID, db.TaskStatusReady); err != nil {
+			return fmt.Errorf("failed to transition to ready: %w", err)
+		}
+	}
+
+	if err := s.scheduler.Enqueue(taskID); err != nil {
+		return fmt.Errorf("failed to queue task: %w", err)
+	}
+	s.queuedStartsMu.Lock()
+	s.queuedStarts[taskID] = opts
+	s.queuedStartsMu.Unlock()
+
+	s.broadcastTaskUpdated(taskID, db.TaskStatusReady)
+	fmt.Printf("startTask: %v, queued task %s\n", reason, taskID)
+	return fmt.Errorf("%w: task %s is queued %s", reason, taskID, until)
+}
+
+// startQueuedTasks starts the queued tasks that can start now, highest priority
+// first: those queued while the scheduler was paused, and those waiting on a
+// repo or quest whose running tasks have stopped. It returns the started task
+// IDs and errors by task ID.
+func (s *Server) startQueuedTasks() ([]string, map[string]string) {
+	started := []string{}
+	failed := map[string]string{}
+
+	for {
+		item := s.scheduler.NextStartable(s.canStartQueuedTask)
+		if item == nil {
+			break
+		}
+
+		s.queuedStartsMu.Lock()
+		opts := s.queuedStarts[item.TaskID]
+		delete(s.queuedStarts, item.TaskID)
+		s.queuedStartsMu.Unlock()
+
+		// The start outlives the request that resumed the scheduler
+		result, err := s.startTask(context.Background(), item.TaskID, opts)
+		if orchestrator.IsQueued(err) {
+			// Another start took the repo's slot first; it stays queued
+			continue
+		}
+		if err != nil {
+			fmt.Printf("startQueuedTasks: failed to start task %s: %v\n", item.TaskID, err)
+			failed[item.TaskID] = err.Error()
+			continue
+		}
+		fmt.Printf("startQueuedTasks: started task %s (session %s)\n", item.TaskID, result.SessionID)
+		started = append(started, item.TaskID)
+	}
+
+	return started, failed
+}
+
 // resolveWorktreePath determines the appropriate working directory for a task
 func (s *Server) resolveWorktreePath(taskID string, project *db.Project, opts startTaskOptions) (string, error) {
-	// Try to inherit worktree from predecessor
+	// Try to inherit worktree from predecessor, unless another task is already working in it
 	if opts.InheritedWorktree != "" {
 		if _, err := os.Stat(opts.InheritedWorktree); err == nil {
-			fmt.Printf("resolveWorktreePath: task %s inheriting worktree %s\n", taskID, opts.InheritedWorktree)
-			if err := s.db.UpdateTaskWorktree(taskID, opts.InheritedWorktree, ""); err != nil {
+			err := s.db.ClaimTaskWorktree(taskID, opts.InheritedWorktree, opts.InheritedBranch)
+			if err == nil {
+				fmt.Printf("resolveWorktreePath: task %s inheriting worktree %s\n", taskID, opts.InheritedWorktree)
+				return opts.InheritedWorktree, nil
+			}
+			if opts.ReuseWorktree || !errors.Is(err, db.ErrWorktreeInUse) {
 				return "", fmt.Errorf("failed to save inherited worktree path: %w", err)
 			}
-			return opts.InheritedWorktree, nil
+			fmt.Printf("resolveWorktreePath: inherited worktree %s is busy (%v), creating new\n", opts.InheritedWorktree, err)
+		} else if opts.ReuseWorktree {
+			return "", fmt.Errorf("%w: worktree %s no longer exists", core.ErrWorktreeReuse, opts.InheritedWorktree)
+		} else {
+			fmt.Printf("resolveWorktreePath: inherited worktree %s no longer exists, creating new\n", opts.InheritedWorktree)
 		}
-		fmt.Printf("resolveWorktreePath: inherited worktree %s no longer exists, creating new\n", opts.InheritedWorktree)
 	}
 
 	projectPath := project.RepoPath
@@ -164,11 +280,8 @@ func (s *Server) transitionTaskToRunning(taskID, currentStatus string) error {
 }
 
 // createAndStartSession creates a session for a task and starts it
-func (s *Server) createAndStartSession(ctx context.Context, taskID string, task *db.Task, worktreePath, predecessorHandoff string) (*struct{ ID string }, error) {
-	hat := "creator"
-	if task.Hat.Valid && task.Hat.String != "" {
-		hat = task.Hat.String
-	}
+func (s *Server) createAndStartSession(ctx context.Context, taskID string, task *db.Task, worktreePath, predecessorHandoff, priority string) (*struct{ ID string }, error) {
+	hat := s.resolveStartingHat(task)
 
 	sess, err := s.sessionManager.CreateSession(taskID, hat, worktreePath)
 	if err != nil {
@@ -179,6 +292,12 @@ func (s *Server) createAndStartSession(ctx context.Context, taskID string, task
 		s.sessionManager.SetPredecessorContext(sess.ID, predecessorHandoff)
 	}
 
+	if priority != "" {
+		if err := s.sessionManager.SetPriority(sess.ID, priority); err != nil {
+			fmt.Printf("createAndStartSession: warning - failed to set session priority: %v\n", err)
+		}
+	}
+
 	if err := s.sessionManager.Start(ctx, sess.ID); err != nil {
 		return nil, fmt.Errorf("failed to start session: %w", err)
 	}
@@ -186,6 +305,32 @@ func (s *Server) createAndStartSession(ctx context.Context, taskID string, task
 	return &struct{ ID string }{ID: sess.ID}, nil
 }
 
+// resolveStartingHat returns the hat a task's session starts in: the task's own
+// hat if set, otherwise the project's starting hat, otherwise one picked by
+// session.SelectStartingHat
+func (s *Server) resolveStartingHat(task *db.Task) string {
+	if task.Hat.Valid && task.Hat.String != "" {
+		return task.Hat.String
+	}
+
+	hat, err := s.db.GetProjectStartingHat(task.ProjectID)
+	if err != nil {
+		fmt.Printf("resolveStartingHat: warning - failed to get project starting hat: %v\n", err)
+		hat = db.StartingHatAuto
+	}
+	if hat != db.StartingHatAuto {
+		return hat
+	}
+
+	input := session.StartingHatInput{TaskType: task.Type, Description: task.Description.String}
+	if planning, err := s.db.GetPlanningSessionByTaskID(task.ID); err == nil && planning != nil {
+		input.HasPlan = planning.RefinedPrompt.Valid && planning.RefinedPrompt.String != ""
+	}
+	hat, reason := session.SelectStartingHat(input)
+	fmt.Printf("resolveStartingHat: task %s starts as %s (%s)\n", task.ID, hat, reason)
+	return hat
+}
+
 // broadcastTaskUpdated sends a task.updated WebSocket event
 func (s *Server) broadcastTaskUpdated(taskID, status string) {
 	if s.broadcaster != nil {
@@ -201,20 +346,56 @@ func (s *Server) broadcastTaskUpdated(taskID, status string) {
 }
 
 // startTaskInternal starts a task by ID with an optional base branch
-// This is a convenience wrapper for external callers
+// This is a convenience wrapper for external callers. A task set to reuse a
+// predecessor's worktree continues in it and its branch instead. Callers set
+// the session's priority with session.WithStartPriority on ctx.
 func (s *Server) startTaskInternal(ctx context.Context, taskID string, baseBranch string) (*startTaskResult, error) {
-	return s.startTask(ctx, taskID, startTaskOptions{
+	opts, err := s.withWorktreeReuse(taskID, startTaskOptions{
 		BaseBranch: baseBranch,
+		Priority:   session.StartPriority(ctx),
 	})
+	if err != nil {
+		return nil, err
+	}
+	return s.startTask(ctx, taskID, opts)
+}
+
+// withWorktreeReuse points opts at the worktree and branch of the predecessor
+// the task is set to reuse, if any. That choice overrides any worktree the
+// caller would otherwise inherit.
+func (s *Server) withWorktreeReuse(taskID string, opts startTaskOptions) (startTaskOptions, error) {
+	predecessorID, err := s.db.GetTaskReuseWorktreeFrom(taskID)
+	if err != nil || predecessorID == "" {
+		return opts, err
+	}
+
+	predecessor, err := s.db.GetTaskByID(predecessorID)
+	if err != nil {
+		return opts, fmt.Errorf("%w: failed to get task %s: %w", core.ErrWorktreeReuse, predecessorID, err)
+	}
+	if predecessor == nil || predecessor.GetWorktreePath() == "" {
+		return opts, fmt.Errorf("%w: task %s has no worktree", core.ErrWorktreeReuse, predecessorID)
+	}
+
+	opts.InheritedWorktree = predecessor.GetWorktreePath()
+	opts.InheritedBranch = predecessor.GetBranchName()
+	opts.ReuseWorktree = true
+	return opts, nil
 }
 
 // startTaskWithInheritance starts a task, optionally inheriting a worktree from a predecessor
-// This is a convenience wrapper for task dependency handling
+// This is a convenience wrapper for task dependency handling. Nobody asked for
+// these tasks to start, so their sessions run at low priority.
 func (s *Server) startTaskWithInheritance(ctx context.Context, taskID string, inheritedWorktree string, predecessorHandoff string) (*startTaskResult, error) {
-	return s.startTask(ctx, taskID, startTaskOptions{
+	opts, err := s.withWorktreeReuse(taskID, startTaskOptions{
 		InheritedWorktree:  inheritedWorktree,
 		PredecessorHandoff: predecessorHandoff,
+		Priority:           db.SessionPriorityLow,
 	})
+	if err != nil {
+		return nil, err
+	}
+	return s.startTask(ctx, taskID, opts)
 }
 
 // handleTaskUnblocking finds tasks that became ready because the given task completed
@@ -246,24 +427,35 @@ func (s *Server) handleTaskUnblocking(ctx context.Context, completedTaskID strin
 		predecessorHandoff = s.generatePredecessorHandoff(completedTask)
 	}
 
-	for _, task := range tasksToAutoStart {
+	for _, ready := range tasksToAutoStart {
 		// Broadcast task unblocked event
 		if s.broadcaster != nil {
-			s.broadcaster.PublishTaskEvent(realtime.EventTaskUnblocked, task.ID, map[string]any{
+			s.broadcaster.PublishTaskEvent(realtime.EventTaskUnblocked, ready.ID, map[string]any{
 				"unblocked_by": completedTaskID,
-				"quest_id":     task.QuestID.String,
-				"title":        task.Title,
-				"project_id":   task.ProjectID,
+				"quest_id":     ready.QuestID.String,
+				"title":        ready.Title,
+				"project_id":   ready.ProjectID,
 			})
 		}
 
 		// Auto-start the task in a goroutine, inheriting predecessor's worktree
-		taskID := task.ID
-		projectID := task.ProjectID
-		inheritedWorktree := completedTask.GetWorktreePath()
-		handoff := predecessorHandoff
+		taskID := ready.ID
+		projectID := ready.ProjectID
+		opts := startTaskOptions{
+			InheritedWorktree:  completedTask.GetWorktreePath(),
+			InheritedBranch:    completedTask.GetBranchName(),
+			PredecessorHandoff: predecessorHandoff,
+		}
 		go func() {
-			startResult, err := s.startTaskWithInheritance(context.Background(), taskID, inheritedWorktree, handoff)
+			var startResult *startTaskResult
+			opts, err := s.withWorktreeReuse(taskID, opts)
+			if err == nil {
+				startResult, err = s.startTask(context.Background(), taskID, opts)
+			}
+			if orchestrator.IsQueued(err) || errors.Is(err, task.ErrTaskBusy) {
+				fmt.Printf("handleTaskUnblocking: %v\n", err)
+				return
+			}
 			if err != nil {
 				fmt.Printf("handleTaskUnblocking: auto-start failed for task %s: %v\n", taskID, err)
 				if s.broadcaster != nil {
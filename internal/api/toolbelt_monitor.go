@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// DefaultToolbeltCheckInterval is how often toolbelt connections are tested
+// in the background unless configured otherwise
+const DefaultToolbeltCheckInterval = 30 * time.Minute
+
+const (
+	toolbeltCheckTimeout     = time.Minute        // Bound on one round of connection tests
+	toolbeltHistoryRetention = 7 * 24 * time.Hour // Checks older than this are pruned
+)
+
+// startToolbeltMonitor tests toolbelt connections now and then every
+// toolbeltCheckInterval, until the server shuts down
+func (s *Server) startToolbeltMonitor() {
+	if s.toolbeltCheckInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopToolbeltMonitor = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.toolbeltCheckInterval)
+		defer ticker.Stop()
+
+		// Success of each service at the last check, to broadcast only changes
+		last := make(map[string]bool)
+		s.checkToolbelt(ctx, last)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.checkToolbelt(ctx, last)
+			}
+		}
+	}()
+	fmt.Printf("Toolbelt monitor started (every %s)\n", s.toolbeltCheckInterval)
+}
+
+// checkToolbelt runs one round of connection tests, records the results, and
+// broadcasts toolbelt.status if any service's health changed since last
+func (s *Server) checkToolbelt(ctx context.Context, last map[string]bool) {
+	s.toolbeltMu.RLock()
+	tb := s.toolbelt
+	s.toolbeltMu.RUnlock()
+	if tb == nil {
+		return
+	}
+
+	testCtx, cancel := context.WithTimeout(ctx, toolbeltCheckTimeout)
+	results := tb.TestConnections(testCtx)
+	cancel()
+	if ctx.Err() != nil {
+		// Shutting down: failures are from the cancellation, not the services
+		return
+	}
+
+	checkedAt := time.Now()
+	checks := make([]db.ToolbeltCheck, 0, len(results))
+	for _, r := range results {
+		checks = append(checks, db.ToolbeltCheck{
+			Service:   r.Name,
+			Success:   r.Success,
+			Error:     r.Error,
+			LatencyMs: r.Latency,
+			CheckedAt: checkedAt,
+		})
+	}
+	if err := s.db.RecordToolbeltChecks(checks); err != nil {
+		fmt.Printf("checkToolbelt: warning - failed to record results: %v\n", err)
+	}
+	if _, err := s.db.PruneToolbeltChecks(checkedAt.Add(-toolbeltHistoryRetention)); err != nil {
+		fmt.Printf("checkToolbelt: warning - failed to prune history: %v\n", err)
+	}
+
+	changed := toolbeltChanges(last, results)
+	if len(changed) == 0 {
+		return
+	}
+	for _, r := range results {
+		if !r.Success {
+			fmt.Printf("Toolbelt: %s connection failing: %s\n", r.Name, r.Error)
+		}
+	}
+	if s.broadcaster != nil {
+		s.broadcaster.Publish(realtime.EventToolbeltStatus, map[string]any{
+			"checked_at": checkedAt,
+			"changed":    changed,
+			"results":    results,
+		})
+	}
+}
+
+// toolbeltChanges returns the services whose success differs from last, or
+// which appeared or disappeared, and updates last to match results
+func toolbeltChanges(last map[string]bool, results []toolbelt.TestResult) []string {
+	changed := []string{}
+	seen := make(map[string]bool, len(results))
+	for _, r := range results {
+		seen[r.Name] = true
+		if prev, ok := last[r.Name]; !ok || prev != r.Success {
+			changed = append(changed, r.Name)
+		}
+		last[r.Name] = r.Success
+	}
+	for name := range last {
+		if !seen[name] {
+			changed = append(changed, name)
+			delete(last, name)
+		}
+	}
+	return changed
+}
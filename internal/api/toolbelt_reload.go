@@ -23,7 +23,8 @@ func (s *Server) getDataDir() string {
 	return "/opt/dex"
 }
 
-// ReloadToolbelt reloads the toolbelt from database secrets and updates the session manager
+// ReloadToolbelt reloads the toolbelt from the environment and database secrets
+// and updates the session manager
 // This is called after setup completes when API keys are first entered
 func (s *Server) ReloadToolbelt() error {
 	// First try to migrate any existing secrets from file to database
@@ -42,14 +43,8 @@ func (s *Server) ReloadToolbelt() error {
 
 	fmt.Printf("ReloadToolbelt: loading from database (%d secrets)\n", len(secrets))
 
-	// Build toolbelt config from secrets
-	config := &toolbelt.Config{}
-	if token := secrets[db.SecretKeyGitHubToken]; token != "" {
-		config.GitHub = &toolbelt.GitHubConfig{Token: token}
-	}
-	if key := secrets[db.SecretKeyAnthropicKey]; key != "" {
-		config.Anthropic = &toolbelt.AnthropicConfig{APIKey: key}
-	}
+	// Credentials set in the environment take precedence over stored ones
+	config := toolbelt.ResolveConfig(secrets[db.SecretKeyAnthropicKey], secrets[db.SecretKeyGitHubToken])
 
 	tb, err := toolbelt.New(config)
 	if err != nil {
@@ -61,6 +56,7 @@ func (s *Server) ReloadToolbelt() error {
 	s.toolbeltMu.Unlock()
 
 	// Update session manager with new clients
+	s.sessionManager.SetGitHubClient(tb.GitHub)
 	if tb.Anthropic != nil {
 		fmt.Println("ReloadToolbelt: Anthropic client initialized, updating session manager")
 		s.sessionManager.SetAnthropicClient(tb.Anthropic)
@@ -78,6 +74,7 @@ func (s *Server) ReloadToolbelt() error {
 			s.questHandler = quest.NewHandler(s.db, tb.Anthropic, s.broadcaster)
 			s.questHandler.SetPromptLoader(s.sessionManager.GetPromptLoader())
 			s.questHandler.SetBaseDir(s.getDataDir())
+			s.questHandler.SetHistoryWindow(s.questWindow)
 			s.deps.QuestHandler = s.questHandler
 			fmt.Println("ReloadToolbelt: Quest handler created")
 		}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// worktreePruneInterval is how often debug worktrees are checked against the retention policy
+const worktreePruneInterval = time.Hour
+
+// startWorktreePruner removes the worktrees of cancelled and timed-out tasks
+// the debug retention policy no longer keeps, now and then every
+// worktreePruneInterval, until the server shuts down
+func (s *Server) startWorktreePruner() {
+	if s.gitService == nil || s.gitService.DebugRetention().IsZero() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopWorktreePruner = cancel
+
+	go func() {
+		ticker := time.NewTicker(worktreePruneInterval)
+		defer ticker.Stop()
+
+		s.pruneDebugWorktrees()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.pruneDebugWorktrees()
+			}
+		}
+	}()
+	policy := s.gitService.DebugRetention()
+	fmt.Printf("Worktree pruner started (keep %d, max age %s)\n", policy.Keep, policy.MaxAge)
+}
+
+// pruneDebugWorktrees applies the debug retention policy once
+func (s *Server) pruneDebugWorktrees() {
+	result, err := s.gitService.PruneDebugWorktrees()
+	if err != nil {
+		fmt.Printf("pruneDebugWorktrees: warning - %v\n", err)
+		return
+	}
+	for _, e := range result.Errors {
+		fmt.Printf("pruneDebugWorktrees: warning - %s\n", e)
+	}
+	if len(result.Pruned) > 0 {
+		fmt.Printf("pruneDebugWorktrees: removed %d debug worktrees, kept %d\n", len(result.Pruned), result.Kept)
+	}
+}
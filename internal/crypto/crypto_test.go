@@ -493,3 +493,60 @@ func TestLoadWorkerIdentity_InvalidJSON(t *testing.T) {
 		t.Error("Should fail for invalid JSON")
 	}
 }
+
+func TestKeyPair_SignAndVerify(t *testing.T) {
+	kp, _ := GenerateKeyPair()
+	message := []byte("message to sign")
+
+	sig := kp.Sign(message)
+	if err := VerifySignature(kp.SigningPublicKey(), message, sig); err != nil {
+		t.Fatalf("VerifySignature failed: %v", err)
+	}
+
+	// Tampered message must fail
+	if err := VerifySignature(kp.SigningPublicKey(), []byte("tampered"), sig); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for tampered message, got %v", err)
+	}
+
+	// Different key must fail
+	other, _ := GenerateKeyPair()
+	if err := VerifySignature(other.SigningPublicKey(), message, sig); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for wrong key, got %v", err)
+	}
+
+	// Malformed key must fail
+	if err := VerifySignature("not-a-key", message, sig); err == nil {
+		t.Error("Expected error for malformed signing key")
+	}
+}
+
+func TestWorkerIdentity_SigningKeyStableAcrossLoads(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "identity.json")
+
+	identity, _ := NewWorkerIdentity("worker")
+	if err := identity.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadWorkerIdentity(path)
+	if err != nil {
+		t.Fatalf("LoadWorkerIdentity failed: %v", err)
+	}
+
+	if loaded.SigningPublicKey() != identity.SigningPublicKey() {
+		t.Error("Signing key should be derived deterministically from the identity")
+	}
+
+	sig, err := loaded.Sign([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := VerifySignature(identity.SigningPublicKey(), []byte("hello"), sig); err != nil {
+		t.Errorf("VerifySignature failed: %v", err)
+	}
+
+	if identity.PublicIdentity().SigningPublicKey() != "" {
+		t.Error("Public-only identity should not have a signing key")
+	}
+}
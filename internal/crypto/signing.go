@@ -0,0 +1,79 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// signingKeyContext domain-separates the ed25519 seed derivation from any
+// other use of the age identity material.
+const signingKeyContext = "dex-signing-key-v1:"
+
+// ErrInvalidSignature is returned when a signature does not verify against
+// the expected signing public key.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// signingKey derives the ed25519 signing key for this identity.
+// The seed is derived from the age X25519 private key, so every existing
+// identity file gets a stable signing key without needing to be migrated.
+func (kp *KeyPair) signingKey() ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte(signingKeyContext + kp.identity.String()))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// SigningPublicKey returns the base64-encoded ed25519 public key used to
+// verify signatures produced by Sign.
+func (kp *KeyPair) SigningPublicKey() string {
+	pub := kp.signingKey().Public().(ed25519.PublicKey)
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+// Sign signs a message with this identity's ed25519 signing key.
+// Returns the base64-encoded signature.
+func (kp *KeyPair) Sign(message []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(kp.signingKey(), message))
+}
+
+// VerifySignature checks a base64-encoded signature against a base64-encoded
+// ed25519 signing public key (as returned by SigningPublicKey).
+func VerifySignature(signingPublicKey string, message []byte, signature string) error {
+	pub, err := base64.StdEncoding.DecodeString(signingPublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid signing public key: %w", err)
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid signing public key size: got %d, want %d", len(pub), ed25519.PublicKeySize)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(pub), message, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SigningPublicKey returns the worker's ed25519 signing public key.
+// Returns empty string if the identity has no private key loaded.
+func (wi *WorkerIdentity) SigningPublicKey() string {
+	kp := wi.ToKeyPair()
+	if kp == nil {
+		return ""
+	}
+	return kp.SigningPublicKey()
+}
+
+// Sign signs a message with the worker's signing key.
+func (wi *WorkerIdentity) Sign(message []byte) (string, error) {
+	kp := wi.ToKeyPair()
+	if kp == nil {
+		return "", errors.New("worker identity has no private key")
+	}
+	return kp.Sign(message), nil
+}
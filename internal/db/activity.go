@@ -35,6 +35,10 @@ const (
 	ActivityTypeLoopHealth    = "loop_health"
 	ActivityTypeDecision      = "decision"
 	ActivityTypeMemoryCreated = "memory_created"
+	ActivityTypeBudgetTopUp   = "budget_top_up"
+	ActivityTypeRequestQueued = "request_queued" // API request waited for the shared request gate
+	ActivityTypeModelFallback = "model_fallback" // Model was over capacity, so the request went to a fallback model
+	ActivityTypeSecurityEvent = "security_event" // Untrusted content matched an injection pattern
 )
 
 // CreateSessionActivity inserts a new activity record
@@ -156,10 +160,11 @@ func (db *DB) GetSessionActivitySummary(sessionID string) (*SessionActivitySumma
 	err := db.QueryRow(
 		`SELECT COALESCE(MAX(iteration), 0),
 		        COALESCE(SUM(tokens_input), 0),
-		        COALESCE(SUM(tokens_output), 0)
+		        COALESCE(SUM(tokens_output), 0),
+		        COALESCE(SUM(tokens_thinking), 0)
 		 FROM session_activity WHERE session_id = ?`,
 		sessionID,
-	).Scan(&summary.TotalIterations, &summary.InputTokens, &summary.OutputTokens)
+	).Scan(&summary.TotalIterations, &summary.InputTokens, &summary.OutputTokens, &summary.ThinkingTokens)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session activity summary: %w", err)
 	}
@@ -189,6 +194,7 @@ type SessionActivitySummary struct {
 	TotalTokens      int64  `json:"total_tokens"`
 	InputTokens      int64  `json:"input_tokens"`
 	OutputTokens     int64  `json:"output_tokens"`
+	ThinkingTokens   int64  `json:"thinking_tokens"` // Part of OutputTokens
 	CompletionReason string `json:"completion_reason,omitempty"`
 }
 
@@ -218,6 +224,27 @@ func (db *DB) GetTaskTokensFromActivity(taskID string) (inputTokens, outputToken
 	return inputTokens, outputTokens, nil
 }
 
+// GetTaskCost returns the dollars a task has spent across all its sessions,
+// pricing each session's tokens from session_activity at that session's rates
+func (db *DB) GetTaskCost(taskID string) (float64, error) {
+	var cost float64
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(session_tokens.input_sum * s.input_rate + session_tokens.output_sum * s.output_rate) / 1000000.0, 0)
+		FROM sessions s
+		JOIN (
+		    SELECT session_id,
+		           SUM(tokens_input) as input_sum,
+		           SUM(tokens_output) as output_sum
+		    FROM session_activity
+		    GROUP BY session_id
+		) session_tokens ON session_tokens.session_id = s.id
+		WHERE s.task_id = ?`, taskID).Scan(&cost)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get task cost: %w", err)
+	}
+	return cost, nil
+}
+
 // DeleteSessionActivity removes all activity records for a session
 func (db *DB) DeleteSessionActivity(sessionID string) error {
 	_, err := db.Exec(`DELETE FROM session_activity WHERE session_id = ?`, sessionID)
@@ -0,0 +1,148 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Activity levels control which session activity events are recorded and broadcast
+const (
+	ActivityLevelMinimal  = "minimal"  // Tool calls and results, completions, hat transitions, and security events (broadcast only)
+	ActivityLevelStandard = "standard" // Everything except debug logs
+	ActivityLevelDebug    = "debug"    // Everything, including debug logs
+)
+
+// ValidateActivityLevel checks that level is a known level for recording activity.
+// Recording can't be minimal, because session token counts are computed from
+// the recorded responses.
+func ValidateActivityLevel(level string) error {
+	switch level {
+	case ActivityLevelStandard, ActivityLevelDebug:
+		return nil
+	default:
+		return fmt.Errorf("invalid activity level %q (must be standard or debug)", level)
+	}
+}
+
+// ValidateBroadcastLevel checks that level is a known level for broadcasting activity
+func ValidateBroadcastLevel(level string) error {
+	switch level {
+	case ActivityLevelMinimal, ActivityLevelStandard, ActivityLevelDebug:
+		return nil
+	default:
+		return fmt.Errorf("invalid broadcast level %q (must be minimal, standard, or debug)", level)
+	}
+}
+
+// ActivityLevelIncludes reports whether events of eventType are included at level
+func ActivityLevelIncludes(level, eventType string) bool {
+	switch eventType {
+	case ActivityTypeDebugLog:
+		return level == ActivityLevelDebug
+	case ActivityTypeToolCall, ActivityTypeToolResult, ActivityTypeCompletion, ActivityTypeHatTransition, ActivityTypeSecurityEvent:
+		return true
+	default:
+		return level != ActivityLevelMinimal
+	}
+}
+
+// activityLevelColumn converts a level into a nullable column value (empty clears the override)
+func activityLevelColumn(level string) (sql.NullString, error) {
+	if level == "" {
+		return sql.NullString{}, nil
+	}
+	if err := ValidateActivityLevel(level); err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: level, Valid: true}, nil
+}
+
+// GetProjectActivityLevel returns the project's activity level, or "" if not set
+func (db *DB) GetProjectActivityLevel(projectID string) (string, error) {
+	var level sql.NullString
+	err := db.QueryRow(`SELECT activity_level FROM projects WHERE id = ?`, projectID).Scan(&level)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project activity level: %w", err)
+	}
+	return level.String, nil
+}
+
+// SetProjectActivityLevel sets the project's activity level ("" clears it)
+func (db *DB) SetProjectActivityLevel(projectID, level string) error {
+	value, err := activityLevelColumn(level)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE projects SET activity_level = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project activity level: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// GetTaskActivityLevel returns the task's own activity level, or "" if it inherits
+func (db *DB) GetTaskActivityLevel(taskID string) (string, error) {
+	var level sql.NullString
+	err := db.QueryRow(`SELECT activity_level FROM tasks WHERE id = ?`, taskID).Scan(&level)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get task activity level: %w", err)
+	}
+	return level.String, nil
+}
+
+// SetTaskActivityLevel sets the task's activity level ("" inherits from the project)
+func (db *DB) SetTaskActivityLevel(taskID, level string) error {
+	value, err := activityLevelColumn(level)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE tasks SET activity_level = ? WHERE id = ?`, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update task activity level: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
+
+// ResolveActivityLevel returns the effective activity level for a task: the
+// task's own level, then its project's, or "" so the caller's default applies
+func (db *DB) ResolveActivityLevel(taskID string) (string, error) {
+	var taskLevel, projectLevel sql.NullString
+	err := db.QueryRow(
+		`SELECT t.activity_level, p.activity_level
+		 FROM tasks t LEFT JOIN projects p ON p.id = t.project_id
+		 WHERE t.id = ?`,
+		taskID,
+	).Scan(&taskLevel, &projectLevel)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve activity level: %w", err)
+	}
+
+	if taskLevel.String != "" {
+		return taskLevel.String, nil
+	}
+	return projectLevel.String, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import "testing"
+
+func TestResolveActivityLevel(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Explore", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing configured: the caller's default applies
+	level, err := db.ResolveActivityLevel(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != "" {
+		t.Errorf("default level = %q, want empty", level)
+	}
+
+	// Project level applies to its tasks
+	if err := db.SetProjectActivityLevel(project.ID, ActivityLevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ = db.ResolveActivityLevel(task.ID); level != ActivityLevelDebug {
+		t.Errorf("level = %q, want project's debug", level)
+	}
+
+	// Task override wins
+	if err := db.SetTaskActivityLevel(task.ID, ActivityLevelStandard); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ = db.ResolveActivityLevel(task.ID); level != ActivityLevelStandard {
+		t.Errorf("level = %q, want task's standard", level)
+	}
+
+	// Clearing the override falls back to the project again
+	if err := db.SetTaskActivityLevel(task.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if own, _ := db.GetTaskActivityLevel(task.ID); own != "" {
+		t.Errorf("task level = %q, want empty after clearing", own)
+	}
+	if level, _ = db.ResolveActivityLevel(task.ID); level != ActivityLevelDebug {
+		t.Errorf("level = %q, want project's debug", level)
+	}
+
+	if err := db.SetProjectActivityLevel(project.ID, "verbose"); err == nil {
+		t.Error("expected unknown activity level to be rejected")
+	}
+}
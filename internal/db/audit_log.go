@@ -0,0 +1,94 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditActionBudgetTopUp = "task.budget_top_up"
+)
+
+// AuditActorSystem is the actor for actions taken without an authenticated user
+const AuditActorSystem = "system"
+
+// AuditEntry records who did what to which entity
+type AuditEntry struct {
+	ID         string
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   string
+	Details    sql.NullString // JSON
+	CreatedAt  time.Time
+}
+
+// CreateAuditEntry records an action. details is marshaled to JSON and may be nil.
+func (db *DB) CreateAuditEntry(actor, action, entityType, entityID string, details any) (*AuditEntry, error) {
+	if actor == "" {
+		actor = AuditActorSystem
+	}
+	entry := &AuditEntry{
+		ID:         NewPrefixedID("audit"),
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		CreatedAt:  time.Now(),
+	}
+
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		entry.Details = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO audit_log (id, actor, action, entity_type, entity_id, details, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Actor, entry.Action, entry.EntityType, entry.EntityID, entry.Details, entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// ListAuditEntries returns an entity's audit entries, newest first
+func (db *DB) ListAuditEntries(entityType, entityID string) ([]*AuditEntry, error) {
+	rows, err := db.Query(
+		`SELECT id, actor, action, entity_type, entity_id, details, created_at
+		 FROM audit_log WHERE entity_type = ? AND entity_id = ?
+		 ORDER BY created_at DESC`,
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []*AuditEntry
+	for rows.Next() {
+		entry := &AuditEntry{}
+		if err := rows.Scan(
+			&entry.ID, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&entry.Details, &entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+
+	return entries, nil
+}
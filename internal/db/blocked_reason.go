@@ -0,0 +1,129 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Blocked reason categories let operators triage blocked tasks by cause
+const (
+	BlockedReasonMissingCredential     = "missing_credential"     // An API key, token, or login is needed
+	BlockedReasonAmbiguousRequirements = "ambiguous_requirements" // The task needs clarification
+	BlockedReasonExternalDependency    = "external_dependency"    // Waiting on another service, team, or release
+	BlockedReasonTestEnvironment       = "test_environment"       // Tests or tooling can't run
+	BlockedReasonOther                 = "other"                  // Anything else
+)
+
+// BlockedReasonCategories lists every blocked reason category
+var BlockedReasonCategories = []string{
+	BlockedReasonMissingCredential,
+	BlockedReasonAmbiguousRequirements,
+	BlockedReasonExternalDependency,
+	BlockedReasonTestEnvironment,
+	BlockedReasonOther,
+}
+
+// IsValidBlockedReason reports whether category is a known blocked reason category
+func IsValidBlockedReason(category string) bool {
+	for _, c := range BlockedReasonCategories {
+		if category == c {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeBlockedReason maps a category chosen by an agent onto the known
+// categories, so a missing or misspelled one is still recorded as "other"
+func NormalizeBlockedReason(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if IsValidBlockedReason(category) {
+		return category
+	}
+	return BlockedReasonOther
+}
+
+// BlockedReason is why a task's session last reported being blocked
+type BlockedReason struct {
+	Category  string    `json:"category"`
+	Detail    string    `json:"detail,omitempty"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// SetTaskBlockedReason records why a task is blocked. The category is normalized.
+func (db *DB) SetTaskBlockedReason(taskID, category, detail string) error {
+	result, err := db.Exec(
+		`UPDATE tasks SET blocked_reason = ?, blocked_detail = ?, blocked_at = ? WHERE id = ?`,
+		NormalizeBlockedReason(category), sql.NullString{String: detail, Valid: detail != ""}, time.Now(), taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set task blocked reason: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+	return nil
+}
+
+// ClearTaskBlockedReason removes a task's blocked reason once the blocker is resolved
+func (db *DB) ClearTaskBlockedReason(taskID string) error {
+	_, err := db.Exec(
+		`UPDATE tasks SET blocked_reason = NULL, blocked_detail = NULL, blocked_at = NULL WHERE id = ?`,
+		taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear task blocked reason: %w", err)
+	}
+	return nil
+}
+
+// GetTaskBlockedReason returns why a task is blocked, or nil if it isn't
+func (db *DB) GetTaskBlockedReason(taskID string) (*BlockedReason, error) {
+	var category, detail sql.NullString
+	var blockedAt sql.NullTime
+	err := db.QueryRow(
+		`SELECT blocked_reason, blocked_detail, blocked_at FROM tasks WHERE id = ?`,
+		taskID,
+	).Scan(&category, &detail, &blockedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task blocked reason: %w", err)
+	}
+
+	if !category.Valid || category.String == "" {
+		return nil, nil
+	}
+	return &BlockedReason{
+		Category:  category.String,
+		Detail:    detail.String,
+		BlockedAt: blockedAt.Time,
+	}, nil
+}
+
+// ListTasksByBlockedReason returns tasks blocked in the given category, across
+// all projects. Completed and cancelled tasks are left out unless asked for by
+// status. A non-empty projectID or status narrows the result.
+func (db *DB) ListTasksByBlockedReason(category, projectID, status string) ([]*Task, error) {
+	where := `WHERE blocked_reason = ?`
+	args := []any{category}
+	if projectID != "" {
+		where += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+	if status != "" {
+		where += ` AND status = ?`
+		args = append(args, status)
+	} else {
+		where += ` AND status NOT IN (?, ?)`
+		args = append(args, TaskStatusCompleted, TaskStatusCancelled)
+	}
+
+	return db.listTasks(where+` ORDER BY blocked_at DESC`, args...)
+}
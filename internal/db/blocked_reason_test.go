@@ -0,0 +1,88 @@
+package db
+
+import "testing"
+
+func TestTaskBlockedReason(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := db.CreateProject("Other", "/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripe, err := db.CreateTask(project.ID, "Wire up Stripe", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := db.CreateTask(project.ID, "Build the dashboard", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy, err := db.CreateTask(other.ID, "Deploy to Fly", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reason, err := db.GetTaskBlockedReason(stripe.ID); err != nil || reason != nil {
+		t.Fatalf("got %+v, %v; want no blocked reason", reason, err)
+	}
+
+	if err := db.SetTaskBlockedReason(stripe.ID, BlockedReasonMissingCredential, "No STRIPE_SECRET_KEY"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetTaskBlockedReason(deploy.ID, " Missing_Credential ", ""); err != nil {
+		t.Fatal(err)
+	}
+	// Unknown categories are kept as "other"
+	if err := db.SetTaskBlockedReason(spec.ID, "needs_design", "Which charts?"); err != nil {
+		t.Fatal(err)
+	}
+
+	reason, err := db.GetTaskBlockedReason(stripe.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason.Category != BlockedReasonMissingCredential || reason.Detail != "No STRIPE_SECRET_KEY" || reason.BlockedAt.IsZero() {
+		t.Errorf("got %+v", reason)
+	}
+	if reason, _ = db.GetTaskBlockedReason(spec.ID); reason.Category != BlockedReasonOther {
+		t.Errorf("got category %q, want other", reason.Category)
+	}
+
+	tasks, err := db.ListTasksByBlockedReason(BlockedReasonMissingCredential, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want both missing-credential tasks", len(tasks))
+	}
+	if tasks, _ = db.ListTasksByBlockedReason(BlockedReasonMissingCredential, project.ID, ""); len(tasks) != 1 || tasks[0].ID != stripe.ID {
+		t.Errorf("got %+v, want only the Stripe task", tasks)
+	}
+
+	// Finished tasks drop out unless asked for by status
+	if err := db.UpdateTaskStatus(deploy.ID, TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ = db.ListTasksByBlockedReason(BlockedReasonMissingCredential, "", ""); len(tasks) != 1 {
+		t.Errorf("got %d tasks, want the completed one left out", len(tasks))
+	}
+	if tasks, _ = db.ListTasksByBlockedReason(BlockedReasonMissingCredential, "", TaskStatusCompleted); len(tasks) != 1 || tasks[0].ID != deploy.ID {
+		t.Errorf("got %+v, want the completed task", tasks)
+	}
+
+	if err := db.ClearTaskBlockedReason(stripe.ID); err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ = db.GetTaskBlockedReason(stripe.ID); reason != nil {
+		t.Errorf("got %+v after clearing, want nil", reason)
+	}
+
+	if err := db.SetTaskBlockedReason("task-missing", BlockedReasonOther, ""); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
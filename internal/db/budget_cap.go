@@ -0,0 +1,83 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// BudgetCap limits how far resuming a paused task can raise its session's
+// budgets in a project. Zero fields are uncapped.
+type BudgetCap struct {
+	Iterations int     `json:"iterations,omitempty"`
+	Tokens     int64   `json:"tokens,omitempty"`
+	Dollars    float64 `json:"dollars,omitempty"`
+}
+
+// IsZero reports whether the cap limits nothing
+func (c *BudgetCap) IsZero() bool {
+	return c == nil || *c == BudgetCap{}
+}
+
+// Validate checks that no cap is negative
+func (c *BudgetCap) Validate() error {
+	if c.Iterations < 0 || c.Tokens < 0 || c.Dollars < 0 {
+		return fmt.Errorf("budget caps must not be negative")
+	}
+	return nil
+}
+
+// GetProjectBudgetCap returns the project's budget cap, or nil if it has none
+func (db *DB) GetProjectBudgetCap(projectID string) (*BudgetCap, error) {
+	var iterations, tokens sql.NullInt64
+	var dollars sql.NullFloat64
+	err := db.QueryRow(
+		`SELECT budget_cap_iterations, budget_cap_tokens, budget_cap_dollars FROM projects WHERE id = ?`,
+		projectID,
+	).Scan(&iterations, &tokens, &dollars)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project budget cap: %w", err)
+	}
+
+	budgetCap := &BudgetCap{
+		Iterations: int(iterations.Int64),
+		Tokens:     tokens.Int64,
+		Dollars:    dollars.Float64,
+	}
+	if budgetCap.IsZero() {
+		return nil, nil
+	}
+	return budgetCap, nil
+}
+
+// SetProjectBudgetCap sets the project's budget cap (nil or zero clears it)
+func (db *DB) SetProjectBudgetCap(projectID string, budgetCap *BudgetCap) error {
+	var iterations, tokens sql.NullInt64
+	var dollars sql.NullFloat64
+	if budgetCap != nil {
+		if err := budgetCap.Validate(); err != nil {
+			return err
+		}
+		iterations = sql.NullInt64{Int64: int64(budgetCap.Iterations), Valid: budgetCap.Iterations > 0}
+		tokens = sql.NullInt64{Int64: budgetCap.Tokens, Valid: budgetCap.Tokens > 0}
+		dollars = sql.NullFloat64{Float64: budgetCap.Dollars, Valid: budgetCap.Dollars > 0}
+	}
+
+	result, err := db.Exec(
+		`UPDATE projects SET budget_cap_iterations = ?, budget_cap_tokens = ?, budget_cap_dollars = ? WHERE id = ?`,
+		iterations, tokens, dollars, projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update project budget cap: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
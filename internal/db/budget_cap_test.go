@@ -0,0 +1,69 @@
+package db
+
+import "testing"
+
+func TestProjectBudgetCap(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budgetCap, err := db.GetProjectBudgetCap(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budgetCap != nil {
+		t.Errorf("default cap = %+v, want nil", budgetCap)
+	}
+
+	if err := db.SetProjectBudgetCap(project.ID, &BudgetCap{Iterations: 200, Dollars: 25}); err != nil {
+		t.Fatal(err)
+	}
+	budgetCap, err = db.GetProjectBudgetCap(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budgetCap == nil || *budgetCap != (BudgetCap{Iterations: 200, Dollars: 25}) {
+		t.Errorf("cap = %+v, want 200 iterations and $25", budgetCap)
+	}
+
+	if err := db.SetProjectBudgetCap(project.ID, &BudgetCap{Tokens: -1}); err == nil {
+		t.Error("expected negative cap to be rejected")
+	}
+
+	// A zero cap clears it
+	if err := db.SetProjectBudgetCap(project.ID, &BudgetCap{}); err != nil {
+		t.Fatal(err)
+	}
+	if budgetCap, _ = db.GetProjectBudgetCap(project.ID); budgetCap != nil {
+		t.Errorf("cap = %+v, want nil after clearing", budgetCap)
+	}
+
+	if err := db.SetProjectBudgetCap("proj-missing", nil); err == nil {
+		t.Error("expected error for unknown project")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := db.CreateAuditEntry("", AuditActionBudgetTopUp, "task", "task-1", map[string]any{"additional_dollars": 5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateAuditEntry("user-1", AuditActionBudgetTopUp, "task", "task-2", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := db.ListAuditEntries("task", "task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if entries[0].Actor != AuditActorSystem || entries[0].Details.String != `{"additional_dollars":5}` {
+		t.Errorf("entry = %+v", entries[0])
+	}
+}
@@ -102,6 +102,95 @@ func (db *DB) CreateChecklistItem(checklistID, description string, sortOrder int
 	return item, nil
 }
 
+// CreateChecklistItems adds items to the end of a checklist in one
+// transaction, in the order given, so either all of them are added or none
+func (db *DB) CreateChecklistItems(checklistID string, descriptions []string) ([]*ChecklistItem, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var next int
+	if err := tx.QueryRow(
+		`SELECT COALESCE(MAX(sort_order) + 1, 0) FROM checklist_items WHERE checklist_id = ?`,
+		checklistID,
+	).Scan(&next); err != nil {
+		return nil, fmt.Errorf("failed to get checklist item order: %w", err)
+	}
+
+	items := make([]*ChecklistItem, len(descriptions))
+	for i, description := range descriptions {
+		item := &ChecklistItem{
+			ID:          NewPrefixedID("citm"),
+			ChecklistID: checklistID,
+			Description: description,
+			Status:      ChecklistItemStatusPending,
+			SortOrder:   next + i,
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO checklist_items (id, checklist_id, description, status, sort_order)
+			 VALUES (?, ?, ?, ?, ?)`,
+			item.ID, item.ChecklistID, item.Description, item.Status, item.SortOrder,
+		); err != nil {
+			return nil, fmt.Errorf("failed to create checklist item: %w", err)
+		}
+		items[i] = item
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit checklist items: %w", err)
+	}
+	return items, nil
+}
+
+// ReorderChecklistItems sets the order of a checklist's items. itemIDs must
+// list every item in the checklist exactly once, in the new order.
+func (db *DB) ReorderChecklistItems(checklistID string, itemIDs []string) error {
+	items, err := db.GetChecklistItems(checklistID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(items))
+	for _, item := range items {
+		existing[item.ID] = true
+	}
+	if len(itemIDs) != len(items) {
+		return fmt.Errorf("reorder must list all %d checklist items, got %d", len(items), len(itemIDs))
+	}
+	seen := make(map[string]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if !existing[id] {
+			return fmt.Errorf("checklist item not found: %s", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("checklist item listed twice: %s", id)
+		}
+		seen[id] = true
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, id := range itemIDs {
+		if _, err := tx.Exec(
+			`UPDATE checklist_items SET sort_order = ? WHERE id = ? AND checklist_id = ?`,
+			i, id, checklistID,
+		); err != nil {
+			return fmt.Errorf("failed to reorder checklist item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit checklist order: %w", err)
+	}
+	return nil
+}
+
 // GetChecklistItem retrieves a checklist item by ID
 func (db *DB) GetChecklistItem(id string) (*ChecklistItem, error) {
 	item := &ChecklistItem{}
@@ -0,0 +1,69 @@
+package db
+
+import "testing"
+
+func TestChecklistBatchCreateAndReorder(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checklist, err := db.CreateTaskChecklist(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := db.CreateChecklistItem(checklist.ID, "Write tests", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := db.CreateChecklistItems(checklist.ID, []string{"Add endpoint", "Update docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 2 || added[0].SortOrder != 1 || added[1].SortOrder != 2 {
+		t.Fatalf("added = %+v, want two items after the existing one", added)
+	}
+
+	descriptions := func() []string {
+		t.Helper()
+		items, err := db.GetChecklistItems(checklist.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Description)
+		}
+		return out
+	}
+	if got := descriptions(); len(got) != 3 || got[2] != "Update docs" {
+		t.Fatalf("items = %v, want the batch appended", got)
+	}
+
+	if err := db.ReorderChecklistItems(checklist.ID, []string{added[1].ID, first.ID, added[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := descriptions(); got[0] != "Update docs" || got[1] != "Write tests" || got[2] != "Add endpoint" {
+		t.Errorf("items after reorder = %v", got)
+	}
+
+	// The order must cover every item exactly once
+	for name, ids := range map[string][]string{
+		"missing an item": {first.ID, added[0].ID},
+		"an item twice":   {first.ID, first.ID, added[0].ID},
+		"an unknown item": {first.ID, added[0].ID, "citm-other"},
+	} {
+		if err := db.ReorderChecklistItems(checklist.ID, ids); err == nil {
+			t.Errorf("expected an error reordering with %s", name)
+		}
+	}
+	if got := descriptions(); got[0] != "Update docs" {
+		t.Errorf("a rejected reorder changed the items: %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Completion strictness modes control how task completion is verified
+const (
+	CompletionStrictnessStrict  = "strict"  // All checklist items done, or failures explicitly acknowledged
+	CompletionStrictnessLenient = "lenient" // Pending items allowed once the done ratio is reached
+	CompletionStrictnessManual  = "manual"  // A human must approve every completion
+)
+
+// DefaultCompletionMinDoneRatio is the done ratio lenient mode requires when none is configured
+const DefaultCompletionMinDoneRatio = 0.8
+
+// CompletionPolicy configures completion verification for a task or project
+type CompletionPolicy struct {
+	Strictness   string  `json:"strictness"`
+	MinDoneRatio float64 `json:"min_done_ratio,omitempty"` // Only used by lenient mode
+}
+
+// DefaultCompletionPolicy returns the policy used when neither task nor project sets one
+func DefaultCompletionPolicy() *CompletionPolicy {
+	return &CompletionPolicy{Strictness: CompletionStrictnessStrict}
+}
+
+// Validate checks the strictness mode and ratio, filling in the lenient default ratio
+func (p *CompletionPolicy) Validate() error {
+	switch p.Strictness {
+	case CompletionStrictnessStrict, CompletionStrictnessManual:
+		p.MinDoneRatio = 0
+	case CompletionStrictnessLenient:
+		if p.MinDoneRatio == 0 {
+			p.MinDoneRatio = DefaultCompletionMinDoneRatio
+		}
+		if p.MinDoneRatio < 0 || p.MinDoneRatio > 1 {
+			return fmt.Errorf("min_done_ratio must be between 0 and 1, got %v", p.MinDoneRatio)
+		}
+	default:
+		return fmt.Errorf("invalid completion strictness %q (must be strict, lenient, or manual)", p.Strictness)
+	}
+	return nil
+}
+
+// scanCompletionPolicy converts nullable columns into a policy, or nil if unset
+func scanCompletionPolicy(strictness sql.NullString, ratio sql.NullFloat64) *CompletionPolicy {
+	if !strictness.Valid || strictness.String == "" {
+		return nil
+	}
+	policy := &CompletionPolicy{Strictness: strictness.String}
+	if ratio.Valid {
+		policy.MinDoneRatio = ratio.Float64
+	}
+	return policy
+}
+
+// completionPolicyColumns converts a policy into nullable column values (nil clears the override)
+func completionPolicyColumns(policy *CompletionPolicy) (sql.NullString, sql.NullFloat64, error) {
+	if policy == nil {
+		return sql.NullString{}, sql.NullFloat64{}, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return sql.NullString{}, sql.NullFloat64{}, err
+	}
+	ratio := sql.NullFloat64{}
+	if policy.Strictness == CompletionStrictnessLenient {
+		ratio = sql.NullFloat64{Float64: policy.MinDoneRatio, Valid: true}
+	}
+	return sql.NullString{String: policy.Strictness, Valid: true}, ratio, nil
+}
+
+// GetProjectCompletionPolicy returns the project's completion policy, or nil if not set
+func (db *DB) GetProjectCompletionPolicy(projectID string) (*CompletionPolicy, error) {
+	var strictness sql.NullString
+	var ratio sql.NullFloat64
+	err := db.QueryRow(
+		`SELECT completion_strictness, completion_min_done_ratio FROM projects WHERE id = ?`,
+		projectID,
+	).Scan(&strictness, &ratio)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project completion policy: %w", err)
+	}
+	return scanCompletionPolicy(strictness, ratio), nil
+}
+
+// SetProjectCompletionPolicy sets the project's completion policy (nil clears it)
+func (db *DB) SetProjectCompletionPolicy(projectID string, policy *CompletionPolicy) error {
+	strictness, ratio, err := completionPolicyColumns(policy)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(
+		`UPDATE projects SET completion_strictness = ?, completion_min_done_ratio = ? WHERE id = ?`,
+		strictness, ratio, projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update project completion policy: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// GetTaskCompletionPolicy returns the task's own completion policy, or nil if it inherits
+func (db *DB) GetTaskCompletionPolicy(taskID string) (*CompletionPolicy, error) {
+	var strictness sql.NullString
+	var ratio sql.NullFloat64
+	err := db.QueryRow(
+		`SELECT completion_strictness, completion_min_done_ratio FROM tasks WHERE id = ?`,
+		taskID,
+	).Scan(&strictness, &ratio)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task completion policy: %w", err)
+	}
+	return scanCompletionPolicy(strictness, ratio), nil
+}
+
+// SetTaskCompletionPolicy sets the task's completion policy (nil inherits from the project)
+func (db *DB) SetTaskCompletionPolicy(taskID string, policy *CompletionPolicy) error {
+	strictness, ratio, err := completionPolicyColumns(policy)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(
+		`UPDATE tasks SET completion_strictness = ?, completion_min_done_ratio = ? WHERE id = ?`,
+		strictness, ratio, taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task completion policy: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
+
+// ResolveCompletionPolicy returns the effective policy for a task:
+// the task's own policy, then its project's, then the strict default
+func (db *DB) ResolveCompletionPolicy(taskID string) (*CompletionPolicy, error) {
+	var taskStrictness, projectStrictness sql.NullString
+	var taskRatio, projectRatio sql.NullFloat64
+	err := db.QueryRow(
+		`SELECT t.completion_strictness, t.completion_min_done_ratio,
+		        p.completion_strictness, p.completion_min_done_ratio
+		 FROM tasks t LEFT JOIN projects p ON p.id = t.project_id
+		 WHERE t.id = ?`,
+		taskID,
+	).Scan(&taskStrictness, &taskRatio, &projectStrictness, &projectRatio)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve completion policy: %w", err)
+	}
+
+	if policy := scanCompletionPolicy(taskStrictness, taskRatio); policy != nil {
+		return policy, nil
+	}
+	if policy := scanCompletionPolicy(projectStrictness, projectRatio); policy != nil {
+		return policy, nil
+	}
+	return DefaultCompletionPolicy(), nil
+}
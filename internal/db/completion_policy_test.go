@@ -0,0 +1,79 @@
+package db
+
+import "testing"
+
+func TestResolveCompletionPolicy(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Explore", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing configured: strict default
+	policy, err := db.ResolveCompletionPolicy(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Strictness != CompletionStrictnessStrict {
+		t.Errorf("default strictness = %q, want strict", policy.Strictness)
+	}
+
+	// Project policy applies to its tasks
+	if err := db.SetProjectCompletionPolicy(project.ID, &CompletionPolicy{Strictness: CompletionStrictnessManual}); err != nil {
+		t.Fatal(err)
+	}
+	policy, _ = db.ResolveCompletionPolicy(task.ID)
+	if policy.Strictness != CompletionStrictnessManual {
+		t.Errorf("strictness = %q, want project's manual", policy.Strictness)
+	}
+
+	// Task override wins, with the default lenient ratio filled in
+	if err := db.SetTaskCompletionPolicy(task.ID, &CompletionPolicy{Strictness: CompletionStrictnessLenient}); err != nil {
+		t.Fatal(err)
+	}
+	policy, _ = db.ResolveCompletionPolicy(task.ID)
+	if policy.Strictness != CompletionStrictnessLenient || policy.MinDoneRatio != DefaultCompletionMinDoneRatio {
+		t.Errorf("policy = %+v, want lenient with default ratio", policy)
+	}
+
+	// Clearing the override falls back to the project again
+	if err := db.SetTaskCompletionPolicy(task.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if own, _ := db.GetTaskCompletionPolicy(task.ID); own != nil {
+		t.Errorf("task policy = %+v, want nil after clearing", own)
+	}
+	policy, _ = db.ResolveCompletionPolicy(task.ID)
+	if policy.Strictness != CompletionStrictnessManual {
+		t.Errorf("strictness = %q, want project's manual", policy.Strictness)
+	}
+}
+
+func TestCompletionPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  CompletionPolicy
+		wantErr bool
+	}{
+		{"strict", CompletionPolicy{Strictness: CompletionStrictnessStrict}, false},
+		{"manual", CompletionPolicy{Strictness: CompletionStrictnessManual}, false},
+		{"lenient with ratio", CompletionPolicy{Strictness: CompletionStrictnessLenient, MinDoneRatio: 0.5}, false},
+		{"lenient ratio above 1", CompletionPolicy{Strictness: CompletionStrictnessLenient, MinDoneRatio: 1.5}, true},
+		{"unknown strictness", CompletionPolicy{Strictness: "relaxed"}, true},
+		{"empty strictness", CompletionPolicy{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
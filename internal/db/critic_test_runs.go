@@ -0,0 +1,37 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetProjectCriticRequiresTests returns whether the project rejects review
+// approvals from sessions that haven't run the test suite
+func (db *DB) GetProjectCriticRequiresTests(projectID string) (bool, error) {
+	var required sql.NullBool
+	err := db.QueryRow(`SELECT critic_requires_tests FROM projects WHERE id = ?`, projectID).Scan(&required)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get project critic test requirement: %w", err)
+	}
+	return required.Bool, nil
+}
+
+// SetProjectCriticRequiresTests sets whether the project rejects review
+// approvals from sessions that haven't run the test suite
+func (db *DB) SetProjectCriticRequiresTests(projectID string, required bool) error {
+	result, err := db.Exec(`UPDATE projects SET critic_requires_tests = ? WHERE id = ?`, required, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project critic test requirement: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
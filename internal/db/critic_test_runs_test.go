@@ -0,0 +1,38 @@
+package db
+
+import "testing"
+
+func TestProjectCriticRequiresTests(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	required, err := db.GetProjectCriticRequiresTests(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if required {
+		t.Error("new project requires tests behind review approvals")
+	}
+
+	if err := db.SetProjectCriticRequiresTests(project.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	required, err = db.GetProjectCriticRequiresTests(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !required {
+		t.Error("requirement not saved")
+	}
+
+	if err := db.SetProjectCriticRequiresTests("missing", true); err == nil {
+		t.Error("set the requirement on a missing project")
+	}
+	if _, err := db.GetProjectCriticRequiresTests("missing"); err == nil {
+		t.Error("got the requirement of a missing project")
+	}
+}
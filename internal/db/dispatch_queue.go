@@ -0,0 +1,175 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// migrationDispatchQueue tracks objectives HQ has handed to workers, so the
+// dispatch state survives an HQ restart. Payloads are stored without secrets;
+// they're fetched again when an objective is re-dispatched.
+const migrationDispatchQueue = `
+CREATE TABLE IF NOT EXISTS dispatch_queue (
+	objective_id TEXT PRIMARY KEY,
+	worker_id TEXT,
+	state TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	excluded_workers TEXT,
+	last_error TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispatch_queue_state ON dispatch_queue(state);
+`
+
+// Dispatch queue states
+const (
+	DispatchStateQueued     = "queued"     // Waiting for a worker
+	DispatchStateDispatched = "dispatched" // Sent to a worker, not yet accepted
+	DispatchStateAccepted   = "accepted"   // Worker started running it
+	DispatchStateCompleted  = "completed"
+	DispatchStateFailed     = "failed"
+	DispatchStateCancelled  = "cancelled"
+
+	// A worker sent the objective's plan and it's waiting for approval before
+	// being dispatched again to run
+	DispatchStatePlanPending = "plan_pending"
+)
+
+// DispatchEntry is an objective's place in the dispatch queue
+type DispatchEntry struct {
+	ObjectiveID     string
+	WorkerID        string   // Worker it was last dispatched to
+	State           string   // One of the DispatchState constants
+	Payload         string   // JSON objective payload, without secrets
+	Attempts        int      // Dispatches so far
+	ExcludedWorkers []string // Workers it already timed out on
+	LastError       string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// EnqueueDispatch records an objective as queued for dispatch. It's a no-op
+// returning false if the objective is already queued or running, so a repeated
+// request can't dispatch it twice. An objective that finished is queued afresh.
+func (db *DB) EnqueueDispatch(objectiveID, payload string) (bool, error) {
+	now := time.Now()
+	result, err := db.Exec(`
+		INSERT INTO dispatch_queue (objective_id, state, payload, attempts, created_at, updated_at)
+		VALUES (?, ?, ?, 0, ?, ?)
+		ON CONFLICT(objective_id) DO UPDATE SET
+			worker_id = NULL,
+			state = excluded.state,
+			payload = excluded.payload,
+			attempts = 0,
+			excluded_workers = NULL,
+			last_error = NULL,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at
+		WHERE dispatch_queue.state NOT IN (?, ?, ?)
+	`, objectiveID, DispatchStateQueued, payload, now, now,
+		DispatchStateQueued, DispatchStateDispatched, DispatchStateAccepted)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue dispatch: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// RequeueDispatch puts an objective back in the queue after it was taken away
+// from a worker, keeping its attempt count and the workers to avoid
+func (db *DB) RequeueDispatch(objectiveID string, attempts int, excludedWorkers []string) error {
+	excluded, err := json.Marshal(excludedWorkers)
+	if err != nil {
+		return fmt.Errorf("failed to encode excluded workers: %w", err)
+	}
+
+	_, err = db.Exec(`
+		UPDATE dispatch_queue
+		SET state = ?, worker_id = NULL, attempts = ?, excluded_workers = ?, updated_at = ?
+		WHERE objective_id = ?
+	`, DispatchStateQueued, attempts, string(excluded), time.Now(), objectiveID)
+	if err != nil {
+		return fmt.Errorf("failed to requeue dispatch: %w", err)
+	}
+	return nil
+}
+
+// MarkDispatched records that an objective was sent to a worker
+func (db *DB) MarkDispatched(objectiveID, workerID string, attempts int) error {
+	_, err := db.Exec(`
+		UPDATE dispatch_queue SET state = ?, worker_id = ?, attempts = ?, updated_at = ?
+		WHERE objective_id = ?
+	`, DispatchStateDispatched, workerID, attempts, time.Now(), objectiveID)
+	if err != nil {
+		return fmt.Errorf("failed to mark dispatched: %w", err)
+	}
+	return nil
+}
+
+// UpdateDispatchState moves an objective to another state, recording lastError
+// if not empty. Objectives that already finished are left alone.
+func (db *DB) UpdateDispatchState(objectiveID, state, lastError string) error {
+	_, err := db.Exec(`
+		UPDATE dispatch_queue
+		SET state = ?, last_error = COALESCE(NULLIF(?, ''), last_error), updated_at = ?
+		WHERE objective_id = ? AND state IN (?, ?, ?, ?)
+	`, state, lastError, time.Now(), objectiveID,
+		DispatchStateQueued, DispatchStateDispatched, DispatchStateAccepted, DispatchStatePlanPending)
+	if err != nil {
+		return fmt.Errorf("failed to update dispatch state: %w", err)
+	}
+	return nil
+}
+
+// GetDispatch returns an objective's dispatch queue entry, or nil if it has none
+func (db *DB) GetDispatch(objectiveID string) (*DispatchEntry, error) {
+	entries, err := db.listDispatches(`WHERE objective_id = ?`, objectiveID)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+// ListActiveDispatches returns objectives still queued or running on a worker,
+// oldest first
+func (db *DB) ListActiveDispatches() ([]*DispatchEntry, error) {
+	return db.listDispatches(`WHERE state IN (?, ?, ?) ORDER BY created_at ASC`,
+		DispatchStateQueued, DispatchStateDispatched, DispatchStateAccepted)
+}
+
+// listDispatches returns dispatch queue entries matching a WHERE clause
+func (db *DB) listDispatches(where string, args ...any) ([]*DispatchEntry, error) {
+	rows, err := db.Query(`
+		SELECT objective_id, worker_id, state, payload, attempts, excluded_workers, last_error, created_at, updated_at
+		FROM dispatch_queue `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispatches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []*DispatchEntry
+	for rows.Next() {
+		e := &DispatchEntry{}
+		var workerID, excluded, lastError sql.NullString
+		if err := rows.Scan(&e.ObjectiveID, &workerID, &e.State, &e.Payload, &e.Attempts,
+			&excluded, &lastError, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dispatch: %w", err)
+		}
+		e.WorkerID = workerID.String
+		e.LastError = lastError.String
+		if excluded.String != "" {
+			if err := json.Unmarshal([]byte(excluded.String), &e.ExcludedWorkers); err != nil {
+				return nil, fmt.Errorf("failed to decode excluded workers: %w", err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,76 @@
+package db
+
+import "testing"
+
+func TestDispatchQueue(t *testing.T) {
+	db := setupTestDB(t)
+
+	queued, err := db.EnqueueDispatch("obj-1", `{"objective":{"id":"obj-1"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !queued {
+		t.Fatal("expected new objective to be queued")
+	}
+
+	// Queuing again while it's active is a no-op
+	if queued, _ = db.EnqueueDispatch("obj-1", `{}`); queued {
+		t.Error("expected duplicate enqueue to be ignored")
+	}
+
+	if err := db.MarkDispatched("obj-1", "worker-a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateDispatchState("obj-1", DispatchStateAccepted, ""); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := db.GetDispatch("obj-1")
+	if err != nil || entry == nil {
+		t.Fatalf("GetDispatch = %v, %v", entry, err)
+	}
+	if entry.State != DispatchStateAccepted || entry.WorkerID != "worker-a" || entry.Attempts != 1 {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Payload != `{"objective":{"id":"obj-1"}}` {
+		t.Errorf("payload = %q, want the original", entry.Payload)
+	}
+
+	if err := db.RequeueDispatch("obj-1", 1, []string{"worker-a"}); err != nil {
+		t.Fatal(err)
+	}
+	active, err := db.ListActiveDispatches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].State != DispatchStateQueued || len(active[0].ExcludedWorkers) != 1 {
+		t.Fatalf("unexpected active dispatches: %+v", active)
+	}
+
+	// Finished objectives stay finished, and drop out of the active list
+	if err := db.UpdateDispatchState("obj-1", DispatchStateFailed, "boom"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateDispatchState("obj-1", DispatchStateCompleted, ""); err != nil {
+		t.Fatal(err)
+	}
+	entry, _ = db.GetDispatch("obj-1")
+	if entry.State != DispatchStateFailed || entry.LastError != "boom" {
+		t.Errorf("unexpected finished entry: %+v", entry)
+	}
+	if active, _ = db.ListActiveDispatches(); len(active) != 0 {
+		t.Errorf("expected no active dispatches, got %d", len(active))
+	}
+
+	// A finished objective can be queued again from scratch
+	if queued, _ = db.EnqueueDispatch("obj-1", `{}`); !queued {
+		t.Error("expected finished objective to be queued again")
+	}
+	entry, _ = db.GetDispatch("obj-1")
+	if entry.State != DispatchStateQueued || entry.Attempts != 0 || entry.WorkerID != "" || entry.LastError != "" {
+		t.Errorf("unexpected re-queued entry: %+v", entry)
+	}
+
+	if entry, err = db.GetDispatch("obj-missing"); err != nil || entry != nil {
+		t.Errorf("GetDispatch(missing) = %v, %v; want nil", entry, err)
+	}
+}
@@ -0,0 +1,103 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// GitAuthor is the identity sessions commit as in a project's worktrees
+type GitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// IsZero reports whether no identity is set
+func (a *GitAuthor) IsZero() bool {
+	return a == nil || (a.Name == "" && a.Email == "")
+}
+
+// Validate checks that both fields are set and the email is a bare address
+func (a *GitAuthor) Validate() error {
+	if a.Name == "" || a.Email == "" {
+		return fmt.Errorf("git author requires both name and email")
+	}
+	if strings.ContainsAny(a.Name, "<>\n\r") {
+		return fmt.Errorf("git author name must not contain angle brackets or newlines")
+	}
+	addr, err := mail.ParseAddress(a.Email)
+	if err != nil || addr.Address != a.Email {
+		return fmt.Errorf("invalid git author email %q", a.Email)
+	}
+	return nil
+}
+
+// ParseGitAuthor parses an identity written as "Name <email>". Empty means
+// none and returns nil.
+func ParseGitAuthor(s string) (*GitAuthor, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name == "" {
+		return nil, fmt.Errorf("git author %q must be written as \"Name <email>\"", s)
+	}
+	author := &GitAuthor{Name: addr.Name, Email: addr.Address}
+	if err := author.Validate(); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// GetProjectGitAuthor returns the identity sessions commit as in the project,
+// or nil if it doesn't set one
+func (db *DB) GetProjectGitAuthor(projectID string) (*GitAuthor, error) {
+	var name, email sql.NullString
+	err := db.QueryRow(
+		`SELECT git_author_name, git_author_email FROM projects WHERE id = ?`,
+		projectID,
+	).Scan(&name, &email)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project git author: %w", err)
+	}
+
+	author := &GitAuthor{Name: name.String, Email: email.String}
+	if author.IsZero() {
+		return nil, nil
+	}
+	return author, nil
+}
+
+// SetProjectGitAuthor sets the identity sessions commit as in the project
+// (nil or empty clears it)
+func (db *DB) SetProjectGitAuthor(projectID string, author *GitAuthor) error {
+	var name, email sql.NullString
+	if !author.IsZero() {
+		if err := author.Validate(); err != nil {
+			return err
+		}
+		name = sql.NullString{String: author.Name, Valid: true}
+		email = sql.NullString{String: author.Email, Valid: true}
+	}
+
+	result, err := db.Exec(
+		`UPDATE projects SET git_author_name = ?, git_author_email = ? WHERE id = ?`,
+		name, email, projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update project git author: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
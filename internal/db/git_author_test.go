@@ -0,0 +1,69 @@
+package db
+
+import "testing"
+
+func TestProjectGitAuthor(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if author, err := db.GetProjectGitAuthor(project.ID); err != nil || author != nil {
+		t.Fatalf("expected no author by default, got %+v, %v", author, err)
+	}
+
+	want := &GitAuthor{Name: "Dex Bot", Email: "dex-bot@ourco.com"}
+	if err := db.SetProjectGitAuthor(project.ID, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetProjectGitAuthor(project.ID); got == nil || *got != *want {
+		t.Errorf("author = %+v, want %+v", got, want)
+	}
+
+	if err := db.SetProjectGitAuthor(project.ID, &GitAuthor{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetProjectGitAuthor(project.ID); got != nil {
+		t.Errorf("expected author cleared, got %+v", got)
+	}
+
+	if err := db.SetProjectGitAuthor("proj-missing", want); err == nil {
+		t.Error("expected an error for a missing project")
+	}
+}
+
+func TestParseGitAuthor(t *testing.T) {
+	author, err := ParseGitAuthor(" dex-bot <dex@ourco.com> ")
+	if err != nil {
+		t.Fatalf("ParseGitAuthor() error = %v", err)
+	}
+	if *author != (GitAuthor{Name: "dex-bot", Email: "dex@ourco.com"}) {
+		t.Errorf("ParseGitAuthor() = %+v", author)
+	}
+
+	if author, err := ParseGitAuthor(""); err != nil || author != nil {
+		t.Errorf("ParseGitAuthor(\"\") = %+v, %v, want nil", author, err)
+	}
+	for _, s := range []string{"dex@ourco.com", "dex-bot", "dex-bot <not an email>"} {
+		if _, err := ParseGitAuthor(s); err == nil {
+			t.Errorf("ParseGitAuthor(%q) expected error", s)
+		}
+	}
+}
+
+func TestGitAuthor_Validate(t *testing.T) {
+	invalid := []GitAuthor{
+		{Name: "Dex Bot"},
+		{Email: "dex-bot@ourco.com"},
+		{Name: "Dex <Bot>", Email: "dex-bot@ourco.com"},
+		{Name: "Dex Bot", Email: "not an email"},
+		{Name: "Dex Bot", Email: "Dex Bot <dex-bot@ourco.com>"},
+	}
+	for _, a := range invalid {
+		if err := a.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", a)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// HatUsage is what a task spent under one hat, across all its sessions
+type HatUsage struct {
+	Hat             string  `json:"hat"`
+	Sessions        int     `json:"sessions"`
+	Iterations      int     `json:"iterations"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	DollarsUsed     float64 `json:"dollars_used"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	TokenShare      float64 `json:"token_share"` // Fraction of the task's tokens, 0-1
+	CostShare       float64 `json:"cost_share"`  // Fraction of the task's cost, 0-1
+}
+
+// GetTaskHatBreakdown attributes a task's tokens, cost, iterations, and time to
+// the hats its sessions ran under, in the order the hats first ran. Tokens and
+// iterations come from session activity, priced at each session's rates.
+// Sessions still running count time up to now; ones that never started count none.
+func (db *DB) GetTaskHatBreakdown(taskID string) ([]*HatUsage, error) {
+	rows, err := db.Query(
+		`SELECT s.hat, MAX(s.iteration_count, COALESCE(a.max_iteration, 0)), s.input_rate, s.output_rate, s.started_at, s.ended_at,
+		        COALESCE(a.input_sum, 0), COALESCE(a.output_sum, 0)
+		 FROM sessions s
+		 LEFT JOIN (
+		     SELECT session_id,
+		            MAX(iteration) as max_iteration,
+		            SUM(tokens_input) as input_sum,
+		            SUM(tokens_output) as output_sum
+		     FROM session_activity
+		     GROUP BY session_id
+		 ) a ON a.session_id = s.id
+		 WHERE s.task_id = ?
+		 ORDER BY s.created_at ASC`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task hat breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	var breakdown []*HatUsage
+	byHat := make(map[string]*HatUsage)
+	var totalTokens int64
+	var totalDollars float64
+	for rows.Next() {
+		var hat string
+		var iterations int
+		var inputRate, outputRate float64
+		var startedAt, endedAt sql.NullTime
+		var inputTokens, outputTokens int64
+		if err := rows.Scan(&hat, &iterations, &inputRate, &outputRate, &startedAt, &endedAt, &inputTokens, &outputTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan session usage: %w", err)
+		}
+
+		usage, ok := byHat[hat]
+		if !ok {
+			usage = &HatUsage{Hat: hat}
+			byHat[hat] = usage
+			breakdown = append(breakdown, usage)
+		}
+
+		dollars := (float64(inputTokens)*inputRate + float64(outputTokens)*outputRate) / 1000000.0
+		usage.Sessions++
+		usage.Iterations += iterations
+		usage.InputTokens += inputTokens
+		usage.OutputTokens += outputTokens
+		usage.TotalTokens += inputTokens + outputTokens
+		usage.DollarsUsed += dollars
+		if startedAt.Valid {
+			end := time.Now()
+			if endedAt.Valid {
+				end = endedAt.Time
+			}
+			usage.DurationSeconds += end.Sub(startedAt.Time).Seconds()
+		}
+
+		totalTokens += inputTokens + outputTokens
+		totalDollars += dollars
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	for _, usage := range breakdown {
+		if totalTokens > 0 {
+			usage.TokenShare = float64(usage.TotalTokens) / float64(totalTokens)
+		}
+		if totalDollars > 0 {
+			usage.CostShare = usage.DollarsUsed / totalDollars
+		}
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetTaskHatBreakdown(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No sessions yet
+	hats, err := db.GetTaskHatBreakdown(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hats) != 0 {
+		t.Fatalf("got %d hats, want none", len(hats))
+	}
+
+	record := func(hat string, iterations int, input, output int) {
+		t.Helper()
+		sess, err := db.CreateSession(task.ID, hat, "/tmp/wt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.UpdateSessionStatus(sess.ID, SessionStatusRunning); err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i <= iterations; i++ {
+			in, out := input/iterations, output/iterations
+			if _, err := db.CreateSessionActivity(sess.ID, i, ActivityTypeAssistantResponse, hat, "", &in, &out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.UpdateSessionStatus(sess.ID, SessionStatusCompleted); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record("creator", 2, 2000, 1000)
+	record("critic", 3, 6000, 3000)
+	record("creator", 1, 1000, 0)
+
+	hats, err = db.GetTaskHatBreakdown(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hats) != 2 || hats[0].Hat != "creator" || hats[1].Hat != "critic" {
+		t.Fatalf("hats = %+v, want creator then critic", hats)
+	}
+
+	creator, critic := hats[0], hats[1]
+	if creator.Sessions != 2 || creator.Iterations != 3 {
+		t.Errorf("creator sessions/iterations = %d/%d, want 2/3", creator.Sessions, creator.Iterations)
+	}
+	if creator.InputTokens != 3000 || creator.OutputTokens != 1000 || creator.TotalTokens != 4000 {
+		t.Errorf("creator tokens = %d/%d/%d, want 3000/1000/4000", creator.InputTokens, creator.OutputTokens, creator.TotalTokens)
+	}
+	if critic.TotalTokens != 9000 {
+		t.Errorf("critic tokens = %d, want 9000", critic.TotalTokens)
+	}
+
+	// Default rates are $3/MTok in and $15/MTok out
+	if want := (6000*3.0 + 3000*15.0) / 1000000; math.Abs(critic.DollarsUsed-want) > 1e-9 {
+		t.Errorf("critic dollars = %f, want %f", critic.DollarsUsed, want)
+	}
+	if want := 9000.0 / 13000; math.Abs(critic.TokenShare-want) > 1e-9 {
+		t.Errorf("critic token share = %f, want %f", critic.TokenShare, want)
+	}
+	if total := creator.CostShare + critic.CostShare; math.Abs(total-1) > 1e-9 {
+		t.Errorf("cost shares sum to %f, want 1", total)
+	}
+	if creator.DurationSeconds < 0 {
+		t.Errorf("creator duration = %f, want non-negative", creator.DurationSeconds)
+	}
+}
@@ -187,6 +187,25 @@ func (db *DB) ListMemories(projectID string, memType *MemoryType, minConfidence
 	return scanMemories(rows)
 }
 
+// ListMemoriesByTask retrieves the memories a task's sessions created, oldest first
+func (db *DB) ListMemoriesByTask(taskID string) ([]Memory, error) {
+	rows, err := db.Query(`
+		SELECT id, project_id, type, title, content,
+			confidence, tags, file_refs,
+			created_by_hat, created_by_task_id, created_by_session_id, source,
+			created_at, last_used_at, use_count, verified_at
+		FROM memories
+		WHERE created_by_task_id = ?
+		ORDER BY created_at ASC
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanMemories(rows)
+}
+
 // MemorySearchParams defines search parameters
 type MemorySearchParams struct {
 	Query            string
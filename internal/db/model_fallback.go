@@ -0,0 +1,142 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ModelFallbackOff turns fallback off for a task whose project has it on
+const ModelFallbackOff = "off"
+
+// ParseModelFallback parses a comma-separated fallback chain such as
+// "sonnet", the models tried in order when the one before is over capacity.
+// "" and ModelFallbackOff both parse to no fallback.
+func ParseModelFallback(chain string) ([]string, error) {
+	if chain == "" || chain == ModelFallbackOff {
+		return nil, nil
+	}
+
+	var models []string
+	for _, model := range strings.Split(chain, ",") {
+		model = strings.TrimSpace(model)
+		switch {
+		case model != TaskModelSonnet && model != TaskModelOpus:
+			return nil, fmt.Errorf("invalid fallback model %q (must be sonnet or opus)", model)
+		case slices.Contains(models, model):
+			return nil, fmt.Errorf("fallback model %q is listed more than once", model)
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// modelFallbackColumn converts a chain into a nullable column value (empty clears it)
+func modelFallbackColumn(chain string) (sql.NullString, error) {
+	if chain == "" {
+		return sql.NullString{}, nil
+	}
+	if chain == ModelFallbackOff {
+		return sql.NullString{String: ModelFallbackOff, Valid: true}, nil
+	}
+	models, err := ParseModelFallback(chain)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: strings.Join(models, ","), Valid: true}, nil
+}
+
+// GetProjectModelFallback returns the project's fallback chain, or "" if it has none
+func (db *DB) GetProjectModelFallback(projectID string) (string, error) {
+	var chain sql.NullString
+	err := db.QueryRow(`SELECT model_fallback FROM projects WHERE id = ?`, projectID).Scan(&chain)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project model fallback: %w", err)
+	}
+	return chain.String, nil
+}
+
+// SetProjectModelFallback sets the project's fallback chain ("" or "off" turns it off)
+func (db *DB) SetProjectModelFallback(projectID, chain string) error {
+	if chain == ModelFallbackOff {
+		chain = ""
+	}
+	value, err := modelFallbackColumn(chain)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE projects SET model_fallback = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project model fallback: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// GetTaskModelFallback returns the task's own fallback chain, "off", or "" if it inherits
+func (db *DB) GetTaskModelFallback(taskID string) (string, error) {
+	var chain sql.NullString
+	err := db.QueryRow(`SELECT model_fallback FROM tasks WHERE id = ?`, taskID).Scan(&chain)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get task model fallback: %w", err)
+	}
+	return chain.String, nil
+}
+
+// SetTaskModelFallback sets the task's fallback chain ("off" turns it off
+// even if the project has one, "" inherits from the project)
+func (db *DB) SetTaskModelFallback(taskID, chain string) error {
+	value, err := modelFallbackColumn(chain)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE tasks SET model_fallback = ? WHERE id = ?`, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update task model fallback: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
+
+// ResolveModelFallback returns the models a task's sessions fall back to, in
+// order: the task's own chain, then its project's. Nil means no fallback.
+func (db *DB) ResolveModelFallback(taskID string) ([]string, error) {
+	var taskChain, projectChain sql.NullString
+	err := db.QueryRow(
+		`SELECT t.model_fallback, p.model_fallback
+		 FROM tasks t LEFT JOIN projects p ON p.id = t.project_id
+		 WHERE t.id = ?`,
+		taskID,
+	).Scan(&taskChain, &projectChain)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve model fallback: %w", err)
+	}
+
+	if taskChain.String != "" {
+		return ParseModelFallback(taskChain.String)
+	}
+	return ParseModelFallback(projectChain.String)
+}
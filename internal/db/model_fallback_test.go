@@ -0,0 +1,64 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolveModelFallback(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Refactor", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Off unless opted in
+	if models, err := db.ResolveModelFallback(task.ID); err != nil || models != nil {
+		t.Fatalf("default fallback = %v, %v; want none", models, err)
+	}
+
+	// Project chain applies to its tasks
+	if err := db.SetProjectModelFallback(project.ID, " sonnet "); err != nil {
+		t.Fatal(err)
+	}
+	if chain, _ := db.GetProjectModelFallback(project.ID); chain != "sonnet" {
+		t.Errorf("project chain = %q, want sonnet", chain)
+	}
+	if models, _ := db.ResolveModelFallback(task.ID); !slices.Equal(models, []string{"sonnet"}) {
+		t.Errorf("fallback = %v, want the project's [sonnet]", models)
+	}
+
+	// A task can turn it off, and clones keep that
+	if err := db.SetTaskModelFallback(task.ID, ModelFallbackOff); err != nil {
+		t.Fatal(err)
+	}
+	if models, _ := db.ResolveModelFallback(task.ID); models != nil {
+		t.Errorf("fallback = %v, want none for a task that turned it off", models)
+	}
+	clone, err := db.CloneTask(task.ID, CloneTaskOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain, _ := db.GetTaskModelFallback(clone.ID); chain != ModelFallbackOff {
+		t.Errorf("clone chain = %q, want off", chain)
+	}
+
+	// Clearing the override inherits from the project again
+	if err := db.SetTaskModelFallback(task.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if models, _ := db.ResolveModelFallback(task.ID); !slices.Equal(models, []string{"sonnet"}) {
+		t.Errorf("fallback = %v, want the project's [sonnet]", models)
+	}
+
+	for _, chain := range []string{"haiku", "sonnet,sonnet", "sonnet,"} {
+		if err := db.SetTaskModelFallback(task.ID, chain); err == nil {
+			t.Errorf("SetTaskModelFallback(%q) expected error", chain)
+		}
+	}
+}
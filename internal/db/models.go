@@ -212,7 +212,7 @@ type Approval struct {
 	ID          string
 	TaskID      sql.NullString
 	SessionID   sql.NullString
-	Type        string // commit, hat_transition, pr, merge, conflict_resolution
+	Type        string // commit, hat_transition, pr, merge, conflict_resolution, task_completion
 	Title       string
 	Description sql.NullString
 	Data        json.RawMessage
@@ -307,6 +307,7 @@ const (
 	TaskStatusQuarantined = "quarantined"
 	TaskStatusCompleted   = "completed"
 	TaskStatusCancelled   = "cancelled"
+	TaskStatusTimedOut    = "timed_out" // Worker objective exceeded its timeout on every attempt
 )
 
 // Task type constants
@@ -334,6 +335,10 @@ const (
 	ApprovalTypePR                 = "pr"
 	ApprovalTypeMerge              = "merge"
 	ApprovalTypeConflictResolution = "conflict_resolution"
+	ApprovalTypeTaskCompletion     = "task_completion"
+	ApprovalTypeObjectivePlan      = "objective_plan"
+	ApprovalTypeBudgetIncrease     = "budget_increase"
+	ApprovalTypePromptInjection    = "prompt_injection"
 )
 
 // Approval status constants
@@ -377,16 +382,19 @@ const (
 
 // Quest represents a conversation with Dex that spawns tasks
 type Quest struct {
-	ID               string
-	ProjectID        string
-	Title            sql.NullString
-	Status           string
-	Model            string
-	AutoStartDefault bool
-	ConversationPath sql.NullString // Path to git conversation file: quests/{quest-id}/conversation.md
-	IssueNumber      sql.NullInt64  // Issue number on the git provider (GitHub or Forgejo)
-	CreatedAt        time.Time
-	CompletedAt      sql.NullTime
+	ID                      string
+	ProjectID               string
+	Title                   sql.NullString
+	Status                  string
+	Model                   string
+	AutoStartDefault        bool
+	AutoComplete            bool           // Complete the quest once every task it spawned is done
+	MaxConcurrentObjectives int            // Most of the quest's tasks running at once (0 = no limit)
+	ObjectiveStartMode      string         // How auto-started objectives start (ObjectiveStartMode*, "" = dependency order)
+	ConversationPath        sql.NullString // Path to git conversation file: quests/{quest-id}/conversation.md
+	IssueNumber             sql.NullInt64  // Issue number on the git provider (GitHub or Forgejo)
+	CreatedAt               time.Time
+	CompletedAt             sql.NullTime
 }
 
 // GetTitle returns the title string, or empty if null
@@ -422,6 +430,11 @@ type QuestMessage struct {
 	Content   string
 	ToolCalls []QuestToolCall // Tool calls made during this message (assistant only)
 	CreatedAt time.Time
+
+	// Model usage behind the message, including tool turns and history summarization (assistant only)
+	InputTokens  int64
+	OutputTokens int64
+	DollarsUsed  float64
 }
 
 // QuestTemplate represents a reusable quest template
@@ -434,6 +447,18 @@ type QuestTemplate struct {
 	CreatedAt     time.Time
 }
 
+// ObjectiveTemplate is a reusable objective with a pre-filled title, hat, and checklist
+type ObjectiveTemplate struct {
+	ID          string
+	ProjectID   string
+	Name        string
+	Title       string
+	Description sql.NullString
+	Hat         sql.NullString
+	Checklist   []string // Checklist item descriptions, in order
+	CreatedAt   time.Time
+}
+
 // GetDescription returns the description string, or empty if null
 func (t *Task) GetDescription() string {
 	if t.Description.Valid {
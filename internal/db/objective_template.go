@@ -0,0 +1,149 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const objectiveTemplateColumns = `id, project_id, name, title, description, hat, checklist, created_at`
+
+// CreateObjectiveTemplate creates a new objective template
+func (db *DB) CreateObjectiveTemplate(projectID, name, title, description, hat string, checklist []string) (*ObjectiveTemplate, error) {
+	template := &ObjectiveTemplate{
+		ID:          NewPrefixedID("otpl"),
+		ProjectID:   projectID,
+		Name:        name,
+		Title:       title,
+		Description: sql.NullString{String: description, Valid: description != ""},
+		Hat:         sql.NullString{String: hat, Valid: hat != ""},
+		Checklist:   checklist,
+		CreatedAt:   time.Now(),
+	}
+
+	checklistJSON, err := json.Marshal(checklist)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal checklist: %w", err)
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO objective_templates (`+objectiveTemplateColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		template.ID, template.ProjectID, template.Name, template.Title,
+		template.Description, template.Hat, string(checklistJSON), template.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create objective template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetObjectiveTemplateByID retrieves an objective template by its ID
+func (db *DB) GetObjectiveTemplateByID(id string) (*ObjectiveTemplate, error) {
+	template, err := scanObjectiveTemplate(db.QueryRow(
+		`SELECT `+objectiveTemplateColumns+` FROM objective_templates WHERE id = ?`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get objective template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetObjectiveTemplatesByProjectID retrieves all objective templates for a project
+func (db *DB) GetObjectiveTemplatesByProjectID(projectID string) ([]*ObjectiveTemplate, error) {
+	rows, err := db.Query(
+		`SELECT `+objectiveTemplateColumns+`
+		 FROM objective_templates WHERE project_id = ? ORDER BY name ASC`,
+		projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get objective templates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var templates []*ObjectiveTemplate
+	for rows.Next() {
+		template, err := scanObjectiveTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan objective template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating objective templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// UpdateObjectiveTemplate updates an objective template
+func (db *DB) UpdateObjectiveTemplate(id, name, title, description, hat string, checklist []string) error {
+	checklistJSON, err := json.Marshal(checklist)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checklist: %w", err)
+	}
+
+	result, err := db.Exec(
+		`UPDATE objective_templates SET name = ?, title = ?, description = ?, hat = ?, checklist = ? WHERE id = ?`,
+		name, title,
+		sql.NullString{String: description, Valid: description != ""},
+		sql.NullString{String: hat, Valid: hat != ""},
+		string(checklistJSON), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update objective template: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("objective template not found: %s", id)
+	}
+
+	return nil
+}
+
+// DeleteObjectiveTemplate removes an objective template
+func (db *DB) DeleteObjectiveTemplate(id string) error {
+	result, err := db.Exec(`DELETE FROM objective_templates WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete objective template: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("objective template not found: %s", id)
+	}
+
+	return nil
+}
+
+// scanObjectiveTemplate scans a row selected with objectiveTemplateColumns
+func scanObjectiveTemplate(row interface{ Scan(...any) error }) (*ObjectiveTemplate, error) {
+	template := &ObjectiveTemplate{}
+	var checklistJSON sql.NullString
+
+	err := row.Scan(
+		&template.ID, &template.ProjectID, &template.Name, &template.Title,
+		&template.Description, &template.Hat, &checklistJSON, &template.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if checklistJSON.Valid && checklistJSON.String != "" {
+		if err := json.Unmarshal([]byte(checklistJSON.String), &template.Checklist); err != nil {
+			return nil, fmt.Errorf("failed to parse checklist: %w", err)
+		}
+	}
+
+	return template, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestObjectiveTemplateCRUD(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checklist := []string{"Add route", "Add handler", "Add tests"}
+	created, err := db.CreateObjectiveTemplate(project.ID, "API endpoint", "Add endpoint", "", "creator", checklist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetObjectiveTemplateByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Title != "Add endpoint" || got.Hat.String != "creator" || got.Description.Valid {
+		t.Fatalf("got %+v, want stored template", got)
+	}
+	if !slices.Equal(got.Checklist, checklist) {
+		t.Errorf("checklist = %v, want %v", got.Checklist, checklist)
+	}
+
+	if err := db.UpdateObjectiveTemplate(created.ID, "API endpoint", "Add endpoint", "REST", "", []string{"Add route"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = db.GetObjectiveTemplateByID(created.ID)
+	if got.Hat.Valid || got.Description.String != "REST" || !slices.Equal(got.Checklist, []string{"Add route"}) {
+		t.Errorf("after update got %+v", got)
+	}
+
+	templates, err := db.GetObjectiveTemplatesByProjectID(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 {
+		t.Errorf("len(templates) = %d, want 1", len(templates))
+	}
+
+	if err := db.DeleteObjectiveTemplate(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetObjectiveTemplateByID(created.ID); got != nil {
+		t.Error("template still present after delete")
+	}
+	if err := db.DeleteObjectiveTemplate(created.ID); err == nil {
+		t.Error("expected error deleting missing template")
+	}
+}
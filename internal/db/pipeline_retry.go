@@ -0,0 +1,125 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// DefaultPipelineRetries is how many pipeline retries a task gets when the policy doesn't say
+	DefaultPipelineRetries = 1
+
+	// MaxPipelineRetries caps the retries a policy may allow
+	MaxPipelineRetries = 5
+)
+
+// PipelineRetryRule restarts a task at RetryHat when a session in FailedHat
+// fails, instead of pausing the task
+type PipelineRetryRule struct {
+	FailedHat string `json:"failed_hat"`
+	RetryHat  string `json:"retry_hat"`
+}
+
+// PipelineRetryPolicy configures pipeline-level recovery for a project's tasks
+type PipelineRetryPolicy struct {
+	Rules      []PipelineRetryRule `json:"rules"`
+	MaxRetries int                 `json:"max_retries,omitempty"` // Per task; 0 uses DefaultPipelineRetries
+}
+
+// IsZero reports whether the policy retries nothing
+func (p *PipelineRetryPolicy) IsZero() bool {
+	return p == nil || len(p.Rules) == 0
+}
+
+// Validate checks that every rule names both hats, no failed hat has two
+// rules, and the retry limit is in range. Hat names are checked by the session package.
+func (p *PipelineRetryPolicy) Validate() error {
+	if p.MaxRetries < 0 || p.MaxRetries > MaxPipelineRetries {
+		return fmt.Errorf("max_retries must be between 0 and %d, got %d", MaxPipelineRetries, p.MaxRetries)
+	}
+	seen := make(map[string]bool, len(p.Rules))
+	for _, rule := range p.Rules {
+		if rule.FailedHat == "" || rule.RetryHat == "" {
+			return fmt.Errorf("pipeline retry rules require failed_hat and retry_hat")
+		}
+		if seen[rule.FailedHat] {
+			return fmt.Errorf("duplicate pipeline retry rule for hat %q", rule.FailedHat)
+		}
+		seen[rule.FailedHat] = true
+	}
+	return nil
+}
+
+// RetryHatFor returns the hat to restart at when failedHat fails
+func (p *PipelineRetryPolicy) RetryHatFor(failedHat string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	for _, rule := range p.Rules {
+		if rule.FailedHat == failedHat {
+			return rule.RetryHat, true
+		}
+	}
+	return "", false
+}
+
+// Limit returns how many pipeline retries a task gets
+func (p *PipelineRetryPolicy) Limit() int {
+	if p == nil || p.MaxRetries == 0 {
+		return DefaultPipelineRetries
+	}
+	return p.MaxRetries
+}
+
+// GetProjectPipelineRetry returns the project's pipeline retry policy, or nil if it has none
+func (db *DB) GetProjectPipelineRetry(projectID string) (*PipelineRetryPolicy, error) {
+	var policyJSON sql.NullString
+	err := db.QueryRow(`SELECT pipeline_retry FROM projects WHERE id = ?`, projectID).Scan(&policyJSON)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project pipeline retry policy: %w", err)
+	}
+	if !policyJSON.Valid || policyJSON.String == "" {
+		return nil, nil
+	}
+
+	var policy PipelineRetryPolicy
+	if err := json.Unmarshal([]byte(policyJSON.String), &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse project pipeline retry policy: %w", err)
+	}
+	if policy.IsZero() {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+// SetProjectPipelineRetry sets the project's pipeline retry policy (nil or no rules clears it)
+func (db *DB) SetProjectPipelineRetry(projectID string, policy *PipelineRetryPolicy) error {
+	var policyJSON sql.NullString
+	if !policy.IsZero() {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(policy)
+		if err != nil {
+			return fmt.Errorf("failed to marshal pipeline retry policy: %w", err)
+		}
+		policyJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	result, err := db.Exec(`UPDATE projects SET pipeline_retry = ? WHERE id = ?`, policyJSON, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project pipeline retry policy: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
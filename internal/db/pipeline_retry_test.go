@@ -0,0 +1,65 @@
+package db
+
+import "testing"
+
+func TestProjectPipelineRetry(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if policy, err := db.GetProjectPipelineRetry(project.ID); err != nil || policy != nil {
+		t.Fatalf("expected no policy by default, got %+v, %v", policy, err)
+	}
+
+	policy := &PipelineRetryPolicy{
+		Rules:      []PipelineRetryRule{{FailedHat: "critic", RetryHat: "creator"}},
+		MaxRetries: 2,
+	}
+	if err := db.SetProjectPipelineRetry(project.ID, policy); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetProjectPipelineRetry(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hat, ok := got.RetryHatFor("critic"); !ok || hat != "creator" || got.Limit() != 2 {
+		t.Errorf("unexpected policy: %+v", got)
+	}
+	if _, ok := got.RetryHatFor("editor"); ok {
+		t.Error("expected no rule for editor")
+	}
+
+	// An empty policy clears it
+	if err := db.SetProjectPipelineRetry(project.ID, &PipelineRetryPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetProjectPipelineRetry(project.ID); got != nil {
+		t.Errorf("expected policy cleared, got %+v", got)
+	}
+
+	if err := db.SetProjectPipelineRetry("proj-missing", policy); err == nil {
+		t.Error("expected an error for a missing project")
+	}
+}
+
+func TestPipelineRetryPolicy_Validate(t *testing.T) {
+	invalid := []PipelineRetryPolicy{
+		{Rules: []PipelineRetryRule{{FailedHat: "critic"}}},
+		{Rules: []PipelineRetryRule{{FailedHat: "critic", RetryHat: "creator"}, {FailedHat: "critic", RetryHat: "critic"}}},
+		{Rules: []PipelineRetryRule{{FailedHat: "critic", RetryHat: "creator"}}, MaxRetries: MaxPipelineRetries + 1},
+		{Rules: []PipelineRetryRule{{FailedHat: "critic", RetryHat: "creator"}}, MaxRetries: -1},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", p)
+		}
+	}
+
+	var none *PipelineRetryPolicy
+	if none.Limit() != DefaultPipelineRetries {
+		t.Errorf("expected default limit, got %d", none.Limit())
+	}
+}
@@ -0,0 +1,178 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migrationProjectGitCredentials stores git credentials for projects whose
+// repos need different access than the global GitHub token. Tokens are
+// encrypted with the master key.
+const migrationProjectGitCredentials = `
+CREATE TABLE IF NOT EXISTS project_git_credentials (
+	project_id TEXT PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+	token TEXT,
+	installation TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// ErrProjectNotFound is returned when storing git credentials for a project
+// that doesn't exist
+var ErrProjectNotFound = errors.New("project not found")
+
+// ProjectGitCredentials are a project's git credentials: either a token, or
+// the account login of a GitHub App installation to mint tokens from
+type ProjectGitCredentials struct {
+	Token        string `json:"-"`
+	Installation string `json:"installation,omitempty"`
+}
+
+// Validate checks that exactly one of token and installation is set
+func (c *ProjectGitCredentials) Validate() error {
+	c.Token = strings.TrimSpace(c.Token)
+	c.Installation = strings.TrimSpace(c.Installation)
+	if (c.Token == "") == (c.Installation == "") {
+		return fmt.Errorf("exactly one of token and installation must be set")
+	}
+	return nil
+}
+
+// SetProjectGitCredentials stores a project's git credentials, replacing any
+// it had. Tokens are never stored in plaintext, so a master key is required.
+func (s *EncryptedSecretsStore) SetProjectGitCredentials(projectID string, creds *ProjectGitCredentials) error {
+	if err := creds.Validate(); err != nil {
+		return err
+	}
+
+	var token sql.NullString
+	if creds.Token != "" {
+		if s.masterKey == nil {
+			return fmt.Errorf("encryption is not configured; project git tokens can't be stored")
+		}
+		enc, err := s.masterKey.Encrypt([]byte(creds.Token))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt project git token: %w", err)
+		}
+		token = sql.NullString{String: enc, Valid: true}
+	}
+	installation := sql.NullString{String: creds.Installation, Valid: creds.Installation != ""}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM projects WHERE id = ?)`, projectID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check project: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectID)
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		INSERT INTO project_git_credentials (project_id, token, installation, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(project_id) DO UPDATE SET
+			token = excluded.token,
+			installation = excluded.installation,
+			updated_at = excluded.updated_at
+	`, projectID, token, installation, now, now); err != nil {
+		return fmt.Errorf("failed to set project git credentials: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit project git credentials: %w", err)
+	}
+	return nil
+}
+
+// GetProjectGitCredentials returns a project's decrypted git credentials,
+// or nil if it has none
+func (s *EncryptedSecretsStore) GetProjectGitCredentials(projectID string) (*ProjectGitCredentials, error) {
+	var token, installation sql.NullString
+	err := s.db.QueryRow(
+		`SELECT token, installation FROM project_git_credentials WHERE project_id = ?`,
+		projectID,
+	).Scan(&token, &installation)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project git credentials: %w", err)
+	}
+
+	creds := &ProjectGitCredentials{Installation: installation.String}
+	if token.Valid && token.String != "" {
+		if s.masterKey == nil {
+			return nil, fmt.Errorf("encryption is not configured; project git token can't be decrypted")
+		}
+		decrypted, err := s.masterKey.Decrypt(token.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt project git token: %w", err)
+		}
+		creds.Token = string(decrypted)
+	}
+	return creds, nil
+}
+
+// DeleteProjectGitCredentials removes a project's git credentials, so it
+// falls back to the global ones
+func (s *EncryptedSecretsStore) DeleteProjectGitCredentials(projectID string) error {
+	_, err := s.db.Exec(`DELETE FROM project_git_credentials WHERE project_id = ?`, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete project git credentials: %w", err)
+	}
+	return nil
+}
+
+// GitHubAppCredentials are what's needed to authenticate as the GitHub App
+type GitHubAppCredentials struct {
+	AppID      int64
+	PrivateKey string // PEM
+}
+
+// GetGitHubAppCredentials returns the configured GitHub App with its private
+// key decrypted, or nil if there isn't one
+func (s *EncryptedSecretsStore) GetGitHubAppCredentials() (*GitHubAppCredentials, error) {
+	app := &GitHubAppCredentials{}
+	var encrypted sql.NullBool
+	err := s.db.QueryRow(
+		`SELECT app_id, private_key, encrypted FROM github_app_config WHERE id = 1`,
+	).Scan(&app.AppID, &app.PrivateKey, &encrypted)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub App config: %w", err)
+	}
+
+	if encrypted.Bool {
+		if s.masterKey == nil {
+			return nil, fmt.Errorf("encryption is not configured; GitHub App private key can't be decrypted")
+		}
+		decrypted, err := s.masterKey.Decrypt(app.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt GitHub App private key: %w", err)
+		}
+		app.PrivateKey = string(decrypted)
+	}
+	return app, nil
+}
+
+// HasGitHubApp reports whether a GitHub App is configured
+func (db *DB) HasGitHubApp() (bool, error) {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM github_app_config`).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check GitHub App config: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/crypto"
+)
+
+func TestProjectGitCredentials(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	masterKey, err := crypto.NewMasterKey([]byte("test-password"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewEncryptedSecretsStore(db, masterKey)
+
+	if creds, err := store.GetProjectGitCredentials(project.ID); err != nil || creds != nil {
+		t.Fatalf("got %+v, %v; want no credentials", creds, err)
+	}
+
+	if err := store.SetProjectGitCredentials(project.ID, &ProjectGitCredentials{Token: "ghp_secret"}); err != nil {
+		t.Fatal(err)
+	}
+	var stored string
+	if err := db.QueryRow(`SELECT token FROM project_git_credentials WHERE project_id = ?`, project.ID).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored == "" || strings.Contains(stored, "ghp_secret") {
+		t.Errorf("token stored as %q, want it encrypted", stored)
+	}
+	creds, err := store.GetProjectGitCredentials(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Token != "ghp_secret" || creds.Installation != "" {
+		t.Errorf("got %+v, want the token back", creds)
+	}
+
+	// Switching to an installation replaces the token
+	if err := store.SetProjectGitCredentials(project.ID, &ProjectGitCredentials{Installation: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if creds, _ = store.GetProjectGitCredentials(project.ID); creds.Token != "" || creds.Installation != "acme" {
+		t.Errorf("got %+v, want only the installation", creds)
+	}
+
+	for _, bad := range []*ProjectGitCredentials{{}, {Token: "t", Installation: "acme"}} {
+		if err := store.SetProjectGitCredentials(project.ID, bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+	if err := store.SetProjectGitCredentials("proj-missing", &ProjectGitCredentials{Installation: "acme"}); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("got %v, want project not found", err)
+	}
+	if err := NewEncryptedSecretsStore(db, nil).SetProjectGitCredentials(project.ID, &ProjectGitCredentials{Token: "t"}); err == nil {
+		t.Error("expected tokens to be refused without a master key")
+	}
+
+	// Deleting the project removes its credentials
+	if err := db.DeleteProject(project.ID); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM project_git_credentials`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("got %d credential rows after deleting the project, want 0", count)
+	}
+}
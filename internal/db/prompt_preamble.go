@@ -0,0 +1,38 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetProjectPromptPreamble returns the guidance prepended to every hat's system
+// prompt in the project, or "" if it doesn't set any
+func (db *DB) GetProjectPromptPreamble(projectID string) (string, error) {
+	var preamble sql.NullString
+	err := db.QueryRow(`SELECT prompt_preamble FROM projects WHERE id = ?`, projectID).Scan(&preamble)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project prompt preamble: %w", err)
+	}
+	return preamble.String, nil
+}
+
+// SetProjectPromptPreamble sets the guidance prepended to every hat's system
+// prompt in the project (empty clears it)
+func (db *DB) SetProjectPromptPreamble(projectID, preamble string) error {
+	value := sql.NullString{String: preamble, Valid: preamble != ""}
+	result, err := db.Exec(`UPDATE projects SET prompt_preamble = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project prompt preamble: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import "testing"
+
+func TestProjectPromptPreamble(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preamble, err := db.GetProjectPromptPreamble(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preamble != "" {
+		t.Errorf("new project has preamble %q", preamble)
+	}
+
+	if err := db.SetProjectPromptPreamble(project.ID, "Never use library X."); err != nil {
+		t.Fatal(err)
+	}
+	if preamble, _ = db.GetProjectPromptPreamble(project.ID); preamble != "Never use library X." {
+		t.Errorf("got preamble %q after setting it", preamble)
+	}
+
+	if err := db.SetProjectPromptPreamble(project.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if preamble, _ = db.GetProjectPromptPreamble(project.ID); preamble != "" {
+		t.Errorf("got preamble %q after clearing it", preamble)
+	}
+
+	if err := db.SetProjectPromptPreamble("missing", "guidance"); err == nil {
+		t.Error("set a preamble on a missing project")
+	}
+}
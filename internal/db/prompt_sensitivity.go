@@ -0,0 +1,109 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/security"
+)
+
+// promptSensitivityColumn converts a sensitivity into a nullable column value (empty clears the override)
+func promptSensitivityColumn(level string) (sql.NullString, error) {
+	if level == "" {
+		return sql.NullString{}, nil
+	}
+	if _, err := security.ParseSensitivity(level); err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: level, Valid: true}, nil
+}
+
+// GetProjectPromptSensitivity returns the project's prompt sensitivity, or "" if not set
+func (db *DB) GetProjectPromptSensitivity(projectID string) (string, error) {
+	var level sql.NullString
+	err := db.QueryRow(`SELECT prompt_sensitivity FROM projects WHERE id = ?`, projectID).Scan(&level)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project prompt sensitivity: %w", err)
+	}
+	return level.String, nil
+}
+
+// SetProjectPromptSensitivity sets the project's prompt sensitivity ("" clears it)
+func (db *DB) SetProjectPromptSensitivity(projectID, level string) error {
+	value, err := promptSensitivityColumn(level)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE projects SET prompt_sensitivity = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project prompt sensitivity: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// GetTaskPromptSensitivity returns the task's own prompt sensitivity, or "" if it inherits
+func (db *DB) GetTaskPromptSensitivity(taskID string) (string, error) {
+	var level sql.NullString
+	err := db.QueryRow(`SELECT prompt_sensitivity FROM tasks WHERE id = ?`, taskID).Scan(&level)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get task prompt sensitivity: %w", err)
+	}
+	return level.String, nil
+}
+
+// SetTaskPromptSensitivity sets the task's prompt sensitivity ("" inherits from the project)
+func (db *DB) SetTaskPromptSensitivity(taskID, level string) error {
+	value, err := promptSensitivityColumn(level)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE tasks SET prompt_sensitivity = ? WHERE id = ?`, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update task prompt sensitivity: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
+
+// ResolvePromptSensitivity returns the effective prompt sensitivity for a task:
+// the task's own level, then its project's, or "" so the caller's default applies
+func (db *DB) ResolvePromptSensitivity(taskID string) (string, error) {
+	var taskLevel, projectLevel sql.NullString
+	err := db.QueryRow(
+		`SELECT t.prompt_sensitivity, p.prompt_sensitivity
+		 FROM tasks t LEFT JOIN projects p ON p.id = t.project_id
+		 WHERE t.id = ?`,
+		taskID,
+	).Scan(&taskLevel, &projectLevel)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve prompt sensitivity: %w", err)
+	}
+
+	if taskLevel.String != "" {
+		return taskLevel.String, nil
+	}
+	return projectLevel.String, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import "testing"
+
+func TestResolvePromptSensitivity(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Triage inbound tickets", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing configured: the caller's default applies
+	level, err := db.ResolvePromptSensitivity(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != "" {
+		t.Errorf("default sensitivity = %q, want empty", level)
+	}
+
+	// Project level applies to its tasks
+	if err := db.SetProjectPromptSensitivity(project.ID, "off"); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ = db.ResolvePromptSensitivity(task.ID); level != "off" {
+		t.Errorf("sensitivity = %q, want project's off", level)
+	}
+
+	// Task override wins
+	if err := db.SetTaskPromptSensitivity(task.ID, "strict"); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ = db.ResolvePromptSensitivity(task.ID); level != "strict" {
+		t.Errorf("sensitivity = %q, want task's strict", level)
+	}
+
+	// Clones keep the override
+	clone, err := db.CloneTask(task.ID, CloneTaskOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own, _ := db.GetTaskPromptSensitivity(clone.ID); own != "strict" {
+		t.Errorf("clone sensitivity = %q, want strict", own)
+	}
+
+	// Clearing the override falls back to the project again
+	if err := db.SetTaskPromptSensitivity(task.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ = db.ResolvePromptSensitivity(task.ID); level != "off" {
+		t.Errorf("sensitivity = %q, want project's off", level)
+	}
+
+	if err := db.SetProjectPromptSensitivity(project.ID, "paranoid"); err == nil {
+		t.Error("expected unknown sensitivity to be rejected")
+	}
+}
@@ -36,13 +36,15 @@ func (db *DB) GetQuestByID(id string) (*Quest, error) {
 	quest := &Quest{}
 
 	err := db.QueryRow(
-		`SELECT id, project_id, title, status, model, auto_start_default, conversation_path,
-		        issue_number, created_at, completed_at
+		`SELECT id, project_id, title, status, model, auto_start_default, auto_complete_on_tasks_done,
+		        COALESCE(max_concurrent_objectives, 0), COALESCE(objective_start_mode, ''),
+		        conversation_path, issue_number, created_at, completed_at
 		 FROM quests WHERE id = ?`,
 		id,
 	).Scan(
 		&quest.ID, &quest.ProjectID, &quest.Title, &quest.Status,
-		&quest.Model, &quest.AutoStartDefault, &quest.ConversationPath,
+		&quest.Model, &quest.AutoStartDefault, &quest.AutoComplete,
+		&quest.MaxConcurrentObjectives, &quest.ObjectiveStartMode, &quest.ConversationPath,
 		&quest.IssueNumber, &quest.CreatedAt, &quest.CompletedAt,
 	)
 
@@ -59,8 +61,9 @@ func (db *DB) GetQuestByID(id string) (*Quest, error) {
 // GetQuestsByProjectID retrieves all Quests for a project
 func (db *DB) GetQuestsByProjectID(projectID string) ([]*Quest, error) {
 	rows, err := db.Query(
-		`SELECT id, project_id, title, status, model, auto_start_default, conversation_path,
-		        issue_number, created_at, completed_at
+		`SELECT id, project_id, title, status, model, auto_start_default, auto_complete_on_tasks_done,
+		        COALESCE(max_concurrent_objectives, 0), COALESCE(objective_start_mode, ''),
+		        conversation_path, issue_number, created_at, completed_at
 		 FROM quests WHERE project_id = ? ORDER BY created_at DESC`,
 		projectID,
 	)
@@ -74,7 +77,8 @@ func (db *DB) GetQuestsByProjectID(projectID string) ([]*Quest, error) {
 		quest := &Quest{}
 		err := rows.Scan(
 			&quest.ID, &quest.ProjectID, &quest.Title, &quest.Status,
-			&quest.Model, &quest.AutoStartDefault, &quest.ConversationPath,
+			&quest.Model, &quest.AutoStartDefault, &quest.AutoComplete,
+			&quest.MaxConcurrentObjectives, &quest.ObjectiveStartMode, &quest.ConversationPath,
 			&quest.IssueNumber, &quest.CreatedAt, &quest.CompletedAt,
 		)
 		if err != nil {
@@ -93,8 +97,9 @@ func (db *DB) GetQuestsByProjectID(projectID string) ([]*Quest, error) {
 // GetActiveQuests retrieves all active Quests for a project
 func (db *DB) GetActiveQuests(projectID string) ([]*Quest, error) {
 	rows, err := db.Query(
-		`SELECT id, project_id, title, status, model, auto_start_default, conversation_path,
-		        issue_number, created_at, completed_at
+		`SELECT id, project_id, title, status, model, auto_start_default, auto_complete_on_tasks_done,
+		        COALESCE(max_concurrent_objectives, 0), COALESCE(objective_start_mode, ''),
+		        conversation_path, issue_number, created_at, completed_at
 		 FROM quests WHERE project_id = ? AND status = ? ORDER BY created_at DESC`,
 		projectID, QuestStatusActive,
 	)
@@ -108,7 +113,8 @@ func (db *DB) GetActiveQuests(projectID string) ([]*Quest, error) {
 		quest := &Quest{}
 		err := rows.Scan(
 			&quest.ID, &quest.ProjectID, &quest.Title, &quest.Status,
-			&quest.Model, &quest.AutoStartDefault, &quest.ConversationPath,
+			&quest.Model, &quest.AutoStartDefault, &quest.AutoComplete,
+			&quest.MaxConcurrentObjectives, &quest.ObjectiveStartMode, &quest.ConversationPath,
 			&quest.IssueNumber, &quest.CreatedAt, &quest.CompletedAt,
 		)
 		if err != nil {
@@ -301,7 +307,8 @@ func (db *DB) CreateQuestMessageWithToolCalls(questID, role, content string, too
 // GetQuestMessages retrieves all messages for a Quest in chronological order
 func (db *DB) GetQuestMessages(questID string) ([]*QuestMessage, error) {
 	rows, err := db.Query(
-		`SELECT id, quest_id, role, content, tool_calls, created_at
+		`SELECT id, quest_id, role, content, tool_calls, created_at,
+		        COALESCE(input_tokens, 0), COALESCE(output_tokens, 0), COALESCE(dollars_used, 0)
 		 FROM quest_messages WHERE quest_id = ?
 		 ORDER BY created_at ASC`,
 		questID,
@@ -315,7 +322,8 @@ func (db *DB) GetQuestMessages(questID string) ([]*QuestMessage, error) {
 	for rows.Next() {
 		msg := &QuestMessage{}
 		var toolCallsJSON sql.NullString
-		err := rows.Scan(&msg.ID, &msg.QuestID, &msg.Role, &msg.Content, &toolCallsJSON, &msg.CreatedAt)
+		err := rows.Scan(&msg.ID, &msg.QuestID, &msg.Role, &msg.Content, &toolCallsJSON, &msg.CreatedAt,
+			&msg.InputTokens, &msg.OutputTokens, &msg.DollarsUsed)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan quest message: %w", err)
 		}
@@ -338,12 +346,15 @@ func (db *DB) GetQuestMessages(questID string) ([]*QuestMessage, error) {
 	return messages, nil
 }
 
-// DeleteQuestMessages removes all messages for a Quest
+// DeleteQuestMessages removes all messages for a Quest, along with the summary of its history
 func (db *DB) DeleteQuestMessages(questID string) error {
 	_, err := db.Exec(`DELETE FROM quest_messages WHERE quest_id = ?`, questID)
 	if err != nil {
 		return fmt.Errorf("failed to delete quest messages: %w", err)
 	}
+	if err := db.SetQuestHistorySummary(questID, "", 0); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -394,7 +405,9 @@ type QuestSummary struct {
 	FailedTasks      int
 	BlockedTasks     int
 	PendingTasks     int
-	TotalDollarsUsed float64
+	TotalDollarsUsed float64 // Tasks' sessions
+
+	ConversationDollarsUsed float64 // The quest conversation itself
 }
 
 // GetQuestSummary calculates task statistics for a Quest (derived from tasks and sessions)
@@ -450,5 +463,10 @@ func (db *DB) GetQuestSummary(questID string) (*QuestSummary, error) {
 		return nil, fmt.Errorf("failed to aggregate quest cost: %w", err)
 	}
 
+	summary.ConversationDollarsUsed, err = db.GetQuestConversationCost(questID)
+	if err != nil {
+		return nil, err
+	}
+
 	return summary, nil
 }
@@ -0,0 +1,47 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetQuestAutoComplete sets whether the quest completes on its own once every
+// task it spawned is done
+func (db *DB) SetQuestAutoComplete(id string, enabled bool) error {
+	result, err := db.Exec(`UPDATE quests SET auto_complete_on_tasks_done = ? WHERE id = ?`, enabled, id)
+	if err != nil {
+		return fmt.Errorf("failed to update quest auto-complete: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("quest not found: %s", id)
+	}
+
+	return nil
+}
+
+// CompleteQuestIfTasksDone completes an active quest that has auto-complete
+// on, if it spawned at least one task and every one of them is completed,
+// cancelled, timed out, or failed. Returns whether it completed the quest;
+// when several tasks finish at once, only one call does.
+func (db *DB) CompleteQuestIfTasksDone(id string) (bool, error) {
+	result, err := db.Exec(
+		`UPDATE quests SET status = ?, completed_at = ?
+		 WHERE id = ? AND status = ? AND auto_complete_on_tasks_done = 1
+		   AND EXISTS (SELECT 1 FROM tasks WHERE quest_id = quests.id)
+		   AND NOT EXISTS (
+		       SELECT 1 FROM tasks
+		       WHERE quest_id = quests.id AND status NOT IN (?, ?, ?, 'failed')
+		   )`,
+		QuestStatusCompleted, time.Now(), id, QuestStatusActive,
+		TaskStatusCompleted, TaskStatusCancelled, TaskStatusTimedOut,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to auto-complete quest: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
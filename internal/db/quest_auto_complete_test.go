@@ -0,0 +1,74 @@
+package db
+
+import "testing"
+
+func TestCompleteQuestIfTasksDone(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := db.CreateQuest(project.ID, QuestModelSonnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complete := func() bool {
+		t.Helper()
+		done, err := db.CompleteQuestIfTasksDone(quest.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return done
+	}
+
+	if err := db.SetQuestAutoComplete(quest.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if complete() {
+		t.Error("completed a quest that hasn't spawned any tasks")
+	}
+
+	api, err := db.CreateTaskForQuest(quest.ID, project.ID, "API", "", "creator", TaskTypeTask, TaskModelSonnet, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui, err := db.CreateTaskForQuest(quest.ID, project.ID, "UI", "", "creator", TaskTypeTask, TaskModelSonnet, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskStatus(api.ID, TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if complete() {
+		t.Error("completed a quest with a task still pending")
+	}
+
+	if err := db.UpdateTaskStatus(ui.ID, TaskStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetQuestAutoComplete(quest.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if complete() {
+		t.Error("completed a quest with auto-complete off")
+	}
+
+	if err := db.SetQuestAutoComplete(quest.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if !complete() {
+		t.Fatal("expected the quest to complete once every task is done")
+	}
+	got, err := db.GetQuestByID(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != QuestStatusCompleted || !got.CompletedAt.Valid || !got.AutoComplete {
+		t.Errorf("quest = %+v, want completed with auto-complete on", got)
+	}
+	if complete() {
+		t.Error("completed the quest twice")
+	}
+}
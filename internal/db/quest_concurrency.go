@@ -0,0 +1,80 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Objective start modes control which of a quest's auto-started objectives
+// start as soon as they're accepted
+const (
+	// ObjectiveStartModeDependencyOrder starts an objective once its blockers
+	// complete (the default)
+	ObjectiveStartModeDependencyOrder = "dependency_order"
+	// ObjectiveStartModeAll starts every accepted objective, blocked or not
+	ObjectiveStartModeAll = "all"
+)
+
+// ValidateObjectiveStartMode reports whether mode is a known objective start mode
+func ValidateObjectiveStartMode(mode string) error {
+	switch mode {
+	case ObjectiveStartModeDependencyOrder, ObjectiveStartModeAll:
+		return nil
+	}
+	return fmt.Errorf("invalid objective start mode: %s (must be %s or %s)",
+		mode, ObjectiveStartModeDependencyOrder, ObjectiveStartModeAll)
+}
+
+// SetQuestMaxConcurrentObjectives sets how many of the quest's tasks may run
+// at once (0 clears the limit)
+func (db *DB) SetQuestMaxConcurrentObjectives(id string, limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("max concurrent objectives must not be negative")
+	}
+
+	value := sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+	result, err := db.Exec(`UPDATE quests SET max_concurrent_objectives = ? WHERE id = ?`, value, id)
+	if err != nil {
+		return fmt.Errorf("failed to update quest max concurrent objectives: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("quest not found: %s", id)
+	}
+
+	return nil
+}
+
+// SetQuestObjectiveStartMode sets how the quest's auto-started objectives start
+func (db *DB) SetQuestObjectiveStartMode(id, mode string) error {
+	if err := ValidateObjectiveStartMode(mode); err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE quests SET objective_start_mode = ? WHERE id = ?`, mode, id)
+	if err != nil {
+		return fmt.Errorf("failed to update quest objective start mode: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("quest not found: %s", id)
+	}
+
+	return nil
+}
+
+// CountRunningQuestTasks returns how many of the quest's tasks are running
+func (db *DB) CountRunningQuestTasks(questID string) (int, error) {
+	var count int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM tasks WHERE quest_id = ? AND status = ?`,
+		questID, TaskStatusRunning,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count running quest tasks: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import "testing"
+
+func TestQuestObjectiveStartSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := db.CreateQuest(project.ID, QuestModelSonnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quest.MaxConcurrentObjectives != 0 || quest.ObjectiveStartMode != "" {
+		t.Fatalf("new quest has start settings %d/%q, want none", quest.MaxConcurrentObjectives, quest.ObjectiveStartMode)
+	}
+
+	if err := db.SetQuestMaxConcurrentObjectives(quest.ID, -1); err == nil {
+		t.Error("accepted a negative limit")
+	}
+	if err := db.SetQuestObjectiveStartMode(quest.ID, "whenever"); err == nil {
+		t.Error("accepted an unknown start mode")
+	}
+	if err := db.SetQuestMaxConcurrentObjectives("missing", 2); err == nil {
+		t.Error("set a limit on a missing quest")
+	}
+
+	if err := db.SetQuestMaxConcurrentObjectives(quest.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetQuestObjectiveStartMode(quest.ID, ObjectiveStartModeAll); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetQuestByID(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxConcurrentObjectives != 2 || got.ObjectiveStartMode != ObjectiveStartModeAll {
+		t.Errorf("got start settings %d/%q, want 2/%q", got.MaxConcurrentObjectives, got.ObjectiveStartMode, ObjectiveStartModeAll)
+	}
+
+	if err := db.SetQuestMaxConcurrentObjectives(quest.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err = db.GetQuestByID(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxConcurrentObjectives != 0 {
+		t.Errorf("got limit %d after clearing it, want 0", got.MaxConcurrentObjectives)
+	}
+}
+
+func TestCountRunningQuestTasks(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := db.CreateQuest(project.ID, QuestModelSonnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"API", "UI", "Docs"} {
+		task, err := db.CreateTaskForQuest(quest.ID, project.ID, title, "", "creator", TaskTypeTask, TaskModelSonnet, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title != "Docs" {
+			if err := db.UpdateTaskStatus(task.ID, TaskStatusRunning); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	other, err := db.CreateTask(project.ID, "Unrelated", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskStatus(other.ID, TaskStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := db.CountRunningQuestTasks(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("got %d running quest tasks, want 2", count)
+	}
+}
@@ -0,0 +1,74 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetQuestHistorySummary returns the summary of the quest's oldest messages
+// and how many messages it covers (0 if there is none)
+func (db *DB) GetQuestHistorySummary(questID string) (string, int, error) {
+	var summary sql.NullString
+	var through sql.NullInt64
+	err := db.QueryRow(`SELECT history_summary, history_summary_through FROM quests WHERE id = ?`, questID).Scan(&summary, &through)
+	if err == sql.ErrNoRows {
+		return "", 0, fmt.Errorf("quest not found: %s", questID)
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get quest history summary: %w", err)
+	}
+	if !summary.Valid || summary.String == "" {
+		return "", 0, nil
+	}
+	return summary.String, int(through.Int64), nil
+}
+
+// SetQuestHistorySummary stores the summary of the quest's first through
+// messages ("" clears it)
+func (db *DB) SetQuestHistorySummary(questID, summary string, through int) error {
+	value := sql.NullString{String: summary, Valid: summary != ""}
+	if !value.Valid {
+		through = 0
+	}
+
+	result, err := db.Exec(`UPDATE quests SET history_summary = ?, history_summary_through = ? WHERE id = ?`, value, through, questID)
+	if err != nil {
+		return fmt.Errorf("failed to update quest history summary: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("quest not found: %s", questID)
+	}
+
+	return nil
+}
+
+// SetQuestMessageUsage records the tokens and dollars spent producing a quest message
+func (db *DB) SetQuestMessageUsage(messageID string, inputTokens, outputTokens int64, dollars float64) error {
+	result, err := db.Exec(
+		`UPDATE quest_messages SET input_tokens = ?, output_tokens = ?, dollars_used = ? WHERE id = ?`,
+		inputTokens, outputTokens, dollars, messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update quest message usage: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("quest message not found: %s", messageID)
+	}
+
+	return nil
+}
+
+// GetQuestConversationCost returns the dollars spent on the quest's conversation
+func (db *DB) GetQuestConversationCost(questID string) (float64, error) {
+	var dollars float64
+	err := db.QueryRow(`SELECT COALESCE(SUM(dollars_used), 0) FROM quest_messages WHERE quest_id = ?`, questID).Scan(&dollars)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quest conversation cost: %w", err)
+	}
+	return dollars, nil
+}
@@ -0,0 +1,100 @@
+package db
+
+import "testing"
+
+func TestQuestHistorySummary(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := db.CreateQuest(project.ID, QuestModelSonnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, through, err := db.GetQuestHistorySummary(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != "" || through != 0 {
+		t.Fatalf("new quest has summary %q through %d, want none", summary, through)
+	}
+
+	if err := db.SetQuestHistorySummary(quest.ID, "- Wants a CLI", 12); err != nil {
+		t.Fatal(err)
+	}
+	if summary, through, _ = db.GetQuestHistorySummary(quest.ID); summary != "- Wants a CLI" || through != 12 {
+		t.Errorf("got summary %q through %d, want %q through 12", summary, through, "- Wants a CLI")
+	}
+
+	if err := db.SetQuestHistorySummary("missing", "x", 1); err == nil {
+		t.Error("set a summary on a missing quest")
+	}
+
+	// Deleting the conversation drops its summary
+	if err := db.DeleteQuestMessages(quest.ID); err != nil {
+		t.Fatal(err)
+	}
+	if summary, through, _ = db.GetQuestHistorySummary(quest.ID); summary != "" || through != 0 {
+		t.Errorf("got summary %q through %d after deleting messages, want none", summary, through)
+	}
+}
+
+func TestQuestMessageUsage(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := db.CreateQuest(project.ID, QuestModelSonnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.CreateQuestMessage(quest.ID, "user", "Plan a CLI"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := db.CreateQuestMessage(quest.ID, "assistant", "Here's a plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.CreateQuestMessage(quest.ID, "assistant", "And a second thought")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.SetQuestMessageUsage(first.ID, 1000, 200, 0.006); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetQuestMessageUsage(second.ID, 2000, 100, 0.0075); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetQuestMessageUsage("missing", 1, 1, 1); err == nil {
+		t.Error("recorded usage for a missing message")
+	}
+
+	messages, err := db.GetQuestMessages(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *QuestMessage
+	for _, msg := range messages {
+		if msg.ID == first.ID {
+			got = msg
+		}
+	}
+	if got == nil || got.InputTokens != 1000 || got.OutputTokens != 200 || got.DollarsUsed != 0.006 {
+		t.Errorf("got usage %+v, want 1000 in, 200 out, $0.006", got)
+	}
+
+	summary, err := db.GetQuestSummary(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0.0135; summary.ConversationDollarsUsed < want-1e-9 || summary.ConversationDollarsUsed > want+1e-9 {
+		t.Errorf("conversation cost = %v, want %v", summary.ConversationDollarsUsed, want)
+	}
+}
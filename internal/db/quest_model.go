@@ -0,0 +1,69 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ValidateQuestModel checks that model is a known quest model
+func ValidateQuestModel(model string) error {
+	if model != QuestModelSonnet && model != QuestModelOpus {
+		return fmt.Errorf("invalid quest model %q (must be sonnet or opus)", model)
+	}
+	return nil
+}
+
+// GetProjectDefaultQuestModel returns the model new quests in the project start
+// on, or "" if not set
+func (db *DB) GetProjectDefaultQuestModel(projectID string) (string, error) {
+	var model sql.NullString
+	err := db.QueryRow(`SELECT default_quest_model FROM projects WHERE id = ?`, projectID).Scan(&model)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project default quest model: %w", err)
+	}
+	return model.String, nil
+}
+
+// SetProjectDefaultQuestModel sets the model new quests in the project start on
+// ("" clears it)
+func (db *DB) SetProjectDefaultQuestModel(projectID, model string) error {
+	value := sql.NullString{String: model, Valid: model != ""}
+	if value.Valid {
+		if err := ValidateQuestModel(model); err != nil {
+			return err
+		}
+	}
+
+	result, err := db.Exec(`UPDATE projects SET default_quest_model = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project default quest model: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// ResolveQuestModel returns the model a new quest in the project should use:
+// the requested one if given, otherwise the project's default, otherwise sonnet
+func (db *DB) ResolveQuestModel(projectID, requested string) (string, error) {
+	if requested != "" {
+		return requested, nil
+	}
+
+	model, err := db.GetProjectDefaultQuestModel(projectID)
+	if err != nil {
+		return "", err
+	}
+	if model == "" {
+		return QuestModelSonnet, nil
+	}
+	return model, nil
+}
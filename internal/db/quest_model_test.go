@@ -0,0 +1,49 @@
+package db
+
+import "testing"
+
+func TestResolveQuestModel(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing configured: sonnet
+	model, err := db.ResolveQuestModel(project.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model != QuestModelSonnet {
+		t.Errorf("default model = %q, want sonnet", model)
+	}
+
+	// Project default applies when the request omits a model
+	if err := db.SetProjectDefaultQuestModel(project.ID, QuestModelOpus); err != nil {
+		t.Fatal(err)
+	}
+	if model, _ = db.ResolveQuestModel(project.ID, ""); model != QuestModelOpus {
+		t.Errorf("model = %q, want project's opus", model)
+	}
+
+	// An explicit model wins
+	if model, _ = db.ResolveQuestModel(project.ID, QuestModelSonnet); model != QuestModelSonnet {
+		t.Errorf("model = %q, want requested sonnet", model)
+	}
+
+	// Clearing the default falls back to sonnet
+	if err := db.SetProjectDefaultQuestModel(project.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if model, _ = db.ResolveQuestModel(project.ID, ""); model != QuestModelSonnet {
+		t.Errorf("model = %q, want sonnet after clearing", model)
+	}
+
+	if err := db.SetProjectDefaultQuestModel(project.ID, "haiku"); err == nil {
+		t.Error("expected unknown quest model to be rejected")
+	}
+	if _, err := db.ResolveQuestModel("proj-missing", ""); err == nil {
+		t.Error("expected missing project to be an error")
+	}
+}
@@ -0,0 +1,177 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import "fmt"
+
+// QuestTaskNode is one task in a quest's task tree
+type QuestTaskNode struct {
+	ID              string           `json:"id"`
+	Title           string           `json:"title"`
+	Type            string           `json:"type"`
+	Status          string           `json:"status"`
+	Hat             string           `json:"hat,omitempty"`
+	PRNumber        int64            `json:"pr_number,omitempty"`
+	PRMerged        bool             `json:"pr_merged"`
+	DollarsUsed     float64          `json:"dollars_used"`      // The task's own sessions
+	TreeDollarsUsed float64          `json:"tree_dollars_used"` // The task's and its subtasks' sessions
+	BlockedBy       []string         `json:"blocked_by,omitempty"`
+	Children        []*QuestTaskNode `json:"children,omitempty"`
+}
+
+// QuestTaskTree is every task a quest spawned, arranged by parent, with what
+// they cost and what blocks them
+type QuestTaskTree struct {
+	QuestID      string           `json:"quest_id"`
+	Tasks        []*QuestTaskNode `json:"tasks"` // Tasks with no parent in the quest
+	TaskCount    int              `json:"task_count"`
+	StatusCounts map[string]int   `json:"status_counts"`
+	DollarsUsed  float64          `json:"dollars_used"`
+}
+
+// GetQuestTaskTree returns the tasks a quest spawned as a tree. Subtasks sit
+// under their parent; tasks whose parent isn't part of the quest are roots.
+// Dependencies are listed on the blocked task, as the IDs of its blockers.
+// Costs are priced from session activity at each session's rates.
+func (db *DB) GetQuestTaskTree(questID string) (*QuestTaskTree, error) {
+	tasks, err := db.GetTasksByQuestID(questID)
+	if err != nil {
+		return nil, err
+	}
+	costs, err := db.getQuestTaskCosts(questID)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := db.getQuestTaskBlockers(questID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &QuestTaskTree{
+		QuestID:      questID,
+		Tasks:        []*QuestTaskNode{},
+		TaskCount:    len(tasks),
+		StatusCounts: make(map[string]int),
+	}
+
+	nodes := make(map[string]*QuestTaskNode, len(tasks))
+	for _, t := range tasks {
+		node := &QuestTaskNode{
+			ID:          t.ID,
+			Title:       t.Title,
+			Type:        t.Type,
+			Status:      t.Status,
+			Hat:         t.Hat.String,
+			PRNumber:    t.PRNumber.Int64,
+			PRMerged:    t.PRMergedAt.Valid,
+			DollarsUsed: costs[t.ID],
+			BlockedBy:   blockers[t.ID],
+		}
+		nodes[t.ID] = node
+		tree.StatusCounts[t.Status]++
+		tree.DollarsUsed += node.DollarsUsed
+	}
+
+	parents := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		if _, ok := nodes[t.ParentID.String]; ok && t.ParentID.Valid {
+			parents[t.ID] = t.ParentID.String
+		}
+	}
+
+	// Tasks come oldest first, so children keep their creation order
+	for _, t := range tasks {
+		node := nodes[t.ID]
+		if parentID, ok := parents[t.ID]; ok && !inParentCycle(parents, t.ID) {
+			nodes[parentID].Children = append(nodes[parentID].Children, node)
+		} else {
+			tree.Tasks = append(tree.Tasks, node)
+		}
+	}
+	for _, root := range tree.Tasks {
+		sumTreeDollars(root)
+	}
+
+	return tree, nil
+}
+
+// inParentCycle reports whether following parents up from id loops, which
+// would leave the task out of the tree if it were nested
+func inParentCycle(parents map[string]string, id string) bool {
+	next, ok := parents[id]
+	for range len(parents) {
+		if !ok {
+			return false
+		}
+		next, ok = parents[next]
+	}
+	return ok
+}
+
+// sumTreeDollars sets TreeDollarsUsed on node and its descendants
+func sumTreeDollars(node *QuestTaskNode) float64 {
+	node.TreeDollarsUsed = node.DollarsUsed
+	for _, child := range node.Children {
+		node.TreeDollarsUsed += sumTreeDollars(child)
+	}
+	return node.TreeDollarsUsed
+}
+
+// getQuestTaskCosts returns the dollars each of a quest's tasks has spent
+func (db *DB) getQuestTaskCosts(questID string) (map[string]float64, error) {
+	rows, err := db.Query(
+		`SELECT s.task_id, SUM(session_tokens.input_sum * s.input_rate + session_tokens.output_sum * s.output_rate) / 1000000.0
+		 FROM sessions s
+		 JOIN tasks t ON s.task_id = t.id
+		 JOIN (
+		     SELECT session_id,
+		            SUM(tokens_input) as input_sum,
+		            SUM(tokens_output) as output_sum
+		     FROM session_activity
+		     GROUP BY session_id
+		 ) session_tokens ON session_tokens.session_id = s.id
+		 WHERE t.quest_id = ?
+		 GROUP BY s.task_id`,
+		questID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest task costs: %w", err)
+	}
+	defer rows.Close()
+
+	costs := make(map[string]float64)
+	for rows.Next() {
+		var taskID string
+		var cost float64
+		if err := rows.Scan(&taskID, &cost); err != nil {
+			return nil, fmt.Errorf("failed to scan task cost: %w", err)
+		}
+		costs[taskID] = cost
+	}
+	return costs, rows.Err()
+}
+
+// getQuestTaskBlockers returns the IDs of the tasks blocking each of a quest's tasks
+func (db *DB) getQuestTaskBlockers(questID string) (map[string][]string, error) {
+	rows, err := db.Query(
+		`SELECT td.blocked_id, td.blocker_id
+		 FROM task_dependencies td
+		 JOIN tasks t ON td.blocked_id = t.id
+		 WHERE t.quest_id = ?
+		 ORDER BY td.created_at ASC`,
+		questID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest task dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	blockers := make(map[string][]string)
+	for rows.Next() {
+		var blockedID, blockerID string
+		if err := rows.Scan(&blockedID, &blockerID); err != nil {
+			return nil, fmt.Errorf("failed to scan task dependency: %w", err)
+		}
+		blockers[blockedID] = append(blockers[blockedID], blockerID)
+	}
+	return blockers, rows.Err()
+}
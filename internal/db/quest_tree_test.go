@@ -0,0 +1,119 @@
+package db
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetQuestTaskTree(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := db.CreateQuest(project.ID, QuestModelSonnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := db.GetQuestTaskTree(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.TaskCount != 0 || len(tree.Tasks) != 0 {
+		t.Fatalf("tree = %+v, want no tasks", tree)
+	}
+
+	create := func(title, parentID string) *Task {
+		t.Helper()
+		task, err := db.CreateTaskForQuest(quest.ID, project.ID, title, "", "creator", TaskTypeTask, TaskModelSonnet, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parentID != "" {
+			if _, err := db.Exec(`UPDATE tasks SET parent_id = ? WHERE id = ?`, parentID, task.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return task
+	}
+	spend := func(task *Task, input, output int) {
+		t.Helper()
+		sess, err := db.CreateSession(task.ID, "creator", "/tmp/wt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.CreateSessionActivity(sess.ID, 1, ActivityTypeAssistantResponse, "creator", "", &input, &output); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	epic := create("Epic", "")
+	api := create("API", epic.ID)
+	ui := create("UI", epic.ID)
+	docs := create("Docs", "")
+	if err := db.AddTaskDependency(api.ID, ui.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskPRNumber(api.ID, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskStatus(api.ID, TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	spend(epic, 1_000_000, 0) // $3 at the default rates
+	spend(api, 0, 100_000)    // $1.50
+	spend(api, 0, 100_000)    // $1.50
+	spend(ui, 1_000_000, 0)   // $3
+
+	tree, err = db.GetQuestTaskTree(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.TaskCount != 4 || len(tree.Tasks) != 2 || tree.Tasks[0].ID != epic.ID || tree.Tasks[1].ID != docs.ID {
+		t.Fatalf("roots = %+v, want the epic then docs", tree.Tasks)
+	}
+	if math.Abs(tree.DollarsUsed-9) > 1e-9 {
+		t.Errorf("quest dollars = %v, want 9", tree.DollarsUsed)
+	}
+	if tree.StatusCounts[TaskStatusCompleted] != 1 {
+		t.Errorf("status counts = %v, want one completed", tree.StatusCounts)
+	}
+
+	root := tree.Tasks[0]
+	if len(root.Children) != 2 || root.Children[0].ID != api.ID || root.Children[1].ID != ui.ID {
+		t.Fatalf("epic children = %+v, want API then UI", root.Children)
+	}
+	if math.Abs(root.DollarsUsed-3) > 1e-9 || math.Abs(root.TreeDollarsUsed-9) > 1e-9 {
+		t.Errorf("epic dollars = %v own, %v tree, want 3 and 9", root.DollarsUsed, root.TreeDollarsUsed)
+	}
+	apiNode, uiNode := root.Children[0], root.Children[1]
+	if apiNode.PRNumber != 42 || math.Abs(apiNode.DollarsUsed-3) > 1e-9 {
+		t.Errorf("API node = %+v, want PR 42 and $3 across both sessions", apiNode)
+	}
+	if len(uiNode.BlockedBy) != 1 || uiNode.BlockedBy[0] != api.ID {
+		t.Errorf("UI blocked by %v, want the API task", uiNode.BlockedBy)
+	}
+
+	// A parent loop can't hide tasks
+	if _, err := db.Exec(`UPDATE tasks SET parent_id = ? WHERE id = ?`, api.ID, epic.ID); err != nil {
+		t.Fatal(err)
+	}
+	tree, err = db.GetQuestTaskTree(quest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	var walk func(nodes []*QuestTaskNode)
+	walk = func(nodes []*QuestTaskNode) {
+		for _, n := range nodes {
+			count++
+			walk(n.Children)
+		}
+	}
+	walk(tree.Tasks)
+	if count != 4 {
+		t.Errorf("tree holds %d tasks after a parent loop, want 4", count)
+	}
+}
@@ -0,0 +1,59 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetProjectMaxConcurrentTasks returns how many of the project's tasks may run
+// at once against its repo, or 0 if the project doesn't set a limit
+func (db *DB) GetProjectMaxConcurrentTasks(projectID string) (int, error) {
+	var limit sql.NullInt64
+	err := db.QueryRow(`SELECT max_concurrent_tasks FROM projects WHERE id = ?`, projectID).Scan(&limit)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get project max concurrent tasks: %w", err)
+	}
+	return int(limit.Int64), nil
+}
+
+// SetProjectMaxConcurrentTasks sets how many of the project's tasks may run at
+// once against its repo (0 clears it, falling back to the server default)
+func (db *DB) SetProjectMaxConcurrentTasks(projectID string, limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("max concurrent tasks must not be negative")
+	}
+
+	value := sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+	result, err := db.Exec(`UPDATE projects SET max_concurrent_tasks = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project max concurrent tasks: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// CountRunningTasksInRepo returns how many tasks are running against the
+// project's repo, counting tasks in other projects that share its repo path
+func (db *DB) CountRunningTasksInRepo(projectID string) (int, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM tasks t
+		JOIN projects p ON p.id = t.project_id
+		WHERE t.status = ?
+		  AND (t.project_id = ?
+		       OR (p.repo_path != '' AND p.repo_path = (SELECT repo_path FROM projects WHERE id = ?)))
+	`, TaskStatusRunning, projectID, projectID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count running tasks in repo: %w", err)
+	}
+	return count, nil
+}
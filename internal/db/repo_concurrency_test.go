@@ -0,0 +1,81 @@
+package db
+
+import "testing"
+
+func TestCountRunningTasksInRepo(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/repos/shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sibling, err := db.CreateProject("Sibling", "/repos/shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := db.CreateProject("Other", "/repos/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(projectID string) {
+		t.Helper()
+		task, err := db.CreateTask(projectID, "Task", TaskTypeTask, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.UpdateTaskStatus(task.ID, TaskStatusRunning); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run(project.ID)
+	run(sibling.ID)
+	run(other.ID)
+	if _, err := db.CreateTask(project.ID, "Pending", TaskTypeTask, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tasks in projects sharing the repo count; pending tasks and other repos don't
+	count, err := db.CountRunningTasksInRepo(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("running in shared repo = %d, want 2", count)
+	}
+	if count, _ = db.CountRunningTasksInRepo(other.ID); count != 1 {
+		t.Errorf("running in other repo = %d, want 1", count)
+	}
+}
+
+func TestProjectMaxConcurrentTasks(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit, err := db.GetProjectMaxConcurrentTasks(project.ID); err != nil || limit != 0 {
+		t.Fatalf("unset limit = %d, %v; want 0", limit, err)
+	}
+	if err := db.SetProjectMaxConcurrentTasks(project.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if limit, _ := db.GetProjectMaxConcurrentTasks(project.ID); limit != 2 {
+		t.Errorf("limit = %d, want 2", limit)
+	}
+	if err := db.SetProjectMaxConcurrentTasks(project.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if limit, _ := db.GetProjectMaxConcurrentTasks(project.ID); limit != 0 {
+		t.Errorf("limit = %d after clearing, want 0", limit)
+	}
+
+	if err := db.SetProjectMaxConcurrentTasks(project.ID, -1); err == nil {
+		t.Error("expected negative limit to be rejected")
+	}
+	if err := db.SetProjectMaxConcurrentTasks("proj-missing", 1); err == nil {
+		t.Error("expected missing project to be an error")
+	}
+}
@@ -0,0 +1,260 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Search result kinds
+const (
+	SearchKindTask         = "task"
+	SearchKindQuestMessage = "quest_message"
+	SearchKindMemory       = "memory"
+)
+
+// DefaultSearchLimit is how many results Search returns when no limit is given
+const DefaultSearchLimit = 20
+
+// MaxSearchLimit caps the number of results Search returns
+const MaxSearchLimit = 100
+
+// SearchResult is a single match from Search
+type SearchResult struct {
+	Kind      string  `json:"kind"`
+	ID        string  `json:"id"`
+	ParentID  string  `json:"parent_id,omitempty"` // Quest ID for quest messages
+	ProjectID string  `json:"project_id"`
+	Title     string  `json:"title"`
+	Snippet   string  `json:"snippet"`
+	Score     float64 `json:"score"` // Higher is more relevant
+}
+
+// migrationSearchIndex creates a full-text index over task titles and
+// descriptions, quest messages, and memories, kept in sync by triggers.
+// The index holds its own copy of the text rather than pointing at the source
+// tables, because their rowids aren't stable.
+const migrationSearchIndex = `
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+	kind UNINDEXED,
+	ref_id UNINDEXED,
+	parent_id UNINDEXED,
+	project_id UNINDEXED,
+	title,
+	body,
+	tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS search_tasks_ai AFTER INSERT ON tasks BEGIN
+	INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+	VALUES ('task', new.id, '', new.project_id, new.title, COALESCE(new.description, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_tasks_au AFTER UPDATE OF title, description, project_id ON tasks BEGIN
+	DELETE FROM search_index WHERE kind = 'task' AND ref_id = old.id;
+	INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+	VALUES ('task', new.id, '', new.project_id, new.title, COALESCE(new.description, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_tasks_ad AFTER DELETE ON tasks BEGIN
+	DELETE FROM search_index WHERE kind = 'task' AND ref_id = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_quest_messages_ai AFTER INSERT ON quest_messages BEGIN
+	INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+	VALUES ('quest_message', new.id, new.quest_id,
+	        COALESCE((SELECT project_id FROM quests WHERE id = new.quest_id), ''), '', new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_quest_messages_ad AFTER DELETE ON quest_messages BEGIN
+	DELETE FROM search_index WHERE kind = 'quest_message' AND ref_id = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_memories_ai AFTER INSERT ON memories BEGIN
+	INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+	VALUES ('memory', new.id, '', new.project_id, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_memories_au AFTER UPDATE OF title, content, project_id ON memories BEGIN
+	DELETE FROM search_index WHERE kind = 'memory' AND ref_id = old.id;
+	INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+	VALUES ('memory', new.id, '', new.project_id, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_memories_ad AFTER DELETE ON memories BEGIN
+	DELETE FROM search_index WHERE kind = 'memory' AND ref_id = old.id;
+END;
+`
+
+// searchIndexBackfill indexes rows that existed before the index was created
+const searchIndexBackfill = `
+INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+SELECT 'task', id, '', project_id, title, COALESCE(description, '') FROM tasks;
+
+INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+SELECT 'quest_message', m.id, m.quest_id, COALESCE(q.project_id, ''), '', m.content
+FROM quest_messages m LEFT JOIN quests q ON q.id = m.quest_id;
+
+INSERT INTO search_index (kind, ref_id, parent_id, project_id, title, body)
+SELECT 'memory', id, '', project_id, title, content FROM memories;
+`
+
+// migrateSearchIndex creates the search index, backfilling it the first time
+func (db *DB) migrateSearchIndex() error {
+	var exists int
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'search_index'`,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check search index: %w", err)
+	}
+
+	if _, err := db.Exec(migrationSearchIndex); err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	if _, err := db.Exec(searchIndexBackfill); err != nil {
+		return fmt.Errorf("failed to backfill search index: %w", err)
+	}
+	return nil
+}
+
+// SearchOptions narrows a search
+type SearchOptions struct {
+	Kinds     []string // Empty searches every kind
+	ProjectID string   // Empty searches every project
+	Limit     int      // 0 uses DefaultSearchLimit
+}
+
+// Search finds tasks, quest messages, and memories matching every word of
+// query, best matches first. The last word also matches as a prefix, so
+// results show up while a word is still being typed. Titles count more than
+// bodies.
+func (db *DB) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	match := searchMatchExpression(query)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	where := `search_index MATCH ?`
+	args := []any{match}
+	if len(opts.Kinds) > 0 {
+		placeholders := make([]string, len(opts.Kinds))
+		for i, kind := range opts.Kinds {
+			placeholders[i] = "?"
+			args = append(args, kind)
+		}
+		where += ` AND search_index.kind IN (` + strings.Join(placeholders, ", ") + `)`
+	}
+	if opts.ProjectID != "" {
+		where += ` AND search_index.project_id = ?`
+		args = append(args, opts.ProjectID)
+	}
+	args = append(args, limit)
+
+	// bm25 weights follow column order: kind, ref_id, parent_id, project_id, title, body
+	rows, err := db.Query(
+		`SELECT search_index.kind, ref_id, parent_id, search_index.project_id,
+		        CASE WHEN search_index.kind = 'quest_message' THEN COALESCE(q.title, '') ELSE search_index.title END,
+		        snippet(search_index, -1, '', '', '…', 16),
+		        bm25(search_index, 0, 0, 0, 0, 5.0, 1.0) AS rank
+		 FROM search_index
+		 LEFT JOIN quests q ON search_index.kind = 'quest_message' AND q.id = search_index.parent_id
+		 WHERE `+where+`
+		 ORDER BY rank
+		 LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		var rank float64
+		if err := rows.Scan(&r.Kind, &r.ID, &r.ParentID, &r.ProjectID, &r.Title, &r.Snippet, &rank); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		r.Score = -rank // bm25 is lower for better matches
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// searchMatchExpression turns free text into an FTS5 query that ANDs its words.
+// Each word is quoted so punctuation (e.g. "customer-123") isn't read as query
+// syntax, and the last word matches as a prefix.
+func searchMatchExpression(query string) string {
+	words := strings.Fields(query)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`"`)
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
+
+// SimilarOpenTasks finds the project's open tasks sharing any word with text
+// in their title or description, best matches first. It narrows the candidates
+// for duplicate detection; callers decide which are similar enough.
+func (db *DB) SimilarOpenTasks(projectID, text string, limit int) ([]*Task, error) {
+	match := anyWordMatchExpression(text)
+	if match == "" {
+		return []*Task{}, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	return db.listTasks(
+		`JOIN (SELECT ref_id, bm25(search_index, 0, 0, 0, 0, 5.0, 1.0) AS rank
+		       FROM search_index
+		       WHERE search_index MATCH ? AND kind = 'task' AND project_id = ?
+		       ORDER BY rank
+		       LIMIT ?) matches ON matches.ref_id = tasks.id
+		 WHERE tasks.status NOT IN (?, ?, ?, ?, 'failed')
+		 ORDER BY matches.rank`,
+		match, projectID, limit,
+		TaskStatusCompleted, TaskStatusCompletedWithIssues, TaskStatusCancelled, TaskStatusTimedOut,
+	)
+}
+
+// anyWordMatchExpression turns free text into an FTS5 query that ORs its
+// distinct words, quoted like searchMatchExpression's
+func anyWordMatchExpression(text string) string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, `"`+word+`"`)
+	}
+	return strings.Join(terms, " OR ")
+}
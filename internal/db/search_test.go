@@ -0,0 +1,189 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := db.CreateProject("Other", "/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := db.CreateTask(project.ID, "Migrate billing to Stripe", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTask(other.ID, "Fix billing export for customer-123", TaskTypeTask, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	quest, err := db.CreateQuest(project.ID, "sonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateQuestTitle(quest.ID, "Payments planning"); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := db.CreateQuestMessage(quest.ID, "user", "We should retry failed billing webhooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memory := &Memory{
+		ID:        NewPrefixedID("mem"),
+		ProjectID: project.ID,
+		Type:      MemoryArchitecture,
+		Title:     "Webhook handlers",
+		Content:   "Billing webhooks are handled in internal/payments",
+		Source:    SourceManual,
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateMemory(memory); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := func(results []SearchResult) map[string]SearchResult {
+		byKind := make(map[string]SearchResult)
+		for _, r := range results {
+			byKind[r.Kind] = r
+		}
+		return byKind
+	}
+
+	// Every kind, every project
+	results, err := db.Search("billing", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4: %+v", len(results), results)
+	}
+
+	// Project filter, and quest messages carry their quest
+	results, err = db.Search("billing", SearchOptions{ProjectID: project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byKind := kinds(results)
+	if len(results) != 3 || len(byKind) != 3 {
+		t.Fatalf("got %+v, want one task, quest message, and memory", results)
+	}
+	if r := byKind[SearchKindTask]; r.ID != task.ID {
+		t.Errorf("task result = %+v, want %s", r, task.ID)
+	}
+	if r := byKind[SearchKindQuestMessage]; r.ID != msg.ID || r.ParentID != quest.ID || r.Title != "Payments planning" {
+		t.Errorf("quest message result = %+v", r)
+	}
+	if r := byKind[SearchKindMemory]; r.ID != memory.ID || r.Snippet == "" {
+		t.Errorf("memory result = %+v", r)
+	}
+
+	// Kind filter
+	results, err = db.Search("billing", SearchOptions{Kinds: []string{SearchKindMemory}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Kind != SearchKindMemory {
+		t.Errorf("got %+v, want only the memory", results)
+	}
+
+	// The last word matches as a prefix; every word must match
+	results, err = db.Search("stripe bill", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != task.ID {
+		t.Errorf("got %+v, want only the Stripe task", results)
+	}
+
+	// Punctuation isn't query syntax
+	results, err = db.Search(`customer-123 "export`, SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ProjectID != other.ID {
+		t.Errorf("got %+v, want the customer-123 task", results)
+	}
+
+	// Title matches rank above body matches
+	results, err = db.Search("webhook", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Kind != SearchKindMemory {
+		t.Errorf("got %+v, want the memory titled Webhook handlers first", results)
+	}
+
+	// Updates and deletes keep the index in sync
+	if _, err := db.Exec(`UPDATE tasks SET title = ? WHERE id = ?`, "Migrate invoicing to Stripe", task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteMemory(memory.ID); err != nil {
+		t.Fatal(err)
+	}
+	results, err = db.Search("billing", SearchOptions{ProjectID: project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Kind != SearchKindQuestMessage {
+		t.Errorf("got %+v, want only the quest message after update and delete", results)
+	}
+	if results, _ = db.Search("invoicing", SearchOptions{}); len(results) != 1 {
+		t.Errorf("got %+v, want the renamed task", results)
+	}
+
+	if results, _ = db.Search("   ", SearchOptions{}); len(results) != 0 {
+		t.Errorf("got %+v, want nothing for a blank query", results)
+	}
+}
+
+func TestSimilarOpenTasks(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := db.CreateProject("Other", "/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	open, err := db.CreateTask(project.ID, "Add dark mode to settings", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := db.CreateTask(project.ID, "Add dark mode toggle", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskStatus(done.ID, TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTask(project.ID, "Fix login redirect", TaskTypeTask, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTask(other.ID, "Dark mode for the docs site", TaskTypeTask, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := db.SimilarOpenTasks(project.ID, "Dark-mode support", 10)
+	if err != nil {
+		t.Fatalf("SimilarOpenTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != open.ID {
+		t.Errorf("SimilarOpenTasks() = %d tasks, want only the open one in the project", len(tasks))
+	}
+
+	if tasks, err := db.SimilarOpenTasks(project.ID, " -- ", 10); err != nil || len(tasks) != 0 {
+		t.Errorf("SimilarOpenTasks(no words) = %v, %v", tasks, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import "strings"
+
+// SessionHistoryFilter narrows ListSessionHistory
+type SessionHistoryFilter struct {
+	TaskID  string   // "" for sessions of every task
+	Reasons []string // Termination reasons to include (empty for all)
+	Limit   int      // Most sessions to return (0 for no limit)
+}
+
+// ListSessionHistory returns sessions that have ended, with why they ended,
+// newest first
+func (db *DB) ListSessionHistory(filter SessionHistoryFilter) ([]*Session, error) {
+	where := []string{"termination_reason IS NOT NULL", "termination_reason != ''"}
+	var args []any
+	if filter.TaskID != "" {
+		where = append(where, "task_id = ?")
+		args = append(args, filter.TaskID)
+	}
+	if len(filter.Reasons) > 0 {
+		where = append(where, "termination_reason IN (?"+strings.Repeat(", ?", len(filter.Reasons)-1)+")")
+		for _, reason := range filter.Reasons {
+			args = append(args, reason)
+		}
+	}
+
+	clause := "WHERE " + strings.Join(where, " AND ") + " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	return db.listSessions(clause, args...)
+}
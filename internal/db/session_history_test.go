@@ -0,0 +1,75 @@
+package db
+
+import "testing"
+
+func TestListSessionHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	build, err := db.CreateTask(project.ID, "Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := db.CreateTask(project.ID, "Docs", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := func(taskID, reason string) *Session {
+		t.Helper()
+		sess, err := db.CreateSession(taskID, "creator", "/tmp/wt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason != "" {
+			if err := db.UpdateSessionTermination(sess.ID, reason, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return sess
+	}
+	end(build.ID, "max_tokens")
+	end(build.ID, "completed")
+	end(docs.ID, "error")
+	end(docs.ID, "") // Still running
+
+	all, err := db.ListSessionHistory(SessionHistoryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("history has %d sessions, want the 3 that ended", len(all))
+	}
+	for _, sess := range all {
+		if !sess.TerminationReason.Valid || sess.TerminationReason.String == "" {
+			t.Errorf("session %s has no termination reason", sess.ID)
+		}
+	}
+
+	byTask, err := db.ListSessionHistory(SessionHistoryFilter{TaskID: build.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byTask) != 2 {
+		t.Errorf("task history has %d sessions, want 2", len(byTask))
+	}
+
+	failed, err := db.ListSessionHistory(SessionHistoryFilter{Reasons: []string{"max_tokens", "error"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 2 {
+		t.Errorf("filtered history has %d sessions, want 2", len(failed))
+	}
+
+	limited, err := db.ListSessionHistory(SessionHistoryFilter{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 1 {
+		t.Errorf("limited history has %d sessions, want 1", len(limited))
+	}
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Session priorities decide whose API requests go first when sessions queue
+// behind the shared request gate
+const (
+	SessionPriorityLow    = "low"    // Batch and automatically started work
+	SessionPriorityNormal = "normal" // Default
+	SessionPriorityHigh   = "high"   // Interactive sessions someone is watching
+)
+
+// ValidateSessionPriority checks that priority is low, normal, or high
+func ValidateSessionPriority(priority string) error {
+	switch priority {
+	case SessionPriorityLow, SessionPriorityNormal, SessionPriorityHigh:
+		return nil
+	default:
+		return fmt.Errorf("invalid session priority %q: must be low, normal, or high", priority)
+	}
+}
+
+// GetSessionPriority returns the session's priority, normal if none was set
+func (db *DB) GetSessionPriority(sessionID string) (string, error) {
+	var priority sql.NullString
+	err := db.QueryRow(`SELECT priority FROM sessions WHERE id = ?`, sessionID).Scan(&priority)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get session priority: %w", err)
+	}
+	if !priority.Valid || priority.String == "" {
+		return SessionPriorityNormal, nil
+	}
+	return priority.String, nil
+}
+
+// SetSessionPriority sets the session's priority
+func (db *DB) SetSessionPriority(sessionID, priority string) error {
+	if err := ValidateSessionPriority(priority); err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE sessions SET priority = ? WHERE id = ?`, priority, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update session priority: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	return nil
+}
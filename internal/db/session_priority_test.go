@@ -0,0 +1,49 @@
+package db
+
+import "testing"
+
+func TestSessionPriority(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Task", TaskTypeTask, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := db.CreateSession(task.ID, "creator", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priority, err := db.GetSessionPriority(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priority != SessionPriorityNormal {
+		t.Errorf("new session priority = %q, want normal", priority)
+	}
+
+	if err := db.SetSessionPriority(session.ID, SessionPriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	priority, err = db.GetSessionPriority(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priority != SessionPriorityHigh {
+		t.Errorf("priority = %q, want high", priority)
+	}
+
+	if err := db.SetSessionPriority(session.ID, "urgent"); err == nil {
+		t.Error("set an invalid priority")
+	}
+	if err := db.SetSessionPriority("missing", SessionPriorityLow); err == nil {
+		t.Error("set the priority of a missing session")
+	}
+	if _, err := db.GetSessionPriority("missing"); err == nil {
+		t.Error("got the priority of a missing session")
+	}
+}
@@ -321,6 +321,44 @@ func (db *DB) GetLatestSessionCheckpoint(sessionID string) (*SessionCheckpoint,
 	return checkpoint, nil
 }
 
+// GetSessionCheckpoint retrieves a session's checkpoint by ID
+func (db *DB) GetSessionCheckpoint(sessionID, id string) (*SessionCheckpoint, error) {
+	return db.getSessionCheckpoint(
+		`SELECT id, session_id, iteration, state, created_at
+		 FROM session_checkpoints WHERE session_id = ? AND id = ?`,
+		sessionID, id,
+	)
+}
+
+// GetSessionCheckpointByIteration retrieves the latest checkpoint a session saved at an iteration
+func (db *DB) GetSessionCheckpointByIteration(sessionID string, iteration int) (*SessionCheckpoint, error) {
+	return db.getSessionCheckpoint(
+		`SELECT id, session_id, iteration, state, created_at
+		 FROM session_checkpoints WHERE session_id = ? AND iteration = ?
+		 ORDER BY created_at DESC LIMIT 1`,
+		sessionID, iteration,
+	)
+}
+
+// getSessionCheckpoint runs a single-checkpoint query, returning nil if there is no match
+func (db *DB) getSessionCheckpoint(query string, args ...any) (*SessionCheckpoint, error) {
+	checkpoint := &SessionCheckpoint{}
+	var stateJSON string
+
+	err := db.QueryRow(query, args...).Scan(
+		&checkpoint.ID, &checkpoint.SessionID, &checkpoint.Iteration, &stateJSON, &checkpoint.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint: %w", err)
+	}
+
+	checkpoint.State = json.RawMessage(stateJSON)
+	return checkpoint, nil
+}
+
 // ListSessionCheckpoints returns all checkpoints for a session
 func (db *DB) ListSessionCheckpoints(sessionID string) ([]*SessionCheckpoint, error) {
 	rows, err := db.Query(
@@ -50,6 +50,8 @@ func (db *DB) Migrate() error {
 		migrationProjects,
 		migrationTasks,
 		migrationTaskDependencies,
+		migrationTaskTags,
+		migrationTaskAttachments,
 		migrationSessions,
 		migrationSessionCheckpoints,
 		migrationApprovals,
@@ -61,12 +63,17 @@ func (db *DB) Migrate() error {
 		migrationQuests,
 		migrationQuestMessages,
 		migrationQuestTemplates,
+		migrationObjectiveTemplates,
 		migrationGitHubApp,
 		migrationOnboardingProgress,
 		migrationSecrets,
 		migrationMemories,
 		migrationEvents,
+		migrationAuditLog,
+		migrationProjectGitCredentials,
 		migrationWorkers,
+		migrationDispatchQueue,
+		migrationToolbeltChecks,
 		migrationForgejoConfig,
 		migrationMeshOnboardingStatus,
 		migrationDexProfile,
@@ -131,11 +138,86 @@ func (db *DB) Migrate() error {
 		"ALTER TABLE webauthn_credentials ADD COLUMN location TEXT DEFAULT ''",
 		"ALTER TABLE webauthn_credentials ADD COLUMN last_used_at DATETIME",
 		"ALTER TABLE webauthn_credentials ADD COLUMN last_used_ip TEXT",
+		// Completion verification strictness (task overrides project, default strict)
+		"ALTER TABLE projects ADD COLUMN completion_strictness TEXT",
+		"ALTER TABLE projects ADD COLUMN completion_min_done_ratio REAL",
+		"ALTER TABLE tasks ADD COLUMN completion_strictness TEXT",
+		"ALTER TABLE tasks ADD COLUMN completion_min_done_ratio REAL",
+		// Project defaults inherited by new tasks
+		"ALTER TABLE projects ADD COLUMN default_model TEXT",
+		"ALTER TABLE projects ADD COLUMN default_autonomy INTEGER",
+		// Activity recording level (task overrides project, then the server default)
+		"ALTER TABLE projects ADD COLUMN activity_level TEXT",
+		"ALTER TABLE tasks ADD COLUMN activity_level TEXT",
+		// Project caps on how far a resume can raise session budgets
+		"ALTER TABLE projects ADD COLUMN budget_cap_iterations INTEGER",
+		"ALTER TABLE projects ADD COLUMN budget_cap_tokens INTEGER",
+		"ALTER TABLE projects ADD COLUMN budget_cap_dollars REAL",
+		// Why a task's session reported being blocked (cleared when resolved)
+		"ALTER TABLE tasks ADD COLUMN blocked_reason TEXT",
+		"ALTER TABLE tasks ADD COLUMN blocked_detail TEXT",
+		"ALTER TABLE tasks ADD COLUMN blocked_at DATETIME",
+		// Lineage of tasks cloned for a re-run or variant
+		"ALTER TABLE tasks ADD COLUMN cloned_from TEXT REFERENCES tasks(id) ON DELETE SET NULL",
+		// Model new quests start on when the request doesn't pick one
+		"ALTER TABLE projects ADD COLUMN default_quest_model TEXT",
+		// Limit on tasks running at once against the project's repo
+		"ALTER TABLE projects ADD COLUMN max_concurrent_tasks INTEGER",
+		// Read-only tool results each session may cache (opt-in)
+		"ALTER TABLE projects ADD COLUMN tool_cache_size INTEGER",
+		// Reject review approvals until the session has run the tests
+		"ALTER TABLE projects ADD COLUMN critic_requires_tests INTEGER DEFAULT 0",
+		// Guidance prepended to every hat's system prompt in the project
+		"ALTER TABLE projects ADD COLUMN prompt_preamble TEXT",
+		// Extended thinking budget (NULL uses the hat defaults, 0 turns it off)
+		"ALTER TABLE tasks ADD COLUMN thinking_budget INTEGER",
+		// Output tokens spent on extended thinking (included in tokens_output)
+		"ALTER TABLE session_activity ADD COLUMN tokens_thinking INTEGER",
+		// Predecessor whose worktree and branch a task continues in instead of its own
+		"ALTER TABLE tasks ADD COLUMN reuse_worktree_from TEXT REFERENCES tasks(id) ON DELETE SET NULL",
+		// Complete a quest once every task it spawned is done (opt-in)
+		"ALTER TABLE quests ADD COLUMN auto_complete_on_tasks_done INTEGER DEFAULT 0",
+		// How a quest's auto-started objectives start: how many at once, and whether blocked ones wait
+		"ALTER TABLE quests ADD COLUMN max_concurrent_objectives INTEGER",
+		"ALTER TABLE quests ADD COLUMN objective_start_mode TEXT",
+		// Where a session's API requests queue behind the shared request gate (NULL is normal)
+		"ALTER TABLE sessions ADD COLUMN priority TEXT",
+		// How aggressively untrusted content is neutralized (NULL inherits)
+		"ALTER TABLE projects ADD COLUMN prompt_sensitivity TEXT",
+		"ALTER TABLE tasks ADD COLUMN prompt_sensitivity TEXT",
+		// Cached natural-language summary for the completion report, and the session it covers
+		"ALTER TABLE tasks ADD COLUMN report_summary TEXT",
+		"ALTER TABLE tasks ADD COLUMN report_summary_session TEXT",
+		// Which hat a failed task restarts at instead of pausing (JSON, NULL pauses)
+		"ALTER TABLE projects ADD COLUMN pipeline_retry TEXT",
+		// Identity sessions commit as in the project's worktrees (NULL leaves git's default)
+		"ALTER TABLE projects ADD COLUMN git_author_name TEXT",
+		"ALTER TABLE projects ADD COLUMN git_author_email TEXT",
+		// Summary of quest messages older than the history window, and how many it covers
+		"ALTER TABLE quests ADD COLUMN history_summary TEXT",
+		"ALTER TABLE quests ADD COLUMN history_summary_through INTEGER DEFAULT 0",
+		// Model usage behind each quest assistant message
+		"ALTER TABLE quest_messages ADD COLUMN input_tokens INTEGER DEFAULT 0",
+		"ALTER TABLE quest_messages ADD COLUMN output_tokens INTEGER DEFAULT 0",
+		"ALTER TABLE quest_messages ADD COLUMN dollars_used REAL DEFAULT 0",
+		// Hat new task sessions start in, or "auto" to pick by heuristic (NULL is auto)
+		"ALTER TABLE projects ADD COLUMN starting_hat TEXT",
+		// Models to fall back to when the task's model is over capacity (task overrides project, opt-in)
+		"ALTER TABLE projects ADD COLUMN model_fallback TEXT",
+		"ALTER TABLE tasks ADD COLUMN model_fallback TEXT",
+		// Cached pre-execution explanation of a task's approach, and the prompt it explains
+		"ALTER TABLE tasks ADD COLUMN explanation TEXT",
+		"ALTER TABLE tasks ADD COLUMN explanation_key TEXT",
 	}
 	for _, migration := range optionalMigrations {
 		_, _ = db.Exec(migration) // Ignore errors - column may already exist
 	}
 
+	// Full-text search index (after the tables it indexes and their columns exist)
+	if err := db.migrateSearchIndex(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -231,6 +313,30 @@ CREATE INDEX IF NOT EXISTS idx_task_deps_blocker ON task_dependencies(blocker_id
 CREATE INDEX IF NOT EXISTS idx_task_deps_blocked ON task_dependencies(blocked_id);
 `
 
+const migrationTaskTags = `
+CREATE TABLE IF NOT EXISTS task_tags (
+	task_id TEXT NOT NULL REFERENCES tasks(id),
+	tag TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (task_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag ON task_tags(tag);
+`
+
+const migrationTaskAttachments = `
+CREATE TABLE IF NOT EXISTS task_attachments (
+	id TEXT PRIMARY KEY,
+	task_id TEXT NOT NULL REFERENCES tasks(id),
+	name TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	content BLOB NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (task_id, name)
+);
+`
+
 const migrationSessions = `
 CREATE TABLE IF NOT EXISTS sessions (
 	id TEXT PRIMARY KEY,
@@ -407,6 +513,22 @@ CREATE TABLE IF NOT EXISTS quest_templates (
 CREATE INDEX IF NOT EXISTS idx_quest_templates_project ON quest_templates(project_id);
 `
 
+const migrationObjectiveTemplates = `
+CREATE TABLE IF NOT EXISTS objective_templates (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT,
+	hat TEXT,
+	checklist TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_objective_templates_project ON objective_templates(project_id);
+`
+
 const migrationGitHubApp = `
 -- GitHub App configuration (singleton - only one row)
 CREATE TABLE IF NOT EXISTS github_app_config (
@@ -526,6 +648,21 @@ CREATE INDEX IF NOT EXISTS idx_events_session ON events(session_id);
 CREATE INDEX IF NOT EXISTS idx_events_topic ON events(topic);
 `
 
+const migrationAuditLog = `
+-- Record of operator actions that change what the system is allowed to do
+CREATE TABLE IF NOT EXISTS audit_log (
+	id TEXT PRIMARY KEY,
+	actor TEXT NOT NULL,        -- User ID, or "system"
+	action TEXT NOT NULL,       -- e.g. "task.budget_top_up"
+	entity_type TEXT NOT NULL,  -- e.g. "task"
+	entity_id TEXT NOT NULL,
+	details TEXT,               -- JSON
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+`
+
 const migrationForgejoConfig = `
 -- Forgejo instance configuration (singleton - only one row)
 CREATE TABLE IF NOT EXISTS forgejo_config (
@@ -0,0 +1,62 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+)
+
+// StartingHatAuto lets a heuristic pick each task's starting hat
+const StartingHatAuto = "auto"
+
+// StartingHats are the hats a task's first session can start in
+var StartingHats = []string{"explorer", "planner", "designer", "creator"}
+
+// ValidateStartingHat checks that hat is one of StartingHats or StartingHatAuto
+func ValidateStartingHat(hat string) error {
+	if hat == StartingHatAuto || slices.Contains(StartingHats, hat) {
+		return nil
+	}
+	return fmt.Errorf("invalid starting hat %q (must be auto, explorer, planner, designer, or creator)", hat)
+}
+
+// GetProjectStartingHat returns the hat the project's tasks start in, or
+// StartingHatAuto if not set
+func (db *DB) GetProjectStartingHat(projectID string) (string, error) {
+	var hat sql.NullString
+	err := db.QueryRow(`SELECT starting_hat FROM projects WHERE id = ?`, projectID).Scan(&hat)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project starting hat: %w", err)
+	}
+	if !hat.Valid || hat.String == "" {
+		return StartingHatAuto, nil
+	}
+	return hat.String, nil
+}
+
+// SetProjectStartingHat sets the hat the project's tasks start in ("" or
+// StartingHatAuto picks one per task)
+func (db *DB) SetProjectStartingHat(projectID, hat string) error {
+	value := sql.NullString{String: hat, Valid: hat != "" && hat != StartingHatAuto}
+	if value.Valid {
+		if err := ValidateStartingHat(hat); err != nil {
+			return err
+		}
+	}
+
+	result, err := db.Exec(`UPDATE projects SET starting_hat = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project starting hat: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
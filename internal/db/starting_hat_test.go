@@ -0,0 +1,36 @@
+package db
+
+import "testing"
+
+func TestProjectStartingHat(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing configured: auto
+	if hat, err := db.GetProjectStartingHat(project.ID); err != nil || hat != StartingHatAuto {
+		t.Errorf("default starting hat = %q, %v; want auto", hat, err)
+	}
+
+	if err := db.SetProjectStartingHat(project.ID, "explorer"); err != nil {
+		t.Fatal(err)
+	}
+	if hat, _ := db.GetProjectStartingHat(project.ID); hat != "explorer" {
+		t.Errorf("starting hat = %q, want explorer", hat)
+	}
+
+	if err := db.SetProjectStartingHat(project.ID, "critic"); err == nil {
+		t.Error("expected error for a hat tasks can't start in")
+	}
+
+	// Clearing goes back to auto
+	if err := db.SetProjectStartingHat(project.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if hat, _ := db.GetProjectStartingHat(project.ID); hat != StartingHatAuto {
+		t.Errorf("starting hat = %q, want auto after clearing", hat)
+	}
+}
@@ -0,0 +1,157 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaxAttachmentSize is the largest file that can be attached to a task
+const MaxAttachmentSize = 5 << 20
+
+// TaskAttachment is reference material (a spec, a log, a sample config) given
+// to a task's sessions. Content is only loaded by GetTaskAttachment.
+type TaskAttachment struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Content     []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NormalizeAttachmentName reduces an uploaded file name to a plain file name
+// that's safe to write into a worktree, returning an error if nothing is left
+func NormalizeAttachmentName(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" || name == ".." || name == "" {
+		return "", fmt.Errorf("attachment name must be a file name")
+	}
+	if len(name) > 255 {
+		return "", fmt.Errorf("attachment name is longer than 255 characters")
+	}
+	if name == ".gitignore" {
+		return "", fmt.Errorf("attachment name %s is reserved", name)
+	}
+	return name, nil
+}
+
+// SaveTaskAttachment attaches a file to a task, replacing any attachment with
+// the same name
+func (db *DB) SaveTaskAttachment(taskID, name, contentType string, content []byte) (*TaskAttachment, error) {
+	name, err := NormalizeAttachmentName(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > MaxAttachmentSize {
+		return nil, fmt.Errorf("attachment is larger than %d bytes", MaxAttachmentSize)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tasks WHERE id = ?`, taskID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check task: %w", err)
+	}
+	if exists == 0 {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO task_attachments (id, task_id, name, content_type, size, content, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (task_id, name) DO UPDATE SET
+			content_type = excluded.content_type,
+			size = excluded.size,
+			content = excluded.content,
+			created_at = excluded.created_at
+	`, NewPrefixedID("att"), taskID, name, contentType, len(content), content, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	return db.getTaskAttachment(`task_id = ? AND name = ?`, taskID, name)
+}
+
+// GetTaskAttachment returns a task's attachment with its content
+func (db *DB) GetTaskAttachment(taskID, attachmentID string) (*TaskAttachment, error) {
+	return db.getTaskAttachment(`task_id = ? AND id = ?`, taskID, attachmentID)
+}
+
+func (db *DB) getTaskAttachment(where string, args ...any) (*TaskAttachment, error) {
+	a := &TaskAttachment{}
+	err := db.QueryRow(`
+		SELECT id, task_id, name, content_type, size, content, created_at
+		FROM task_attachments WHERE `+where, args...,
+	).Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.Content, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return a, nil
+}
+
+// ListTaskAttachments returns a task's attachments by name, without content
+func (db *DB) ListTaskAttachments(taskID string) ([]*TaskAttachment, error) {
+	rows, err := db.Query(`
+		SELECT id, task_id, name, content_type, size, created_at
+		FROM task_attachments WHERE task_id = ? ORDER BY name
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	attachments := []*TaskAttachment{}
+	for rows.Next() {
+		a := &TaskAttachment{}
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// GetTaskAttachmentsWithContent returns a task's attachments by name, with content
+func (db *DB) GetTaskAttachmentsWithContent(taskID string) ([]*TaskAttachment, error) {
+	rows, err := db.Query(`
+		SELECT id, task_id, name, content_type, size, content, created_at
+		FROM task_attachments WHERE task_id = ? ORDER BY name
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var attachments []*TaskAttachment
+	for rows.Next() {
+		a := &TaskAttachment{}
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.Content, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// DeleteTaskAttachment removes an attachment from a task
+func (db *DB) DeleteTaskAttachment(taskID, attachmentID string) error {
+	result, err := db.Exec(`DELETE FROM task_attachments WHERE task_id = ? AND id = ?`, taskID, attachmentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("attachment not found on task %s: %s", taskID, attachmentID)
+	}
+	return nil
+}
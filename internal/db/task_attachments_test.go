@@ -0,0 +1,69 @@
+package db
+
+import "testing"
+
+func TestTaskAttachments(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("A", "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _ := db.CreateTask(project.ID, "Fix crash", TaskTypeTask, 3)
+
+	// Directories are stripped from uploaded names
+	first, err := db.SaveTaskAttachment(task.ID, "../logs/trace.txt", "text/plain", []byte("panic: boom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != "trace.txt" || first.Size != 11 {
+		t.Errorf("saved %q (%d bytes), want trace.txt (11 bytes)", first.Name, first.Size)
+	}
+
+	// Re-uploading a name replaces its content
+	if _, err := db.SaveTaskAttachment(task.ID, "trace.txt", "", []byte("panic: bang!")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SaveTaskAttachment(task.ID, "spec.md", "text/markdown", []byte("# Spec")); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := db.ListTaskAttachments(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "spec.md" || list[1].Name != "trace.txt" {
+		t.Fatalf("attachments = %v, want spec.md and trace.txt", list)
+	}
+	if list[1].Content != nil {
+		t.Error("list returned content")
+	}
+
+	got, err := db.GetTaskAttachment(task.ID, list[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Content) != "panic: bang!" || got.ContentType != "application/octet-stream" {
+		t.Errorf("content = %q (%s), want replaced content", got.Content, got.ContentType)
+	}
+
+	if _, err := db.SaveTaskAttachment("missing", "a.txt", "", []byte("x")); err == nil {
+		t.Error("expected error attaching to a missing task")
+	}
+	if _, err := db.SaveTaskAttachment(task.ID, "..", "", []byte("x")); err == nil {
+		t.Error("expected error for an invalid name")
+	}
+	if _, err := db.SaveTaskAttachment(task.ID, "docs/.gitignore", "", []byte("!*")); err == nil {
+		t.Error("expected error for a reserved name")
+	}
+
+	if err := db.DeleteTaskAttachment(task.ID, got.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteTaskAttachment(task.ID, got.ID); err == nil {
+		t.Error("expected error deleting a missing attachment")
+	}
+	if err := db.DeleteTask(task.ID); err != nil {
+		t.Fatalf("delete task with attachments: %v", err)
+	}
+}
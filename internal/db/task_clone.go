@@ -0,0 +1,130 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CloneTaskOptions edits the copy CloneTask makes
+type CloneTaskOptions struct {
+	Title       string  // "" keeps the original's title
+	Description *string // nil keeps the original's description
+}
+
+// CloneTask creates a pending copy of a task for a re-run or variant. The copy
+// keeps the original's description, type, model, priority, autonomy, budgets,
+// completion, activity, and prompt sensitivity settings, thinking budget, and
+// model fallback, and its checklist with every item reset to pending. It starts
+// with the hat the original's first session used, not the one the original
+// finished on. The worktree, branch, PR, and sessions aren't copied. The copy
+// records the original in cloned_from.
+func (db *DB) CloneTask(sourceID string, opts CloneTaskOptions) (*Task, error) {
+	source, err := db.GetTaskByID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("task not found: %s", sourceID)
+	}
+
+	title := source.Title
+	if opts.Title != "" {
+		title = opts.Title
+	}
+	description := source.Description
+	if opts.Description != nil {
+		description = sql.NullString{String: *opts.Description, Valid: *opts.Description != ""}
+	}
+
+	hat, err := db.getTaskStartingHat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	id := NewPrefixedID("task")
+	_, err = db.Exec(
+		`INSERT INTO tasks (id, project_id, title, description, type, hat, model, priority, autonomy_level,
+		                    status, base_branch, token_budget, time_budget_min, dollar_budget,
+		                    completion_strictness, completion_min_done_ratio, activity_level,
+		                    prompt_sensitivity, thinking_budget, model_fallback, cloned_from, created_at)
+		 SELECT ?, project_id, ?, ?, type, ?, model, priority, autonomy_level,
+		        ?, base_branch, token_budget, time_budget_min, dollar_budget,
+		        completion_strictness, completion_min_done_ratio, activity_level,
+		        prompt_sensitivity, thinking_budget, model_fallback, id, ?
+		 FROM tasks WHERE id = ?`,
+		id, title, description, hat, TaskStatusPending, time.Now(), sourceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone task: %w", err)
+	}
+
+	if err := db.cloneTaskChecklist(sourceID, id); err != nil {
+		return nil, err
+	}
+
+	return db.GetTaskByID(id)
+}
+
+// getTaskStartingHat returns the hat a task's first session started with,
+// falling back to the task's current hat if it has never run
+func (db *DB) getTaskStartingHat(t *Task) (sql.NullString, error) {
+	var hat sql.NullString
+	err := db.QueryRow(
+		`SELECT hat FROM sessions WHERE task_id = ? ORDER BY created_at ASC LIMIT 1`,
+		t.ID,
+	).Scan(&hat)
+	if err == sql.ErrNoRows || (err == nil && hat.String == "") {
+		return t.Hat, nil
+	}
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to get starting hat: %w", err)
+	}
+	return hat, nil
+}
+
+// cloneTaskChecklist copies a task's checklist items, all pending, to another task
+func (db *DB) cloneTaskChecklist(sourceID, targetID string) error {
+	source, err := db.GetChecklistByTaskID(sourceID)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return nil
+	}
+
+	items, err := db.GetChecklistItems(source.ID)
+	if err != nil {
+		return err
+	}
+
+	checklist, err := db.CreateTaskChecklist(targetID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := db.CreateChecklistItem(checklist.ID, item.Description, item.SortOrder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTaskClonedFrom returns the ID of the task a task was cloned from, or "" if it wasn't
+func (db *DB) GetTaskClonedFrom(taskID string) (string, error) {
+	var clonedFrom sql.NullString
+	err := db.QueryRow(`SELECT cloned_from FROM tasks WHERE id = ?`, taskID).Scan(&clonedFrom)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get cloned_from: %w", err)
+	}
+	return clonedFrom.String, nil
+}
+
+// ListTaskClones returns the tasks cloned from a task, oldest first
+func (db *DB) ListTaskClones(taskID string) ([]*Task, error) {
+	return db.listTasks(`WHERE cloned_from = ? ORDER BY created_at ASC`, taskID)
+}
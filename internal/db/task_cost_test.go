@@ -0,0 +1,54 @@
+package db
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetTaskCost(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cost, err := db.GetTaskCost(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != 0 {
+		t.Fatalf("cost = %v, want 0 before any session", cost)
+	}
+
+	record := func(hat string, inputRate, outputRate float64, input, output int) {
+		t.Helper()
+		sess, err := db.CreateSession(task.ID, hat, "/tmp/wt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetSessionRates(sess.ID, inputRate, outputRate); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.CreateSessionActivity(sess.ID, 1, ActivityTypeAssistantResponse, hat, "", &input, &output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record("creator", 3, 15, 1_000_000, 100_000) // $3 + $1.50
+	record("critic", 15, 75, 100_000, 10_000)    // $1.50 + $0.75
+	if _, err := db.CreateSession(task.ID, "editor", "/tmp/wt"); err != nil {
+		t.Fatal(err) // No activity yet
+	}
+
+	cost, err = db.GetTaskCost(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(cost-6.75) > 1e-9 {
+		t.Errorf("cost = %v, want 6.75 across every session", cost)
+	}
+}
@@ -0,0 +1,84 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetProjectDefaultModel returns the model new tasks in the project use unless
+// they set one, or "" if not set
+func (db *DB) GetProjectDefaultModel(projectID string) (string, error) {
+	var model sql.NullString
+	err := db.QueryRow(`SELECT default_model FROM projects WHERE id = ?`, projectID).Scan(&model)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project default model: %w", err)
+	}
+	return model.String, nil
+}
+
+// SetProjectDefaultModel sets the model new tasks in the project use unless
+// they set one ("" clears it)
+func (db *DB) SetProjectDefaultModel(projectID, model string) error {
+	value := sql.NullString{String: model, Valid: model != ""}
+	if value.Valid && model != TaskModelSonnet && model != TaskModelOpus {
+		return fmt.Errorf("invalid default model %q (must be sonnet or opus)", model)
+	}
+
+	result, err := db.Exec(`UPDATE projects SET default_model = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project default model: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
+
+// GetProjectDefaultAutonomy returns the autonomy level new tasks in the project
+// use unless they set one, or nil if not set
+func (db *DB) GetProjectDefaultAutonomy(projectID string) (*int, error) {
+	var level sql.NullInt64
+	err := db.QueryRow(`SELECT default_autonomy FROM projects WHERE id = ?`, projectID).Scan(&level)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project default autonomy: %w", err)
+	}
+	if !level.Valid {
+		return nil, nil
+	}
+	autonomy := int(level.Int64)
+	return &autonomy, nil
+}
+
+// SetProjectDefaultAutonomy sets the autonomy level new tasks in the project
+// use unless they set one (nil clears it)
+func (db *DB) SetProjectDefaultAutonomy(projectID string, level *int) error {
+	var value sql.NullInt64
+	if level != nil {
+		if *level < 0 {
+			return fmt.Errorf("default autonomy must not be negative")
+		}
+		value = sql.NullInt64{Int64: int64(*level), Valid: true}
+	}
+
+	result, err := db.Exec(`UPDATE projects SET default_autonomy = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project default autonomy: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import "testing"
+
+func TestProjectTaskDefaults(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model, err := db.GetProjectDefaultModel(project.ID)
+	if err != nil || model != "" {
+		t.Fatalf("expected no default model, got %q, %v", model, err)
+	}
+	autonomy, err := db.GetProjectDefaultAutonomy(project.ID)
+	if err != nil || autonomy != nil {
+		t.Fatalf("expected no default autonomy, got %v, %v", autonomy, err)
+	}
+
+	if err := db.SetProjectDefaultModel(project.ID, TaskModelOpus); err != nil {
+		t.Fatal(err)
+	}
+	manual := 0
+	if err := db.SetProjectDefaultAutonomy(project.ID, &manual); err != nil {
+		t.Fatal(err)
+	}
+	if model, _ = db.GetProjectDefaultModel(project.ID); model != TaskModelOpus {
+		t.Errorf("model = %q, want opus", model)
+	}
+	if autonomy, _ = db.GetProjectDefaultAutonomy(project.ID); autonomy == nil || *autonomy != 0 {
+		t.Errorf("autonomy = %v, want 0", autonomy)
+	}
+
+	// Each default clears on its own
+	if err := db.SetProjectDefaultModel(project.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if model, _ = db.GetProjectDefaultModel(project.ID); model != "" {
+		t.Errorf("expected model cleared, got %q", model)
+	}
+	if autonomy, _ = db.GetProjectDefaultAutonomy(project.ID); autonomy == nil {
+		t.Error("clearing the model cleared the autonomy level")
+	}
+	if err := db.SetProjectDefaultAutonomy(project.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if autonomy, _ = db.GetProjectDefaultAutonomy(project.ID); autonomy != nil {
+		t.Errorf("expected autonomy cleared, got %d", *autonomy)
+	}
+
+	if err := db.SetProjectDefaultModel(project.ID, "gpt"); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+	if err := db.SetProjectDefaultModel("proj-missing", TaskModelOpus); err == nil {
+		t.Error("expected an error for a missing project")
+	}
+}
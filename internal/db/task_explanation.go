@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetTaskExplanation returns the task's cached explanation (JSON) and the key
+// of the prompt context it explains, or empty strings if none is cached
+func (db *DB) GetTaskExplanation(taskID string) (explanation, key string, err error) {
+	var explanationCol, keyCol sql.NullString
+	err = db.QueryRow(
+		`SELECT explanation, explanation_key FROM tasks WHERE id = ?`,
+		taskID,
+	).Scan(&explanationCol, &keyCol)
+	if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get task explanation: %w", err)
+	}
+	return explanationCol.String, keyCol.String, nil
+}
+
+// SetTaskExplanation caches the task's explanation, recording the key of the
+// prompt context it explains so a change to that context invalidates it
+func (db *DB) SetTaskExplanation(taskID, key, explanation string) error {
+	result, err := db.Exec(
+		`UPDATE tasks SET explanation = ?, explanation_key = ? WHERE id = ?`,
+		explanation, key, taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task explanation: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import "testing"
+
+func TestTaskExplanation(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Add login", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, key, err := db.GetTaskExplanation(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation != "" || key != "" {
+		t.Errorf("expected no cached explanation, got %q for %q", explanation, key)
+	}
+
+	if err := db.SetTaskExplanation(task.ID, "abc123", `{"approach":"Add a login form."}`); err != nil {
+		t.Fatal(err)
+	}
+	explanation, key, _ = db.GetTaskExplanation(task.ID)
+	if explanation != `{"approach":"Add a login form."}` || key != "abc123" {
+		t.Errorf("got %q for %q, want the cached explanation for abc123", explanation, key)
+	}
+
+	if _, _, err := db.GetTaskExplanation("task-missing"); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+	if err := db.SetTaskExplanation("task-missing", "abc123", "{}"); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
@@ -0,0 +1,90 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTaskLinkedAcrossProjects is returned when moving a task would leave its
+// subtasks or dependencies in another project
+var ErrTaskLinkedAcrossProjects = errors.New("task is linked to tasks in another project")
+
+// MoveTask reassigns a task to another project, keeping its sessions,
+// activity, checklist, and settings. Everything tied to the old project's repo
+// or planning is dropped: the worktree and branch references, the PR and issue
+// numbers, the quest that spawned it, and its parent if that's in another
+// project. A paused or quarantined task goes back to pending, since it can't
+// resume without its worktree. A task with subtasks, or with dependencies on
+// tasks outside the target project, can't be moved. Callers must make sure no
+// session is running and clean up the worktree first.
+func (db *DB) MoveTask(taskID, projectID string) (*Task, error) {
+	t, err := db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	project, err := db.GetProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if t.ProjectID == projectID {
+		return t, nil
+	}
+
+	if err := db.CheckTaskLinksForMove(taskID, projectID); err != nil {
+		return nil, err
+	}
+
+	var worktreeCleanedAt any
+	if t.WorktreePath.Valid && t.WorktreePath.String != "" {
+		worktreeCleanedAt = time.Now()
+	}
+
+	_, err = db.Exec(
+		`UPDATE tasks SET
+		    project_id = ?, quest_id = NULL, issue_number = NULL,
+		    parent_id = CASE WHEN parent_id IN (SELECT id FROM tasks WHERE project_id = ?) THEN parent_id ELSE NULL END,
+		    worktree_path = NULL, branch_name = NULL, pr_number = NULL, pr_merged_at = NULL,
+		    worktree_cleaned_at = COALESCE(?, worktree_cleaned_at),
+		    status = CASE WHEN status IN (?, ?) THEN ? ELSE status END
+		 WHERE id = ?`,
+		projectID, projectID, worktreeCleanedAt,
+		TaskStatusPaused, TaskStatusQuarantined, TaskStatusPending,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move task: %w", err)
+	}
+
+	return db.GetTaskByID(taskID)
+}
+
+// CheckTaskLinksForMove returns ErrTaskLinkedAcrossProjects if moving the task
+// to projectID would leave subtasks or dependencies in another project
+func (db *DB) CheckTaskLinksForMove(taskID, projectID string) error {
+	var subtasks, dependencies int
+	err := db.QueryRow(
+		`SELECT
+		    (SELECT COUNT(*) FROM tasks WHERE parent_id = ? AND project_id != ?),
+		    (SELECT COUNT(*) FROM task_dependencies d
+		     JOIN tasks other ON other.id = CASE WHEN d.blocker_id = ? THEN d.blocked_id ELSE d.blocker_id END
+		     WHERE (d.blocker_id = ? OR d.blocked_id = ?) AND other.project_id != ?)`,
+		taskID, projectID, taskID, taskID, taskID, projectID,
+	).Scan(&subtasks, &dependencies)
+	if err != nil {
+		return fmt.Errorf("failed to check task links: %w", err)
+	}
+	if subtasks > 0 || dependencies > 0 {
+		return fmt.Errorf("%w: it has %d subtasks and %d dependencies that would be left behind; move or unlink them first",
+			ErrTaskLinkedAcrossProjects, subtasks, dependencies)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetTaskReportSummary returns the task's cached completion report summary and
+// the ID of the last session it covers, or empty strings if none is cached
+func (db *DB) GetTaskReportSummary(taskID string) (summary, sessionID string, err error) {
+	var summaryCol, sessionCol sql.NullString
+	err = db.QueryRow(
+		`SELECT report_summary, report_summary_session FROM tasks WHERE id = ?`,
+		taskID,
+	).Scan(&summaryCol, &sessionCol)
+	if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get task report summary: %w", err)
+	}
+	return summaryCol.String, sessionCol.String, nil
+}
+
+// SetTaskReportSummary caches the task's completion report summary, recording
+// the last session it covers so a later session invalidates it
+func (db *DB) SetTaskReportSummary(taskID, sessionID, summary string) error {
+	result, err := db.Exec(
+		`UPDATE tasks SET report_summary = ?, report_summary_session = ? WHERE id = ?`,
+		summary, sessionID, taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task report summary: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestTaskReportSummary(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Add login", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, sessionID, err := db.GetTaskReportSummary(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != "" || sessionID != "" {
+		t.Errorf("expected no cached summary, got %q for %q", summary, sessionID)
+	}
+
+	if err := db.SetTaskReportSummary(task.ID, "sess-1", "Added login with tests."); err != nil {
+		t.Fatal(err)
+	}
+	summary, sessionID, _ = db.GetTaskReportSummary(task.ID)
+	if summary != "Added login with tests." || sessionID != "sess-1" {
+		t.Errorf("got %q for %q, want the cached summary for sess-1", summary, sessionID)
+	}
+
+	if err := db.SetTaskReportSummary("task-missing", "sess-1", "x"); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
+
+func TestListMemoriesByTask(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Add login", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := db.CreateTask(project.ID, "Add logout", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, taskID := range []string{task.ID, other.ID, task.ID} {
+		m := &Memory{
+			ID:              NewPrefixedID("mem"),
+			ProjectID:       project.ID,
+			Type:            MemoryPitfall,
+			Title:           []string{"First", "Other", "Second"}[i],
+			Content:         "content",
+			Confidence:      InitialConfidenceExplicit,
+			CreatedByHat:    "creator",
+			CreatedByTaskID: sql.NullString{String: taskID, Valid: true},
+			Source:          SourceExplicit,
+			CreatedAt:       time.Now().Add(time.Duration(i) * time.Second),
+		}
+		if err := db.CreateMemory(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	memories, err := db.ListMemoriesByTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memories) != 2 || memories[0].Title != "First" || memories[1].Title != "Second" {
+		t.Errorf("expected the task's two memories oldest first, got %+v", memories)
+	}
+}
@@ -0,0 +1,150 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxTagLength is the longest tag a task can have
+const MaxTagLength = 50
+
+// tagPattern allows tags like "flaky", "needs-review", "customer-123", or "team:infra"
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._:-]*$`)
+
+// TagCount is a tag and how many tasks have it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTag lowercases and trims a tag, returning an error if it isn't valid
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag must not be empty")
+	}
+	if len(tag) > MaxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+	}
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("invalid tag %q (use letters, digits, '.', '_', ':', and '-')", tag)
+	}
+	return tag, nil
+}
+
+// AddTaskTags adds tags to a task, ignoring ones it already has
+func (db *DB) AddTaskTags(taskID string, tags []string) error {
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tasks WHERE id = ?`, taskID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check task: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	for _, tag := range tags {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(
+			`INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`,
+			taskID, normalized,
+		); err != nil {
+			return fmt.Errorf("failed to add tag %s: %w", normalized, err)
+		}
+	}
+	return nil
+}
+
+// RemoveTaskTag removes a tag from a task
+func (db *DB) RemoveTaskTag(taskID, tag string) error {
+	result, err := db.Exec(
+		`DELETE FROM task_tags WHERE task_id = ? AND tag = ?`,
+		taskID, strings.ToLower(strings.TrimSpace(tag)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("tag not found on task %s: %s", taskID, tag)
+	}
+	return nil
+}
+
+// GetTaskTags returns a task's tags in alphabetical order
+func (db *DB) GetTaskTags(taskID string) ([]string, error) {
+	rows, err := db.Query(`SELECT tag FROM task_tags WHERE task_id = ? ORDER BY tag`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// ListTaskTagCounts returns every tag in use and how many tasks have it, most used first
+func (db *DB) ListTaskTagCounts() ([]TagCount, error) {
+	rows, err := db.Query(`SELECT tag, COUNT(*) FROM task_tags GROUP BY tag ORDER BY COUNT(*) DESC, tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag count: %w", err)
+		}
+		counts = append(counts, tc)
+	}
+	return counts, rows.Err()
+}
+
+// ListTasksByTags returns tasks that have every one of the given tags, across
+// all projects and quests. A non-empty projectID or status narrows the result.
+func (db *DB) ListTasksByTags(tags []string, projectID, status string) ([]*Task, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("at least one tag is required")
+	}
+
+	seen := make(map[string]bool)
+	var placeholders []string
+	var args []any
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		placeholders = append(placeholders, "?")
+		args = append(args, tag)
+	}
+	args = append(args, len(placeholders))
+
+	where := `WHERE id IN (SELECT task_id FROM task_tags WHERE tag IN (` + strings.Join(placeholders, ", ") + `)
+	                        GROUP BY task_id HAVING COUNT(DISTINCT tag) = ?)`
+	if projectID != "" {
+		where += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+	if status != "" {
+		where += ` AND status = ?`
+		args = append(args, status)
+	}
+
+	return db.listTasks(where+` ORDER BY priority ASC, created_at DESC`, args...)
+}
@@ -0,0 +1,85 @@
+package db
+
+import "testing"
+
+func TestTaskTags(t *testing.T) {
+	db := setupTestDB(t)
+
+	projectA, err := db.CreateProject("A", "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectB, err := db.CreateProject("B", "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flakyA, _ := db.CreateTask(projectA.ID, "Flaky in A", TaskTypeTask, 3)
+	flakyB, _ := db.CreateTask(projectB.ID, "Flaky in B", TaskTypeTask, 3)
+	other, _ := db.CreateTask(projectB.ID, "Untagged", TaskTypeTask, 3)
+
+	if err := db.AddTaskTags(flakyA.ID, []string{"Flaky", "customer-123"}); err != nil {
+		t.Fatal(err)
+	}
+	// Adding a tag twice is a no-op
+	if err := db.AddTaskTags(flakyB.ID, []string{"flaky", " flaky "}); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := db.GetTaskTags(flakyA.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags[0] != "customer-123" || tags[1] != "flaky" {
+		t.Errorf("tags = %v, want [customer-123 flaky]", tags)
+	}
+
+	// Filtering spans projects
+	tasks, err := db.ListTasksByTags([]string{"flaky"}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("got %d flaky tasks, want 2", len(tasks))
+	}
+
+	// Every tag must match
+	tasks, _ = db.ListTasksByTags([]string{"flaky", "customer-123", "FLAKY"}, "", "")
+	if len(tasks) != 1 || tasks[0].ID != flakyA.ID {
+		t.Errorf("expected only %s to have both tags, got %d tasks", flakyA.ID, len(tasks))
+	}
+
+	// Project narrows the result
+	tasks, _ = db.ListTasksByTags([]string{"flaky"}, projectB.ID, "")
+	if len(tasks) != 1 || tasks[0].ID != flakyB.ID {
+		t.Errorf("expected only %s in project B, got %d tasks", flakyB.ID, len(tasks))
+	}
+
+	counts, err := db.ListTaskTagCounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || counts[0] != (TagCount{Tag: "flaky", Count: 2}) {
+		t.Errorf("counts = %v, want flaky first with 2", counts)
+	}
+
+	if err := db.RemoveTaskTag(flakyA.ID, "flaky"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RemoveTaskTag(other.ID, "flaky"); err == nil {
+		t.Error("expected error removing a tag the task doesn't have")
+	}
+	if err := db.AddTaskTags("task-missing", []string{"flaky"}); err == nil {
+		t.Error("expected error tagging a missing task")
+	}
+	if err := db.AddTaskTags(other.ID, []string{"has space"}); err == nil {
+		t.Error("expected invalid tag to be rejected")
+	}
+
+	// Deleting a task removes its tags
+	if err := db.DeleteTask(flakyB.ID); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ = db.ListTasksByTags([]string{"flaky"}, "", ""); len(tasks) != 0 {
+		t.Errorf("expected no flaky tasks left, got %d", len(tasks))
+	}
+}
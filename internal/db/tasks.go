@@ -9,22 +9,28 @@ import (
 
 // CreateTask inserts a new task into the database
 func (db *DB) CreateTask(projectID, title string, taskType string, priority int) (*Task, error) {
+	return db.CreateTaskWithSettings(projectID, title, taskType, priority, "", 1)
+}
+
+// CreateTaskWithSettings inserts a new task with a model ("" = the loop's default) and autonomy level
+func (db *DB) CreateTaskWithSettings(projectID, title, taskType string, priority int, model string, autonomyLevel int) (*Task, error) {
 	task := &Task{
 		ID:            NewPrefixedID("task"),
 		ProjectID:     projectID,
 		Title:         title,
 		Type:          taskType,
+		Model:         sql.NullString{String: model, Valid: model != ""},
 		Priority:      priority,
-		AutonomyLevel: 1,
+		AutonomyLevel: autonomyLevel,
 		Status:        TaskStatusPending,
 		BaseBranch:    "main",
 		CreatedAt:     time.Now(),
 	}
 
 	_, err := db.Exec(
-		`INSERT INTO tasks (id, project_id, title, type, priority, autonomy_level, status, base_branch, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		task.ID, task.ProjectID, task.Title, task.Type, task.Priority,
+		`INSERT INTO tasks (id, project_id, title, type, model, priority, autonomy_level, status, base_branch, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		task.ID, task.ProjectID, task.Title, task.Type, task.Model, task.Priority,
 		task.AutonomyLevel, task.Status, task.BaseBranch, task.CreatedAt,
 	)
 	if err != nil {
@@ -270,6 +276,16 @@ func (db *DB) MarkTaskWorktreeCleaned(id string) error {
 	return nil
 }
 
+// worktreeNotClaimedClause excludes tasks whose worktree a successor that is
+// still unfinished has claimed (see ClaimTaskWorktree), so cleanup never
+// removes a worktree out from under a running task.
+const worktreeNotClaimedClause = `
+		  AND NOT EXISTS (
+		      SELECT 1 FROM tasks other
+		      WHERE other.worktree_path = tasks.worktree_path AND other.id != tasks.id
+		        AND other.status NOT IN ('completed', 'completed_with_issues', 'cancelled', 'timed_out')
+		  )`
+
 // GetTasksWithStaleWorktrees returns tasks that have worktrees but haven't been cleaned
 // A stale worktree is one where the task is completed and either:
 // - Has a PR that was merged, or
@@ -279,11 +295,24 @@ func (db *DB) GetTasksWithStaleWorktrees() ([]*Task, error) {
 		WHERE worktree_path IS NOT NULL
 		  AND worktree_path != ''
 		  AND worktree_cleaned_at IS NULL
-		  AND status IN ('completed', 'cancelled')
+		  AND status IN ('completed', 'cancelled')` + worktreeNotClaimedClause + `
 		ORDER BY completed_at ASC
 	`)
 }
 
+// GetTasksWithDebugWorktrees returns cancelled and timed-out tasks that still
+// have worktrees, kept in case their failure needs debugging. Most recently
+// finished first. Worktrees a successor has claimed are left out.
+func (db *DB) GetTasksWithDebugWorktrees() ([]*Task, error) {
+	return db.listTasks(`
+		WHERE worktree_path IS NOT NULL
+		  AND worktree_path != ''
+		  AND worktree_cleaned_at IS NULL
+		  AND status IN ('cancelled', 'timed_out')` + worktreeNotClaimedClause + `
+		ORDER BY COALESCE(completed_at, started_at, created_at) DESC
+	`)
+}
+
 // GetTasksReadyForWorktreeCleanup returns completed tasks with merged PRs ready for cleanup
 func (db *DB) GetTasksReadyForWorktreeCleanup() ([]*Task, error) {
 	return db.listTasks(`
@@ -376,6 +405,14 @@ func (db *DB) DeleteTask(id string) error {
 		return fmt.Errorf("failed to delete task dependencies: %w", err)
 	}
 
+	if _, err := db.Exec(`DELETE FROM task_tags WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task tags: %w", err)
+	}
+
+	if _, err := db.Exec(`DELETE FROM task_attachments WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task attachments: %w", err)
+	}
+
 	result, err := db.Exec(`DELETE FROM tasks WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
@@ -0,0 +1,74 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Extended thinking budgets, in tokens. A budget of 0 turns thinking off.
+const (
+	MinThinkingBudget = 1024  // Smallest budget the API accepts
+	MaxThinkingBudget = 32000 // Largest budget a task may set
+)
+
+// ValidateThinkingBudget checks that budget is 0 (off) or within the accepted range
+func ValidateThinkingBudget(budget int) error {
+	if budget == 0 || (budget >= MinThinkingBudget && budget <= MaxThinkingBudget) {
+		return nil
+	}
+	return fmt.Errorf("invalid thinking budget %d (must be 0 to turn thinking off, or %d to %d tokens)",
+		budget, MinThinkingBudget, MaxThinkingBudget)
+}
+
+// GetTaskThinkingBudget returns the task's extended thinking budget, or nil if
+// the task uses each hat's default
+func (db *DB) GetTaskThinkingBudget(taskID string) (*int, error) {
+	var budget sql.NullInt64
+	err := db.QueryRow(`SELECT thinking_budget FROM tasks WHERE id = ?`, taskID).Scan(&budget)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task thinking budget: %w", err)
+	}
+	if !budget.Valid {
+		return nil, nil
+	}
+	value := int(budget.Int64)
+	return &value, nil
+}
+
+// SetTaskThinkingBudget sets the task's extended thinking budget (nil restores
+// the hat defaults, 0 turns thinking off for every hat)
+func (db *DB) SetTaskThinkingBudget(taskID string, budget *int) error {
+	var value sql.NullInt64
+	if budget != nil {
+		if err := ValidateThinkingBudget(*budget); err != nil {
+			return err
+		}
+		value = sql.NullInt64{Int64: int64(*budget), Valid: true}
+	}
+
+	result, err := db.Exec(`UPDATE tasks SET thinking_budget = ? WHERE id = ?`, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update task thinking budget: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
+
+// SetActivityThinkingTokens records how many of an activity's output tokens
+// went to extended thinking
+func (db *DB) SetActivityThinkingTokens(activityID string, tokens int) error {
+	_, err := db.Exec(`UPDATE session_activity SET tokens_thinking = ? WHERE id = ?`, tokens, activityID)
+	if err != nil {
+		return fmt.Errorf("failed to record thinking tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import "testing"
+
+func TestTaskThinkingBudget(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := db.CreateTask(project.ID, "Design", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unset: hats use their defaults
+	budget, err := db.GetTaskThinkingBudget(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget != nil {
+		t.Errorf("default budget = %d, want nil", *budget)
+	}
+
+	// Zero is kept, so a task can turn thinking off
+	off := 0
+	if err := db.SetTaskThinkingBudget(task.ID, &off); err != nil {
+		t.Fatal(err)
+	}
+	if budget, _ = db.GetTaskThinkingBudget(task.ID); budget == nil || *budget != 0 {
+		t.Errorf("budget = %v, want 0", budget)
+	}
+
+	// Clones keep the budget
+	want := 16000
+	if err := db.SetTaskThinkingBudget(task.ID, &want); err != nil {
+		t.Fatal(err)
+	}
+	clone, err := db.CloneTask(task.ID, CloneTaskOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget, _ = db.GetTaskThinkingBudget(clone.ID); budget == nil || *budget != want {
+		t.Errorf("clone budget = %v, want %d", budget, want)
+	}
+
+	tooSmall := 500
+	if err := db.SetTaskThinkingBudget(task.ID, &tooSmall); err == nil {
+		t.Error("expected a budget below the minimum to be rejected")
+	}
+	if err := db.SetTaskThinkingBudget("task-missing", nil); err == nil {
+		t.Error("expected unknown task to be rejected")
+	}
+}
+
+func TestSessionActivitySummary_ThinkingTokens(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, _ := db.CreateProject("Test", "/test")
+	task, _ := db.CreateTask(project.ID, "Plan", TaskTypeTask, 3)
+	session, err := db.CreateSession(task.ID, "planner", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in, out := 1000, 600
+	activity, err := db.CreateSessionActivity(session.ID, 1, ActivityTypeAssistantResponse, "planner", "plan", &in, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetActivityThinkingTokens(activity.ID, 400); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := db.GetSessionActivitySummary(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.OutputTokens != 600 || summary.ThinkingTokens != 400 {
+		t.Errorf("output=%d thinking=%d, want 600 and 400", summary.OutputTokens, summary.ThinkingTokens)
+	}
+}
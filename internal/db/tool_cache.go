@@ -0,0 +1,42 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetProjectToolCacheSize returns how many read-only tool results each of the
+// project's sessions may cache, or 0 if caching is off
+func (db *DB) GetProjectToolCacheSize(projectID string) (int, error) {
+	var size sql.NullInt64
+	err := db.QueryRow(`SELECT tool_cache_size FROM projects WHERE id = ?`, projectID).Scan(&size)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("project not found: %s", projectID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get project tool cache size: %w", err)
+	}
+	return int(size.Int64), nil
+}
+
+// SetProjectToolCacheSize sets how many read-only tool results each of the
+// project's sessions may cache (0 turns caching off)
+func (db *DB) SetProjectToolCacheSize(projectID string, size int) error {
+	if size < 0 {
+		return fmt.Errorf("tool cache size must not be negative")
+	}
+
+	value := sql.NullInt64{Int64: int64(size), Valid: size > 0}
+	result, err := db.Exec(`UPDATE projects SET tool_cache_size = ? WHERE id = ?`, value, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update project tool cache size: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrationToolbeltChecks records the results of periodic toolbelt connection
+// tests, so expired or revoked credentials show up in the history.
+const migrationToolbeltChecks = `
+CREATE TABLE IF NOT EXISTS toolbelt_checks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	service TEXT NOT NULL,
+	success INTEGER NOT NULL,
+	error TEXT,
+	latency_ms INTEGER NOT NULL DEFAULT 0,
+	checked_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_toolbelt_checks_checked_at ON toolbelt_checks(checked_at);
+`
+
+// ToolbeltCheck is the result of testing one toolbelt service's connection
+type ToolbeltCheck struct {
+	ID        int64     `json:"id"`
+	Service   string    `json:"service"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// RecordToolbeltChecks stores the results of one round of connection tests
+func (db *DB) RecordToolbeltChecks(checks []ToolbeltCheck) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, c := range checks {
+		if _, err := tx.Exec(`
+			INSERT INTO toolbelt_checks (service, success, error, latency_ms, checked_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, c.Service, c.Success, sql.NullString{String: c.Error, Valid: c.Error != ""}, c.LatencyMs, c.CheckedAt); err != nil {
+			return fmt.Errorf("failed to record toolbelt check: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit toolbelt checks: %w", err)
+	}
+	return nil
+}
+
+// ListToolbeltChecks returns up to limit checks, newest first, optionally for
+// a single service
+func (db *DB) ListToolbeltChecks(service string, limit int) ([]*ToolbeltCheck, error) {
+	query := `SELECT id, service, success, error, latency_ms, checked_at FROM toolbelt_checks`
+	var args []any
+	if service != "" {
+		query += ` WHERE service = ?`
+		args = append(args, service)
+	}
+	query += ` ORDER BY checked_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list toolbelt checks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	checks := []*ToolbeltCheck{}
+	for rows.Next() {
+		c := &ToolbeltCheck{}
+		var checkErr sql.NullString
+		if err := rows.Scan(&c.ID, &c.Service, &c.Success, &checkErr, &c.LatencyMs, &c.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan toolbelt check: %w", err)
+		}
+		c.Error = checkErr.String
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+// PruneToolbeltChecks deletes checks made before the cutoff
+func (db *DB) PruneToolbeltChecks(before time.Time) (int64, error) {
+	result, err := db.Exec(`DELETE FROM toolbelt_checks WHERE checked_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune toolbelt checks: %w", err)
+	}
+	return result.RowsAffected()
+}
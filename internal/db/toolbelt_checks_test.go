@@ -0,0 +1,51 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToolbeltChecks(t *testing.T) {
+	db := setupTestDB(t)
+
+	old := time.Now().Add(-48 * time.Hour)
+	now := time.Now()
+	if err := db.RecordToolbeltChecks([]ToolbeltCheck{
+		{Service: "github", Success: true, LatencyMs: 120, CheckedAt: old},
+		{Service: "anthropic", Success: true, LatencyMs: 300, CheckedAt: old},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RecordToolbeltChecks([]ToolbeltCheck{
+		{Service: "github", Success: false, Error: "401 Bad credentials", LatencyMs: 90, CheckedAt: now},
+		{Service: "anthropic", Success: true, LatencyMs: 250, CheckedAt: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	checks, err := db.ListToolbeltChecks("github", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("got %d github checks, want 2", len(checks))
+	}
+	if checks[0].Success || checks[0].Error != "401 Bad credentials" || !checks[1].Success {
+		t.Errorf("expected newest failed check first, got %+v, %+v", checks[0], checks[1])
+	}
+
+	if checks, _ := db.ListToolbeltChecks("", 3); len(checks) != 3 {
+		t.Errorf("got %d checks with limit 3", len(checks))
+	}
+
+	pruned, err := db.PruneToolbeltChecks(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d checks, want 2", pruned)
+	}
+	if checks, _ := db.ListToolbeltChecks("", 10); len(checks) != 2 {
+		t.Errorf("got %d checks after pruning, want 2", len(checks))
+	}
+}
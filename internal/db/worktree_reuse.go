@@ -0,0 +1,107 @@
+// Package db provides SQLite database access for Poindexter
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrWorktreeInUse is returned when a task claims a worktree another
+// unfinished task is already working in
+var ErrWorktreeInUse = errors.New("worktree is in use by another task")
+
+// GetTaskReuseWorktreeFrom returns the ID of the task whose worktree the task
+// continues in, or "" if it gets a worktree of its own
+func (db *DB) GetTaskReuseWorktreeFrom(taskID string) (string, error) {
+	var from sql.NullString
+	err := db.QueryRow(`SELECT reuse_worktree_from FROM tasks WHERE id = ?`, taskID).Scan(&from)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get reuse_worktree_from: %w", err)
+	}
+	return from.String, nil
+}
+
+// SetTaskReuseWorktreeFrom makes the task continue in a predecessor's worktree
+// and branch when it starts, instead of creating its own. The predecessor must
+// be another task in the same project. An empty predecessorID clears it.
+func (db *DB) SetTaskReuseWorktreeFrom(taskID, predecessorID string) error {
+	var value sql.NullString
+	if predecessorID != "" {
+		if predecessorID == taskID {
+			return fmt.Errorf("a task can't reuse its own worktree")
+		}
+		task, err := db.GetTaskByID(taskID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+		predecessor, err := db.GetTaskByID(predecessorID)
+		if err != nil {
+			return err
+		}
+		if predecessor == nil {
+			return fmt.Errorf("task not found: %s", predecessorID)
+		}
+		if predecessor.ProjectID != task.ProjectID {
+			return fmt.Errorf("task %s belongs to a different project", predecessorID)
+		}
+		value = sql.NullString{String: predecessorID, Valid: true}
+	}
+
+	result, err := db.Exec(`UPDATE tasks SET reuse_worktree_from = ? WHERE id = ?`, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update task reuse_worktree_from: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	return nil
+}
+
+// ClaimTaskWorktree sets the task's worktree path and branch like
+// UpdateTaskWorktree, unless another task that hasn't completed or been
+// cancelled already has that worktree, in which case it returns
+// ErrWorktreeInUse. The check and the update are one statement, so two tasks
+// reusing the same worktree at once can't both claim it.
+func (db *DB) ClaimTaskWorktree(id, worktreePath, branchName string) error {
+	result, err := db.Exec(
+		`UPDATE tasks SET worktree_path = ?, branch_name = ?
+		 WHERE id = ? AND NOT EXISTS (
+		     SELECT 1 FROM tasks other
+		     WHERE other.worktree_path = ? AND other.id != ?
+		       AND other.status NOT IN (?, ?)
+		 )`,
+		worktreePath, branchName, id,
+		worktreePath, id, TaskStatusCompleted, TaskStatusCancelled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to claim task worktree: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows > 0 {
+		return nil
+	}
+
+	var holder string
+	err = db.QueryRow(
+		`SELECT id FROM tasks WHERE worktree_path = ? AND id != ? AND status NOT IN (?, ?) LIMIT 1`,
+		worktreePath, id, TaskStatusCompleted, TaskStatusCancelled,
+	).Scan(&holder)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim task worktree: %w", err)
+	}
+	return fmt.Errorf("%w: %s", ErrWorktreeInUse, holder)
+}
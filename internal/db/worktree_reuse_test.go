@@ -0,0 +1,167 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTaskReuseWorktreeFrom(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := db.CreateProject("Other", "/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := db.CreateTask(project.ID, "Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix, err := db.CreateTask(project.ID, "Fix: Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere, err := db.CreateTask(other.ID, "Elsewhere", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if from, err := db.GetTaskReuseWorktreeFrom(fix.ID); err != nil || from != "" {
+		t.Fatalf("default = %q, %v, want none", from, err)
+	}
+	if err := db.SetTaskReuseWorktreeFrom(fix.ID, fix.ID); err == nil {
+		t.Error("expected an error reusing the task's own worktree")
+	}
+	if err := db.SetTaskReuseWorktreeFrom(fix.ID, elsewhere.ID); err == nil {
+		t.Error("expected an error reusing a worktree from another project")
+	}
+	if err := db.SetTaskReuseWorktreeFrom(fix.ID, original.ID); err != nil {
+		t.Fatal(err)
+	}
+	if from, _ := db.GetTaskReuseWorktreeFrom(fix.ID); from != original.ID {
+		t.Errorf("reuse_worktree_from = %q, want %q", from, original.ID)
+	}
+	if err := db.SetTaskReuseWorktreeFrom(fix.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if from, _ := db.GetTaskReuseWorktreeFrom(fix.ID); from != "" {
+		t.Errorf("reuse_worktree_from = %q after clearing, want none", from)
+	}
+}
+
+func TestClaimTaskWorktree(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := func(title string) *Task {
+		t.Helper()
+		task, err := db.CreateTask(project.ID, title, TaskTypeTask, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	original, first, second := create("Build"), create("Fix"), create("Docs")
+
+	if err := db.UpdateTaskWorktree(original.ID, "/wt/build", "task/task-build"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The original is still unfinished, so nobody else may work in its worktree
+	if err := db.ClaimTaskWorktree(first.ID, "/wt/build", "task/task-build"); !errors.Is(err, ErrWorktreeInUse) {
+		t.Fatalf("claim while the original is pending = %v, want ErrWorktreeInUse", err)
+	}
+
+	if err := db.UpdateTaskStatus(original.ID, TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ClaimTaskWorktree(first.ID, "/wt/build", "task/task-build"); err != nil {
+		t.Fatalf("claim after the original completed: %v", err)
+	}
+	claimed, err := db.GetTaskByID(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed.GetWorktreePath() != "/wt/build" || claimed.GetBranchName() != "task/task-build" {
+		t.Errorf("claimed worktree = %q on %q, want the original's", claimed.GetWorktreePath(), claimed.GetBranchName())
+	}
+
+	// Only one successor gets it
+	if err := db.ClaimTaskWorktree(second.ID, "/wt/build", "task/task-build"); !errors.Is(err, ErrWorktreeInUse) {
+		t.Errorf("second claim = %v, want ErrWorktreeInUse", err)
+	}
+	// Claiming a worktree the task already holds is fine
+	if err := db.ClaimTaskWorktree(first.ID, "/wt/build", "task/task-build"); err != nil {
+		t.Errorf("reclaim by the holder: %v", err)
+	}
+	if err := db.ClaimTaskWorktree("missing", "/wt/other", ""); err == nil || errors.Is(err, ErrWorktreeInUse) {
+		t.Errorf("claim for a missing task = %v, want not found", err)
+	}
+}
+
+func TestDebugWorktrees_SkipClaimedWorktrees(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := db.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	predecessor, err := db.CreateTask(project.ID, "Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	successor, err := db.CreateTask(project.ID, "Retry: Build", TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.UpdateTaskWorktree(predecessor.ID, "/wt/build", "task/task-build"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskStatus(predecessor.ID, TaskStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	debugIDs := func() []string {
+		t.Helper()
+		tasks, err := db.GetTasksWithDebugWorktrees()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	if ids := debugIDs(); len(ids) != 1 || ids[0] != predecessor.ID {
+		t.Fatalf("debug worktrees = %v, want the cancelled predecessor", ids)
+	}
+
+	// The running successor now works in the predecessor's worktree
+	if err := db.ClaimTaskWorktree(successor.ID, "/wt/build", "task/task-build"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTaskStatus(successor.ID, TaskStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if ids := debugIDs(); len(ids) != 0 {
+		t.Errorf("debug worktrees = %v, want none while the successor uses it", ids)
+	}
+	if stale, err := db.GetTasksWithStaleWorktrees(); err != nil || len(stale) != 0 {
+		t.Errorf("stale worktrees = %d, %v, want none while the successor uses it", len(stale), err)
+	}
+
+	// Once the successor finishes too, the worktree can go
+	if err := db.UpdateTaskStatus(successor.ID, TaskStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if ids := debugIDs(); len(ids) != 2 {
+		t.Errorf("debug worktrees = %v, want both tasks once neither is running", ids)
+	}
+}
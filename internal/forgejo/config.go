@@ -34,6 +34,13 @@ type Config struct {
 	// Projects are created under this org. Defaults to "workspace".
 	DefaultOrgName string
 
+	// BotUsername is the account Dex uses for repos, issues, and PRs.
+	// Defaults to "dex-bot". Changing it creates a new bot on the next start.
+	BotUsername string
+
+	// BotEmail is the bot account's email. Defaults to {BotUsername}@hq.local.
+	BotEmail string
+
 	// RunUser is the username to run Forgejo as.
 	// If empty and running as root, defaults to "nobody".
 	// Forgejo refuses to run as root for security reasons.
@@ -86,6 +93,25 @@ func (c *Config) GetDefaultOrgName() string {
 	return "workspace"
 }
 
+// GetBotUsername returns the bot account's username.
+func (c *Config) GetBotUsername() string {
+	if c.BotUsername != "" {
+		return c.BotUsername
+	}
+	return BotUsername
+}
+
+// GetBotEmail returns the bot account's email.
+func (c *Config) GetBotEmail() string {
+	if c.BotEmail != "" {
+		return c.BotEmail
+	}
+	if c.BotUsername != "" && c.BotUsername != BotUsername {
+		return c.BotUsername + "@hq.local"
+	}
+	return BotEmail
+}
+
 // EnvVars returns the environment variables needed for Forgejo processes.
 func (c *Config) EnvVars() []string {
 	// HOME must point to a directory the Forgejo user can write to.
@@ -0,0 +1,119 @@
+package forgejo
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// DefaultLogLines is how many lines of Forgejo output are kept in memory
+const DefaultLogLines = 1000
+
+// maxLogLineBytes caps a single line, so output without newlines can't grow
+// the partial line without bound
+const maxLogLineBytes = 16 * 1024
+
+// LogLine is one line of Forgejo process output
+type LogLine struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// LogBuffer keeps the most recent lines written to it and passes new lines on
+// to subscribers. It is the Forgejo process's stdout and stderr.
+type LogBuffer struct {
+	mu      sync.Mutex
+	lines   []LogLine // Ring buffer, oldest at next once full
+	next    int
+	full    bool
+	partial []byte // Output after the last newline
+	subs    map[chan LogLine]struct{}
+}
+
+// NewLogBuffer creates a buffer keeping up to size lines
+func NewLogBuffer(size int) *LogBuffer {
+	if size <= 0 {
+		size = DefaultLogLines
+	}
+	return &LogBuffer{
+		lines: make([]LogLine, size),
+		subs:  make(map[chan LogLine]struct{}),
+	}
+}
+
+// Write splits p into lines, keeping any trailing partial line for the next write
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.addLocked(LogLine{Time: now, Text: string(bytes.TrimRight(data[:i], "\r"))})
+		data = data[i+1:]
+	}
+	if len(data) > maxLogLineBytes {
+		b.addLocked(LogLine{Time: now, Text: string(data)})
+		data = nil
+	}
+	b.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+// addLocked stores a line and sends it to subscribers. A subscriber that
+// isn't keeping up misses lines rather than blocking the process.
+func (b *LogBuffer) addLocked(line LogLine) {
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// Tail returns up to n of the most recent lines, oldest first (all if n <= 0)
+func (b *LogBuffer) Tail(n int) []LogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tailLocked(n)
+}
+
+func (b *LogBuffer) tailLocked(n int) []LogLine {
+	var lines []LogLine
+	if b.full {
+		lines = append(lines, b.lines[b.next:]...)
+	}
+	lines = append(lines, b.lines[:b.next]...)
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// Follow returns up to n of the most recent lines and a channel receiving the
+// lines written after them. Call cancel to stop following.
+func (b *LogBuffer) Follow(n int) (recent []LogLine, lines <-chan LogLine, cancel func()) {
+	ch := make(chan LogLine, 256)
+
+	b.mu.Lock()
+	recent = b.tailLocked(n)
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return recent, ch, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
@@ -2,7 +2,9 @@ package forgejo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -24,31 +26,61 @@ const (
 	SecretKeyOAuthSecret   = "forgejo_oauth_secret"
 )
 
+// ErrRepoExists is returned when creating a repository whose name is taken.
+var ErrRepoExists = errors.New("forgejo repository already exists")
+
 // OAuthClientID is the client ID used when registering Forgejo with HQ's OIDC provider.
 const OAuthClientID = "forgejo"
 
+// SecretStore holds the Forgejo tokens and passwords. *db.DB stores them in
+// plaintext; *db.EncryptedSecretsStore encrypts them with the master key.
+type SecretStore interface {
+	GetSecret(key string) (string, error)
+	SetSecret(key, value string) error
+	HasSecret(key string) bool
+}
+
 // Manager controls the lifecycle of an embedded Forgejo instance.
 type Manager struct {
-	config Config
-	db     *db.DB
-
-	mu      sync.Mutex
-	cmd     *exec.Cmd
-	running bool
-	cancel  context.CancelFunc
+	config  Config
+	secrets SecretStore
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	running   bool
+	cancel    context.CancelFunc
+	exited    chan struct{} // Closed when the running process exits
+	stopping  bool          // Stop was called, so the exit is expected
+	startedAt time.Time
+	exitErr   error // Why the process last exited unexpectedly
+
+	logs *LogBuffer // Recent process output
 }
 
 // NewManager creates a Forgejo manager.
 func NewManager(config Config, database *db.DB) *Manager {
 	return &Manager{
-		config: config,
-		db:     database,
+		config:  config,
+		secrets: database,
+		logs:    NewLogBuffer(DefaultLogLines),
 	}
 }
 
+// SetSecretStore replaces where Forgejo secrets are kept, so they can be
+// encrypted. Call it before Start.
+func (m *Manager) SetSecretStore(store SecretStore) {
+	m.secrets = store
+}
+
 // Start launches the Forgejo process and waits until it is healthy.
 // If this is the first run (no admin token in DB), it performs bootstrap setup.
 func (m *Manager) Start(ctx context.Context) error {
+	return m.start(ctx, ctx)
+}
+
+// start launches the process under procParent, using ctx for setup and the
+// wait for health.
+func (m *Manager) start(procParent, ctx context.Context) error {
 	m.mu.Lock()
 	if m.running {
 		m.mu.Unlock()
@@ -76,10 +108,10 @@ func (m *Manager) Start(ctx context.Context) error {
 	}
 
 	// Check if bootstrap is needed (first run)
-	needsBootstrap := !m.db.HasSecret(SecretKeyAdminToken)
+	needsBootstrap := !m.secrets.HasSecret(SecretKeyAdminToken)
 
 	// Start the process
-	procCtx, cancel := context.WithCancel(ctx)
+	procCtx, cancel := context.WithCancel(procParent)
 	m.mu.Lock()
 	m.cancel = cancel
 	m.mu.Unlock()
@@ -105,46 +137,59 @@ func (m *Manager) Start(ctx context.Context) error {
 			return fmt.Errorf("forgejo bootstrap failed: %w", err)
 		}
 		fmt.Println("Forgejo bootstrap complete")
+	} else {
+		// Recreate the bot or its token if they've gone missing, e.g. after a
+		// bootstrap that failed partway or a change of bot username
+		adminToken, err := m.AdminToken()
+		if err == nil {
+			err = m.ensureBot(ctx, adminToken)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to ensure Forgejo bot account: %v\n", err)
+		}
 	}
 
 	return nil
 }
 
-// Stop shuts down the Forgejo process gracefully.
+// Stop shuts down the Forgejo process gracefully, killing it if it hasn't
+// exited after 10 seconds.
 func (m *Manager) Stop() error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	if !m.running || m.cmd == nil {
+		m.mu.Unlock()
 		return nil
 	}
+	m.stopping = true
+	cmd, exited, cancel := m.cmd, m.exited, m.cancel
+	m.mu.Unlock()
 
-	// Cancel the process context
-	if m.cancel != nil {
-		m.cancel()
+	// Send SIGINT and wait; cancelling the process context kills it
+	if cmd.Process != nil {
+		_ = cmd.Process.Signal(os.Interrupt)
 	}
-
-	// Send SIGTERM and wait
-	if m.cmd.Process != nil {
-		_ = m.cmd.Process.Signal(os.Interrupt)
-
-		// Give it 10 seconds to shut down
-		done := make(chan error, 1)
-		go func() { done <- m.cmd.Wait() }()
-
-		select {
-		case <-done:
-		case <-time.After(10 * time.Second):
-			_ = m.cmd.Process.Kill()
-		}
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+	}
+	if cancel != nil {
+		cancel()
 	}
+	<-exited
 
-	m.running = false
-	m.cmd = nil
 	fmt.Println("Forgejo stopped")
 	return nil
 }
 
+// Restart stops Forgejo if it's running and starts it again. The new process
+// isn't tied to the caller's context, which only bounds the wait for health.
+func (m *Manager) Restart(ctx context.Context) error {
+	if err := m.Stop(); err != nil {
+		return err
+	}
+	return m.start(context.Background(), ctx)
+}
+
 // IsRunning returns whether the Forgejo process is currently running.
 func (m *Manager) IsRunning() bool {
 	m.mu.Lock()
@@ -152,22 +197,66 @@ func (m *Manager) IsRunning() bool {
 	return m.running
 }
 
+// Status describes the Forgejo process for diagnostics.
+type Status struct {
+	Running   bool       `json:"running"`
+	Healthy   bool       `json:"healthy"` // Responded to HTTP just now
+	PID       int        `json:"pid,omitempty"`
+	Port      int        `json:"port"`
+	URL       string     `json:"url"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"` // Why it last exited unexpectedly
+}
+
+// Status reports whether Forgejo is running and, if so, whether it responds.
+func (m *Manager) Status(ctx context.Context) Status {
+	m.mu.Lock()
+	status := Status{
+		Running: m.running,
+		Port:    m.port(),
+		URL:     m.BaseURL(),
+	}
+	if m.running && m.cmd != nil && m.cmd.Process != nil {
+		status.PID = m.cmd.Process.Pid
+		startedAt := m.startedAt
+		status.StartedAt = &startedAt
+	}
+	if m.exitErr != nil {
+		status.LastError = m.exitErr.Error()
+	}
+	m.mu.Unlock()
+
+	if status.Running {
+		status.Healthy = m.checkHealth(ctx) == nil
+	}
+	return status
+}
+
+// Logs returns the buffer holding recent Forgejo process output.
+func (m *Manager) Logs() *LogBuffer {
+	return m.logs
+}
+
 // BaseURL returns the HTTP base URL for the Forgejo instance.
 func (m *Manager) BaseURL() string {
 	addr := m.config.HTTPAddr
 	if addr == "" {
 		addr = "127.0.0.1"
 	}
-	port := m.config.HTTPPort
-	if port == 0 {
-		port = 3000
+	return fmt.Sprintf("http://%s:%d", addr, m.port())
+}
+
+// port returns the HTTP port Forgejo listens on.
+func (m *Manager) port() int {
+	if m.config.HTTPPort == 0 {
+		return 3000
 	}
-	return fmt.Sprintf("http://%s:%d", addr, port)
+	return m.config.HTTPPort
 }
 
 // BotToken returns the bot account's API token from the database.
 func (m *Manager) BotToken() (string, error) {
-	token, err := m.db.GetSecret(SecretKeyBotToken)
+	token, err := m.secrets.GetSecret(SecretKeyBotToken)
 	if err != nil {
 		return "", fmt.Errorf("failed to get bot token: %w", err)
 	}
@@ -179,7 +268,7 @@ func (m *Manager) BotToken() (string, error) {
 
 // AdminToken returns the admin account's API token from the database.
 func (m *Manager) AdminToken() (string, error) {
-	token, err := m.db.GetSecret(SecretKeyAdminToken)
+	token, err := m.secrets.GetSecret(SecretKeyAdminToken)
 	if err != nil {
 		return "", fmt.Errorf("failed to get admin token: %w", err)
 	}
@@ -193,7 +282,7 @@ func (m *Manager) AdminToken() (string, error) {
 // This is safe to call multiple times - it only generates if missing.
 func (m *Manager) EnsureOAuthSecret() error {
 	// Check if already exists
-	if secret, _ := m.db.GetSecret(SecretKeyOAuthSecret); secret != "" {
+	if secret, _ := m.secrets.GetSecret(SecretKeyOAuthSecret); secret != "" {
 		return nil
 	}
 
@@ -203,7 +292,7 @@ func (m *Manager) EnsureOAuthSecret() error {
 		return fmt.Errorf("failed to generate OAuth secret: %w", err)
 	}
 
-	if err := m.db.SetSecret(SecretKeyOAuthSecret, oauthSecret); err != nil {
+	if err := m.secrets.SetSecret(SecretKeyOAuthSecret, oauthSecret); err != nil {
 		return fmt.Errorf("failed to store OAuth secret: %w", err)
 	}
 
@@ -213,7 +302,7 @@ func (m *Manager) EnsureOAuthSecret() error {
 
 // OAuthSecret returns the OAuth client secret for OIDC integration.
 func (m *Manager) OAuthSecret() (string, error) {
-	secret, err := m.db.GetSecret(SecretKeyOAuthSecret)
+	secret, err := m.secrets.GetSecret(SecretKeyOAuthSecret)
 	if err != nil {
 		return "", fmt.Errorf("failed to get OAuth secret: %w", err)
 	}
@@ -232,7 +321,7 @@ type AccessInfo struct {
 
 // WebAccess returns the URL and credentials for the Forgejo web UI.
 func (m *Manager) WebAccess() (*AccessInfo, error) {
-	password, err := m.db.GetSecret(SecretKeyAdminPassword)
+	password, err := m.secrets.GetSecret(SecretKeyAdminPassword)
 	if err != nil || password == "" {
 		return nil, fmt.Errorf("admin password not available (bootstrap may not have run)")
 	}
@@ -249,6 +338,11 @@ func (m *Manager) RepoPath(owner, repo string) string {
 	return fmt.Sprintf("%s/%s/%s.git", m.config.GetRepoRoot(), owner, repo)
 }
 
+// CloneURL returns the HTTP clone URL of a repository on this instance.
+func (m *Manager) CloneURL(owner, repo string) string {
+	return fmt.Sprintf("%s/%s/%s.git", m.BaseURL(), owner, repo)
+}
+
 // Config returns the current configuration (read-only copy).
 func (m *Manager) Config() Config {
 	return m.config
@@ -262,9 +356,9 @@ func (m *Manager) startProcess(ctx context.Context) error {
 		"--work-path", m.config.DataDir,
 	)
 
-	// Forgejo logs to console in our config; capture stderr
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Forgejo logs to console in our config; keep recent output for the API
+	cmd.Stdout = io.MultiWriter(os.Stdout, m.logs)
+	cmd.Stderr = io.MultiWriter(os.Stderr, m.logs)
 
 	// Set FORGEJO_WORK_DIR so Forgejo can find its data
 	cmd.Env = append(os.Environ(), m.config.EnvVars()...)
@@ -303,9 +397,14 @@ func (m *Manager) startProcess(ctx context.Context) error {
 		return fmt.Errorf("failed to start forgejo: %w", err)
 	}
 
+	exited := make(chan struct{})
 	m.mu.Lock()
 	m.cmd = cmd
 	m.running = true
+	m.exited = exited
+	m.stopping = false
+	m.startedAt = time.Now()
+	m.exitErr = nil
 	m.mu.Unlock()
 
 	// Monitor the process in background
@@ -314,8 +413,13 @@ func (m *Manager) startProcess(ctx context.Context) error {
 		m.mu.Lock()
 		m.running = false
 		m.cmd = nil
+		unexpected := err != nil && !m.stopping && ctx.Err() == nil
+		if unexpected {
+			m.exitErr = err
+		}
 		m.mu.Unlock()
-		if err != nil && ctx.Err() == nil {
+		close(exited)
+		if unexpected {
 			fmt.Fprintf(os.Stderr, "Forgejo process exited unexpectedly: %v\n", err)
 		}
 	}()
@@ -325,16 +429,6 @@ func (m *Manager) startProcess(ctx context.Context) error {
 
 func (m *Manager) waitForHealthy(ctx context.Context, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
-	// Use root path - we just need to verify Forgejo responds to HTTP
-	healthURL := m.BaseURL() + "/"
-
-	client := &http.Client{
-		Timeout: 2 * time.Second,
-		// Don't follow redirects - we just want to know if Forgejo responds
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
 
 	for time.Now().Before(deadline) {
 		select {
@@ -343,11 +437,7 @@ func (m *Manager) waitForHealthy(ctx context.Context, timeout time.Duration) err
 		default:
 		}
 
-		resp, err := client.Get(healthURL)
-		if err == nil {
-			_ = resp.Body.Close()
-			// Any HTTP response means Forgejo is running.
-			// Could be 200, 302 redirect, 403 forbidden - all indicate healthy.
+		if m.checkHealth(ctx) == nil {
 			return nil
 		}
 
@@ -357,6 +447,31 @@ func (m *Manager) waitForHealthy(ctx context.Context, timeout time.Duration) err
 	return fmt.Errorf("forgejo did not become healthy within %s", timeout)
 }
 
+// checkHealth makes one request to Forgejo. Any HTTP response means it is
+// running: 200, a 302 redirect, and 403 forbidden all indicate healthy.
+func (m *Manager) checkHealth(ctx context.Context) error {
+	// Use root path - we just need to verify Forgejo responds to HTTP
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.BaseURL()+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		// Don't follow redirects - we just want to know if Forgejo responds
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
 // chownRecursive changes ownership of a directory and all its contents.
 func chownRecursive(path string, uid, gid int) error {
 	return filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
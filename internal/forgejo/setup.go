@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,9 +14,12 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/lirancohen/dex/internal/gitprovider"
 )
 
-// User account constants for Forgejo bootstrap.
+// User account constants for Forgejo bootstrap. The bot's are defaults that
+// Config can override.
 const (
 	AdminUsername = "dex-admin"
 	AdminEmail    = "admin@hq.local"
@@ -24,7 +28,8 @@ const (
 )
 
 // bootstrap performs first-run setup: creates admin and bot accounts,
-// generates API tokens, and stores them in the Dex database.
+// generates API tokens, and stores them in the Dex database. Later starts
+// only repeat the bot steps, through ensureBot.
 func (m *Manager) bootstrap(ctx context.Context) error {
 	// 1. Create admin user via Forgejo CLI
 	adminPassword, err := generateSecret(16)
@@ -37,7 +42,7 @@ func (m *Manager) bootstrap(ctx context.Context) error {
 	}
 
 	// Store admin password so the user can log into the Forgejo web UI
-	if err := m.db.SetSecret(SecretKeyAdminPassword, adminPassword); err != nil {
+	if err := m.secrets.SetSecret(SecretKeyAdminPassword, adminPassword); err != nil {
 		return fmt.Errorf("failed to store admin password: %w", err)
 	}
 
@@ -48,43 +53,17 @@ func (m *Manager) bootstrap(ctx context.Context) error {
 	}
 
 	// Store admin token immediately so we can use the API
-	if err := m.db.SetSecret(SecretKeyAdminToken, adminToken); err != nil {
+	if err := m.secrets.SetSecret(SecretKeyAdminToken, adminToken); err != nil {
 		return fmt.Errorf("failed to store admin token: %w", err)
 	}
 
-	// 3. Create bot user via API
-	botPassword, err := generateSecret(16)
-	if err != nil {
-		return fmt.Errorf("failed to generate bot password: %w", err)
-	}
-
-	if err := m.apiCreateUser(ctx, adminToken, BotUsername, BotEmail, botPassword); err != nil {
-		return fmt.Errorf("failed to create bot user: %w", err)
-	}
-
-	// 4. Generate bot API token via CLI
-	botToken, err := m.cliCreateToken(ctx, BotUsername, "dex-bot-token")
-	if err != nil {
-		return fmt.Errorf("failed to create bot token: %w", err)
-	}
-
-	// Store bot token
-	if err := m.db.SetSecret(SecretKeyBotToken, botToken); err != nil {
-		return fmt.Errorf("failed to store bot token: %w", err)
-	}
-
-	// 5. Create default organization so projects have a home
-	orgName := m.config.GetDefaultOrgName()
-	if err := m.apiCreateOrg(ctx, adminToken, orgName); err != nil {
-		return fmt.Errorf("failed to create default org: %w", err)
-	}
-
-	// 6. Add bot user to the org so it can create repos and PRs
-	if err := m.apiAddOrgMember(ctx, adminToken, orgName, BotUsername); err != nil {
-		return fmt.Errorf("failed to add bot to org: %w", err)
+	// 3. Create the default organization, so projects have a home, and the
+	// bot account and its API token
+	if err := m.ensureBot(ctx, adminToken); err != nil {
+		return err
 	}
 
-	// 7. Generate OAuth secret for SSO (but don't configure provider yet)
+	// 4. Generate OAuth secret for SSO (but don't configure provider yet)
 	// Always generate the secret so it's available if OIDC is enabled on the server.
 	// The OAuth provider setup requires HQ's HTTP server to be reachable,
 	// so we defer that to SetupSSOProvider() which is called after HTTP starts.
@@ -196,7 +175,22 @@ func (m *Manager) CreateRepo(ctx context.Context, org, name string) error {
 	if err != nil {
 		return err
 	}
-	return m.apiCreateOrgRepo(ctx, botToken, org, name)
+	return m.apiCreateOrgRepo(ctx, botToken, org, name, "")
+}
+
+// CreateProjectRepo creates a repository for a new project in a Forgejo
+// organization using the bot token. It returns ErrRepoExists if the
+// organization already has a repository with that name.
+func (m *Manager) CreateProjectRepo(ctx context.Context, org, name, description string) error {
+	botToken, err := m.BotToken()
+	if err != nil {
+		return err
+	}
+	err = m.apiCreateOrgRepo(ctx, botToken, org, name, description)
+	if hasStatus(err, http.StatusConflict) {
+		return fmt.Errorf("%w: %s/%s", ErrRepoExists, org, name)
+	}
+	return err
 }
 
 // EnsureRepo ensures a repository exists in Forgejo, creating it if necessary.
@@ -215,7 +209,7 @@ func (m *Manager) EnsureRepo(ctx context.Context, org, name string) error {
 
 	// If not found, create it
 	if strings.Contains(err.Error(), "404") {
-		return m.apiCreateOrgRepo(ctx, botToken, org, name)
+		return m.apiCreateOrgRepo(ctx, botToken, org, name, "")
 	}
 
 	return err
@@ -245,7 +239,72 @@ func (m *Manager) AddBotToOrg(ctx context.Context, org string) error {
 	if err != nil {
 		return err
 	}
-	return m.apiAddOrgMember(ctx, adminToken, org, BotUsername)
+	return m.apiAddOrgMember(ctx, adminToken, org, m.config.GetBotUsername())
+}
+
+// ensureBot makes sure the default org and the bot account exist, the bot
+// owns the org, and it has a working API token in the secret store. It runs on every start and
+// only does what's missing: an existing account is reused, and a stored token
+// is kept as long as it still authenticates as the bot. A new token gets a
+// unique name, since Forgejo rejects a second token with the same name.
+func (m *Manager) ensureBot(ctx context.Context, adminToken string) error {
+	username := m.config.GetBotUsername()
+
+	exists, err := m.apiUserExists(ctx, adminToken, username)
+	if err != nil {
+		return fmt.Errorf("failed to look up bot user: %w", err)
+	}
+	if !exists {
+		botPassword, err := generateSecret(16)
+		if err != nil {
+			return fmt.Errorf("failed to generate bot password: %w", err)
+		}
+		if err := m.apiCreateUser(ctx, adminToken, username, m.config.GetBotEmail(), botPassword); err != nil {
+			return fmt.Errorf("failed to create bot user: %w", err)
+		}
+		fmt.Printf("Created Forgejo bot user %s\n", username)
+	}
+
+	botToken, err := m.secrets.GetSecret(SecretKeyBotToken)
+	if err != nil {
+		return fmt.Errorf("failed to get bot token: %w", err)
+	}
+	if botToken != "" {
+		if login, err := m.apiTokenUser(ctx, botToken); err != nil || login != username {
+			botToken = ""
+		}
+	}
+	if botToken == "" {
+		tokenName := fmt.Sprintf("dex-bot-token-%d", time.Now().Unix())
+		botToken, err = m.cliCreateToken(ctx, username, tokenName)
+		if err != nil {
+			return fmt.Errorf("failed to create bot token: %w", err)
+		}
+	}
+
+	// Store even a token we kept, so one saved before encryption was
+	// configured is encrypted now
+	if err := m.secrets.SetSecret(SecretKeyBotToken, botToken); err != nil {
+		return fmt.Errorf("failed to store bot token: %w", err)
+	}
+
+	orgName := m.config.GetDefaultOrgName()
+	orgExists, err := m.apiOrgExists(ctx, adminToken, orgName)
+	if err != nil {
+		return fmt.Errorf("failed to look up default org: %w", err)
+	}
+	if !orgExists {
+		if err := m.apiCreateOrg(ctx, adminToken, orgName); err != nil {
+			return fmt.Errorf("failed to create default org: %w", err)
+		}
+	}
+
+	// Adding an existing member is a no-op
+	if err := m.apiAddOrgMember(ctx, adminToken, orgName, username); err != nil {
+		return fmt.Errorf("failed to add bot to org: %w", err)
+	}
+
+	return nil
 }
 
 // --- CLI helpers ---
@@ -357,6 +416,49 @@ func (m *Manager) apiCreateUser(ctx context.Context, adminToken, username, email
 	return err
 }
 
+// apiUserExists reports whether a Forgejo account exists
+func (m *Manager) apiUserExists(ctx context.Context, adminToken, username string) (bool, error) {
+	return m.apiExists(ctx, adminToken, "/api/v1/users/"+username)
+}
+
+// apiOrgExists reports whether a Forgejo organization exists
+func (m *Manager) apiOrgExists(ctx context.Context, adminToken, org string) (bool, error) {
+	return m.apiExists(ctx, adminToken, "/api/v1/orgs/"+org)
+}
+
+// apiExists reports whether GET path finds something
+func (m *Manager) apiExists(ctx context.Context, token, path string) (bool, error) {
+	_, err := m.apiRequest(ctx, token, "GET", path, nil)
+	if err == nil {
+		return true, nil
+	}
+	if hasStatus(err, http.StatusNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// hasStatus reports whether err is an API response with the given status
+func hasStatus(err error, status int) bool {
+	var httpErr *gitprovider.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == status
+}
+
+// apiTokenUser returns the login of the account a token authenticates as
+func (m *Manager) apiTokenUser(ctx context.Context, token string) (string, error) {
+	resp, err := m.apiRequest(ctx, token, "GET", "/api/v1/user", nil)
+	if err != nil {
+		return "", err
+	}
+	var u struct {
+		Login string `json:"login"`
+	}
+	if err := json.Unmarshal(resp, &u); err != nil {
+		return "", fmt.Errorf("failed to parse user response: %w", err)
+	}
+	return u.Login, nil
+}
+
 func (m *Manager) apiCreateOrg(ctx context.Context, token, name string) error {
 	body := map[string]interface{}{
 		"username":   name,
@@ -366,13 +468,16 @@ func (m *Manager) apiCreateOrg(ctx context.Context, token, name string) error {
 	return err
 }
 
-func (m *Manager) apiCreateOrgRepo(ctx context.Context, token, org, name string) error {
+func (m *Manager) apiCreateOrgRepo(ctx context.Context, token, org, name, description string) error {
 	body := map[string]interface{}{
-		"name":          name,
-		"private":       true,
-		"auto_init":     true,
+		"name":           name,
+		"private":        true,
+		"auto_init":      true,
 		"default_branch": "main",
 	}
+	if description != "" {
+		body["description"] = description
+	}
 	_, err := m.apiRequest(ctx, token, "POST", fmt.Sprintf("/api/v1/orgs/%s/repos", org), body)
 	return err
 }
@@ -447,7 +552,7 @@ func (m *Manager) apiRequest(ctx context.Context, token, method, path string, bo
 	_, _ = respBody.ReadFrom(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API %s %s returned %d: %s", method, path, resp.StatusCode, respBody.String())
+		return nil, &gitprovider.HTTPError{Method: method, Path: path, StatusCode: resp.StatusCode, Body: respBody.String()}
 	}
 
 	return respBody.Bytes(), nil
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// trailerPattern matches a commit trailer, e.g. "Assisted-by: Dex"
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: [^\r\n]+$`)
+
+// ValidateTrailer checks that trailer is a single "Token: value" line
+func ValidateTrailer(trailer string) error {
+	if !trailerPattern.MatchString(trailer) {
+		return fmt.Errorf("commit trailer %q must be written as \"Token: value\", e.g. \"Assisted-by: Dex\"", trailer)
+	}
+	return nil
+}
+
+// SetCommitIdentity sets the user.name and user.email that commits in a
+// worktree are authored and committed as. The settings go in the worktree's
+// own config, so other worktrees of the repository keep their identity.
+func (o *Operations) SetCommitIdentity(dir, name, email string) error {
+	if name == "" || email == "" {
+		return fmt.Errorf("commit identity requires both name and email")
+	}
+
+	// Per-worktree config must be switched on for the repository first
+	if _, err := runGit(dir, "config", "extensions.worktreeConfig", "true"); err != nil {
+		return fmt.Errorf("failed to enable worktree config: %w", err)
+	}
+	if _, err := runGit(dir, "config", "--worktree", "user.name", name); err != nil {
+		return fmt.Errorf("failed to set user.name: %w", err)
+	}
+	if _, err := runGit(dir, "config", "--worktree", "user.email", email); err != nil {
+		return fmt.Errorf("failed to set user.email: %w", err)
+	}
+	return nil
+}
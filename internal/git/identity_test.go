@@ -0,0 +1,63 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetCommitIdentity(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoPath, "first")
+
+	worktreePath := filepath.Join(t.TempDir(), "task")
+	gitOutput(t, repoPath, "worktree", "add", "-b", "task/one", worktreePath)
+
+	ops := NewOperations()
+	if err := ops.SetCommitIdentity(worktreePath, "Dex Bot", "dex-bot@ourco.com"); err != nil {
+		t.Fatalf("SetCommitIdentity failed: %v", err)
+	}
+
+	createCommit(t, worktreePath, "from the agent")
+	if got := gitOutput(t, worktreePath, "log", "-1", "--format=%an <%ae> / %cn <%ce>"); got != "Dex Bot <dex-bot@ourco.com> / Dex Bot <dex-bot@ourco.com>" {
+		t.Errorf("worktree commit identity = %q", got)
+	}
+
+	// The main checkout keeps its own identity
+	if got := gitOutput(t, repoPath, "config", "user.email"); got != "test@test.com" {
+		t.Errorf("main checkout user.email = %q, want test@test.com", got)
+	}
+
+	if err := ops.SetCommitIdentity(worktreePath, "", "dex-bot@ourco.com"); err == nil {
+		t.Error("expected an error without a name")
+	}
+}
+
+func TestCommit_Trailers(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoPath, "first")
+
+	ops := NewOperations()
+	if _, err := ops.Commit(repoPath, CommitOptions{
+		Message:    "Add dark mode",
+		AllowEmpty: true,
+		Trailers:   []string{"Assisted-by: Dex"},
+	}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if got := gitOutput(t, repoPath, "log", "-1", "--format=%(trailers:key=Assisted-by,valueonly)"); got != "Dex" {
+		t.Errorf("Assisted-by trailer = %q, want Dex", got)
+	}
+
+	for _, trailer := range []string{"Assisted-by: Dex", "Co-authored-by: Dex Bot <dex-bot@ourco.com>"} {
+		if err := ValidateTrailer(trailer); err != nil {
+			t.Errorf("ValidateTrailer(%q) error = %v", trailer, err)
+		}
+	}
+	for _, trailer := range []string{"Assisted by Dex", "Assisted-by:", "Assisted-by: Dex\nSigned-off-by: me"} {
+		if err := ValidateTrailer(trailer); err == nil {
+			t.Errorf("ValidateTrailer(%q) expected error", trailer)
+		}
+	}
+}
@@ -1,26 +1,38 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/lirancohen/dex/internal/gitprovider"
 )
 
 // Operations provides git commands for working with repositories
-type Operations struct{}
+type Operations struct {
+	pushRetry gitprovider.RetryPolicy // Retries for pushes that fail transiently
+}
 
 // NewOperations creates a new Operations instance
 func NewOperations() *Operations {
-	return &Operations{}
+	return &Operations{pushRetry: gitprovider.DefaultRetryPolicy()}
+}
+
+// SetPushRetry sets how pushes that fail transiently are retried
+func (o *Operations) SetPushRetry(policy gitprovider.RetryPolicy) {
+	o.pushRetry = policy
 }
 
 // CommitOptions configures a git commit
 type CommitOptions struct {
-	Message    string // Commit message (required)
-	All        bool   // Stage all tracked files (-a flag)
-	AllowEmpty bool   // Allow empty commit
-	Author     string // Override author (optional, format: "Name <email>")
+	Message    string   // Commit message (required)
+	All        bool     // Stage all tracked files (-a flag)
+	AllowEmpty bool     // Allow empty commit
+	Author     string   // Override author (optional, format: "Name <email>")
+	Trailers   []string // Trailers added to the message (optional, format: "Token: value")
 }
 
 // Commit creates a git commit in the specified directory
@@ -40,6 +52,9 @@ func (o *Operations) Commit(dir string, opts CommitOptions) (string, error) {
 	if opts.Author != "" {
 		args = append(args, "--author", opts.Author)
 	}
+	for _, trailer := range opts.Trailers {
+		args = append(args, "--trailer", trailer)
+	}
 	args = append(args, "-m", opts.Message)
 
 	cmd := exec.Command("git", args...)
@@ -69,7 +84,8 @@ type PushOptions struct {
 	Force       bool   // Force push (use with caution)
 }
 
-// Push pushes commits to a remote.
+// Push pushes commits to a remote, retrying with backoff if the push fails
+// transiently (see IsRetryablePushError).
 // For worktrees created from a bare repo (Forgejo), this is a no-op since
 // commits are already in the bare repo's object store.
 func (o *Operations) Push(dir string, opts PushOptions) error {
@@ -99,15 +115,16 @@ func (o *Operations) Push(dir string, opts PushOptions) error {
 		args = append(args, opts.Branch)
 	}
 
-	cmd := exec.Command("git", args...)
-	cmd.Dir = dir
+	return o.pushRetry.Do(context.Background(), "git push", IsRetryablePushError, func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("push failed: %s: %w", string(output), err)
-	}
-
-	return nil
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("push failed: %s: %w", string(output), err)
+		}
+		return nil
+	})
 }
 
 // IsWorktreeOfBareRepo checks if dir is a git worktree whose parent repo
@@ -171,6 +188,23 @@ func (o *Operations) Pull(dir string, opts PullOptions) error {
 	return nil
 }
 
+// runGit runs a git command in dir and returns its stdout untrimmed. On
+// failure the error carries git's stderr.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
 // GetCurrentBranch returns the current branch name
 func (o *Operations) GetCurrentBranch(dir string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
@@ -0,0 +1,58 @@
+package git
+
+import "strings"
+
+// pushRejectedMarkers appear in the output of pushes the remote refused, which
+// fail the same way however often they're retried
+var pushRejectedMarkers = []string{
+	"[rejected]",
+	"[remote rejected]",
+	"protected branch",
+	"non-fast-forward",
+	"hook declined",
+	"stale info",
+	"permission denied",
+	"returned error: 403",
+}
+
+// pushTransientMarkers appear in the output of pushes that failed on the way
+// to the remote: network trouble, server errors, or a momentary auth failure
+var pushTransientMarkers = []string{
+	"could not resolve host",
+	"connection timed out",
+	"connection refused",
+	"connection reset",
+	"operation timed out",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"rpc failed",
+	"temporary failure",
+	"tls handshake",
+	"gnutls",
+	"ssl_error",
+	"authentication failed",
+	"returned error: 5",
+	"internal server error",
+}
+
+// IsRetryablePushError reports whether a failed push is worth retrying. Pushes
+// the remote rejected (protected branches, non-fast-forward, hooks,
+// permissions) are not.
+func IsRetryablePushError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, marker := range pushRejectedMarkers {
+		if strings.Contains(msg, marker) {
+			return false
+		}
+	}
+	for _, marker := range pushTransientMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsRetryablePushError(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{"dns failure", "fatal: unable to access 'https://github.com/o/r.git/': Could not resolve host: github.com", true},
+		{"server error", "error: RPC failed; HTTP 502 curl 22 The requested URL returned error: 502", true},
+		{"hung up", "fatal: the remote end hung up unexpectedly", true},
+		{"momentary auth", "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/o/r.git/'", true},
+		{"non-fast-forward", " ! [rejected]        main -> main (non-fast-forward)", false},
+		{"protected branch", "remote: error: GH006: Protected branch update failed for refs/heads/main.\n ! [remote rejected] main -> main (protected branch hook declined)", false},
+		{"forbidden", "fatal: unable to access 'https://github.com/o/r.git/': The requested URL returned error: 403", false},
+		{"unknown", "error: src refspec feature does not match any", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := errors.New("push failed: " + tt.output + ": exit status 128")
+			if got := IsRetryablePushError(err); got != tt.want {
+				t.Errorf("IsRetryablePushError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if IsRetryablePushError(nil) {
+		t.Error("nil error should not be retryable")
+	}
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// DebugRetention limits how many worktrees of cancelled and timed-out tasks
+// are kept around for debugging, and for how long
+type DebugRetention struct {
+	Keep   int           // Most recent debug worktrees to keep (0 = no count limit)
+	MaxAge time.Duration // Remove debug worktrees of tasks that finished longer ago (0 = no age limit)
+}
+
+// DefaultDebugRetention keeps the 20 most recent debug worktrees for up to a week
+func DefaultDebugRetention() DebugRetention {
+	return DebugRetention{Keep: 20, MaxAge: 7 * 24 * time.Hour}
+}
+
+// IsZero reports whether the policy keeps debug worktrees forever
+func (r DebugRetention) IsZero() bool {
+	return r.Keep <= 0 && r.MaxAge <= 0
+}
+
+// Expired reports whether the debug worktree at position rank (0 = most
+// recently finished) of a task that finished at finishedAt should be removed
+func (r DebugRetention) Expired(rank int, finishedAt, now time.Time) bool {
+	if r.Keep > 0 && rank >= r.Keep {
+		return true
+	}
+	return r.MaxAge > 0 && now.Sub(finishedAt) > r.MaxAge
+}
+
+// PruneResult summarizes a pass over debug worktrees
+type PruneResult struct {
+	Pruned []string `json:"pruned"` // IDs of tasks whose worktrees were removed
+	Kept   int      `json:"kept"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// SetDebugRetention sets the policy PruneDebugWorktrees applies
+func (s *Service) SetDebugRetention(policy DebugRetention) {
+	s.debugRetention = policy
+}
+
+// DebugRetention returns the policy PruneDebugWorktrees applies
+func (s *Service) DebugRetention() DebugRetention {
+	return s.debugRetention
+}
+
+// PruneDebugWorktrees removes the worktrees of cancelled and timed-out tasks
+// that the debug retention policy no longer keeps. Their branches are left in
+// place. A zero policy keeps everything.
+func (s *Service) PruneDebugWorktrees() (*PruneResult, error) {
+	tasks, err := s.db.GetTasksWithDebugWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{Pruned: []string{}}
+	now := time.Now()
+	for rank, task := range tasks {
+		if s.debugRetention.IsZero() || !s.debugRetention.Expired(rank, taskFinishedAt(task), now) {
+			result.Kept++
+			continue
+		}
+
+		project, err := s.db.GetProjectByID(task.ProjectID)
+		if err != nil || project == nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("task %s: failed to get project", task.ID))
+			continue
+		}
+		if err := s.CleanupTaskWorktree(project.RepoPath, task.ID, false); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("task %s: %v", task.ID, err))
+			continue
+		}
+		if err := s.db.MarkTaskWorktreeCleaned(task.ID); err != nil {
+			fmt.Printf("PruneDebugWorktrees: warning - failed to mark task %s worktree as cleaned: %v\n", task.ID, err)
+		}
+		result.Pruned = append(result.Pruned, task.ID)
+	}
+	return result, nil
+}
+
+// taskFinishedAt returns when the task stopped running, as best the record says
+func taskFinishedAt(task *db.Task) time.Time {
+	switch {
+	case task.CompletedAt.Valid:
+		return task.CompletedAt.Time
+	case task.StartedAt.Valid:
+		return task.StartedAt.Time
+	default:
+		return task.CreatedAt
+	}
+}
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebugRetention_Expired(t *testing.T) {
+	now := time.Now()
+	policy := DebugRetention{Keep: 2, MaxAge: 24 * time.Hour}
+
+	tests := []struct {
+		name       string
+		rank       int
+		finishedAt time.Time
+		want       bool
+	}{
+		{"newest and recent", 0, now.Add(-time.Hour), false},
+		{"within count, too old", 1, now.Add(-48 * time.Hour), true},
+		{"beyond count, recent", 2, now.Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Expired(tt.rank, tt.finishedAt, now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (DebugRetention{}).Expired(100, now.Add(-365*24*time.Hour), now) {
+		t.Error("zero policy should never expire worktrees")
+	}
+	if !(DebugRetention{}).IsZero() || DefaultDebugRetention().IsZero() {
+		t.Error("IsZero() is wrong for the zero or default policy")
+	}
+}
@@ -1,18 +1,24 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 
 	"github.com/lirancohen/dex/internal/db"
 )
 
+// ErrNoWorktree is returned for a task that has no worktree
+var ErrNoWorktree = errors.New("task has no worktree")
+
 // Service coordinates git operations with database records
 type Service struct {
 	db         *db.DB
 	worktrees  *WorktreeManager
 	operations *Operations
 	repos      *RepoManager
+
+	debugRetention DebugRetention // Worktrees kept for cancelled and timed-out tasks
 }
 
 // NewService creates a git service
@@ -21,6 +27,8 @@ func NewService(database *db.DB, worktreeBase, reposBase string) *Service {
 		db:         database,
 		worktrees:  NewWorktreeManager(worktreeBase),
 		operations: NewOperations(),
+
+		debugRetention: DefaultDebugRetention(),
 	}
 	if reposBase != "" {
 		s.repos = NewRepoManager(reposBase)
@@ -79,7 +87,7 @@ func (s *Service) CleanupTaskWorktree(projectPath, taskID string, cleanupBranch
 	}
 
 	if !task.WorktreePath.Valid || task.WorktreePath.String == "" {
-		return fmt.Errorf("task has no worktree: %s", taskID)
+		return fmt.Errorf("%w: %s", ErrNoWorktree, taskID)
 	}
 
 	// Remove the worktree
@@ -105,12 +113,49 @@ func (s *Service) GetTaskWorktreeStatus(taskID string) (*GitStatus, error) {
 		return nil, fmt.Errorf("task not found: %s", taskID)
 	}
 	if !task.WorktreePath.Valid || task.WorktreePath.String == "" {
-		return nil, fmt.Errorf("task has no worktree: %s", taskID)
+		return nil, fmt.Errorf("%w: %s", ErrNoWorktree, taskID)
 	}
 
 	return s.worktrees.GetStatus(task.WorktreePath.String)
 }
 
+// GetTaskWorktreeDiff returns the uncommitted changes in a task's worktree,
+// or only the staged ones
+func (s *Service) GetTaskWorktreeDiff(taskID string, staged bool) (*WorktreeDiff, error) {
+	task, err := s.db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if !task.WorktreePath.Valid || task.WorktreePath.String == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoWorktree, taskID)
+	}
+
+	return s.worktrees.GetDiff(task.WorktreePath.String, staged)
+}
+
+// UndoTaskCommit undoes the last commit in a task's worktree. Soft resets are
+// limited to commits the task made on top of its base branch.
+func (s *Service) UndoTaskCommit(taskID string, mode UndoMode) (*UndoResult, error) {
+	task, err := s.db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if !task.WorktreePath.Valid || task.WorktreePath.String == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoWorktree, taskID)
+	}
+
+	return s.operations.UndoLastCommit(task.WorktreePath.String, UndoCommitOptions{
+		Mode:       mode,
+		ProtectRef: task.BaseBranch,
+	})
+}
+
 // ListWorktrees returns all worktrees for a project
 func (s *Service) ListWorktrees(projectPath string) ([]WorktreeInfo, error) {
 	return s.worktrees.List(projectPath)
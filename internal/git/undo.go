@@ -0,0 +1,122 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UndoMode selects how the last commit is undone
+type UndoMode string
+
+const (
+	// UndoSoft drops the commit but keeps its changes staged (git reset --soft HEAD~1)
+	UndoSoft UndoMode = "soft"
+	// UndoRevert adds a commit reversing it, leaving history intact (git revert HEAD)
+	UndoRevert UndoMode = "revert"
+)
+
+// ErrUndoRefused is returned when the last commit can't be undone safely
+var ErrUndoRefused = errors.New("undo refused")
+
+// IsValidUndoMode reports whether mode is a known undo mode
+func IsValidUndoMode(mode string) bool {
+	return mode == string(UndoSoft) || mode == string(UndoRevert)
+}
+
+// UndoCommitOptions configures undoing the last commit
+type UndoCommitOptions struct {
+	Mode UndoMode // How to undo (default: UndoSoft)
+	// ProtectRef refuses a soft reset when the last commit is already on this
+	// ref (e.g. the task's base branch), so only the branch's own commits can be dropped
+	ProtectRef string
+}
+
+// UndoResult describes an undone commit
+type UndoResult struct {
+	Mode         UndoMode `json:"mode"`
+	UndoneCommit string   `json:"undone_commit"`
+	Subject      string   `json:"subject"`
+	Head         string   `json:"head"` // HEAD afterwards: the parent for soft, the revert commit for revert
+}
+
+// Summary describes the undo for the agent that asked for it
+func (r *UndoResult) Summary() string {
+	if r.Mode == UndoRevert {
+		return fmt.Sprintf("Reverted commit %s (%q) with new commit %s", shortHash(r.UndoneCommit), r.Subject, shortHash(r.Head))
+	}
+	return fmt.Sprintf("Undid commit %s (%q); its changes are still staged. HEAD is now %s", shortHash(r.UndoneCommit), r.Subject, shortHash(r.Head))
+}
+
+// UndoLastCommit undoes the commit at HEAD. A soft reset rewrites history, so
+// it's refused for commits that were already pushed or are on ProtectRef; a
+// revert is always safe to push.
+func (o *Operations) UndoLastCommit(dir string, opts UndoCommitOptions) (*UndoResult, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = UndoSoft
+	}
+	if !IsValidUndoMode(string(mode)) {
+		return nil, fmt.Errorf("invalid undo mode %q (must be soft or revert)", mode)
+	}
+
+	// hash, parents, subject
+	out, err := runGit(dir, "log", "-1", "--format=%H%x00%P%x00%s")
+	if err != nil {
+		return nil, fmt.Errorf("no commit to undo: %w", err)
+	}
+	parts := strings.SplitN(strings.TrimSpace(out), "\x00", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected git log output: %q", out)
+	}
+	hash, parents, subject := parts[0], strings.Fields(parts[1]), parts[2]
+
+	switch mode {
+	case UndoSoft:
+		if len(parents) == 0 {
+			return nil, fmt.Errorf("%w: commit %s is the first commit in the repository and can't be reset", ErrUndoRefused, shortHash(hash))
+		}
+		if remotes, _ := runGit(dir, "branch", "-r", "--contains", hash); strings.TrimSpace(remotes) != "" {
+			return nil, fmt.Errorf("%w: commit %s was already pushed (%s); use revert mode instead",
+				ErrUndoRefused, shortHash(hash), strings.Join(strings.Fields(remotes), ", "))
+		}
+		if opts.ProtectRef != "" {
+			if _, err := runGit(dir, "merge-base", "--is-ancestor", hash, opts.ProtectRef); err == nil {
+				return nil, fmt.Errorf("%w: commit %s is already on %s and can't be reset", ErrUndoRefused, shortHash(hash), opts.ProtectRef)
+			}
+		}
+		if _, err := runGit(dir, "reset", "--soft", "HEAD~1"); err != nil {
+			return nil, fmt.Errorf("reset failed: %w", err)
+		}
+	case UndoRevert:
+		if len(parents) > 1 {
+			return nil, fmt.Errorf("%w: commit %s is a merge commit and can't be reverted automatically", ErrUndoRefused, shortHash(hash))
+		}
+		if _, err := runGit(dir, "revert", "--no-edit", hash); err != nil {
+			// Leave the worktree as it was rather than mid-revert
+			_, _ = runGit(dir, "revert", "--abort")
+			return nil, fmt.Errorf("revert failed: %w", err)
+		}
+	}
+
+	head, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	head = strings.TrimSpace(head)
+
+	return &UndoResult{
+		Mode:         mode,
+		UndoneCommit: hash,
+		Subject:      subject,
+		Head:         head,
+	}, nil
+}
+
+// shortHash abbreviates a commit hash for messages
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
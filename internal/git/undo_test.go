@@ -0,0 +1,107 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitOutput runs a git command in dir and returns its trimmed output
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(dir, args...)
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(out)
+}
+
+func TestUndoLastCommit_Soft(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	first := createCommit(t, repoPath, "first")
+	second := createCommit(t, repoPath, "second")
+
+	result, err := NewOperations().UndoLastCommit(repoPath, UndoCommitOptions{})
+	if err != nil {
+		t.Fatalf("UndoLastCommit failed: %v", err)
+	}
+	if result.Mode != UndoSoft || result.UndoneCommit != second || result.Subject != "second" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Head != first {
+		t.Errorf("HEAD = %s, want %s", result.Head, first)
+	}
+
+	// The undone commit's changes stay staged
+	if staged := gitOutput(t, repoPath, "diff", "--cached", "--name-only"); staged != "test.txt" {
+		t.Errorf("staged files = %q, want test.txt", staged)
+	}
+}
+
+func TestUndoLastCommit_Revert(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, repoPath, "first")
+	second := createCommit(t, repoPath, "second")
+
+	result, err := NewOperations().UndoLastCommit(repoPath, UndoCommitOptions{Mode: UndoRevert})
+	if err != nil {
+		t.Fatalf("UndoLastCommit failed: %v", err)
+	}
+	if result.UndoneCommit != second || result.Head == second {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	content, err := os.ReadFile(filepath.Join(repoPath, "test.txt"))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if string(content) != "first\n" {
+		t.Errorf("test.txt = %q, want the first commit's content", content)
+	}
+	if subject := gitOutput(t, repoPath, "log", "-1", "--format=%s"); !strings.HasPrefix(subject, "Revert") {
+		t.Errorf("HEAD subject = %q, want a revert commit", subject)
+	}
+}
+
+func TestUndoLastCommit_RefusesUnsafeSoftReset(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	ops := NewOperations()
+
+	// Nothing to reset to
+	createCommit(t, repoPath, "initial")
+	if _, err := ops.UndoLastCommit(repoPath, UndoCommitOptions{}); !errors.Is(err, ErrUndoRefused) {
+		t.Errorf("root commit: got %v, want ErrUndoRefused", err)
+	}
+
+	// Commit already on the protected base branch
+	gitOutput(t, repoPath, "branch", "base")
+	if _, err := ops.UndoLastCommit(repoPath, UndoCommitOptions{ProtectRef: "base"}); !errors.Is(err, ErrUndoRefused) {
+		t.Errorf("protected commit: got %v, want ErrUndoRefused", err)
+	}
+
+	// Commit already pushed
+	remote := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare", remote)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare failed: %s: %v", output, err)
+	}
+	createCommit(t, repoPath, "pushed")
+	gitOutput(t, repoPath, "remote", "add", "origin", remote)
+	gitOutput(t, repoPath, "push", "origin", "HEAD:refs/heads/feature")
+	if _, err := ops.UndoLastCommit(repoPath, UndoCommitOptions{}); !errors.Is(err, ErrUndoRefused) {
+		t.Errorf("pushed commit: got %v, want ErrUndoRefused", err)
+	}
+
+	// Reverting stays possible
+	if _, err := ops.UndoLastCommit(repoPath, UndoCommitOptions{Mode: UndoRevert}); err != nil {
+		t.Errorf("revert of pushed commit failed: %v", err)
+	}
+}
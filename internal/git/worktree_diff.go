@@ -0,0 +1,77 @@
+package git
+
+import (
+	"strings"
+)
+
+// MaxWorktreeDiffBytes caps the diff returned for a worktree; the file lists
+// are always complete
+const MaxWorktreeDiffBytes = 1 << 20
+
+// WorktreeDiff is a worktree's uncommitted changes
+type WorktreeDiff struct {
+	Status    *GitStatus `json:"status"`
+	Staged    bool       `json:"staged"`    // Only staged changes, rather than everything uncommitted
+	Files     []string   `json:"files"`     // Files the diff touches
+	Untracked []string   `json:"untracked"` // New files git doesn't track yet, so not in the diff
+	Diff      string     `json:"diff"`
+	Truncated bool       `json:"truncated"` // The diff was cut off at MaxWorktreeDiffBytes
+}
+
+// GetDiff returns the uncommitted changes in a worktree: all of them against
+// HEAD, or with staged only those staged for the next commit
+func (m *WorktreeManager) GetDiff(worktreePath string, staged bool) (*WorktreeDiff, error) {
+	status, err := m.GetStatus(worktreePath)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--cached")
+	} else {
+		args = append(args, "HEAD")
+	}
+
+	diff, err := runGit(worktreePath, args...)
+	if err != nil {
+		return nil, err
+	}
+	names, err := runGit(worktreePath, append(args, "--name-only")...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := runGit(worktreePath, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WorktreeDiff{
+		Status:    status,
+		Staged:    staged,
+		Files:     splitLines(names),
+		Untracked: splitLines(untracked),
+		Diff:      diff,
+	}
+	if len(result.Diff) > MaxWorktreeDiffBytes {
+		// Cut at a line boundary so the last hunk line isn't split
+		cut := result.Diff[:MaxWorktreeDiffBytes]
+		if i := strings.LastIndexByte(cut, '\n'); i >= 0 {
+			cut = cut[:i+1]
+		}
+		result.Diff = cut
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// splitLines returns the non-empty lines of s
+func splitLines(s string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWorktreeManager_GetDiff(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, repoPath, "first")
+
+	// One staged change, one unstaged, and a new untracked file
+	if err := os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("staged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, repoPath, "add", "test.txt")
+	if err := os.WriteFile(filepath.Join(repoPath, "other.txt"), []byte("tracked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, repoPath, "add", "other.txt")
+	gitOutput(t, repoPath, "commit", "-m", "second")
+	if err := os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("staged again\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, repoPath, "add", "test.txt")
+	if err := os.WriteFile(filepath.Join(repoPath, "other.txt"), []byte("unstaged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewWorktreeManager(t.TempDir())
+
+	all, err := m.GetDiff(repoPath, false)
+	if err != nil {
+		t.Fatalf("GetDiff failed: %v", err)
+	}
+	if !slices.Equal(all.Files, []string{"other.txt", "test.txt"}) {
+		t.Errorf("files = %v, want other.txt and test.txt", all.Files)
+	}
+	if !slices.Equal(all.Untracked, []string{"new.txt"}) {
+		t.Errorf("untracked = %v, want new.txt", all.Untracked)
+	}
+	if !strings.Contains(all.Diff, "+unstaged") || !strings.Contains(all.Diff, "+staged again") {
+		t.Errorf("diff is missing changes:\n%s", all.Diff)
+	}
+	if all.Status == nil || all.Status.StagedFiles != 1 || all.Status.ModifiedFiles != 1 {
+		t.Errorf("status = %+v, want one staged and one modified file", all.Status)
+	}
+
+	staged, err := m.GetDiff(repoPath, true)
+	if err != nil {
+		t.Fatalf("GetDiff(staged) failed: %v", err)
+	}
+	if !staged.Staged || !slices.Equal(staged.Files, []string{"test.txt"}) {
+		t.Errorf("staged files = %v, want test.txt", staged.Files)
+	}
+	if strings.Contains(staged.Diff, "+unstaged") {
+		t.Errorf("staged diff includes an unstaged change:\n%s", staged.Diff)
+	}
+}
@@ -186,6 +186,39 @@ func (c *Client) CreatePR(ctx context.Context, owner, repo string, opts gitprovi
 	return pr, nil
 }
 
+func (c *Client) GetPR(ctx context.Context, owner, repo string, number int) (*gitprovider.PullRequest, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d", owner, repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("get PR: %w", err)
+	}
+	return parsePR(resp)
+}
+
+func (c *Client) ListPRReviews(ctx context.Context, owner, repo string, number int) ([]*gitprovider.Review, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews", owner, repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("list PR reviews: %w", err)
+	}
+
+	reviews, err := parseReviews(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Inline comments are fetched per review
+	for _, review := range reviews {
+		resp, err := c.get(ctx, fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews/%d/comments", owner, repo, number, review.ID))
+		if err != nil {
+			return nil, fmt.Errorf("list review %d comments: %w", review.ID, err)
+		}
+		if review.Comments, err = parseReviewComments(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return reviews, nil
+}
+
 func (c *Client) MergePR(ctx context.Context, owner, repo string, number int, method gitprovider.MergeMethod) error {
 	body := map[string]interface{}{
 		"Do": string(method),
@@ -338,7 +371,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	_, _ = respBuf.ReadFrom(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, respBuf.String())
+		return nil, &gitprovider.HTTPError{Method: method, Path: path, StatusCode: resp.StatusCode, Body: respBuf.String()}
 	}
 
 	return respBuf.Bytes(), nil
@@ -406,26 +439,96 @@ func parseComment(data []byte) (*gitprovider.Comment, error) {
 
 func parsePR(data []byte) (*gitprovider.PullRequest, error) {
 	var raw struct {
-		Number    int64     `json:"number"`
-		Title     string    `json:"title"`
-		Body      string    `json:"body"`
-		State     string    `json:"state"`
-		HTMLURL   string    `json:"html_url"`
+		Number    int64                `json:"number"`
+		Title     string               `json:"title"`
+		Body      string               `json:"body"`
+		State     string               `json:"state"`
+		HTMLURL   string               `json:"html_url"`
+		Merged    bool                 `json:"merged"`
 		Head      struct{ Ref string } `json:"head"`
 		Base      struct{ Ref string } `json:"base"`
-		CreatedAt time.Time `json:"created_at"`
+		CreatedAt time.Time            `json:"created_at"`
 	}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, fmt.Errorf("parse PR response: %w", err)
 	}
+	// Forgejo reports merged PRs as closed with a merged flag
+	state := raw.State
+	if raw.Merged {
+		state = "merged"
+	}
 	return &gitprovider.PullRequest{
 		Number:    int(raw.Number),
 		Title:     raw.Title,
 		Body:      raw.Body,
-		State:     raw.State,
+		State:     state,
 		Head:      raw.Head.Ref,
 		Base:      raw.Base.Ref,
 		HTMLURL:   raw.HTMLURL,
 		CreatedAt: raw.CreatedAt,
 	}, nil
 }
+
+func parseReviews(data []byte) ([]*gitprovider.Review, error) {
+	var raw []struct {
+		ID          int64                  `json:"id"`
+		User        struct{ Login string } `json:"user"`
+		Body        string                 `json:"body"`
+		State       string                 `json:"state"`
+		Stale       bool                   `json:"stale"`
+		Dismissed   bool                   `json:"dismissed"`
+		SubmittedAt time.Time              `json:"submitted_at"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse reviews response: %w", err)
+	}
+	reviews := make([]*gitprovider.Review, len(raw))
+	for i, r := range raw {
+		reviews[i] = &gitprovider.Review{
+			ID:          r.ID,
+			Author:      r.User.Login,
+			Body:        r.Body,
+			State:       r.State,
+			Stale:       r.Stale,
+			Dismissed:   r.Dismissed,
+			SubmittedAt: r.SubmittedAt,
+		}
+	}
+	return reviews, nil
+}
+
+func parseReviewComments(data []byte) ([]gitprovider.ReviewComment, error) {
+	var raw []struct {
+		ID               int64                  `json:"id"`
+		User             struct{ Login string } `json:"user"`
+		Body             string                 `json:"body"`
+		Path             string                 `json:"path"`
+		Position         int                    `json:"position"`
+		OriginalPosition int                    `json:"original_position"`
+		DiffHunk         string                 `json:"diff_hunk"`
+		Resolver         *struct{}              `json:"resolver"`
+		CreatedAt        time.Time              `json:"created_at"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse review comments response: %w", err)
+	}
+	comments := make([]gitprovider.ReviewComment, len(raw))
+	for i, r := range raw {
+		// Position is the line in the new file; comments on removed lines only have the original
+		line := r.Position
+		if line == 0 {
+			line = r.OriginalPosition
+		}
+		comments[i] = gitprovider.ReviewComment{
+			ID:        r.ID,
+			Author:    r.User.Login,
+			Body:      r.Body,
+			Path:      r.Path,
+			Line:      line,
+			DiffHunk:  r.DiffHunk,
+			Resolved:  r.Resolver != nil,
+			CreatedAt: r.CreatedAt,
+		}
+	}
+	return comments, nil
+}
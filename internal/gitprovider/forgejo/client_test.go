@@ -272,6 +272,70 @@ func TestClient_MergePR(t *testing.T) {
 	}
 }
 
+func TestClient_GetPR_Merged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/myorg/myrepo/pulls/3" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"number": 3, "state": "closed", "merged": true, "head": {"ref": "feature-branch"}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "test-token")
+	pr, err := c.GetPR(context.Background(), "myorg", "myrepo", 3)
+	if err != nil {
+		t.Fatalf("GetPR() error = %v", err)
+	}
+	if pr.State != "merged" {
+		t.Errorf("State = %q, want %q", pr.State, "merged")
+	}
+}
+
+func TestClient_ListPRReviews(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/myorg/myrepo/pulls/3/reviews":
+			_, _ = w.Write([]byte(`[
+				{"id": 11, "user": {"login": "alice"}, "body": "Needs error handling", "state": "REQUEST_CHANGES", "stale": false},
+				{"id": 12, "user": {"login": "bob"}, "body": "", "state": "APPROVED", "stale": true, "dismissed": true}
+			]`))
+		case "/api/v1/repos/myorg/myrepo/pulls/3/reviews/11/comments":
+			_, _ = w.Write([]byte(`[
+				{"id": 21, "user": {"login": "alice"}, "body": "Check the error", "path": "main.go", "position": 42, "resolver": null},
+				{"id": 22, "user": {"login": "alice"}, "body": "Removed too much", "path": "util.go", "position": 0, "original_position": 7, "resolver": {"login": "bob"}}
+			]`))
+		case "/api/v1/repos/myorg/myrepo/pulls/3/reviews/12/comments":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "test-token")
+	reviews, err := c.ListPRReviews(context.Background(), "myorg", "myrepo", 3)
+	if err != nil {
+		t.Fatalf("ListPRReviews() error = %v", err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("len(reviews) = %d, want 2", len(reviews))
+	}
+
+	review := reviews[0]
+	if review.Author != "alice" || review.State != "REQUEST_CHANGES" || len(review.Comments) != 2 {
+		t.Errorf("unexpected review: %+v", review)
+	}
+	if review.Comments[0].Path != "main.go" || review.Comments[0].Line != 42 || review.Comments[0].Resolved {
+		t.Errorf("unexpected comment: %+v", review.Comments[0])
+	}
+	if review.Comments[1].Line != 7 || !review.Comments[1].Resolved {
+		t.Errorf("expected original position and resolved flag: %+v", review.Comments[1])
+	}
+	if !reviews[1].Stale || !reviews[1].Dismissed {
+		t.Errorf("expected stale, dismissed review: %+v", reviews[1])
+	}
+}
+
 func TestClient_HTTPError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -34,6 +34,8 @@ type Provider interface {
 	// --- Pull Requests ---
 
 	CreatePR(ctx context.Context, owner, repo string, opts CreatePROpts) (*PullRequest, error)
+	GetPR(ctx context.Context, owner, repo string, number int) (*PullRequest, error)
+	ListPRReviews(ctx context.Context, owner, repo string, number int) ([]*Review, error)
 	MergePR(ctx context.Context, owner, repo string, number int, method MergeMethod) error
 
 	// --- Webhooks ---
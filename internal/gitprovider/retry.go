@@ -0,0 +1,79 @@
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Defaults for retrying git operations that talk to a remote
+const (
+	DefaultRetryAttempts = 3
+	DefaultRetryBackoff  = 2 * time.Second
+)
+
+// HTTPError is a provider API response with an error status.
+type HTTPError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s %s: HTTP %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// IsRetryable reports whether a provider API error is likely transient: a
+// network failure, a 5xx, or rate limiting. Other HTTP errors (bad request,
+// permissions, an existing PR) won't go away on their own.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryPolicy retries remote git operations that fail transiently. The delay
+// doubles after each failed attempt.
+type RetryPolicy struct {
+	Attempts int           // Total attempts (values below 1 mean one)
+	Backoff  time.Duration // Delay before the first retry
+}
+
+// DefaultRetryPolicy returns the policy used unless one is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{Attempts: DefaultRetryAttempts, Backoff: DefaultRetryBackoff}
+}
+
+// Do runs fn until it succeeds, fails with an error retryable rejects, runs out
+// of attempts, or ctx is done. The last error is returned.
+func (p RetryPolicy) Do(ctx context.Context, op string, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	attempts := max(p.Attempts, 1)
+	delay := p.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+
+		fmt.Printf("%s failed (attempt %d/%d), retrying in %v: %v\n", op, attempt, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,72 @@
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"server error", &HTTPError{StatusCode: 502}, true},
+		{"rate limited", &HTTPError{StatusCode: 429}, true},
+		{"conflict", &HTTPError{StatusCode: 409}, false},
+		{"forbidden", &HTTPError{StatusCode: 403}, false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("parse PR response"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	transient := &HTTPError{StatusCode: 503}
+
+	// Succeeds once the transient failures stop
+	calls := 0
+	err := policy.Do(context.Background(), "op", IsRetryable, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return transient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("got err %v after %d calls, want success after 3", err, calls)
+	}
+
+	// Gives up after the last attempt
+	calls = 0
+	err = policy.Do(context.Background(), "op", IsRetryable, func(ctx context.Context) error {
+		calls++
+		return transient
+	})
+	if !errors.Is(err, transient) || calls != 3 {
+		t.Errorf("got err %v after %d calls, want the transient error after 3", err, calls)
+	}
+
+	// Fatal errors aren't retried
+	calls = 0
+	fatal := &HTTPError{StatusCode: 422}
+	err = policy.Do(context.Background(), "op", IsRetryable, func(ctx context.Context) error {
+		calls++
+		return fatal
+	})
+	if !errors.Is(err, fatal) || calls != 1 {
+		t.Errorf("got err %v after %d calls, want the fatal error after 1", err, calls)
+	}
+}
@@ -41,6 +41,30 @@ type PullRequest struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Review is a review submitted on a pull request.
+type Review struct {
+	ID          int64           `json:"id"`
+	Author      string          `json:"author"`
+	Body        string          `json:"body"`
+	State       string          `json:"state"`     // "APPROVED", "REQUEST_CHANGES", "COMMENT", "PENDING"
+	Stale       bool            `json:"stale"`     // Submitted against an older commit
+	Dismissed   bool            `json:"dismissed"` // Dismissed by a maintainer
+	Comments    []ReviewComment `json:"comments,omitempty"`
+	SubmittedAt time.Time       `json:"submitted_at"`
+}
+
+// ReviewComment is an inline comment on a line of a pull request's diff.
+type ReviewComment struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Path      string    `json:"path"`
+	Line      int       `json:"line"` // Line in the new version of the file (0 if unknown)
+	DiffHunk  string    `json:"diff_hunk,omitempty"`
+	Resolved  bool      `json:"resolved"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // CreateRepoOpts contains options for creating a repository.
 type CreateRepoOpts struct {
 	Name          string `json:"name"`
@@ -0,0 +1,135 @@
+// Package markdown provides helpers for scanning Markdown written by the model.
+package markdown
+
+import "strings"
+
+// CodeMasker blanks out code in Markdown text one line at a time, tracking
+// whether a fenced code block is open across lines. The zero value is ready to use.
+type CodeMasker struct {
+	fence string // Opening fence of the current code block ("" = not in one)
+}
+
+// MaskLine returns line with code replaced by spaces. Fence lines and lines
+// inside a fenced block are blanked entirely; outside one, inline code spans
+// are. The result has the same length as line, so indexes into it are valid in line.
+func (m *CodeMasker) MaskLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+
+	if m.fence != "" {
+		if strings.HasPrefix(trimmed, m.fence) && strings.Trim(trimmed, m.fence[:1]) == "" {
+			m.fence = ""
+		}
+		return blank(line)
+	}
+
+	if fence := openingFence(trimmed); fence != "" {
+		m.fence = fence
+		return blank(line)
+	}
+
+	return maskInlineCode(line)
+}
+
+// Reset forgets any open code block
+func (m *CodeMasker) Reset() {
+	m.fence = ""
+}
+
+// MaskCode blanks out fenced code blocks and inline code spans in text. Byte
+// offsets and line breaks are preserved, so indexes into the result are valid in text.
+func MaskCode(text string) string {
+	if !strings.ContainsAny(text, "`~") {
+		return text
+	}
+
+	var m CodeMasker
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, line := range strings.SplitAfter(text, "\n") {
+		sb.WriteString(m.MaskLine(line))
+	}
+	return sb.String()
+}
+
+// openingFence returns the backtick or tilde run that opens a fenced code
+// block, or "" if the line doesn't open one
+func openingFence(trimmed string) string {
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == c {
+			n++
+		}
+		// Backtick fences can't have backticks in their info string
+		if n >= 3 && (c == '~' || !strings.Contains(trimmed[n:], "`")) {
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
+
+// maskInlineCode blanks out inline code spans - a run of backticks closed by
+// a run of the same length. Unclosed runs are left as they are.
+func maskInlineCode(line string) string {
+	if !strings.Contains(line, "`") {
+		return line
+	}
+
+	masked := []byte(line)
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+
+		n := backtickRun(line, i)
+		end := closingRun(line, i+n, n)
+		if end == -1 {
+			i += n
+			continue
+		}
+		for j := i; j < end; j++ {
+			if masked[j] != '\n' && masked[j] != '\r' {
+				masked[j] = ' '
+			}
+		}
+		i = end
+	}
+	return string(masked)
+}
+
+// backtickRun returns the length of the run of backticks starting at i
+func backtickRun(line string, i int) int {
+	n := 0
+	for i+n < len(line) && line[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+// closingRun returns the index just past the next run of exactly n backticks
+// at or after from, or -1 if there is none
+func closingRun(line string, from, n int) int {
+	for i := from; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(line, i)
+		if run == n {
+			return i + run
+		}
+		i += run
+	}
+	return -1
+}
+
+// blank replaces everything but line breaks with spaces
+func blank(line string) string {
+	masked := []byte(line)
+	for i, c := range masked {
+		if c != '\n' && c != '\r' {
+			masked[i] = ' '
+		}
+	}
+	return string(masked)
+}
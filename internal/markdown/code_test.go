@@ -0,0 +1,105 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		visible []string // Must survive masking
+		hidden  []string // Must be masked
+	}{
+		{
+			name:    "no code",
+			text:    "EVENT:task.complete",
+			visible: []string{"EVENT:task.complete"},
+		},
+		{
+			name:    "fenced block",
+			text:    "Example:\n```go\nlog(\"EVENT:task.complete\")\n```\nEVENT:implementation.done\n",
+			visible: []string{"Example:", "EVENT:implementation.done"},
+			hidden:  []string{"EVENT:task.complete", "```"},
+		},
+		{
+			name:    "tilde fence",
+			text:    "~~~\nCHECKLIST_DONE:item-1\n~~~\nafter",
+			visible: []string{"after"},
+			hidden:  []string{"CHECKLIST_DONE:item-1"},
+		},
+		{
+			name:    "longer closing fence required",
+			text:    "````\n```\nEVENT:task.complete\n````\nEVENT:resolved",
+			visible: []string{"EVENT:resolved"},
+			hidden:  []string{"EVENT:task.complete"},
+		},
+		{
+			name:    "unclosed fence hides the rest",
+			text:    "start\n```\nEVENT:task.complete",
+			visible: []string{"start"},
+			hidden:  []string{"EVENT:task.complete"},
+		},
+		{
+			name:    "inline code",
+			text:    "Emit `EVENT:task.complete` when done. EVENT:review.approved",
+			visible: []string{"Emit", "when done.", "EVENT:review.approved"},
+			hidden:  []string{"EVENT:task.complete"},
+		},
+		{
+			name:    "double backtick span",
+			text:    "Use ``a `EVENT:task.complete` b`` here",
+			visible: []string{"Use", "here"},
+			hidden:  []string{"EVENT:task.complete"},
+		},
+		{
+			name:    "unclosed backtick",
+			text:    "It's a ` stray EVENT:resolved",
+			visible: []string{"EVENT:resolved"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked := MaskCode(tt.text)
+			if len(masked) != len(tt.text) {
+				t.Fatalf("masked length %d, want %d", len(masked), len(tt.text))
+			}
+			if strings.Count(masked, "\n") != strings.Count(tt.text, "\n") {
+				t.Errorf("line breaks not preserved: %q", masked)
+			}
+			for _, s := range tt.visible {
+				if !strings.Contains(masked, s) {
+					t.Errorf("expected %q to be visible in %q", s, masked)
+				}
+			}
+			for _, s := range tt.hidden {
+				if strings.Contains(masked, s) {
+					t.Errorf("expected %q to be masked in %q", s, masked)
+				}
+			}
+		})
+	}
+}
+
+func TestCodeMasker_AcrossLines(t *testing.T) {
+	var m CodeMasker
+
+	lines := []string{"```", "CHECKLIST_DONE:item-1", "```", "CHECKLIST_DONE:item-2"}
+	var visible []string
+	for _, line := range lines {
+		if masked := m.MaskLine(line); strings.TrimSpace(masked) != "" {
+			visible = append(visible, masked)
+		}
+	}
+	if len(visible) != 1 || visible[0] != "CHECKLIST_DONE:item-2" {
+		t.Errorf("expected only the line after the block to be visible, got %q", visible)
+	}
+
+	m.MaskLine("```")
+	m.Reset()
+	if masked := m.MaskLine("CHECKLIST_DONE:item-3"); masked != "CHECKLIST_DONE:item-3" {
+		t.Errorf("expected Reset to close the block, got %q", masked)
+	}
+}
@@ -3,7 +3,9 @@ package orchestrator
 
 import (
 	"container/heap"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,6 +16,22 @@ import (
 // Default maximum number of parallel sessions
 const DefaultMaxParallel = 25
 
+// ErrPaused is returned when work can't start because the scheduler is paused
+var ErrPaused = errors.New("scheduler is paused")
+
+// ErrRepoBusy is returned when a task can't start because its repo already
+// has as many running tasks as it allows
+var ErrRepoBusy = errors.New("repo is at its concurrent task limit")
+
+// ErrQuestBusy is returned when a task can't start because its quest already
+// has as many running tasks as it allows
+var ErrQuestBusy = errors.New("quest is at its concurrent objective limit")
+
+// IsQueued reports whether err means a task was queued rather than started
+func IsQueued(err error) bool {
+	return errors.Is(err, ErrPaused) || errors.Is(err, ErrRepoBusy) || errors.Is(err, ErrQuestBusy)
+}
+
 // QueuedTask represents a task waiting in the priority queue
 type QueuedTask struct {
 	TaskID    string
@@ -78,6 +96,8 @@ type Scheduler struct {
 	running     map[string]*RunningTask // Currently running tasks keyed by TaskID
 	taskIndex   map[string]int          // Maps TaskID to queue index for O(1) lookup
 	maxParallel int                     // Max concurrent (default 25)
+	paused      bool                    // No new tasks start while paused
+	pausedAt    time.Time
 }
 
 // NewScheduler creates a scheduler with max parallel limit
@@ -99,14 +119,15 @@ func NewScheduler(database *db.DB, taskService *task.Service, maxParallel int) *
 	}
 }
 
-// Enqueue adds a ready task to the queue
+// Enqueue adds a ready task to the queue. A task that is already queued keeps
+// its place.
 func (s *Scheduler) Enqueue(taskID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Check if already in queue
 	if _, exists := s.taskIndex[taskID]; exists {
-		return fmt.Errorf("task %s already in queue", taskID)
+		return nil
 	}
 
 	// Check if already running
@@ -173,14 +194,43 @@ func (s *Scheduler) rebuildIndex() {
 	}
 }
 
-// Next returns the next task to run, or nil if none ready or at capacity
+// Pause stops new tasks from starting; running tasks are left alone and
+// queued tasks wait until Resume
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		s.paused = true
+		s.pausedAt = time.Now()
+	}
+}
+
+// Resume lets queued and new tasks start again
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = false
+	s.pausedAt = time.Time{}
+}
+
+// IsPaused reports whether the scheduler is paused, and since when
+func (s *Scheduler) IsPaused() (bool, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused, s.pausedAt
+}
+
+// Next returns the next task to run, or nil if none ready, at capacity, or paused
 // Also handles preemption if high-priority task is waiting
 // Returns (toRun, toPauseID) where toPauseID is set if preemption is needed
 func (s *Scheduler) Next() (*QueuedTask, *string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.readyQueue.Len() == 0 {
+	if s.paused || s.readyQueue.Len() == 0 {
 		return nil, nil
 	}
 
@@ -206,6 +256,50 @@ func (s *Scheduler) Next() (*QueuedTask, *string) {
 	return nil, nil
 }
 
+// NextStartable removes and returns the highest priority queued task that
+// canStart accepts, or nil if there is none or the scheduler is paused.
+// Tasks canStart rejects stay queued in order. canStart is called without the
+// scheduler's lock held, so it may query the database or the scheduler.
+func (s *Scheduler) NextStartable(canStart func(taskID string) bool) *QueuedTask {
+	for _, item := range s.orderedQueue() {
+		if !canStart(item.TaskID) {
+			continue
+		}
+
+		// The queue may have changed while canStart ran
+		s.mu.Lock()
+		if s.paused {
+			s.mu.Unlock()
+			return nil
+		}
+		_, queued := s.taskIndex[item.TaskID]
+		if queued {
+			s.dequeueLocked(item.TaskID)
+		}
+		s.mu.Unlock()
+		if queued {
+			return item
+		}
+	}
+	return nil
+}
+
+// orderedQueue returns the queued tasks in priority order, or nil while paused
+func (s *Scheduler) orderedQueue() PriorityQueue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused || s.readyQueue.Len() == 0 {
+		return nil
+	}
+
+	// The heap is only partially ordered, so sort a copy
+	ordered := make(PriorityQueue, s.readyQueue.Len())
+	copy(ordered, *s.readyQueue)
+	sort.Slice(ordered, ordered.Less)
+	return ordered
+}
+
 // MarkRunning moves a task from ready queue to running map
 func (s *Scheduler) MarkRunning(taskID string) error {
 	s.mu.Lock()
@@ -0,0 +1,184 @@
+package orchestrator
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func setupTestScheduler(t *testing.T) (*Scheduler, *db.DB) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return NewScheduler(database, nil, 2), database
+}
+
+// createReadyTask creates a ready task with the given priority and returns its ID
+func createReadyTask(t *testing.T, database *db.DB, projectID string, priority int) string {
+	t.Helper()
+	task, err := database.CreateTask(projectID, "Task", db.TaskTypeTask, priority)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateTaskStatus(task.ID, db.TaskStatusReady); err != nil {
+		t.Fatal(err)
+	}
+	return task.ID
+}
+
+func TestScheduler_PauseAndResume(t *testing.T) {
+	s, database := setupTestScheduler(t)
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	low := createReadyTask(t, database, project.ID, 4)
+	high := createReadyTask(t, database, project.ID, 1)
+
+	s.Pause()
+	paused, pausedAt := s.IsPaused()
+	if !paused || pausedAt.IsZero() {
+		t.Fatalf("IsPaused() = %v, %v, want paused with a time", paused, pausedAt)
+	}
+
+	// Tasks still queue while paused, but none is handed out
+	for _, id := range []string{low, high} {
+		if err := s.Enqueue(id); err != nil {
+			t.Fatalf("Enqueue(%s) while paused error = %v", id, err)
+		}
+	}
+	if item := s.NextStartable(func(string) bool { return true }); item != nil {
+		t.Errorf("NextStartable() while paused = %s, want nil", item.TaskID)
+	}
+	if item, _ := s.Next(); item != nil {
+		t.Errorf("Next() while paused = %s, want nil", item.TaskID)
+	}
+	if s.QueueSize() != 2 {
+		t.Errorf("QueueSize() = %d, want 2", s.QueueSize())
+	}
+
+	// Resuming drains the queue, highest priority first
+	s.Resume()
+	if paused, pausedAt := s.IsPaused(); paused || !pausedAt.IsZero() {
+		t.Errorf("IsPaused() after Resume = %v, %v", paused, pausedAt)
+	}
+	var order []string
+	for item := s.NextStartable(func(string) bool { return true }); item != nil; item = s.NextStartable(func(string) bool { return true }) {
+		order = append(order, item.TaskID)
+	}
+	if len(order) != 2 || order[0] != high || order[1] != low {
+		t.Errorf("drained %v, want [%s %s]", order, high, low)
+	}
+	if s.QueueSize() != 0 {
+		t.Errorf("QueueSize() after draining = %d, want 0", s.QueueSize())
+	}
+}
+
+func TestScheduler_NextStartableSkipsRejected(t *testing.T) {
+	s, database := setupTestScheduler(t)
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy := createReadyTask(t, database, project.ID, 1)
+	free := createReadyTask(t, database, project.ID, 3)
+	for _, id := range []string{busy, free} {
+		if err := s.Enqueue(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// canStart may call back into the scheduler
+	item := s.NextStartable(func(taskID string) bool {
+		return s.IsQueued(taskID) && taskID != busy
+	})
+	if item == nil || item.TaskID != free {
+		t.Fatalf("NextStartable() = %v, want %s", item, free)
+	}
+	if !s.IsQueued(busy) || s.IsQueued(free) {
+		t.Error("expected the rejected task to stay queued and the started one to leave")
+	}
+	if item := s.NextStartable(func(taskID string) bool { return taskID != busy }); item != nil {
+		t.Errorf("NextStartable() = %s, want nil with only rejected tasks queued", item.TaskID)
+	}
+}
+
+func TestScheduler_EnqueueTwiceKeepsPlace(t *testing.T) {
+	s, database := setupTestScheduler(t)
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := createReadyTask(t, database, project.ID, 2)
+	second := createReadyTask(t, database, project.ID, 2)
+
+	for _, id := range []string{first, second, first} {
+		if err := s.Enqueue(id); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+	}
+	if s.QueueSize() != 2 {
+		t.Fatalf("QueueSize() = %d, want 2", s.QueueSize())
+	}
+	if item := s.NextStartable(func(string) bool { return true }); item == nil || item.TaskID != first {
+		t.Errorf("NextStartable() = %v, want %s still first", item, first)
+	}
+}
+
+func TestScheduler_NextStartableConcurrent(t *testing.T) {
+	s, database := setupTestScheduler(t)
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const tasks = 10
+	for range tasks {
+		if err := s.Enqueue(createReadyTask(t, database, project.ID, 3)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each queued task is handed out exactly once, however many drain at once
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = map[string]int{}
+	)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item := s.NextStartable(func(taskID string) bool {
+					task, err := database.GetTaskByID(taskID)
+					return err == nil && task != nil
+				})
+				if item == nil {
+					return
+				}
+				mu.Lock()
+				seen[item.TaskID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != tasks {
+		t.Errorf("started %d tasks, want %d", len(seen), tasks)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("task %s handed out %d times", id, n)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package planning
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -19,6 +20,18 @@ type ParsedChecklist struct {
 	Optional []string
 }
 
+// ErrChecklistNotEditable is returned when the pending checklist of a planning
+// session that was skipped or is still processing a response is edited
+var ErrChecklistNotEditable = errors.New("pending checklist can't be edited in this planning state")
+
+// ErrNoPendingChecklist is returned when reclassifying items of a planning
+// session that has no pending checklist
+var ErrNoPendingChecklist = errors.New("planning session has no pending checklist")
+
+// ErrInvalidChecklistIndex is returned when a reclassified item's index isn't
+// a position in its list
+var ErrInvalidChecklistIndex = errors.New("invalid checklist index")
+
 const (
 	planningModelSonnet = "claude-sonnet-4-5-20250929"
 	planningModelOpus   = "claude-opus-4-5-20251101"
@@ -544,6 +557,124 @@ func (p *Planner) storePendingChecklist(sessionID string, checklist *ParsedCheck
 	return nil
 }
 
+// UpdatePendingChecklist replaces the planning session's pending checklist
+// with the user's edits. If the session was completed by a checklist, the
+// refined prompt is rebuilt from the edited items so the two stay in sync.
+func (p *Planner) UpdatePendingChecklist(sessionID string, checklist *ParsedChecklist) (*db.PlanningSession, error) {
+	session, err := p.db.GetPlanningSessionByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planning session: %w", err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("planning session not found: %s", sessionID)
+	}
+	if session.Status == db.PlanningStatusSkipped || session.Status == db.PlanningStatusProcessing {
+		return nil, ErrChecklistNotEditable
+	}
+
+	completedByChecklist := session.Status == db.PlanningStatusCompleted && session.GetPendingChecklist() != nil
+
+	if err := p.storePendingChecklist(session.ID, checklist); err != nil {
+		return nil, err
+	}
+	if completedByChecklist {
+		if err := p.db.CompletePlanningSession(session.ID, buildRefinedPromptFromChecklist(checklist)); err != nil {
+			return nil, fmt.Errorf("failed to update refined prompt: %w", err)
+		}
+	}
+
+	// Re-read so the caller sees the stored checklist and prompt
+	session, err = p.db.GetPlanningSessionByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planning session: %w", err)
+	}
+
+	if p.broadcaster != nil {
+		p.broadcaster.PublishTaskEvent(realtime.EventPlanningUpdated, session.TaskID, map[string]any{
+			"session_id":        session.ID,
+			"status":            session.Status,
+			"checklist_updated": true,
+		})
+	}
+
+	return session, nil
+}
+
+// ReclassifyPendingChecklist promotes optional items to must-haves and demotes
+// must-haves to optional, by their positions in the current pending checklist.
+// Moved items go to the end of their new list, keeping their relative order,
+// so the returned session's indices are the ones to accept with.
+func (p *Planner) ReclassifyPendingChecklist(sessionID string, promote, demote []int) (*db.PlanningSession, error) {
+	session, err := p.db.GetPlanningSessionByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planning session: %w", err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("planning session not found: %s", sessionID)
+	}
+	pending := session.GetPendingChecklist()
+	if pending == nil {
+		return nil, ErrNoPendingChecklist
+	}
+
+	checklist, err := reclassifyChecklist(&ParsedChecklist{MustHave: pending.MustHave, Optional: pending.Optional}, promote, demote)
+	if err != nil {
+		return nil, err
+	}
+	return p.UpdatePendingChecklist(sessionID, checklist)
+}
+
+// reclassifyChecklist moves the optional items at promote to the end of the
+// must-haves and the must-haves at demote to the end of the optional items
+func reclassifyChecklist(checklist *ParsedChecklist, promote, demote []int) (*ParsedChecklist, error) {
+	promoted, err := checklistIndexSet(promote, len(checklist.Optional), "optional")
+	if err != nil {
+		return nil, err
+	}
+	demoted, err := checklistIndexSet(demote, len(checklist.MustHave), "must-have")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ParsedChecklist{
+		MustHave: make([]string, 0, len(checklist.MustHave)+len(promoted)-len(demoted)),
+		Optional: make([]string, 0, len(checklist.Optional)+len(demoted)-len(promoted)),
+	}
+	for i, item := range checklist.MustHave {
+		if !demoted[i] {
+			result.MustHave = append(result.MustHave, item)
+		}
+	}
+	for i, item := range checklist.Optional {
+		if promoted[i] {
+			result.MustHave = append(result.MustHave, item)
+		} else {
+			result.Optional = append(result.Optional, item)
+		}
+	}
+	for i, item := range checklist.MustHave {
+		if demoted[i] {
+			result.Optional = append(result.Optional, item)
+		}
+	}
+	return result, nil
+}
+
+// checklistIndexSet checks that indices are distinct positions in a list of n items
+func checklistIndexSet(indices []int, n int, kind string) (map[int]bool, error) {
+	set := make(map[int]bool, len(indices))
+	for _, idx := range indices {
+		if idx < 0 || idx >= n {
+			return nil, fmt.Errorf("%w: %s index %d out of range (have %d)", ErrInvalidChecklistIndex, kind, idx, n)
+		}
+		if set[idx] {
+			return nil, fmt.Errorf("%w: duplicate %s index %d", ErrInvalidChecklistIndex, kind, idx)
+		}
+		set[idx] = true
+	}
+	return set, nil
+}
+
 // GetChecklistByTask retrieves the checklist for a task
 func (p *Planner) GetChecklistByTask(taskID string) (*db.TaskChecklist, []*db.ChecklistItem, error) {
 	checklist, err := p.db.GetChecklistByTaskID(taskID)
@@ -68,6 +68,7 @@ type Handler struct {
 	readOnlyTools []toolbelt.AnthropicTool
 	baseDir       string                // Base Dex directory (e.g., /opt/dex) for computing repo paths
 	sessions      *QuestSessionRegistry // Quest session registry for blocking tools
+	historyWindow int                   // Recent messages sent as-is; older ones are summarized (<= 0 sends all)
 }
 
 // NewHandler creates a new Quest handler
@@ -92,6 +93,7 @@ func NewHandler(database *db.DB, client *toolbelt.AnthropicClient, broadcaster *
 		toolSet:       toolSet,
 		readOnlyTools: readOnlyTools,
 		sessions:      NewQuestSessionRegistry(),
+		historyWindow: DefaultHistoryWindow,
 	}
 }
 
@@ -165,14 +167,9 @@ func (h *Handler) ProcessMessage(ctx context.Context, questID, content string) (
 		return nil, fmt.Errorf("failed to get quest messages: %w", err)
 	}
 
-	// Convert to Anthropic message format
-	anthropicMessages := make([]toolbelt.AnthropicMessage, len(messages))
-	for i, msg := range messages {
-		anthropicMessages[i] = toolbelt.AnthropicMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
-	}
+	// Convert to Anthropic message format, summarizing what's outside the history window
+	var usage questUsage
+	anthropicMessages := h.buildHistory(ctx, questID, messages, &usage)
 
 	// Select model based on quest settings
 	model := ModelSonnet
@@ -222,6 +219,7 @@ func (h *Handler) ProcessMessage(ctx context.Context, questID, content string) (
 		if err != nil {
 			return nil, fmt.Errorf("failed to get response from Dex: %w", err)
 		}
+		usage.add(quest.Model, response.Usage)
 
 		// Check if model wants to use tools
 		if response.HasToolUse() {
@@ -299,6 +297,11 @@ func (h *Handler) ProcessMessage(ctx context.Context, questID, content string) (
 		if err != nil {
 			return nil, fmt.Errorf("failed to store assistant response: %w", err)
 		}
+		if err := h.db.SetQuestMessageUsage(assistantMsg.ID, usage.inputTokens, usage.outputTokens, usage.dollars); err != nil {
+			fmt.Printf("warning: failed to record usage for quest message %s: %v\n", assistantMsg.ID, err)
+		} else {
+			assistantMsg.InputTokens, assistantMsg.OutputTokens, assistantMsg.DollarsUsed = usage.inputTokens, usage.outputTokens, usage.dollars
+		}
 
 		// Broadcast the assistant message
 		// Note: Questions and drafts are now handled via tools (ask_question, propose_objective)
@@ -306,12 +309,15 @@ func (h *Handler) ProcessMessage(ctx context.Context, questID, content string) (
 		if h.broadcaster != nil {
 			h.broadcaster.PublishQuestEvent(realtime.EventQuestMessage, questID, map[string]any{
 				"message": map[string]any{
-					"id":         assistantMsg.ID,
-					"quest_id":   assistantMsg.QuestID,
-					"role":       assistantMsg.Role,
-					"content":    assistantMsg.Content,
-					"tool_calls": allToolCalls,
-					"created_at": assistantMsg.CreatedAt,
+					"id":            assistantMsg.ID,
+					"quest_id":      assistantMsg.QuestID,
+					"role":          assistantMsg.Role,
+					"content":       assistantMsg.Content,
+					"tool_calls":    allToolCalls,
+					"input_tokens":  assistantMsg.InputTokens,
+					"output_tokens": assistantMsg.OutputTokens,
+					"dollars_used":  assistantMsg.DollarsUsed,
+					"created_at":    assistantMsg.CreatedAt,
 				},
 			})
 		}
@@ -0,0 +1,164 @@
+package quest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/session"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// DefaultHistoryWindow is how many of a quest's most recent messages are sent
+// to the model as-is; older ones are replaced by a summary
+const DefaultHistoryWindow = 40
+
+// historySummaryTimeout bounds the LLM call that summarizes older quest messages
+const historySummaryTimeout = 60 * time.Second
+
+// historyMessageLimit caps each message in the transcript given to the summary model
+const historyMessageLimit = 4000
+
+// historySummaryPrompt asks for a summary of the older part of a quest conversation
+const historySummaryPrompt = `Summarize this earlier part of a planning conversation between a user and Dex, an assistant that breaks work into objectives. The summary replaces these messages in Dex's context, so keep everything Dex needs to continue: the user's goals and constraints, decisions made, objectives proposed or created, open questions, and preferences the user stated. Drop pleasantries and anything superseded. Use short bullet points.
+%s
+%s`
+
+// questUsage adds up the tokens and dollars spent producing one quest reply
+type questUsage struct {
+	inputTokens  int64
+	outputTokens int64
+	dollars      float64
+}
+
+// add records a model call; model is "sonnet", "opus", or "haiku"
+func (u *questUsage) add(model string, usage toolbelt.AnthropicUsage) {
+	inputRate, outputRate := session.ModelRates(model)
+	u.inputTokens += int64(usage.InputTokens)
+	u.outputTokens += int64(usage.OutputTokens)
+	u.dollars += (float64(usage.InputTokens)*inputRate + float64(usage.OutputTokens)*outputRate) / 1_000_000
+}
+
+// SetHistoryWindow sets how many recent messages are sent as-is (0 keeps the
+// default, negative sends the full history)
+func (h *Handler) SetHistoryWindow(n int) {
+	if n != 0 {
+		h.historyWindow = n
+	}
+}
+
+// buildHistory converts the quest's messages for the model. Messages outside
+// the history window are replaced by a summary, kept on the quest and extended
+// as the conversation grows, which is prepended to the first recent message.
+func (h *Handler) buildHistory(ctx context.Context, questID string, messages []*db.QuestMessage, usage *questUsage) []toolbelt.AnthropicMessage {
+	cut := historyCut(messages, h.historyWindow)
+
+	history := make([]toolbelt.AnthropicMessage, 0, len(messages)-cut)
+	for _, msg := range messages[cut:] {
+		history = append(history, toolbelt.AnthropicMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	if cut == 0 {
+		return history
+	}
+
+	var preface string
+	if summary := h.historySummary(ctx, questID, messages[:cut], usage); summary != "" {
+		preface = fmt.Sprintf("[Summary of the %d earlier messages in this conversation]\n%s", cut, summary)
+	} else {
+		preface = fmt.Sprintf("[%d earlier messages in this conversation were left out]", cut)
+	}
+	history[0].Content = preface + "\n\n---\n\n" + messages[cut].Content
+	return history
+}
+
+// historyCut returns how many of the oldest messages fall outside the window.
+// The first message kept is always the user's, so roles still alternate.
+func historyCut(messages []*db.QuestMessage, window int) int {
+	if window <= 0 || len(messages) <= window {
+		return 0
+	}
+	cut := len(messages) - window
+	for cut < len(messages) && messages[cut].Role != "user" {
+		cut++
+	}
+	if cut == len(messages) {
+		return 0
+	}
+	return cut
+}
+
+// historySummary returns a summary of older, reusing the quest's stored
+// summary and only summarizing messages it doesn't cover yet
+func (h *Handler) historySummary(ctx context.Context, questID string, older []*db.QuestMessage, usage *questUsage) string {
+	stored, through, err := h.db.GetQuestHistorySummary(questID)
+	if err != nil {
+		fmt.Printf("quest history: warning - %v\n", err)
+		stored, through = "", 0
+	}
+	if stored != "" && through == len(older) {
+		return stored
+	}
+
+	// Extend the stored summary, unless history was rewritten under it
+	previous, from := "", 0
+	if stored != "" && through < len(older) {
+		previous, from = stored, through
+	}
+
+	summary, err := h.summarizeHistory(ctx, previous, older[from:], usage)
+	if err != nil {
+		fmt.Printf("quest history: warning - failed to summarize %d messages for quest %s: %v\n", len(older)-from, questID, err)
+		return ""
+	}
+	if err := h.db.SetQuestHistorySummary(questID, summary, len(older)); err != nil {
+		fmt.Printf("quest history: warning - %v\n", err)
+	}
+	return summary
+}
+
+// summarizeHistory asks the summary model to fold messages into the previous summary
+func (h *Handler) summarizeHistory(ctx context.Context, previous string, messages []*db.QuestMessage, usage *questUsage) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		speaker := "User"
+		if msg.Role == "assistant" {
+			speaker = "Dex"
+		}
+		content := msg.Content
+		if len(content) > historyMessageLimit {
+			content = content[:historyMessageLimit] + "... (truncated)"
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n\n", speaker, content)
+	}
+
+	var earlier string
+	if previous != "" {
+		earlier = fmt.Sprintf("\nSummary of the conversation before these messages, to fold into yours:\n%s\n", previous)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, historySummaryTimeout)
+	defer cancel()
+
+	resp, err := h.client.Chat(ctx, &toolbelt.AnthropicChatRequest{
+		Model:     session.SummaryModelHaiku,
+		MaxTokens: 1024,
+		Messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: fmt.Sprintf(historySummaryPrompt, earlier, transcript.String())},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("summary API call failed: %w", err)
+	}
+	usage.add("haiku", resp.Usage)
+
+	summary := strings.TrimSpace(resp.Text())
+	if summary == "" {
+		return "", fmt.Errorf("summary model returned no text")
+	}
+	return summary, nil
+}
@@ -0,0 +1,167 @@
+package quest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// maxPromotedMemories caps how many memories one quest can promote
+const maxPromotedMemories = 10
+
+// maxPromotedConfidence caps what a promoted memory starts at. Quest learnings
+// come from planning talk rather than working code, so they start no higher
+// than a memory an agent signals explicitly.
+const maxPromotedConfidence = db.InitialConfidenceExplicit
+
+// promoteMemoriesPrompt asks for the durable learnings in a quest conversation
+const promoteMemoriesPrompt = `You extract durable project knowledge from a planning conversation between a user and Dex, an AI orchestration assistant.
+
+Find what future tasks on this project should know: decisions and why they were made, constraints, conventions, architecture, dependencies and their quirks, pitfalls, and fixes. Skip anything specific to this conversation, such as greetings, task drafts, progress updates, or open questions.
+
+Respond with only a JSON array, at most %d entries, each:
+{"type": "<architecture|dependency|decision|constraint|pattern|convention|pitfall|fix>", "title": "<one line>", "content": "<the learning, self-contained>", "confidence": <0.1-1.0, how settled it is>}
+
+Respond with [] if there is nothing durable.`
+
+// promotedMemory is a learning as the model reports it
+type promotedMemory struct {
+	Type       string  `json:"type"`
+	Title      string  `json:"title"`
+	Content    string  `json:"content"`
+	Confidence float64 `json:"confidence"`
+}
+
+// PromoteMemories extracts durable learnings from a quest's conversation and
+// saves them as memories of the quest's project, so future tasks benefit from
+// what was settled while planning. Learnings the project already has a memory
+// titled for are skipped. Returns the memories created.
+func (h *Handler) PromoteMemories(ctx context.Context, questID string) ([]*db.Memory, error) {
+	if h.client == nil {
+		return nil, fmt.Errorf("anthropic client not configured")
+	}
+
+	quest, err := h.db.GetQuestByID(questID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest: %w", err)
+	}
+	if quest == nil {
+		return nil, fmt.Errorf("quest not found: %s", questID)
+	}
+
+	messages, err := h.db.GetQuestMessages(questID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&transcript, "## %s\n\n%s\n\n", msg.Role, msg.Content)
+	}
+
+	resp, err := h.client.Chat(ctx, &toolbelt.AnthropicChatRequest{
+		Model:     ModelSonnet,
+		MaxTokens: 4096,
+		System:    fmt.Sprintf(promoteMemoriesPrompt, maxPromotedMemories),
+		Messages: []toolbelt.AnthropicMessage{{
+			Role:    "user",
+			Content: transcript.String(),
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract learnings: %w", err)
+	}
+
+	learnings, err := parsePromotedMemories(resp.Text())
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := h.db.ListMemories(quest.ProjectID, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project memories: %w", err)
+	}
+	known := make(map[string]bool, len(existing))
+	for _, m := range existing {
+		known[strings.ToLower(m.Title)] = true
+	}
+
+	var created []*db.Memory
+	for _, learning := range learnings {
+		memory := learning.toMemory(quest.ProjectID, questID)
+		if memory == nil || known[strings.ToLower(memory.Title)] {
+			continue
+		}
+		if err := h.db.CreateMemory(memory); err != nil {
+			return created, fmt.Errorf("failed to save memory: %w", err)
+		}
+		known[strings.ToLower(memory.Title)] = true
+		created = append(created, memory)
+	}
+
+	return created, nil
+}
+
+// parsePromotedMemories parses the model's JSON array of learnings,
+// tolerating a code fence or prose around it
+func parsePromotedMemories(text string) ([]promotedMemory, error) {
+	start, end := strings.Index(text, "["), strings.LastIndex(text, "]")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("no learnings in response")
+	}
+
+	var learnings []promotedMemory
+	if err := json.Unmarshal([]byte(text[start:end+1]), &learnings); err != nil {
+		return nil, fmt.Errorf("failed to parse learnings: %w", err)
+	}
+	if len(learnings) > maxPromotedMemories {
+		learnings = learnings[:maxPromotedMemories]
+	}
+	return learnings, nil
+}
+
+// toMemory converts a learning from a quest into a project memory tagged with
+// the quest, or nil if it's incomplete
+func (p promotedMemory) toMemory(projectID, questID string) *db.Memory {
+	if !db.IsValidMemoryType(p.Type) {
+		return nil
+	}
+
+	// Sanitize content to prevent prompt injection when memories are recalled
+	title := strings.TrimSpace(security.SanitizeForPrompt(p.Title))
+	content := strings.TrimSpace(security.SanitizeForPrompt(p.Content))
+	if title == "" || content == "" {
+		return nil
+	}
+	if len(title) > 100 {
+		title = title[:100] + "..."
+	}
+
+	confidence := db.InitialConfidenceAutomatic
+	if p.Confidence > 0 {
+		confidence = min(max(p.Confidence, db.MinConfidence), maxPromotedConfidence)
+	}
+
+	return &db.Memory{
+		ID:           uuid.New().String(),
+		ProjectID:    projectID,
+		Type:         db.MemoryType(p.Type),
+		Title:        title,
+		Content:      content,
+		Confidence:   confidence,
+		Tags:         []string{"quest:" + questID},
+		CreatedByHat: "quest",
+		Source:       db.SourceAutomatic,
+		CreatedAt:    time.Now(),
+	}
+}
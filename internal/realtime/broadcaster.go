@@ -37,6 +37,7 @@
 package realtime
 
 import (
+	"sync"
 	"time"
 )
 
@@ -45,6 +46,9 @@ import (
 // automatic channel routing based on event type and payload.
 type Broadcaster struct {
 	node *Node
+
+	mu   sync.Mutex
+	subs map[chan Event]EventFilter // In-process subscribers (see Subscribe)
 }
 
 // NewBroadcaster creates a new broadcaster
@@ -64,6 +68,7 @@ func (b *Broadcaster) Publish(eventType string, payload map[string]any) {
 	if b.node != nil {
 		_ = b.node.Publish(eventType, payload)
 	}
+	b.notify(eventType, payload)
 }
 
 // PublishTaskEvent publishes a task-related event
@@ -122,6 +127,33 @@ func (b *Broadcaster) PublishWorkerFailed(objectiveID string, payload map[string
 	b.Publish(EventWorkerFailed, payload)
 }
 
+// PublishWorkerTimedOut publishes an objective timeout event
+func (b *Broadcaster) PublishWorkerTimedOut(objectiveID string, payload map[string]any) {
+	if payload == nil {
+		payload = make(map[string]any)
+	}
+	payload["objective_id"] = objectiveID
+	b.Publish(EventWorkerTimedOut, payload)
+}
+
+// PublishWorkerRejected publishes an objective rejected for unmet acceptance criteria
+func (b *Broadcaster) PublishWorkerRejected(objectiveID string, payload map[string]any) {
+	if payload == nil {
+		payload = make(map[string]any)
+	}
+	payload["objective_id"] = objectiveID
+	b.Publish(EventWorkerRejected, payload)
+}
+
+// PublishWorkerLog publishes a batch of log lines streamed from a worker
+func (b *Broadcaster) PublishWorkerLog(workerID string, payload map[string]any) {
+	if payload == nil {
+		payload = make(map[string]any)
+	}
+	payload["worker_id"] = workerID
+	b.Publish(EventWorkerLog, payload)
+}
+
 // Event types as constants for consistency.
 //
 // Events are published to channels based on their prefix:
@@ -129,6 +161,7 @@ func (b *Broadcaster) PublishWorkerFailed(objectiveID string, payload map[string
 //   - quest.* → quest channel
 //   - approval.* → system + user + project + task channels
 //   - hat.* → task + project channels
+//   - toolbelt.* → system channel
 //   - All events also go to the global channel
 const (
 	// Task events - published to task:<id> and project:<id> channels
@@ -147,6 +180,14 @@ const (
 	EventSessionIteration = "session.iteration"
 	EventSessionCompleted = "session.completed"
 
+	// EventSessionToolOutput carries output of a command a session's tool is
+	// running, such as a build, as it's produced
+	EventSessionToolOutput = "session.tool_output"
+
+	// EventSessionBudgetWarning is published when a session passes a warning
+	// threshold of its token or dollar budget
+	EventSessionBudgetWarning = "session.budget_warning"
+
 	// Activity events - published to task:<id> channel
 	EventActivityNew = "activity.new"
 
@@ -199,4 +240,10 @@ const (
 	EventWorkerProgress  = "worker.progress"
 	EventWorkerCompleted = "worker.completed"
 	EventWorkerFailed    = "worker.failed"
+	EventWorkerTimedOut  = "worker.timed_out"
+	EventWorkerLog       = "worker.log"
+	EventWorkerRejected  = "worker.rejected"
+
+	// Toolbelt events - published to system channel
+	EventToolbeltStatus = "toolbelt.status"
 )
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -20,8 +21,8 @@ import (
 //   - Latency measurement via ping RPC
 //
 // Connection Flow:
-//  1. Client connects via WebSocket with JWT token in Centrifuge protocol
-//  2. OnConnecting validates token and sets credentials
+//  1. Client upgrades to WebSocket with its JWT as a ?token= query param (or Bearer header)
+//  2. OnConnecting validates the token (or one sent in the Centrifuge protocol) and sets credentials
 //  3. OnConnecting auto-subscribes to user:<userID> channel
 //  4. Client can then subscribe to additional channels (task:, quest:, etc.)
 //
@@ -31,6 +32,7 @@ type Node struct {
 	historySize    int
 	historyTTL     time.Duration
 	tokenValidator TokenValidator
+	allowedOrigins []string
 }
 
 // Config holds configuration for the realtime node
@@ -45,6 +47,9 @@ type Config struct {
 	HistoryTTL time.Duration
 	// TokenValidator validates JWT tokens during connection. If nil, anonymous access is allowed.
 	TokenValidator TokenValidator
+	// AllowedOrigins are other origins allowed to open WebSocket connections
+	// ("*" allows any). Same-origin connections are always allowed.
+	AllowedOrigins []string
 }
 
 // NewNode creates a new Centrifuge node with the given configuration
@@ -76,6 +81,7 @@ func NewNode(cfg Config) (*Node, error) {
 		historySize:    cfg.HistorySize,
 		historyTTL:     cfg.HistoryTTL,
 		tokenValidator: cfg.TokenValidator,
+		allowedOrigins: cfg.AllowedOrigins,
 	}
 	n.setupHandlers()
 
@@ -90,18 +96,24 @@ func (n *Node) setupHandlers() {
 		var userID string
 
 		if n.tokenValidator != nil {
+			// A client that authenticated its WebSocket upgrade needn't send a token again
+			upgradeCred, upgradeAuthed := centrifuge.GetCredentials(ctx)
+
 			// Validate the token sent by client
-			if e.Token == "" {
+			switch {
+			case e.Token != "":
+				user, err := n.tokenValidator.ValidateToken(ctx, e.Token)
+				if err != nil {
+					fmt.Printf("[Realtime] Invalid token: %v\n", err)
+					return centrifuge.ConnectReply{}, centrifuge.ErrorUnauthorized
+				}
+				userID = user.ID
+			case upgradeAuthed:
+				userID = upgradeCred.UserID
+			default:
 				fmt.Printf("[Realtime] Client connecting without token\n")
 				return centrifuge.ConnectReply{}, centrifuge.ErrorUnauthorized
 			}
-
-			user, err := n.tokenValidator.ValidateToken(ctx, e.Token)
-			if err != nil {
-				fmt.Printf("[Realtime] Invalid token: %v\n", err)
-				return centrifuge.ConnectReply{}, centrifuge.ErrorUnauthorized
-			}
-			userID = user.ID
 		} else {
 			// No validator configured - allow anonymous access (development mode)
 			userID = "anonymous"
@@ -175,12 +187,43 @@ func (n *Node) Shutdown(ctx context.Context) error {
 	return n.node.Shutdown(ctx)
 }
 
-// WebSocketHandler returns an HTTP handler for WebSocket connections
+// WebSocketHandler returns an HTTP handler for WebSocket connections.
+// When a token validator is configured, the upgrade request itself must carry
+// a valid JWT, as a Bearer Authorization header or a ?token= query param since
+// browsers can't set headers on WebSocket upgrades. Unauthenticated upgrades
+// are rejected with 401 before the connection is accepted.
 func (n *Node) WebSocketHandler() http.Handler {
-	return centrifuge.NewWebsocketHandler(n.node, centrifuge.WebsocketConfig{
+	wsConfig := centrifuge.WebsocketConfig{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
-	})
+	}
+	if len(n.allowedOrigins) > 0 {
+		wsConfig.CheckOrigin = n.checkOrigin
+	}
+	handler := centrifuge.NewWebsocketHandler(n.node, wsConfig)
+	if n.tokenValidator == nil {
+		return handler
+	}
+	return AuthMiddleware(n.tokenValidator)(handler)
+}
+
+// checkOrigin allows WebSocket upgrades from the same origin, from clients
+// that send no Origin (non-browser tools), and from the allowed origins
+func (n *Node) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range n.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	fmt.Printf("[Realtime] Rejected WebSocket upgrade from origin %s\n", origin)
+	return false
 }
 
 // Publish sends an event to the appropriate channel(s) with history for recovery
@@ -276,6 +319,10 @@ func routeEvent(eventType string, payload map[string]any) []string {
 			channels = append(channels, "task:"+taskID)
 		}
 
+	case strings.HasPrefix(eventType, "toolbelt."):
+		// Service health concerns the whole system
+		channels = append(channels, "system")
+
 	case strings.HasPrefix(eventType, "project."):
 		if projectID, ok := payload["project_id"].(string); ok && projectID != "" {
 			channels = append(channels, "project:"+projectID)
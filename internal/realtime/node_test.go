@@ -2,6 +2,9 @@ package realtime
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -156,6 +159,12 @@ func TestRouteEvent(t *testing.T) {
 			payload:   map[string]any{"user_id": "u-1", "project_id": "p-1", "task_id": "t-1"},
 			expected:  []string{"global", "system", "user:u-1", "project:p-1", "task:t-1"},
 		},
+		{
+			name:      "toolbelt event routes to global and system channels",
+			eventType: "toolbelt.status",
+			payload:   map[string]any{"changed": []string{"github"}},
+			expected:  []string{"global", "system"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -442,3 +451,76 @@ func TestRouteEventEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeWebSocketHandler_RejectsUnauthenticatedUpgrades(t *testing.T) {
+	node, err := NewNode(Config{
+		TokenValidator: &mockTokenValidator{user: &UserInfo{ID: "user-123"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if err := node.Run(); err != nil {
+		t.Fatalf("Failed to run node: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = node.Shutdown(ctx)
+	}()
+
+	req := httptest.NewRequest("GET", "/api/v1/realtime", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	rec := httptest.NewRecorder()
+
+	node.WebSocketHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an upgrade without a token, got %d", rec.Code)
+	}
+
+	node.tokenValidator = &mockTokenValidator{err: errors.New("invalid token")}
+	req = httptest.NewRequest("GET", "/api/v1/realtime?token=forged", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	rec = httptest.NewRecorder()
+
+	node.WebSocketHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an upgrade with an invalid token, got %d", rec.Code)
+	}
+}
+
+func TestNodeCheckOrigin(t *testing.T) {
+	node := &Node{allowedOrigins: []string{"https://dash.example.com/"}}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "", true},
+		{"same origin", "https://hq.example.com", true},
+		{"allowed origin", "https://dash.example.com", true},
+		{"other origin", "https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "https://hq.example.com/api/v1/realtime", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := node.checkOrigin(req); got != tt.want {
+				t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+
+	node.allowedOrigins = []string{"*"}
+	req := httptest.NewRequest("GET", "https://hq.example.com/api/v1/realtime", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	if !node.checkOrigin(req) {
+		t.Error("expected * to allow any origin")
+	}
+}
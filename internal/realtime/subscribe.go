@@ -0,0 +1,66 @@
+package realtime
+
+import "maps"
+
+// subscriberBuffer is how many events an in-process subscriber may fall
+// behind by before further events are dropped for it
+const subscriberBuffer = 256
+
+// Event is a published event as seen by in-process subscribers
+type Event struct {
+	Type    string         `json:"type"`
+	Payload map[string]any `json:"payload"`
+}
+
+// EventFilter selects which events a subscriber receives
+type EventFilter func(eventType string, payload map[string]any) bool
+
+// Subscribe returns a channel receiving every event published from now on that
+// matches filter (all events if nil). A subscriber that falls behind misses
+// events rather than slowing publishers. Call cancel to stop receiving.
+func (b *Broadcaster) Subscribe(filter EventFilter) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[chan Event]EventFilter)
+	}
+	b.subs[ch] = filter
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+// notify delivers a published event to matching in-process subscribers
+func (b *Broadcaster) notify(eventType string, payload map[string]any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, filter := range b.subs {
+		if filter != nil && !filter(eventType, payload) {
+			continue
+		}
+		// Each subscriber gets its own copy so none can race with another
+		select {
+		case ch <- Event{Type: eventType, Payload: maps.Clone(payload)}:
+		default:
+		}
+	}
+}
+
+// TaskEvents matches the events about one task: its lifecycle, sessions,
+// activity, approvals, and hat transitions, plus worker events for it, which
+// carry the task ID as their objective ID
+func TaskEvents(taskID string) EventFilter {
+	return func(eventType string, payload map[string]any) bool {
+		if id, ok := payload["task_id"].(string); ok && id == taskID {
+			return true
+		}
+		id, ok := payload["objective_id"].(string)
+		return ok && id == taskID
+	}
+}
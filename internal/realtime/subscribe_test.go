@@ -0,0 +1,46 @@
+package realtime
+
+import "testing"
+
+func TestBroadcasterSubscribe(t *testing.T) {
+	b := NewBroadcaster(nil)
+
+	events, cancel := b.Subscribe(TaskEvents("task-1"))
+	all, cancelAll := b.Subscribe(nil)
+	defer cancelAll()
+
+	b.PublishTaskEvent(EventTaskUpdated, "task-1", map[string]any{"status": "running"})
+	b.PublishTaskEvent(EventTaskUpdated, "task-2", map[string]any{"status": "running"})
+	b.PublishWorkerCompletion("task-1", map[string]any{"status": "completed"})
+
+	var got []string
+	for len(events) > 0 {
+		event := <-events
+		got = append(got, event.Type)
+		if event.Payload["timestamp"] == nil {
+			t.Errorf("%s event has no timestamp", event.Type)
+		}
+	}
+	if len(got) != 2 || got[0] != EventTaskUpdated || got[1] != EventWorkerCompleted {
+		t.Errorf("task-1 subscriber got %v, want its task update and worker completion", got)
+	}
+	if len(all) != 3 {
+		t.Errorf("unfiltered subscriber got %d events, want 3", len(all))
+	}
+	for len(all) > 0 {
+		<-all
+	}
+
+	// Each subscriber gets its own copy of the payload
+	b.PublishTaskEvent(EventTaskUpdated, "task-1", map[string]any{"status": "paused"})
+	(<-events).Payload["status"] = "changed"
+	if event := <-all; event.Payload["status"] != "paused" {
+		t.Errorf("status = %v, want paused unaffected by another subscriber", event.Payload["status"])
+	}
+
+	cancel()
+	b.PublishTaskEvent(EventTaskUpdated, "task-1", nil)
+	if len(events) != 0 {
+		t.Error("cancelled subscriber still receives events")
+	}
+}
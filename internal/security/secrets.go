@@ -0,0 +1,233 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretPattern is a named regular expression that matches a kind of credential
+type SecretPattern struct {
+	Name  string
+	Regex *regexp.Regexp
+}
+
+// defaultSecretPatterns match common credentials - compiled once at package init
+var defaultSecretPatterns = []SecretPattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"anthropic-api-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{"openai-api-key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9]{32,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`)},
+	{"stripe-secret-key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"generic-secret", regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|token|passw(?:or)?d)["']?\s*[:=]\s*["'][A-Za-z0-9/+=_\-]{20,}["']`)},
+}
+
+// DefaultSecretPatterns returns the built-in credential patterns
+func DefaultSecretPatterns() []SecretPattern {
+	return append([]SecretPattern(nil), defaultSecretPatterns...)
+}
+
+// SecretFinding is a likely credential on an added line of a diff
+type SecretFinding struct {
+	File    string
+	Line    int    // Line number in the new version of the file
+	Pattern string // Name of the pattern that matched
+	Match   string // Redacted match, safe to show in logs and prompts
+}
+
+// String formats the finding as file:line feedback
+func (f SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.File, f.Line, f.Pattern, f.Match)
+}
+
+// SecretScanner detects credentials in diffs before they are committed
+type SecretScanner struct {
+	patterns  []SecretPattern
+	allowlist []string // Path globs that are never scanned, e.g. test fixtures
+}
+
+// NewSecretScanner creates a scanner. Allowlist entries are slash-separated
+// path globs; "**" matches any number of directories.
+func NewSecretScanner(patterns []SecretPattern, allowlist []string) *SecretScanner {
+	return &SecretScanner{patterns: patterns, allowlist: allowlist}
+}
+
+// SecretScanConfig is the on-disk configuration for secret scanning
+type SecretScanConfig struct {
+	// DisableDefaults drops the built-in patterns, leaving only Patterns
+	DisableDefaults bool `yaml:"disable_defaults"`
+
+	// Patterns are additional named regular expressions
+	Patterns []SecretPatternConfig `yaml:"patterns"`
+
+	// Allowlist are path globs excluded from scanning (e.g. "testdata/**")
+	Allowlist []string `yaml:"allowlist"`
+}
+
+// SecretPatternConfig is an uncompiled SecretPattern
+type SecretPatternConfig struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// LoadSecretScanner builds a scanner from a YAML config file
+func LoadSecretScanner(configPath string) (*SecretScanner, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret scan config: %w", err)
+	}
+
+	var config SecretScanConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse secret scan config: %w", err)
+	}
+	return config.Scanner()
+}
+
+// Scanner compiles the config into a scanner
+func (c SecretScanConfig) Scanner() (*SecretScanner, error) {
+	var patterns []SecretPattern
+	if !c.DisableDefaults {
+		patterns = DefaultSecretPatterns()
+	}
+
+	for _, p := range c.Patterns {
+		if p.Name == "" || p.Regex == "" {
+			return nil, fmt.Errorf("secret patterns need a name and a regex")
+		}
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %s: %w", p.Name, err)
+		}
+		patterns = append(patterns, SecretPattern{Name: p.Name, Regex: re})
+	}
+
+	for _, glob := range c.Allowlist {
+		if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("invalid allowlist glob %q: %w", glob, err)
+		}
+	}
+
+	return NewSecretScanner(patterns, c.Allowlist), nil
+}
+
+// IsAllowlisted reports whether a file is excluded from scanning
+func (s *SecretScanner) IsAllowlisted(file string) bool {
+	for _, glob := range s.allowlist {
+		if matchGlob(strings.Split(glob, "/"), strings.Split(file, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanDiff scans the lines a unified diff adds. Removed and context lines are
+// ignored, as are files on the allowlist. At most one finding is reported per line.
+func (s *SecretScanner) ScanDiff(diff string) []SecretFinding {
+	var findings []SecretFinding
+	var file string
+	line := 0
+	inHeader := false // Between "diff --git" and the first hunk, where "+++" names the file
+
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git"):
+			inHeader, file = true, ""
+		case strings.HasPrefix(text, "@@"):
+			inHeader, line = false, hunkStart(text)
+		case inHeader:
+			if strings.HasPrefix(text, "+++ ") {
+				file = diffPath(strings.TrimPrefix(text, "+++ "))
+			}
+		case strings.HasPrefix(text, "+"):
+			if file != "" && !s.IsAllowlisted(file) {
+				if finding, ok := s.scanLine(text[1:]); ok {
+					finding.File, finding.Line = file, line
+					findings = append(findings, finding)
+				}
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// scanLine returns the first pattern that matches a line
+func (s *SecretScanner) scanLine(text string) (SecretFinding, bool) {
+	for _, p := range s.patterns {
+		if match := p.Regex.FindString(text); match != "" {
+			return SecretFinding{Pattern: p.Name, Match: redactSecret(match)}, true
+		}
+	}
+	return SecretFinding{}, false
+}
+
+// diffPath extracts the file path from a +++ header; deleted files have none
+func diffPath(header string) string {
+	header = strings.TrimSpace(header)
+	if unquoted, err := strconv.Unquote(header); err == nil {
+		header = unquoted
+	}
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// hunkStart returns the first new-file line number of a hunk header like
+// "@@ -10,4 +12,6 @@"
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, "+") {
+			start, _, _ := strings.Cut(f[1:], ",")
+			n, err := strconv.Atoi(start)
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// redactSecret keeps just enough of a match to locate it
+func redactSecret(match string) string {
+	const visible = 6
+	if len(match) <= visible {
+		return strings.Repeat("*", len(match))
+	}
+	return match[:visible] + strings.Repeat("*", min(len(match)-visible, 8))
+}
+
+// matchGlob matches path segments against glob segments, where a "**" segment
+// matches zero or more directories
+func matchGlob(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(glob[1:], segments[1:])
+}
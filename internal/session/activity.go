@@ -7,24 +7,29 @@ import (
 
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/security"
 )
 
 // ActivityRecorder records session activity to the database and broadcasts via WebSocket
 type ActivityRecorder struct {
-	db        *db.DB
-	sessionID string
-	taskID    string
-	hat       string
-	broadcast func(eventType string, payload map[string]any)
+	db             *db.DB
+	sessionID      string
+	taskID         string
+	hat            string
+	level          string // db.ActivityLevel*; debug logs are only recorded at debug level
+	broadcastLevel string // db.ActivityLevel*; only recorded events at this level are broadcast
+	broadcast      func(eventType string, payload map[string]any)
 }
 
 // NewActivityRecorder creates a new ActivityRecorder for a session
 func NewActivityRecorder(database *db.DB, sessionID, taskID string, broadcast func(eventType string, payload map[string]any)) *ActivityRecorder {
 	return &ActivityRecorder{
-		db:        database,
-		sessionID: sessionID,
-		taskID:    taskID,
-		broadcast: broadcast,
+		db:             database,
+		sessionID:      sessionID,
+		taskID:         taskID,
+		level:          db.ActivityLevelStandard,
+		broadcastLevel: db.ActivityLevelStandard,
+		broadcast:      broadcast,
 	}
 }
 
@@ -33,9 +38,31 @@ func (r *ActivityRecorder) SetHat(hat string) {
 	r.hat = hat
 }
 
+// SetLevel sets which events are recorded (db.ActivityLevelStandard or db.ActivityLevelDebug)
+func (r *ActivityRecorder) SetLevel(level string) {
+	r.level = level
+}
+
+// SetBroadcastLevel sets which recorded events are broadcast to clients
+// (db.ActivityLevelMinimal, db.ActivityLevelStandard, or db.ActivityLevelDebug)
+func (r *ActivityRecorder) SetBroadcastLevel(level string) {
+	r.broadcastLevel = level
+}
+
+// Records reports whether events of the given type are recorded at the current level
+func (r *ActivityRecorder) Records(eventType string) bool {
+	return db.ActivityLevelIncludes(r.level, eventType)
+}
+
+// Broadcasts reports whether events of the given type reach clients. Only
+// recorded events are broadcast, so this never includes more than Records.
+func (r *ActivityRecorder) Broadcasts(eventType string) bool {
+	return r.Records(eventType) && db.ActivityLevelIncludes(r.broadcastLevel, eventType)
+}
+
 // broadcastActivity sends an activity event through WebSocket
 func (r *ActivityRecorder) broadcastActivity(activity *db.SessionActivity) {
-	if r.broadcast == nil {
+	if r.broadcast == nil || !r.Broadcasts(activity.EventType) {
 		return
 	}
 
@@ -92,7 +119,7 @@ func (r *ActivityRecorder) RecordUserMessage(iteration int, content string) erro
 }
 
 // RecordAssistantResponse records Claude's response
-func (r *ActivityRecorder) RecordAssistantResponse(iteration int, content string, inputTokens, outputTokens int) error {
+func (r *ActivityRecorder) RecordAssistantResponse(iteration int, content string, inputTokens, outputTokens, thinkingTokens int) error {
 	activity, err := r.db.CreateSessionActivity(
 		r.sessionID,
 		iteration,
@@ -105,6 +132,11 @@ func (r *ActivityRecorder) RecordAssistantResponse(iteration int, content string
 	if err != nil {
 		return fmt.Errorf("failed to record assistant response: %w", err)
 	}
+	if thinkingTokens > 0 {
+		if err := r.db.SetActivityThinkingTokens(activity.ID, thinkingTokens); err != nil {
+			return err
+		}
+	}
 	r.broadcastActivity(activity)
 	return nil
 }
@@ -234,8 +266,13 @@ type DebugLogData struct {
 	Details    any    `json:"details,omitempty"`
 }
 
-// RecordDebugLog records a debug-level log entry
+// RecordDebugLog records a debug-level log entry. It's a no-op unless the
+// recorder is at debug level.
 func (r *ActivityRecorder) RecordDebugLog(iteration int, level, message string, durationMs int64, details any) error {
+	if !r.Records(db.ActivityTypeDebugLog) {
+		return nil
+	}
+
 	data := DebugLogData{
 		Level:      level,
 		Message:    message,
@@ -361,6 +398,19 @@ type CheckData struct {
 	DurationMs int64  `json:"duration_ms"`
 }
 
+// newCheckData converts a quality check result for recording (nil if the check didn't run)
+func newCheckData(result *CheckResult) *CheckData {
+	if result == nil {
+		return nil
+	}
+	return &CheckData{
+		Passed:     result.Passed,
+		Skipped:    result.Skipped,
+		SkipReason: result.SkipReason,
+		DurationMs: result.DurationMs,
+	}
+}
+
 // RecordQualityGate records a quality gate validation attempt
 func (r *ActivityRecorder) RecordQualityGate(iteration int, data *QualityGateData) error {
 	content, err := json.Marshal(data)
@@ -481,3 +531,138 @@ func (r *ActivityRecorder) RecordMemoryCreated(iteration int, data *MemoryCreate
 	r.broadcastActivity(activity)
 	return nil
 }
+
+// BudgetTopUpData represents budgets raised when a paused session resumes
+type BudgetTopUpData struct {
+	AddedIterations     int      `json:"added_iterations,omitempty"`
+	AddedTokens         int64    `json:"added_tokens,omitempty"`
+	AddedDollars        float64  `json:"added_dollars,omitempty"`
+	AddedRuntimeMinutes float64  `json:"added_runtime_minutes,omitempty"`
+	MaxIterations       int      `json:"max_iterations"`
+	TokensBudget        *int64   `json:"tokens_budget,omitempty"`
+	DollarsBudget       *float64 `json:"dollars_budget,omitempty"`
+}
+
+// newBudgetTopUpData describes ext after it was applied to s
+func newBudgetTopUpData(ext BudgetExtension, s *ActiveSession) *BudgetTopUpData {
+	return &BudgetTopUpData{
+		AddedIterations:     ext.Iterations,
+		AddedTokens:         ext.Tokens,
+		AddedDollars:        ext.Dollars,
+		AddedRuntimeMinutes: ext.Runtime.Minutes(),
+		MaxIterations:       s.MaxIterations,
+		TokensBudget:        s.TokensBudget,
+		DollarsBudget:       s.DollarsBudget,
+	}
+}
+
+// RecordBudgetTopUp records budgets raised on resume
+func (r *ActivityRecorder) RecordBudgetTopUp(iteration int, data *BudgetTopUpData) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget top-up: %w", err)
+	}
+
+	activity, err := r.db.CreateSessionActivity(
+		r.sessionID,
+		iteration,
+		db.ActivityTypeBudgetTopUp,
+		r.hat,
+		string(content),
+		nil,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record budget top-up: %w", err)
+	}
+
+	r.broadcastActivity(activity)
+	return nil
+}
+
+// SecurityEventData represents untrusted content that matched prompt-injection patterns
+type SecurityEventData struct {
+	Source   string                      `json:"source"` // e.g. "tool:web_fetch"
+	Action   string                      `json:"action"` // security.InjectionAction applied
+	Findings []security.InjectionFinding `json:"findings"`
+	Bytes    int                         `json:"bytes"` // Size of the content
+}
+
+// RecordSecurityEvent records a suspected prompt injection
+func (r *ActivityRecorder) RecordSecurityEvent(iteration int, data *SecurityEventData) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal security event: %w", err)
+	}
+
+	activity, err := r.db.CreateSessionActivity(
+		r.sessionID,
+		iteration,
+		db.ActivityTypeSecurityEvent,
+		r.hat,
+		string(content),
+		nil,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+
+	r.broadcastActivity(activity)
+	return nil
+}
+
+// RecordModelFallback records a request moving to a fallback model because
+// the session's model was over capacity
+func (r *ActivityRecorder) RecordModelFallback(iteration int, data *ModelFallbackData) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model fallback data: %w", err)
+	}
+
+	activity, err := r.db.CreateSessionActivity(
+		r.sessionID,
+		iteration,
+		db.ActivityTypeModelFallback,
+		r.hat,
+		string(content),
+		nil,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record model fallback: %w", err)
+	}
+
+	r.broadcastActivity(activity)
+	return nil
+}
+
+// RequestQueuedData represents time an API request spent queued before it was sent
+type RequestQueuedData struct {
+	WaitMs int64 `json:"wait_ms"`
+}
+
+// RecordRequestQueued records how long an API request waited for the shared
+// request gate and rate limiter
+func (r *ActivityRecorder) RecordRequestQueued(iteration int, data *RequestQueuedData) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request queue data: %w", err)
+	}
+
+	activity, err := r.db.CreateSessionActivity(
+		r.sessionID,
+		iteration,
+		db.ActivityTypeRequestQueued,
+		r.hat,
+		string(content),
+		nil,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record request queued: %w", err)
+	}
+
+	r.broadcastActivity(activity)
+	return nil
+}
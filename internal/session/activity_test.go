@@ -0,0 +1,63 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestActivityRecorder_Level(t *testing.T) {
+	// No database: recording anything that isn't filtered would panic
+	r := NewActivityRecorder(nil, "sess-1", "task-1", nil)
+
+	if r.Records(db.ActivityTypeDebugLog) {
+		t.Error("expected debug logs to be skipped at the standard level")
+	}
+	if !r.Records(db.ActivityTypeToolCall) || !r.Records(db.ActivityTypeChecklistUpdate) {
+		t.Error("expected non-debug events to be recorded at the standard level")
+	}
+	if err := r.RecordDebugLog(1, "info", "skipped", 0, nil); err != nil {
+		t.Errorf("RecordDebugLog returned %v", err)
+	}
+	r.Debug(1, "skipped")
+
+	r.SetLevel(db.ActivityLevelDebug)
+	if !r.Records(db.ActivityTypeDebugLog) {
+		t.Error("expected debug logs to be recorded at the debug level")
+	}
+}
+
+func TestActivityRecorder_BroadcastLevel(t *testing.T) {
+	r := NewActivityRecorder(nil, "sess-1", "task-1", nil)
+
+	if !r.Broadcasts(db.ActivityTypeAssistantResponse) || r.Broadcasts(db.ActivityTypeDebugLog) {
+		t.Error("expected the standard broadcast level to match the standard recording level")
+	}
+
+	r.SetBroadcastLevel(db.ActivityLevelMinimal)
+	for _, eventType := range []string{db.ActivityTypeToolCall, db.ActivityTypeToolResult, db.ActivityTypeCompletion, db.ActivityTypeHatTransition} {
+		if !r.Broadcasts(eventType) {
+			t.Errorf("expected %s to be broadcast at the minimal level", eventType)
+		}
+	}
+	if r.Broadcasts(db.ActivityTypeAssistantResponse) || r.Broadcasts(db.ActivityTypeChecklistUpdate) {
+		t.Error("expected other events to be withheld at the minimal level")
+	}
+
+	// Broadcasting debug logs needs them to be recorded too
+	r.SetBroadcastLevel(db.ActivityLevelDebug)
+	if r.Broadcasts(db.ActivityTypeDebugLog) {
+		t.Error("expected unrecorded debug logs not to be broadcast")
+	}
+	r.SetLevel(db.ActivityLevelDebug)
+	if !r.Broadcasts(db.ActivityTypeDebugLog) {
+		t.Error("expected debug logs to be broadcast at the debug level")
+	}
+
+	if err := db.ValidateBroadcastLevel(db.ActivityLevelMinimal); err != nil {
+		t.Errorf("ValidateBroadcastLevel(minimal) = %v", err)
+	}
+	if err := db.ValidateActivityLevel(db.ActivityLevelMinimal); err == nil {
+		t.Error("expected minimal to be rejected as a recording level")
+	}
+}
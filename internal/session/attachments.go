@@ -0,0 +1,111 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/security"
+)
+
+// AttachmentDir is where a task's attachments are written, relative to the
+// session's worktree. It's kept out of git by its own .gitignore.
+const AttachmentDir = ".dex/context"
+
+// MaxInlineAttachmentSize is the largest text attachment whose content goes
+// straight into the system prompt; larger ones are only listed
+const MaxInlineAttachmentSize = 8 << 10
+
+// writeAttachments writes a task's attachments into the worktree's
+// AttachmentDir, replacing whatever a previous session left there, including
+// attachments since deleted. The .gitignore is written last so no attachment
+// can replace it.
+func writeAttachments(worktreePath string, attachments []*db.TaskAttachment) error {
+	dir := filepath.Join(worktreePath, AttachmentDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear attachment directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	for _, a := range attachments {
+		if err := os.WriteFile(filepath.Join(dir, a.Name), a.Content, 0o644); err != nil {
+			return fmt.Errorf("failed to write attachment %s: %w", a.Name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write attachment .gitignore: %w", err)
+	}
+	return nil
+}
+
+// isInlineAttachment reports whether an attachment is small text that can go
+// in the prompt as is
+func isInlineAttachment(a *db.TaskAttachment) bool {
+	return len(a.Content) <= MaxInlineAttachmentSize &&
+		utf8.Valid(a.Content) && !bytes.ContainsRune(a.Content, 0)
+}
+
+// formatAttachments builds the prompt section describing a task's attachments.
+// Small text attachments are included in full; the rest are referenced by
+// their path in the worktree, or noted as unavailable when they weren't written.
+func formatAttachments(attachments []*db.TaskAttachment, written bool) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if written {
+		fmt.Fprintf(&sb, "These files are in `%s/` in your working directory. They are not tracked by git; don't commit or modify them.\n", AttachmentDir)
+	}
+	for _, a := range attachments {
+		location := "not available in the worktree"
+		if written {
+			location = "`" + AttachmentDir + "/" + a.Name + "`"
+		}
+		fmt.Fprintf(&sb, "\n- **%s** (%s, %d bytes): %s\n", a.Name, a.ContentType, a.Size, location)
+		if isInlineAttachment(a) {
+			fmt.Fprintf(&sb, "\n````\n%s\n````\n", strings.TrimRight(security.SanitizeForPrompt(string(a.Content)), "\n"))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// prepareAttachments writes the task's attachments into the session's
+// worktree, once as the session starts, so the prompt can point at them
+func (r *RalphLoop) prepareAttachments() {
+	if r.session.WorktreePath == "" {
+		return
+	}
+	attachments, err := r.db.GetTaskAttachmentsWithContent(r.session.TaskID)
+	if err != nil {
+		fmt.Printf("RalphLoop.Run: warning - failed to load attachments: %v\n", err)
+		return
+	}
+	if len(attachments) == 0 {
+		// Only clear out what a previous session left
+		if _, err := os.Stat(filepath.Join(r.session.WorktreePath, AttachmentDir)); err != nil {
+			return
+		}
+	}
+	if err := writeAttachments(r.session.WorktreePath, attachments); err != nil {
+		fmt.Printf("RalphLoop.Run: warning - %v\n", err)
+		return
+	}
+	r.attachmentsWritten = true
+}
+
+// attachmentSection returns the prompt section describing the task's
+// attachments, or "" if it has none. It doesn't touch the worktree.
+func (r *RalphLoop) attachmentSection() string {
+	attachments, err := r.db.GetTaskAttachmentsWithContent(r.session.TaskID)
+	if err != nil {
+		fmt.Printf("RalphLoop.buildPrompt: warning - failed to load attachments: %v\n", err)
+		return ""
+	}
+	return formatAttachments(attachments, r.attachmentsWritten)
+}
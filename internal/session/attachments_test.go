@@ -0,0 +1,120 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestWriteAndFormatAttachments(t *testing.T) {
+	attachments := []*db.TaskAttachment{
+		{Name: "trace.txt", ContentType: "text/plain", Size: 11, Content: []byte("panic: boom")},
+		{Name: "big.log", ContentType: "text/plain", Size: MaxInlineAttachmentSize + 1, Content: []byte(strings.Repeat("x", MaxInlineAttachmentSize+1))},
+		{Name: "diagram.png", ContentType: "image/png", Size: 4, Content: []byte{0x89, 'P', 0, 'G'}},
+	}
+
+	worktree := t.TempDir()
+	if err := writeAttachments(worktree, attachments); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(worktree, AttachmentDir, "trace.txt"))
+	if err != nil || string(content) != "panic: boom" {
+		t.Errorf("trace.txt = %q, %v; want its content", content, err)
+	}
+	if ignore, _ := os.ReadFile(filepath.Join(worktree, AttachmentDir, ".gitignore")); string(ignore) != "*\n" {
+		t.Errorf(".gitignore = %q, want everything ignored", ignore)
+	}
+
+	section := formatAttachments(attachments, true)
+	if !strings.Contains(section, "panic: boom") {
+		t.Error("small text attachment wasn't inlined")
+	}
+	if strings.Contains(section, "xxxx") {
+		t.Error("large attachment was inlined")
+	}
+	for _, path := range []string{".dex/context/big.log", ".dex/context/diagram.png"} {
+		if !strings.Contains(section, path) {
+			t.Errorf("section doesn't reference %s", path)
+		}
+	}
+
+	// Without the files on disk, only inline content is usable
+	section = formatAttachments(attachments, false)
+	if strings.Contains(section, ".dex/context/") || !strings.Contains(section, "not available") {
+		t.Errorf("section references files that weren't written:\n%s", section)
+	}
+
+	if formatAttachments(nil, true) != "" {
+		t.Error("expected no section without attachments")
+	}
+}
+
+func TestWriteAttachments_ReplacesPreviousSession(t *testing.T) {
+	worktree := t.TempDir()
+	first := []*db.TaskAttachment{
+		{Name: "old.txt", Content: []byte("stale")},
+		{Name: ".gitignore", Content: []byte("!*\n")}, // Saved before the name was reserved
+	}
+	if err := writeAttachments(worktree, first); err != nil {
+		t.Fatal(err)
+	}
+	if ignore, _ := os.ReadFile(filepath.Join(worktree, AttachmentDir, ".gitignore")); string(ignore) != "*\n" {
+		t.Errorf(".gitignore = %q, want it not replaced by an attachment", ignore)
+	}
+
+	if err := writeAttachments(worktree, []*db.TaskAttachment{{Name: "new.txt", Content: []byte("fresh")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, AttachmentDir, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("deleted attachment still in the worktree: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(worktree, AttachmentDir, "new.txt")); string(content) != "fresh" {
+		t.Errorf("new.txt = %q, want its content", content)
+	}
+}
+
+func TestExplainLoop_DoesNotWriteAttachments(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Fix crash", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree := t.TempDir()
+	if err := database.UpdateTaskWorktree(task.ID, worktree, "task/fix-crash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.SaveTaskAttachment(task.ID, "trace.txt", "text/plain", []byte("panic: boom")); err != nil {
+		t.Fatal(err)
+	}
+	task, err = database.GetTaskByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(database, nil, filepath.Join(t.TempDir(), "missing")) // Embedded prompts
+	prompt, err := m.explainLoop(task, "creator").buildPrompt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, AttachmentDir+"/trace.txt") {
+		t.Error("explain prompt should describe attachments as the session will see them")
+	}
+	if _, err := os.Stat(filepath.Join(worktree, AttachmentDir)); !os.IsNotExist(err) {
+		t.Errorf("explaining the task wrote into its worktree: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/realtime"
+)
+
+// DefaultBudgetWarnings are the fractions of a token or dollar budget at
+// which a session warns that it's close to pausing
+var DefaultBudgetWarnings = []float64{0.75, 0.9}
+
+// budgetIncreaseFraction is how much of the current budget a warning offers to add
+const budgetIncreaseFraction = 0.5
+
+// Budgets that warn before they're exhausted
+const (
+	budgetTokens  = "tokens"
+	budgetDollars = "dollars"
+)
+
+// BudgetIncreaseData is the data of a budget_increase approval. Approving it
+// raises the session's budget by Tokens or Dollars, whichever it offers.
+type BudgetIncreaseData struct {
+	SessionID        string  `json:"session_id"`
+	Budget           string  `json:"budget"` // "tokens" or "dollars"
+	ThresholdPercent int     `json:"threshold_percent"`
+	Used             float64 `json:"used"`
+	Limit            float64 `json:"limit"`
+	Tokens           int64   `json:"tokens,omitempty"`
+	Dollars          float64 `json:"dollars,omitempty"`
+}
+
+// Extension returns the budget extension approving d grants
+func (d BudgetIncreaseData) Extension() BudgetExtension {
+	return BudgetExtension{Tokens: d.Tokens, Dollars: d.Dollars}
+}
+
+// ParseBudgetWarnings parses comma-separated warning thresholds given as
+// percentages of a budget (e.g. "75,90") into ascending fractions. An empty
+// string disables warnings.
+func ParseBudgetWarnings(s string) ([]float64, error) {
+	thresholds := []float64{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "%"))
+		if part == "" {
+			continue
+		}
+		percent, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid budget warning %q: %w", part, err)
+		}
+		if percent <= 0 || percent >= 100 {
+			return nil, fmt.Errorf("budget warning %q must be between 0 and 100 percent", part)
+		}
+		thresholds = append(thresholds, percent/100)
+	}
+	slices.Sort(thresholds)
+	return slices.Compact(thresholds), nil
+}
+
+// SetBudgetWarnings configures the fractions of a budget at which sessions warn
+func (r *RalphLoop) SetBudgetWarnings(thresholds []float64) {
+	r.budgetWarnings = thresholds
+}
+
+// budgetUsage returns how much of a budget the session has used and its
+// limit, or ok false if the budget is unlimited
+func (r *RalphLoop) budgetUsage(budget string) (used, limit float64, ok bool) {
+	switch budget {
+	case budgetTokens:
+		if r.session.TokensBudget != nil {
+			return float64(r.session.TotalTokens()), float64(*r.session.TokensBudget), true
+		}
+	case budgetDollars:
+		if r.session.DollarsBudget != nil {
+			return r.session.Cost(), *r.session.DollarsBudget, true
+		}
+	}
+	return 0, 0, false
+}
+
+// passedBudgetWarning returns the highest warning threshold used has reached, or 0
+func (r *RalphLoop) passedBudgetWarning(used, limit float64) float64 {
+	passed := 0.0
+	for _, threshold := range r.budgetWarnings {
+		if limit > 0 && used >= threshold*limit {
+			passed = threshold
+		}
+	}
+	return passed
+}
+
+// markBudgetWarnings records the thresholds the session has already passed so
+// it only warns about the ones it passes from now on. Called when the loop
+// starts and when a budget is raised.
+func (r *RalphLoop) markBudgetWarnings() {
+	if r.budgetWarned == nil {
+		r.budgetWarned = make(map[string]float64)
+	}
+	for _, budget := range []string{budgetTokens, budgetDollars} {
+		if used, limit, ok := r.budgetUsage(budget); ok {
+			r.budgetWarned[budget] = r.passedBudgetWarning(used, limit)
+		}
+	}
+}
+
+// checkBudgetWarnings warns once for each threshold a budget passes, offering
+// to raise it before the session pauses. An exhausted budget is left to
+// checkBudget.
+func (r *RalphLoop) checkBudgetWarnings() {
+	if r.budgetWarned == nil {
+		r.budgetWarned = make(map[string]float64)
+	}
+	for _, budget := range []string{budgetTokens, budgetDollars} {
+		used, limit, ok := r.budgetUsage(budget)
+		if !ok || used >= limit {
+			continue
+		}
+		threshold := r.passedBudgetWarning(used, limit)
+		if threshold <= r.budgetWarned[budget] {
+			continue
+		}
+		r.budgetWarned[budget] = threshold
+
+		data := BudgetIncreaseData{
+			SessionID:        r.session.ID,
+			Budget:           budget,
+			ThresholdPercent: int(math.Round(threshold * 100)),
+			Used:             used,
+			Limit:            limit,
+		}
+		if budget == budgetTokens {
+			data.Tokens = int64(limit * budgetIncreaseFraction)
+		} else {
+			data.Dollars = math.Round(limit*budgetIncreaseFraction*100) / 100
+		}
+
+		payload := map[string]any{
+			"session_id":        r.session.ID,
+			"budget":            budget,
+			"threshold_percent": data.ThresholdPercent,
+			"used":              used,
+			"limit":             limit,
+		}
+		if approvalID := r.requestBudgetIncrease(data); approvalID != "" {
+			payload["approval_id"] = approvalID
+		}
+		r.broadcastEvent(realtime.EventSessionBudgetWarning, payload)
+	}
+}
+
+// requestBudgetIncrease creates a budget_increase approval unless one for the
+// same budget is still pending, returning the pending approval's ID
+func (r *RalphLoop) requestBudgetIncrease(data BudgetIncreaseData) string {
+	if r.db == nil {
+		return ""
+	}
+	if r.budgetApprovals == nil {
+		r.budgetApprovals = make(map[string]string)
+	}
+	if id, ok := r.budgetApprovals[data.Budget]; ok {
+		return id
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		fmt.Printf("RalphLoop: warning - failed to marshal budget increase: %v\n", err)
+		return ""
+	}
+
+	var title, description string
+	if data.Budget == budgetTokens {
+		title = fmt.Sprintf("Raise token budget by %d?", data.Tokens)
+		description = fmt.Sprintf("The session has used %d%% of its token budget (%.0f of %.0f tokens). Approve to add %d tokens before it pauses.",
+			data.ThresholdPercent, data.Used, data.Limit, data.Tokens)
+	} else {
+		title = fmt.Sprintf("Raise dollar budget by $%.2f?", data.Dollars)
+		description = fmt.Sprintf("The session has used %d%% of its dollar budget ($%.2f of $%.2f). Approve to add $%.2f before it pauses.",
+			data.ThresholdPercent, data.Used, data.Limit, data.Dollars)
+	}
+
+	taskID, sessionID := r.session.TaskID, r.session.ID
+	approval, err := r.db.CreateApproval(&taskID, &sessionID, db.ApprovalTypeBudgetIncrease, title, &description, raw)
+	if err != nil {
+		fmt.Printf("RalphLoop: warning - failed to create budget increase approval: %v\n", err)
+		return ""
+	}
+	r.budgetApprovals[data.Budget] = approval.ID
+
+	r.broadcastEvent(realtime.EventApprovalRequired, map[string]any{
+		"session_id":  r.session.ID,
+		"approval_id": approval.ID,
+		"reason":      description,
+	})
+	return approval.ID
+}
+
+// applyBudgetIncreases raises the budgets whose increase has been approved
+// since the session asked. Rejected requests are dropped; the session runs on
+// until the budget is exhausted.
+func (r *RalphLoop) applyBudgetIncreases() {
+	if r.db == nil {
+		return
+	}
+	for budget, id := range r.budgetApprovals {
+		approval, err := r.db.GetApprovalByID(id)
+		if err != nil {
+			fmt.Printf("RalphLoop: warning - failed to check budget increase %s: %v\n", id, err)
+			continue
+		}
+		if approval == nil || approval.Status == db.ApprovalStatusRejected {
+			delete(r.budgetApprovals, budget)
+			continue
+		}
+		if approval.Status != db.ApprovalStatusApproved {
+			continue
+		}
+		delete(r.budgetApprovals, budget)
+
+		var data BudgetIncreaseData
+		if err := json.Unmarshal(approval.Data, &data); err != nil {
+			fmt.Printf("RalphLoop: warning - invalid budget increase %s: %v\n", id, err)
+			continue
+		}
+		data.Extension().apply(r.session)
+		if err := r.db.SetSessionBudgets(r.session.ID, r.session.TokensBudget, r.session.DollarsBudget); err != nil {
+			fmt.Printf("RalphLoop: warning - failed to save raised budgets: %v\n", err)
+		}
+		r.markBudgetWarnings()
+		r.activity.Debug(r.session.IterationCount, fmt.Sprintf("Raised %s budget (approval %s)", budget, id))
+	}
+}
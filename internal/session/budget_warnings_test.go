@@ -0,0 +1,106 @@
+package session
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/realtime"
+)
+
+func TestParseBudgetWarnings(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []float64
+		wantErr bool
+	}{
+		{"75,90", []float64{0.75, 0.9}, false},
+		{" 90%, 50 ,90", []float64{0.5, 0.9}, false},
+		{"", []float64{}, false},
+		{"0", nil, true},
+		{"100", nil, true},
+		{"half", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBudgetWarnings(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBudgetWarnings(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !slices.Equal(got, tt.want) {
+			t.Errorf("ParseBudgetWarnings(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckBudgetWarnings(t *testing.T) {
+	tokens := int64(100_000)
+	broadcaster := realtime.NewBroadcaster(nil)
+	events, cancel := broadcaster.Subscribe(nil)
+	defer cancel()
+
+	session := &ActiveSession{ID: "sess-1", TaskID: "task-1", TokensBudget: &tokens}
+	loop := &RalphLoop{session: session, broadcaster: broadcaster, budgetWarnings: DefaultBudgetWarnings}
+	loop.markBudgetWarnings()
+
+	warnings := func() []map[string]any {
+		var got []map[string]any
+		for len(events) > 0 {
+			if event := <-events; event.Type == realtime.EventSessionBudgetWarning {
+				got = append(got, event.Payload)
+			}
+		}
+		return got
+	}
+
+	session.InputTokens = 70_000
+	loop.checkBudgetWarnings()
+	if got := warnings(); len(got) != 0 {
+		t.Fatalf("warned below the first threshold: %v", got)
+	}
+
+	session.InputTokens = 80_000
+	loop.checkBudgetWarnings()
+	loop.checkBudgetWarnings()
+	got := warnings()
+	if len(got) != 1 || got[0]["threshold_percent"] != 75 || got[0]["budget"] != budgetTokens {
+		t.Fatalf("expected one 75%% token warning, got %v", got)
+	}
+
+	// Jumping past both thresholds warns once, at the higher one
+	session.InputTokens = 95_000
+	loop.checkBudgetWarnings()
+	if got := warnings(); len(got) != 1 || got[0]["threshold_percent"] != 90 {
+		t.Fatalf("expected one 90%% warning, got %v", got)
+	}
+
+	// An exhausted budget is a hard stop, not a warning
+	session.InputTokens = 100_000
+	loop.checkBudgetWarnings()
+	if got := warnings(); len(got) != 0 {
+		t.Fatalf("warned about an exhausted budget: %v", got)
+	}
+
+	// Raising the budget only warns again once a threshold of the new one is passed
+	BudgetExtension{Tokens: 50_000}.apply(session)
+	loop.markBudgetWarnings()
+	loop.checkBudgetWarnings()
+	if got := warnings(); len(got) != 0 {
+		t.Fatalf("warned right after the budget was raised: %v", got)
+	}
+	session.InputTokens = 120_000
+	loop.checkBudgetWarnings()
+	if got := warnings(); len(got) != 1 || got[0]["limit"] != 150_000.0 {
+		t.Fatalf("expected a warning against the raised budget, got %v", got)
+	}
+}
+
+func TestCheckBudgetWarnings_Disabled(t *testing.T) {
+	dollars := 1.0
+	session := &ActiveSession{InputTokens: 1_000_000, InputRate: 0.95, DollarsBudget: &dollars}
+	loop := &RalphLoop{session: session}
+
+	loop.checkBudgetWarnings()
+	if loop.budgetWarned[budgetDollars] != 0 {
+		t.Errorf("warned with no thresholds configured")
+	}
+}
@@ -0,0 +1,160 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// CheckpointDiff describes how a session changed between two checkpoints
+type CheckpointDiff struct {
+	SessionID string        `json:"session_id"`
+	From      CheckpointRef `json:"from"`
+	To        CheckpointRef `json:"to"`
+
+	Iterations     int     `json:"iterations"`      // Iterations run between the checkpoints
+	ElapsedSeconds float64 `json:"elapsed_seconds"` // Wall-clock time between the checkpoints
+
+	InputTokens  int64 `json:"input_tokens"`  // Input tokens spent between the checkpoints
+	OutputTokens int64 `json:"output_tokens"` // Output tokens spent between the checkpoints
+
+	HatChanged bool   `json:"hat_changed"`
+	FromHat    string `json:"from_hat"`
+	ToHat      string `json:"to_hat"`
+
+	// MessagesAdded are the conversation messages in the later checkpoint after
+	// the history both share. MessagesRemoved counts earlier messages that were
+	// dropped or rewritten in between, e.g. by context compaction.
+	MessagesAdded   []toolbelt.AnthropicMessage `json:"messages_added"`
+	MessagesRemoved int                         `json:"messages_removed"`
+
+	Scratchpad ScratchpadDiff `json:"scratchpad"`
+
+	FromError string `json:"from_error,omitempty"`
+	ToError   string `json:"to_error,omitempty"`
+}
+
+// CheckpointRef identifies one side of a checkpoint diff
+type CheckpointRef struct {
+	ID        string `json:"id"`
+	Iteration int    `json:"iteration"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ScratchpadDiff is a line diff of the scratchpad
+type ScratchpadDiff struct {
+	Changed bool     `json:"changed"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// DiffCheckpoints computes the structured difference from checkpoint a to checkpoint b
+func DiffCheckpoints(a, b *db.SessionCheckpoint) (*CheckpointDiff, error) {
+	var from, to checkpointState
+	if err := json.Unmarshal(a.State, &from); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", a.ID, err)
+	}
+	if err := json.Unmarshal(b.State, &to); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", b.ID, err)
+	}
+
+	common := commonMessagePrefix(from.Messages, to.Messages)
+	added := to.Messages[common:]
+	if added == nil {
+		added = []toolbelt.AnthropicMessage{}
+	}
+
+	return &CheckpointDiff{
+		SessionID:       b.SessionID,
+		From:            checkpointRef(a),
+		To:              checkpointRef(b),
+		Iterations:      b.Iteration - a.Iteration,
+		ElapsedSeconds:  b.CreatedAt.Sub(a.CreatedAt).Seconds(),
+		InputTokens:     to.InputTokens - from.InputTokens,
+		OutputTokens:    to.OutputTokens - from.OutputTokens,
+		HatChanged:      from.Hat != to.Hat,
+		FromHat:         from.Hat,
+		ToHat:           to.Hat,
+		MessagesAdded:   added,
+		MessagesRemoved: len(from.Messages) - common,
+		Scratchpad:      diffLines(from.Scratchpad, to.Scratchpad),
+		FromError:       from.LastError,
+		ToError:         to.LastError,
+	}, nil
+}
+
+// checkpointRef summarizes a checkpoint for a diff
+func checkpointRef(c *db.SessionCheckpoint) CheckpointRef {
+	return CheckpointRef{
+		ID:        c.ID,
+		Iteration: c.Iteration,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// commonMessagePrefix returns how many leading messages a and b share
+func commonMessagePrefix(a, b []toolbelt.AnthropicMessage) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n].Role == b[n].Role && reflect.DeepEqual(a[n].Content, b[n].Content) {
+		n++
+	}
+	return n
+}
+
+// diffLines returns the lines removed from and added to a text, using the
+// longest common subsequence of lines so moved context isn't reported twice
+func diffLines(before, after string) ScratchpadDiff {
+	diff := ScratchpadDiff{Added: []string{}, Removed: []string{}}
+	if before == after {
+		return diff
+	}
+	diff.Changed = true
+
+	a, b := splitLines(before), splitLines(after)
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff.Removed = append(diff.Removed, a[i])
+			i++
+		default:
+			diff.Added = append(diff.Added, b[j])
+			j++
+		}
+	}
+	diff.Removed = append(diff.Removed, a[i:]...)
+	diff.Added = append(diff.Added, b[j:]...)
+	return diff
+}
+
+// splitLines splits text into lines, treating empty text as no lines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
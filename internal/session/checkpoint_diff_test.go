@@ -0,0 +1,99 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func testCheckpoint(t *testing.T, id string, iteration int, createdAt time.Time, state map[string]any) *db.SessionCheckpoint {
+	t.Helper()
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &db.SessionCheckpoint{ID: id, SessionID: "sess-1", Iteration: iteration, State: data, CreatedAt: createdAt}
+}
+
+func TestDiffCheckpoints(t *testing.T) {
+	start := time.Now()
+	a := testCheckpoint(t, "ckpt-a", 5, start, map[string]any{
+		"iteration":     5,
+		"input_tokens":  1000,
+		"output_tokens": 200,
+		"hat":           "explorer",
+		"messages": []map[string]any{
+			{"role": "user", "content": "explore the repo"},
+			{"role": "assistant", "content": "looking around"},
+		},
+		"scratchpad": "goal: add endpoint\nstatus: exploring\n",
+	})
+	b := testCheckpoint(t, "ckpt-b", 10, start.Add(90*time.Second), map[string]any{
+		"iteration":     10,
+		"input_tokens":  4000,
+		"output_tokens": 900,
+		"hat":           "creator",
+		"messages": []map[string]any{
+			{"role": "user", "content": "explore the repo"},
+			{"role": "assistant", "content": "looking around"},
+			{"role": "user", "content": "now build it"},
+		},
+		"scratchpad": "goal: add endpoint\nstatus: building\nnext: tests\n",
+		"last_error": "tests failed",
+	})
+
+	diff, err := DiffCheckpoints(a, b)
+	if err != nil {
+		t.Fatalf("DiffCheckpoints() error = %v", err)
+	}
+
+	if diff.Iterations != 5 || diff.ElapsedSeconds != 90 {
+		t.Errorf("iterations=%d elapsed=%v, want 5 and 90", diff.Iterations, diff.ElapsedSeconds)
+	}
+	if diff.InputTokens != 3000 || diff.OutputTokens != 700 {
+		t.Errorf("tokens = %d/%d, want 3000/700", diff.InputTokens, diff.OutputTokens)
+	}
+	if !diff.HatChanged || diff.FromHat != "explorer" || diff.ToHat != "creator" {
+		t.Errorf("hat change = %v %s->%s, want explorer->creator", diff.HatChanged, diff.FromHat, diff.ToHat)
+	}
+	if len(diff.MessagesAdded) != 1 || diff.MessagesAdded[0].Content != "now build it" || diff.MessagesRemoved != 0 {
+		t.Errorf("messages added=%v removed=%d, want the one new message", diff.MessagesAdded, diff.MessagesRemoved)
+	}
+	if len(diff.Scratchpad.Removed) != 1 || diff.Scratchpad.Removed[0] != "status: exploring" {
+		t.Errorf("scratchpad removed = %v", diff.Scratchpad.Removed)
+	}
+	if len(diff.Scratchpad.Added) != 2 || diff.Scratchpad.Added[0] != "status: building" || diff.Scratchpad.Added[1] != "next: tests" {
+		t.Errorf("scratchpad added = %v", diff.Scratchpad.Added)
+	}
+	if diff.ToError != "tests failed" {
+		t.Errorf("to_error = %q", diff.ToError)
+	}
+}
+
+func TestDiffCheckpoints_CompactedHistory(t *testing.T) {
+	now := time.Now()
+	a := testCheckpoint(t, "ckpt-a", 1, now, map[string]any{
+		"messages": []map[string]any{
+			{"role": "user", "content": "one"},
+			{"role": "assistant", "content": "two"},
+		},
+	})
+	b := testCheckpoint(t, "ckpt-b", 2, now, map[string]any{
+		"messages": []map[string]any{
+			{"role": "user", "content": "summary of one and two"},
+		},
+	})
+
+	diff, err := DiffCheckpoints(a, b)
+	if err != nil {
+		t.Fatalf("DiffCheckpoints() error = %v", err)
+	}
+	if diff.MessagesRemoved != 2 || len(diff.MessagesAdded) != 1 {
+		t.Errorf("removed=%d added=%d, want 2 and 1", diff.MessagesRemoved, len(diff.MessagesAdded))
+	}
+	if diff.Scratchpad.Changed {
+		t.Error("scratchpad reported changed when both are empty")
+	}
+}
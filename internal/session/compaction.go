@@ -16,6 +16,8 @@ const (
 	DefaultContextCompactPct = 50     // Compact at 50% (leaves 50% buffer for responses)
 	MaxRecentMessages        = 6      // Messages to keep after compaction
 	CharsPerToken            = 4      // Approximate chars per token
+	DefaultMaxMessages       = 1000   // Hard cap on message history, well above normal sessions
+	MinMaxMessages           = 20     // Smallest hard cap that leaves room for recent context
 
 	// Summarization model options
 	SummaryModelHaiku  = "claude-haiku-4-5-20251001"  // Default: fast and cheap
@@ -41,6 +43,7 @@ type ContextGuard struct {
 	promptLoader   *PromptLoader             // For loading summarization prompt
 	summaryModel   string                    // Model to use for summarization (default: Haiku)
 	lastUsagePct   int                       // Last calculated usage percentage for UI
+	maxMessages    int                       // Hard cap on message count (0 = no cap)
 }
 
 // NewContextGuard creates a new context guard with default thresholds
@@ -51,6 +54,7 @@ func NewContextGuard(activity *ActivityRecorder) *ContextGuard {
 		compactAt:    DefaultContextWindowMax * DefaultContextCompactPct / 100,
 		activity:     activity,
 		summaryModel: SummaryModelHaiku, // Default to Haiku for cost efficiency
+		maxMessages:  DefaultMaxMessages,
 	}
 }
 
@@ -61,6 +65,13 @@ func (g *ContextGuard) SetThresholds(windowMax, warnPct, compactPct int) {
 	g.compactAt = windowMax * compactPct / 100
 }
 
+// SetMaxMessages sets the hard cap on message count (0 or less removes it).
+// The cap is a safety net for when token estimates are off: going over it
+// forces compaction, and if that isn't enough the oldest messages are dropped.
+func (g *ContextGuard) SetMaxMessages(n int) {
+	g.maxMessages = max(n, 0)
+}
+
 // SetSummarizer configures LLM-based summarization
 // If client is nil, falls back to rule-based summarization
 // Model can be SummaryModelHaiku (default), SummaryModelSonnet, or SummaryModelSame
@@ -196,15 +207,31 @@ func estimateBlockTokens(block map[string]any) int {
 // Returns the compacted messages and whether compaction occurred
 func (g *ContextGuard) CheckAndCompact(messages []toolbelt.AnthropicMessage, systemPrompt string, scratchpad string) ([]toolbelt.AnthropicMessage, bool, error) {
 	tokens := EstimateTokens(messages, systemPrompt)
+	overCap := g.maxMessages > 0 && len(messages) > g.maxMessages
 
-	if tokens >= g.compactAt {
+	if overCap {
+		fmt.Printf("ContextGuard: %d messages exceeds hard cap of %d, forcing compaction\n", len(messages), g.maxMessages)
 		if g.activity != nil {
+			g.activity.Debug(0, fmt.Sprintf("%d messages exceeds hard cap of %d, forcing compaction", len(messages), g.maxMessages))
+		}
+	}
+
+	if tokens >= g.compactAt || overCap {
+		if g.activity != nil && !overCap {
 			g.activity.Debug(0, fmt.Sprintf("context at %d%%, triggering compaction", tokens*100/g.windowMax))
 		}
-		compacted, err := g.compactProgressive(messages, scratchpad)
+		compacted, err := g.compactProgressive(messages, scratchpad, g.windowMax*35/100)
 		if err != nil {
 			return messages, false, err
 		}
+		if g.maxMessages > 0 && len(compacted) > g.maxMessages {
+			before := len(compacted)
+			compacted = dropOldestMessages(compacted, g.maxMessages)
+			fmt.Printf("ContextGuard: still %d messages after compaction, dropped oldest to %d\n", before, len(compacted))
+			if g.activity != nil {
+				g.activity.Debug(0, fmt.Sprintf("still %d messages after compaction, dropped oldest to %d", before, len(compacted)))
+			}
+		}
 		return compacted, true, nil
 	} else if tokens >= g.warnAt {
 		if g.activity != nil {
@@ -215,10 +242,26 @@ func (g *ContextGuard) CheckAndCompact(messages []toolbelt.AnthropicMessage, sys
 	return messages, false, nil
 }
 
-// compactProgressive tries progressive tool response removal before full compaction
-func (g *ContextGuard) compactProgressive(messages []toolbelt.AnthropicMessage, scratchpad string) ([]toolbelt.AnthropicMessage, error) {
-	targetTokens := g.windowMax * 35 / 100 // Target 35% of context window (leaves 65% for responses)
+// CompactToTarget compacts regardless of current usage, aiming for targetPct
+// of the context window. It's used when the API rejects a request the token
+// estimate said would fit, so the usual thresholds can't be trusted.
+func (g *ContextGuard) CompactToTarget(messages []toolbelt.AnthropicMessage, scratchpad string, targetPct int) ([]toolbelt.AnthropicMessage, error) {
+	if g.activity != nil {
+		g.activity.Debug(0, fmt.Sprintf("forcing compaction to %d%% of the context window", targetPct))
+	}
+	compacted, err := g.compactProgressive(messages, scratchpad, g.windowMax*targetPct/100)
+	if err != nil {
+		return messages, err
+	}
+	if g.maxMessages > 0 && len(compacted) > g.maxMessages {
+		compacted = dropOldestMessages(compacted, g.maxMessages)
+	}
+	return compacted, nil
+}
 
+// compactProgressive tries progressive tool response removal before full compaction.
+// CheckAndCompact targets 35% of the context window (leaves 65% for responses).
+func (g *ContextGuard) compactProgressive(messages []toolbelt.AnthropicMessage, scratchpad string, targetTokens int) ([]toolbelt.AnthropicMessage, error) {
 	for _, pct := range RemovalLevels {
 		filtered := filterToolResponses(messages, pct)
 		tokens := EstimateTokens(filtered, "")
@@ -248,7 +291,7 @@ func (g *ContextGuard) compactProgressive(messages []toolbelt.AnthropicMessage,
 						// Prepend summary context to filtered messages
 						summaryMsg := toolbelt.AnthropicMessage{
 							Role:    "user",
-							Content: fmt.Sprintf("## Compacted Context Summary\n\n%s\n\nContinue with the task.", summary),
+							Content: fmt.Sprintf("%s\n\n%s\n\nContinue with the task.", compactedSummaryHeading, summary),
 						}
 						filtered = append([]toolbelt.AnthropicMessage{summaryMsg}, filtered...)
 
@@ -397,7 +440,7 @@ func (g *ContextGuard) keepRecentWithSummary(messages []toolbelt.AnthropicMessag
 
 	// Build context message
 	var contextBuilder strings.Builder
-	contextBuilder.WriteString("## Session Context (compacted)\n\n")
+	contextBuilder.WriteString(compactedContextHeading + "\n\n")
 
 	if scratchpad != "" {
 		contextBuilder.WriteString("### Scratchpad\n")
@@ -424,6 +467,55 @@ func (g *ContextGuard) keepRecentWithSummary(messages []toolbelt.AnthropicMessag
 	return result
 }
 
+// Headings of messages that carry context compaction would otherwise lose
+const (
+	compactedContextHeading = "## Session Context (compacted)"
+	compactedSummaryHeading = "## Compacted Context Summary"
+	droppedMessagesHeading  = "## Earlier Messages Dropped"
+)
+
+// isEssentialMessage reports whether the message at index i must survive
+// dropOldestMessages: the task prompt that opens the session, and compaction summaries
+func isEssentialMessage(i int, msg toolbelt.AnthropicMessage) bool {
+	if i == 0 {
+		return true
+	}
+	content, ok := msg.Content.(string)
+	return ok && (strings.HasPrefix(content, compactedContextHeading) || strings.HasPrefix(content, compactedSummaryHeading))
+}
+
+// dropOldestMessages trims messages to at most limit by dropping the oldest
+// non-essential ones and marking where they were. Tool results left at the
+// cut are dropped too, since their tool calls are gone.
+func dropOldestMessages(messages []toolbelt.AnthropicMessage, limit int) []toolbelt.AnthropicMessage {
+	if len(messages) <= limit {
+		return messages
+	}
+
+	toDrop := len(messages) - limit + 1 // Room for the marker
+	var kept []toolbelt.AnthropicMessage
+	dropped := 0
+	i := 0
+	for ; i < len(messages) && dropped < toDrop; i++ {
+		if isEssentialMessage(i, messages[i]) {
+			kept = append(kept, messages[i])
+			continue
+		}
+		dropped++
+	}
+	for i < len(messages) && messages[i].Role == "user" && hasToolResponse(messages[i]) {
+		i++
+		dropped++
+	}
+
+	kept = append(kept, toolbelt.AnthropicMessage{
+		Role: "user",
+		Content: fmt.Sprintf("%s\n\n%d older messages were dropped to keep the conversation under %d messages. "+
+			"Check your scratchpad and the repository for progress made in them.", droppedMessagesHeading, dropped, limit),
+	})
+	return append(kept, messages[i:]...)
+}
+
 // summarizeMessages extracts key events from messages
 func summarizeMessages(messages []toolbelt.AnthropicMessage) string {
 	var summary strings.Builder
@@ -169,3 +169,77 @@ func TestSummarizeMessages(t *testing.T) {
 		t.Error("Expected summary to contain quality gate result")
 	}
 }
+
+func TestDropOldestMessages(t *testing.T) {
+	messages := []toolbelt.AnthropicMessage{
+		{Role: "user", Content: "Task prompt"},
+		{Role: "assistant", Content: "old 1"},
+		{Role: "user", Content: compactedContextHeading + "\n\nearlier summary"},
+		{Role: "assistant", Content: []toolbelt.ContentBlock{{Type: "tool_use", ID: "t1", Name: "bash"}}},
+		{Role: "user", Content: []toolbelt.ContentBlock{{Type: "tool_result", ToolUseID: "t1", Content: "out"}}},
+		{Role: "assistant", Content: "recent 1"},
+		{Role: "user", Content: "recent 2"},
+		{Role: "assistant", Content: "recent 3"},
+	}
+
+	result := dropOldestMessages(messages, 6)
+	if len(result) > 6 {
+		t.Fatalf("got %d messages, want at most 6", len(result))
+	}
+
+	// Task prompt and summary survive, then the marker, then recent history
+	if result[0].Content != "Task prompt" {
+		t.Errorf("first message = %v, want the task prompt", result[0].Content)
+	}
+	if content, _ := result[1].Content.(string); !strings.HasPrefix(content, compactedContextHeading) {
+		t.Errorf("second message = %v, want the earlier summary", result[1].Content)
+	}
+	marker, _ := result[2].Content.(string)
+	if !strings.HasPrefix(marker, droppedMessagesHeading) || !strings.Contains(marker, "3 older messages") {
+		t.Errorf("marker = %q, want it to count 3 dropped messages", marker)
+	}
+	// The tool result whose call was dropped goes too
+	for _, msg := range result {
+		if hasToolResponse(msg) {
+			t.Errorf("orphaned tool result kept: %+v", msg)
+		}
+	}
+	if result[len(result)-1].Content != "recent 3" {
+		t.Errorf("last message = %v, want the most recent", result[len(result)-1].Content)
+	}
+
+	if got := dropOldestMessages(messages, 100); len(got) != len(messages) {
+		t.Errorf("under the cap: got %d messages, want %d unchanged", len(got), len(messages))
+	}
+}
+
+func TestContextGuard_MessageCap(t *testing.T) {
+	guard := NewContextGuard(nil)
+	guard.SetMaxMessages(MinMaxMessages)
+
+	messages := []toolbelt.AnthropicMessage{{Role: "user", Content: "Task prompt"}}
+	for i := 0; i < 30; i++ {
+		messages = append(messages,
+			toolbelt.AnthropicMessage{Role: "assistant", Content: "working"},
+			toolbelt.AnthropicMessage{Role: "user", Content: "Continue."},
+		)
+	}
+
+	// Far below the token threshold, but over the message cap
+	result, compacted, err := guard.CheckAndCompact(messages, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compacted {
+		t.Fatal("expected the message cap to force compaction")
+	}
+	if len(result) > MinMaxMessages {
+		t.Errorf("got %d messages, want at most %d", len(result), MinMaxMessages)
+	}
+
+	// No cap, no compaction
+	guard.SetMaxMessages(0)
+	if _, compacted, _ = guard.CheckAndCompact(messages, "", ""); compacted {
+		t.Error("expected no compaction without a cap")
+	}
+}
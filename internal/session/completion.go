@@ -0,0 +1,97 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/realtime"
+)
+
+// completionDecision is the outcome of checking a completion signal against a policy
+type completionDecision int
+
+const (
+	completionAllowed         completionDecision = iota // Task may complete
+	completionNeedsResolution                           // Send back to the agent to resolve checklist issues
+	completionNeedsApproval                             // Pause until a human approves completion
+)
+
+// evaluateCompletion decides whether a task may complete given its checklist progress.
+// done and total count checklist items; total is 0 when the task has no checklist.
+func evaluateCompletion(policy *db.CompletionPolicy, done, total int, acknowledged bool) completionDecision {
+	if policy.Strictness == db.CompletionStrictnessManual {
+		return completionNeedsApproval
+	}
+	if done >= total || acknowledged {
+		return completionAllowed
+	}
+	if policy.Strictness == db.CompletionStrictnessLenient {
+		minRatio := policy.MinDoneRatio
+		if minRatio == 0 {
+			minRatio = db.DefaultCompletionMinDoneRatio
+		}
+		if float64(done)/float64(total) >= minRatio {
+			return completionAllowed
+		}
+	}
+	return completionNeedsResolution
+}
+
+// completionPolicy returns the effective completion policy for the session's task
+func (r *RalphLoop) completionPolicy() *db.CompletionPolicy {
+	if r.db == nil {
+		return db.DefaultCompletionPolicy()
+	}
+	policy, err := r.db.ResolveCompletionPolicy(r.session.TaskID)
+	if err != nil {
+		fmt.Printf("RalphLoop: warning - failed to resolve completion policy, using strict: %v\n", err)
+		return db.DefaultCompletionPolicy()
+	}
+	return policy
+}
+
+// requestCompletionApproval creates a task_completion approval for a human to resolve
+func (r *RalphLoop) requestCompletionApproval(issues []db.ChecklistIssue, total int) error {
+	title := "Approve task completion"
+	if task, err := r.db.GetTaskByID(r.session.TaskID); err == nil && task != nil {
+		title = fmt.Sprintf("Approve completion: %s", task.Title)
+	}
+
+	pending := make([]map[string]string, len(issues))
+	for i, issue := range issues {
+		pending[i] = map[string]string{
+			"item_id":     issue.ItemID,
+			"description": issue.Description,
+			"status":      issue.Status,
+			"notes":       issue.Notes,
+		}
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"iterations":      r.session.IterationCount,
+		"checklist_total": total,
+		"checklist_done":  total - len(issues),
+		"issues":          pending,
+		"summary":         r.getCompletionSummary(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval data: %w", err)
+	}
+
+	description := fmt.Sprintf("The agent signaled completion after %d iterations with %d of %d checklist items done.",
+		r.session.IterationCount, total-len(issues), total)
+	taskID, sessionID := r.session.TaskID, r.session.ID
+	approval, err := r.db.CreateApproval(&taskID, &sessionID, db.ApprovalTypeTaskCompletion, title, &description, data)
+	if err != nil {
+		return err
+	}
+
+	r.activity.Debug(r.session.IterationCount, fmt.Sprintf("Completion awaiting approval %s", approval.ID))
+	r.broadcastEvent(realtime.EventApprovalRequired, map[string]any{
+		"session_id":  r.session.ID,
+		"approval_id": approval.ID,
+		"reason":      ErrCompletionApprovalRequired.Error(),
+	})
+	return nil
+}
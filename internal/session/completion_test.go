@@ -0,0 +1,40 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestEvaluateCompletion(t *testing.T) {
+	strict := &db.CompletionPolicy{Strictness: db.CompletionStrictnessStrict}
+	lenient := &db.CompletionPolicy{Strictness: db.CompletionStrictnessLenient, MinDoneRatio: 0.75}
+	manual := &db.CompletionPolicy{Strictness: db.CompletionStrictnessManual}
+
+	tests := []struct {
+		name         string
+		policy       *db.CompletionPolicy
+		done, total  int
+		acknowledged bool
+		want         completionDecision
+	}{
+		{"strict all done", strict, 4, 4, false, completionAllowed},
+		{"strict no checklist", strict, 0, 0, false, completionAllowed},
+		{"strict pending", strict, 3, 4, false, completionNeedsResolution},
+		{"strict acknowledged", strict, 1, 4, true, completionAllowed},
+		{"lenient at ratio", lenient, 3, 4, false, completionAllowed},
+		{"lenient below ratio", lenient, 2, 4, false, completionNeedsResolution},
+		{"lenient default ratio", &db.CompletionPolicy{Strictness: db.CompletionStrictnessLenient}, 4, 5, false, completionAllowed},
+		{"manual all done", manual, 4, 4, false, completionNeedsApproval},
+		{"manual acknowledged", manual, 1, 4, true, completionNeedsApproval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateCompletion(tt.policy, tt.done, tt.total, tt.acknowledged)
+			if got != tt.want {
+				t.Errorf("evaluateCompletion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+const (
+	// contextRecoveryTargetPct is the share of the context window that forced
+	// compaction aims for after the API rejects a request as too long. It's well
+	// below the usual 35% target since the estimate has just proven optimistic.
+	contextRecoveryTargetPct = 20
+
+	// contextRecoveryKeepToolResults is how many of the most recent tool result
+	// messages keep their content when older ones are pruned.
+	contextRecoveryKeepToolResults = 2
+)
+
+// prunedToolResultContent replaces the content of pruned tool results
+const prunedToolResultContent = "[tool result removed to fit the context window]"
+
+// isContextLengthError reports whether err is the API rejecting a request
+// that doesn't fit in the model's context window
+func isContextLengthError(err error) bool {
+	var apiErr *toolbelt.AnthropicAPIError
+	return errors.As(err, &apiErr) && apiErr.IsContextLengthError()
+}
+
+// recoverFromContextLength shrinks the conversation after the API rejected it
+// as too long and retries: first with forced compaction, then with old tool
+// results pruned. The token estimate can under-count, so an over-long request
+// is treated as a recoverable event rather than a session failure. cause is
+// returned (wrapped) only when neither step makes the request fit.
+func (r *RalphLoop) recoverFromContextLength(cause error, retry func() (*toolbelt.AnthropicChatResponse, error)) (*toolbelt.AnthropicChatResponse, error) {
+	iteration := r.session.IterationCount + 1
+	fmt.Printf("RalphLoop: request exceeded the context window (%d messages), compacting and retrying\n", len(r.messages))
+	if r.activity != nil {
+		r.activity.DebugError(iteration, "Request exceeded the context window, compacting and retrying", map[string]any{"error": cause.Error()})
+	}
+
+	if r.contextGuard != nil {
+		compacted, err := r.contextGuard.CompactToTarget(r.messages, r.session.Scratchpad, contextRecoveryTargetPct)
+		if err != nil {
+			fmt.Printf("RalphLoop: warning - forced compaction failed: %v\n", err)
+		} else {
+			r.messages = compacted
+			r.checkpointRecoveredContext()
+			response, err := retry()
+			if !isContextLengthError(err) {
+				return response, err
+			}
+			cause = err
+		}
+	}
+
+	pruned, count := pruneOldestToolResults(r.messages, contextRecoveryKeepToolResults)
+	if count == 0 {
+		return nil, fmt.Errorf("request exceeds the context window and can't be shrunk further: %w", cause)
+	}
+	fmt.Printf("RalphLoop: still over the context window, pruned %d old tool results and retrying\n", count)
+	if r.activity != nil {
+		r.activity.Debug(iteration, fmt.Sprintf("Still over the context window, pruned %d old tool results and retrying", count))
+	}
+	r.messages = pruned
+	r.checkpointRecoveredContext()
+
+	response, err := retry()
+	if isContextLengthError(err) {
+		return nil, fmt.Errorf("request exceeds the context window even after compaction and pruning: %w", err)
+	}
+	return response, err
+}
+
+// checkpointRecoveredContext saves the shrunk history so a restart doesn't
+// resume from the conversation that was too long
+func (r *RalphLoop) checkpointRecoveredContext() {
+	if err := r.checkpoint(); err != nil {
+		fmt.Printf("RalphLoop: warning - checkpoint after context recovery failed: %v\n", err)
+	}
+}
+
+// pruneOldestToolResults replaces the content of all but the keep most recent
+// tool result messages with a short placeholder. The tool_result blocks stay
+// so every tool_use still has its result. Returns the new history and the
+// number of messages pruned; the input slice is left untouched.
+func pruneOldestToolResults(messages []toolbelt.AnthropicMessage, keep int) ([]toolbelt.AnthropicMessage, int) {
+	var toolIndices []int
+	for i, msg := range messages {
+		if msg.Role == "user" && hasToolResponse(msg) {
+			toolIndices = append(toolIndices, i)
+		}
+	}
+	if len(toolIndices) <= keep {
+		return messages, 0
+	}
+
+	result := make([]toolbelt.AnthropicMessage, len(messages))
+	copy(result, messages)
+	count := 0
+	for _, i := range toolIndices[:len(toolIndices)-keep] {
+		if content, ok := prunedToolResults(result[i].Content); ok {
+			result[i].Content = content
+			count++
+		}
+	}
+	return result, count
+}
+
+// prunedToolResults returns a copy of content with each tool result's content
+// replaced by the placeholder, and whether anything changed
+func prunedToolResults(content any) (any, bool) {
+	changed := false
+	switch c := content.(type) {
+	case []toolbelt.ContentBlock:
+		blocks := make([]toolbelt.ContentBlock, len(c))
+		copy(blocks, c)
+		for i := range blocks {
+			if blocks[i].Type == "tool_result" && blocks[i].Content != prunedToolResultContent {
+				blocks[i].Content = prunedToolResultContent
+				changed = true
+			}
+		}
+		return blocks, changed
+	case []any:
+		blocks := make([]any, len(c))
+		for i, block := range c {
+			blocks[i] = block
+			blockMap, ok := block.(map[string]any)
+			if !ok || blockMap["type"] != "tool_result" || blockMap["content"] == prunedToolResultContent {
+				continue
+			}
+			pruned := make(map[string]any, len(blockMap))
+			for k, v := range blockMap {
+				pruned[k] = v
+			}
+			pruned["content"] = prunedToolResultContent
+			blocks[i] = pruned
+			changed = true
+		}
+		return blocks, changed
+	}
+	return content, false
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestIsContextLengthError(t *testing.T) {
+	tooLong := &toolbelt.AnthropicAPIError{StatusCode: 400, Type: "invalid_request_error", Message: "prompt is too long: 215000 tokens > 200000 maximum"}
+	if !isContextLengthError(tooLong) {
+		t.Error("expected prompt-too-long error to be a context length error")
+	}
+	if !isContextLengthError(fmt.Errorf("request failed: %w", tooLong)) {
+		t.Error("expected wrapped prompt-too-long error to be a context length error")
+	}
+
+	others := []error{
+		nil,
+		fmt.Errorf("connection reset"),
+		&toolbelt.AnthropicAPIError{StatusCode: 400, Type: "invalid_request_error", Message: "credit balance is too low"},
+		&toolbelt.AnthropicAPIError{StatusCode: 429, Type: "rate_limit_error", Message: "rate limited"},
+	}
+	for _, err := range others {
+		if isContextLengthError(err) {
+			t.Errorf("expected %v not to be a context length error", err)
+		}
+	}
+}
+
+func TestPruneOldestToolResults(t *testing.T) {
+	messages := []toolbelt.AnthropicMessage{
+		{Role: "user", Content: "task"},
+		{Role: "assistant", Content: []toolbelt.ContentBlock{{Type: "tool_use", ID: "t1"}}},
+		{Role: "user", Content: []toolbelt.ContentBlock{{Type: "tool_result", ToolUseID: "t1", Content: "old result"}}},
+		{Role: "assistant", Content: []any{map[string]any{"type": "tool_use", "id": "t2"}}},
+		{Role: "user", Content: []any{map[string]any{"type": "tool_result", "tool_use_id": "t2", "content": "restored result"}}},
+		{Role: "assistant", Content: []toolbelt.ContentBlock{{Type: "tool_use", ID: "t3"}}},
+		{Role: "user", Content: []toolbelt.ContentBlock{{Type: "tool_result", ToolUseID: "t3", Content: "recent result"}}},
+	}
+
+	pruned, count := pruneOldestToolResults(messages, 1)
+	if count != 2 {
+		t.Fatalf("expected 2 tool results pruned, got %d", count)
+	}
+	if len(pruned) != len(messages) {
+		t.Fatalf("pruning should keep every message, got %d of %d", len(pruned), len(messages))
+	}
+
+	first := pruned[2].Content.([]toolbelt.ContentBlock)[0]
+	if first.Content != prunedToolResultContent || first.ToolUseID != "t1" {
+		t.Errorf("expected oldest result replaced with its tool_use_id kept, got %+v", first)
+	}
+	restored := pruned[4].Content.([]any)[0].(map[string]any)
+	if restored["content"] != prunedToolResultContent || restored["tool_use_id"] != "t2" {
+		t.Errorf("expected restored result replaced with its tool_use_id kept, got %v", restored)
+	}
+	if recent := pruned[6].Content.([]toolbelt.ContentBlock)[0]; recent.Content != "recent result" {
+		t.Errorf("expected most recent result kept, got %q", recent.Content)
+	}
+
+	// The original history is left untouched
+	if messages[2].Content.([]toolbelt.ContentBlock)[0].Content != "old result" {
+		t.Error("pruning modified the original typed blocks")
+	}
+	if messages[4].Content.([]any)[0].(map[string]any)["content"] != "restored result" {
+		t.Error("pruning modified the original map blocks")
+	}
+
+	// Nothing left to prune the second time round
+	if _, count := pruneOldestToolResults(pruned, 1); count != 0 {
+		t.Errorf("expected nothing to prune again, got %d", count)
+	}
+}
+
+func TestPruneOldestToolResults_TooFew(t *testing.T) {
+	messages := []toolbelt.AnthropicMessage{
+		{Role: "user", Content: "task"},
+		{Role: "user", Content: []toolbelt.ContentBlock{{Type: "tool_result", Content: "result"}}},
+	}
+	if _, count := pruneOldestToolResults(messages, 2); count != 0 {
+		t.Errorf("expected nothing pruned with fewer results than kept, got %d", count)
+	}
+}
+
+func TestContextGuard_CompactToTarget(t *testing.T) {
+	guard := NewContextGuard(nil)
+	guard.SetThresholds(10000, 40, 50)
+
+	// Well under the usual compaction threshold, so CheckAndCompact leaves it alone
+	messages := []toolbelt.AnthropicMessage{{Role: "user", Content: "task"}}
+	for i := 0; i < 8; i++ {
+		messages = append(messages,
+			toolbelt.AnthropicMessage{Role: "assistant", Content: fmt.Sprintf("step %d", i)},
+			toolbelt.AnthropicMessage{Role: "user", Content: []toolbelt.ContentBlock{{Type: "tool_result", Content: strings.Repeat("x", 1000)}}},
+		)
+	}
+	if _, compacted, _ := guard.CheckAndCompact(messages, "", ""); compacted {
+		t.Fatal("expected no compaction below the threshold")
+	}
+
+	compacted, err := guard.CompactToTarget(messages, "", 10)
+	if err != nil {
+		t.Fatalf("CompactToTarget failed: %v", err)
+	}
+	if tokens := EstimateTokens(compacted, ""); tokens >= 1000 {
+		t.Errorf("expected compaction under 1000 tokens, got %d", tokens)
+	}
+}
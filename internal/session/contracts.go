@@ -116,6 +116,18 @@ func GetSubscribers(topic string) []string {
 	return subscribers
 }
 
+// hatPriority orders hats for ambiguous cases, when several subscribe to a
+// topic: the lowest number wins
+var hatPriority = map[string]int{
+	"planner":  1,
+	"designer": 2,
+	"creator":  3,
+	"critic":   4,
+	"editor":   5,
+	"resolver": 6,
+	"explorer": 7,
+}
+
 // GetNextHatForTopic returns the primary hat that should handle a topic
 // Uses priority: most specific subscriber wins
 func GetNextHatForTopic(topic string) string {
@@ -124,23 +136,12 @@ func GetNextHatForTopic(topic string) string {
 		return ""
 	}
 
-	// Priority order for ambiguous cases
-	priority := map[string]int{
-		"planner":  1,
-		"designer": 2,
-		"creator":  3,
-		"critic":   4,
-		"editor":   5,
-		"resolver": 6,
-		"explorer": 7,
-	}
-
 	// Return lowest priority number (highest priority)
 	bestHat := subscribers[0]
-	bestPriority := priority[bestHat]
+	bestPriority := hatPriority[bestHat]
 
 	for _, hat := range subscribers[1:] {
-		if p, ok := priority[hat]; ok && p < bestPriority {
+		if p, ok := hatPriority[hat]; ok && p < bestPriority {
 			bestHat = hat
 			bestPriority = p
 		}
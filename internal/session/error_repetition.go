@@ -0,0 +1,103 @@
+package session
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxIdenticalErrors is how many times a tool may fail with the same
+// error before the session is steered away from its approach
+const DefaultMaxIdenticalErrors = 3
+
+// maxErrorExcerpt caps how much of the repeated error the steering message quotes
+const maxErrorExcerpt = 500
+
+// ErrorRepetitionTracker detects a tool failing with the same error again and
+// again across iterations, such as a compile error the agent keeps
+// re-triggering. Unlike RepetitionInspector, the failing calls need not be
+// consecutive or identical: edits in between that don't fix the error still count.
+type ErrorRepetitionTracker struct {
+	mu sync.Mutex
+
+	maxRepeats int                       // Identical errors before steering
+	counts     map[[sha256.Size]byte]int // Failures per tool and normalized error
+}
+
+// NewErrorRepetitionTracker creates a tracker with the default threshold
+func NewErrorRepetitionTracker() *ErrorRepetitionTracker {
+	return NewErrorRepetitionTrackerWithConfig(DefaultMaxIdenticalErrors)
+}
+
+// NewErrorRepetitionTrackerWithConfig creates a tracker that steers after
+// maxRepeats identical errors
+func NewErrorRepetitionTrackerWithConfig(maxRepeats int) *ErrorRepetitionTracker {
+	return &ErrorRepetitionTracker{
+		maxRepeats: maxRepeats,
+		counts:     make(map[[sha256.Size]byte]int),
+	}
+}
+
+// RecordError counts a failed tool call. Once the tool has failed with the
+// same error maxRepeats times, and again every maxRepeats times after, it
+// returns a message steering the agent to a different approach; otherwise "".
+func (t *ErrorRepetitionTracker) RecordError(toolName, output string) string {
+	normalized := normalizeToolError(output)
+	if normalized == "" || t.maxRepeats <= 0 {
+		return ""
+	}
+
+	t.mu.Lock()
+	key := sha256.Sum256([]byte(toolName + "\x00" + normalized))
+	t.counts[key]++
+	count := t.counts[key]
+	t.mu.Unlock()
+
+	if count%t.maxRepeats != 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"%s has now failed %d times with the same error:\n\n%s\n\n"+
+			"This approach keeps failing. Don't retry it as is: re-read the error and the code it points to, "+
+			"question the assumption behind your fix, and try something different.",
+		toolName, count, truncateOutput(strings.TrimSpace(output), maxErrorExcerpt))
+}
+
+// Reset forgets the errors seen so far (e.g., on user message or hat transition)
+func (t *ErrorRepetitionTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counts = make(map[[sha256.Size]byte]int)
+}
+
+// volatileErrorParts match the parts of tool output that differ between runs
+// of the same failure, each with what it's replaced by
+var volatileErrorParts = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	// 2026-01-02T15:04:05.123Z, 2026/01/02 15:04:05
+	{regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	// Pointers and other addresses: 0xc000123456
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "0x?"},
+	// Elapsed times: (0.01s), 1.234s, 250ms, 1m30s
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(h|m|s|ms|µs|us|ns))+\b`), "<duration>"},
+	// Goroutine numbers in panics
+	{regexp.MustCompile(`\bgoroutine \d+\b`), "goroutine ?"},
+	// Build and temp directories: /tmp/go-build1234567890/, /tmp/TestFoo123456/
+	{regexp.MustCompile(`(/tmp/[A-Za-z_-]*?)\d{4,}`), "${1}?"},
+}
+
+// normalizeToolError reduces tool output to what stays the same when the same
+// error recurs: timestamps, durations, addresses, and temp directories are
+// masked and whitespace collapsed
+func normalizeToolError(output string) string {
+	for _, part := range volatileErrorParts {
+		output = part.pattern.ReplaceAllString(output, part.replace)
+	}
+	return strings.Join(strings.Fields(output), " ")
+}
@@ -0,0 +1,122 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestErrorRepetitionTracker_SteersOnRepeatedError(t *testing.T) {
+	tracker := NewErrorRepetitionTrackerWithConfig(3)
+	compileError := "main.go:12:2: undefined: handler\nexit status 1"
+
+	if msg := tracker.RecordError("run_command", compileError); msg != "" {
+		t.Fatalf("first error steered: %q", msg)
+	}
+	// Other errors in between don't reset the count, and whitespace doesn't matter
+	tracker.RecordError("run_command", "main.go:3:1: syntax error")
+	tracker.RecordError("read_file", compileError)
+	if msg := tracker.RecordError("run_command", "  main.go:12:2: undefined: handler\n\nexit status 1\n"); msg != "" {
+		t.Fatalf("second error steered: %q", msg)
+	}
+
+	msg := tracker.RecordError("run_command", compileError)
+	if !strings.Contains(msg, "run_command has now failed 3 times") || !strings.Contains(msg, "undefined: handler") {
+		t.Errorf("third error steering = %q, want the tool, count, and error", msg)
+	}
+
+	// Steers again after another round rather than on every repeat
+	if msg := tracker.RecordError("run_command", compileError); msg != "" {
+		t.Errorf("fourth error steered: %q", msg)
+	}
+	tracker.RecordError("run_command", compileError)
+	if msg := tracker.RecordError("run_command", compileError); !strings.Contains(msg, "failed 6 times") {
+		t.Errorf("sixth error steering = %q", msg)
+	}
+}
+
+func TestNormalizeToolError_MasksVolatileParts(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		second   string
+		wantSame bool
+	}{
+		{
+			name: "go test failure with timings",
+			first: "--- FAIL: TestLogin (0.02s)\n    auth_test.go:41: got 401, want 200\n" +
+				"FAIL\nFAIL\tgithub.com/acme/app/auth\t0.153s\nFAIL",
+			second: "--- FAIL: TestLogin (0.01s)\n    auth_test.go:41: got 401, want 200\n" +
+				"FAIL\nFAIL\tgithub.com/acme/app/auth\t1.204s\nFAIL",
+			wantSame: true,
+		},
+		{
+			name: "go build in a fresh temp dir",
+			first: "# github.com/acme/app\n/tmp/go-build3361485937/b001/_testmain.go:14:2: undefined: handler\n" +
+				"2026-03-04T10:15:02.123Z build failed after 1m2.5s",
+			second: "# github.com/acme/app\n/tmp/go-build1129734012/b001/_testmain.go:14:2: undefined: handler\n" +
+				"2026-03-04T10:17:45.981Z build failed after 58.1s",
+			wantSame: true,
+		},
+		{
+			name: "panic with addresses and goroutine numbers",
+			first: "panic: runtime error: invalid memory address or nil pointer dereference\n" +
+				"[signal SIGSEGV: segmentation violation code=0x1 addr=0x18 pc=0x5f2c3a]\n\ngoroutine 7 [running]:\n" +
+				"github.com/acme/app/auth.(*Store).Get(0x0, {0xc00001e0c0, 0x5})",
+			second: "panic: runtime error: invalid memory address or nil pointer dereference\n" +
+				"[signal SIGSEGV: segmentation violation code=0x1 addr=0x18 pc=0x5f2d10]\n\ngoroutine 23 [running]:\n" +
+				"github.com/acme/app/auth.(*Store).Get(0x0, {0xc000112040, 0x5})",
+			wantSame: true,
+		},
+		{
+			name:     "different errors stay different",
+			first:    "main.go:12:2: undefined: handler",
+			second:   "main.go:12:2: undefined: router",
+			wantSame: false,
+		},
+		{
+			name:     "line numbers are kept",
+			first:    "--- FAIL: TestLogin (0.02s)\n    auth_test.go:41: got 401",
+			second:   "--- FAIL: TestLogin (0.02s)\n    auth_test.go:52: got 401",
+			wantSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second := normalizeToolError(tt.first), normalizeToolError(tt.second)
+			if (first == second) != tt.wantSame {
+				t.Errorf("normalized outputs equal = %v, want %v\n  first:  %q\n  second: %q", first == second, tt.wantSame, first, second)
+			}
+		})
+	}
+}
+
+func TestErrorRepetitionTracker_Reset(t *testing.T) {
+	tracker := NewErrorRepetitionTrackerWithConfig(2)
+
+	tracker.RecordError("run_command", "exit status 1")
+	tracker.Reset()
+	if msg := tracker.RecordError("run_command", "exit status 1"); msg != "" {
+		t.Errorf("error after reset steered: %q", msg)
+	}
+	if msg := tracker.RecordError("run_command", "  "); msg != "" {
+		t.Errorf("empty error steered: %q", msg)
+	}
+}
+
+func TestLoopHealth_RecordToolError(t *testing.T) {
+	health := NewLoopHealth()
+
+	var msg string
+	for range DefaultMaxIdenticalErrors {
+		msg = health.RecordToolError("run_command", "FAIL: TestLogin")
+	}
+	if msg == "" {
+		t.Errorf("expected steering after %d identical errors", DefaultMaxIdenticalErrors)
+	}
+
+	health.ResetRepetition()
+	if msg := health.RecordToolError("run_command", "FAIL: TestLogin"); msg != "" {
+		t.Errorf("error after ResetRepetition steered: %q", msg)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lirancohen/dex/internal/markdown"
 )
 
 // Event represents a pub/sub event for hat coordination
@@ -49,16 +50,22 @@ func IsTerminalEvent(topic string) bool {
 	return topic == TopicTaskComplete
 }
 
-// ParseEvent extracts an EVENT:topic or EVENT:topic:{"json"} from text
+// ParseEvent extracts an EVENT:topic or EVENT:topic:{"json"} from text,
+// ignoring events inside fenced code blocks or inline code.
 // Returns the Event and true if found, nil and false otherwise
 func ParseEvent(text, sessionID, sourceHat string) (*Event, bool) {
-	idx := strings.Index(text, SignalEvent)
+	return parseEventWithPrefix(text, SignalEvent, sessionID, sourceHat)
+}
+
+// parseEventWithPrefix is ParseEvent for a configured event signal prefix
+func parseEventWithPrefix(text, prefix, sessionID, sourceHat string) (*Event, bool) {
+	idx := strings.Index(markdown.MaskCode(text), prefix)
 	if idx == -1 {
 		return nil, false
 	}
 
 	// Extract content after EVENT:
-	remaining := text[idx+len(SignalEvent):]
+	remaining := text[idx+len(prefix):]
 
 	// Find end of topic (whitespace, newline, or end of string)
 	// Also handle EVENT:topic:{"payload"} format
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/security"
 	"github.com/lirancohen/dex/internal/toolbelt"
 	"github.com/lirancohen/dex/internal/tools"
 )
@@ -44,6 +45,14 @@ type ToolExecutor struct {
 	activity *ActivityRecorder
 	// Mail/calendar tool executor (optional - for Zoho Mail integration via Central)
 	mailExecutor mailToolHandler
+	// Blocks commits whose staged changes contain likely secrets
+	secretScanner *security.SecretScanner
+	// Trailer added to commits made through git_commit, e.g. "Assisted-by: Dex" (optional)
+	commitTrailer string
+	// Task's base branch; git_undo_commit won't reset commits already on it (optional)
+	baseBranch string
+	// Reuses read-only tool results until a write may have changed them (optional)
+	resultCache *tools.ResultCache
 }
 
 // NewToolExecutor creates a new ToolExecutor
@@ -54,6 +63,8 @@ func NewToolExecutor(worktreePath string, gitOps *git.Operations, githubClient *
 		githubClient: githubClient,
 		owner:        owner,
 		repo:         repo,
+
+		secretScanner: security.NewSecretScanner(security.DefaultSecretPatterns(), nil),
 	}
 }
 
@@ -84,9 +95,56 @@ func (e *ToolExecutor) SetMailExecutor(me mailToolHandler) {
 	e.mailExecutor = me
 }
 
-// Execute runs a tool with the given input and returns the result
-// Overrides base executor for tools that need git.Operations or GitHub client
+// SetSecretScanner sets the scanner run over staged changes before each commit
+func (e *ToolExecutor) SetSecretScanner(scanner *security.SecretScanner) {
+	e.secretScanner = scanner
+}
+
+// SetCommitTrailer sets the trailer added to commits made through git_commit
+// (empty adds none)
+func (e *ToolExecutor) SetCommitTrailer(trailer string) {
+	e.commitTrailer = trailer
+}
+
+// SetBaseBranch sets the task's base branch, whose commits git_undo_commit
+// won't reset
+func (e *ToolExecutor) SetBaseBranch(branch string) {
+	e.baseBranch = branch
+}
+
+// SetResultCache sets the cache for read-only tool results (nil disables caching)
+func (e *ToolExecutor) SetResultCache(cache *tools.ResultCache) {
+	e.resultCache = cache
+}
+
+// Execute runs a tool with the given input and returns the result, serving
+// repeated read-only calls from the result cache if one is set
 func (e *ToolExecutor) Execute(ctx context.Context, toolName string, input map[string]any) ToolResult {
+	if e.resultCache == nil {
+		return e.execute(ctx, toolName, input)
+	}
+
+	if output, ok := e.resultCache.Get(toolName, input); ok {
+		return ToolResult{Output: output + tools.CacheHitNote, IsError: false}
+	}
+
+	result := e.execute(ctx, toolName, input)
+	e.resultCache.Invalidate(toolName, input)
+	if !result.IsError {
+		e.resultCache.Put(toolName, input, result.Output)
+	}
+	return result
+}
+
+// ExecuteStreaming runs a tool like Execute, sending the output of commands it
+// runs (bash, and the tests, lint, and build checks) to onOutput as they run
+func (e *ToolExecutor) ExecuteStreaming(ctx context.Context, toolName string, input map[string]any, onOutput tools.OutputFunc) ToolResult {
+	return e.Execute(tools.WithOutput(ctx, onOutput), toolName, input)
+}
+
+// execute runs a tool without the result cache
+// Overrides base executor for tools that need git.Operations or GitHub client
+func (e *ToolExecutor) execute(ctx context.Context, toolName string, input map[string]any) ToolResult {
 	var result ToolResult
 
 	switch toolName {
@@ -95,6 +153,8 @@ func (e *ToolExecutor) Execute(ctx context.Context, toolName string, input map[s
 		result = e.executeGitDiff(input)
 	case "git_commit":
 		result = e.executeGitCommit(input)
+	case "git_undo_commit":
+		result = e.executeGitUndoCommit(input)
 	case "git_push":
 		result = e.executeGitPush(input)
 	case "git_remote_add":
@@ -199,9 +259,15 @@ func (e *ToolExecutor) executeGitCommit(input map[string]any) ToolResult {
 		}
 	}
 
-	hash, err := e.gitOps.Commit(e.WorkDir(), git.CommitOptions{
-		Message: message,
-	})
+	if result, blocked := e.scanStagedSecrets(); blocked {
+		return result
+	}
+
+	opts := git.CommitOptions{Message: message}
+	if e.commitTrailer != "" {
+		opts.Trailers = []string{e.commitTrailer}
+	}
+	hash, err := e.gitOps.Commit(e.WorkDir(), opts)
 	if err != nil {
 		return ToolResult{
 			Output:  fmt.Sprintf("git commit failed: %v", err),
@@ -215,6 +281,60 @@ func (e *ToolExecutor) executeGitCommit(input map[string]any) ToolResult {
 	}
 }
 
+func (e *ToolExecutor) executeGitUndoCommit(input map[string]any) ToolResult {
+	if e.gitOps == nil {
+		return ToolResult{Output: "Git operations not configured", IsError: true}
+	}
+
+	mode, _ := input["mode"].(string)
+	if mode != "" && !git.IsValidUndoMode(mode) {
+		return ToolResult{Output: "mode must be soft or revert", IsError: true}
+	}
+
+	result, err := e.gitOps.UndoLastCommit(e.WorkDir(), git.UndoCommitOptions{
+		Mode:       git.UndoMode(mode),
+		ProtectRef: e.baseBranch,
+	})
+	if err != nil {
+		return ToolResult{
+			Output:  fmt.Sprintf("git undo commit failed: %v", err),
+			IsError: true,
+		}
+	}
+
+	return ToolResult{Output: result.Summary(), IsError: false}
+}
+
+// scanStagedSecrets checks the staged diff for likely secrets. If any are found
+// the commit is blocked with file and line feedback so the model removes them.
+func (e *ToolExecutor) scanStagedSecrets() (ToolResult, bool) {
+	if e.secretScanner == nil {
+		return ToolResult{}, false
+	}
+
+	diff, err := e.gitOps.GetDiff(e.WorkDir(), git.DiffOptions{Staged: true})
+	if err != nil {
+		return ToolResult{
+			Output:  fmt.Sprintf("Failed to scan staged changes for secrets: %v", err),
+			IsError: true,
+		}, true
+	}
+
+	findings := e.secretScanner.ScanDiff(diff)
+	if len(findings) == 0 {
+		return ToolResult{}, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Commit blocked: staged changes appear to contain secrets:\n")
+	for _, f := range findings {
+		sb.WriteString(fmt.Sprintf("- %s\n", f))
+	}
+	sb.WriteString("\nRemove these values from the files (read them from environment variables or a secret store instead), stage the changes again, and retry the commit.")
+
+	return ToolResult{Output: sb.String(), IsError: true}, true
+}
+
 func (e *ToolExecutor) executeGitPush(input map[string]any) ToolResult {
 	if e.gitOps == nil {
 		return ToolResult{Output: "Git operations not configured", IsError: true}
@@ -0,0 +1,238 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/hints"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// Limits on the LLM call that explains a task's approach
+const (
+	explainTimeout   = 60 * time.Second
+	explainMaxTokens = 1024
+)
+
+// Confidence levels in a TaskExplanation
+const (
+	ExplainConfidenceHigh   = "high"
+	ExplainConfidenceMedium = "medium"
+	ExplainConfidenceLow    = "low"
+)
+
+// explainSystemPrompt sets the model up to review a session's prompt rather than act on it
+const explainSystemPrompt = `You review how an autonomous coding agent will approach a task before it starts. You are shown the system prompt and first message the agent will receive. Don't do the task or write code; explain the approach the agent is set up to take.`
+
+// explainPrompt asks for the explanation as JSON, given the hat, system
+// prompt, and first message of the session it explains
+const explainPrompt = `The agent will work in the %s hat.
+
+<system_prompt>
+%s
+</system_prompt>
+
+<first_message>
+%s
+</first_message>
+
+Reply with only a JSON object with these fields:
+- "approach": 3-6 sentences on how the agent will likely go about the task, in order, and what it will produce
+- "confidence": "high", "medium", or "low": how likely the agent is to finish the task as described with the context it has
+- "risks": 1-3 sentences on what could go wrong, what's ambiguous, or what's missing from the context`
+
+// TaskExplanation is a short account, written before a task runs, of how its
+// session would approach it
+type TaskExplanation struct {
+	TaskID       string    `json:"task_id"`
+	Hat          string    `json:"hat"`
+	Approach     string    `json:"approach"`
+	Confidence   string    `json:"confidence"` // high, medium, or low (empty if the model gave none)
+	Risks        string    `json:"risks"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+	Cached       bool      `json:"cached"` // Served from the task's cache rather than generated for this request
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+// ExplainTask explains how a session in hat would approach the task, without
+// starting one: it assembles the system prompt and first message the session
+// would get and asks Haiku, with no tools, for the approach, a confidence, and
+// the risks. The explanation is cached on the task until that prompt changes,
+// or refresh is set.
+func (m *Manager) ExplainTask(ctx context.Context, taskID, hat string, refresh bool) (*TaskExplanation, error) {
+	task, err := m.db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	loop := m.explainLoop(task, hat)
+	systemPrompt, err := loop.buildPrompt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+	firstMessage := loop.buildInitialMessage()
+	key := explanationKey(hat, systemPrompt, firstMessage)
+
+	if !refresh {
+		if cached := m.cachedExplanation(taskID, key); cached != nil {
+			return cached, nil
+		}
+	}
+
+	m.mu.RLock()
+	client := m.anthropicClient
+	m.mu.RUnlock()
+	if client == nil {
+		return nil, ErrNoAnthropicClient
+	}
+
+	explanation, err := generateExplanation(ctx, client, hat, systemPrompt, firstMessage)
+	if err != nil {
+		return nil, err
+	}
+	explanation.TaskID = taskID
+	explanation.Hat = hat
+
+	if data, err := json.Marshal(explanation); err != nil {
+		fmt.Printf("ExplainTask: warning - failed to encode explanation: %v\n", err)
+	} else if err := m.db.SetTaskExplanation(taskID, key, string(data)); err != nil {
+		fmt.Printf("ExplainTask: warning - failed to cache explanation: %v\n", err)
+	}
+	return explanation, nil
+}
+
+// explainLoop returns a loop set up as far as the task's session in hat would
+// be to build its prompt. It has no worktree, so attachments are described
+// rather than written; hints come from the task's worktree or the project's repo.
+func (m *Manager) explainLoop(task *db.Task, hat string) *RalphLoop {
+	session := &ActiveSession{TaskID: task.ID, ProjectID: task.ProjectID, Hat: hat}
+	loop := NewRalphLoop(m, session, nil, nil, m.db)
+	// Tool sets come back in map order; sort them so the prompt, and the cache key, are stable
+	slices.SortFunc(loop.tools, func(a, b toolbelt.AnthropicTool) int { return strings.Compare(a.Name, b.Name) })
+
+	m.mu.RLock()
+	promptSensitivity := m.promptSensitivity
+	m.mu.RUnlock()
+	if level, err := m.db.ResolvePromptSensitivity(task.ID); err != nil {
+		fmt.Printf("ExplainTask: warning - failed to resolve prompt sensitivity: %v\n", err)
+	} else if level != "" {
+		promptSensitivity = security.Sensitivity(level)
+	}
+	loop.SetPromptSensitivity(promptSensitivity)
+	// Describe attachments as the session will see them, without writing them anywhere
+	loop.attachmentsWritten = true
+
+	dir := task.GetWorktreePath()
+	if dir == "" {
+		if project, err := m.db.GetProjectByID(task.ProjectID); err == nil && project != nil {
+			dir = project.RepoPath
+		}
+	}
+	if dir != "" {
+		if _, err := os.Stat(dir); err == nil {
+			loop.hintsLoader = hints.NewLoader(dir)
+			loop.qualityGate = NewQualityGate(dir, nil)
+		}
+	}
+	return loop
+}
+
+// explanationKey identifies the prompt context an explanation was written from
+func explanationKey(hat, systemPrompt, firstMessage string) string {
+	sum := sha256.Sum256([]byte(hat + "\x00" + systemPrompt + "\x00" + firstMessage))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedExplanation returns the task's cached explanation if it was written
+// from the prompt context identified by key, otherwise nil
+func (m *Manager) cachedExplanation(taskID, key string) *TaskExplanation {
+	cached, cachedKey, err := m.db.GetTaskExplanation(taskID)
+	if err != nil {
+		fmt.Printf("ExplainTask: warning - failed to get cached explanation: %v\n", err)
+		return nil
+	}
+	if cached == "" || cachedKey != key {
+		return nil
+	}
+
+	var explanation TaskExplanation
+	if err := json.Unmarshal([]byte(cached), &explanation); err != nil {
+		fmt.Printf("ExplainTask: warning - ignoring unreadable cached explanation: %v\n", err)
+		return nil
+	}
+	explanation.Cached = true
+	return &explanation
+}
+
+// generateExplanation asks Haiku to explain the session the prompts set up
+func generateExplanation(ctx context.Context, client *toolbelt.AnthropicClient, hat, systemPrompt, firstMessage string) (*TaskExplanation, error) {
+	ctx, cancel := context.WithTimeout(ctx, explainTimeout)
+	defer cancel()
+
+	resp, err := client.Chat(ctx, &toolbelt.AnthropicChatRequest{
+		Model:     SummaryModelHaiku,
+		MaxTokens: explainMaxTokens,
+		System:    explainSystemPrompt,
+		Messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: fmt.Sprintf(explainPrompt, hat, systemPrompt, firstMessage)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("explanation API call failed: %w", err)
+	}
+
+	explanation, err := parseExplanation(resp.Text())
+	if err != nil {
+		return nil, err
+	}
+	inputRate, outputRate := ModelRates("haiku")
+	explanation.InputTokens = resp.Usage.InputTokens
+	explanation.OutputTokens = resp.Usage.OutputTokens
+	explanation.Cost = (float64(resp.Usage.InputTokens)*inputRate + float64(resp.Usage.OutputTokens)*outputRate) / 1_000_000
+	explanation.GeneratedAt = time.Now()
+	return explanation, nil
+}
+
+// parseExplanation reads the model's JSON reply, tolerating text or a code
+// fence around it. A confidence other than high, medium, or low is dropped.
+func parseExplanation(text string) (*TaskExplanation, error) {
+	var reply struct {
+		Approach   string `json:"approach"`
+		Confidence string `json:"confidence"`
+		Risks      string `json:"risks"`
+	}
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("explanation model returned no JSON")
+	}
+	if err := json.Unmarshal([]byte(extractJSON(text[start:])), &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse explanation: %w", err)
+	}
+	if strings.TrimSpace(reply.Approach) == "" {
+		return nil, fmt.Errorf("explanation model returned no approach")
+	}
+
+	explanation := &TaskExplanation{
+		Approach: strings.TrimSpace(reply.Approach),
+		Risks:    strings.TrimSpace(reply.Risks),
+	}
+	switch confidence := strings.ToLower(strings.TrimSpace(reply.Confidence)); confidence {
+	case ExplainConfidenceHigh, ExplainConfidenceMedium, ExplainConfidenceLow:
+		explanation.Confidence = confidence
+	}
+	return explanation, nil
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestParseExplanation(t *testing.T) {
+	explanation, err := parseExplanation("Here it is:\n```json\n{\"approach\": \" Add a login form. \", \"confidence\": \"High\", \"risks\": \"No design given.\"}\n```")
+	if err != nil {
+		t.Fatalf("parseExplanation() error = %v", err)
+	}
+	if explanation.Approach != "Add a login form." || explanation.Confidence != ExplainConfidenceHigh || explanation.Risks != "No design given." {
+		t.Errorf("parseExplanation() = %+v", explanation)
+	}
+
+	explanation, err = parseExplanation(`{"approach": "Fix the bug.", "confidence": "very sure"}`)
+	if err != nil {
+		t.Fatalf("parseExplanation() error = %v", err)
+	}
+	if explanation.Confidence != "" {
+		t.Errorf("unrecognized confidence kept as %q", explanation.Confidence)
+	}
+
+	for _, text := range []string{"I'd add a login form.", `{"confidence": "low"}`, `{"approach": `} {
+		if _, err := parseExplanation(text); err == nil {
+			t.Errorf("parseExplanation(%q) expected error", text)
+		}
+	}
+}
+
+func TestManager_ExplainTask_Cache(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Add login", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(database, nil, filepath.Join(t.TempDir(), "missing")) // Embedded prompts
+
+	// Nothing cached and no client to write one
+	if _, err := m.ExplainTask(context.Background(), task.ID, "creator", false); !errors.Is(err, ErrNoAnthropicClient) {
+		t.Fatalf("ExplainTask() error = %v, want ErrNoAnthropicClient", err)
+	}
+
+	loop := m.explainLoop(task, "creator")
+	systemPrompt, err := loop.buildPrompt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := explanationKey("creator", systemPrompt, loop.buildInitialMessage())
+	cached := `{"task_id":"` + task.ID + `","hat":"creator","approach":"Add a login form.","confidence":"medium"}`
+	if err := database.SetTaskExplanation(task.ID, key, cached); err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, err := m.ExplainTask(context.Background(), task.ID, "creator", false)
+	if err != nil {
+		t.Fatalf("ExplainTask() error = %v", err)
+	}
+	if !explanation.Cached || explanation.Approach != "Add a login form." {
+		t.Errorf("ExplainTask() = %+v, want the cached explanation", explanation)
+	}
+
+	// Another hat gets a different prompt, and refresh skips the cache
+	if _, err := m.ExplainTask(context.Background(), task.ID, "planner", false); !errors.Is(err, ErrNoAnthropicClient) {
+		t.Errorf("ExplainTask(planner) error = %v, want ErrNoAnthropicClient", err)
+	}
+	if _, err := m.ExplainTask(context.Background(), task.ID, "creator", true); !errors.Is(err, ErrNoAnthropicClient) {
+		t.Errorf("ExplainTask(refresh) error = %v, want ErrNoAnthropicClient", err)
+	}
+
+	if _, err := m.ExplainTask(context.Background(), "task-missing", "creator", false); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
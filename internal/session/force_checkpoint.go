@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+var (
+	ErrSessionNotRunning = errors.New("session is not running")
+	ErrSessionEnded      = errors.New("session ended before it could checkpoint")
+)
+
+// checkpointResult answers a forced checkpoint request
+type checkpointResult struct {
+	checkpoint *db.SessionCheckpoint
+	err        error
+}
+
+// RequestCheckpoint asks a running session to checkpoint at its next safe
+// point, between iterations, and waits for it. Requests made while one is
+// pending share its checkpoint. If ctx ends first the checkpoint is still
+// taken; only the wait is abandoned.
+func (m *Manager) RequestCheckpoint(ctx context.Context, sessionID string) (*db.SessionCheckpoint, error) {
+	result := make(chan checkpointResult, 1)
+
+	m.mu.Lock()
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	if session.State != StateRunning || session.checkpointsClosed {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotRunning, session.State)
+	}
+	session.checkpointWaiters = append(session.checkpointWaiters, result)
+	m.mu.Unlock()
+
+	select {
+	case r := <-result:
+		return r.checkpoint, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// takeCheckpointRequests returns and clears the session's pending forced
+// checkpoint requests. Closing refuses any made afterwards.
+func (r *RalphLoop) takeCheckpointRequests(closing bool) []chan checkpointResult {
+	if r.manager == nil {
+		return nil
+	}
+	r.manager.mu.Lock()
+	defer r.manager.mu.Unlock()
+	if closing {
+		r.session.checkpointsClosed = true
+	}
+	waiters := r.session.checkpointWaiters
+	r.session.checkpointWaiters = nil
+	return waiters
+}
+
+// serveCheckpointRequests takes a checkpoint if any were requested since the
+// last safe point and hands it to every requester
+func (r *RalphLoop) serveCheckpointRequests() {
+	waiters := r.takeCheckpointRequests(false)
+	if len(waiters) == 0 {
+		return
+	}
+
+	checkpoint, err := r.saveCheckpoint()
+	if err != nil {
+		fmt.Printf("RalphLoop.Run: warning - forced checkpoint failed: %v\n", err)
+	} else {
+		fmt.Printf("RalphLoop.Run: saved forced checkpoint %s at iteration %d\n", checkpoint.ID, r.session.IterationCount)
+	}
+	for _, waiter := range waiters {
+		waiter <- checkpointResult{checkpoint: checkpoint, err: err}
+	}
+}
+
+// closeCheckpointRequests answers requests still pending when the loop exits
+// with its final checkpoint, or ErrSessionEnded if it saved none
+func (r *RalphLoop) closeCheckpointRequests(checkpoint *db.SessionCheckpoint, err error) {
+	if checkpoint == nil && err == nil {
+		err = ErrSessionEnded
+	}
+	for _, waiter := range r.takeCheckpointRequests(true) {
+		waiter <- checkpointResult{checkpoint: checkpoint, err: err}
+	}
+}
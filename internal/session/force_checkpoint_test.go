@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestRequestCheckpoint(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Build", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(database, nil, t.TempDir())
+	sess, err := m.CreateSession(task.ID, "creator", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := m.RequestCheckpoint(ctx, sess.ID); !errors.Is(err, ErrSessionNotRunning) {
+		t.Errorf("checkpoint of a created session: err = %v, want ErrSessionNotRunning", err)
+	}
+	if _, err := m.RequestCheckpoint(ctx, "missing"); err == nil {
+		t.Error("checkpointed a missing session")
+	}
+
+	m.mu.Lock()
+	sess.State = StateRunning
+	sess.IterationCount = 4
+	m.mu.Unlock()
+	loop := &RalphLoop{
+		manager:  m,
+		session:  sess,
+		db:       database,
+		messages: []toolbelt.AnthropicMessage{{Role: "user", Content: "Begin"}},
+	}
+
+	// Two requests before the next safe point share one checkpoint
+	type answer struct {
+		checkpoint *db.SessionCheckpoint
+		err        error
+	}
+	answers := make(chan answer, 2)
+	for range 2 {
+		go func() {
+			checkpoint, err := m.RequestCheckpoint(ctx, sess.ID)
+			answers <- answer{checkpoint, err}
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		m.mu.RLock()
+		pending := len(sess.checkpointWaiters)
+		m.mu.RUnlock()
+		if pending == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	loop.serveCheckpointRequests()
+	first, second := <-answers, <-answers
+	if first.err != nil || second.err != nil {
+		t.Fatalf("forced checkpoint failed: %v, %v", first.err, second.err)
+	}
+	if first.checkpoint.ID != second.checkpoint.ID || first.checkpoint.Iteration != 4 {
+		t.Errorf("got checkpoints %s and %s at iteration %d, want one shared at 4",
+			first.checkpoint.ID, second.checkpoint.ID, first.checkpoint.Iteration)
+	}
+	latest, err := database.GetLatestSessionCheckpoint(sess.ID)
+	if err != nil || latest.ID != first.checkpoint.ID {
+		t.Errorf("latest checkpoint = %v, %v; want %s", latest, err, first.checkpoint.ID)
+	}
+
+	// A wait abandoned by the caller still gets its checkpoint taken
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := m.RequestCheckpoint(waitCtx, sess.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("abandoned wait: err = %v, want DeadlineExceeded", err)
+	}
+	loop.serveCheckpointRequests()
+
+	// Once the loop exits, requests are refused
+	loop.closeCheckpointRequests(nil, nil)
+	if _, err := m.RequestCheckpoint(ctx, sess.ID); !errors.Is(err, ErrSessionNotRunning) {
+		t.Errorf("checkpoint after the loop exited: err = %v, want ErrSessionNotRunning", err)
+	}
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// SetGitHubClient sets the global GitHub client, used by projects without
+// their own git credentials
+func (m *Manager) SetGitHubClient(client *toolbelt.GitHubClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.githubClient = client
+}
+
+// SetGitCredentialStore sets where per-project git credentials are read from
+func (m *Manager) SetGitCredentialStore(store *db.EncryptedSecretsStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gitCredentials = store
+}
+
+// resolveGitHubClient returns the GitHub client a session on project should
+// use: one from the project's own credentials if it has them, otherwise the
+// global client. Forgejo-hosted projects get nil, since their pushes go through
+// the Forgejo bot.
+func (m *Manager) resolveGitHubClient(ctx context.Context, project *db.Project) *toolbelt.GitHubClient {
+	if project.IsForgejo() {
+		return nil
+	}
+
+	m.mu.RLock()
+	global := m.githubClient
+	store := m.gitCredentials
+	m.mu.RUnlock()
+
+	if store == nil {
+		return global
+	}
+
+	client, err := m.projectGitHubClient(ctx, store, project.ID)
+	if err != nil {
+		fmt.Printf("runSession: warning - failed to use git credentials for project %s, falling back to global credentials: %v\n", project.ID, err)
+		return global
+	}
+	if client == nil {
+		return global
+	}
+	return client
+}
+
+// projectGitHubClient builds a GitHub client from a project's own credentials,
+// or returns nil if it has none
+func (m *Manager) projectGitHubClient(ctx context.Context, store *db.EncryptedSecretsStore, projectID string) (*toolbelt.GitHubClient, error) {
+	creds, err := store.GetProjectGitCredentials(projectID)
+	if err != nil || creds == nil {
+		return nil, err
+	}
+
+	if creds.Token != "" {
+		return toolbelt.NewGitHubClient(&toolbelt.GitHubConfig{Token: creds.Token}), nil
+	}
+
+	app, err := store.GetGitHubAppCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("project uses GitHub App installation %s but no GitHub App is configured", creds.Installation)
+	}
+	return toolbelt.NewGitHubAppInstallationClient(ctx, app.AppID, app.PrivateKey, creds.Installation)
+}
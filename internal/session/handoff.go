@@ -2,14 +2,120 @@
 package session
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/git"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/toolbelt"
 )
 
+// HandoffSection is a part of a handoff summary that can be included or left out
+type HandoffSection string
+
+const (
+	HandoffSectionDone      HandoffSection = "done"       // What was done
+	HandoffSectionState     HandoffSection = "state"      // Branch, progress, and key decisions
+	HandoffSectionNextSteps HandoffSection = "next_steps" // Remaining checklist items
+	HandoffSectionBlockers  HandoffSection = "blockers"   // Failed items and open issues
+	HandoffSectionKeyFiles  HandoffSection = "key_files"  // Files changed in the worktree
+	HandoffSectionVerify    HandoffSection = "verify"     // What the next hat should check
+)
+
+// HandoffSections lists every handoff section in the order they're written
+var HandoffSections = []HandoffSection{
+	HandoffSectionDone,
+	HandoffSectionState,
+	HandoffSectionNextSteps,
+	HandoffSectionBlockers,
+	HandoffSectionKeyFiles,
+	HandoffSectionVerify,
+}
+
+// DefaultHandoffSections are written for hats without sections of their own
+var DefaultHandoffSections = []HandoffSection{
+	HandoffSectionDone,
+	HandoffSectionState,
+	HandoffSectionNextSteps,
+	HandoffSectionBlockers,
+	HandoffSectionKeyFiles,
+}
+
+// hatHandoffSections tailor a handoff to what the receiving hat needs
+var hatHandoffSections = map[string][]HandoffSection{
+	"critic":   {HandoffSectionDone, HandoffSectionVerify, HandoffSectionKeyFiles, HandoffSectionBlockers},
+	"editor":   {HandoffSectionDone, HandoffSectionState, HandoffSectionKeyFiles},
+	"creator":  {HandoffSectionState, HandoffSectionNextSteps, HandoffSectionBlockers, HandoffSectionKeyFiles},
+	"resolver": {HandoffSectionBlockers, HandoffSectionState, HandoffSectionNextSteps, HandoffSectionKeyFiles},
+}
+
+// HandoffSectionsForHat returns the sections a handoff to hat includes by default
+func HandoffSectionsForHat(hat string) []HandoffSection {
+	if sections, ok := hatHandoffSections[hat]; ok {
+		return sections
+	}
+	return DefaultHandoffSections
+}
+
+// ParseHandoffSections parses comma-separated section names (e.g.
+// "done,next_steps"). An empty string returns nil, which tailors the
+// sections to the receiving hat.
+func ParseHandoffSections(s string) ([]HandoffSection, error) {
+	var sections []HandoffSection
+	for _, part := range strings.Split(s, ",") {
+		section := HandoffSection(strings.TrimSpace(part))
+		if section == "" {
+			continue
+		}
+		if !slices.Contains(HandoffSections, section) {
+			return nil, fmt.Errorf("unknown handoff section %q", section)
+		}
+		if !slices.Contains(sections, section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+// ResolveHandoffModel maps a summary model name ("haiku" or "sonnet") to its
+// model ID. An empty name keeps handoffs rule-based.
+func ResolveHandoffModel(name string) (string, error) {
+	switch name {
+	case "":
+		return "", nil
+	case "haiku", SummaryModelHaiku:
+		return SummaryModelHaiku, nil
+	case "sonnet", SummaryModelSonnet:
+		return SummaryModelSonnet, nil
+	}
+	return "", fmt.Errorf("unknown handoff model %q (must be haiku or sonnet)", name)
+}
+
+// HandoffOptions configures how handoff summaries are generated
+type HandoffOptions struct {
+	// Model writes cross-hat handoffs in prose (empty = rule-based only).
+	// Checkpoint handoffs stay rule-based so checkpoints don't cost an LLM call.
+	Model string
+
+	// Sections to include (empty = tailored to the receiving hat)
+	Sections []HandoffSection
+}
+
+// handoffSummaryTimeout bounds the LLM call that writes a cross-hat handoff
+const handoffSummaryTimeout = 60 * time.Second
+
+// handoffScratchpadLimit caps the scratchpad given to the handoff model
+const handoffScratchpadLimit = 4000
+
+// handoffSummaryPrompt asks for a handoff written for the receiving hat
+const handoffSummaryPrompt = `The %s hat is handing this task to the %s hat. Write the handoff the %s hat will start from. Use exactly these sections, each as a "## " heading, and leave out anything else: %s. Be concrete and brief; name files, commands, and checklist items rather than describing them. Don't invent work that isn't in the notes.
+
+%s`
+
 // HandoffSummary provides structured checkpoint metadata for human review and efficient resume
 type HandoffSummary struct {
 	GeneratedAt time.Time `json:"generated_at"`
@@ -32,14 +138,21 @@ type HandoffSummary struct {
 	// Context
 	KeyDecisions []string `json:"key_decisions,omitempty"`
 
+	// Cross-hat handoffs
+	TargetHat string           `json:"target_hat,omitempty"` // Hat the handoff is written for
+	Sections  []HandoffSection `json:"sections,omitempty"`   // Sections Format writes
+	Summary   string           `json:"summary,omitempty"`    // Written by the handoff model, if configured
+
 	// For resume
 	ContinuationPrompt string `json:"continuation_prompt"`
 }
 
 // HandoffGenerator creates handoff summaries from session state
 type HandoffGenerator struct {
-	db     *db.DB
-	gitOps *git.Operations
+	db      *db.DB
+	gitOps  *git.Operations
+	client  *toolbelt.AnthropicClient // Writes cross-hat handoffs when options name a model
+	options HandoffOptions
 }
 
 // NewHandoffGenerator creates a new handoff generator
@@ -50,16 +163,39 @@ func NewHandoffGenerator(database *db.DB, gitOps *git.Operations) *HandoffGenera
 	}
 }
 
-// Generate creates a handoff summary for the current session state
-func (g *HandoffGenerator) Generate(session *ActiveSession, scratchpad string, worktreePath string) *HandoffSummary {
+// SetOptions configures the handoff model and sections
+func (g *HandoffGenerator) SetOptions(client *toolbelt.AnthropicClient, options HandoffOptions) {
+	g.client = client
+	g.options = options
+}
+
+// Generate creates a handoff summary for the current session state.
+// targetHat is the hat that picks the task up next; empty means the same hat
+// resuming from a checkpoint. Handoffs to another hat use the sections that
+// hat needs and, if a model is configured, are written by it.
+func (g *HandoffGenerator) Generate(session *ActiveSession, scratchpad string, worktreePath string, targetHat string) *HandoffSummary {
+	return g.generate(session.TaskID, session.Hat, targetHat, scratchpad, worktreePath)
+}
+
+// generate creates a handoff from currentHat to targetHat for the task
+func (g *HandoffGenerator) generate(taskID, currentHat, targetHat, scratchpad, worktreePath string) *HandoffSummary {
+	if targetHat == "" {
+		targetHat = currentHat
+	}
+	sections := g.options.Sections
+	if len(sections) == 0 {
+		sections = HandoffSectionsForHat(targetHat)
+	}
 	handoff := &HandoffSummary{
 		GeneratedAt: time.Now(),
-		CurrentHat:  session.Hat,
+		CurrentHat:  currentHat,
+		TargetHat:   targetHat,
+		Sections:    sections,
 	}
 
 	// Get task title
 	if g.db != nil {
-		if task, err := g.db.GetTaskByID(session.TaskID); err == nil && task != nil {
+		if task, err := g.db.GetTaskByID(taskID); err == nil && task != nil {
 			handoff.TaskTitle = task.Title
 		}
 	}
@@ -69,13 +205,24 @@ func (g *HandoffGenerator) Generate(session *ActiveSession, scratchpad string, w
 		if branch, err := g.gitOps.GetCurrentBranch(worktreePath); err == nil {
 			handoff.Branch = branch
 		}
-		// Note: ModifiedFiles and CreatedFiles would need git status parsing
-		// For now we rely on the scratchpad to track file changes
+		if log, err := g.gitOps.GetLog(worktreePath, 1); err == nil && len(log) > 0 {
+			handoff.HeadCommit = log[0].Hash
+		}
+		// Uncommitted changes; committed work is on the branch
+		for _, staged := range []bool{false, true} {
+			if names, err := g.gitOps.GetDiff(worktreePath, git.DiffOptions{Staged: staged, NameOnly: true}); err == nil {
+				for _, name := range strings.Fields(names) {
+					if !slices.Contains(handoff.ModifiedFiles, name) {
+						handoff.ModifiedFiles = append(handoff.ModifiedFiles, name)
+					}
+				}
+			}
+		}
 	}
 
 	// Extract progress from checklist
 	if g.db != nil {
-		if checklist, err := g.db.GetChecklistByTaskID(session.TaskID); err == nil && checklist != nil {
+		if checklist, err := g.db.GetChecklistByTaskID(taskID); err == nil && checklist != nil {
 			if items, err := g.db.GetChecklistItems(checklist.ID); err == nil {
 				for _, item := range items {
 					switch item.Status {
@@ -102,13 +249,57 @@ func (g *HandoffGenerator) Generate(session *ActiveSession, scratchpad string, w
 	handoff.ContinuationPrompt = fmt.Sprintf(
 		"Continue working on: %s\nCurrent phase: %s\nNext step: %s",
 		handoff.TaskTitle,
-		session.Hat,
+		currentHat,
 		nextStep,
 	)
 
+	if targetHat != currentHat && g.client != nil && g.options.Model != "" {
+		summary, err := g.writeSummary(handoff, scratchpad)
+		if err != nil {
+			fmt.Printf("HandoffGenerator: warning - falling back to rule-based handoff: %v\n", err)
+		} else {
+			handoff.Summary = summary
+		}
+	}
+
 	return handoff
 }
 
+// writeSummary asks the handoff model to write the handoff for the target hat
+func (g *HandoffGenerator) writeSummary(handoff *HandoffSummary, scratchpad string) (string, error) {
+	var input strings.Builder
+	input.WriteString(handoff.formatSections())
+	if scratchpad != "" {
+		fmt.Fprintf(&input, "\n## Scratchpad\n%s\n", truncateForFeedback(security.SanitizeForPrompt(scratchpad), handoffScratchpadLimit))
+	}
+
+	names := make([]string, len(handoff.Sections))
+	for i, section := range handoff.Sections {
+		names[i] = handoffSectionTitles[section]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handoffSummaryTimeout)
+	defer cancel()
+
+	resp, err := g.client.Chat(ctx, &toolbelt.AnthropicChatRequest{
+		Model:     g.options.Model,
+		MaxTokens: 1024,
+		Messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: fmt.Sprintf(handoffSummaryPrompt, handoff.CurrentHat, handoff.TargetHat, handoff.TargetHat,
+				strings.Join(names, ", "), input.String())},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("handoff API call failed: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Text())
+	if summary == "" {
+		return "", fmt.Errorf("handoff model returned no text")
+	}
+	return summary, nil
+}
+
 // extractDecisionsFromScratchpad parses decisions from the scratchpad content
 func extractDecisionsFromScratchpad(scratchpad string) []string {
 	if scratchpad == "" {
@@ -189,6 +380,86 @@ func (h *HandoffSummary) FormatForResume() string {
 	return sb.String()
 }
 
+// handoffSectionTitles are the headings Format writes
+var handoffSectionTitles = map[HandoffSection]string{
+	HandoffSectionDone:      "What Was Done",
+	HandoffSectionState:     "Current State",
+	HandoffSectionNextSteps: "Next Steps",
+	HandoffSectionBlockers:  "Blockers",
+	HandoffSectionKeyFiles:  "Key Files",
+	HandoffSectionVerify:    "What to Verify",
+}
+
+// Format writes the handoff for the hat that picks the task up next: the
+// model's summary if there is one, otherwise the handoff's sections
+func (h *HandoffSummary) Format() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Handoff from %s\n\n", h.CurrentHat)
+	fmt.Fprintf(&sb, "**Task**: %s\n\n", h.TaskTitle)
+	if h.Summary != "" {
+		sb.WriteString(h.Summary)
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString(h.formatSections())
+	}
+	return sb.String()
+}
+
+// formatSections writes each of the handoff's sections that has content
+func (h *HandoffSummary) formatSections() string {
+	sections := h.Sections
+	if len(sections) == 0 {
+		sections = DefaultHandoffSections
+	}
+
+	var sb strings.Builder
+	writeList := func(section HandoffSection, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "### %s\n", handoffSectionTitles[section])
+		for _, item := range items {
+			fmt.Fprintf(&sb, "- %s\n", item)
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, section := range sections {
+		switch section {
+		case HandoffSectionDone:
+			writeList(section, h.CompletedItems)
+		case HandoffSectionState:
+			var state []string
+			if h.Branch != "" {
+				state = append(state, fmt.Sprintf("Branch %s", h.Branch))
+			}
+			if total := len(h.CompletedItems) + len(h.RemainingItems) + len(h.BlockingIssues); total > 0 {
+				state = append(state, fmt.Sprintf("%d/%d checklist items done", len(h.CompletedItems), total))
+			}
+			for _, decision := range h.KeyDecisions {
+				state = append(state, "Decided: "+decision)
+			}
+			writeList(section, state)
+		case HandoffSectionNextSteps:
+			writeList(section, h.RemainingItems)
+		case HandoffSectionBlockers:
+			writeList(section, h.BlockingIssues)
+		case HandoffSectionKeyFiles:
+			writeList(section, append(slices.Clone(h.ModifiedFiles), h.CreatedFiles...))
+		case HandoffSectionVerify:
+			verify := make([]string, 0, len(h.CompletedItems)+len(h.BlockingIssues))
+			for _, item := range h.CompletedItems {
+				verify = append(verify, "Check that it's really done: "+item)
+			}
+			for _, issue := range h.BlockingIssues {
+				verify = append(verify, "Check whether this still fails: "+issue)
+			}
+			writeList(section, verify)
+		}
+	}
+	return sb.String()
+}
+
 // FormatForAPI creates a JSON-friendly structure for the API
 func (h *HandoffSummary) FormatForAPI() map[string]any {
 	return map[string]any{
@@ -203,6 +474,9 @@ func (h *HandoffSummary) FormatForAPI() map[string]any {
 		"modified_files":      h.ModifiedFiles,
 		"created_files":       h.CreatedFiles,
 		"key_decisions":       h.KeyDecisions,
+		"target_hat":          h.TargetHat,
+		"sections":            h.Sections,
+		"summary":             h.Summary,
 		"continuation_prompt": h.ContinuationPrompt,
 	}
 }
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -165,7 +166,7 @@ func TestHandoffGenerator_Generate_Minimal(t *testing.T) {
 		Scratchpad: "## Key Decisions\n- Decision 1\n",
 	}
 
-	handoff := gen.Generate(session, session.Scratchpad, "")
+	handoff := gen.Generate(session, session.Scratchpad, "", "")
 
 	if handoff.CurrentHat != "creator" {
 		t.Errorf("Expected current_hat to be 'creator', got %s", handoff.CurrentHat)
@@ -176,4 +177,122 @@ func TestHandoffGenerator_Generate_Minimal(t *testing.T) {
 	if handoff.GeneratedAt.IsZero() {
 		t.Error("Expected GeneratedAt to be set")
 	}
+	if handoff.TargetHat != "creator" {
+		t.Errorf("Expected target_hat to default to the current hat, got %s", handoff.TargetHat)
+	}
+}
+
+func TestHandoffGenerator_Generate_TargetHat(t *testing.T) {
+	gen := NewHandoffGenerator(nil, nil)
+
+	session := &ActiveSession{TaskID: "task-123", Hat: "creator"}
+	handoff := gen.Generate(session, "", "", "critic")
+
+	if handoff.CurrentHat != "creator" || handoff.TargetHat != "critic" {
+		t.Errorf("Expected creator -> critic, got %s -> %s", handoff.CurrentHat, handoff.TargetHat)
+	}
+	if !slices.Contains(handoff.Sections, HandoffSectionVerify) {
+		t.Errorf("Expected a handoff to the critic to include %s, got %v", HandoffSectionVerify, handoff.Sections)
+	}
+	if handoff.Summary != "" {
+		t.Errorf("Expected no model summary without a client, got %q", handoff.Summary)
+	}
+}
+
+func TestHandoffGenerator_Generate_ConfiguredSections(t *testing.T) {
+	gen := NewHandoffGenerator(nil, nil)
+	gen.SetOptions(nil, HandoffOptions{Sections: []HandoffSection{HandoffSectionNextSteps}})
+
+	handoff := gen.Generate(&ActiveSession{TaskID: "task-123", Hat: "creator"}, "", "", "critic")
+
+	if !slices.Equal(handoff.Sections, []HandoffSection{HandoffSectionNextSteps}) {
+		t.Errorf("Expected configured sections to override the hat's, got %v", handoff.Sections)
+	}
+}
+
+func TestHandoffSummary_Format_Sections(t *testing.T) {
+	handoff := &HandoffSummary{
+		TaskTitle:      "Add feature X",
+		CurrentHat:     "creator",
+		TargetHat:      "critic",
+		Sections:       []HandoffSection{HandoffSectionDone, HandoffSectionVerify, HandoffSectionKeyFiles},
+		CompletedItems: []string{"Add the endpoint"},
+		RemainingItems: []string{"Write docs"},
+		ModifiedFiles:  []string{"internal/api/server.go"},
+	}
+
+	output := handoff.Format()
+
+	for _, part := range []string{"Handoff from creator", "### What Was Done", "### What to Verify", "Check that it's really done: Add the endpoint", "internal/api/server.go"} {
+		if !strings.Contains(output, part) {
+			t.Errorf("Expected output to contain %q:\n%s", part, output)
+		}
+	}
+	if strings.Contains(output, "Write docs") {
+		t.Errorf("Expected next steps to be left out:\n%s", output)
+	}
+}
+
+func TestHandoffSummary_Format_Summary(t *testing.T) {
+	handoff := &HandoffSummary{
+		TaskTitle:      "Add feature X",
+		CurrentHat:     "creator",
+		CompletedItems: []string{"Add the endpoint"},
+		Summary:        "## What Was Done\nAdded the endpoint.",
+	}
+
+	output := handoff.Format()
+
+	if !strings.Contains(output, "Added the endpoint.") {
+		t.Errorf("Expected output to contain the model summary:\n%s", output)
+	}
+	if strings.Contains(output, "### What Was Done") {
+		t.Errorf("Expected the summary to replace the rule-based sections:\n%s", output)
+	}
+}
+
+func TestParseHandoffSections(t *testing.T) {
+	sections, err := ParseHandoffSections(" done, verify,done ,")
+	if err != nil {
+		t.Fatalf("ParseHandoffSections: %v", err)
+	}
+	if !slices.Equal(sections, []HandoffSection{HandoffSectionDone, HandoffSectionVerify}) {
+		t.Errorf("Expected [done verify], got %v", sections)
+	}
+
+	if sections, err := ParseHandoffSections(""); err != nil || sections != nil {
+		t.Errorf("Expected nil sections for empty input, got %v, %v", sections, err)
+	}
+	if _, err := ParseHandoffSections("done,summary"); err == nil {
+		t.Error("Expected an error for an unknown section")
+	}
+}
+
+func TestResolveHandoffModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"haiku", SummaryModelHaiku, false},
+		{"sonnet", SummaryModelSonnet, false},
+		{SummaryModelSonnet, SummaryModelSonnet, false},
+		{"opus", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveHandoffModel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveHandoffModel(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestHandoffSectionsForHat(t *testing.T) {
+	if !slices.Contains(HandoffSectionsForHat("critic"), HandoffSectionVerify) {
+		t.Error("Expected critic handoffs to include what to verify")
+	}
+	if !slices.Equal(HandoffSectionsForHat("explorer"), DefaultHandoffSections) {
+		t.Error("Expected hats without their own sections to use the defaults")
+	}
 }
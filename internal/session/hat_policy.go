@@ -0,0 +1,359 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// HatPolicy describes a hat workflow: the topic that starts a task, the
+// topics that finish it, and what each hat subscribes to and publishes.
+// Sessions run DefaultHatPolicy; other policies can be validated against the
+// same rules the event router applies.
+type HatPolicy struct {
+	StartTopic     string         `json:"start_topic"`
+	TerminalTopics []string       `json:"terminal_topics"`
+	Hats           []HatPolicyHat `json:"hats"`
+}
+
+// HatPolicyHat is one hat's contract within a HatPolicy
+type HatPolicyHat struct {
+	Name       string   `json:"name"`
+	Subscribes []string `json:"subscribes"`
+	Publishes  []string `json:"publishes"`
+	Priority   int      `json:"priority"` // Lowest wins when several hats subscribe to a topic
+
+	ThinkingBudget int `json:"thinking_budget"` // Default extended thinking budget (0 = off)
+	MaxTokens      int `json:"max_tokens"`      // Output token limit per response, before thinking
+}
+
+// HatTransition is a move from one hat to the next when it publishes a topic
+type HatTransition struct {
+	From     string `json:"from"`
+	Topic    string `json:"topic"`
+	To       string `json:"to,omitempty"`
+	Terminal bool   `json:"terminal,omitempty"` // The topic completes the task
+}
+
+// UnroutableEvent is a topic hat prompts tell the model to publish that the
+// policy can't route
+type UnroutableEvent struct {
+	Topic  string   `json:"topic"`
+	Hats   []string `json:"hats"` // Hats whose prompts mention it
+	Reason string   `json:"reason"`
+}
+
+// HatPolicyReport is the result of validating a HatPolicy. Errors are
+// problems that would strand sessions; warnings are worth a look.
+type HatPolicyReport struct {
+	Valid       bool              `json:"valid"`
+	Errors      []string          `json:"errors"`
+	Warnings    []string          `json:"warnings"`
+	Transitions []HatTransition   `json:"transitions"`
+	Unroutable  []UnroutableEvent `json:"unroutable_events"`
+	Description string            `json:"description"`
+	Mermaid     string            `json:"mermaid"` // Flowchart of the transitions
+}
+
+// DefaultHatPolicy returns the built-in workflow from HatContracts
+func DefaultHatPolicy() *HatPolicy {
+	policy := &HatPolicy{
+		StartTopic:     TopicTaskStarted,
+		TerminalTopics: []string{TopicTaskComplete},
+	}
+	for _, hat := range ValidHats {
+		contract := HatContracts[hat]
+		if contract == nil {
+			continue
+		}
+		policy.Hats = append(policy.Hats, HatPolicyHat{
+			Name:       contract.Name,
+			Subscribes: slices.Clone(contract.Subscribes),
+			Publishes:  slices.Clone(contract.Publishes),
+			Priority:   hatPriority[contract.Name],
+
+			ThinkingBudget: hatThinkingBudgets[contract.Name],
+			MaxTokens:      MaxTokens(contract.Name, hatMaxTokens),
+		})
+	}
+	sort.SliceStable(policy.Hats, func(i, j int) bool {
+		return policy.Hats[i].Priority < policy.Hats[j].Priority
+	})
+	return policy
+}
+
+// isTerminal reports whether a topic completes the task
+func (p *HatPolicy) isTerminal(topic string) bool {
+	return slices.Contains(p.TerminalTopics, topic)
+}
+
+// subscribers returns the hats subscribed to a topic, in routing order
+func (p *HatPolicy) subscribers(topic string) []HatPolicyHat {
+	var hats []HatPolicyHat
+	for _, h := range p.Hats {
+		if slices.Contains(h.Subscribes, topic) {
+			hats = append(hats, h)
+		}
+	}
+	sort.SliceStable(hats, func(i, j int) bool { return hats[i].Priority < hats[j].Priority })
+	return hats
+}
+
+// next returns the hat a topic routes to, or "" if none subscribes
+func (p *HatPolicy) next(topic string) string {
+	if subs := p.subscribers(topic); len(subs) > 0 {
+		return subs[0].Name
+	}
+	return ""
+}
+
+// publishedBy reports whether any hat publishes a topic
+func (p *HatPolicy) publishedBy(topic string) bool {
+	for _, h := range p.Hats {
+		if slices.Contains(h.Publishes, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateHatPolicy checks that a policy forms a workable graph: the start
+// topic reaches a hat, every topic a hat publishes is routed or terminal, and
+// every hat can still reach a terminal topic. promptEvents maps topics that
+// hat prompts mention to the hats mentioning them (see
+// PromptLoader.ReferencedEvents); those the policy can't route are reported.
+func ValidateHatPolicy(p *HatPolicy, promptEvents map[string][]string) *HatPolicyReport {
+	report := &HatPolicyReport{
+		Errors:      []string{},
+		Warnings:    []string{},
+		Transitions: []HatTransition{},
+		Unroutable:  []UnroutableEvent{},
+	}
+	errorf := func(format string, args ...any) {
+		report.Errors = append(report.Errors, fmt.Sprintf(format, args...))
+	}
+	warnf := func(format string, args ...any) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	// Structure
+	if p.StartTopic == "" {
+		errorf("start_topic is required")
+	}
+	if len(p.TerminalTopics) == 0 {
+		errorf("at least one terminal topic is required")
+	}
+	if len(p.Hats) == 0 {
+		errorf("at least one hat is required")
+	}
+	seen := make(map[string]bool)
+	for _, h := range p.Hats {
+		if !IsValidHat(h.Name) {
+			errorf("unknown hat %q (must be one of %s)", h.Name, strings.Join(ValidHats, ", "))
+		}
+		if seen[h.Name] {
+			errorf("hat %q is listed more than once", h.Name)
+		}
+		seen[h.Name] = true
+		if slices.Contains(h.Subscribes, "") || slices.Contains(h.Publishes, "") {
+			errorf("hat %q has an empty topic", h.Name)
+		}
+	}
+	if len(report.Errors) > 0 {
+		return report
+	}
+
+	// Transitions, and topics nothing can route
+	edges := make(map[string][]string)
+	for _, h := range p.Hats {
+		for _, topic := range h.Publishes {
+			if p.isTerminal(topic) {
+				report.Transitions = append(report.Transitions, HatTransition{From: h.Name, Topic: topic, Terminal: true})
+				continue
+			}
+			to := p.next(topic)
+			if to == "" {
+				errorf("hat %s publishes %s, but no hat subscribes to it", h.Name, topic)
+				continue
+			}
+			report.Transitions = append(report.Transitions, HatTransition{From: h.Name, Topic: topic, To: to})
+			edges[h.Name] = append(edges[h.Name], to)
+		}
+		for _, topic := range h.Subscribes {
+			if topic != p.StartTopic && !p.publishedBy(topic) {
+				warnf("hat %s subscribes to %s, but no hat publishes it", h.Name, topic)
+			}
+		}
+	}
+
+	// Ambiguous routing
+	topics := make(map[string]bool)
+	for _, h := range p.Hats {
+		for _, topic := range h.Subscribes {
+			topics[topic] = true
+		}
+	}
+	for _, topic := range sortedKeys(topics) {
+		if subs := p.subscribers(topic); len(subs) > 1 && subs[0].Priority == subs[1].Priority {
+			warnf("%s and %s both subscribe to %s with priority %d; %s is listed first, so it wins",
+				subs[0].Name, subs[1].Name, topic, subs[0].Priority, subs[0].Name)
+		}
+	}
+
+	// Reachability from the start topic
+	startHat := p.next(p.StartTopic)
+	if startHat == "" {
+		errorf("no hat subscribes to the start topic %s", p.StartTopic)
+	} else {
+		reached := reachable(edges, startHat)
+		for _, h := range p.Hats {
+			if !reached[h.Name] {
+				warnf("hat %s isn't reachable from %s; it only runs when a task starts with it", h.Name, p.StartTopic)
+			}
+		}
+	}
+
+	// Every hat must be able to finish the task
+	finishers := make(map[string]bool)
+	for _, t := range report.Transitions {
+		if t.Terminal {
+			finishers[t.From] = true
+		}
+	}
+	if len(finishers) == 0 {
+		errorf("no hat publishes a terminal topic (%s), so no task can complete", strings.Join(p.TerminalTopics, ", "))
+	} else {
+		for _, h := range p.Hats {
+			canFinish := false
+			for hat := range reachable(edges, h.Name) {
+				if finishers[hat] {
+					canFinish = true
+					break
+				}
+			}
+			if !canFinish {
+				errorf("sessions on hat %s can never reach a terminal topic", h.Name)
+			}
+		}
+	}
+
+	// Events prompts ask for that the policy can't route
+	for _, topic := range sortedKeys(promptEvents) {
+		var reason string
+		switch {
+		case p.isTerminal(topic):
+			continue
+		case p.next(topic) == "":
+			reason = "no hat subscribes to it"
+		case !p.publishedBy(topic):
+			reason = "no hat may publish it"
+		default:
+			continue
+		}
+		hats := slices.Clone(promptEvents[topic])
+		sort.Strings(hats)
+		report.Unroutable = append(report.Unroutable, UnroutableEvent{Topic: topic, Hats: hats, Reason: reason})
+	}
+
+	report.Valid = len(report.Errors) == 0
+	report.Description = p.describe(report.Transitions)
+	report.Mermaid = p.mermaid(report.Transitions)
+	return report
+}
+
+// reachable returns the hats reachable from start, including start
+func reachable(edges map[string][]string, start string) map[string]bool {
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		hat := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[hat] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// describe renders the workflow as text, one line per hat
+func (p *HatPolicy) describe(transitions []HatTransition) string {
+	var b strings.Builder
+	if start := p.next(p.StartTopic); start != "" {
+		fmt.Fprintf(&b, "Tasks start on %s (%s).\n", start, p.StartTopic)
+	}
+	for _, h := range p.Hats {
+		var moves []string
+		for _, t := range transitions {
+			if t.From != h.Name {
+				continue
+			}
+			if t.Terminal {
+				moves = append(moves, fmt.Sprintf("%s → task complete", t.Topic))
+			} else {
+				moves = append(moves, fmt.Sprintf("%s → %s", t.Topic, t.To))
+			}
+		}
+		if len(moves) == 0 {
+			moves = []string{"(publishes nothing)"}
+		}
+		fmt.Fprintf(&b, "%s: %s\n", h.Name, strings.Join(moves, "; "))
+	}
+	return b.String()
+}
+
+// mermaid renders the workflow as a Mermaid flowchart
+func (p *HatPolicy) mermaid(transitions []HatTransition) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	if start := p.next(p.StartTopic); start != "" {
+		fmt.Fprintf(&b, "    start((start)) -->|%s| %s\n", p.StartTopic, start)
+	}
+	for _, t := range transitions {
+		to := t.To
+		if t.Terminal {
+			to = "done((done))"
+		}
+		fmt.Fprintf(&b, "    %s -->|%s| %s\n", t.From, t.Topic, to)
+	}
+	return b.String()
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// eventPlaceholders are topics the prompts use to show the event format
+var eventPlaceholders = map[string]bool{"topic": true}
+
+// ReferencedEvents returns the event topics each hat's prompt tells the model
+// it can publish, mapped to the hats whose prompts mention them
+func (p *PromptLoader) ReferencedEvents() (map[string][]string, error) {
+	pattern := regexp.MustCompile(regexp.QuoteMeta(p.signals.Event) + `([A-Za-z0-9_.-]+)`)
+
+	events := make(map[string][]string)
+	for _, hat := range ValidHats {
+		prompt, err := p.Get(hat, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range pattern.FindAllStringSubmatch(prompt, -1) {
+			topic := strings.TrimRight(match[1], ".")
+			if topic == "" || eventPlaceholders[topic] || slices.Contains(events[topic], hat) {
+				continue
+			}
+			events[topic] = append(events[topic], hat)
+		}
+	}
+	return events, nil
+}
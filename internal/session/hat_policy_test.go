@@ -0,0 +1,81 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateHatPolicy_Default(t *testing.T) {
+	loader := NewPromptLoader("nonexistent-prompts-dir")
+	if err := loader.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+	events, err := loader.ReferencedEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events[TopicImplementationDone]) == 0 {
+		t.Fatalf("expected prompts to mention %s, got %v", TopicImplementationDone, events)
+	}
+
+	report := ValidateHatPolicy(DefaultHatPolicy(), events)
+	if !report.Valid {
+		t.Fatalf("default policy invalid: %v", report.Errors)
+	}
+	if len(report.Unroutable) != 0 {
+		t.Errorf("unexpected unroutable events: %+v", report.Unroutable)
+	}
+	if !strings.Contains(report.Mermaid, "creator -->|implementation.done| critic") {
+		t.Errorf("mermaid missing creator → critic:\n%s", report.Mermaid)
+	}
+	if !strings.HasPrefix(report.Description, "Tasks start on planner (task.started).") {
+		t.Errorf("unexpected description:\n%s", report.Description)
+	}
+}
+
+func TestValidateHatPolicy_StrandedSessions(t *testing.T) {
+	policy := &HatPolicy{
+		StartTopic:     TopicTaskStarted,
+		TerminalTopics: []string{TopicTaskComplete},
+		Hats: []HatPolicyHat{
+			{Name: "planner", Subscribes: []string{TopicTaskStarted}, Publishes: []string{TopicPlanComplete}},
+			{Name: "creator", Subscribes: []string{TopicPlanComplete}, Publishes: []string{TopicImplementationDone}},
+			{Name: "editor", Subscribes: []string{TopicReviewApproved}, Publishes: []string{TopicTaskComplete}},
+		},
+	}
+
+	report := ValidateHatPolicy(policy, map[string][]string{
+		TopicImplementationDone: {"creator"},
+		TopicReviewApproved:     {"critic"},
+	})
+	if report.Valid {
+		t.Fatal("expected policy with no route out of creator to be invalid")
+	}
+	for _, want := range []string{
+		"hat creator publishes implementation.done, but no hat subscribes to it",
+		"sessions on hat planner can never reach a terminal topic",
+	} {
+		if !slices.Contains(report.Errors, want) {
+			t.Errorf("errors = %v, missing %q", report.Errors, want)
+		}
+	}
+	if !slices.Contains(report.Warnings, "hat editor subscribes to review.approved, but no hat publishes it") {
+		t.Errorf("warnings = %v", report.Warnings)
+	}
+	if len(report.Unroutable) != 2 || report.Unroutable[0].Topic != TopicImplementationDone ||
+		report.Unroutable[1].Reason != "no hat may publish it" {
+		t.Errorf("unroutable = %+v", report.Unroutable)
+	}
+}
+
+func TestValidateHatPolicy_RejectsUnknownHats(t *testing.T) {
+	report := ValidateHatPolicy(&HatPolicy{
+		StartTopic:     TopicTaskStarted,
+		TerminalTopics: []string{TopicTaskComplete},
+		Hats:           []HatPolicyHat{{Name: "wizard", Subscribes: []string{TopicTaskStarted}}},
+	}, nil)
+	if report.Valid || len(report.Errors) != 1 || !strings.HasPrefix(report.Errors[0], `unknown hat "wizard"`) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
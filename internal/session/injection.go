@@ -0,0 +1,91 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/tools"
+)
+
+// screenToolOutput scans output from tools that read outside content for
+// prompt-injection attempts. Findings are recorded as a security event and
+// the output is flagged or quarantined; clean output is neutralized as usual.
+func (r *RalphLoop) screenToolOutput(toolName, output string) string {
+	if r.injectionDetector == nil || !tools.ReturnsUntrustedContent(toolName) {
+		return r.neutralizeToolOutput(toolName, output)
+	}
+	findings := r.injectionDetector.Scan(output)
+	if len(findings) == 0 {
+		return r.neutralizeToolOutput(toolName, output)
+	}
+
+	source := "tool:" + toolName
+	event := SecurityEventData{
+		Source:   source,
+		Action:   string(r.injectionDetector.Action()),
+		Findings: findings,
+		Bytes:    len(output),
+	}
+	fmt.Printf("RalphLoop: suspected prompt injection in %s output: %v (%s)\n", toolName, findings, event.Action)
+	if r.activity != nil {
+		if err := r.activity.RecordSecurityEvent(r.session.IterationCount, &event); err != nil {
+			fmt.Printf("RalphLoop: warning - failed to record security event: %v\n", err)
+		}
+	}
+	if r.injectionDetector.PausesForApproval() {
+		r.pendingInjections = append(r.pendingInjections, event)
+	}
+
+	return r.injectionDetector.Neutralize(output, source, findings)
+}
+
+// requestInjectionApproval creates a prompt_injection approval for the
+// findings waiting on a human, so the session can be resumed once they're reviewed
+func (r *RalphLoop) requestInjectionApproval() error {
+	events := r.pendingInjections
+	r.pendingInjections = nil
+	if r.db == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"iteration": r.session.IterationCount,
+		"events":    events,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval data: %w", err)
+	}
+
+	sources := make([]string, len(events))
+	for i, e := range events {
+		sources[i] = e.Source
+	}
+	title := "Review suspected prompt injection"
+	description := fmt.Sprintf("Output from %s looks like it contains a prompt-injection attempt. Approve to resume the session; the output is %s.",
+		strings.Join(sources, ", "), injectionActionDescription(events[0].Action))
+	taskID, sessionID := r.session.TaskID, r.session.ID
+	approval, err := r.db.CreateApproval(&taskID, &sessionID, db.ApprovalTypePromptInjection, title, &description, data)
+	if err != nil {
+		return err
+	}
+
+	r.activity.Debug(r.session.IterationCount, fmt.Sprintf("Suspected prompt injection awaiting review %s", approval.ID))
+	r.broadcastEvent(realtime.EventApprovalRequired, map[string]any{
+		"session_id":  r.session.ID,
+		"approval_id": approval.ID,
+		"reason":      ErrInjectionApprovalRequired.Error(),
+	})
+	return nil
+}
+
+// injectionActionDescription says what the model will see of flagged output
+func injectionActionDescription(action string) string {
+	if action == string(security.InjectionActionQuarantine) {
+		return "withheld from the model"
+	}
+	return "passed on behind a warning"
+}
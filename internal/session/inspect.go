@@ -0,0 +1,290 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// Inspection limits keep a snapshot small enough to fetch while a session runs
+const (
+	InspectMessageLimit = 20  // Most recent messages included
+	inspectTextLimit    = 500 // Characters kept of each message, tool input, and tool result
+)
+
+// SessionInspection is a snapshot of a running session for debugging: its
+// recent conversation, tools, health, context usage, scratchpad, and budget
+type SessionInspection struct {
+	SessionID    string             `json:"session_id"`
+	TaskID       string             `json:"task_id"`
+	Hat          string             `json:"hat"`
+	State        SessionState       `json:"state"`
+	Iteration    int                `json:"iteration"`
+	MessageCount int                `json:"message_count"`
+	Messages     []InspectedMessage `json:"messages"`
+	Tools        []string           `json:"tools"`
+	LastToolCall *InspectedToolCall `json:"last_tool_call,omitempty"`
+	Health       InspectedHealth    `json:"health"`
+	Context      *ContextStatus     `json:"context,omitempty"`
+	Scratchpad   string             `json:"scratchpad"`
+	Budget       InspectedBudget    `json:"budget"`
+	CapturedAt   *time.Time         `json:"captured_at,omitempty"` // When the loop last published its state (nil = not yet)
+}
+
+// InspectedMessage is a conversation message flattened to truncated text
+type InspectedMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// InspectedToolCall is the most recent tool call a session made
+type InspectedToolCall struct {
+	Name       string         `json:"name"`
+	Input      map[string]any `json:"input,omitempty"`
+	Output     string         `json:"output"`
+	IsError    bool           `json:"is_error"`
+	DurationMs int64          `json:"duration_ms"`
+	At         time.Time      `json:"at"`
+}
+
+// InspectedHealth is a session's loop health
+type InspectedHealth struct {
+	Status                        HealthStatus `json:"status"`
+	ConsecutiveFailures           int          `json:"consecutive_failures"`
+	TotalFailures                 int          `json:"total_failures"`
+	ConsecutiveValidationFailures int          `json:"consecutive_validation_failures"`
+	QualityGateAttempts           int          `json:"quality_gate_attempts"`
+}
+
+// InspectedBudget is what a session has used of its limits and what remains
+// (nil remaining = no limit)
+type InspectedBudget struct {
+	Iterations          int      `json:"iterations"`
+	MaxIterations       int      `json:"max_iterations"`
+	IterationsRemaining *int     `json:"iterations_remaining,omitempty"`
+	Tokens              int64    `json:"tokens"`
+	TokensBudget        *int64   `json:"tokens_budget,omitempty"`
+	TokensRemaining     *int64   `json:"tokens_remaining,omitempty"`
+	Dollars             float64  `json:"dollars"`
+	DollarsBudget       *float64 `json:"dollars_budget,omitempty"`
+	DollarsRemaining    *float64 `json:"dollars_remaining,omitempty"`
+	RuntimeSeconds      int64    `json:"runtime_seconds"`
+	MaxRuntimeSeconds   int64    `json:"max_runtime_seconds,omitempty"`
+	RuntimeRemaining    *int64   `json:"runtime_remaining_seconds,omitempty"`
+}
+
+// loopInspection is the part of an inspection only the running loop knows,
+// published to its ActiveSession as the loop goes
+type loopInspection struct {
+	messageCount int
+	messages     []InspectedMessage
+	tools        []string
+	lastToolCall *InspectedToolCall
+	health       InspectedHealth
+	context      *ContextStatus
+	capturedAt   time.Time
+}
+
+// Inspect returns a snapshot of an active session, or nil if it isn't active
+func (m *Manager) Inspect(sessionID string) *SessionInspection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil
+	}
+
+	inspection := &SessionInspection{
+		SessionID:  session.ID,
+		TaskID:     session.TaskID,
+		Hat:        session.Hat,
+		State:      session.State,
+		Iteration:  session.IterationCount,
+		Messages:   []InspectedMessage{},
+		Tools:      []string{},
+		Health:     InspectedHealth{Status: HealthOK},
+		Scratchpad: session.Scratchpad,
+		Budget:     inspectBudget(session, time.Now()),
+	}
+
+	if loop := session.inspection; loop != nil {
+		inspection.MessageCount = loop.messageCount
+		inspection.Messages = loop.messages
+		inspection.Tools = loop.tools
+		inspection.LastToolCall = loop.lastToolCall
+		inspection.Health = loop.health
+		inspection.Context = loop.context
+		capturedAt := loop.capturedAt
+		inspection.CapturedAt = &capturedAt
+	}
+
+	return inspection
+}
+
+// inspectBudget reports what the session has used of its limits as of now
+func inspectBudget(session *ActiveSession, now time.Time) InspectedBudget {
+	budget := InspectedBudget{
+		Iterations:    session.IterationCount,
+		MaxIterations: session.MaxIterations,
+		Tokens:        session.TotalTokens(),
+		TokensBudget:  session.TokensBudget,
+		Dollars:       session.Cost(),
+		DollarsBudget: session.DollarsBudget,
+	}
+
+	if session.MaxIterations > 0 {
+		remaining := max(session.MaxIterations-session.IterationCount, 0)
+		budget.IterationsRemaining = &remaining
+	}
+	if session.TokensBudget != nil {
+		remaining := max(*session.TokensBudget-budget.Tokens, 0)
+		budget.TokensRemaining = &remaining
+	}
+	if session.DollarsBudget != nil {
+		remaining := max(*session.DollarsBudget-budget.Dollars, 0)
+		budget.DollarsRemaining = &remaining
+	}
+
+	if !session.StartedAt.IsZero() {
+		budget.RuntimeSeconds = int64(now.Sub(session.StartedAt).Seconds())
+	}
+	if session.MaxRuntime > 0 {
+		budget.MaxRuntimeSeconds = int64(session.MaxRuntime.Seconds())
+		remaining := max(budget.MaxRuntimeSeconds-budget.RuntimeSeconds, 0)
+		budget.RuntimeRemaining = &remaining
+	}
+
+	return budget
+}
+
+// publishInspection shares the loop's current state with inspectors
+func (r *RalphLoop) publishInspection(systemPrompt string) {
+	if r.manager == nil {
+		return
+	}
+
+	snapshot := &loopInspection{
+		messageCount: len(r.messages),
+		messages:     inspectMessages(r.messages, InspectMessageLimit),
+		tools:        make([]string, len(r.tools)),
+		lastToolCall: r.lastToolCall,
+		health:       r.inspectHealth(),
+		capturedAt:   time.Now(),
+	}
+	for i, tool := range r.tools {
+		snapshot.tools[i] = tool.Name
+	}
+	if r.contextGuard != nil {
+		status := r.contextGuard.GetStatus(r.messages, systemPrompt)
+		snapshot.context = &status
+	}
+
+	r.manager.mu.Lock()
+	r.session.inspection = snapshot
+	r.manager.mu.Unlock()
+}
+
+// recordLastToolCall remembers a tool call for inspection
+func (r *RalphLoop) recordLastToolCall(block toolbelt.AnthropicContentBlock, result ToolResult, elapsed time.Duration) {
+	input := make(map[string]any, len(block.Input))
+	for k, v := range block.Input {
+		if s, ok := v.(string); ok {
+			v = truncateOutput(s, inspectTextLimit)
+		}
+		input[k] = v
+	}
+
+	r.lastToolCall = &InspectedToolCall{
+		Name:       block.Name,
+		Input:      input,
+		Output:     truncateOutput(result.Output, inspectTextLimit),
+		IsError:    result.IsError,
+		DurationMs: elapsed.Milliseconds(),
+		At:         time.Now(),
+	}
+}
+
+// inspectHealth copies the loop's health counters
+func (r *RalphLoop) inspectHealth() InspectedHealth {
+	if r.health == nil {
+		return InspectedHealth{Status: HealthOK}
+	}
+
+	status := r.health.Status()
+	r.health.mu.RLock()
+	defer r.health.mu.RUnlock()
+	return InspectedHealth{
+		Status:                        status,
+		ConsecutiveFailures:           r.health.ConsecutiveFailures,
+		TotalFailures:                 r.health.TotalFailures,
+		ConsecutiveValidationFailures: r.health.ConsecutiveValidationFailures,
+		QualityGateAttempts:           r.health.QualityGateAttempts,
+	}
+}
+
+// inspectMessages flattens the last limit messages to truncated text
+func inspectMessages(messages []toolbelt.AnthropicMessage, limit int) []InspectedMessage {
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+
+	inspected := make([]InspectedMessage, len(messages))
+	for i, msg := range messages {
+		inspected[i] = InspectedMessage{
+			Role:    msg.Role,
+			Content: truncateOutput(messageText(msg.Content), inspectTextLimit),
+		}
+	}
+	return inspected
+}
+
+// messageText renders message content as text, naming tool calls and
+// truncating tool results
+func messageText(content any) string {
+	var parts []string
+	switch c := content.(type) {
+	case string:
+		return c
+	case []toolbelt.AnthropicContentBlock:
+		for _, block := range c {
+			switch block.Type {
+			case "text":
+				parts = append(parts, block.Text)
+			case "tool_use":
+				parts = append(parts, fmt.Sprintf("[Tool: %s]", block.Name))
+			}
+		}
+	case []toolbelt.ContentBlock:
+		for _, block := range c {
+			switch block.Type {
+			case "text":
+				parts = append(parts, block.Text)
+			case "tool_use":
+				parts = append(parts, fmt.Sprintf("[Tool: %s]", block.Name))
+			case "tool_result":
+				parts = append(parts, fmt.Sprintf("[Result: %s]", truncateOutput(block.Content, inspectTextLimit)))
+			}
+		}
+	case []any:
+		// Restored from a checkpoint
+		for _, block := range c {
+			blockMap, ok := block.(map[string]any)
+			if !ok {
+				continue
+			}
+			if text, ok := blockMap["text"].(string); ok && text != "" {
+				parts = append(parts, text)
+			}
+			if name, ok := blockMap["name"].(string); ok && blockMap["type"] == "tool_use" {
+				parts = append(parts, fmt.Sprintf("[Tool: %s]", name))
+			}
+			if result, ok := blockMap["content"].(string); ok && blockMap["type"] == "tool_result" {
+				parts = append(parts, fmt.Sprintf("[Result: %s]", truncateOutput(result, inspectTextLimit)))
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
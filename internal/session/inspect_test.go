@@ -0,0 +1,118 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestInspect(t *testing.T) {
+	tokens := int64(10_000)
+	session := &ActiveSession{
+		ID:             "sess-1",
+		TaskID:         "task-1",
+		Hat:            "creator",
+		State:          StateRunning,
+		IterationCount: 3,
+		MaxIterations:  10,
+		InputTokens:    4_000,
+		OutputTokens:   1_000,
+		TokensBudget:   &tokens,
+		StartedAt:      time.Now().Add(-time.Minute),
+		MaxRuntime:     time.Hour,
+		Scratchpad:     "Plan: add the endpoint",
+	}
+	m := &Manager{sessions: map[string]*ActiveSession{session.ID: session}}
+
+	if m.Inspect("missing") != nil {
+		t.Error("inspected a session that isn't active")
+	}
+
+	// Before the loop publishes anything, the session's own state is still there
+	got := m.Inspect(session.ID)
+	if got == nil {
+		t.Fatal("no inspection for an active session")
+	}
+	if got.CapturedAt != nil || len(got.Messages) != 0 {
+		t.Errorf("got loop state before the loop published any: %+v", got)
+	}
+	if got.Scratchpad != session.Scratchpad || got.Iteration != 3 {
+		t.Errorf("got scratchpad %q at iteration %d", got.Scratchpad, got.Iteration)
+	}
+	if got.Budget.IterationsRemaining == nil || *got.Budget.IterationsRemaining != 7 {
+		t.Errorf("got iterations remaining %v, want 7", got.Budget.IterationsRemaining)
+	}
+	if got.Budget.TokensRemaining == nil || *got.Budget.TokensRemaining != 5_000 {
+		t.Errorf("got tokens remaining %v, want 5000", got.Budget.TokensRemaining)
+	}
+	if got.Budget.DollarsRemaining != nil {
+		t.Errorf("got dollars remaining %v without a dollar budget", *got.Budget.DollarsRemaining)
+	}
+	if got.Budget.RuntimeRemaining == nil || *got.Budget.RuntimeRemaining > 3600-59 {
+		t.Errorf("got runtime remaining %v, want under 3541s", got.Budget.RuntimeRemaining)
+	}
+
+	loop := &RalphLoop{
+		manager: m,
+		session: session,
+		health:  NewLoopHealth(),
+		tools:   []toolbelt.AnthropicTool{{Name: "read_file"}, {Name: "bash"}},
+		messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: "Add the endpoint"},
+			{Role: "assistant", Content: []toolbelt.AnthropicContentBlock{
+				{Type: "text", Text: "Reading the handler."},
+				{Type: "tool_use", Name: "read_file"},
+			}},
+			{Role: "user", Content: []toolbelt.ContentBlock{
+				{Type: "tool_result", Content: strings.Repeat("x", 2000)},
+			}},
+		},
+	}
+	loop.health.RecordFailure("bash")
+	loop.recordLastToolCall(
+		toolbelt.AnthropicContentBlock{Name: "read_file", Input: map[string]any{"path": "main.go"}},
+		ToolResult{Output: "package main"},
+		20*time.Millisecond,
+	)
+	loop.publishInspection("system")
+
+	got = m.Inspect(session.ID)
+	if got.CapturedAt == nil {
+		t.Fatal("published loop state missing")
+	}
+	if got.MessageCount != 3 || len(got.Messages) != 3 {
+		t.Fatalf("got %d of %d messages, want 3 of 3", len(got.Messages), got.MessageCount)
+	}
+	if got.Messages[1].Content != "Reading the handler.\n[Tool: read_file]" {
+		t.Errorf("got assistant message %q", got.Messages[1].Content)
+	}
+	if len(got.Messages[2].Content) > inspectTextLimit+len("...") {
+		t.Errorf("tool result not truncated: %d chars", len(got.Messages[2].Content))
+	}
+	if strings.Join(got.Tools, ",") != "read_file,bash" {
+		t.Errorf("got tools %v", got.Tools)
+	}
+	if got.LastToolCall == nil || got.LastToolCall.Name != "read_file" || got.LastToolCall.Output != "package main" {
+		t.Errorf("got last tool call %+v", got.LastToolCall)
+	}
+	if got.Health.Status != HealthDegraded || got.Health.ConsecutiveFailures != 1 {
+		t.Errorf("got health %+v, want degraded after a failure", got.Health)
+	}
+}
+
+func TestInspectMessages_Limit(t *testing.T) {
+	messages := make([]toolbelt.AnthropicMessage, 30)
+	for i := range messages {
+		messages[i] = toolbelt.AnthropicMessage{Role: "user", Content: string(rune('a' + i%26))}
+	}
+
+	got := inspectMessages(messages, InspectMessageLimit)
+	if len(got) != InspectMessageLimit {
+		t.Fatalf("got %d messages, want %d", len(got), InspectMessageLimit)
+	}
+	if got[len(got)-1].Content != messages[len(messages)-1].Content {
+		t.Error("didn't keep the most recent messages")
+	}
+}
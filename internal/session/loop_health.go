@@ -42,6 +42,9 @@ type LoopHealth struct {
 	// Tool repetition detection
 	Repetition *RepetitionInspector
 
+	// Repeated identical tool error detection
+	Errors *ErrorRepetitionTracker
+
 	// Thresholds
 	MaxConsecutiveFailures   int
 	MaxQualityGateAttempts   int
@@ -57,6 +60,7 @@ func NewLoopHealth() *LoopHealth {
 	return &LoopHealth{
 		TaskBlockCounts:          make(map[string]int),
 		Repetition:               NewRepetitionInspector(),
+		Errors:                   NewErrorRepetitionTracker(),
 		MaxConsecutiveFailures:   DefaultMaxConsecutiveFailures,
 		MaxQualityGateAttempts:   DefaultMaxQualityGateAttempts,
 		MaxTaskBlocks:            DefaultMaxTaskBlocks,
@@ -128,11 +132,23 @@ func (h *LoopHealth) CheckToolCall(toolName, paramsJSON string) (bool, string) {
 	return h.Repetition.Check(ToolCallSignature{Name: toolName, Params: paramsJSON})
 }
 
-// ResetRepetition resets the repetition counter (e.g., on user message or hat change)
+// RecordToolError tracks a tool's error for repetition. Returns a steering
+// message once the tool keeps failing with the same error, otherwise "".
+func (h *LoopHealth) RecordToolError(toolName, output string) string {
+	if h.Errors == nil {
+		return ""
+	}
+	return h.Errors.RecordError(toolName, output)
+}
+
+// ResetRepetition resets the repetition counters (e.g., on user message or hat change)
 func (h *LoopHealth) ResetRepetition() {
 	if h.Repetition != nil {
 		h.Repetition.Reset()
 	}
+	if h.Errors != nil {
+		h.Errors.Reset()
+	}
 }
 
 // Status returns the current health status
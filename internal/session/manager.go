@@ -3,7 +3,10 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +18,7 @@ import (
 	forgejoclient "github.com/lirancohen/dex/internal/gitprovider/forgejo"
 	"github.com/lirancohen/dex/internal/orchestrator"
 	"github.com/lirancohen/dex/internal/realtime"
+	"github.com/lirancohen/dex/internal/security"
 	"github.com/lirancohen/dex/internal/toolbelt"
 	"github.com/lirancohen/dex/internal/tools"
 )
@@ -45,13 +49,14 @@ type ActiveSession struct {
 	IterationCount int
 	MaxIterations  int
 
-	InputTokens   int64   // Total input tokens used
-	OutputTokens  int64   // Total output tokens used
-	InputRate     float64 // $/MTok for input (captured at session start)
-	OutputRate    float64 // $/MTok for output (captured at session start)
-	TokensBudget  *int64
-	DollarsBudget *float64
-	MaxRuntime    time.Duration // Maximum runtime before termination (0 = unlimited)
+	InputTokens    int64   // Total input tokens used
+	OutputTokens   int64   // Total output tokens used
+	ThinkingTokens int64   // Output tokens spent on extended thinking (part of OutputTokens)
+	InputRate      float64 // $/MTok for input (captured at session start)
+	OutputRate     float64 // $/MTok for output (captured at session start)
+	TokensBudget   *int64
+	DollarsBudget  *float64
+	MaxRuntime     time.Duration // Maximum runtime before termination (0 = unlimited)
 
 	StartedAt    time.Time
 	LastActivity time.Time
@@ -67,10 +72,29 @@ type ActiveSession struct {
 	// For resuming from a previous session's checkpoint
 	RestoreFromSessionID string
 
+	// ReviewFeedback: PR review comments, pipeline retry context, or the
+	// previous hat's handoff to address, injected after the checkpoint is restored
+	ReviewFeedback string
+
+	// BudgetExtension: limits raised on resume, applied after the checkpoint is restored
+	BudgetExtension BudgetExtension
+
 	// Termination tracking (persisted to DB when session ends)
 	TerminationReason   string // Why the session ended (e.g., "completed", "max_iterations", "quality_gate_exhausted")
 	QualityGateAttempts int    // Number of quality gate validation attempts
 
+	// Priority orders the session's API requests behind the shared request
+	// gate: low, normal, or high (guarded by the manager's mutex)
+	Priority string
+
+	// Loop state published for Inspect (guarded by the manager's mutex)
+	inspection *loopInspection
+
+	// Forced checkpoint requests the loop answers at its next safe point
+	// (guarded by the manager's mutex)
+	checkpointWaiters []chan checkpointResult
+	checkpointsClosed bool // The loop has exited and answers no more requests
+
 	// For cancellation
 	cancel context.CancelFunc
 	done   chan struct{}
@@ -105,6 +129,10 @@ type ChecklistUpdatedCallback func(taskID string)
 // TaskStatusCallback is called when a task status changes (for issue sync)
 type TaskStatusCallback func(taskID string, status string)
 
+// SessionEndedCallback is called when a session stops running, after its
+// task's status has been updated (for starting queued tasks)
+type SessionEndedCallback func(taskID string)
+
 type Manager struct {
 	db           *db.DB
 	scheduler    *orchestrator.Scheduler
@@ -131,6 +159,7 @@ type Manager struct {
 	onPRCreated        PRCreatedCallback
 	onChecklistUpdated ChecklistUpdatedCallback
 	onTaskStatus       TaskStatusCallback
+	onSessionEnded     SessionEndedCallback
 
 	mu       sync.RWMutex
 	sessions map[string]*ActiveSession // sessionID -> session
@@ -139,11 +168,35 @@ type Manager struct {
 	// Transition tracking for loop detection (per task)
 	transitionTrackers map[string]*TransitionTracker // taskID -> tracker
 
+	// Pipeline retries used since each task last completed or paused
+	pipelineRetries map[string]int // taskID -> retries
+
+	// Tool call metrics (global and per session)
+	toolMetrics *ToolMetrics
+
 	// Configuration
 	defaultMaxIterations int
 	defaultTokenBudget   *int64
 	defaultDollarBudget  *float64
 	defaultMaxRuntime    time.Duration
+	requestTimeout       time.Duration               // Per LLM request deadline
+	signals              SignalConfig                // Signal markers and stop sequences
+	secretScanner        *security.SecretScanner     // Pre-commit secret detection (nil = default patterns)
+	injectionDetector    *security.InjectionDetector // Prompt-injection detection in tool output (nil = default patterns)
+	activityLevel        string                      // Activity level for tasks and projects that don't set one
+	broadcastLevel       string                      // Activity level broadcast to clients
+	promptSensitivity    security.Sensitivity        // Prompt sensitivity for tasks and projects that don't set one
+	maxMessages          int                         // Hard cap on session message history (0 = no cap)
+	budgetWarnings       []float64                   // Budget fractions at which sessions warn (empty = none)
+	hatMaxTokens         map[string]int              // Output token limit per response, by hat
+	maxTaskCost          float64                     // Dollars a task may spend across all its sessions (0 = no ceiling)
+	githubClient         *toolbelt.GitHubClient      // Global GitHub credentials (nil = none)
+	gitCredentials       *db.EncryptedSecretsStore   // Per-project git credentials (nil = global only)
+	gitRetry             gitprovider.RetryPolicy     // Retries for provider calls that finalize tasks
+	preamble             string                      // Guidance prepended to every hat's system prompt
+	defaultGitAuthor     *db.GitAuthor               // Commit identity for projects that don't set one (nil = git's own)
+	commitTrailer        string                      // Trailer added to commits made through git_commit (empty = none)
+	handoffOptions       HandoffOptions              // Handoff summary model and sections
 }
 
 // NewManager creates a session manager
@@ -161,8 +214,19 @@ func NewManager(database *db.DB, scheduler *orchestrator.Scheduler, promptsDir s
 		sessions:             make(map[string]*ActiveSession),
 		byTask:               make(map[string]string),
 		transitionTrackers:   make(map[string]*TransitionTracker),
+		pipelineRetries:      make(map[string]int),
+		toolMetrics:          NewToolMetrics(),
 		defaultMaxIterations: 100,
 		defaultMaxRuntime:    4 * time.Hour, // Default: 4 hours
+		requestTimeout:       DefaultRequestTimeout,
+		signals:              DefaultSignalConfig(),
+		activityLevel:        db.ActivityLevelStandard,
+		broadcastLevel:       db.ActivityLevelStandard,
+		promptSensitivity:    security.SensitivityNormal,
+		maxMessages:          DefaultMaxMessages,
+		budgetWarnings:       DefaultBudgetWarnings,
+		hatMaxTokens:         DefaultHatMaxTokens(),
+		gitRetry:             gitprovider.DefaultRetryPolicy(),
 	}
 }
 
@@ -171,6 +235,11 @@ func (m *Manager) GetPromptLoader() *PromptLoader {
 	return m.promptLoader
 }
 
+// ToolMetrics returns the tool call metrics aggregator
+func (m *Manager) ToolMetrics() *ToolMetrics {
+	return m.toolMetrics
+}
+
 // SetDefaults configures default budget limits for new sessions
 func (m *Manager) SetDefaults(maxIterations int, tokenBudget *int64, dollarBudgetFloat *float64) {
 	m.mu.Lock()
@@ -188,6 +257,185 @@ func (m *Manager) SetMaxRuntime(d time.Duration) {
 	m.defaultMaxRuntime = d
 }
 
+// SetRequestTimeout configures the deadline for each LLM request in new sessions (0 disables it)
+func (m *Manager) SetRequestTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requestTimeout = d
+}
+
+// SetMaxMessages configures the hard cap on message history for new sessions (0 or less removes it)
+func (m *Manager) SetMaxMessages(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxMessages = max(n, 0)
+}
+
+// SetBudgetWarnings configures the fractions of a token or dollar budget at
+// which new sessions warn and offer to raise it (empty disables warnings)
+func (m *Manager) SetBudgetWarnings(thresholds []float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budgetWarnings = slices.Sorted(slices.Values(thresholds))
+}
+
+// SetHatMaxTokens configures each hat's output token limit per response in
+// new sessions; hats left out use DefaultMaxTokens
+func (m *Manager) SetHatMaxTokens(limits map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hatMaxTokens = maps.Clone(limits)
+}
+
+// HatMaxTokens returns a hat's output token limit per response
+func (m *Manager) HatMaxTokens(hat string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return MaxTokens(hat, m.hatMaxTokens)
+}
+
+// SetGitRetry configures how provider calls made when a task finishes, such as
+// creating its PR, are retried after transient failures
+func (m *Manager) SetGitRetry(policy gitprovider.RetryPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gitRetry = policy
+}
+
+// SetActivityLevel configures which activity events sessions record when neither
+// their task nor project sets a level
+func (m *Manager) SetActivityLevel(level string) error {
+	if err := db.ValidateActivityLevel(level); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activityLevel = level
+	return nil
+}
+
+// SetPromptSensitivity configures how aggressively untrusted content is
+// neutralized in sessions whose task and project don't set a level
+func (m *Manager) SetPromptSensitivity(level string) error {
+	sensitivity, err := security.ParseSensitivity(level)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.promptSensitivity = sensitivity
+	return nil
+}
+
+// SetPreamble configures guidance prepended to every hat's system prompt, ahead
+// of any project preamble (empty clears it). Sessions started afterwards use it.
+func (m *Manager) SetPreamble(preamble string) error {
+	preamble = NormalizePreamble(preamble)
+	if err := ValidatePreamble(preamble); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.preamble = preamble
+	return nil
+}
+
+// Preamble returns the guidance prepended to every hat's system prompt
+func (m *Manager) Preamble() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.preamble
+}
+
+// SetActivityBroadcastLevel configures which activity events sessions broadcast to
+// clients. Events are only broadcast if they're also recorded.
+func (m *Manager) SetActivityBroadcastLevel(level string) error {
+	if err := db.ValidateBroadcastLevel(level); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcastLevel = level
+	return nil
+}
+
+// SetSignals configures the signal markers and stop sequences for new sessions.
+// Prompts are reloaded so they instruct the model to emit the configured markers.
+func (m *Manager) SetSignals(signals SignalConfig) error {
+	if err := signals.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signals = signals
+	return m.promptLoader.SetSignals(signals)
+}
+
+// SetSecretScanner configures secret detection for commits made by new sessions
+func (m *Manager) SetSecretScanner(scanner *security.SecretScanner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secretScanner = scanner
+}
+
+// SetDefaultGitAuthor sets the identity sessions commit as in projects that
+// don't set their own (nil leaves git's own identity)
+func (m *Manager) SetDefaultGitAuthor(author *db.GitAuthor) error {
+	if author != nil {
+		if err := author.Validate(); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultGitAuthor = author
+	return nil
+}
+
+// SetCommitTrailer sets a trailer, e.g. "Assisted-by: Dex", added to commits
+// new sessions make through git_commit (empty adds none)
+func (m *Manager) SetCommitTrailer(trailer string) error {
+	if trailer != "" {
+		if err := git.ValidateTrailer(trailer); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commitTrailer = trailer
+	return nil
+}
+
+// SetInjectionDetector configures prompt-injection detection for tool output in new sessions
+func (m *Manager) SetInjectionDetector(detector *security.InjectionDetector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.injectionDetector = detector
+}
+
+// SetHandoffOptions configures the model and sections of handoff summaries
+func (m *Manager) SetHandoffOptions(options HandoffOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handoffOptions = options
+}
+
+// newHandoffGenerator creates a handoff generator with the configured options
+func (m *Manager) newHandoffGenerator() *HandoffGenerator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	gen := NewHandoffGenerator(m.db, m.gitOps)
+	gen.SetOptions(m.anthropicClient, m.handoffOptions)
+	return gen
+}
+
 // SetAnthropicClient sets the Anthropic client for the Ralph loop
 func (m *Manager) SetAnthropicClient(client *toolbelt.AnthropicClient) {
 	m.mu.Lock()
@@ -268,6 +516,14 @@ func (m *Manager) SetOnTaskStatus(callback TaskStatusCallback) {
 	m.onTaskStatus = callback
 }
 
+// SetOnSessionEnded sets a callback for sessions that stop running, whether
+// the task finished, paused, failed, or moved on to its next hat
+func (m *Manager) SetOnSessionEnded(callback SessionEndedCallback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onSessionEnded = callback
+}
+
 // SetForgejoCredentials sets the Forgejo API credentials for PR creation.
 func (m *Manager) SetForgejoCredentials(baseURL, botToken string) {
 	m.mu.Lock()
@@ -295,6 +551,16 @@ func (m *Manager) notifyTaskStatus(taskID string, status string) {
 	}
 }
 
+// notifySessionEnded notifies listeners that a task's session stopped running
+func (m *Manager) notifySessionEnded(taskID string) {
+	m.mu.RLock()
+	callback := m.onSessionEnded
+	m.mu.RUnlock()
+	if callback != nil {
+		go callback(taskID)
+	}
+}
+
 // broadcastTaskUpdated sends a task.updated WebSocket event
 func (m *Manager) broadcastTaskUpdated(taskID string, status string) {
 	m.mu.RLock()
@@ -343,6 +609,11 @@ func (m *Manager) CreateSession(taskID, hat, worktreePath string) (*ActiveSessio
 		return nil, fmt.Errorf("task not found: %s", taskID)
 	}
 
+	// A task that has spent its cost ceiling gets no more sessions
+	if err := m.checkTaskCost(taskID); err != nil {
+		return nil, err
+	}
+
 	// Create session record in DB
 	dbSession, err := m.db.CreateSession(taskID, hat, worktreePath)
 	if err != nil {
@@ -361,6 +632,7 @@ func (m *Manager) CreateSession(taskID, hat, worktreePath string) (*ActiveSessio
 		TokensBudget:  m.defaultTokenBudget,
 		DollarsBudget: m.defaultDollarBudget,
 		MaxRuntime:    m.defaultMaxRuntime,
+		Priority:      db.SessionPriorityNormal,
 		done:          make(chan struct{}),
 	}
 
@@ -388,6 +660,7 @@ func (m *Manager) Start(ctx context.Context, sessionID string) error {
 	session.State = StateStarting
 	session.StartedAt = time.Now()
 	session.LastActivity = time.Now()
+	session.checkpointsClosed = false
 
 	// Create cancellable context
 	sessionCtx, cancel := context.WithCancel(ctx)
@@ -560,11 +833,21 @@ func (m *Manager) copySession(s *ActiveSession) *ActiveSession {
 // runSession is the main session execution loop (Ralph loop)
 func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 	defer close(session.done)
+	defer m.notifySessionEnded(session.TaskID)
 
 	m.mu.Lock()
 	session.State = StateRunning
 	anthropicClient := m.anthropicClient
 	broadcaster := m.broadcaster
+	requestTimeout := m.requestTimeout
+	signals := m.signals
+	activityLevel := m.activityLevel
+	broadcastLevel := m.broadcastLevel
+	promptSensitivity := m.promptSensitivity
+	maxMessages := m.maxMessages
+	budgetWarnings := m.budgetWarnings
+	hatMaxTokens := m.hatMaxTokens
+	injectionDetector := m.injectionDetector
 	originalHat := session.Hat
 	m.mu.Unlock()
 
@@ -576,6 +859,41 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 	if anthropicClient != nil {
 		fmt.Printf("runSession: Anthropic client is configured, starting Ralph loop\n")
 		loop := NewRalphLoop(m, session, anthropicClient, broadcaster, m.db)
+		loop.SetRequestTimeout(requestTimeout)
+		loop.SetSignals(signals)
+		loop.SetMaxMessages(maxMessages)
+		loop.SetBudgetWarnings(budgetWarnings)
+		loop.SetHatMaxTokens(hatMaxTokens)
+		if injectionDetector != nil {
+			loop.SetInjectionDetector(injectionDetector)
+		}
+
+		if level, err := m.db.ResolveActivityLevel(session.TaskID); err != nil {
+			fmt.Printf("runSession: warning - failed to resolve activity level: %v\n", err)
+		} else if level != "" {
+			activityLevel = level
+		}
+		loop.SetActivityLevel(activityLevel)
+		loop.SetActivityBroadcastLevel(broadcastLevel)
+
+		if level, err := m.db.ResolvePromptSensitivity(session.TaskID); err != nil {
+			fmt.Printf("runSession: warning - failed to resolve prompt sensitivity: %v\n", err)
+		} else if level != "" {
+			promptSensitivity = security.Sensitivity(level)
+		}
+		loop.SetPromptSensitivity(promptSensitivity)
+
+		if budget, err := m.db.GetTaskThinkingBudget(session.TaskID); err != nil {
+			fmt.Printf("runSession: warning - failed to get thinking budget: %v\n", err)
+		} else {
+			loop.SetThinkingBudget(budget)
+		}
+
+		if fallback, err := m.db.ResolveModelFallback(session.TaskID); err != nil {
+			fmt.Printf("runSession: warning - failed to resolve model fallback: %v\n", err)
+		} else {
+			loop.SetModelFallback(fallback)
+		}
 
 		// Get or create transition tracker for this task and set up event router
 		m.mu.Lock()
@@ -607,10 +925,50 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 				owner := project.GetOwner()
 				repo := project.GetRepo()
 
-				// Initialize executor (no GitHub client - using Forgejo)
-				loop.InitExecutor(session.WorktreePath, m.gitOps, nil, owner, repo)
+				// Initialize executor with the project's GitHub credentials (none for Forgejo)
+				loop.InitExecutor(session.WorktreePath, m.gitOps, m.resolveGitHubClient(ctx, project), owner, repo)
 				fmt.Printf("runSession: initialized tool executor (owner=%s, repo=%s)\n", owner, repo)
 
+				m.mu.RLock()
+				secretScanner := m.secretScanner
+				defaultGitAuthor := m.defaultGitAuthor
+				commitTrailer := m.commitTrailer
+				m.mu.RUnlock()
+
+				// Commit as the project's configured identity, or the server's, whichever tool makes the commit
+				author, err := m.db.GetProjectGitAuthor(project.ID)
+				if err != nil {
+					fmt.Printf("runSession: warning - failed to get project git author: %v\n", err)
+				}
+				if author == nil {
+					author = defaultGitAuthor
+				}
+				if author != nil && m.gitOps != nil {
+					if err := m.gitOps.SetCommitIdentity(session.WorktreePath, author.Name, author.Email); err != nil {
+						fmt.Printf("runSession: warning - failed to set commit identity: %v\n", err)
+					}
+				}
+				loop.SetCommitTrailer(commitTrailer)
+				loop.SetBaseBranch(task.BaseBranch)
+
+				if secretScanner != nil {
+					loop.SetSecretScanner(secretScanner)
+				}
+
+				// Opt-in caching of repeated read-only tool calls
+				if size, err := m.db.GetProjectToolCacheSize(project.ID); err != nil {
+					fmt.Printf("runSession: warning - failed to get tool cache size: %v\n", err)
+				} else if size > 0 {
+					loop.SetToolResultCache(tools.NewResultCache(size))
+				}
+
+				// Safety-critical projects won't accept a review that skipped the tests
+				if required, err := m.db.GetProjectCriticRequiresTests(project.ID); err != nil {
+					fmt.Printf("runSession: warning - failed to get critic test requirement: %v\n", err)
+				} else {
+					loop.SetCriticRequiresTests(required)
+				}
+
 				// Wire up mail/calendar executor if Central is configured
 				m.mu.RLock()
 				centralURL := m.centralURL
@@ -671,6 +1029,29 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 			}
 		}
 
+		// Addressing review feedback: keep the requested hat rather than the checkpoint's
+		if session.ReviewFeedback != "" {
+			loop.SetReviewFeedback(originalHat, session.ReviewFeedback)
+		}
+
+		// Resuming after a budget pause: raise limits past what the checkpoint used
+		if !session.BudgetExtension.IsZero() {
+			m.mu.Lock()
+			ext := session.BudgetExtension
+			ext.apply(session)
+			session.BudgetExtension = BudgetExtension{}
+			topUp := newBudgetTopUpData(ext, session)
+			iteration, hat := session.IterationCount, session.Hat
+			m.mu.Unlock()
+			fmt.Printf("runSession: extended budgets (max iterations %d)\n", topUp.MaxIterations)
+
+			recorder := NewActivityRecorder(m.db, session.ID, session.TaskID, loop.broadcastEvent)
+			recorder.SetHat(hat)
+			if err := recorder.RecordBudgetTopUp(iteration, topUp); err != nil {
+				fmt.Printf("runSession: warning - %v\n", err)
+			}
+		}
+
 		// Run the loop
 		loopErr = loop.Run(ctx)
 		if loopErr != nil {
@@ -701,7 +1082,7 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 		terminationReason = string(TerminationUserStopped)
 	} else if session.State == StatePaused {
 		// Keep paused state
-		terminationReason = "paused"
+		terminationReason = string(TerminationPaused)
 	} else if loopErr != nil {
 		// Check if it's a budget error (requires approval, not a failure)
 		switch loopErr {
@@ -719,7 +1100,10 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 			terminationReason = string(TerminationMaxRuntime)
 		case ErrBudgetExceeded:
 			session.State = StatePaused
-			terminationReason = "budget_exceeded"
+			terminationReason = string(TerminationBudgetExceeded)
+		case ErrCompletionApprovalRequired, ErrInjectionApprovalRequired:
+			session.State = StatePaused
+			terminationReason = string(TerminationAwaitingApproval)
 		case context.Canceled:
 			session.State = StateStopped
 			terminationReason = string(TerminationUserStopped)
@@ -727,7 +1111,9 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 			session.State = StateFailed
 			// Check if it's a loop health termination
 			errStr := loopErr.Error()
-			if strings.Contains(errStr, "loop terminated:") {
+			if errors.Is(loopErr, ErrRequestTimeout) {
+				terminationReason = string(TerminationRequestTimeout)
+			} else if strings.Contains(errStr, "loop terminated:") {
 				// Extract reason from "loop terminated: <reason>"
 				parts := strings.SplitN(errStr, "loop terminated: ", 2)
 				if len(parts) == 2 {
@@ -772,21 +1158,17 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 	// Update task status based on final state
 	switch finalState {
 	case StateCompleted:
+		m.resetPipelineRetries(taskID)
 		_ = m.db.UpdateTaskStatus(taskID, db.TaskStatusCompleted)
-		m.broadcastTaskUpdated(taskID, db.TaskStatusCompleted)
+		m.finishCompletedTask(taskID, worktreePath)
 
-		// Notify task completed (for issue sync)
-		m.mu.RLock()
-		onTaskCompleted := m.onTaskCompleted
-		m.mu.RUnlock()
-		if onTaskCompleted != nil {
-			go onTaskCompleted(taskID)
+	case StateFailed:
+		// Restart at the hat the project's pipeline retry policy names, if any
+		if m.retryPipeline(taskID, sessionID, nextHat, terminationReason, worktreePath) {
+			return
 		}
+		m.resetPipelineRetries(taskID)
 
-		// Push branch and create PR (non-blocking, log errors)
-		go m.createPRForTask(taskID, worktreePath)
-
-	case StateFailed:
 		// Mark task as paused so it can be resumed after fixing the issue
 		_ = m.db.UpdateTaskStatus(taskID, db.TaskStatusPaused)
 		m.broadcastTaskUpdated(taskID, db.TaskStatusPaused)
@@ -800,12 +1182,45 @@ func (m *Manager) runSession(ctx context.Context, session *ActiveSession) {
 
 	case StatePaused, StateStopped:
 		// Mark task as paused so it can be resumed
+		m.resetPipelineRetries(taskID)
 		_ = m.db.UpdateTaskStatus(taskID, db.TaskStatusPaused)
 		m.broadcastTaskUpdated(taskID, db.TaskStatusPaused)
 		m.notifyTaskStatus(taskID, "paused")
 	}
 }
 
+// finishCompletedTask broadcasts a completed task and runs the completion hooks
+func (m *Manager) finishCompletedTask(taskID, worktreePath string) {
+	m.broadcastTaskUpdated(taskID, db.TaskStatusCompleted)
+
+	// Notify task completed (for issue sync)
+	m.mu.RLock()
+	onTaskCompleted := m.onTaskCompleted
+	m.mu.RUnlock()
+	if onTaskCompleted != nil {
+		go onTaskCompleted(taskID)
+	}
+
+	// Push branch and create PR (non-blocking, log errors)
+	go m.createPRForTask(taskID, worktreePath)
+}
+
+// CompleteApprovedTask completes a task whose session paused awaiting completion approval.
+// The task must still be paused; it is marked completed and the usual completion hooks run.
+func (m *Manager) CompleteApprovedTask(taskID string) error {
+	if err := m.db.TransitionTaskStatus(taskID, db.TaskStatusPaused, db.TaskStatusCompleted); err != nil {
+		return err
+	}
+
+	task, err := m.db.GetTaskByID(taskID)
+	if err != nil || task == nil {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	m.finishCompletedTask(taskID, task.GetWorktreePath())
+	return nil
+}
+
 // handleHatTransition handles transitioning a task to a new hat
 func (m *Manager) handleHatTransition(ctx context.Context, taskID, originalHat, nextHat, worktreePath string) {
 	// Get transition tracker and old session ID
@@ -829,12 +1244,23 @@ func (m *Manager) handleHatTransition(ctx context.Context, taskID, originalHat,
 
 	// Now safe to remove old session
 	m.mu.Lock()
+	var scratchpad string
+	if oldSession := m.sessions[oldSessionID]; oldSession != nil {
+		scratchpad = oldSession.Scratchpad
+	}
 	delete(m.sessions, oldSessionID)
 	delete(m.byTask, taskID)
 	m.mu.Unlock()
 
 	// Create new session with next hat
 	newSession, err := m.CreateSession(taskID, nextHat, worktreePath)
+	if errors.Is(err, ErrTaskCostCeiling) {
+		fmt.Printf("hat transition: task %s not transitioning to %s: %v, pausing task\n", taskID, nextHat, err)
+		_ = m.db.UpdateTaskStatus(taskID, db.TaskStatusPaused)
+		m.broadcastTaskUpdated(taskID, db.TaskStatusPaused)
+		m.cleanupTransitionTracker(taskID)
+		return
+	}
 	if err != nil {
 		fmt.Printf("error: failed to create session for hat transition: %v\n", err)
 		_ = m.db.UpdateTaskStatus(taskID, db.TaskStatusCancelled)
@@ -842,6 +1268,12 @@ func (m *Manager) handleHatTransition(ctx context.Context, taskID, originalHat,
 		return
 	}
 
+	// Tell the next hat what it's picking up, tailored to what it needs
+	handoff := m.newHandoffGenerator().generate(taskID, originalHat, nextHat, scratchpad, worktreePath)
+	m.mu.Lock()
+	newSession.ReviewFeedback = handoff.Format()
+	m.mu.Unlock()
+
 	// Start the new session
 	if err := m.Start(ctx, newSession.ID); err != nil {
 		fmt.Printf("error: failed to start session for hat transition: %v\n", err)
@@ -853,6 +1285,13 @@ func (m *Manager) handleHatTransition(ctx context.Context, taskID, originalHat,
 	fmt.Printf("hat transition: task %s transitioned from %s to %s (session %s)\n", taskID, originalHat, nextHat, newSession.ID)
 }
 
+// resetPipelineRetries forgets the pipeline retries a task has used
+func (m *Manager) resetPipelineRetries(taskID string) {
+	m.mu.Lock()
+	delete(m.pipelineRetries, taskID)
+	m.mu.Unlock()
+}
+
 // cleanupTransitionTracker removes the transition tracker for a task
 func (m *Manager) cleanupTransitionTracker(taskID string) {
 	m.mu.Lock()
@@ -973,6 +1412,7 @@ func (m *Manager) createPRForTask(taskID, worktreePath string) {
 
 	m.mu.RLock()
 	gitOps := m.gitOps
+	gitRetry := m.gitRetry
 	m.mu.RUnlock()
 
 	// Get task from DB
@@ -1016,11 +1456,28 @@ func (m *Manager) createPRForTask(taskID, worktreePath string) {
 		}
 
 		forgejoProvider := forgejoclient.New(baseURL, botToken)
-		pr, err := forgejoProvider.CreatePR(ctx, owner, repo, gitprovider.CreatePROpts{
-			Title: task.Title,
-			Body:  fmt.Sprintf("Closes task: %s\n\n%s", taskID, task.GetDescription()),
-			Head:  branchName,
-			Base:  project.DefaultBranch,
+
+		// The task already has a PR (e.g. it was resumed to address review
+		// feedback): its commits are already on the PR's branch
+		if task.PRNumber.Valid {
+			prNumber := int(task.PRNumber.Int64)
+			if _, err := forgejoProvider.AddComment(ctx, owner, repo, prNumber, "Review feedback addressed. Please take another look."); err != nil {
+				fmt.Printf("createPRForTask: failed to comment on Forgejo PR #%d for task %s: %v\n", prNumber, taskID, err)
+			}
+			fmt.Printf("createPRForTask: task %s already has PR #%d, skipping PR creation\n", taskID, prNumber)
+			return
+		}
+
+		var pr *gitprovider.PullRequest
+		err = gitRetry.Do(ctx, "createPRForTask: create Forgejo PR", gitprovider.IsRetryable, func(ctx context.Context) error {
+			created, err := forgejoProvider.CreatePR(ctx, owner, repo, gitprovider.CreatePROpts{
+				Title: task.Title,
+				Body:  fmt.Sprintf("Closes task: %s\n\n%s", taskID, task.GetDescription()),
+				Head:  branchName,
+				Base:  project.DefaultBranch,
+			})
+			pr = created
+			return err
 		})
 		if err != nil {
 			fmt.Printf("createPRForTask: failed to create Forgejo PR for task %s: %v\n", taskID, err)
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxTokens is the output token limit per response for hats without
+// their own
+const DefaultMaxTokens = 8192
+
+// Range a hat's output token limit may be set to
+const (
+	MinHatMaxTokens = 1024
+	MaxHatMaxTokens = 64000 // Largest output the session models support
+)
+
+// hatMaxTokens are the hats whose output token limit differs from
+// DefaultMaxTokens. Planning hats write long plans; the editor's changes are
+// mechanical and small. Extended thinking is added on top.
+var hatMaxTokens = map[string]int{
+	"planner":  16384,
+	"designer": 16384,
+	"editor":   4096,
+}
+
+// DefaultHatMaxTokens returns the built-in per-hat output token limits
+func DefaultHatMaxTokens() map[string]int {
+	return maps.Clone(hatMaxTokens)
+}
+
+// MaxTokens returns a hat's output token limit per response from limits,
+// falling back to DefaultMaxTokens
+func MaxTokens(hat string, limits map[string]int) int {
+	if n, ok := limits[hat]; ok && n > 0 {
+		return n
+	}
+	return DefaultMaxTokens
+}
+
+// ParseHatMaxTokens overrides the default per-hat output token limits from a
+// comma-separated list of hat=tokens pairs, e.g. "planner=24000,editor=2048".
+// An empty spec returns the defaults.
+func ParseHatMaxTokens(spec string) (map[string]int, error) {
+	limits := DefaultHatMaxTokens()
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		hat, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid hat max tokens %q: expected hat=tokens", pair)
+		}
+		hat = strings.TrimSpace(hat)
+		if !IsValidHat(hat) {
+			return nil, fmt.Errorf("unknown hat %q (must be one of %s)", hat, strings.Join(ValidHats, ", "))
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid max tokens for %s: %w", hat, err)
+		}
+		if n < MinHatMaxTokens || n > MaxHatMaxTokens {
+			return nil, fmt.Errorf("max tokens for %s must be between %d and %d", hat, MinHatMaxTokens, MaxHatMaxTokens)
+		}
+		limits[hat] = n
+	}
+	return limits, nil
+}
+
+// SetHatMaxTokens configures the per-hat output token limits (nil uses DefaultMaxTokens for every hat)
+func (r *RalphLoop) SetHatMaxTokens(limits map[string]int) {
+	r.hatMaxTokens = limits
+}
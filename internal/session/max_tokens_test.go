@@ -0,0 +1,39 @@
+package session
+
+import "testing"
+
+func TestParseHatMaxTokens(t *testing.T) {
+	limits, err := ParseHatMaxTokens("")
+	if err != nil {
+		t.Fatalf("ParseHatMaxTokens(\"\") error = %v", err)
+	}
+	if got := MaxTokens("planner", limits); got != hatMaxTokens["planner"] {
+		t.Errorf("default planner = %d, want %d", got, hatMaxTokens["planner"])
+	}
+	if got := MaxTokens("creator", limits); got != DefaultMaxTokens {
+		t.Errorf("default creator = %d, want %d", got, DefaultMaxTokens)
+	}
+
+	limits, err = ParseHatMaxTokens(" planner=24000, creator=12000 ")
+	if err != nil {
+		t.Fatalf("ParseHatMaxTokens() error = %v", err)
+	}
+	if got := MaxTokens("planner", limits); got != 24000 {
+		t.Errorf("planner = %d, want 24000", got)
+	}
+	if got := MaxTokens("creator", limits); got != 12000 {
+		t.Errorf("creator = %d, want 12000", got)
+	}
+	if got := MaxTokens("editor", limits); got != hatMaxTokens["editor"] {
+		t.Errorf("editor = %d, want its default %d", got, hatMaxTokens["editor"])
+	}
+	if hatMaxTokens["planner"] == 24000 {
+		t.Error("overrides changed the built-in defaults")
+	}
+
+	for _, spec := range []string{"planner", "wizard=4096", "planner=lots", "editor=512", "planner=100000"} {
+		if _, err := ParseHatMaxTokens(spec); err == nil {
+			t.Errorf("ParseHatMaxTokens(%q) expected error", spec)
+		}
+	}
+}
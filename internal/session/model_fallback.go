@@ -0,0 +1,99 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// ModelFallbackData is the content of a model_fallback activity: a request
+// the session's model couldn't serve for lack of capacity, retried on another
+type ModelFallbackData struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Error string `json:"error"`
+}
+
+// apiModel returns the API model ID for a task model ("sonnet" or "opus")
+func apiModel(model string) string {
+	if model == db.TaskModelOpus {
+		return "claude-opus-4-5-20251101"
+	}
+	return "claude-sonnet-4-5-20250929"
+}
+
+// isCapacityError reports whether err is the API saying the model is overloaded
+func isCapacityError(err error) bool {
+	var apiErr *toolbelt.AnthropicAPIError
+	return errors.As(err, &apiErr) && apiErr.IsOverloadedError()
+}
+
+// SetModelFallback sets the models tried, in order, when the loop's model is
+// over capacity (nil or empty turns fallback off)
+func (r *RalphLoop) SetModelFallback(models []string) {
+	r.modelFallback = models
+}
+
+// fallbackModels returns the models to try after primary, skipping primary itself
+func (r *RalphLoop) fallbackModels(primary string) []string {
+	var models []string
+	for _, model := range r.modelFallback {
+		if model != primary {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// recordModelFallback notes in the session's activity that a request moved
+// from one model to another because the first was over capacity
+func (r *RalphLoop) recordModelFallback(from, to string, cause error) {
+	fmt.Printf("RalphLoop: %s is over capacity, falling back to %s: %v\n", from, to, cause)
+	if !r.fellBack {
+		// Rates are only captured when the task names its model
+		if r.session.InputRate == 0 && r.session.OutputRate == 0 {
+			r.session.InputRate, r.session.OutputRate = ModelRates(from)
+		}
+		r.fellBack = true
+	}
+	if r.activity == nil {
+		return
+	}
+	if err := r.activity.RecordModelFallback(r.session.IterationCount+1, &ModelFallbackData{
+		From:  from,
+		To:    to,
+		Error: cause.Error(),
+	}); err != nil {
+		fmt.Printf("RalphLoop: warning - failed to record model fallback: %v\n", err)
+	}
+}
+
+// blendRates folds a response's tokens, priced at the rates of the model that
+// served it, into the session's rates. Cost is computed as the session's
+// tokens times its rates, so once the session has fallen back, the rates
+// become token-weighted averages of the models it used. Call it before adding
+// the response's tokens to the session's.
+func (r *RalphLoop) blendRates(model string, inputTokens, outputTokens int) {
+	inputRate, outputRate := ModelRates(model)
+	if !r.fellBack || (inputRate == r.session.InputRate && outputRate == r.session.OutputRate) {
+		return
+	}
+
+	blend := func(rate float64, tokens int64, rateNew float64, tokensNew int) float64 {
+		total := tokens + int64(tokensNew)
+		if total == 0 {
+			return rate
+		}
+		return (rate*float64(tokens) + rateNew*float64(tokensNew)) / float64(total)
+	}
+	r.session.InputRate = blend(r.session.InputRate, r.session.InputTokens, inputRate, inputTokens)
+	r.session.OutputRate = blend(r.session.OutputRate, r.session.OutputTokens, outputRate, outputTokens)
+
+	if r.db != nil {
+		if err := r.db.SetSessionRates(r.session.ID, r.session.InputRate, r.session.OutputRate); err != nil {
+			fmt.Printf("RalphLoop: warning - failed to update session rates: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestFallbackModels(t *testing.T) {
+	loop := &RalphLoop{modelFallback: []string{"opus", "sonnet"}}
+	if got := loop.fallbackModels("opus"); !slices.Equal(got, []string{"sonnet"}) {
+		t.Errorf("fallbackModels(opus) = %v, want [sonnet]", got)
+	}
+	if got := (&RalphLoop{}).fallbackModels("opus"); got != nil {
+		t.Errorf("fallbackModels() without a chain = %v, want none", got)
+	}
+}
+
+func TestIsCapacityError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&toolbelt.AnthropicAPIError{StatusCode: 529, Type: "overloaded_error"}, true},
+		{fmt.Errorf("wrapped: %w", &toolbelt.AnthropicAPIError{StatusCode: 500, Type: "overloaded_error"}), true},
+		{&toolbelt.AnthropicAPIError{StatusCode: 429, Type: "rate_limit_error"}, false},
+		{&toolbelt.AnthropicAPIError{StatusCode: 500, Type: "api_error"}, false},
+		{ErrRequestTimeout, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isCapacityError(tt.err); got != tt.want {
+			t.Errorf("isCapacityError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBlendRates(t *testing.T) {
+	opusIn, opusOut := ModelRates("opus")
+	sonnetIn, sonnetOut := ModelRates("sonnet")
+
+	session := &ActiveSession{ID: "sess-1", InputRate: opusIn, OutputRate: opusOut}
+	loop := &RalphLoop{session: session}
+	add := func(model string, in, out int) {
+		loop.blendRates(model, in, out)
+		session.InputTokens += int64(in)
+		session.OutputTokens += int64(out)
+	}
+
+	// Nothing changes until the session has fallen back
+	add("opus", 1000, 100)
+	add("sonnet", 0, 0)
+	if session.InputRate != opusIn || session.OutputRate != opusOut {
+		t.Fatalf("rates changed without a fallback: %v/%v", session.InputRate, session.OutputRate)
+	}
+
+	loop.fellBack = true
+	add("sonnet", 3000, 300)
+	add("opus", 1000, 100)
+
+	want := (2000*opusIn+3000*sonnetIn)/1e6 + (200*opusOut+300*sonnetOut)/1e6
+	if got := session.Cost(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/security"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// pipelineRetryContextLimit caps each piece of the failed session's context in retry feedback
+const pipelineRetryContextLimit = 2000
+
+// ValidatePipelineRetryPolicy checks the policy's structure and that every rule names known hats
+func ValidatePipelineRetryPolicy(policy *db.PipelineRetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	for _, rule := range policy.Rules {
+		if !IsValidHat(rule.FailedHat) {
+			return fmt.Errorf("unknown hat %q in pipeline retry rule", rule.FailedHat)
+		}
+		if !IsValidHat(rule.RetryHat) {
+			return fmt.Errorf("unknown hat %q in pipeline retry rule", rule.RetryHat)
+		}
+	}
+	return nil
+}
+
+// retryPipeline restarts a failed task at the hat its project's pipeline
+// retry policy names for the failed hat, instead of pausing it. The new
+// session restores the latest checkpoint of an earlier session in the retry
+// hat, if there is one, and is told why the previous attempt failed.
+// Returns false if the task should pause as usual.
+func (m *Manager) retryPipeline(taskID, failedSessionID, failedHat, reason, worktreePath string) bool {
+	task, err := m.db.GetTaskByID(taskID)
+	if err != nil || task == nil {
+		return false
+	}
+	policy, err := m.db.GetProjectPipelineRetry(task.ProjectID)
+	if err != nil {
+		fmt.Printf("retryPipeline: warning - failed to get pipeline retry policy for task %s: %v\n", taskID, err)
+		return false
+	}
+	retryHat, ok := policy.RetryHatFor(failedHat)
+	if !ok {
+		return false
+	}
+
+	m.mu.Lock()
+	attempt := m.pipelineRetries[taskID] + 1
+	if attempt > policy.Limit() {
+		m.mu.Unlock()
+		fmt.Printf("retryPipeline: task %s used all %d pipeline retries, pausing\n", taskID, policy.Limit())
+		return false
+	}
+	m.pipelineRetries[taskID] = attempt
+	m.mu.Unlock()
+
+	// Pick up the retry hat's own work, not the failed session's
+	restoreFrom := ""
+	if sessions, err := m.db.ListSessionsByTask(taskID); err == nil {
+		for _, s := range sessions { // Most recent first
+			if s.ID != failedSessionID && s.Hat == retryHat {
+				restoreFrom = s.ID
+				break
+			}
+		}
+	}
+
+	feedback := m.pipelineRetryFeedback(failedSessionID, failedHat, reason, attempt, policy.Limit())
+	sess, err := m.CreateSession(taskID, retryHat, worktreePath)
+	if err != nil {
+		fmt.Printf("retryPipeline: warning - failed to create %s session for task %s: %v\n", retryHat, taskID, err)
+		return false
+	}
+	m.mu.Lock()
+	sess.RestoreFromSessionID = restoreFrom
+	sess.ReviewFeedback = feedback
+	m.mu.Unlock()
+
+	// The failed session's context is done; the retry runs on its own
+	if err := m.Start(context.Background(), sess.ID); err != nil {
+		fmt.Printf("retryPipeline: warning - failed to start %s session for task %s: %v\n", retryHat, taskID, err)
+		return false
+	}
+
+	fmt.Printf("retryPipeline: task %s failed in %s, retrying at %s (session %s, retry %d of %d)\n",
+		taskID, failedHat, retryHat, sess.ID, attempt, policy.Limit())
+	return true
+}
+
+// pipelineRetryFeedback tells the retry session what the failed session was
+// doing and why it failed, from the failed session's latest checkpoint
+func (m *Manager) pipelineRetryFeedback(failedSessionID, failedHat, reason string, attempt, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PIPELINE RETRY (%d of %d): The previous %s session for this task failed", attempt, limit, failedHat)
+	if reason != "" {
+		fmt.Fprintf(&sb, ": %s", reason)
+	}
+	sb.WriteString(".\n")
+
+	if checkpoint, err := m.db.GetLatestSessionCheckpoint(failedSessionID); err == nil && checkpoint != nil {
+		var state checkpointState
+		if json.Unmarshal(checkpoint.State, &state) == nil {
+			if state.Scratchpad != "" {
+				fmt.Fprintf(&sb, "\n## Its scratchpad\n%s\n", truncateForFeedback(security.SanitizeForPrompt(state.Scratchpad), pipelineRetryContextLimit))
+			}
+			if last := lastAssistantText(state.Messages); last != "" {
+				fmt.Fprintf(&sb, "\n## Its last response\n%s\n", truncateForFeedback(security.SanitizeForPrompt(last), pipelineRetryContextLimit))
+			}
+		}
+	}
+
+	sb.WriteString("\nPick up from here: address whatever the failed session couldn't, then continue the task.")
+	return sb.String()
+}
+
+// lastAssistantText returns the text of the most recent assistant message with any
+func lastAssistantText(messages []toolbelt.AnthropicMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "assistant" {
+			continue
+		}
+		if text := strings.TrimSpace(messageText(messages[i].Content)); text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestValidatePipelineRetryPolicy(t *testing.T) {
+	if err := ValidatePipelineRetryPolicy(&db.PipelineRetryPolicy{
+		Rules: []db.PipelineRetryRule{{FailedHat: "critic", RetryHat: "creator"}, {FailedHat: "editor", RetryHat: "editor"}},
+	}); err != nil {
+		t.Errorf("expected a valid policy, got %v", err)
+	}
+	if err := ValidatePipelineRetryPolicy(&db.PipelineRetryPolicy{
+		Rules: []db.PipelineRetryRule{{FailedHat: "critic", RetryHat: "wizard"}},
+	}); err == nil {
+		t.Error("expected an unknown retry hat to be rejected")
+	}
+	if err := ValidatePipelineRetryPolicy(nil); err != nil {
+		t.Errorf("expected nil to clear the policy, got %v", err)
+	}
+}
+
+func TestManager_PipelineRetryFeedback(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Add login", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := database.CreateSession(task.ID, "critic", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ := json.Marshal(checkpointState{
+		Hat:        "critic",
+		Scratchpad: "Reviewing the login handler",
+		Messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: "review"},
+			{Role: "assistant", Content: "The session cookie is missing SameSite"},
+			{Role: "user", Content: "continue"},
+		},
+	})
+	if _, err := database.CreateSessionCheckpoint(sess.ID, 3, state); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(database, nil, t.TempDir())
+	feedback := m.pipelineRetryFeedback(sess.ID, "critic", "repeated_error", 1, 2)
+	for _, want := range []string{"PIPELINE RETRY (1 of 2)", "previous critic session", "repeated_error", "Reviewing the login handler", "missing SameSite"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	// Without a checkpoint, just the failure
+	if feedback := m.pipelineRetryFeedback("sess-missing", "editor", "", 1, 1); strings.Contains(feedback, "scratchpad") {
+		t.Errorf("expected no checkpoint context, got:\n%s", feedback)
+	}
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lirancohen/dex/internal/security"
+)
+
+// MaxPreambleTokens caps a system-prompt preamble, so organization-wide
+// guidance can't crowd the hat's own prompt out of the context window
+const MaxPreambleTokens = 2000
+
+// NormalizePreamble sanitizes a preamble and trims surrounding whitespace
+func NormalizePreamble(preamble string) string {
+	return strings.TrimSpace(security.SanitizeForPrompt(preamble))
+}
+
+// ValidatePreamble reports whether the preamble fits within MaxPreambleTokens
+func ValidatePreamble(preamble string) error {
+	if tokens := len(preamble) / CharsPerToken; tokens > MaxPreambleTokens {
+		return fmt.Errorf("preamble is about %d tokens, more than the %d allowed", tokens, MaxPreambleTokens)
+	}
+	return nil
+}
+
+// withPreambles prepends the server and project preambles, in that order, to
+// a hat's rendered prompt. Empty preambles are skipped.
+func withPreambles(prompt string, preambles ...string) string {
+	var sections []string
+	for _, preamble := range preambles {
+		if preamble = NormalizePreamble(preamble); preamble != "" {
+			sections = append(sections, preamble)
+		}
+	}
+	if len(sections) == 0 {
+		return prompt
+	}
+	return strings.Join(append(sections, prompt), "\n\n")
+}
@@ -0,0 +1,34 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithPreambles(t *testing.T) {
+	prompt := "You are the creator."
+
+	if got := withPreambles(prompt, "", "  "); got != prompt {
+		t.Errorf("empty preambles changed the prompt to %q", got)
+	}
+
+	got := withPreambles(prompt, "Follow the style guide.\n", "Never use library X.")
+	want := "Follow the style guide.\n\nNever use library X.\n\nYou are the creator."
+	if got != want {
+		t.Errorf("withPreambles() = %q, want %q", got, want)
+	}
+
+	// Invisible characters are stripped before the preamble reaches the model
+	if got := withPreambles(prompt, "Be\u200b careful."); !strings.HasPrefix(got, "Be careful.\n\n") {
+		t.Errorf("preamble not sanitized: %q", got)
+	}
+}
+
+func TestValidatePreamble(t *testing.T) {
+	if err := ValidatePreamble(strings.Repeat("a", MaxPreambleTokens*CharsPerToken)); err != nil {
+		t.Errorf("rejected a preamble at the limit: %v", err)
+	}
+	if err := ValidatePreamble(strings.Repeat("a", (MaxPreambleTokens+1)*CharsPerToken)); err == nil {
+		t.Error("accepted a preamble over the limit")
+	}
+}
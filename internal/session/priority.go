@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+type startPriorityKey struct{}
+
+// WithStartPriority returns a context asking that tasks started with it run
+// their sessions at priority (db.SessionPriorityLow, Normal, or High)
+func WithStartPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, startPriorityKey{}, priority)
+}
+
+// StartPriority returns the priority set with WithStartPriority, or "" if none was
+func StartPriority(ctx context.Context) string {
+	priority, _ := ctx.Value(startPriorityKey{}).(string)
+	return priority
+}
+
+// SetPriority changes a session's priority and persists it. The session's
+// next API request queues at the new priority.
+func (m *Manager) SetPriority(sessionID, priority string) error {
+	if err := db.ValidateSessionPriority(priority); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	session, exists := m.sessions[sessionID]
+	if exists {
+		session.Priority = priority
+	}
+	m.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	return m.db.SetSessionPriority(sessionID, priority)
+}
+
+// requestPriority returns the request gate priority for the session's next request
+func (m *Manager) requestPriority(session *ActiveSession) toolbelt.RequestPriority {
+	m.mu.Lock()
+	priority := session.Priority
+	m.mu.Unlock()
+
+	switch priority {
+	case db.SessionPriorityLow:
+		return toolbelt.RequestPriorityLow
+	case db.SessionPriorityHigh:
+		return toolbelt.RequestPriorityHigh
+	default:
+		return toolbelt.RequestPriorityNormal
+	}
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestManager_SetPriority(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Build", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(database, nil, t.TempDir())
+	sess, err := m.CreateSession(task.ID, "creator", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.requestPriority(sess); got != toolbelt.RequestPriorityNormal {
+		t.Errorf("new session request priority = %v, want normal", got)
+	}
+
+	if err := m.SetPriority(sess.ID, db.SessionPriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.requestPriority(sess); got != toolbelt.RequestPriorityHigh {
+		t.Errorf("request priority = %v, want high", got)
+	}
+	if stored, _ := database.GetSessionPriority(sess.ID); stored != db.SessionPriorityHigh {
+		t.Errorf("stored priority = %q, want high", stored)
+	}
+
+	if err := m.SetPriority(sess.ID, "urgent"); err == nil {
+		t.Error("set an invalid priority")
+	}
+	if err := m.SetPriority("missing", db.SessionPriorityLow); err == nil {
+		t.Error("set the priority of a missing session")
+	}
+}
+
+func TestStartPriority(t *testing.T) {
+	if got := StartPriority(context.Background()); got != "" {
+		t.Errorf("StartPriority without one set = %q, want empty", got)
+	}
+	ctx := WithStartPriority(context.Background(), db.SessionPriorityLow)
+	if got := StartPriority(ctx); got != db.SessionPriorityLow {
+		t.Errorf("StartPriority = %q, want low", got)
+	}
+}
@@ -2,13 +2,14 @@ package session
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"slices"
 	"strings"
 
 	"github.com/lirancohen/dex/internal/db"
 	"github.com/lirancohen/dex/internal/tools"
+	"github.com/lirancohen/dex/prompts"
 	"github.com/lirancohen/promptloom"
 	"gopkg.in/yaml.v3"
 )
@@ -25,6 +26,7 @@ type PromptContext struct {
 	ProjectHints       string             // Loaded from .dexhints, AGENTS.md, etc.
 	ProjectMemories    string             // Formatted memory section from previous sessions
 	PredecessorContext string             // Handoff from predecessor task in dependency chain
+	Attachments        string             // Reference files attached to the task
 	Language           tools.ProjectType  // Detected programming language
 }
 
@@ -46,9 +48,13 @@ type ToolbeltService struct {
 // PromptLoader loads and assembles hat prompts using PromptLoom
 type PromptLoader struct {
 	promptsDir         string
+	fsys               fs.FS        // Where prompts were loaded from
+	set                *prompts.Set // Loaded prompt files, shipped to workers
 	registry           *promptloom.Registry
 	assembler          *promptloom.Assembler
 	languageGuidelines map[string]string // language name -> guidelines content
+	kickoffs           map[string]string // task type -> initial conversation guidance
+	signals            SignalConfig      // Markers the prompts tell the model to emit
 }
 
 // languageFile represents a language guidelines YAML file
@@ -57,24 +63,41 @@ type languageFile struct {
 	Instructions string `yaml:"instructions"`
 }
 
+// kickoffFile represents a task type kickoff YAML file
+type kickoffFile struct {
+	Type         string `yaml:"type"`
+	Instructions string `yaml:"instructions"`
+}
+
 // NewPromptLoader creates a prompt loader for the given prompts directory
 func NewPromptLoader(promptsDir string) *PromptLoader {
 	return &PromptLoader{
 		promptsDir: promptsDir,
 		registry:   promptloom.NewRegistry(),
+		signals:    DefaultSignalConfig(),
 	}
 }
 
-// LoadAll loads all prompt components and profiles from the prompts directory
+// LoadAll loads all prompt components and profiles from the prompts directory,
+// falling back to the prompts compiled into the binary if it doesn't exist
 func (p *PromptLoader) LoadAll() error {
-	fmt.Printf("PromptLoader.LoadAll: loading prompts from %s\n", p.promptsDir)
+	if info, err := os.Stat(p.promptsDir); err == nil && info.IsDir() {
+		fmt.Printf("PromptLoader.LoadAll: loading prompts from %s\n", p.promptsDir)
+		p.fsys = os.DirFS(p.promptsDir)
+	} else {
+		fmt.Printf("PromptLoader.LoadAll: %s not found, using embedded prompts\n", p.promptsDir)
+		p.fsys = prompts.FS
+	}
 
-	// Create filesystem from the prompts directory
-	fsys := os.DirFS(p.promptsDir)
+	set, err := prompts.Load(p.fsys)
+	if err != nil {
+		return fmt.Errorf("failed to load prompts: %w", err)
+	}
+	p.set = set
 
 	// Load components and profiles from the filesystem
-	// The root path is "." since DirFS is already rooted at promptsDir
-	if err := p.registry.LoadFromFS(fsys, "."); err != nil {
+	// The root path is "." since the filesystem is rooted at the prompts directory
+	if err := p.registry.LoadFromFS(p.fsys, "."); err != nil {
 		return fmt.Errorf("failed to load prompts: %w", err)
 	}
 
@@ -83,6 +106,9 @@ func (p *PromptLoader) LoadAll() error {
 		return fmt.Errorf("prompt validation failed: %w", err)
 	}
 
+	// Prompts are written with the default signal markers
+	p.rewriteSignals()
+
 	// Create the assembler
 	p.assembler = promptloom.NewAssembler(p.registry)
 
@@ -93,6 +119,13 @@ func (p *PromptLoader) LoadAll() error {
 		// Don't fail on language loading - it's optional
 	}
 
+	// Load task type kickoffs
+	p.kickoffs = make(map[string]string)
+	if err := p.loadKickoffs(); err != nil {
+		fmt.Printf("PromptLoader.LoadAll: warning: failed to load kickoffs: %v\n", err)
+		// Don't fail on kickoff loading - it's optional
+	}
+
 	// Verify all required hats have profiles
 	profiles := p.registry.ListProfiles()
 	fmt.Printf("PromptLoader.LoadAll: loaded %d profiles\n", len(profiles))
@@ -110,15 +143,40 @@ func (p *PromptLoader) LoadAll() error {
 		}
 	}
 
-	fmt.Printf("PromptLoader.LoadAll: all required hats validated\n")
+	fmt.Printf("PromptLoader.LoadAll: all required hats validated (prompt set %s)\n", p.set.Version)
 	return nil
 }
 
+// PromptSet returns the loaded prompt files, or nil if LoadAll hasn't succeeded
+func (p *PromptLoader) PromptSet() *prompts.Set {
+	return p.set
+}
+
+// SetSignals sets the signal markers prompts refer to and reloads them
+func (p *PromptLoader) SetSignals(signals SignalConfig) error {
+	p.signals = signals
+	return p.Reload()
+}
+
+// rewriteSignals replaces the default signal markers in every loaded component
+func (p *PromptLoader) rewriteSignals() {
+	if p.signals.IsDefault() {
+		return
+	}
+	for _, name := range p.registry.ListComponents() {
+		component := p.registry.GetComponent(name)
+		component.Instructions = p.signals.RewritePrompt(component.Instructions)
+		for i := range component.OrderedSections {
+			component.OrderedSections[i].Content = p.signals.RewritePrompt(component.OrderedSections[i].Content)
+		}
+	}
+}
+
 // loadLanguageGuidelines loads language-specific guidelines from the languages directory
 func (p *PromptLoader) loadLanguageGuidelines() error {
-	languagesDir := filepath.Join(p.promptsDir, "languages")
+	languagesDir := "languages"
 
-	entries, err := os.ReadDir(languagesDir)
+	entries, err := fs.ReadDir(p.fsys, languagesDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // No languages directory is OK
@@ -131,8 +189,8 @@ func (p *PromptLoader) loadLanguageGuidelines() error {
 			continue
 		}
 
-		path := filepath.Join(languagesDir, entry.Name())
-		data, err := os.ReadFile(path)
+		path := languagesDir + "/" + entry.Name()
+		data, err := fs.ReadFile(p.fsys, path)
 		if err != nil {
 			fmt.Printf("PromptLoader: warning: failed to read %s: %v\n", path, err)
 			continue
@@ -153,6 +211,55 @@ func (p *PromptLoader) loadLanguageGuidelines() error {
 	return nil
 }
 
+// loadKickoffs loads task type kickoff guidance from the kickoffs directory
+func (p *PromptLoader) loadKickoffs() error {
+	kickoffsDir := "kickoffs"
+
+	entries, err := fs.ReadDir(p.fsys, kickoffsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // No kickoffs directory is OK
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+
+		path := kickoffsDir + "/" + entry.Name()
+		data, err := fs.ReadFile(p.fsys, path)
+		if err != nil {
+			fmt.Printf("PromptLoader: warning: failed to read %s: %v\n", path, err)
+			continue
+		}
+
+		var kf kickoffFile
+		if err := yaml.Unmarshal(data, &kf); err != nil {
+			fmt.Printf("PromptLoader: warning: failed to parse %s: %v\n", path, err)
+			continue
+		}
+
+		if kf.Type != "" && kf.Instructions != "" {
+			p.kickoffs[kf.Type] = strings.TrimSpace(kf.Instructions)
+		}
+	}
+
+	fmt.Printf("PromptLoader: loaded kickoffs for %d task types\n", len(p.kickoffs))
+	return nil
+}
+
+// GetKickoff returns the initial conversation guidance for a task type,
+// or "" if there is none
+func (p *PromptLoader) GetKickoff(taskType string) string {
+	kickoff, ok := p.kickoffs[taskType]
+	if !ok {
+		return ""
+	}
+	return p.signals.RewritePrompt(kickoff)
+}
+
 // projectTypeToLanguage maps ProjectType to language guideline names
 func projectTypeToLanguage(pt tools.ProjectType) string {
 	switch pt {
@@ -244,6 +351,12 @@ func (p *PromptLoader) Get(hatName string, ctx *PromptContext) (string, error) {
 			loomCtx.SetFlag("has_predecessor_context", true)
 		}
 
+		// Add task attachments
+		if ctx.Attachments != "" {
+			loomCtx.SetValue("attachments", ctx.Attachments)
+			loomCtx.SetFlag("has_attachments", true)
+		}
+
 		// Add toolbelt services
 		if len(ctx.Toolbelt) > 0 {
 			var services []string
@@ -318,6 +431,7 @@ func (p *PromptLoader) Reload() error {
 	p.registry = promptloom.NewRegistry()
 	p.assembler = nil
 	p.languageGuidelines = nil
+	p.kickoffs = nil
 	return p.LoadAll()
 }
 
@@ -0,0 +1,30 @@
+package session
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestPromptLoader_GetKickoff(t *testing.T) {
+	loader := NewPromptLoader(t.TempDir() + "/missing")
+	if err := loader.LoadAll(); err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+
+	for _, taskType := range []string{db.TaskTypeBug, db.TaskTypeFeature, db.TaskTypeChore, db.TaskTypeEpic} {
+		if loader.GetKickoff(taskType) == "" {
+			t.Errorf("expected a kickoff for %s tasks", taskType)
+		}
+	}
+
+	if !strings.Contains(loader.GetKickoff(db.TaskTypeBug), "failing test") {
+		t.Error("expected bug kickoff to ask for a failing test")
+	}
+
+	// Generic tasks use the default initial message
+	if kickoff := loader.GetKickoff(db.TaskTypeTask); kickoff != "" {
+		t.Errorf("expected no kickoff for generic tasks, got %q", kickoff)
+	}
+}
@@ -15,6 +15,7 @@ type QualityGate struct {
 	workDir    string
 	projectCfg *tools.ProjectConfig // Cached after first detection
 	activity   *ActivityRecorder
+	testRuns   int // Times the test suite was checked, including finding none to run
 }
 
 // NewQualityGate creates a new QualityGate for the given work directory
@@ -105,6 +106,8 @@ func (g *QualityGate) Validate(ctx context.Context, opts TaskCompleteOpts) *Gate
 
 // runTests runs the project's test suite
 func (g *QualityGate) runTests(ctx context.Context, cfg *tools.ProjectConfig) *CheckResult {
+	g.testRuns++
+
 	cmd, ok := cfg.GetTestCommand()
 	if !ok {
 		return &CheckResult{
@@ -155,7 +158,7 @@ func (g *QualityGate) runCommand(ctx context.Context, command, checkType string,
 	cmd := exec.CommandContext(execCtx, "bash", "-c", command)
 	cmd.Dir = g.workDir
 
-	output, err := cmd.CombinedOutput()
+	output, err := tools.CombinedOutput(ctx, cmd)
 	duration := time.Since(start).Milliseconds()
 
 	result := &CheckResult{
@@ -214,6 +217,7 @@ func truncateForFeedback(s string, maxLen int) string {
 // RunTests runs only the test suite (for standalone use)
 func (g *QualityGate) RunTests(ctx context.Context, verbose bool, timeoutSecs int) *CheckResult {
 	cfg := g.getProjectConfig()
+	g.testRuns++
 
 	cmd, ok := cfg.GetTestCommand()
 	if !ok {
@@ -246,6 +250,13 @@ func (g *QualityGate) RunTests(ctx context.Context, verbose bool, timeoutSecs in
 	return g.runCommand(ctx, cmd, "tests", timeoutSecs)
 }
 
+// TestRuns returns how many times the test suite has been checked, by run_tests
+// or task_complete. A check that found no test command to run counts, since
+// there's nothing more it could have done; one skipped by request doesn't.
+func (g *QualityGate) TestRuns() int {
+	return g.testRuns
+}
+
 // RunLint runs only the linter (for standalone use)
 func (g *QualityGate) RunLint(ctx context.Context, fix bool) *CheckResult {
 	cfg := g.getProjectConfig()
@@ -17,6 +17,7 @@ import (
 	"github.com/lirancohen/dex/internal/git"
 	"github.com/lirancohen/dex/internal/gitprovider"
 	"github.com/lirancohen/dex/internal/hints"
+	"github.com/lirancohen/dex/internal/markdown"
 	"github.com/lirancohen/dex/internal/realtime"
 	"github.com/lirancohen/dex/internal/security"
 	"github.com/lirancohen/dex/internal/toolbelt"
@@ -32,6 +33,10 @@ const (
 	SignalMemory              = "MEMORY:"
 )
 
+// minRecordedQueueWait is the shortest wait for the shared Anthropic request
+// gate worth recording as activity
+const minRecordedQueueWait = 100 * time.Millisecond
+
 // Budget limit errors
 var (
 	ErrBudgetExceeded    = errors.New("budget exceeded")
@@ -40,6 +45,12 @@ var (
 	ErrDollarBudget      = errors.New("dollar budget exceeded")
 	ErrRuntimeLimit      = errors.New("runtime limit exceeded")
 	ErrNoAnthropicClient = errors.New("anthropic client not configured")
+
+	// ErrCompletionApprovalRequired pauses the session until a human approves completion
+	ErrCompletionApprovalRequired = errors.New("task completion requires approval")
+
+	// ErrInjectionApprovalRequired pauses the session until a human reviews a suspected prompt injection
+	ErrInjectionApprovalRequired = errors.New("suspected prompt injection requires review")
 )
 
 // StreamingSignalDetector processes checklist signals in real-time during streaming
@@ -49,6 +60,8 @@ type StreamingSignalDetector struct {
 	onDone        func(itemID string)
 	onFailed      func(itemID, reason string)
 	processedDone map[string]bool
+	signals       SignalConfig
+	code          markdown.CodeMasker // Signals inside code don't count
 }
 
 // NewStreamingSignalDetector creates a new detector with callbacks for signal processing
@@ -57,9 +70,15 @@ func NewStreamingSignalDetector(onDone func(itemID string), onFailed func(itemID
 		onDone:        onDone,
 		onFailed:      onFailed,
 		processedDone: make(map[string]bool),
+		signals:       DefaultSignalConfig(),
 	}
 }
 
+// SetSignals sets the checklist signal prefixes the detector looks for
+func (d *StreamingSignalDetector) SetSignals(signals SignalConfig) {
+	d.signals = signals
+}
+
 // ProcessDelta handles incoming text deltas and detects complete signals
 func (d *StreamingSignalDetector) ProcessDelta(delta string) {
 	d.buffer.WriteString(delta)
@@ -79,10 +98,11 @@ func (d *StreamingSignalDetector) checkForSignals() {
 
 		line := text[:newlineIdx]
 		text = text[newlineIdx+1:]
+		masked := d.code.MaskLine(line)
 
 		// Check for CHECKLIST_DONE signal
-		if idx := strings.Index(line, SignalChecklistDone); idx != -1 {
-			itemID := strings.TrimSpace(line[idx+len(SignalChecklistDone):])
+		if idx := strings.Index(masked, d.signals.ChecklistDone); idx != -1 {
+			itemID := strings.TrimSpace(line[idx+len(d.signals.ChecklistDone):])
 			if itemID != "" && !d.processedDone[itemID] {
 				d.processedDone[itemID] = true
 				if d.onDone != nil {
@@ -92,8 +112,8 @@ func (d *StreamingSignalDetector) checkForSignals() {
 		}
 
 		// Check for CHECKLIST_FAILED signal
-		if idx := strings.Index(line, SignalChecklistFailed); idx != -1 {
-			content := strings.TrimSpace(line[idx+len(SignalChecklistFailed):])
+		if idx := strings.Index(masked, d.signals.ChecklistFailed); idx != -1 {
+			content := strings.TrimSpace(line[idx+len(d.signals.ChecklistFailed):])
 			parts := strings.SplitN(content, ":", 2)
 			itemID := strings.TrimSpace(parts[0])
 			reason := ""
@@ -114,6 +134,13 @@ func (d *StreamingSignalDetector) checkForSignals() {
 	d.buffer.WriteString(text)
 }
 
+// resetBuffer drops partially streamed text, e.g. from a request that timed out.
+// Signals already processed are kept so a retry doesn't apply them twice.
+func (d *StreamingSignalDetector) resetBuffer() {
+	d.buffer.Reset()
+	d.code.Reset()
+}
+
 // ProcessedSignals returns the map of signals that were processed during streaming
 func (d *StreamingSignalDetector) ProcessedSignals() map[string]bool {
 	return d.processedDone
@@ -134,11 +161,42 @@ type RalphLoop struct {
 	checkpointInterval int
 
 	// Activity recorder for visibility
-	activity *ActivityRecorder
+	activity       *ActivityRecorder
+	activityLevel  string // db.ActivityLevel* ("" = standard)
+	broadcastLevel string // db.ActivityLevel* ("" = standard)
 
 	// AI model to use for this loop (sonnet or opus)
 	model string
 
+	// Models tried in order when model is over capacity (nil = no fallback),
+	// the model that served the latest response, and whether any fallback has
+	modelFallback []string
+	servedModel   string
+	fellBack      bool
+
+	// Task's extended thinking budget (nil = each hat's default)
+	thinkingBudget *int
+
+	// Output token limit per response, by hat (missing = DefaultMaxTokens)
+	hatMaxTokens map[string]int
+
+	// The project won't accept a review approval until tests have run this session
+	criticRequiresTests bool
+
+	// How aggressively untrusted content is neutralized ("" = normal)
+	promptSensitivity security.Sensitivity
+
+	// Prompt-injection detection for untrusted tool output, and findings
+	// waiting on a human when the detector pauses for approval
+	injectionDetector *security.InjectionDetector
+	pendingInjections []SecurityEventData
+
+	// Task attachments are in the worktree for the prompt to point at
+	attachmentsWritten bool
+
+	// Most recent tool call, for inspection
+	lastToolCall *InspectedToolCall
+
 	// Tool use support
 	executor *ToolExecutor
 	tools    []toolbelt.AnthropicTool
@@ -156,12 +214,26 @@ type RalphLoop struct {
 	// to avoid double-processing after response completes
 	streamProcessedSignals map[string]bool
 
+	// Per-request deadline and retries for LLM calls
+	requestPolicy requestPolicy
+
+	// Signal markers recognized in responses, and stop sequences for requests
+	// (nil = defaults)
+	signals *SignalConfig
+
 	// Context management
 	contextGuard     *ContextGuard
+	maxMessages      int // Hard cap on message history (0 = no cap)
 	handoffGen       *HandoffGenerator
 	hintsLoader      *hints.Loader
 	lastSystemPrompt string // Cached for token estimation
 
+	// Budget warning thresholds as ascending fractions, the highest each
+	// budget has passed, and its pending budget_increase approval
+	budgetWarnings  []float64
+	budgetWarned    map[string]float64
+	budgetApprovals map[string]string
+
 	// Failure context for checkpoint recovery
 	lastError    string // Last error encountered
 	failedAt     string // Where failure occurred: "tool", "api", "validation"
@@ -170,6 +242,9 @@ type RalphLoop struct {
 	// Issue activity sync (uses gitprovider interface)
 	issueCommenter  *gitprovider.IssueCommenter
 	forgejoProvider gitprovider.Provider
+
+	// PR review feedback to address once the conversation is set up
+	reviewFeedback string
 }
 
 // NewRalphLoop creates a new RalphLoop for the given session
@@ -185,12 +260,17 @@ func NewRalphLoop(manager *Manager, session *ActiveSession, client *toolbelt.Ant
 		tools:                  GetToolDefinitionsForHat(session.Hat),
 		health:                 NewLoopHealth(),
 		streamProcessedSignals: make(map[string]bool),
+		requestPolicy:          defaultRequestPolicy(),
+		maxMessages:            DefaultMaxMessages,
+		hatMaxTokens:           hatMaxTokens,
+		injectionDetector:      security.DefaultInjectionDetector(),
 	}
 }
 
 // InitExecutor initializes the tool executor with project context
 func (r *RalphLoop) InitExecutor(worktreePath string, gitOps *git.Operations, githubClient *toolbelt.GitHubClient, owner, repo string) {
 	r.executor = NewToolExecutor(worktreePath, gitOps, githubClient, owner, repo)
+	r.executor.SetSensitivity(r.promptSensitivity)
 	// Quality gate will be initialized when activity recorder is ready
 	r.qualityGate = NewQualityGate(worktreePath, nil)
 }
@@ -208,6 +288,34 @@ func (r *RalphLoop) SetMailExecutor(me mailToolHandler) {
 	}
 }
 
+// SetSecretScanner sets the scanner that blocks commits containing secrets
+func (r *RalphLoop) SetSecretScanner(scanner *security.SecretScanner) {
+	if r.executor != nil {
+		r.executor.SetSecretScanner(scanner)
+	}
+}
+
+// SetCommitTrailer sets the trailer added to commits made through git_commit
+func (r *RalphLoop) SetCommitTrailer(trailer string) {
+	if r.executor != nil {
+		r.executor.SetCommitTrailer(trailer)
+	}
+}
+
+// SetBaseBranch sets the task's base branch, whose commits git_undo_commit won't reset
+func (r *RalphLoop) SetBaseBranch(branch string) {
+	if r.executor != nil {
+		r.executor.SetBaseBranch(branch)
+	}
+}
+
+// SetToolResultCache sets the cache for read-only tool results
+func (r *RalphLoop) SetToolResultCache(cache *tools.ResultCache) {
+	if r.executor != nil {
+		r.executor.SetResultCache(cache)
+	}
+}
+
 // SetOnRepoCreated sets the callback for when a repo is created
 // This allows updating the project's git info in the database
 func (r *RalphLoop) SetOnRepoCreated(callback func(owner, repo string)) {
@@ -221,6 +329,20 @@ func (r *RalphLoop) SetForgejoProvider(provider gitprovider.Provider) {
 	r.forgejoProvider = provider
 }
 
+// SetInjectionDetector sets the detector that screens untrusted tool output for prompt injection
+func (r *RalphLoop) SetInjectionDetector(detector *security.InjectionDetector) {
+	r.injectionDetector = detector
+}
+
+// SetReviewFeedback queues PR review feedback for the session to address.
+// The hat is applied after any checkpoint restore, which would otherwise
+// switch back to the hat the task finished with.
+func (r *RalphLoop) SetReviewFeedback(hat, feedback string) {
+	r.session.Hat = hat
+	r.tools = GetToolDefinitionsForHat(hat)
+	r.reviewFeedback = feedback
+}
+
 // initIssueCommenter initializes the issue commenter if task has a linked issue
 func (r *RalphLoop) initIssueCommenter(task *db.Task) {
 	if r.forgejoProvider == nil {
@@ -264,6 +386,29 @@ func (r *RalphLoop) postIssueComment(ctx context.Context, comment string) {
 	}
 }
 
+// recordQualityGate records a quality gate run in the session's activity so
+// the task's completion report can show it
+func (r *RalphLoop) recordQualityGate(result *GateResult) {
+	if r.activity == nil {
+		return
+	}
+	data := &QualityGateData{
+		Attempt: r.health.QualityGateAttempts + 1,
+		Passed:  result.Passed,
+		Tests:   newCheckData(result.Tests),
+		Lint:    newCheckData(result.Lint),
+		Build:   newCheckData(result.Build),
+	}
+	for _, check := range []*CheckData{data.Tests, data.Lint, data.Build} {
+		if check != nil {
+			data.DurationMs += check.DurationMs
+		}
+	}
+	if err := r.activity.RecordQualityGate(r.session.IterationCount, data); err != nil {
+		fmt.Printf("RalphLoop: warning - failed to record quality gate: %v\n", err)
+	}
+}
+
 // postQualityGateComment posts a comment about quality gate results to the linked issue
 func (r *RalphLoop) postQualityGateComment(ctx context.Context, result *GateResult) {
 	if r.issueCommenter == nil || result == nil {
@@ -327,18 +472,66 @@ func extractTestFailureDetails(output string) []string {
 	return details
 }
 
+// SetRequestTimeout sets the deadline for each LLM request (0 disables it).
+// Requests that hit the deadline are retried up to DefaultRequestAttempts times.
+func (r *RalphLoop) SetRequestTimeout(d time.Duration) {
+	r.requestPolicy.timeout = d
+}
+
+// SetMaxMessages sets the hard cap on message history (0 or less removes it)
+func (r *RalphLoop) SetMaxMessages(n int) {
+	r.maxMessages = n
+}
+
+// SetActivityLevel sets which activity events the loop records
+func (r *RalphLoop) SetActivityLevel(level string) {
+	r.activityLevel = level
+}
+
+// SetThinkingBudget sets the task's extended thinking budget (nil uses each hat's default)
+func (r *RalphLoop) SetThinkingBudget(budget *int) {
+	r.thinkingBudget = budget
+}
+
+// SetCriticRequiresTests sets whether a review approval is rejected until the
+// session has run the test suite
+func (r *RalphLoop) SetCriticRequiresTests(required bool) {
+	r.criticRequiresTests = required
+}
+
+// SetPromptSensitivity sets how aggressively untrusted content (tool outputs,
+// restored messages, memories) is neutralized before the model sees it
+func (r *RalphLoop) SetPromptSensitivity(level security.Sensitivity) {
+	r.promptSensitivity = level
+	if r.executor != nil {
+		r.executor.SetSensitivity(level)
+	}
+}
+
+// SetActivityBroadcastLevel sets which recorded activity events the loop broadcasts
+func (r *RalphLoop) SetActivityBroadcastLevel(level string) {
+	r.broadcastLevel = level
+}
+
+// SetSignals sets the signal markers to look for and the stop sequences to send
+func (r *RalphLoop) SetSignals(signals SignalConfig) {
+	r.signals = &signals
+}
+
+// signalConfig returns the loop's signals, or the defaults if none were set
+func (r *RalphLoop) signalConfig() SignalConfig {
+	if r.signals == nil {
+		return DefaultSignalConfig()
+	}
+	return *r.signals
+}
+
 // SetModel sets the AI model to use for this loop and captures the rates
 // model should be "sonnet" or "opus"
 func (r *RalphLoop) SetModel(model string) {
 	r.model = model
 	// Capture rates at session start for historical accuracy
-	if model == db.TaskModelOpus {
-		r.session.InputRate = getEnvFloat("DEX_OPUS_INPUT_COST", 5.0)
-		r.session.OutputRate = getEnvFloat("DEX_OPUS_OUTPUT_COST", 25.0)
-	} else {
-		r.session.InputRate = getEnvFloat("DEX_SONNET_INPUT_COST", 3.0)
-		r.session.OutputRate = getEnvFloat("DEX_SONNET_OUTPUT_COST", 15.0)
-	}
+	r.session.InputRate, r.session.OutputRate = ModelRates(model)
 	// Persist rates to database
 	if r.db != nil {
 		_ = r.db.SetSessionRates(r.session.ID, r.session.InputRate, r.session.OutputRate)
@@ -350,9 +543,16 @@ func (r *RalphLoop) initializeServices(ctx context.Context) (*db.Task, error) {
 	// Initialize activity recorder with WebSocket broadcasting
 	r.activity = NewActivityRecorder(r.db, r.session.ID, r.session.TaskID, r.broadcastEvent)
 	r.activity.SetHat(r.session.Hat)
+	if r.activityLevel != "" {
+		r.activity.SetLevel(r.activityLevel)
+	}
+	if r.broadcastLevel != "" {
+		r.activity.SetBroadcastLevel(r.broadcastLevel)
+	}
 
 	// Initialize context guard for token management
 	r.contextGuard = NewContextGuard(r.activity)
+	r.contextGuard.SetMaxMessages(r.maxMessages)
 
 	// Configure LLM-based summarization for context compaction (uses Haiku by default)
 	if r.client != nil && r.manager != nil && r.manager.promptLoader != nil {
@@ -360,7 +560,7 @@ func (r *RalphLoop) initializeServices(ctx context.Context) (*db.Task, error) {
 	}
 
 	// Initialize handoff generator for checkpoint summaries
-	r.handoffGen = NewHandoffGenerator(r.db, r.manager.gitOps)
+	r.handoffGen = r.manager.newHandoffGenerator()
 
 	// Initialize hints loader for project context
 	if r.session.WorktreePath != "" {
@@ -384,6 +584,7 @@ func (r *RalphLoop) initializeServices(ctx context.Context) (*db.Task, error) {
 	// Set up quality gate result callback for issue comments
 	if r.executor != nil {
 		r.executor.SetOnQualityGateResult(func(result *GateResult) {
+			r.recordQualityGate(result)
 			r.postQualityGateComment(ctx, result)
 		})
 	}
@@ -397,7 +598,24 @@ func (r *RalphLoop) initializeServices(ctx context.Context) (*db.Task, error) {
 
 // setupInitialConversation builds the initial message for the conversation
 func (r *RalphLoop) setupInitialConversation() {
+	initialMessage := r.buildInitialMessage()
+
+	r.messages = append(r.messages, toolbelt.AnthropicMessage{
+		Role:    "user",
+		Content: initialMessage,
+	})
+
+	// Record initial user message
+	if err := r.activity.RecordUserMessage(0, initialMessage); err != nil {
+		fmt.Printf("RalphLoop.Run: warning - failed to record initial message: %v\n", err)
+	}
+}
+
+// buildInitialMessage returns the first user message of a session: the task's
+// kickoff, then its checklist or refined prompt
+func (r *RalphLoop) buildInitialMessage() string {
 	initialMessage := "Begin working on the task. Follow your hat instructions and report progress."
+	kickoff := r.taskKickoff()
 
 	// Check for checklist first
 	if checklist, err := r.db.GetChecklistByTaskID(r.session.TaskID); err == nil && checklist != nil {
@@ -412,15 +630,41 @@ func (r *RalphLoop) setupInitialConversation() {
 		}
 	}
 
+	// Lead with guidance for the kind of task, e.g. reproduce bugs before fixing them
+	if kickoff != "" {
+		initialMessage = kickoff + "\n\n---\n\n" + initialMessage
+	}
+	return initialMessage
+}
+
+// addReviewFeedback appends the queued PR review feedback or handoff as a user message
+func (r *RalphLoop) addReviewFeedback() {
 	r.messages = append(r.messages, toolbelt.AnthropicMessage{
 		Role:    "user",
-		Content: initialMessage,
+		Content: r.reviewFeedback,
 	})
+	if err := r.activity.RecordUserMessage(r.session.IterationCount, r.reviewFeedback); err != nil {
+		fmt.Printf("RalphLoop.Run: warning - failed to record review feedback: %v\n", err)
+	}
+	fmt.Printf("RalphLoop.Run: added review feedback (%d chars)\n", len(r.reviewFeedback))
+	r.reviewFeedback = ""
+}
 
-	// Record initial user message
-	if err := r.activity.RecordUserMessage(0, initialMessage); err != nil {
-		fmt.Printf("RalphLoop.Run: warning - failed to record initial message: %v\n", err)
+// taskKickoff returns the kickoff guidance for the session's task type, if any
+func (r *RalphLoop) taskKickoff() string {
+	if r.manager == nil || r.manager.promptLoader == nil {
+		return ""
+	}
+	task, err := r.db.GetTaskByID(r.session.TaskID)
+	if err != nil || task == nil {
+		return ""
 	}
+
+	kickoff := r.manager.promptLoader.GetKickoff(task.Type)
+	if kickoff != "" {
+		fmt.Printf("RalphLoop.Run: using %s kickoff\n", task.Type)
+	}
+	return kickoff
 }
 
 // executeToolCalls processes tool use blocks and returns the results
@@ -453,7 +697,7 @@ func (r *RalphLoop) executeToolCalls(ctx context.Context, toolBlocks []toolbelt.
 		toolStart := time.Now()
 		var result ToolResult
 		if r.executor != nil {
-			result = r.executor.Execute(ctx, block.Name, block.Input)
+			result = r.executor.ExecuteStreaming(ctx, block.Name, block.Input, r.streamToolOutput(block))
 		} else {
 			result = ToolResult{
 				Output:  "Tool executor not initialized",
@@ -461,7 +705,16 @@ func (r *RalphLoop) executeToolCalls(ctx context.Context, toolBlocks []toolbelt.
 			}
 			r.activity.DebugError(r.session.IterationCount, "Tool executor not initialized", nil)
 		}
-		toolDuration := time.Since(toolStart).Milliseconds()
+		toolElapsed := time.Since(toolStart)
+		toolDuration := toolElapsed.Milliseconds()
+
+		// Accumulate per-tool metrics and report slow calls
+		if r.manager != nil {
+			if slow := r.manager.toolMetrics.Record(r.session.ID, block.Name, toolElapsed, result.IsError); slow {
+				fmt.Printf("RalphLoop.Run: slow tool %s took %dms (threshold %s)\n", block.Name, toolDuration, SlowToolThreshold)
+				r.activity.DebugWithDuration(r.session.IterationCount, fmt.Sprintf("Slow tool call: %s", block.Name), toolDuration)
+			}
+		}
 
 		// Record tool result
 		if err := r.activity.RecordToolResult(r.session.IterationCount, block.Name, result); err != nil {
@@ -469,10 +722,17 @@ func (r *RalphLoop) executeToolCalls(ctx context.Context, toolBlocks []toolbelt.
 		}
 
 		// Update health tracking
+		var steering string
 		if result.IsError {
 			r.activity.DebugError(r.session.IterationCount, fmt.Sprintf("Tool %s failed after %dms", block.Name, toolDuration), map[string]any{"output": truncateOutput(result.Output, 500)})
 			r.health.RecordFailure(block.Name)
 
+			// Steer away from an approach that keeps failing the same way
+			if steering = r.health.RecordToolError(block.Name, result.Output); steering != "" {
+				fmt.Printf("RalphLoop.Run: tool %s keeps failing with the same error, steering\n", block.Name)
+				r.activity.Debug(r.session.IterationCount, fmt.Sprintf("Tool %s repeated the same error; steering to a different approach", block.Name))
+			}
+
 			if block.Name == "task_complete" && strings.Contains(result.Output, "QUALITY_BLOCKED") {
 				r.health.RecordQualityBlock()
 			}
@@ -486,11 +746,16 @@ func (r *RalphLoop) executeToolCalls(ctx context.Context, toolBlocks []toolbelt.
 		}
 
 		fmt.Printf("RalphLoop.Run: tool %s result (error=%v): %s\n", block.Name, result.IsError, truncateOutput(result.Output, 200))
+		r.recordLastToolCall(block, result, toolElapsed)
 
+		content := r.screenToolOutput(block.Name, result.Output)
+		if steering != "" {
+			content += "\n\n---\n\n" + steering
+		}
 		results = append(results, toolbelt.ContentBlock{
 			Type:      "tool_result",
 			ToolUseID: block.ID,
-			Content:   result.Output,
+			Content:   content,
 			IsError:   result.IsError,
 		})
 	}
@@ -498,13 +763,23 @@ func (r *RalphLoop) executeToolCalls(ctx context.Context, toolBlocks []toolbelt.
 	return results
 }
 
+// neutralizeToolOutput marks output from tools that read outside content as
+// untrusted in strict mode, so instructions injected into files, command
+// output, or web pages are treated as data
+func (r *RalphLoop) neutralizeToolOutput(toolName, output string) string {
+	if r.promptSensitivity != security.SensitivityStrict || !tools.ReturnsUntrustedContent(toolName) {
+		return output
+	}
+	return security.SanitizeUntrusted(output, security.SensitivityStrict, "tool:"+toolName)
+}
+
 // handleNonToolResponse processes signals in a text response (no tool use)
 func (r *RalphLoop) handleNonToolResponse(responseText string) {
 	// Process checklist signals
 	r.processChecklistSignals(responseText)
 
 	// Process scratchpad signal
-	if scratchpad, found := parseScratchpadSignal(responseText); found {
+	if scratchpad, found := parseScratchpadSignal(responseText, r.signalConfig()); found {
 		r.session.Scratchpad = security.SanitizeForPrompt(scratchpad)
 		r.activity.Debug(r.session.IterationCount, fmt.Sprintf("Updated scratchpad (%d chars)", len(r.session.Scratchpad)))
 	}
@@ -513,39 +788,47 @@ func (r *RalphLoop) handleNonToolResponse(responseText string) {
 	r.processMemorySignals(responseText)
 }
 
-// handleCompletionSignal processes task completion and returns (shouldEnd, continueLoop)
-func (r *RalphLoop) handleCompletionSignal(ctx context.Context, responseText string) (shouldEnd bool, continueLoop bool) {
+// handleCompletionSignal processes task completion and returns (shouldEnd, continueLoop).
+// Returns ErrCompletionApprovalRequired when the completion policy requires a human to approve.
+func (r *RalphLoop) handleCompletionSignal(ctx context.Context, responseText string) (shouldEnd bool, continueLoop bool, err error) {
 	// Verify checklist completion
-	allComplete, issues := r.verifyChecklist()
+	allComplete, issues, total := r.verifyChecklist()
+	policy := r.completionPolicy()
+	hasAcknowledgment := strings.Contains(markdown.MaskCode(responseText), r.signalConfig().AcknowledgeFailures)
 
-	// If there are issues, check if they're acknowledged
-	if !allComplete {
-		hasAcknowledgment := strings.Contains(responseText, SignalAcknowledgeFailures)
+	switch evaluateCompletion(policy, total-len(issues), total, hasAcknowledgment) {
+	case completionNeedsApproval:
+		if err := r.requestCompletionApproval(issues, total); err != nil {
+			fmt.Printf("RalphLoop.Run: warning - failed to request completion approval: %v\n", err)
+		}
+		return true, false, ErrCompletionApprovalRequired
 
-		if !hasAcknowledgment {
-			// Send back for resolution - require explicit acknowledgment
-			issuesList := r.formatChecklistIssues(issues)
-			r.messages = append(r.messages, toolbelt.AnthropicMessage{
-				Role: "user",
-				Content: fmt.Sprintf(`Some checklist items are not complete:
+	case completionNeedsResolution:
+		// Send back for resolution - require explicit acknowledgment
+		issuesList := r.formatChecklistIssues(issues)
+		r.messages = append(r.messages, toolbelt.AnthropicMessage{
+			Role: "user",
+			Content: fmt.Sprintf(r.signalConfig().RewritePrompt(`Some checklist items are not complete:
 %s
 
 Please either:
 1. Complete the remaining items and signal EVENT:task.complete again
 2. Mark items as failed with CHECKLIST_FAILED:<id>:<reason>
 3. If failures are known and accepted, output ACKNOWLEDGE_FAILURES along with EVENT:task.complete
-4. If blocked, use EVENT:task.blocked:{"reason":"description"}`, issuesList),
-			})
-			fmt.Printf("RalphLoop.Run: task completion blocked - %d unacknowledged checklist issues\n", len(issues))
-			return false, true // Continue loop
-		}
+4. If blocked, use EVENT:task.blocked:{"category":"...","reason":"description"}`), issuesList),
+		})
+		fmt.Printf("RalphLoop.Run: task completion blocked - %d unacknowledged checklist issues (%s)\n", len(issues), policy.Strictness)
+		return false, true, nil // Continue loop
 	}
 
 	// Determine outcome
 	outcome := "completed"
 	if !allComplete {
 		outcome = "completed_with_acknowledged_issues"
-		fmt.Printf("RalphLoop.Run: task completed with %d acknowledged checklist issues\n", len(issues))
+		if !hasAcknowledgment {
+			outcome = "completed_with_pending_items"
+		}
+		fmt.Printf("RalphLoop.Run: task completed with %d checklist issues (%s)\n", len(issues), outcome)
 	}
 
 	// Record completion signal
@@ -567,9 +850,10 @@ Please either:
 		"iterations":   r.session.IterationCount,
 		"has_issues":   !allComplete,
 		"issues_count": len(issues),
+		"strictness":   policy.Strictness,
 	})
 
-	return true, false // End session
+	return true, false, nil // End session
 }
 
 // handleEventTransition processes event-based hat transitions
@@ -656,18 +940,24 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 	// Initialize all services
 	task, _ := r.initializeServices(ctx)
 
-	// Save checkpoint when function exits (success or failure) to preserve state for resume
+	// Save checkpoint when function exits (success or failure) to preserve state for resume.
+	// A checkpoint forced since the last iteration gets this one.
 	defer func() {
+		var checkpoint *db.SessionCheckpoint
+		var err error
 		if len(r.messages) > 0 && r.session.IterationCount > 0 {
-			if err := r.checkpoint(); err != nil {
+			checkpoint, err = r.saveCheckpoint()
+			if err != nil {
 				fmt.Printf("RalphLoop.Run: warning - final checkpoint failed: %v\n", err)
 			} else {
 				fmt.Printf("RalphLoop.Run: saved final checkpoint at iteration %d with %d messages\n", r.session.IterationCount, len(r.messages))
 			}
 		}
+		r.closeCheckpointRequests(checkpoint, err)
 	}()
 
 	// Build initial system prompt from hat template
+	r.prepareAttachments()
 	fmt.Printf("RalphLoop.Run: building prompt for hat %s\n", r.session.Hat)
 	systemPrompt, err := r.buildPrompt()
 	if err != nil {
@@ -704,6 +994,12 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 		fmt.Printf("RalphLoop.Run: restored from checkpoint with %d messages, skipping initial prompt\n", len(r.messages))
 	}
 
+	if r.reviewFeedback != "" {
+		r.addReviewFeedback()
+	}
+
+	r.markBudgetWarnings()
+
 	// Main Ralph loop
 	for {
 		// 1. Check for cancellation
@@ -713,7 +1009,11 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 		default:
 		}
 
-		// 2. Check budget limits
+		// 1.5. Checkpoint now if an operator asked for one
+		r.serveCheckpointRequests()
+
+		// 2. Check budget limits, after any increase approved since the last iteration
+		r.applyBudgetIncreases()
 		if err := r.checkBudget(); err != nil {
 			r.broadcastEvent(realtime.EventApprovalRequired, map[string]any{
 				"session_id": r.session.ID,
@@ -767,6 +1067,7 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 		r.activity.Debug(r.session.IterationCount+1, fmt.Sprintf("Sending API request (iteration %d, %d messages)", r.session.IterationCount+1, len(r.messages)))
 
 		r.lastSystemPrompt = systemPrompt // Cache for token estimation
+		r.publishInspection(systemPrompt)
 		apiStart := time.Now()
 		response, err := r.sendMessage(ctx, systemPrompt)
 		apiDuration := time.Since(apiStart).Milliseconds()
@@ -777,14 +1078,24 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 			return fmt.Errorf("claude API error: %w", err)
 		}
 
+		if response.QueueWait >= minRecordedQueueWait {
+			if err := r.activity.RecordRequestQueued(r.session.IterationCount+1, &RequestQueuedData{
+				WaitMs: response.QueueWait.Milliseconds(),
+			}); err != nil {
+				fmt.Printf("RalphLoop.Run: warning - failed to record request queue wait: %v\n", err)
+			}
+		}
 		r.activity.DebugWithDuration(r.session.IterationCount+1, fmt.Sprintf("API response received (in:%d out:%d tokens, stop:%s)", response.Usage.InputTokens, response.Usage.OutputTokens, response.StopReason), apiDuration)
 		fmt.Printf("RalphLoop.Run: received response (input tokens: %d, output tokens: %d)\n", response.Usage.InputTokens, response.Usage.OutputTokens)
 
 		// 4. Update usage tracking
+		r.blendRates(r.servedModel, response.Usage.InputTokens, response.Usage.OutputTokens)
 		r.session.InputTokens += int64(response.Usage.InputTokens)
 		r.session.OutputTokens += int64(response.Usage.OutputTokens)
+		r.session.ThinkingTokens += int64(response.Usage.ThinkingTokens)
 		r.session.IterationCount++
 		r.session.LastActivity = time.Now()
+		r.checkBudgetWarnings()
 
 		// Broadcast iteration event with context status
 		iterationPayload := map[string]any{
@@ -816,6 +1127,7 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 				response.Text(),
 				response.Usage.InputTokens,
 				response.Usage.OutputTokens,
+				response.Usage.ThinkingTokens,
 			); err != nil {
 				fmt.Printf("RalphLoop.Run: warning - failed to record assistant response: %v\n", err)
 			}
@@ -833,6 +1145,15 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 				Role:    "user",
 				Content: results,
 			})
+			r.publishInspection(systemPrompt)
+
+			// A suspected injection waits for a human before the model sees the results
+			if len(r.pendingInjections) > 0 {
+				if err := r.requestInjectionApproval(); err != nil {
+					fmt.Printf("RalphLoop.Run: warning - failed to request injection review: %v\n", err)
+				}
+				return ErrInjectionApprovalRequired
+			}
 
 			r.activity.Debug(r.session.IterationCount, "All tools complete, continuing to next iteration")
 			continue
@@ -861,6 +1182,7 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 			responseText,
 			response.Usage.InputTokens,
 			response.Usage.OutputTokens,
+			response.Usage.ThinkingTokens,
 		); err != nil {
 			fmt.Printf("RalphLoop.Run: warning - failed to record assistant response: %v\n", err)
 		}
@@ -870,7 +1192,10 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 
 		// 8. Check for task completion
 		if r.detectCompletion(responseText) {
-			shouldEnd, continueLoop := r.handleCompletionSignal(ctx, responseText)
+			shouldEnd, continueLoop, err := r.handleCompletionSignal(ctx, responseText)
+			if err != nil {
+				return err
+			}
 			if continueLoop {
 				continue
 			}
@@ -881,6 +1206,10 @@ func (r *RalphLoop) Run(ctx context.Context) error {
 
 		// 9. Check for event-based transition
 		if event := r.detectEvent(responseText); event != nil {
+			if r.approvalMissingTestRun(event) {
+				r.rejectUntestedApproval()
+				continue
+			}
 			if r.handleEventTransition(ctx, event) {
 				return nil
 			}
@@ -979,6 +1308,8 @@ func (r *RalphLoop) buildMemorySection(projectID string) string {
 	sb.WriteString("## Project Knowledge\n\n")
 	sb.WriteString("Learnings from previous work on this project:\n\n")
 
+	var body strings.Builder
+
 	// Group by type for readability
 	byType := make(map[db.MemoryType][]db.Memory)
 	for _, m := range memories {
@@ -998,14 +1329,25 @@ func (r *RalphLoop) buildMemorySection(projectID string) string {
 			continue
 		}
 
-		sb.WriteString(fmt.Sprintf("### %s\n", memType.Title()))
+		body.WriteString(fmt.Sprintf("### %s\n", memType.Title()))
 		for _, m := range mems {
 			// Sanitize memory content before injection (defense in depth)
-			safeTitle := security.SanitizeForPrompt(m.Title)
-			safeContent := security.SanitizeForPrompt(m.Content)
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", safeTitle, safeContent))
+			safeTitle, safeContent := m.Title, m.Content
+			if r.promptSensitivity != security.SensitivityOff {
+				safeTitle = security.SanitizeForPrompt(safeTitle)
+				safeContent = security.SanitizeForPrompt(safeContent)
+			}
+			body.WriteString(fmt.Sprintf("- **%s**: %s\n", safeTitle, safeContent))
 		}
-		sb.WriteString("\n")
+		body.WriteString("\n")
+	}
+
+	// Memories can be distilled from untrusted content, so strict mode fences them off
+	if r.promptSensitivity == security.SensitivityStrict {
+		sb.WriteString(security.WrapUntrusted(body.String(), "memories"))
+		sb.WriteString("\n\n")
+	} else {
+		sb.WriteString(body.String())
 	}
 
 	return sb.String()
@@ -1158,27 +1500,53 @@ func (r *RalphLoop) buildPrompt() (string, error) {
 		ProjectHints:       projectHints,
 		ProjectMemories:    projectMemories,
 		PredecessorContext: r.session.PredecessorContext,
+		Attachments:        r.attachmentSection(),
 		Language:           detectedLanguage,
 	}
 
-	return r.manager.promptLoader.Get(r.session.Hat, ctx)
+	prompt, err := r.manager.promptLoader.Get(r.session.Hat, ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Organization-wide guidance goes ahead of every hat's prompt
+	var projectPreamble string
+	if project != nil {
+		if projectPreamble, err = r.db.GetProjectPromptPreamble(project.ID); err != nil {
+			fmt.Printf("RalphLoop.buildPrompt: warning - failed to get project preamble: %v\n", err)
+		}
+	}
+	prompt = withPreambles(prompt, r.manager.Preamble(), projectPreamble)
+
+	// Tell the model what the untrusted-content delimiters around tool output mean
+	if r.promptSensitivity == security.SensitivityStrict {
+		prompt += "\n\n" + security.UntrustedContentNotice
+	}
+	return prompt, nil
 }
 
 // sendMessage sends the current conversation to Claude using streaming
 // to enable real-time checklist signal detection and broadcasting
 func (r *RalphLoop) sendMessage(ctx context.Context, systemPrompt string) (*toolbelt.AnthropicChatResponse, error) {
 	// Determine model based on task settings
-	model := "claude-sonnet-4-5-20250929" // default
+	model := db.TaskModelSonnet // default
 	if r.model == db.TaskModelOpus {
-		model = "claude-opus-4-5-20251101"
+		model = db.TaskModelOpus
 	}
 
 	req := &toolbelt.AnthropicChatRequest{
-		Model:     model,
-		MaxTokens: 8192,
+		Model:     apiModel(model),
+		MaxTokens: MaxTokens(r.session.Hat, r.hatMaxTokens),
 		System:    systemPrompt,
 		Messages:  r.messages,
 		Tools:     r.tools,
+
+		StopSequences: r.signalConfig().StopSequences,
+	}
+	// Thinking counts against max_tokens, so raise it to keep the same room for the reply
+	if budget := ThinkingBudget(r.session.Hat, r.thinkingBudget); budget > 0 {
+		req.Thinking = toolbelt.NewAnthropicThinking(budget)
+		req.MaxTokens += budget
 	}
 
 	// Reset the processed signals map for this request
@@ -1217,22 +1585,55 @@ func (r *RalphLoop) sendMessage(ctx context.Context, systemPrompt string) (*tool
 			}
 		},
 	)
-
-	// Use streaming API with the detector's ProcessDelta as callback
-	response, err := r.client.ChatWithStreaming(ctx, req, detector.ProcessDelta)
+	detector.SetSignals(r.signalConfig())
+
+	// Queue behind other sessions' requests according to this session's priority
+	if r.manager != nil {
+		ctx = toolbelt.WithRequestPriority(ctx, r.manager.requestPriority(r.session))
+	}
+
+	// Use streaming API with the detector's ProcessDelta as callback, under a
+	// per-request deadline so a hung stream is retried instead of wedging the loop
+	send := func() (*toolbelt.AnthropicChatResponse, error) {
+		req.Messages = r.messages
+		return r.requestPolicy.do(ctx, func(reqCtx context.Context) (*toolbelt.AnthropicChatResponse, error) {
+			detector.resetBuffer()
+			return r.client.ChatWithStreaming(reqCtx, req, detector.ProcessDelta)
+		}, func(attempt int, err error) {
+			fmt.Printf("RalphLoop: %v (attempt %d/%d), retrying\n", err, attempt, r.requestPolicy.attempts)
+			if r.activity != nil {
+				r.activity.DebugError(r.session.IterationCount+1, fmt.Sprintf("API request timed out (attempt %d), retrying", attempt), map[string]any{"error": err.Error()})
+			}
+		})
+	}
+	response, err := send()
+	// An overloaded model hands the request to the task's fallback models, in order
+	for _, fallback := range r.fallbackModels(model) {
+		if !isCapacityError(err) {
+			break
+		}
+		r.recordModelFallback(model, fallback, err)
+		model = fallback
+		req.Model = apiModel(model)
+		response, err = send()
+	}
+	if isContextLengthError(err) {
+		response, err = r.recoverFromContextLength(err, send)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Store which signals were already processed during streaming
 	r.streamProcessedSignals = detector.ProcessedSignals()
+	r.servedModel = model
 
 	return response, nil
 }
 
 // detectCompletion checks if the response indicates task completion via EVENT:task.complete
 func (r *RalphLoop) detectCompletion(response string) bool {
-	event, found := ParseEvent(response, r.session.ID, r.session.Hat)
+	event, found := parseEventWithPrefix(response, r.signalConfig().Event, r.session.ID, r.session.Hat)
 	if !found {
 		return false
 	}
@@ -1242,7 +1643,7 @@ func (r *RalphLoop) detectCompletion(response string) bool {
 // detectEvent parses the response for an EVENT:topic signal
 // Returns the parsed Event or nil if no event found
 func (r *RalphLoop) detectEvent(response string) *Event {
-	event, found := ParseEvent(response, r.session.ID, r.session.Hat)
+	event, found := parseEventWithPrefix(response, r.signalConfig().Event, r.session.ID, r.session.Hat)
 	if !found {
 		return nil
 	}
@@ -1251,6 +1652,12 @@ func (r *RalphLoop) detectEvent(response string) *Event {
 
 // checkpoint saves the current session state to the database
 func (r *RalphLoop) checkpoint() error {
+	_, err := r.saveCheckpoint()
+	return err
+}
+
+// saveCheckpoint saves the current session state to the database and returns the checkpoint
+func (r *RalphLoop) saveCheckpoint() (*db.SessionCheckpoint, error) {
 	// Build checkpoint state
 	state := map[string]any{
 		"iteration":     r.session.IterationCount,
@@ -1270,20 +1677,19 @@ func (r *RalphLoop) checkpoint() error {
 
 	// Generate handoff summary for easier review and resume
 	if r.handoffGen != nil {
-		handoff := r.handoffGen.Generate(r.session, r.session.Scratchpad, r.session.WorktreePath)
+		handoff := r.handoffGen.Generate(r.session, r.session.Scratchpad, r.session.WorktreePath, "")
 		state["handoff"] = handoff.FormatForAPI()
 	}
 
 	stateJSON, err := json.Marshal(state)
 	if err != nil {
-		return fmt.Errorf("failed to marshal checkpoint state: %w", err)
+		return nil, fmt.Errorf("failed to marshal checkpoint state: %w", err)
 	}
 
 	// Token usage is tracked via session_activity (single source of truth)
 	// No need to update sessions table - tokens are computed from activity on read
 
-	_, err = r.db.CreateSessionCheckpoint(r.session.ID, r.session.IterationCount, stateJSON)
-	return err
+	return r.db.CreateSessionCheckpoint(r.session.ID, r.session.IterationCount, stateJSON)
 }
 
 // SetFailureContext sets failure information for checkpoint recovery
@@ -1302,6 +1708,24 @@ func (r *RalphLoop) ClearFailureContext() {
 	r.recoveryHint = ""
 }
 
+// streamToolOutput returns a function broadcasting output of the commands a
+// tool call runs while it runs. Only the final result goes to the model.
+func (r *RalphLoop) streamToolOutput(block toolbelt.AnthropicContentBlock) tools.OutputFunc {
+	if r.broadcaster == nil {
+		return nil
+	}
+	iteration := r.session.IterationCount
+	return func(chunk string) {
+		r.broadcastEvent(realtime.EventSessionToolOutput, map[string]any{
+			"session_id":  r.session.ID,
+			"iteration":   iteration,
+			"tool_use_id": block.ID,
+			"tool_name":   block.Name,
+			"output":      chunk,
+		})
+	}
+}
+
 // broadcastEvent sends an event through the realtime broadcaster
 func (r *RalphLoop) broadcastEvent(eventType string, payload map[string]any) {
 	if r.broadcaster == nil {
@@ -1314,6 +1738,20 @@ func (r *RalphLoop) broadcastEvent(eventType string, payload map[string]any) {
 	r.broadcaster.Publish(eventType, payload)
 }
 
+// ModelRates returns the dollars per million input and output tokens for
+// model ("sonnet", "opus", or "haiku"; anything else is priced as sonnet),
+// overridable with DEX_<MODEL>_INPUT_COST and DEX_<MODEL>_OUTPUT_COST
+func ModelRates(model string) (input, output float64) {
+	switch model {
+	case db.TaskModelOpus:
+		return getEnvFloat("DEX_OPUS_INPUT_COST", 5.0), getEnvFloat("DEX_OPUS_OUTPUT_COST", 25.0)
+	case "haiku":
+		return getEnvFloat("DEX_HAIKU_INPUT_COST", 1.0), getEnvFloat("DEX_HAIKU_OUTPUT_COST", 5.0)
+	default:
+		return getEnvFloat("DEX_SONNET_INPUT_COST", 3.0), getEnvFloat("DEX_SONNET_OUTPUT_COST", 15.0)
+	}
+}
+
 // getEnvFloat reads a float64 from an environment variable, returning defaultVal if not set or invalid
 // Used for model pricing rates (DEX_SONNET_INPUT_COST, DEX_OPUS_OUTPUT_COST, etc.)
 func getEnvFloat(key string, defaultVal float64) float64 {
@@ -1328,24 +1766,27 @@ func getEnvFloat(key string, defaultVal float64) float64 {
 	return f
 }
 
+// checkpointState is the JSON state saved by checkpoint
+type checkpointState struct {
+	Iteration    int   `json:"iteration"`
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+	// Legacy fields for backwards compatibility
+	TokensUsed  int64                       `json:"tokens_used"`
+	DollarsUsed float64                     `json:"dollars_used"`
+	Hat         string                      `json:"hat"`
+	Messages    []toolbelt.AnthropicMessage `json:"messages"`
+	Scratchpad  string                      `json:"scratchpad,omitempty"`
+	Handoff     map[string]any              `json:"handoff,omitempty"`
+	// Failure context for recovery
+	LastError    string `json:"last_error,omitempty"`
+	FailedAt     string `json:"failed_at,omitempty"`
+	RecoveryHint string `json:"recovery_hint,omitempty"`
+}
+
 // RestoreFromCheckpoint restores session state from a checkpoint
 func (r *RalphLoop) RestoreFromCheckpoint(checkpoint *db.SessionCheckpoint) error {
-	var state struct {
-		Iteration    int   `json:"iteration"`
-		InputTokens  int64 `json:"input_tokens"`
-		OutputTokens int64 `json:"output_tokens"`
-		// Legacy fields for backwards compatibility
-		TokensUsed  int64                       `json:"tokens_used"`
-		DollarsUsed float64                     `json:"dollars_used"`
-		Hat         string                      `json:"hat"`
-		Messages    []toolbelt.AnthropicMessage `json:"messages"`
-		Scratchpad  string                      `json:"scratchpad,omitempty"`
-		Handoff     map[string]any              `json:"handoff,omitempty"`
-		// Failure context for recovery
-		LastError    string `json:"last_error,omitempty"`
-		FailedAt     string `json:"failed_at,omitempty"`
-		RecoveryHint string `json:"recovery_hint,omitempty"`
-	}
+	var state checkpointState
 
 	if err := json.Unmarshal(checkpoint.State, &state); err != nil {
 		return fmt.Errorf("failed to unmarshal checkpoint state: %w", err)
@@ -1364,8 +1805,10 @@ func (r *RalphLoop) RestoreFromCheckpoint(checkpoint *db.SessionCheckpoint) erro
 	r.session.Scratchpad = security.SanitizeForPrompt(state.Scratchpad)
 
 	// Sanitize restored messages to prevent prompt injection via stored content
-	for i := range state.Messages {
-		state.Messages[i].Content = sanitizeMessageContent(state.Messages[i].Content)
+	if r.promptSensitivity != security.SensitivityOff {
+		for i := range state.Messages {
+			state.Messages[i].Content = sanitizeMessageContent(state.Messages[i].Content)
+		}
 	}
 	r.messages = state.Messages
 
@@ -1401,7 +1844,7 @@ func (r *RalphLoop) RestoreFromCheckpoint(checkpoint *db.SessionCheckpoint) erro
 		if state.RecoveryHint != "" {
 			recoveryMsg.WriteString(fmt.Sprintf("Hint: %s\n", state.RecoveryHint))
 		}
-		recoveryMsg.WriteString("\nPlease try a different approach. If blocked, use EVENT:task.blocked:{\"reason\":\"description\"}.\n")
+		recoveryMsg.WriteString(r.signalConfig().RewritePrompt("\nPlease try a different approach. If blocked, use EVENT:task.blocked:{\"category\":\"...\",\"reason\":\"description\"}.\n"))
 	}
 
 	// Add recovery message if we have any content, but avoid duplicates
@@ -1522,6 +1965,7 @@ func (r *RalphLoop) buildChecklistPrompt(items []*db.ChecklistItem) string {
 	}
 
 	sb.WriteString("\n---\n\n")
+	itemsEnd := sb.Len()
 
 	// Hat-specific instructions
 	switch r.session.Hat {
@@ -1549,22 +1993,26 @@ func (r *RalphLoop) buildChecklistPrompt(items []*db.ChecklistItem) string {
 			sb.WriteString("### What to Review\n")
 			sb.WriteString("1. **Verify completed items** - Spot-check that items marked done actually work\n")
 			sb.WriteString("2. **Check for issues** - Look for bugs, security issues, or missing functionality\n")
-			sb.WriteString("3. **Run tests if applicable** - Verify tests pass\n\n")
+			if r.criticRequiresTests {
+				sb.WriteString("3. **Run tests** - Required: approval is rejected until run_tests or task_complete has run the test suite\n\n")
+			} else {
+				sb.WriteString("3. **Run tests if applicable** - Verify tests pass\n\n")
+			}
 			sb.WriteString("### What NOT to Do\n")
 			sb.WriteString("- Do NOT recreate or redo work that's already complete\n")
 			sb.WriteString("- Do NOT mark items as done again (they're already done)\n")
 			sb.WriteString("- Do NOT do extensive verification for simple tasks\n\n")
 			sb.WriteString("### Decision\n")
-			sb.WriteString("- **If work looks good**: `EVENT:review.approved` (moves to editor for PR)\n")
-			sb.WriteString("- **If critical issues found**: `EVENT:review.rejected` with specific feedback\n")
-			sb.WriteString("- **If blocked**: `EVENT:task.blocked:{\"reason\":\"...\"}` \n\n")
+			sb.WriteString("- **If work looks good**: EVENT:review.approved (moves to editor for PR)\n")
+			sb.WriteString("- **If critical issues found**: EVENT:review.rejected with specific feedback\n")
+			sb.WriteString("- **If blocked**: EVENT:task.blocked:{\"category\":\"...\",\"reason\":\"...\"} \n\n")
 			sb.WriteString("For simple tasks (content creation, config, etc.), a quick verification is sufficient. Approve and move on.")
 		} else {
 			sb.WriteString("## Review Instructions\n\n")
 			sb.WriteString("Some items are still pending. Review the completed work and assess:\n")
 			sb.WriteString("- Are the completed items actually done correctly?\n")
 			sb.WriteString("- Should the pending items be completed or are they optional?\n\n")
-			sb.WriteString("Then decide: `EVENT:review.approved` or `EVENT:review.rejected`")
+			sb.WriteString("Then decide: EVENT:review.approved or EVENT:review.rejected")
 		}
 
 	case "editor":
@@ -1577,7 +2025,7 @@ func (r *RalphLoop) buildChecklistPrompt(items []*db.ChecklistItem) string {
 		sb.WriteString("1. **Verify commits** - Ensure all changes are committed\n")
 		sb.WriteString("2. **Push to remote** - Push the branch if not already pushed\n")
 		sb.WriteString("3. **Create PR** - Open a pull request with a clear description\n")
-		sb.WriteString("4. **Complete** - Output `EVENT:task.complete` with the PR URL\n\n")
+		sb.WriteString("4. **Complete** - Output EVENT:task.complete with the PR URL\n\n")
 		sb.WriteString("### What NOT to Do\n")
 		sb.WriteString("- Do NOT recreate or redo work that's already complete\n")
 		sb.WriteString("- Do NOT implement checklist items (they're done)\n")
@@ -1591,7 +2039,9 @@ func (r *RalphLoop) buildChecklistPrompt(items []*db.ChecklistItem) string {
 		sb.WriteString("When all items are addressed, output the appropriate EVENT signal.")
 	}
 
-	return sb.String()
+	// Only the instructions are rewritten; item descriptions are task content
+	prompt := sb.String()
+	return prompt[:itemsEnd] + r.signalConfig().RewritePrompt(prompt[itemsEnd:])
 }
 
 // processChecklistSignals detects and processes checklist update signals
@@ -1599,7 +2049,7 @@ func (r *RalphLoop) buildChecklistPrompt(items []*db.ChecklistItem) string {
 // Skips signals that were already processed during streaming
 func (r *RalphLoop) processChecklistSignals(response string) {
 	// Process all CHECKLIST_DONE signals
-	doneSignals := findAllSignals(response, SignalChecklistDone)
+	doneSignals := findAllSignals(response, r.signalConfig().ChecklistDone)
 	if len(doneSignals) > 0 {
 		fmt.Printf("RalphLoop: found %d CHECKLIST_DONE signals: %v\n", len(doneSignals), doneSignals)
 	}
@@ -1629,7 +2079,7 @@ func (r *RalphLoop) processChecklistSignals(response string) {
 	}
 
 	// Process all CHECKLIST_FAILED signals
-	failedSignals := findAllSignals(response, SignalChecklistFailed)
+	failedSignals := findAllSignals(response, r.signalConfig().ChecklistFailed)
 	if len(failedSignals) > 0 {
 		fmt.Printf("RalphLoop: found %d CHECKLIST_FAILED signals: %v\n", len(failedSignals), failedSignals)
 	}
@@ -1666,20 +2116,22 @@ func (r *RalphLoop) processChecklistSignals(response string) {
 	}
 }
 
-// findAllSignals finds all instances of a signal and extracts their content
+// findAllSignals finds all instances of a signal and extracts their content.
+// Signals inside fenced code blocks or inline code are ignored.
 func findAllSignals(content, signal string) []string {
 	var results []string
-	remaining := content
+	masked := markdown.MaskCode(content)
+	offset := 0
 
 	for {
-		idx := strings.Index(remaining, signal)
+		idx := strings.Index(masked[offset:], signal)
 		if idx == -1 {
 			break
 		}
 
 		// Extract signal content until newline or end
-		start := idx + len(signal)
-		contentAfter := remaining[start:]
+		start := offset + idx + len(signal)
+		contentAfter := content[start:]
 
 		// Find end of signal content (newline or double newline)
 		endIdx := strings.IndexAny(contentAfter, "\n\r")
@@ -1692,7 +2144,7 @@ func findAllSignals(content, signal string) []string {
 			results = append(results, signalContent)
 		}
 
-		remaining = remaining[start+endIdx:]
+		offset = start + endIdx
 	}
 
 	return results
@@ -1700,26 +2152,28 @@ func findAllSignals(content, signal string) []string {
 
 // parseScratchpadSignal extracts scratchpad content from a response
 // The scratchpad continues from the signal until the next major signal or end of text
-func parseScratchpadSignal(text string) (string, bool) {
-	idx := strings.Index(text, SignalScratchpad)
+func parseScratchpadSignal(text string, signals SignalConfig) (string, bool) {
+	masked := markdown.MaskCode(text)
+	idx := strings.Index(masked, signals.Scratchpad)
 	if idx == -1 {
 		return "", false
 	}
 
-	// Extract from signal to end or next major signal
-	content := text[idx+len(SignalScratchpad):]
+	// Extract from signal to end or next major signal; the scratchpad itself may contain code
+	content := text[idx+len(signals.Scratchpad):]
+	maskedContent := masked[idx+len(signals.Scratchpad):]
 
 	// Find end of scratchpad (next signal or end)
 	// Check for common signals that would end the scratchpad
 	endSignals := []string{
-		SignalEvent,
-		SignalChecklistDone,
-		SignalChecklistFailed,
+		signals.Event,
+		signals.ChecklistDone,
+		signals.ChecklistFailed,
 	}
 
 	endIdx := len(content)
 	for _, sig := range endSignals {
-		if sigIdx := strings.Index(content, sig); sigIdx != -1 && sigIdx < endIdx {
+		if sigIdx := strings.Index(maskedContent, sig); sigIdx != -1 && sigIdx < endIdx {
 			endIdx = sigIdx
 		}
 	}
@@ -1729,20 +2183,26 @@ func parseScratchpadSignal(text string) (string, bool) {
 
 // verifyChecklist checks if all selected checklist items are completed
 // Returns true if all done, false if there are issues
-func (r *RalphLoop) verifyChecklist() (bool, []db.ChecklistIssue) {
+func (r *RalphLoop) verifyChecklist() (bool, []db.ChecklistIssue, int) {
 	checklist, err := r.db.GetChecklistByTaskID(r.session.TaskID)
 	if err != nil || checklist == nil {
 		// No checklist, consider it complete
-		return true, nil
+		return true, nil, 0
+	}
+
+	items, err := r.db.GetChecklistItems(checklist.ID)
+	if err != nil {
+		fmt.Printf("RalphLoop: warning - failed to get checklist items: %v\n", err)
+		return true, nil, 0
 	}
 
 	issues, err := r.db.GetChecklistIssues(checklist.ID)
 	if err != nil {
 		fmt.Printf("RalphLoop: warning - failed to get checklist issues: %v\n", err)
-		return true, nil
+		return true, nil, 0
 	}
 
-	return len(issues) == 0, issues
+	return len(issues) == 0, issues, len(items)
 }
 
 // formatChecklistIssues formats checklist issues for display to the AI
@@ -1775,18 +2235,18 @@ var hatContinuations = map[string]string{
 When all items are complete:
 - Simple task (greenfield, no tests): EVENT:review.approved (skip critic, go to editor)
 - Complex task (existing code, tests): EVENT:implementation.done (triggers critic review)
-If blocked: EVENT:task.blocked:{"reason":"description of blocker"}`,
+If blocked: EVENT:task.blocked:{"category":"...","reason":"description of blocker"}`,
 
 	"critic": `Continue reviewing. When review is complete:
 - Approved, ready to finalize: EVENT:review.approved
 - Needs fixes: EVENT:review.rejected
-If blocked: EVENT:task.blocked:{"reason":"description of blocker"}`,
+If blocked: EVENT:task.blocked:{"category":"...","reason":"description of blocker"}`,
 
 	"editor": `Continue polishing. When ready to finalize:
 - Commit any remaining changes
 - Create PR if needed
 - Task complete: EVENT:task.complete
-If blocked: EVENT:task.blocked:{"reason":"description of blocker"}`,
+If blocked: EVENT:task.blocked:{"category":"...","reason":"description of blocker"}`,
 
 	"resolver": `Continue resolving blockers. When resolved:
 - Blocker cleared: EVENT:resolved
@@ -1796,14 +2256,14 @@ If blocked: EVENT:task.blocked:{"reason":"description of blocker"}`,
 // getContinuationPrompt returns a hat-specific continuation prompt
 func (r *RalphLoop) getContinuationPrompt() string {
 	if cont, ok := hatContinuations[r.session.Hat]; ok {
-		return cont
+		return r.signalConfig().RewritePrompt(cont)
 	}
-	return "Continue. Output EVENT:task.complete when done or EVENT:<topic> to signal progress."
+	return r.signalConfig().RewritePrompt("Continue. Output EVENT:task.complete when done or EVENT:<topic> to signal progress.")
 }
 
 // processMemorySignals detects and stores memory signals from the response
 func (r *RalphLoop) processMemorySignals(response string) {
-	memories := findAllSignals(response, SignalMemory)
+	memories := findAllSignals(response, r.signalConfig().Memory)
 	if len(memories) == 0 {
 		return
 	}
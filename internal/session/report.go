@@ -0,0 +1,475 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// reportSummaryTimeout bounds the LLM call that writes a report's summary
+const reportSummaryTimeout = 60 * time.Second
+
+// reportSummaryPrompt asks for the natural-language summary of a finished task
+const reportSummaryPrompt = `Write a short summary (2-4 sentences) of how this task went for someone who wasn't watching: what was done, how it ended, and anything a reviewer should check. Use plain prose with no headings or lists.
+
+%s`
+
+// TaskReport is a structured account of how a task went, assembled from its
+// sessions, activity, checklist, and memories. It's the HQ counterpart of the
+// CompletionReport a worker sends when an objective finishes.
+type TaskReport struct {
+	TaskID       string               `json:"task_id"`
+	Title        string               `json:"title"`
+	Status       string               `json:"status"`
+	Summary      string               `json:"summary"`
+	BranchName   string               `json:"branch_name,omitempty"`
+	PRNumber     int                  `json:"pr_number,omitempty"`
+	PRURL        string               `json:"pr_url,omitempty"`
+	TotalTokens  int64                `json:"total_tokens"`
+	TotalCost    float64              `json:"total_cost"`
+	Iterations   int                  `json:"iterations"`
+	Hats         []*db.HatUsage       `json:"hats"`
+	Checklist    *ChecklistOutcome    `json:"checklist,omitempty"`
+	QualityGates []*QualityGateRecord `json:"quality_gates,omitempty"`
+	Memories     []*MemoryRecord      `json:"memories,omitempty"`
+	BudgetTopUps []*BudgetTopUp       `json:"budget_top_ups,omitempty"`
+	Errors       []string             `json:"errors,omitempty"`
+	CompletedAt  *time.Time           `json:"completed_at,omitempty"`
+	GeneratedAt  time.Time            `json:"generated_at"`
+}
+
+// ChecklistOutcome counts a task's checklist items by status
+type ChecklistOutcome struct {
+	Done    int              `json:"done"`
+	Failed  int              `json:"failed"`
+	Skipped int              `json:"skipped"`
+	Pending int              `json:"pending"` // Includes items still in progress
+	Items   []*ChecklistLine `json:"items"`
+}
+
+// ChecklistLine is one checklist item in a report
+type ChecklistLine struct {
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Notes       string `json:"notes,omitempty"`
+}
+
+// QualityGateRecord is one quality gate run in a report
+type QualityGateRecord struct {
+	SessionID string    `json:"session_id"`
+	Hat       string    `json:"hat"`
+	RanAt     time.Time `json:"ran_at"`
+	QualityGateData
+}
+
+// MemoryRecord is a memory the task's sessions created
+type MemoryRecord struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+// BudgetTopUp is a budget extension someone granted when resuming the task,
+// as recorded in the audit log
+type BudgetTopUp struct {
+	Actor                    string    `json:"actor"`
+	SessionID                string    `json:"session_id"`
+	AdditionalIterations     int       `json:"additional_iterations,omitempty"`
+	AdditionalTokens         int64     `json:"additional_tokens,omitempty"`
+	AdditionalDollars        float64   `json:"additional_dollars,omitempty"`
+	AdditionalRuntimeMinutes int       `json:"additional_runtime_minutes,omitempty"`
+	GrantedAt                time.Time `json:"granted_at"`
+}
+
+// BuildTaskReport assembles the task's completion report. The summary is
+// written by an LLM once the task has finished and cached until another
+// session runs; until then, or without an API client, a plain summary built
+// from the report's numbers is used.
+func (m *Manager) BuildTaskReport(ctx context.Context, taskID string) (*TaskReport, error) {
+	task, err := m.db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	report := &TaskReport{
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Status:      task.Status,
+		BranchName:  task.GetBranchName(),
+		GeneratedAt: time.Now(),
+	}
+	if task.CompletedAt.Valid {
+		completedAt := task.CompletedAt.Time
+		report.CompletedAt = &completedAt
+	}
+	if task.PRNumber.Valid {
+		report.PRNumber = int(task.PRNumber.Int64)
+		if project, err := m.db.GetProjectByID(task.ProjectID); err != nil {
+			fmt.Printf("BuildTaskReport: warning - failed to get project: %v\n", err)
+		} else if project != nil {
+			report.PRURL = m.pullRequestURL(project, report.PRNumber)
+		}
+	}
+
+	if report.Hats, err = m.db.GetTaskHatBreakdown(taskID); err != nil {
+		return nil, err
+	}
+	if report.Hats == nil {
+		report.Hats = []*db.HatUsage{}
+	}
+	for _, hat := range report.Hats {
+		report.TotalTokens += hat.TotalTokens
+		report.TotalCost += hat.DollarsUsed
+		report.Iterations += hat.Iterations
+	}
+
+	if report.Checklist, err = m.checklistOutcome(taskID); err != nil {
+		return nil, err
+	}
+
+	activities, err := m.db.ListTaskActivity(taskID)
+	if err != nil {
+		return nil, err
+	}
+	report.QualityGates = qualityGateRecords(activities)
+
+	memories, err := m.db.ListMemoriesByTask(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task memories: %w", err)
+	}
+	for _, mem := range memories {
+		report.Memories = append(report.Memories, &MemoryRecord{ID: mem.ID, Type: string(mem.Type), Title: mem.Title})
+	}
+
+	if report.BudgetTopUps, err = m.budgetTopUps(taskID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := m.db.ListSessionsByTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sess := range sessions {
+		if sess.Status == db.SessionStatusFailed && sess.TerminationReason.Valid {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s session %s: %s", sess.Hat, sess.ID, sess.TerminationReason.String))
+		}
+	}
+
+	var lastSessionID string
+	if len(sessions) > 0 {
+		lastSessionID = sessions[0].ID // Newest first
+	}
+	report.Summary = m.reportSummary(ctx, task, report, lastSessionID)
+
+	return report, nil
+}
+
+// checklistOutcome tallies the task's checklist, or returns nil if it has none
+func (m *Manager) checklistOutcome(taskID string) (*ChecklistOutcome, error) {
+	checklist, err := m.db.GetChecklistByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if checklist == nil {
+		return nil, nil
+	}
+	items, err := m.db.GetChecklistItems(checklist.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &ChecklistOutcome{Items: make([]*ChecklistLine, 0, len(items))}
+	for _, item := range items {
+		switch item.Status {
+		case db.ChecklistItemStatusDone:
+			outcome.Done++
+		case db.ChecklistItemStatusFailed:
+			outcome.Failed++
+		case db.ChecklistItemStatusSkipped:
+			outcome.Skipped++
+		default:
+			outcome.Pending++
+		}
+		outcome.Items = append(outcome.Items, &ChecklistLine{
+			Description: item.Description,
+			Status:      item.Status,
+			Notes:       item.VerificationNotes.String,
+		})
+	}
+	return outcome, nil
+}
+
+// budgetTopUps returns the task's budget top-ups from the audit log, oldest first
+func (m *Manager) budgetTopUps(taskID string) ([]*BudgetTopUp, error) {
+	entries, err := m.db.ListAuditEntries("task", taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var topUps []*BudgetTopUp
+	for i := len(entries) - 1; i >= 0; i-- { // Entries are newest first
+		entry := entries[i]
+		if entry.Action != db.AuditActionBudgetTopUp {
+			continue
+		}
+		topUp := &BudgetTopUp{Actor: entry.Actor, GrantedAt: entry.CreatedAt}
+		if entry.Details.Valid {
+			if err := json.Unmarshal([]byte(entry.Details.String), topUp); err != nil {
+				fmt.Printf("BuildTaskReport: warning - skipping unreadable budget top-up %s: %v\n", entry.ID, err)
+				continue
+			}
+		}
+		topUps = append(topUps, topUp)
+	}
+	return topUps, nil
+}
+
+// qualityGateRecords extracts the quality gate runs from a task's activity
+func qualityGateRecords(activities []*db.SessionActivity) []*QualityGateRecord {
+	var records []*QualityGateRecord
+	for _, activity := range activities {
+		if activity.EventType != db.ActivityTypeQualityGate || !activity.Content.Valid {
+			continue
+		}
+		record := &QualityGateRecord{SessionID: activity.SessionID, Hat: activity.Hat.String, RanAt: activity.CreatedAt}
+		if err := json.Unmarshal([]byte(activity.Content.String), &record.QualityGateData); err != nil {
+			fmt.Printf("BuildTaskReport: warning - skipping unreadable quality gate record %s: %v\n", activity.ID, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// pullRequestURL links to a task's PR on the project's git provider
+func (m *Manager) pullRequestURL(project *db.Project, number int) string {
+	owner, repo := project.GetOwner(), project.GetRepo()
+	if owner == "" || repo == "" {
+		return ""
+	}
+	if project.IsForgejo() {
+		m.mu.RLock()
+		baseURL := m.forgejoBaseURL
+		m.mu.RUnlock()
+		if baseURL == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s/%s/%s/pulls/%d", strings.TrimSuffix(baseURL, "/"), owner, repo, number)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/pull/%d", owner, repo, number)
+}
+
+// reportSummary returns the cached summary if it still covers the task's last
+// session, otherwise writes one. Only LLM summaries of finished tasks are cached.
+func (m *Manager) reportSummary(ctx context.Context, task *db.Task, report *TaskReport, lastSessionID string) string {
+	cached, coveredSession, err := m.db.GetTaskReportSummary(task.ID)
+	if err != nil {
+		fmt.Printf("BuildTaskReport: warning - failed to get cached summary: %v\n", err)
+	} else if cached != "" && coveredSession == lastSessionID {
+		return cached
+	}
+
+	m.mu.RLock()
+	client := m.anthropicClient
+	m.mu.RUnlock()
+
+	if client == nil || lastSessionID == "" || !taskFinished(task.Status) {
+		return report.plainSummary()
+	}
+
+	summary, err := generateReportSummary(ctx, client, task, report)
+	if err != nil {
+		fmt.Printf("BuildTaskReport: warning - failed to generate summary for task %s: %v\n", task.ID, err)
+		return report.plainSummary()
+	}
+	if err := m.db.SetTaskReportSummary(task.ID, lastSessionID, summary); err != nil {
+		fmt.Printf("BuildTaskReport: warning - failed to cache summary: %v\n", err)
+	}
+	return summary
+}
+
+// taskFinished reports whether a task has stopped running for good
+func taskFinished(status string) bool {
+	switch status {
+	case db.TaskStatusCompleted, db.TaskStatusCompletedWithIssues, db.TaskStatusCancelled, db.TaskStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// generateReportSummary asks the summary model to describe the task in prose
+func generateReportSummary(ctx context.Context, client *toolbelt.AnthropicClient, task *db.Task, report *TaskReport) (string, error) {
+	var input strings.Builder
+	fmt.Fprintf(&input, "Task description:\n%s\n\n", task.GetDescription())
+	input.WriteString(report.markdownBody())
+
+	ctx, cancel := context.WithTimeout(ctx, reportSummaryTimeout)
+	defer cancel()
+
+	resp, err := client.Chat(ctx, &toolbelt.AnthropicChatRequest{
+		Model:     SummaryModelHaiku,
+		MaxTokens: 512,
+		Messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: fmt.Sprintf(reportSummaryPrompt, input.String())},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("summary API call failed: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Text())
+	if summary == "" {
+		return "", fmt.Errorf("summary model returned no text")
+	}
+	return summary, nil
+}
+
+// plainSummary describes the report in one or two sentences without an LLM
+func (r *TaskReport) plainSummary() string {
+	hats := make([]string, len(r.Hats))
+	for i, hat := range r.Hats {
+		hats[i] = hat.Hat
+	}
+
+	var sb strings.Builder
+	if len(hats) == 0 {
+		fmt.Fprintf(&sb, "Task is %s and hasn't run yet.", r.Status)
+	} else {
+		fmt.Fprintf(&sb, "Task is %s after %d iterations (%s).", r.Status, r.Iterations, strings.Join(hats, " → "))
+	}
+	if r.Checklist != nil && len(r.Checklist.Items) > 0 {
+		fmt.Fprintf(&sb, " %d of %d checklist items done.", r.Checklist.Done, len(r.Checklist.Items))
+	}
+	if r.PRNumber > 0 {
+		fmt.Fprintf(&sb, " Opened PR #%d.", r.PRNumber)
+	}
+	return sb.String()
+}
+
+// Markdown renders the report for sharing
+func (r *TaskReport) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", r.Title)
+	if r.Summary != "" {
+		fmt.Fprintf(&sb, "%s\n\n", r.Summary)
+	}
+	sb.WriteString(r.markdownBody())
+	fmt.Fprintf(&sb, "\n_Task %s, report generated %s_\n", r.TaskID, r.GeneratedAt.UTC().Format(time.RFC3339))
+	return sb.String()
+}
+
+// markdownBody renders everything in the report except the title and summary
+func (r *TaskReport) markdownBody() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Status:** %s\n", r.Status)
+	if r.PRURL != "" {
+		fmt.Fprintf(&sb, "**Pull request:** %s\n", r.PRURL)
+	} else if r.PRNumber > 0 {
+		fmt.Fprintf(&sb, "**Pull request:** #%d\n", r.PRNumber)
+	}
+	if r.BranchName != "" {
+		fmt.Fprintf(&sb, "**Branch:** `%s`\n", r.BranchName)
+	}
+	fmt.Fprintf(&sb, "**Iterations:** %d, **Tokens:** %d, **Cost:** $%.2f\n", r.Iterations, r.TotalTokens, r.TotalCost)
+
+	if len(r.Hats) > 0 {
+		sb.WriteString("\n## Hats\n\n| Hat | Sessions | Iterations | Tokens | Cost | Time |\n|---|---|---|---|---|---|\n")
+		for _, hat := range r.Hats {
+			fmt.Fprintf(&sb, "| %s | %d | %d | %d | $%.2f | %s |\n", hat.Hat, hat.Sessions, hat.Iterations, hat.TotalTokens, hat.DollarsUsed,
+				(time.Duration(hat.DurationSeconds) * time.Second).String())
+		}
+	}
+
+	if r.Checklist != nil && len(r.Checklist.Items) > 0 {
+		fmt.Fprintf(&sb, "\n## Checklist\n\n%d done, %d failed, %d skipped, %d pending\n\n",
+			r.Checklist.Done, r.Checklist.Failed, r.Checklist.Skipped, r.Checklist.Pending)
+		for _, item := range r.Checklist.Items {
+			mark := " "
+			if item.Status == db.ChecklistItemStatusDone {
+				mark = "x"
+			}
+			fmt.Fprintf(&sb, "- [%s] %s", mark, item.Description)
+			if item.Status != db.ChecklistItemStatusDone && item.Status != db.ChecklistItemStatusPending {
+				fmt.Fprintf(&sb, " (%s)", item.Status)
+			}
+			if item.Notes != "" {
+				fmt.Fprintf(&sb, ": %s", item.Notes)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(r.QualityGates) > 0 {
+		sb.WriteString("\n## Quality Gates\n\n")
+		for _, gate := range r.QualityGates {
+			result := "failed"
+			if gate.Passed {
+				result = "passed"
+			}
+			fmt.Fprintf(&sb, "- Attempt %d (%s): %s — tests %s, lint %s, build %s\n", gate.Attempt, gate.Hat, result,
+				checkStatus(gate.Tests), checkStatus(gate.Lint), checkStatus(gate.Build))
+		}
+	}
+
+	if len(r.Memories) > 0 {
+		sb.WriteString("\n## Memories Created\n\n")
+		for _, mem := range r.Memories {
+			fmt.Fprintf(&sb, "- **%s** (%s)\n", mem.Title, mem.Type)
+		}
+	}
+
+	if len(r.BudgetTopUps) > 0 {
+		sb.WriteString("\n## Budget Top-Ups\n\n")
+		for _, topUp := range r.BudgetTopUps {
+			fmt.Fprintf(&sb, "- %s by %s: %s\n", topUp.GrantedAt.UTC().Format(time.RFC3339), topUp.Actor, topUp.describe())
+		}
+	}
+
+	if len(r.Errors) > 0 {
+		sb.WriteString("\n## Errors\n\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&sb, "- %s\n", e)
+		}
+	}
+	return sb.String()
+}
+
+// describe lists what the top-up added, like "+10 iterations, +$2.00"
+func (t *BudgetTopUp) describe() string {
+	var parts []string
+	if t.AdditionalIterations > 0 {
+		parts = append(parts, fmt.Sprintf("+%d iterations", t.AdditionalIterations))
+	}
+	if t.AdditionalTokens > 0 {
+		parts = append(parts, fmt.Sprintf("+%d tokens", t.AdditionalTokens))
+	}
+	if t.AdditionalDollars > 0 {
+		parts = append(parts, fmt.Sprintf("+$%.2f", t.AdditionalDollars))
+	}
+	if t.AdditionalRuntimeMinutes > 0 {
+		parts = append(parts, fmt.Sprintf("+%d minutes", t.AdditionalRuntimeMinutes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkStatus describes a recorded quality check in a word
+func checkStatus(check *CheckData) string {
+	switch {
+	case check == nil:
+		return "not run"
+	case check.Skipped:
+		return "skipped"
+	case check.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
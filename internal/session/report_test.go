@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestManager_BuildTaskReport(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Add login", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := database.CreateSession(task.ID, "creator", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetSessionRates(sess.ID, 3.0, 15.0); err != nil {
+		t.Fatal(err)
+	}
+	input, output := 1000, 200
+	if _, err := database.CreateSessionActivity(sess.ID, 1, db.ActivityTypeAssistantResponse, "creator", "done", &input, &output); err != nil {
+		t.Fatal(err)
+	}
+	gate, _ := json.Marshal(QualityGateData{Attempt: 1, Passed: true, Tests: &CheckData{Passed: true}, Lint: &CheckData{Skipped: true}})
+	if _, err := database.CreateSessionActivity(sess.ID, 1, db.ActivityTypeQualityGate, "creator", string(gate), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	checklist, err := database.CreateTaskChecklist(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := database.CreateChecklistItems(checklist.ID, []string{"Login form", "Session cookie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateChecklistItemStatus(items[0].ID, db.ChecklistItemStatusDone, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.CreateMemory(&db.Memory{
+		ID:              db.NewPrefixedID("mem"),
+		ProjectID:       project.ID,
+		Type:            db.MemoryPitfall,
+		Title:           "Cookies need SameSite",
+		Content:         "Set SameSite=Lax on the session cookie",
+		Confidence:      db.InitialConfidenceExplicit,
+		CreatedByHat:    "creator",
+		CreatedByTaskID: sql.NullString{String: task.ID, Valid: true},
+		Source:          db.SourceExplicit,
+		CreatedAt:       time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := database.CreateAuditEntry("user-1", db.AuditActionBudgetTopUp, "task", task.ID, map[string]any{
+		"session_id":            sess.ID,
+		"additional_iterations": 10,
+		"additional_dollars":    2.5,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(database, nil, t.TempDir())
+	report, err := m.BuildTaskReport(context.Background(), task.ID)
+	if err != nil {
+		t.Fatalf("BuildTaskReport failed: %v", err)
+	}
+
+	if report.TotalTokens != 1200 || len(report.Hats) != 1 || report.Hats[0].Hat != "creator" {
+		t.Errorf("unexpected usage: %d tokens, hats %+v", report.TotalTokens, report.Hats)
+	}
+	if report.Checklist == nil || report.Checklist.Done != 1 || report.Checklist.Pending != 1 {
+		t.Errorf("unexpected checklist outcome: %+v", report.Checklist)
+	}
+	if len(report.QualityGates) != 1 || !report.QualityGates[0].Passed || report.QualityGates[0].SessionID != sess.ID {
+		t.Errorf("unexpected quality gates: %+v", report.QualityGates)
+	}
+	if len(report.Memories) != 1 || report.Memories[0].Title != "Cookies need SameSite" {
+		t.Errorf("unexpected memories: %+v", report.Memories)
+	}
+	if len(report.BudgetTopUps) != 1 || report.BudgetTopUps[0].Actor != "user-1" || report.BudgetTopUps[0].AdditionalIterations != 10 {
+		t.Errorf("unexpected budget top-ups: %+v", report.BudgetTopUps)
+	}
+
+	// No API client: a plain summary, not cached
+	if !strings.Contains(report.Summary, "1 of 2 checklist items done") {
+		t.Errorf("expected a plain summary, got %q", report.Summary)
+	}
+	if cached, _, _ := database.GetTaskReportSummary(task.ID); cached != "" {
+		t.Errorf("plain summary shouldn't be cached, got %q", cached)
+	}
+
+	markdown := report.Markdown()
+	for _, want := range []string{"# Add login", "## Hats", "- [x] Login form", "- [ ] Session cookie", "tests passed, lint skipped, build not run", "**Cookies need SameSite**", "by user-1: +10 iterations, +$2.50"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+
+	// A cached summary covering the last session is reused
+	if err := database.SetTaskReportSummary(task.ID, sess.ID, "Added a login form."); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ = m.BuildTaskReport(context.Background(), task.ID); report.Summary != "Added a login form." {
+		t.Errorf("expected the cached summary, got %q", report.Summary)
+	}
+
+	// ...until another session runs
+	if _, err := database.CreateSession(task.ID, "critic", "/tmp/wt"); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ = m.BuildTaskReport(context.Background(), task.ID); report.Summary == "Added a login form." {
+		t.Error("expected a stale cached summary to be replaced")
+	}
+
+	if _, err := m.BuildTaskReport(context.Background(), "task-missing"); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
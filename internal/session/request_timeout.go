@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// Per-request limits for LLM calls
+const (
+	// DefaultRequestTimeout bounds a single streaming API call. It sits below the
+	// HTTP client's own timeout so a stuck stream is abandoned and retried first.
+	DefaultRequestTimeout = 4 * time.Minute
+
+	// DefaultRequestAttempts is how many times a timed-out request is tried in total
+	DefaultRequestAttempts = 3
+
+	// defaultRequestRetryBackoff is multiplied by the attempt number between retries
+	defaultRequestRetryBackoff = 2 * time.Second
+)
+
+// ErrRequestTimeout indicates an LLM request exceeded its per-request deadline.
+// Unlike other API errors, timeouts are retried.
+var ErrRequestTimeout = errors.New("LLM request timed out")
+
+// requestPolicy bounds and retries individual LLM calls
+type requestPolicy struct {
+	timeout  time.Duration // Per-attempt deadline (0 = none)
+	attempts int           // Total attempts for timed-out requests
+	backoff  time.Duration // Base delay between attempts
+}
+
+// defaultRequestPolicy returns the policy new loops start with
+func defaultRequestPolicy() requestPolicy {
+	return requestPolicy{
+		timeout:  DefaultRequestTimeout,
+		attempts: DefaultRequestAttempts,
+		backoff:  defaultRequestRetryBackoff,
+	}
+}
+
+// do runs call with a deadline derived from ctx, retrying only when the deadline
+// was hit. Other errors and cancellation of ctx itself are returned immediately.
+// onTimeout is called before each retry.
+func (p requestPolicy) do(
+	ctx context.Context,
+	call func(ctx context.Context) (*toolbelt.AnthropicChatResponse, error),
+	onTimeout func(attempt int, err error),
+) (*toolbelt.AnthropicChatResponse, error) {
+	attempts := max(p.attempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		response, err := p.attempt(ctx, call)
+		if err == nil || !errors.Is(err, ErrRequestTimeout) || attempt >= attempts {
+			return response, err
+		}
+
+		if onTimeout != nil {
+			onTimeout(attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.backoff * time.Duration(attempt)):
+		}
+	}
+}
+
+// attempt makes a single call under the per-request deadline
+func (p requestPolicy) attempt(
+	ctx context.Context,
+	call func(ctx context.Context) (*toolbelt.AnthropicChatResponse, error),
+) (*toolbelt.AnthropicChatResponse, error) {
+	if p.timeout <= 0 {
+		return call(ctx)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	response, err := call(reqCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %v: %v", ErrRequestTimeout, p.timeout, err)
+	}
+	return response, err
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// hangUntilDone simulates a stuck stream that only returns once its context ends
+func hangUntilDone(ctx context.Context) (*toolbelt.AnthropicChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRequestPolicy_RetriesTimeouts(t *testing.T) {
+	policy := requestPolicy{timeout: 10 * time.Millisecond, attempts: 3, backoff: time.Millisecond}
+
+	calls := 0
+	var retried []int
+	response, err := policy.do(context.Background(), func(ctx context.Context) (*toolbelt.AnthropicChatResponse, error) {
+		calls++
+		if calls < 3 {
+			return hangUntilDone(ctx)
+		}
+		return &toolbelt.AnthropicChatResponse{ID: "ok"}, nil
+	}, func(attempt int, err error) {
+		if !errors.Is(err, ErrRequestTimeout) {
+			t.Errorf("onTimeout err = %v, want ErrRequestTimeout", err)
+		}
+		retried = append(retried, attempt)
+	})
+
+	if err != nil || response == nil || response.ID != "ok" {
+		t.Fatalf("do() = %v, %v; want success on third attempt", response, err)
+	}
+	if len(retried) != 2 {
+		t.Errorf("retried %v, want attempts 1 and 2", retried)
+	}
+}
+
+func TestRequestPolicy_GivesUpAfterAttempts(t *testing.T) {
+	policy := requestPolicy{timeout: 5 * time.Millisecond, attempts: 2, backoff: time.Millisecond}
+
+	calls := 0
+	_, err := policy.do(context.Background(), func(ctx context.Context) (*toolbelt.AnthropicChatResponse, error) {
+		calls++
+		return hangUntilDone(ctx)
+	}, nil)
+
+	if !errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("err = %v, want ErrRequestTimeout", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRequestPolicy_DoesNotRetryOtherErrors(t *testing.T) {
+	policy := requestPolicy{timeout: time.Second, attempts: 3}
+	apiErr := errors.New("overloaded")
+
+	calls := 0
+	_, err := policy.do(context.Background(), func(ctx context.Context) (*toolbelt.AnthropicChatResponse, error) {
+		calls++
+		return nil, apiErr
+	}, nil)
+
+	if !errors.Is(err, apiErr) || errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("err = %v, want the API error unchanged", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRequestPolicy_ParentCancellationIsNotTimeout(t *testing.T) {
+	policy := requestPolicy{timeout: time.Second, attempts: 3}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := policy.do(ctx, hangUntilDone, nil)
+
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,293 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+var (
+	ErrNotPaused               = errors.New("task is not paused")
+	ErrNoCheckpoint            = errors.New("no checkpoint to resume from")
+	ErrBudgetExtensionRequired = errors.New("session was paused by an exhausted budget that must be extended to resume")
+	ErrBudgetCapExceeded       = errors.New("budget extension exceeds the project's budget cap")
+	ErrSessionStopping         = errors.New("session is still stopping")
+	ErrWorktreeCleanedUp       = errors.New("task worktree has been cleaned up")
+	ErrResumeRaced             = errors.New("session changed state while resuming")
+)
+
+// BudgetExtension raises a session's limits when it resumes. Each limit is
+// raised from the higher of the limit and what the restored checkpoint has
+// already used, so the session always gets the full extension. Unlimited
+// budgets stay unlimited.
+type BudgetExtension struct {
+	Iterations int
+	Tokens     int64
+	Dollars    float64
+	Runtime    time.Duration
+}
+
+// IsZero reports whether the extension changes nothing
+func (e BudgetExtension) IsZero() bool {
+	return e == BudgetExtension{}
+}
+
+// check returns an error if the session paused for reason would immediately
+// pause again because e doesn't extend the budget it exhausted. Runtime is
+// measured per run, so a runtime pause can always resume as is.
+func (e BudgetExtension) check(reason string) error {
+	var missing string
+	switch reason {
+	case string(TerminationMaxIterations):
+		if e.Iterations <= 0 {
+			missing = "iterations"
+		}
+	case string(TerminationMaxTokens):
+		if e.Tokens <= 0 {
+			missing = "tokens"
+		}
+	case string(TerminationMaxCost):
+		if e.Dollars <= 0 {
+			missing = "dollars"
+		}
+	case "budget_exceeded":
+		if e.IsZero() {
+			missing = "a budget"
+		}
+	}
+	if missing != "" {
+		return fmt.Errorf("%w: paused at %s, extend %s", ErrBudgetExtensionRequired, reason, missing)
+	}
+	return nil
+}
+
+// apply raises s's limits. Call it after the checkpoint is restored, so usage is known.
+func (e BudgetExtension) apply(s *ActiveSession) {
+	if e.Iterations > 0 && s.MaxIterations > 0 {
+		s.MaxIterations = max(s.MaxIterations, s.IterationCount) + e.Iterations
+	}
+	if e.Tokens > 0 && s.TokensBudget != nil {
+		v := max(*s.TokensBudget, s.TotalTokens()) + e.Tokens
+		s.TokensBudget = &v
+	}
+	if e.Dollars > 0 && s.DollarsBudget != nil {
+		v := max(*s.DollarsBudget, s.Cost()) + e.Dollars
+		s.DollarsBudget = &v
+	}
+	if e.Runtime > 0 && s.MaxRuntime > 0 {
+		s.MaxRuntime += e.Runtime
+	}
+}
+
+// checkCap returns an error if extending a session with s's limits and usage
+// would raise a budget past budgetCap. Only budgets e raises are checked, so a
+// cap lowered after a session started doesn't stop it resuming.
+func (e BudgetExtension) checkCap(s *ActiveSession, budgetCap *db.BudgetCap) error {
+	if budgetCap.IsZero() || e.IsZero() {
+		return nil
+	}
+
+	preview := &ActiveSession{
+		IterationCount: s.IterationCount,
+		MaxIterations:  s.MaxIterations,
+		InputTokens:    s.InputTokens,
+		OutputTokens:   s.OutputTokens,
+		InputRate:      s.InputRate,
+		OutputRate:     s.OutputRate,
+		TokensBudget:   s.TokensBudget,
+		DollarsBudget:  s.DollarsBudget,
+	}
+	e.apply(preview)
+
+	if e.Iterations > 0 && budgetCap.Iterations > 0 && preview.MaxIterations > budgetCap.Iterations {
+		return fmt.Errorf("%w: max iterations would be %d, cap is %d",
+			ErrBudgetCapExceeded, preview.MaxIterations, budgetCap.Iterations)
+	}
+	if e.Tokens > 0 && budgetCap.Tokens > 0 && preview.TokensBudget != nil && *preview.TokensBudget > budgetCap.Tokens {
+		return fmt.Errorf("%w: token budget would be %d, cap is %d",
+			ErrBudgetCapExceeded, *preview.TokensBudget, budgetCap.Tokens)
+	}
+	if e.Dollars > 0 && budgetCap.Dollars > 0 && preview.DollarsBudget != nil && *preview.DollarsBudget > budgetCap.Dollars {
+		return fmt.Errorf("%w: dollar budget would be $%.2f, cap is $%.2f",
+			ErrBudgetCapExceeded, *preview.DollarsBudget, budgetCap.Dollars)
+	}
+	return nil
+}
+
+// checkpointUsage returns the iteration and token usage a session restored
+// from checkpoint starts with, matching RalphLoop.RestoreFromCheckpoint
+func checkpointUsage(checkpoint *db.SessionCheckpoint) (iteration int, inputTokens, outputTokens int64, err error) {
+	var state checkpointState
+	if err := json.Unmarshal(checkpoint.State, &state); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to unmarshal checkpoint state: %w", err)
+	}
+	if state.InputTokens > 0 || state.OutputTokens > 0 {
+		return state.Iteration, state.InputTokens, state.OutputTokens, nil
+	}
+	return state.Iteration, state.TokensUsed * 2 / 3, state.TokensUsed / 3, nil
+}
+
+// Resume restarts a paused task, whatever paused it. A paused session still in
+// memory (e.g. loaded at startup) is started again; otherwise a new session is
+// created from the task's most recent checkpoint. ext raises the budgets of
+// the resumed session and is required when a budget caused the pause.
+func (m *Manager) Resume(taskID string, ext BudgetExtension) (*ActiveSession, error) {
+	m.mu.Lock()
+	var existing *ActiveSession
+	if sessionID, ok := m.byTask[taskID]; ok {
+		existing = m.sessions[sessionID]
+	}
+	if existing != nil {
+		if existing.State != StatePaused {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("%w: session %s is %s", ErrNotPaused, existing.ID, existing.State)
+		}
+		if existing.cancel != nil {
+			// Paused in this process and still winding down; it's removed once done
+			m.mu.Unlock()
+			return nil, fmt.Errorf("%w: session %s, try again shortly", ErrSessionStopping, existing.ID)
+		}
+		if err := ext.check(existing.TerminationReason); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+		if err := m.checkTaskCost(taskID); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+		preview := m.copySession(existing)
+		m.mu.Unlock()
+
+		budgetCap, err := m.db.GetProjectBudgetCap(preview.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get budget cap: %w", err)
+		}
+		if err := ext.checkCap(preview, budgetCap); err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		if existing.State != StatePaused || existing.cancel != nil {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("%w: session %s, try again", ErrResumeRaced, existing.ID)
+		}
+		existing.BudgetExtension = ext
+		result := m.copySession(existing)
+		m.mu.Unlock()
+
+		if err := m.db.UpdateTaskStatus(taskID, db.TaskStatusRunning); err != nil {
+			fmt.Printf("Resume: warning - failed to update task status to running: %v\n", err)
+		}
+		if err := m.Start(context.Background(), existing.ID); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Resume: restarted paused session %s for task %s\n", existing.ID, taskID)
+		return result, nil
+	}
+	m.mu.Unlock()
+
+	task, err := m.db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if task.Status != db.TaskStatusPaused {
+		return nil, fmt.Errorf("%w (status: %s)", ErrNotPaused, task.Status)
+	}
+	if task.WorktreeCleanedAt.Valid {
+		return nil, fmt.Errorf("%w: task %s", ErrWorktreeCleanedUp, taskID)
+	}
+
+	sessions, err := m.db.ListSessionsByTask(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("%w: task %s has no previous session", ErrNoCheckpoint, taskID)
+	}
+	lastSession := sessions[0] // Most recent first
+	if err := ext.check(lastSession.TerminationReason.String); err != nil {
+		return nil, err
+	}
+
+	// A session that failed early may not have checkpointed; use the newest one that did
+	restoreFrom := ""
+	var checkpoint *db.SessionCheckpoint
+	for _, s := range sessions {
+		checkpoint, err = m.db.GetLatestSessionCheckpoint(s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get checkpoint for session %s: %w", s.ID, err)
+		}
+		if checkpoint != nil {
+			restoreFrom = s.ID
+			break
+		}
+	}
+	if restoreFrom == "" {
+		return nil, fmt.Errorf("%w: no session of task %s saved one", ErrNoCheckpoint, taskID)
+	}
+
+	// The new session starts from the defaults plus the checkpoint's usage
+	if !ext.IsZero() {
+		budgetCap, err := m.db.GetProjectBudgetCap(task.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get budget cap: %w", err)
+		}
+		if !budgetCap.IsZero() {
+			iteration, inputTokens, outputTokens, err := checkpointUsage(checkpoint)
+			if err != nil {
+				return nil, err
+			}
+			m.mu.RLock()
+			preview := &ActiveSession{
+				IterationCount: iteration,
+				MaxIterations:  m.defaultMaxIterations,
+				InputTokens:    inputTokens,
+				OutputTokens:   outputTokens,
+				InputRate:      lastSession.InputRate,
+				OutputRate:     lastSession.OutputRate,
+				TokensBudget:   m.defaultTokenBudget,
+				DollarsBudget:  m.defaultDollarBudget,
+			}
+			m.mu.RUnlock()
+			if err := ext.checkCap(preview, budgetCap); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sess, err := m.CreateSession(taskID, lastSession.Hat, lastSession.WorktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	sess.RestoreFromSessionID = restoreFrom
+	sess.BudgetExtension = ext
+
+	// The resumed session keeps the priority the paused one had
+	if priority, err := m.db.GetSessionPriority(lastSession.ID); err != nil {
+		fmt.Printf("Resume: warning - failed to get priority of session %s: %v\n", lastSession.ID, err)
+	} else if priority != db.SessionPriorityNormal {
+		if err := m.SetPriority(sess.ID, priority); err != nil {
+			fmt.Printf("Resume: warning - failed to keep session priority: %v\n", err)
+		}
+	}
+	result := m.copySession(sess)
+
+	if err := m.db.UpdateTaskStatus(taskID, db.TaskStatusRunning); err != nil {
+		fmt.Printf("Resume: warning - failed to update task status to running: %v\n", err)
+	}
+	// The session outlives the request that asked for it
+	if err := m.Start(context.Background(), sess.ID); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Resume: started session %s for task %s from checkpoint of session %s (paused: %s)\n",
+		sess.ID, taskID, restoreFrom, lastSession.TerminationReason.String)
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package session
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestBudgetExtension_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     BudgetExtension
+		reason  string
+		wantErr bool
+	}{
+		{"manual pause", BudgetExtension{}, "paused", false},
+		{"user stopped", BudgetExtension{}, string(TerminationUserStopped), false},
+		{"runtime resets per run", BudgetExtension{}, string(TerminationMaxRuntime), false},
+		{"iterations without extension", BudgetExtension{Tokens: 1000}, string(TerminationMaxIterations), true},
+		{"iterations extended", BudgetExtension{Iterations: 10}, string(TerminationMaxIterations), false},
+		{"tokens without extension", BudgetExtension{}, string(TerminationMaxTokens), true},
+		{"tokens extended", BudgetExtension{Tokens: 1000}, string(TerminationMaxTokens), false},
+		{"cost without extension", BudgetExtension{Iterations: 5}, string(TerminationMaxCost), true},
+		{"cost extended", BudgetExtension{Dollars: 2}, string(TerminationMaxCost), false},
+		{"generic budget", BudgetExtension{}, "budget_exceeded", true},
+		{"generic budget extended", BudgetExtension{Runtime: time.Hour}, "budget_exceeded", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ext.check(tt.reason)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check(%q) = %v, wantErr %v", tt.reason, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBudgetExtensionRequired) {
+				t.Errorf("expected ErrBudgetExtensionRequired, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBudgetExtension_Apply(t *testing.T) {
+	tokens := int64(50_000)
+	dollars := 1.0
+	s := &ActiveSession{
+		IterationCount: 100,
+		MaxIterations:  100,
+		InputTokens:    40_000,
+		OutputTokens:   20_000, // Already over the token budget
+		InputRate:      3.0,
+		OutputRate:     15.0,
+		TokensBudget:   &tokens,
+		DollarsBudget:  &dollars,
+		MaxRuntime:     time.Hour,
+	}
+
+	BudgetExtension{Iterations: 20, Tokens: 10_000, Dollars: 0.5, Runtime: 30 * time.Minute}.apply(s)
+
+	if s.MaxIterations != 120 {
+		t.Errorf("MaxIterations = %d, want 120", s.MaxIterations)
+	}
+	if *s.TokensBudget != 70_000 {
+		t.Errorf("TokensBudget = %d, want 70000 (used 60000 + 10000)", *s.TokensBudget)
+	}
+	if *s.DollarsBudget != 1.5 {
+		t.Errorf("DollarsBudget = %v, want 1.5 (budget above the $0.42 used)", *s.DollarsBudget)
+	}
+	if s.MaxRuntime != 90*time.Minute {
+		t.Errorf("MaxRuntime = %v, want 1h30m", s.MaxRuntime)
+	}
+
+	// Unlimited budgets stay unlimited
+	unlimited := &ActiveSession{IterationCount: 5}
+	BudgetExtension{Iterations: 10, Tokens: 10, Dollars: 1, Runtime: time.Minute}.apply(unlimited)
+	if unlimited.MaxIterations != 0 || unlimited.TokensBudget != nil || unlimited.DollarsBudget != nil || unlimited.MaxRuntime != 0 {
+		t.Errorf("expected unlimited budgets to stay unlimited, got %+v", unlimited)
+	}
+}
+
+func TestBudgetExtension_CheckCap(t *testing.T) {
+	tokens := int64(50_000)
+	s := &ActiveSession{
+		IterationCount: 100,
+		MaxIterations:  100,
+		InputTokens:    60_000,
+		TokensBudget:   &tokens,
+	}
+	budgetCap := &db.BudgetCap{Iterations: 150, Tokens: 100_000}
+
+	if err := (BudgetExtension{Iterations: 50, Tokens: 40_000}).checkCap(s, budgetCap); err != nil {
+		t.Errorf("top-up up to the cap should pass, got %v", err)
+	}
+	if err := (BudgetExtension{Iterations: 51}).checkCap(s, budgetCap); !errors.Is(err, ErrBudgetCapExceeded) {
+		t.Errorf("expected ErrBudgetCapExceeded for iterations, got %v", err)
+	}
+	// Tokens are raised from the 60000 used, not the 50000 budget
+	if err := (BudgetExtension{Tokens: 40_001}).checkCap(s, budgetCap); !errors.Is(err, ErrBudgetCapExceeded) {
+		t.Errorf("expected ErrBudgetCapExceeded for tokens, got %v", err)
+	}
+	// Uncapped budgets can be raised freely
+	if err := (BudgetExtension{Dollars: 100}).checkCap(s, budgetCap); err != nil {
+		t.Errorf("dollars are uncapped, got %v", err)
+	}
+	if err := (BudgetExtension{Iterations: 1000}).checkCap(s, nil); err != nil {
+		t.Errorf("no cap should pass, got %v", err)
+	}
+
+	// Checking doesn't change the session
+	if s.MaxIterations != 100 || *s.TokensBudget != 50_000 {
+		t.Errorf("checkCap modified the session: %+v", s)
+	}
+}
+
+func TestResume_Errors(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(database, nil, t.TempDir())
+
+	// A paused session still winding down can't restart yet
+	stopping, err := database.CreateTask(project.ID, "Stopping", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := m.CreateSession(stopping.ID, "creator", "/tmp/wt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	sess.State = StatePaused
+	sess.cancel = func() {}
+	m.mu.Unlock()
+	if _, err := m.Resume(stopping.ID, BudgetExtension{}); !errors.Is(err, ErrSessionStopping) {
+		t.Errorf("Resume() of a stopping session error = %v, want ErrSessionStopping", err)
+	}
+
+	// Nor can a task whose worktree is gone
+	cleaned, err := database.CreateTask(project.ID, "Cleaned", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateTaskStatus(cleaned.ID, db.TaskStatusPaused); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.MarkTaskWorktreeCleaned(cleaned.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Resume(cleaned.ID, BudgetExtension{}); !errors.Is(err, ErrWorktreeCleanedUp) {
+		t.Errorf("Resume() of a cleaned up task error = %v, want ErrWorktreeCleanedUp", err)
+	}
+}
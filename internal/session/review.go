@@ -0,0 +1,174 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/gitprovider"
+	forgejoclient "github.com/lirancohen/dex/internal/gitprovider/forgejo"
+	"github.com/lirancohen/dex/internal/security"
+)
+
+var (
+	ErrNoPullRequest    = errors.New("task has no pull request")
+	ErrPRNotOpen        = errors.New("pull request is not open")
+	ErrNoReviewFeedback = errors.New("pull request has no review feedback to address")
+
+	ErrInvalidReviewHat     = errors.New("hat cannot address review feedback (use creator or editor)")
+	ErrTaskHasActiveSession = errors.New("task already has an active session")
+
+	// Reviews are only read from Forgejo; GitHub projects are refused up front
+	ErrReviewProviderUnsupported   = errors.New("addressing review feedback is only supported for Forgejo projects")
+	ErrReviewProviderNotConfigured = errors.New("forgejo is not configured")
+)
+
+// reviewHats are the hats allowed to address review feedback
+var reviewHats = map[string]bool{"creator": true, "editor": true}
+
+// AddressReview resumes a task from its last checkpoint to address the review
+// feedback on its pull request. Commits land on the PR's branch, so the PR
+// updates as the session works. hat defaults to creator.
+func (m *Manager) AddressReview(ctx context.Context, taskID, hat string) (*ActiveSession, error) {
+	if hat == "" {
+		hat = "creator"
+	}
+	if !reviewHats[hat] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReviewHat, hat)
+	}
+	if m.GetByTask(taskID) != nil {
+		return nil, fmt.Errorf("%w: task %s", ErrTaskHasActiveSession, taskID)
+	}
+
+	task, err := m.db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	project, err := m.db.GetProjectByID(task.ProjectID)
+	if err != nil || project == nil {
+		return nil, fmt.Errorf("failed to get project for task %s: %v", taskID, err)
+	}
+	if !project.IsForgejo() {
+		return nil, fmt.Errorf("%w: project %s uses %s", ErrReviewProviderUnsupported, project.ID, project.GetGitProvider())
+	}
+
+	if !task.PRNumber.Valid {
+		return nil, ErrNoPullRequest
+	}
+	if task.WorktreeCleanedAt.Valid {
+		return nil, fmt.Errorf("%w: task %s", ErrWorktreeCleanedUp, taskID)
+	}
+
+	m.mu.RLock()
+	baseURL := m.forgejoBaseURL
+	botToken := m.forgejoBotToken
+	m.mu.RUnlock()
+	if baseURL == "" || botToken == "" {
+		return nil, fmt.Errorf("%w for project %s", ErrReviewProviderNotConfigured, project.ID)
+	}
+
+	owner, repo := project.GetOwner(), project.GetRepo()
+	prNumber := int(task.PRNumber.Int64)
+	provider := forgejoclient.New(baseURL, botToken)
+
+	pr, err := provider.GetPR(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", prNumber, err)
+	}
+	if pr.State != "open" {
+		return nil, fmt.Errorf("%w: #%d is %s", ErrPRNotOpen, prNumber, pr.State)
+	}
+
+	reviews, err := provider.ListPRReviews(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews for PR #%d: %w", prNumber, err)
+	}
+	feedback := FormatReviewFeedback(pr, reviews)
+	if feedback == "" {
+		return nil, ErrNoReviewFeedback
+	}
+
+	// Resume in the last session's worktree, from its checkpoint
+	sessions, err := m.db.ListSessionsByTask(taskID)
+	if err != nil || len(sessions) == 0 {
+		return nil, fmt.Errorf("no previous session found for task %s", taskID)
+	}
+	lastSession := sessions[0] // Most recent first
+
+	sess, err := m.CreateSession(taskID, hat, lastSession.WorktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	sess.RestoreFromSessionID = lastSession.ID
+	sess.ReviewFeedback = feedback
+	result := m.copySession(sess)
+
+	if err := m.db.UpdateTaskStatus(taskID, db.TaskStatusRunning); err != nil {
+		fmt.Printf("AddressReview: warning - failed to update task status to running: %v\n", err)
+	}
+	// The session outlives the request that asked for it
+	if err := m.Start(context.Background(), sess.ID); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("AddressReview: started session %s for task %s to address %d review(s) on PR #%d\n", sess.ID, taskID, len(reviews), prNumber)
+	return result, nil
+}
+
+// FormatReviewFeedback renders the actionable reviews on a PR as a prompt.
+// Pending, dismissed, and stale reviews are skipped, as are resolved inline
+// comments and approvals with nothing to say. Returns "" if nothing is left.
+func FormatReviewFeedback(pr *gitprovider.PullRequest, reviews []*gitprovider.Review) string {
+	var sb strings.Builder
+	count := 0
+
+	for _, review := range reviews {
+		if review.State == "PENDING" || review.Dismissed || review.Stale {
+			continue
+		}
+
+		var comments []gitprovider.ReviewComment
+		for _, c := range review.Comments {
+			if !c.Resolved && strings.TrimSpace(c.Body) != "" {
+				comments = append(comments, c)
+			}
+		}
+		body := strings.TrimSpace(review.Body)
+		if body == "" && len(comments) == 0 {
+			continue
+		}
+
+		count++
+		sb.WriteString(fmt.Sprintf("### Review by %s (%s)\n\n", review.Author, review.State))
+		if body != "" {
+			sb.WriteString(security.SanitizeForPrompt(body))
+			sb.WriteString("\n\n")
+		}
+		for _, c := range comments {
+			if c.Line > 0 {
+				sb.WriteString(fmt.Sprintf("- `%s:%d`: %s\n", c.Path, c.Line, security.SanitizeForPrompt(c.Body)))
+			} else {
+				sb.WriteString(fmt.Sprintf("- `%s`: %s\n", c.Path, security.SanitizeForPrompt(c.Body)))
+			}
+		}
+		if len(comments) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+
+	if count == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("## Review Feedback on PR #%d\n\n"+
+		"Reviewers left feedback on the pull request for this task. "+
+		"Address each point below, committing to the same branch so the PR updates. "+
+		"If you disagree with a point, explain why in your final summary instead of changing the code.\n\n%s",
+		pr.Number, strings.TrimRight(sb.String(), "\n"))
+}
@@ -0,0 +1,31 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// approvalMissingTestRun reports whether event approves a review in a project
+// that requires tests behind every approval, before any have run this session
+func (r *RalphLoop) approvalMissingTestRun(event *Event) bool {
+	if !r.criticRequiresTests || event.Topic != TopicReviewApproved {
+		return false
+	}
+	return r.qualityGate == nil || r.qualityGate.TestRuns() == 0
+}
+
+// rejectUntestedApproval sends a review approval back, asking for the test
+// suite to run first
+func (r *RalphLoop) rejectUntestedApproval() {
+	r.activity.Debug(r.session.IterationCount, "Review approval rejected: no test run recorded this session")
+	r.messages = append(r.messages, toolbelt.AnthropicMessage{
+		Role: "user",
+		Content: r.signalConfig().RewritePrompt(`This project requires tests to run before a review is approved, and none have run in this session.
+
+Run the test suite with run_tests, then either:
+1. Signal EVENT:review.approved again if it passes
+2. Signal EVENT:review.rejected with the failures if it doesn't`),
+	})
+	fmt.Printf("RalphLoop.Run: review approval rejected for task %s - no test run recorded\n", r.session.TaskID)
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"context"
+	"testing"
+)
+
+func TestApprovalMissingTestRun(t *testing.T) {
+	approved := &Event{Topic: TopicReviewApproved}
+	rejected := &Event{Topic: TopicReviewRejected}
+
+	gate := NewQualityGate(t.TempDir(), nil)
+	loop := &RalphLoop{qualityGate: gate}
+	if loop.approvalMissingTestRun(approved) {
+		t.Error("rejected an approval in a project that doesn't require tests")
+	}
+
+	loop.SetCriticRequiresTests(true)
+	if !loop.approvalMissingTestRun(approved) {
+		t.Error("accepted an approval before any test run")
+	}
+	if loop.approvalMissingTestRun(rejected) {
+		t.Error("held back a review rejection")
+	}
+
+	// Skipping tests by request doesn't count as running them
+	gate.Validate(context.Background(), TaskCompleteOpts{Summary: "done", SkipTests: true, SkipLint: true, SkipBuild: true})
+	if !loop.approvalMissingTestRun(approved) {
+		t.Error("accepted an approval after tests were skipped by request")
+	}
+
+	// An empty worktree has no test command, which is as far as the gate can go
+	gate.RunTests(context.Background(), false, 0)
+	if loop.approvalMissingTestRun(approved) {
+		t.Error("rejected an approval after the tests ran")
+	}
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/gitprovider"
+)
+
+func TestFormatReviewFeedback(t *testing.T) {
+	pr := &gitprovider.PullRequest{Number: 7}
+	reviews := []*gitprovider.Review{
+		{Author: "alice", State: "REQUEST_CHANGES", Body: "Please handle the error case.", Comments: []gitprovider.ReviewComment{
+			{Path: "main.go", Line: 42, Body: "This can be nil"},
+			{Path: "util.go", Line: 3, Body: "Already fixed", Resolved: true},
+		}},
+		{Author: "bob", State: "COMMENT", Body: "Old feedback", Stale: true},
+		{Author: "carol", State: "REQUEST_CHANGES", Body: "Withdrawn", Dismissed: true},
+		{Author: "dave", State: "PENDING", Body: "Draft"},
+		{Author: "erin", State: "APPROVED"},
+	}
+
+	got := FormatReviewFeedback(pr, reviews)
+
+	for _, want := range []string{"PR #7", "Review by alice (REQUEST_CHANGES)", "Please handle the error case.", "`main.go:42`: This can be nil"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected feedback to contain %q, got:\n%s", want, got)
+		}
+	}
+	for _, skipped := range []string{"Already fixed", "Old feedback", "Withdrawn", "Draft", "erin"} {
+		if strings.Contains(got, skipped) {
+			t.Errorf("expected %q to be skipped, got:\n%s", skipped, got)
+		}
+	}
+}
+
+func TestFormatReviewFeedback_NothingActionable(t *testing.T) {
+	pr := &gitprovider.PullRequest{Number: 7}
+	reviews := []*gitprovider.Review{
+		{Author: "erin", State: "APPROVED"},
+		{Author: "bob", State: "COMMENT", Body: "Old feedback", Stale: true},
+	}
+
+	if got := FormatReviewFeedback(pr, reviews); got != "" {
+		t.Errorf("expected no feedback, got %q", got)
+	}
+}
+
+func TestAddressReview_Errors(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(database, nil, t.TempDir())
+	ctx := context.Background()
+
+	github, err := database.CreateProject("GitHub", "/github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgejo, err := database.GetOrCreateProjectByForgejo("dex", "app", "/forgejo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	onGitHub, err := database.CreateTask(github.ID, "Build", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onForgejo, err := database.CreateTask(forgejo.ID, "Build", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.AddressReview(ctx, onForgejo.ID, "critic"); !errors.Is(err, ErrInvalidReviewHat) {
+		t.Errorf("AddressReview() with the critic hat error = %v, want ErrInvalidReviewHat", err)
+	}
+	if _, err := m.AddressReview(ctx, onGitHub.ID, ""); !errors.Is(err, ErrReviewProviderUnsupported) {
+		t.Errorf("AddressReview() on a GitHub project error = %v, want ErrReviewProviderUnsupported", err)
+	}
+	if _, err := m.AddressReview(ctx, onForgejo.ID, ""); !errors.Is(err, ErrNoPullRequest) {
+		t.Errorf("AddressReview() without a PR error = %v, want ErrNoPullRequest", err)
+	}
+
+	if _, err := m.CreateSession(onForgejo.ID, "creator", "/tmp/wt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddressReview(ctx, onForgejo.ID, ""); !errors.Is(err, ErrTaskHasActiveSession) {
+		t.Errorf("AddressReview() with an active session error = %v, want ErrTaskHasActiveSession", err)
+	}
+}
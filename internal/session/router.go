@@ -92,6 +92,7 @@ func (r *EventRouter) RouteAndPersist(event *Event, currentHat, taskID, projectI
 		// Log but don't fail - persistence is secondary to routing
 		fmt.Printf("EventRouter: warning - failed to persist event: %v\n", err)
 	}
+	r.trackBlockedReason(event, taskID)
 
 	// Broadcast hat event to Centrifuge for real-time updates
 	if r.broadcaster != nil {
@@ -117,6 +118,32 @@ func (r *EventRouter) RouteAndPersist(event *Event, currentHat, taskID, projectI
 	return r.Route(event, currentHat)
 }
 
+// trackBlockedReason stores the category and reason a session gives when it's
+// blocked on the task, and clears them once the blocker is resolved
+func (r *EventRouter) trackBlockedReason(event *Event, taskID string) {
+	if r.db == nil || taskID == "" {
+		return
+	}
+
+	switch event.Topic {
+	case TopicTaskBlocked:
+		var payload struct {
+			Category string `json:"category"`
+			Reason   string `json:"reason"`
+		}
+		if event.Payload != "" {
+			_ = json.Unmarshal([]byte(event.Payload), &payload)
+		}
+		if err := r.db.SetTaskBlockedReason(taskID, payload.Category, payload.Reason); err != nil {
+			fmt.Printf("EventRouter: warning - failed to record blocked reason: %v\n", err)
+		}
+	case TopicResolved:
+		if err := r.db.ClearTaskBlockedReason(taskID); err != nil {
+			fmt.Printf("EventRouter: warning - failed to clear blocked reason: %v\n", err)
+		}
+	}
+}
+
 // topicToHatEvent maps internal event topics to hat event types for broadcasting
 func topicToHatEvent(topic string) string {
 	switch topic {
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SignalConfig holds the markers the loop looks for in model output, and the
+// stop sequences sent with each request. Deployments whose tasks routinely
+// contain the default markers (e.g. documentation that quotes "EVENT:") can
+// use distinct prefixes so task content isn't mistaken for a signal.
+type SignalConfig struct {
+	Event               string
+	ChecklistDone       string
+	ChecklistFailed     string
+	AcknowledgeFailures string
+	Scratchpad          string
+	Memory              string
+
+	// StopSequences end generation when the model emits one (optional)
+	StopSequences []string
+}
+
+// DefaultSignalConfig returns the built-in signal markers
+func DefaultSignalConfig() SignalConfig {
+	return SignalConfig{
+		Event:               SignalEvent,
+		ChecklistDone:       SignalChecklistDone,
+		ChecklistFailed:     SignalChecklistFailed,
+		AcknowledgeFailures: SignalAcknowledgeFailures,
+		Scratchpad:          SignalScratchpad,
+		Memory:              SignalMemory,
+	}
+}
+
+// signalNames maps the names accepted by ParseSignalPrefixes to config fields
+var signalNames = map[string]func(c *SignalConfig) *string{
+	"event":                func(c *SignalConfig) *string { return &c.Event },
+	"checklist_done":       func(c *SignalConfig) *string { return &c.ChecklistDone },
+	"checklist_failed":     func(c *SignalConfig) *string { return &c.ChecklistFailed },
+	"acknowledge_failures": func(c *SignalConfig) *string { return &c.AcknowledgeFailures },
+	"scratchpad":           func(c *SignalConfig) *string { return &c.Scratchpad },
+	"memory":               func(c *SignalConfig) *string { return &c.Memory },
+}
+
+// ParseSignalPrefixes overrides default signal markers from a comma-separated
+// list of name=prefix pairs, e.g. "event=DEX_EVENT:,checklist_done=DEX_DONE:".
+// An empty spec returns the defaults.
+func ParseSignalPrefixes(spec string) (SignalConfig, error) {
+	config := DefaultSignalConfig()
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, prefix, ok := strings.Cut(pair, "=")
+		if !ok {
+			return config, fmt.Errorf("invalid signal prefix %q: expected name=prefix", pair)
+		}
+		field, ok := signalNames[strings.TrimSpace(name)]
+		if !ok {
+			return config, fmt.Errorf("unknown signal %q", name)
+		}
+		*field(&config) = strings.TrimSpace(prefix)
+	}
+	return config, config.Validate()
+}
+
+// markers returns every signal marker paired with its built-in default
+func (c SignalConfig) markers() [][2]string {
+	return [][2]string{
+		{SignalEvent, c.Event},
+		{SignalChecklistDone, c.ChecklistDone},
+		{SignalChecklistFailed, c.ChecklistFailed},
+		{SignalAcknowledgeFailures, c.AcknowledgeFailures},
+		{SignalScratchpad, c.Scratchpad},
+		{SignalMemory, c.Memory},
+	}
+}
+
+// Validate checks that markers are non-empty, single-line, and distinct, and
+// that no stop sequence would cut a marker off
+func (c SignalConfig) Validate() error {
+	seen := make(map[string]bool)
+	for _, m := range c.markers() {
+		switch {
+		case m[1] == "":
+			return fmt.Errorf("signal %s has no prefix", m[0])
+		case strings.ContainsAny(m[1], " \t\r\n"):
+			return fmt.Errorf("signal prefix %q must not contain whitespace", m[1])
+		case seen[m[1]]:
+			return fmt.Errorf("signal prefix %q is used more than once", m[1])
+		}
+		seen[m[1]] = true
+	}
+
+	for _, stop := range c.StopSequences {
+		if strings.TrimSpace(stop) == "" {
+			return fmt.Errorf("stop sequences must not be blank")
+		}
+		for _, m := range c.markers() {
+			if strings.Contains(m[1], stop) {
+				return fmt.Errorf("stop sequence %q would cut off signal %q", stop, m[1])
+			}
+		}
+	}
+	return nil
+}
+
+// IsDefault reports whether the markers are the built-in ones
+func (c SignalConfig) IsDefault() bool {
+	for _, m := range c.markers() {
+		if m[0] != m[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// RewritePrompt replaces the default markers in instruction text with the
+// configured ones. It must only be applied to text we wrote, never to task
+// content, which is exactly what custom markers keep from being read as signals.
+func (c SignalConfig) RewritePrompt(text string) string {
+	if c.IsDefault() {
+		return text
+	}
+
+	// Longer forms first: the replacer matches in argument order, and bare
+	// names (e.g. "CHECKLIST_DONE for A") are rewritten without the colon
+	var pairs []string
+	for _, m := range c.markers() {
+		pairs = append(pairs, m[0], m[1])
+	}
+	for _, m := range c.markers() {
+		if bare := strings.TrimSuffix(m[0], ":"); bare != m[0] {
+			pairs = append(pairs, bare, strings.TrimSuffix(m[1], ":"))
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
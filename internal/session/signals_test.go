@@ -0,0 +1,178 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func customSignals(t *testing.T) SignalConfig {
+	t.Helper()
+	signals, err := ParseSignalPrefixes("event=DEX_EVENT:, checklist_done=DEX_DONE:,checklist_failed=DEX_FAILED:,scratchpad=DEX_NOTES:")
+	if err != nil {
+		t.Fatalf("ParseSignalPrefixes failed: %v", err)
+	}
+	return signals
+}
+
+func TestParseSignalPrefixes(t *testing.T) {
+	signals := customSignals(t)
+
+	if signals.Event != "DEX_EVENT:" || signals.ChecklistDone != "DEX_DONE:" || signals.Scratchpad != "DEX_NOTES:" {
+		t.Errorf("overrides not applied: %+v", signals)
+	}
+	if signals.Memory != SignalMemory || signals.AcknowledgeFailures != SignalAcknowledgeFailures {
+		t.Errorf("unspecified signals should keep defaults: %+v", signals)
+	}
+	if signals.IsDefault() {
+		t.Error("expected custom config not to be default")
+	}
+
+	defaults, err := ParseSignalPrefixes("")
+	if err != nil {
+		t.Fatalf("empty spec failed: %v", err)
+	}
+	if !defaults.IsDefault() {
+		t.Error("expected empty spec to return defaults")
+	}
+}
+
+func TestParseSignalPrefixes_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"missing equals", "event"},
+		{"unknown name", "transition=GO:"},
+		{"empty prefix", "event="},
+		{"whitespace", "event=MY EVENT:"},
+		{"duplicate", "event=X:,memory=X:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSignalPrefixes(tt.spec); err == nil {
+				t.Errorf("expected error for %q", tt.spec)
+			}
+		})
+	}
+}
+
+func TestSignalConfig_ValidateStopSequences(t *testing.T) {
+	signals := DefaultSignalConfig()
+
+	signals.StopSequences = []string{"</done>"}
+	if err := signals.Validate(); err != nil {
+		t.Errorf("expected valid stop sequence, got %v", err)
+	}
+
+	signals.StopSequences = []string{"  "}
+	if err := signals.Validate(); err == nil {
+		t.Error("expected error for blank stop sequence")
+	}
+
+	signals.StopSequences = []string{"EVENT"}
+	if err := signals.Validate(); err == nil {
+		t.Error("expected error for stop sequence inside a signal")
+	}
+}
+
+func TestSignalConfig_RewritePrompt(t *testing.T) {
+	signals := customSignals(t)
+
+	got := signals.RewritePrompt("Output CHECKLIST_DONE:<id>, then CHECKLIST_DONE for the next item. Finish with EVENT:task.complete")
+	want := "Output DEX_DONE:<id>, then DEX_DONE for the next item. Finish with DEX_EVENT:task.complete"
+	if got != want {
+		t.Errorf("RewritePrompt() = %q, want %q", got, want)
+	}
+
+	text := "EVENT:task.complete"
+	if DefaultSignalConfig().RewritePrompt(text) != text {
+		t.Error("default config should not rewrite text")
+	}
+}
+
+func TestParseEventWithPrefix_IgnoresDefaultMarker(t *testing.T) {
+	text := "Documented the bus: handlers log EVENT:user.created when a user signs up.\n"
+
+	if _, found := parseEventWithPrefix(text, "DEX_EVENT:", "s1", "creator"); found {
+		t.Error("default marker in content should not be an event under a custom prefix")
+	}
+
+	event, found := parseEventWithPrefix(text+"DEX_EVENT:implementation.done\n", "DEX_EVENT:", "s1", "creator")
+	if !found {
+		t.Fatal("expected custom event to be found")
+	}
+	if event.Topic != TopicImplementationDone {
+		t.Errorf("expected topic %s, got %s", TopicImplementationDone, event.Topic)
+	}
+}
+
+func TestStreamingSignalDetector_CustomSignals(t *testing.T) {
+	var done []string
+	detector := NewStreamingSignalDetector(
+		func(itemID string) { done = append(done, itemID) },
+		func(itemID, reason string) {},
+	)
+	detector.SetSignals(customSignals(t))
+
+	detector.ProcessDelta("Example doc line: CHECKLIST_DONE:not-an-item\n")
+	detector.ProcessDelta("DEX_DONE:item-1\n")
+
+	if len(done) != 1 || done[0] != "item-1" {
+		t.Errorf("expected only item-1 to be processed, got %v", done)
+	}
+}
+
+func TestParseScratchpadSignal_CustomSignals(t *testing.T) {
+	signals := customSignals(t)
+
+	text := "DEX_NOTES:\nSCRATCHPAD: is quoted in the docs\nDEX_EVENT:implementation.done"
+	got, found := parseScratchpadSignal(text, signals)
+	if !found {
+		t.Fatal("expected scratchpad to be found")
+	}
+	if got != "SCRATCHPAD: is quoted in the docs" {
+		t.Errorf("unexpected scratchpad %q", got)
+	}
+}
+
+func TestPromptLoader_SetSignalsRewritesPrompts(t *testing.T) {
+	loader := NewPromptLoader(t.TempDir() + "/missing")
+	if err := loader.SetSignals(customSignals(t)); err != nil {
+		t.Fatalf("SetSignals failed: %v", err)
+	}
+
+	prompt, err := loader.Get("creator", nil)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if strings.Contains(prompt, "EVENT:") && !strings.Contains(prompt, "DEX_EVENT:") {
+		t.Error("expected prompt to use the custom event marker")
+	}
+	if strings.Contains(strings.ReplaceAll(prompt, "DEX_EVENT:", ""), "EVENT:") {
+		t.Error("expected no default event markers left in the prompt")
+	}
+}
+
+func TestSignalsInCodeAreIgnored(t *testing.T) {
+	response := "Here's the doc:\n```markdown\nEmit CHECKLIST_DONE:item-9 and EVENT:task.complete when done.\n```\n" +
+		"Inline: `CHECKLIST_DONE:item-8`\nCHECKLIST_DONE:item-1\n"
+
+	if got := findAllSignals(response, SignalChecklistDone); len(got) != 1 || got[0] != "item-1" {
+		t.Errorf("findAllSignals() = %v, want [item-1]", got)
+	}
+	if event, found := ParseEvent(response, "s1", "creator"); found {
+		t.Errorf("expected no event outside code, got %s", event.Topic)
+	}
+
+	var done []string
+	detector := NewStreamingSignalDetector(
+		func(itemID string) { done = append(done, itemID) },
+		func(itemID, reason string) {},
+	)
+	for _, chunk := range strings.SplitAfter(response, "\n") {
+		detector.ProcessDelta(chunk)
+	}
+	if len(done) != 1 || done[0] != "item-1" {
+		t.Errorf("detector processed %v, want [item-1]", done)
+	}
+}
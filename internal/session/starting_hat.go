@@ -0,0 +1,41 @@
+package session
+
+import (
+	"strings"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// Description lengths, in characters, at which a task counts as involved
+// enough to explore the codebase first, or to plan before anything else
+const (
+	ExploreDescriptionLength = 400
+	PlanDescriptionLength    = 1500
+)
+
+// StartingHatInput is what SelectStartingHat judges a task by
+type StartingHatInput struct {
+	TaskType    string
+	Description string
+	HasPlan     bool // Planning produced a refined prompt for the task
+}
+
+// SelectStartingHat picks the hat a task's first session starts in and says
+// why. A task that was already planned goes straight to creator; an epic or a
+// long description starts with planner; a feature described at some length
+// starts with explorer; anything else, such as a short bug fix, starts with creator.
+func SelectStartingHat(in StartingHatInput) (hat, reason string) {
+	length := len(strings.TrimSpace(in.Description))
+	switch {
+	case in.HasPlan:
+		return "creator", "planning produced a plan"
+	case in.TaskType == db.TaskTypeEpic:
+		return "planner", "epics are broken down first"
+	case length >= PlanDescriptionLength:
+		return "planner", "long description"
+	case in.TaskType == db.TaskTypeFeature && length >= ExploreDescriptionLength:
+		return "explorer", "feature needs exploration"
+	default:
+		return "creator", "small task"
+	}
+}
@@ -0,0 +1,32 @@
+package session
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestSelectStartingHat(t *testing.T) {
+	tests := []struct {
+		name string
+		in   StartingHatInput
+		want string
+	}{
+		{"short bug", StartingHatInput{TaskType: db.TaskTypeBug, Description: "Fix the typo"}, "creator"},
+		{"short feature", StartingHatInput{TaskType: db.TaskTypeFeature, Description: "Add a flag"}, "creator"},
+		{"described feature", StartingHatInput{TaskType: db.TaskTypeFeature, Description: strings.Repeat("x", ExploreDescriptionLength)}, "explorer"},
+		{"described chore", StartingHatInput{TaskType: db.TaskTypeChore, Description: strings.Repeat("x", ExploreDescriptionLength)}, "creator"},
+		{"long bug", StartingHatInput{TaskType: db.TaskTypeBug, Description: strings.Repeat("x", PlanDescriptionLength)}, "planner"},
+		{"epic", StartingHatInput{TaskType: db.TaskTypeEpic}, "planner"},
+		{"planned epic", StartingHatInput{TaskType: db.TaskTypeEpic, HasPlan: true}, "creator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, reason := SelectStartingHat(tt.in); got != tt.want {
+				t.Errorf("SelectStartingHat() = %s (%s), want %s", got, reason, tt.want)
+			}
+		})
+	}
+}
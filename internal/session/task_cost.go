@@ -0,0 +1,43 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTaskCostCeiling is returned when a task has spent its lifetime cost
+// ceiling and may not start another session
+var ErrTaskCostCeiling = errors.New("task cost ceiling reached")
+
+// SetMaxTaskCost configures the most a task may spend in dollars across all
+// its sessions, hats, and retries (0 or less removes the ceiling). Unlike a
+// session's dollar budget, it's checked before each new session starts.
+func (m *Manager) SetMaxTaskCost(dollars float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxTaskCost = max(dollars, 0)
+}
+
+// CheckTaskCost returns ErrTaskCostCeiling if the task has spent its cost
+// ceiling, so callers can refuse to start it before setting it up
+func (m *Manager) CheckTaskCost(taskID string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.checkTaskCost(taskID)
+}
+
+// checkTaskCost returns ErrTaskCostCeiling if the task has spent its cost
+// ceiling. Callers hold m.mu.
+func (m *Manager) checkTaskCost(taskID string) error {
+	if m.maxTaskCost <= 0 {
+		return nil
+	}
+	spent, err := m.db.GetTaskCost(taskID)
+	if err != nil {
+		return err
+	}
+	if spent >= m.maxTaskCost {
+		return fmt.Errorf("%w: spent $%.2f of $%.2f", ErrTaskCostCeiling, spent, m.maxTaskCost)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestCreateSession_TaskCostCeiling(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "dex.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := database.CreateTask(project.ID, "Build", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(database, nil, t.TempDir())
+	m.SetMaxTaskCost(5)
+
+	sess, err := m.CreateSession(task.ID, "creator", "/tmp/wt")
+	if err != nil {
+		t.Fatalf("first session: %v", err)
+	}
+	// $3 input + $1.50 output at the default rates, under the $5 ceiling
+	input, output := 1_000_000, 100_000
+	if _, err := database.CreateSessionActivity(sess.ID, 1, db.ActivityTypeAssistantResponse, "creator", "", &input, &output); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	delete(m.byTask, task.ID)
+	m.mu.Unlock()
+
+	critic, err := m.CreateSession(task.ID, "critic", "/tmp/wt")
+	if err != nil {
+		t.Fatalf("session under the ceiling: %v", err)
+	}
+	// Another $1.50 puts the task over it
+	input, output = 0, 100_000
+	if _, err := database.CreateSessionActivity(critic.ID, 1, db.ActivityTypeAssistantResponse, "critic", "", &input, &output); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	delete(m.byTask, task.ID)
+	m.mu.Unlock()
+
+	if _, err := m.CreateSession(task.ID, "editor", "/tmp/wt"); !errors.Is(err, ErrTaskCostCeiling) {
+		t.Fatalf("expected ErrTaskCostCeiling once the task spent $6, got %v", err)
+	}
+	if err := m.CheckTaskCost(task.ID); !errors.Is(err, ErrTaskCostCeiling) {
+		t.Errorf("CheckTaskCost = %v, want ErrTaskCostCeiling", err)
+	}
+
+	// No ceiling, no limit
+	m.SetMaxTaskCost(0)
+	if _, err := m.CreateSession(task.ID, "editor", "/tmp/wt"); err != nil {
+		t.Errorf("session without a ceiling: %v", err)
+	}
+}
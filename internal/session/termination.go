@@ -14,6 +14,9 @@ const (
 	TerminationMaxCost       TerminationReason = "max_cost"
 	TerminationMaxRuntime    TerminationReason = "max_runtime"
 
+	// Budget exceeded without a more specific limit (older sessions)
+	TerminationBudgetExceeded TerminationReason = "budget_exceeded"
+
 	// Quality gate exhaustion
 	TerminationQualityGateExhausted TerminationReason = "quality_gate_exhausted"
 
@@ -23,11 +26,71 @@ const (
 	TerminationValidationFailure   TerminationReason = "validation_failure"
 	TerminationRepetitionLoop      TerminationReason = "repetition_loop"
 
+	// Waiting on a human
+	TerminationAwaitingApproval TerminationReason = "awaiting_approval"
+
 	// External termination
 	TerminationUserStopped TerminationReason = "user_stopped"
+	TerminationPaused      TerminationReason = "paused"
 	TerminationError       TerminationReason = "error"
+
+	// LLM requests kept timing out after retries
+	TerminationRequestTimeout TerminationReason = "request_timeout"
+)
+
+// TerminationCategory groups termination reasons by what ended the session
+type TerminationCategory string
+
+const (
+	TerminationCategorySuccess TerminationCategory = "success" // Finished its work or handed off to another hat
+	TerminationCategoryBudget  TerminationCategory = "budget"  // Ran out of iterations, tokens, dollars, or time
+	TerminationCategoryBlocked TerminationCategory = "blocked" // Stuck or waiting on a human to continue
+	TerminationCategoryError   TerminationCategory = "error"   // Failed with an error
+	TerminationCategoryUser    TerminationCategory = "user"    // Stopped or paused by the user
 )
 
+// TerminationCategories lists every category, in the order the UI shows them
+var TerminationCategories = []TerminationCategory{
+	TerminationCategorySuccess,
+	TerminationCategoryBudget,
+	TerminationCategoryBlocked,
+	TerminationCategoryError,
+	TerminationCategoryUser,
+}
+
+// terminationReasons lists every known reason, so categories can be turned
+// back into the reasons they hold
+var terminationReasons = []TerminationReason{
+	TerminationCompleted, TerminationHatTransition,
+	TerminationMaxIterations, TerminationMaxTokens, TerminationMaxCost, TerminationMaxRuntime, TerminationBudgetExceeded,
+	TerminationQualityGateExhausted,
+	TerminationLoopThrashing, TerminationConsecutiveFailures, TerminationValidationFailure, TerminationRepetitionLoop,
+	TerminationAwaitingApproval,
+	TerminationUserStopped, TerminationPaused, TerminationError,
+	TerminationRequestTimeout,
+}
+
+// IsValid reports whether c is a known category
+func (c TerminationCategory) IsValid() bool {
+	for _, known := range TerminationCategories {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Reasons returns the known termination reasons in the category
+func (c TerminationCategory) Reasons() []TerminationReason {
+	var reasons []TerminationReason
+	for _, reason := range terminationReasons {
+		if reason.Category() == c {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
 // TerminationInfo provides detailed information about why a session ended
 type TerminationInfo struct {
 	Reason              TerminationReason `json:"reason"`
@@ -46,7 +109,7 @@ func (t TerminationReason) IsSuccess() bool {
 // IsExhaustion returns true if the termination was due to resource exhaustion
 func (t TerminationReason) IsExhaustion() bool {
 	switch t {
-	case TerminationMaxIterations, TerminationMaxTokens, TerminationMaxCost, TerminationMaxRuntime,
+	case TerminationMaxIterations, TerminationMaxTokens, TerminationMaxCost, TerminationMaxRuntime, TerminationBudgetExceeded,
 		TerminationQualityGateExhausted, TerminationLoopThrashing, TerminationConsecutiveFailures,
 		TerminationValidationFailure, TerminationRepetitionLoop:
 		return true
@@ -55,6 +118,28 @@ func (t TerminationReason) IsExhaustion() bool {
 	}
 }
 
+// Category returns the category the reason falls in. Reasons this version
+// doesn't know are errors; an empty reason (a session that hasn't ended) has
+// no category.
+func (t TerminationReason) Category() TerminationCategory {
+	switch t {
+	case "":
+		return ""
+	case TerminationCompleted, TerminationHatTransition:
+		return TerminationCategorySuccess
+	case TerminationMaxIterations, TerminationMaxTokens, TerminationMaxCost, TerminationMaxRuntime,
+		TerminationBudgetExceeded:
+		return TerminationCategoryBudget
+	case TerminationQualityGateExhausted, TerminationLoopThrashing, TerminationConsecutiveFailures,
+		TerminationValidationFailure, TerminationRepetitionLoop, TerminationAwaitingApproval:
+		return TerminationCategoryBlocked
+	case TerminationUserStopped, TerminationPaused:
+		return TerminationCategoryUser
+	default:
+		return TerminationCategoryError
+	}
+}
+
 // String returns a human-readable description of the termination reason
 func (t TerminationReason) String() string {
 	switch t {
@@ -70,6 +155,8 @@ func (t TerminationReason) String() string {
 		return "Cost budget exhausted"
 	case TerminationMaxRuntime:
 		return "Maximum runtime exceeded"
+	case TerminationBudgetExceeded:
+		return "Budget exceeded"
 	case TerminationQualityGateExhausted:
 		return "Quality gate attempts exhausted"
 	case TerminationLoopThrashing:
@@ -80,10 +167,16 @@ func (t TerminationReason) String() string {
 		return "Too many validation failures"
 	case TerminationRepetitionLoop:
 		return "Tool repetition loop detected"
+	case TerminationAwaitingApproval:
+		return "Completion awaiting human approval"
 	case TerminationUserStopped:
 		return "Stopped by user"
+	case TerminationPaused:
+		return "Paused by user"
 	case TerminationError:
 		return "Error occurred"
+	case TerminationRequestTimeout:
+		return "LLM requests timed out"
 	default:
 		return string(t)
 	}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTerminationReason_Category(t *testing.T) {
+	tests := []struct {
+		reason TerminationReason
+		want   TerminationCategory
+	}{
+		{TerminationCompleted, TerminationCategorySuccess},
+		{TerminationHatTransition, TerminationCategorySuccess},
+		{TerminationMaxCost, TerminationCategoryBudget},
+		{TerminationBudgetExceeded, TerminationCategoryBudget},
+		{TerminationAwaitingApproval, TerminationCategoryBlocked},
+		{TerminationRepetitionLoop, TerminationCategoryBlocked},
+		{TerminationRequestTimeout, TerminationCategoryError},
+		{TerminationPaused, TerminationCategoryUser},
+		{"something_new", TerminationCategoryError},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.reason.Category(); got != tt.want {
+			t.Errorf("%q.Category() = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestTerminationCategory_Reasons(t *testing.T) {
+	seen := 0
+	for _, category := range TerminationCategories {
+		if !category.IsValid() {
+			t.Errorf("%q is not valid", category)
+		}
+		reasons := category.Reasons()
+		if len(reasons) == 0 {
+			t.Errorf("%q has no reasons", category)
+		}
+		for _, reason := range reasons {
+			if reason.Category() != category {
+				t.Errorf("%q listed under %q but categorized as %q", reason, category, reason.Category())
+			}
+			if reason.String() == string(reason) {
+				t.Errorf("%q has no explanation", reason)
+			}
+		}
+		seen += len(reasons)
+	}
+	if seen != len(terminationReasons) {
+		t.Errorf("categories hold %d reasons, want all %d", seen, len(terminationReasons))
+	}
+	if !slices.Contains(TerminationCategoryBudget.Reasons(), TerminationMaxTokens) {
+		t.Error("budget reasons should include max_tokens")
+	}
+	if TerminationCategory("other").IsValid() {
+		t.Error("unknown category should not be valid")
+	}
+}
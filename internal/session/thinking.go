@@ -0,0 +1,22 @@
+package session
+
+// DefaultThinkingBudget is the extended thinking budget, in tokens, for hats
+// that think by default
+const DefaultThinkingBudget = 8000
+
+// hatThinkingBudgets are the hats that get extended thinking unless the task
+// sets its own budget. Hats that mostly write code or run tools leave it off.
+var hatThinkingBudgets = map[string]int{
+	"planner":  DefaultThinkingBudget,
+	"designer": DefaultThinkingBudget,
+	"critic":   DefaultThinkingBudget,
+}
+
+// ThinkingBudget returns the extended thinking budget for a hat: the task's
+// budget when it sets one (0 turns thinking off), else the hat's default
+func ThinkingBudget(hat string, taskBudget *int) int {
+	if taskBudget != nil {
+		return *taskBudget
+	}
+	return hatThinkingBudgets[hat]
+}
@@ -0,0 +1,24 @@
+package session
+
+import "testing"
+
+func TestThinkingBudget(t *testing.T) {
+	off, custom := 0, 16000
+
+	tests := []struct {
+		hat        string
+		taskBudget *int
+		want       int
+	}{
+		{"planner", nil, DefaultThinkingBudget},
+		{"critic", nil, DefaultThinkingBudget},
+		{"creator", nil, 0},
+		{"planner", &off, 0},
+		{"creator", &custom, custom},
+	}
+	for _, tt := range tests {
+		if got := ThinkingBudget(tt.hat, tt.taskBudget); got != tt.want {
+			t.Errorf("ThinkingBudget(%s) = %d, want %d", tt.hat, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package session provides session lifecycle management for Poindexter
+package session
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// SlowToolThreshold is the duration above which a tool call is reported as slow
+	SlowToolThreshold = 30 * time.Second
+
+	// maxToolDurationSamples bounds the samples kept per tool for percentiles
+	maxToolDurationSamples = 1000
+
+	// maxTrackedSessions bounds how many sessions keep a per-session breakdown
+	maxTrackedSessions = 200
+)
+
+// ToolStats is a snapshot of the metrics for a single tool
+type ToolStats struct {
+	Tool         string  `json:"tool"`
+	Count        int     `json:"count"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	SlowCount    int     `json:"slow_count"`
+	TotalMs      int64   `json:"total_ms"`
+	P50Ms        int64   `json:"p50_ms"`
+	P95Ms        int64   `json:"p95_ms"`
+	MaxMs        int64   `json:"max_ms"`
+	LastCalledAt string  `json:"last_called_at,omitempty"`
+}
+
+// toolAccumulator accumulates durations and outcomes for one tool
+type toolAccumulator struct {
+	count      int
+	errors     int
+	slow       int
+	total      time.Duration
+	max        time.Duration
+	samples    []time.Duration // Ring buffer of the most recent durations
+	next       int
+	lastCalled time.Time
+}
+
+func (a *toolAccumulator) record(d time.Duration, isError bool, at time.Time) {
+	a.count++
+	if isError {
+		a.errors++
+	}
+	if d >= SlowToolThreshold {
+		a.slow++
+	}
+	a.total += d
+	if d > a.max {
+		a.max = d
+	}
+	a.lastCalled = at
+
+	if len(a.samples) < maxToolDurationSamples {
+		a.samples = append(a.samples, d)
+	} else {
+		a.samples[a.next] = d
+		a.next = (a.next + 1) % maxToolDurationSamples
+	}
+}
+
+func (a *toolAccumulator) stats(tool string) ToolStats {
+	sorted := slices.Clone(a.samples)
+	slices.Sort(sorted)
+
+	s := ToolStats{
+		Tool:      tool,
+		Count:     a.count,
+		Errors:    a.errors,
+		SlowCount: a.slow,
+		TotalMs:   a.total.Milliseconds(),
+		P50Ms:     percentile(sorted, 0.50).Milliseconds(),
+		P95Ms:     percentile(sorted, 0.95).Milliseconds(),
+		MaxMs:     a.max.Milliseconds(),
+	}
+	if a.count > 0 {
+		s.ErrorRate = float64(a.errors) / float64(a.count)
+	}
+	if !a.lastCalled.IsZero() {
+		s.LastCalledAt = a.lastCalled.UTC().Format(time.RFC3339)
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+	return sorted[idx]
+}
+
+// ToolMetrics aggregates tool call metrics globally and per session.
+// It is safe for concurrent use.
+type ToolMetrics struct {
+	mu           sync.Mutex
+	global       map[string]*toolAccumulator
+	sessions     map[string]map[string]*toolAccumulator // sessionID -> tool -> accumulator
+	sessionOrder []string                               // Insertion order for eviction
+}
+
+// NewToolMetrics creates an empty metrics aggregator
+func NewToolMetrics() *ToolMetrics {
+	return &ToolMetrics{
+		global:   make(map[string]*toolAccumulator),
+		sessions: make(map[string]map[string]*toolAccumulator),
+	}
+}
+
+// Record records a single tool call. Returns true if the call was slow.
+func (m *ToolMetrics) Record(sessionID, tool string, duration time.Duration, isError bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	acc := m.global[tool]
+	if acc == nil {
+		acc = &toolAccumulator{}
+		m.global[tool] = acc
+	}
+	acc.record(duration, isError, now)
+
+	if sessionID != "" {
+		byTool := m.sessions[sessionID]
+		if byTool == nil {
+			byTool = make(map[string]*toolAccumulator)
+			m.sessions[sessionID] = byTool
+			m.sessionOrder = append(m.sessionOrder, sessionID)
+			if len(m.sessionOrder) > maxTrackedSessions {
+				delete(m.sessions, m.sessionOrder[0])
+				m.sessionOrder = m.sessionOrder[1:]
+			}
+		}
+		sacc := byTool[tool]
+		if sacc == nil {
+			sacc = &toolAccumulator{}
+			byTool[tool] = sacc
+		}
+		sacc.record(duration, isError, now)
+	}
+
+	return duration >= SlowToolThreshold
+}
+
+// Snapshot returns metrics for all tools, slowest total time first
+func (m *ToolMetrics) Snapshot() []ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return snapshotAccumulators(m.global)
+}
+
+// SessionSnapshot returns the per-tool breakdown for a session.
+// Returns nil if no tool calls were recorded for the session.
+func (m *ToolMetrics) SessionSnapshot(sessionID string) []ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byTool, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	return snapshotAccumulators(byTool)
+}
+
+func snapshotAccumulators(accs map[string]*toolAccumulator) []ToolStats {
+	stats := make([]ToolStats, 0, len(accs))
+	for tool, acc := range accs {
+		stats = append(stats, acc.stats(tool))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalMs != stats[j].TotalMs {
+			return stats[i].TotalMs > stats[j].TotalMs
+		}
+		return stats[i].Tool < stats[j].Tool
+	})
+	return stats
+}
@@ -0,0 +1,102 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToolMetrics_RecordAndSnapshot(t *testing.T) {
+	m := NewToolMetrics()
+
+	for i := 1; i <= 20; i++ {
+		m.Record("sess-1", "run_tests", time.Duration(i)*time.Second, i%4 == 0)
+	}
+	m.Record("sess-1", "read_file", 10*time.Millisecond, false)
+	m.Record("sess-2", "read_file", 30*time.Millisecond, false)
+
+	stats := m.Snapshot()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(stats))
+	}
+
+	// Sorted by total time: run_tests dominates
+	runTests := stats[0]
+	if runTests.Tool != "run_tests" {
+		t.Fatalf("expected run_tests first, got %s", runTests.Tool)
+	}
+	if runTests.Count != 20 {
+		t.Errorf("Count = %d, want 20", runTests.Count)
+	}
+	if runTests.Errors != 5 {
+		t.Errorf("Errors = %d, want 5", runTests.Errors)
+	}
+	if runTests.ErrorRate != 0.25 {
+		t.Errorf("ErrorRate = %v, want 0.25", runTests.ErrorRate)
+	}
+	if runTests.P50Ms != 10_000 {
+		t.Errorf("P50Ms = %d, want 10000", runTests.P50Ms)
+	}
+	if runTests.P95Ms != 19_000 {
+		t.Errorf("P95Ms = %d, want 19000", runTests.P95Ms)
+	}
+	if runTests.MaxMs != 20_000 {
+		t.Errorf("MaxMs = %d, want 20000", runTests.MaxMs)
+	}
+
+	readFile := stats[1]
+	if readFile.Count != 2 || readFile.TotalMs != 40 {
+		t.Errorf("read_file stats = %+v, want count 2 total 40ms", readFile)
+	}
+}
+
+func TestToolMetrics_SessionSnapshot(t *testing.T) {
+	m := NewToolMetrics()
+	m.Record("sess-1", "read_file", 10*time.Millisecond, false)
+	m.Record("sess-2", "write_file", 20*time.Millisecond, true)
+
+	s1 := m.SessionSnapshot("sess-1")
+	if len(s1) != 1 || s1[0].Tool != "read_file" {
+		t.Errorf("sess-1 breakdown = %+v, want only read_file", s1)
+	}
+
+	s2 := m.SessionSnapshot("sess-2")
+	if len(s2) != 1 || s2[0].Errors != 1 {
+		t.Errorf("sess-2 breakdown = %+v, want one errored write_file", s2)
+	}
+
+	if m.SessionSnapshot("unknown") != nil {
+		t.Error("expected nil for unknown session")
+	}
+}
+
+func TestToolMetrics_SlowCalls(t *testing.T) {
+	m := NewToolMetrics()
+
+	if m.Record("sess-1", "run_tests", SlowToolThreshold-time.Millisecond, false) {
+		t.Error("call under threshold should not be slow")
+	}
+	if !m.Record("sess-1", "run_tests", SlowToolThreshold, false) {
+		t.Error("call at threshold should be slow")
+	}
+
+	if got := m.Snapshot()[0].SlowCount; got != 1 {
+		t.Errorf("SlowCount = %d, want 1", got)
+	}
+}
+
+func TestToolMetrics_EvictsOldestSession(t *testing.T) {
+	m := NewToolMetrics()
+	for i := 0; i <= maxTrackedSessions; i++ {
+		m.Record(time.Duration(i).String(), "read_file", time.Millisecond, false)
+	}
+
+	if m.SessionSnapshot(time.Duration(0).String()) != nil {
+		t.Error("oldest session should have been evicted")
+	}
+	if m.SessionSnapshot(time.Duration(maxTrackedSessions).String()) == nil {
+		t.Error("newest session should be tracked")
+	}
+	if got := m.Snapshot()[0].Count; got != maxTrackedSessions+1 {
+		t.Errorf("global count = %d, want %d", got, maxTrackedSessions+1)
+	}
+}
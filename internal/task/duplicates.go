@@ -0,0 +1,105 @@
+package task
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// DuplicateThreshold is the similarity at or above which an open task is
+// reported as a likely duplicate of a new one
+const DuplicateThreshold = 0.7
+
+// duplicateCandidateLimit caps how many search matches are scored
+const duplicateCandidateLimit = 20
+
+// Duplicate is an open task that looks like the same work as a new one
+type Duplicate struct {
+	Task       *db.Task
+	Similarity float64 // 0-1, at least DuplicateThreshold
+}
+
+// stopWords are left out when comparing task text, since they say nothing
+// about the work
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "into": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "so": true, "that": true,
+	"the": true, "this": true, "to": true, "with": true,
+}
+
+// FindDuplicates returns the project's open tasks whose title and description
+// are similar enough to the given ones to likely be the same work, most
+// similar first. Candidates come from the search index and are scored by the
+// overlap of their normalized words.
+func (s *Service) FindDuplicates(projectID, title, description string) ([]Duplicate, error) {
+	candidates, err := s.db.SimilarOpenTasks(projectID, strings.Join(normalizedWords(title), " "), duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []Duplicate
+	for _, candidate := range candidates {
+		similarity := taskSimilarity(title, description, candidate.Title, candidate.GetDescription())
+		if similarity >= DuplicateThreshold {
+			duplicates = append(duplicates, Duplicate{Task: candidate, Similarity: similarity})
+		}
+	}
+	slices.SortStableFunc(duplicates, func(a, b Duplicate) int {
+		switch {
+		case a.Similarity > b.Similarity:
+			return -1
+		case a.Similarity < b.Similarity:
+			return 1
+		}
+		return 0
+	})
+	return duplicates, nil
+}
+
+// taskSimilarity scores how alike two tasks are from 0 to 1. Titles decide
+// it, unless both tasks have descriptions, which then count for 40%.
+func taskSimilarity(titleA, descriptionA, titleB, descriptionB string) float64 {
+	similarity := jaccard(normalizedWords(titleA), normalizedWords(titleB))
+	wordsA, wordsB := normalizedWords(descriptionA), normalizedWords(descriptionB)
+	if len(wordsA) > 0 && len(wordsB) > 0 {
+		similarity = 0.6*similarity + 0.4*jaccard(wordsA, wordsB)
+	}
+	return similarity
+}
+
+// normalizedWords returns the distinct words of text, lowercased, without
+// punctuation, stop words, or a plural "s"
+func normalizedWords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if stopWords[word] {
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		if !slices.Contains(words, word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// jaccard is the share of the words in either set that are in both
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, word := range a {
+		if slices.Contains(b, word) {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,56 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func TestTaskSimilarity(t *testing.T) {
+	if got := taskSimilarity("Add dark mode to the settings page", "", "add dark-mode: Settings pages", ""); got != 1 {
+		t.Errorf("reworded title similarity = %v, want 1", got)
+	}
+	if got := taskSimilarity("Add dark mode", "", "Fix login redirect", ""); got != 0 {
+		t.Errorf("unrelated title similarity = %v, want 0", got)
+	}
+	// Same title, different work described
+	if got := taskSimilarity("Fix flaky test", "TestUpload times out in CI", "Fix flaky test", "TestLogin races on the session cookie"); got >= DuplicateThreshold {
+		t.Errorf("different descriptions similarity = %v, want below %v", got, DuplicateThreshold)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	svc, database := setupTestService(t)
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := svc.Create(project.ID, "Add dark mode to settings", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := svc.Create(project.ID, "Add dark mode to settings page", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateTaskStatus(done.ID, db.TaskStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(project.ID, "Add dark mode to the docs site", db.TaskTypeTask, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates, err := svc.FindDuplicates(project.ID, "Add a dark mode to Settings", "")
+	if err != nil {
+		t.Fatalf("FindDuplicates() error = %v", err)
+	}
+	if len(duplicates) != 1 || duplicates[0].Task.ID != open.ID {
+		t.Fatalf("FindDuplicates() = %+v, want only the open settings task", duplicates)
+	}
+
+	duplicates, err = svc.FindDuplicates(project.ID, "Fix login redirect", "")
+	if err != nil || len(duplicates) != 0 {
+		t.Errorf("FindDuplicates(unrelated) = %+v, %v", duplicates, err)
+	}
+}
@@ -0,0 +1,49 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTaskBusy is returned when another lifecycle operation on the task is in progress
+var ErrTaskBusy = errors.New("another operation on this task is in progress")
+
+// lifecycleLocks lets one lifecycle operation (start, resume, pause, cancel)
+// at a time proceed per task. Contended operations fail instead of waiting,
+// since the one in progress usually makes them moot.
+type lifecycleLocks struct {
+	mu   sync.Mutex
+	held map[string]string // Task ID -> operation holding it
+}
+
+// tryLock claims the task for op, returning the operation already holding it on failure
+func (l *lifecycleLocks) tryLock(taskID, op string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if holder, ok := l.held[taskID]; ok {
+		return holder, false
+	}
+	if l.held == nil {
+		l.held = make(map[string]string)
+	}
+	l.held[taskID] = op
+	return "", true
+}
+
+func (l *lifecycleLocks) unlock(taskID string) {
+	l.mu.Lock()
+	delete(l.held, taskID)
+	l.mu.Unlock()
+}
+
+// LockLifecycle claims the task for a lifecycle operation such as "start" or
+// "cancel". It returns ErrTaskBusy if another operation holds it; otherwise
+// the caller must call the returned unlock when the operation is done.
+func (s *Service) LockLifecycle(taskID, op string) (func(), error) {
+	if holder, ok := s.locks.tryLock(taskID, op); !ok {
+		return nil, fmt.Errorf("cannot %s task %s: %w (%s)", op, taskID, ErrTaskBusy, holder)
+	}
+	var once sync.Once
+	return func() { once.Do(func() { s.locks.unlock(taskID) }) }, nil
+}
@@ -2,6 +2,7 @@
 package task
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/lirancohen/dex/internal/content"
@@ -12,6 +13,7 @@ import (
 type Service struct {
 	db           *db.DB
 	stateMachine *StateMachine
+	locks        lifecycleLocks
 }
 
 // NewService creates a new task service
@@ -30,8 +32,15 @@ func NewServiceWithStateMachine(database *db.DB, sm *StateMachine) *Service {
 	}
 }
 
-// Create creates a new task with default values
+// Create creates a new task with default values, inheriting the project's
+// default model and autonomy level
 func (s *Service) Create(projectID, title, taskType string, priority int) (*db.Task, error) {
+	return s.CreateWithOptions(projectID, title, taskType, priority, CreateOptions{})
+}
+
+// CreateWithOptions creates a new task. Settings not given in opts are
+// inherited from the project's defaults.
+func (s *Service) CreateWithOptions(projectID, title, taskType string, priority int, opts CreateOptions) (*db.Task, error) {
 	// Validate inputs
 	if projectID == "" {
 		return nil, fmt.Errorf("project ID is required")
@@ -45,8 +54,36 @@ func (s *Service) Create(projectID, title, taskType string, priority int) (*db.T
 	if !IsValidTaskType(taskType) {
 		taskType = db.TaskTypeTask // Default to generic task
 	}
+	if opts.Model != "" && !IsValidModel(opts.Model) {
+		return nil, fmt.Errorf("invalid model %q (must be sonnet or opus)", opts.Model)
+	}
+	if opts.AutonomyLevel != nil && !IsValidAutonomyLevel(*opts.AutonomyLevel) {
+		return nil, fmt.Errorf("invalid autonomy level %d (must be 0-%d)", *opts.AutonomyLevel, MaxAutonomyLevel)
+	}
+
+	model, autonomy := opts.Model, DefaultAutonomyLevel
+	if opts.AutonomyLevel != nil {
+		autonomy = *opts.AutonomyLevel
+	}
+
+	if model == "" {
+		defaultModel, err := s.db.GetProjectDefaultModel(projectID)
+		if err != nil {
+			return nil, err
+		}
+		model = defaultModel
+	}
+	if opts.AutonomyLevel == nil {
+		defaultAutonomy, err := s.db.GetProjectDefaultAutonomy(projectID)
+		if err != nil {
+			return nil, err
+		}
+		if defaultAutonomy != nil {
+			autonomy = *defaultAutonomy
+		}
+	}
 
-	return s.db.CreateTask(projectID, title, taskType, priority)
+	return s.db.CreateTaskWithSettings(projectID, title, taskType, priority, model, autonomy)
 }
 
 // Get retrieves a task by ID
@@ -63,6 +100,12 @@ func (s *Service) Get(id string) (*db.Task, error) {
 
 // List returns tasks with optional filters
 func (s *Service) List(filters ListFilters) ([]*db.Task, error) {
+	if filters.BlockedReason != "" {
+		return s.listByBlockedReason(filters)
+	}
+	if len(filters.Tags) > 0 {
+		return s.db.ListTasksByTags(filters.Tags, filters.ProjectID, filters.Status)
+	}
 	if filters.ProjectID != "" {
 		return s.db.ListTasksByProject(filters.ProjectID)
 	}
@@ -73,6 +116,56 @@ func (s *Service) List(filters ListFilters) ([]*db.Task, error) {
 	return s.db.ListAllTasks()
 }
 
+// listByBlockedReason lists tasks blocked in the filtered category, narrowed by tags if given
+func (s *Service) listByBlockedReason(filters ListFilters) ([]*db.Task, error) {
+	tasks, err := s.db.ListTasksByBlockedReason(filters.BlockedReason, filters.ProjectID, filters.Status)
+	if err != nil || len(filters.Tags) == 0 {
+		return tasks, err
+	}
+
+	tagged, err := s.db.ListTasksByTags(filters.Tags, filters.ProjectID, filters.Status)
+	if err != nil {
+		return nil, err
+	}
+	hasTags := make(map[string]bool, len(tagged))
+	for _, t := range tagged {
+		hasTags[t.ID] = true
+	}
+
+	filtered := tasks[:0]
+	for _, t := range tasks {
+		if hasTags[t.ID] {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+// Clone creates a pending copy of a task for a re-run or variant
+func (s *Service) Clone(id string, opts db.CloneTaskOptions) (*db.Task, error) {
+	return s.db.CloneTask(id, opts)
+}
+
+// ErrTaskNotMovable is returned when a task can't move to another project
+// in its current status
+var ErrTaskNotMovable = errors.New("cannot move task")
+
+// Move reassigns a task to another project. Running and planning tasks can't
+// be moved; see db.MoveTask for what the move keeps and drops.
+func (s *Service) Move(id, projectID string) (*db.Task, error) {
+	if projectID == "" {
+		return nil, fmt.Errorf("project ID is required")
+	}
+	t, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == db.TaskStatusRunning || t.Status == db.TaskStatusPlanning {
+		return nil, fmt.Errorf("%w: it is %s; pause or cancel it first", ErrTaskNotMovable, t.Status)
+	}
+	return s.db.MoveTask(id, projectID)
+}
+
 // UpdateStatus changes a task's status using the state machine for transition validation
 func (s *Service) UpdateStatus(id, status string) error {
 	return s.stateMachine.Transition(id, status)
@@ -91,6 +184,13 @@ func (s *Service) Update(id string, updates TaskUpdates) (*db.Task, error) {
 		return nil, err
 	}
 
+	// Validate before applying anything so a bad policy doesn't leave a partial update
+	if updates.CompletionPolicy != nil && updates.CompletionPolicy.Strictness != "" {
+		if err := updates.CompletionPolicy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply updates if provided
 	if updates.Status != nil && *updates.Status != "" {
 		if err := s.stateMachine.Transition(id, *updates.Status); err != nil {
@@ -102,6 +202,16 @@ func (s *Service) Update(id string, updates TaskUpdates) (*db.Task, error) {
 			return nil, err
 		}
 	}
+	if updates.CompletionPolicy != nil {
+		// An empty strictness clears the override so the task inherits the project's policy
+		policy := updates.CompletionPolicy
+		if policy.Strictness == "" {
+			policy = nil
+		}
+		if err := s.db.SetTaskCompletionPolicy(id, policy); err != nil {
+			return nil, err
+		}
+	}
 
 	// Fetch and return updated task
 	return s.Get(id)
@@ -114,6 +224,14 @@ type TaskUpdates struct {
 	Status      *string `json:"status,omitempty"`
 	Hat         *string `json:"hat,omitempty"`
 	Priority    *int    `json:"priority,omitempty"`
+
+	CompletionPolicy *db.CompletionPolicy `json:"completion_policy,omitempty"`
+}
+
+// CreateOptions holds optional task settings that override the project's defaults
+type CreateOptions struct {
+	Model         string // "" inherits the project's default model
+	AutonomyLevel *int   // nil inherits the project's default autonomy level
 }
 
 // ListFilters defines optional filters for listing tasks
@@ -121,6 +239,29 @@ type ListFilters struct {
 	ProjectID string
 	Status    string
 	Priority  int
+	Tags      []string // Tasks must have every tag; combines with ProjectID and Status
+	// Blocked reason category; combines with the other filters
+	BlockedReason string
+}
+
+// Autonomy levels range from 0 (every PR needs manual approval) to MaxAutonomyLevel
+const (
+	DefaultAutonomyLevel = 1
+	MaxAutonomyLevel     = 3
+
+	// PlanPreviewAutonomyLevel is the level at or below which a worker sends its
+	// plan for approval before running a task
+	PlanPreviewAutonomyLevel = 0
+)
+
+// IsValidModel checks if the task model is valid
+func IsValidModel(model string) bool {
+	return model == db.TaskModelSonnet || model == db.TaskModelOpus
+}
+
+// IsValidAutonomyLevel checks if the autonomy level is in range
+func IsValidAutonomyLevel(level int) bool {
+	return level >= 0 && level <= MaxAutonomyLevel
 }
 
 // IsValidTaskType checks if the task type is valid
@@ -143,6 +284,16 @@ func IsValidStatus(s string) bool {
 	return false
 }
 
+// IsTerminalStatus reports whether a task in this status is done and won't run again on its own
+func IsTerminalStatus(s string) bool {
+	switch s {
+	case db.TaskStatusCompleted, db.TaskStatusCancelled, db.TaskStatusTimedOut,
+		"failed": // Set when a worker fails the task's objective
+		return true
+	}
+	return false
+}
+
 // CreateTaskContentOptions holds options for creating task content files
 type CreateTaskContentOptions struct {
 	Title       string
@@ -0,0 +1,366 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func setupTestService(t *testing.T) (*Service, *db.DB) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(database), database
+}
+
+func TestCreate_InheritsProjectDefaults(t *testing.T) {
+	svc, database := setupTestService(t)
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without project defaults, tasks get the built-in defaults
+	task, err := svc.Create(project.ID, "Plain", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Model.Valid || task.AutonomyLevel != DefaultAutonomyLevel {
+		t.Errorf("got model=%v autonomy=%d, want no model and autonomy %d", task.Model, task.AutonomyLevel, DefaultAutonomyLevel)
+	}
+
+	if err := database.SetProjectDefaultModel(project.ID, db.TaskModelOpus); err != nil {
+		t.Fatal(err)
+	}
+	full := 3
+	if err := database.SetProjectDefaultAutonomy(project.ID, &full); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err = svc.Create(project.ID, "Inherited", db.TaskTypeTask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := database.GetTaskByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Model.String != db.TaskModelOpus || stored.AutonomyLevel != 3 {
+		t.Errorf("got model=%q autonomy=%d, want opus and 3", stored.Model.String, stored.AutonomyLevel)
+	}
+
+	// Per-task settings override the project's defaults, including autonomy 0
+	manual := 0
+	task, err = svc.CreateWithOptions(project.ID, "Override", db.TaskTypeTask, 3, CreateOptions{
+		Model:         db.TaskModelSonnet,
+		AutonomyLevel: &manual,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = database.GetTaskByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Model.String != db.TaskModelSonnet || stored.AutonomyLevel != 0 {
+		t.Errorf("got model=%q autonomy=%d, want sonnet and 0", stored.Model.String, stored.AutonomyLevel)
+	}
+}
+
+func TestCreateWithOptions_Validates(t *testing.T) {
+	svc, database := setupTestService(t)
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.CreateWithOptions(project.ID, "Bad model", db.TaskTypeTask, 3, CreateOptions{Model: "gpt"}); err == nil {
+		t.Error("expected error for invalid model")
+	}
+
+	tooHigh := MaxAutonomyLevel + 1
+	if _, err := svc.CreateWithOptions(project.ID, "Bad autonomy", db.TaskTypeTask, 3, CreateOptions{AutonomyLevel: &tooHigh}); err == nil {
+		t.Error("expected error for invalid autonomy level")
+	}
+}
+
+func TestClone(t *testing.T) {
+	svc, database := setupTestService(t)
+
+	project, err := database.CreateProject("Test", "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := svc.Create(project.ID, "Add billing page", db.TaskTypeFeature, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(
+		`UPDATE tasks SET description = ?, token_budget = ?, dollar_budget = ?, hat = ?, worktree_path = ?, status = ? WHERE id = ?`,
+		"Use Stripe Checkout", 50000, 2.5, "editor", "/worktrees/billing", db.TaskStatusCompleted, original.ID,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.CreateSession(original.ID, "creator", "/worktrees/billing"); err != nil {
+		t.Fatal(err)
+	}
+	checklist, err := database.CreateTaskChecklist(original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := database.CreateChecklistItem(checklist.ID, "Checkout works", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UpdateChecklistItemStatus(item.ID, db.ChecklistItemStatusDone, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	description := "Use Stripe Elements"
+	clone, err := svc.Clone(original.ID, db.CloneTaskOptions{Description: &description})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if clone.ID == original.ID || clone.Title != "Add billing page" || clone.Description.String != description {
+		t.Errorf("got title=%q description=%q", clone.Title, clone.Description.String)
+	}
+	if clone.Type != db.TaskTypeFeature || clone.Priority != 2 || clone.TokenBudget.Int64 != 50000 || clone.DollarBudget.Float64 != 2.5 {
+		t.Errorf("got %+v, want the original's type, priority, and budgets", clone)
+	}
+	// Starts where the original's first session did, without its worktree
+	if clone.Hat.String != "creator" || clone.WorktreePath.Valid || clone.Status != db.TaskStatusPending {
+		t.Errorf("got hat=%q worktree=%v status=%s", clone.Hat.String, clone.WorktreePath, clone.Status)
+	}
+	if from, _ := database.GetTaskClonedFrom(clone.ID); from != original.ID {
+		t.Errorf("got cloned_from %q, want %s", from, original.ID)
+	}
+
+	cloneChecklist, err := database.GetChecklistByTaskID(clone.ID)
+	if err != nil || cloneChecklist == nil {
+		t.Fatalf("got checklist %v, %v", cloneChecklist, err)
+	}
+	items, err := database.GetChecklistItems(cloneChecklist.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Description != "Checkout works" || items[0].Status != db.ChecklistItemStatusPending {
+		t.Errorf("got %+v, want the item reset to pending", items)
+	}
+
+	if clones, _ := database.ListTaskClones(original.ID); len(clones) != 1 || clones[0].ID != clone.ID {
+		t.Errorf("got clones %+v", clones)
+	}
+
+	// Deleting the original keeps the clone
+	if _, err := database.Exec(`DELETE FROM sessions WHERE task_id = ?`, original.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(original.ID); err != nil {
+		t.Fatal(err)
+	}
+	if from, err := database.GetTaskClonedFrom(clone.ID); err != nil || from != "" {
+		t.Errorf("got %q, %v after deleting the original, want no link", from, err)
+	}
+
+	if _, err := svc.Clone("task-missing", db.CloneTaskOptions{}); err == nil {
+		t.Error("expected an error cloning a missing task")
+	}
+}
+
+func TestMove(t *testing.T) {
+	svc, database := setupTestService(t)
+
+	from, err := database.CreateProject("Wrong", "/wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := database.CreateProject("Right", "/right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest, err := database.CreateQuest(from.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := database.CreateTaskForQuest(quest.ID, from.ID, "Fix login", "", "creator", db.TaskTypeBug, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(
+		`UPDATE tasks SET worktree_path = ?, branch_name = ?, pr_number = ?, status = ? WHERE id = ?`,
+		"/worktrees/login", "task/login", 7, db.TaskStatusPaused, moved.ID,
+	); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := database.CreateSession(moved.ID, "creator", "/worktrees/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.Move(moved.ID, to.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ProjectID != to.ID || got.QuestID.Valid || got.PRNumber.Valid {
+		t.Errorf("got project=%s quest=%v pr=%v", got.ProjectID, got.QuestID, got.PRNumber)
+	}
+	// The worktree belonged to the old repo, so the paused task can't resume from it
+	if got.WorktreePath.Valid || got.BranchName.Valid || !got.WorktreeCleanedAt.Valid || got.Status != db.TaskStatusPending {
+		t.Errorf("got worktree=%v branch=%v cleaned=%v status=%s", got.WorktreePath, got.BranchName, got.WorktreeCleanedAt, got.Status)
+	}
+	// History stays with the task
+	if s, _ := database.GetSessionByID(sess.ID); s == nil || s.TaskID != moved.ID {
+		t.Errorf("got session %+v after the move", s)
+	}
+
+	if err := database.UpdateTaskStatus(moved.ID, db.TaskStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Move(moved.ID, from.ID); !errors.Is(err, ErrTaskNotMovable) {
+		t.Errorf("moving a running task = %v, want ErrTaskNotMovable", err)
+	}
+	if _, err := svc.Move("task-missing", to.ID); err == nil {
+		t.Error("expected an error moving a missing task")
+	}
+	if _, err := database.MoveTask(moved.ID, "proj-missing"); err == nil {
+		t.Error("expected an error moving to a missing project")
+	}
+}
+
+func TestMove_RejectsLinksAcrossProjects(t *testing.T) {
+	svc, database := setupTestService(t)
+
+	from, err := database.CreateProject("Wrong", "/wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := database.CreateProject("Right", "/right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := func(title string) *db.Task {
+		t.Helper()
+		task, err := database.CreateTask(from.ID, title, db.TaskTypeTask, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	parent, child, blocker, blocked := create("Epic"), create("Step"), create("Schema"), create("API")
+
+	if _, err := database.Exec(`UPDATE tasks SET parent_id = ? WHERE id = ?`, parent.ID, child.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Move(parent.ID, to.ID); !errors.Is(err, db.ErrTaskLinkedAcrossProjects) {
+		t.Errorf("moving a task with subtasks = %v, want ErrTaskLinkedAcrossProjects", err)
+	}
+
+	if err := database.AddTaskDependency(blocker.ID, blocked.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{blocker.ID, blocked.ID} {
+		if _, err := svc.Move(id, to.ID); !errors.Is(err, db.ErrTaskLinkedAcrossProjects) {
+			t.Errorf("moving a task with a dependency = %v, want ErrTaskLinkedAcrossProjects", err)
+		}
+	}
+	if got, _ := database.GetTaskByID(blocked.ID); got.ProjectID != from.ID {
+		t.Errorf("rejected move changed the project to %s", got.ProjectID)
+	}
+
+	// A subtask may move; it just leaves its parent behind
+	moved, err := svc.Move(child.ID, to.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.ParentID.Valid {
+		t.Errorf("moved subtask kept parent %v in another project", moved.ParentID)
+	}
+}
+
+func TestLockLifecycle(t *testing.T) {
+	svc := NewService(nil)
+
+	unlock, err := svc.LockLifecycle("task-1", "start")
+	if err != nil {
+		t.Fatalf("LockLifecycle() error = %v", err)
+	}
+
+	if _, err := svc.LockLifecycle("task-1", "cancel"); !errors.Is(err, ErrTaskBusy) {
+		t.Fatalf("LockLifecycle() on a held task error = %v, want ErrTaskBusy", err)
+	}
+
+	// Other tasks aren't affected
+	unlockOther, err := svc.LockLifecycle("task-2", "cancel")
+	if err != nil {
+		t.Fatalf("LockLifecycle() on another task error = %v", err)
+	}
+	unlockOther()
+
+	unlock()
+	unlock() // Unlocking twice is harmless
+
+	unlock, err = svc.LockLifecycle("task-1", "resume")
+	if err != nil {
+		t.Fatalf("LockLifecycle() after unlock error = %v", err)
+	}
+	unlock()
+}
+
+func TestLockLifecycle_Concurrent(t *testing.T) {
+	svc := NewService(nil)
+
+	const callers = 32
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		release = make(chan struct{})
+		results = make(chan error, callers)
+	)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			unlock, err := svc.LockLifecycle("task-1", fmt.Sprintf("op-%d", i))
+			results <- err
+			if err == nil {
+				// Hold the lock until every caller has tried
+				<-release
+				unlock()
+			}
+		}()
+	}
+	close(start)
+
+	var locked, busy int
+	for range callers {
+		switch err := <-results; {
+		case err == nil:
+			locked++
+		case errors.Is(err, ErrTaskBusy):
+			busy++
+		default:
+			t.Errorf("LockLifecycle() error = %v, want nil or ErrTaskBusy", err)
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if locked != 1 || busy != callers-1 {
+		t.Errorf("locked = %d, busy = %d, want exactly one holder", locked, busy)
+	}
+}
@@ -17,8 +17,9 @@ const anthropicAPIBaseURL = "https://api.anthropic.com/v1"
 
 // AnthropicClient wraps the Anthropic API for Poindexter's AI/LLM needs.
 type AnthropicClient struct {
-	httpClient *http.Client
-	apiKey     string
+	httpClient  *http.Client
+	apiKey      string
+	rateLimiter *RateLimiter // Shared by every client using the same API key
 }
 
 // NewAnthropicClient creates a new AnthropicClient from configuration
@@ -31,7 +32,8 @@ func NewAnthropicClient(config *AnthropicConfig) *AnthropicClient {
 		httpClient: &http.Client{
 			Timeout: 5 * time.Minute, // Long timeout for large context LLM responses (200K tokens)
 		},
-		apiKey: config.APIKey,
+		apiKey:      config.APIKey,
+		rateLimiter: rateLimiterFor(config.APIKey),
 	}
 }
 
@@ -41,6 +43,37 @@ func (c *AnthropicClient) GetAPIKey() string {
 	return c.apiKey
 }
 
+// RateLimitStatus returns the org's rate limits as of the latest response
+func (c *AnthropicClient) RateLimitStatus() RateLimitStatus {
+	return c.rateLimiter.Status()
+}
+
+// send queues for a slot in the shared request gate and waits out the rate
+// limiter, then sends the request and records the rate-limit headers on the
+// response. The slot is held until the response body is closed. It returns
+// how long the request queued before it was sent.
+func (c *AnthropicClient) send(req *http.Request) (*http.Response, time.Duration, error) {
+	start := time.Now()
+	release, err := anthropicGate.Acquire(req.Context())
+	if err != nil {
+		return nil, time.Since(start), err
+	}
+	if err := c.rateLimiter.Wait(req.Context()); err != nil {
+		release()
+		return nil, time.Since(start), err
+	}
+	wait := time.Since(start)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		release()
+		return nil, wait, err
+	}
+	c.rateLimiter.Observe(resp)
+	resp.Body = &gatedBody{ReadCloser: resp.Body, release: release}
+	return resp, wait, nil
+}
+
 // doRequest performs an HTTP request to the Anthropic API
 func (c *AnthropicClient) doRequest(ctx context.Context, method, url string, body any) (*http.Response, error) {
 	var reqBody io.Reader
@@ -63,7 +96,8 @@ func (c *AnthropicClient) doRequest(ctx context.Context, method, url string, bod
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	return c.httpClient.Do(req)
+	resp, _, err := c.send(req)
+	return resp, err
 }
 
 // anthropicErrorResponse represents an Anthropic API error response
@@ -94,6 +128,21 @@ func (e *AnthropicAPIError) IsBillingError() bool {
 		contains(e.Message, "payment"))
 }
 
+// IsContextLengthError returns true if the request didn't fit in the model's context window
+func (e *AnthropicAPIError) IsContextLengthError() bool {
+	return e.StatusCode == 400 &&
+		(contains(e.Message, "prompt is too long") ||
+			contains(e.Message, "exceed context limit") ||
+			contains(e.Message, "context window") ||
+			contains(e.Message, "context length"))
+}
+
+// IsOverloadedError returns true if the model is over capacity (529, or an
+// overloaded_error event mid-stream)
+func (e *AnthropicAPIError) IsOverloadedError() bool {
+	return e.StatusCode == 529 || e.Type == "overloaded_error"
+}
+
 // IsRateLimitError returns true if this is a rate limit error
 func (e *AnthropicAPIError) IsRateLimitError() bool {
 	return e.StatusCode == 429
@@ -180,6 +229,25 @@ type AnthropicChatRequest struct {
 	Messages  []AnthropicMessage `json:"messages"`
 	System    string             `json:"system,omitempty"`
 	Tools     []AnthropicTool    `json:"tools,omitempty"`
+
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	// Extended thinking (nil leaves it off). MaxTokens must exceed the budget.
+	Thinking *AnthropicThinking `json:"thinking,omitempty"`
+}
+
+// AnthropicThinking enables extended thinking with a token budget
+type AnthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// NewAnthropicThinking returns a thinking config for budget, or nil if budget is 0 or less
+func NewAnthropicThinking(budget int) *AnthropicThinking {
+	if budget <= 0 {
+		return nil
+	}
+	return &AnthropicThinking{Type: "enabled", BudgetTokens: budget}
 }
 
 // AnthropicContentBlock represents a content block in a response
@@ -189,6 +257,10 @@ type AnthropicContentBlock struct {
 	ID    string         `json:"id,omitempty"`    // for tool_use
 	Name  string         `json:"name,omitempty"`  // for tool_use
 	Input map[string]any `json:"input,omitempty"` // for tool_use
+
+	Thinking  string `json:"thinking,omitempty"`  // for thinking
+	Signature string `json:"signature,omitempty"` // for thinking; must be sent back unchanged
+	Data      string `json:"data,omitempty"`      // for redacted_thinking
 }
 
 // MarshalJSON implements custom JSON marshaling to ensure tool_use blocks always have input field
@@ -213,7 +285,7 @@ func (b AnthropicContentBlock) MarshalJSON() ([]byte, error) {
 		})
 	}
 
-	// For other types (text), use default behavior
+	// For other types (text, thinking), use default behavior
 	type contentBlock AnthropicContentBlock
 	return json.Marshal(contentBlock(b))
 }
@@ -222,6 +294,10 @@ func (b AnthropicContentBlock) MarshalJSON() ([]byte, error) {
 type AnthropicUsage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+
+	// ThinkingTokens estimates how many of OutputTokens went to extended
+	// thinking. The API bills thinking as output without breaking it out.
+	ThinkingTokens int `json:"-"`
 }
 
 // AnthropicChatResponse represents a response from the messages API
@@ -234,6 +310,10 @@ type AnthropicChatResponse struct {
 	StopReason   string                  `json:"stop_reason"`
 	StopSequence *string                 `json:"stop_sequence,omitempty"`
 	Usage        AnthropicUsage          `json:"usage"`
+
+	// QueueWait is how long the request queued behind the shared request gate
+	// and rate limiter before it was sent (set by ChatWithStreaming)
+	QueueWait time.Duration `json:"-"`
 }
 
 // Text returns the text content from the response
@@ -246,6 +326,21 @@ func (r *AnthropicChatResponse) Text() string {
 	return ""
 }
 
+// estimateThinkingTokens fills in Usage.ThinkingTokens from the thinking blocks
+// (~4 chars per token, capped at the output tokens)
+func (r *AnthropicChatResponse) estimateThinkingTokens() {
+	chars := 0
+	for _, block := range r.Content {
+		switch block.Type {
+		case "thinking":
+			chars += len(block.Thinking)
+		case "redacted_thinking":
+			chars += len(block.Data)
+		}
+	}
+	r.Usage.ThinkingTokens = min(chars/4, r.Usage.OutputTokens)
+}
+
 // HasToolUse returns true if the response contains tool_use blocks
 // Checks both stop_reason and actual content blocks (handles max_tokens truncation)
 func (r *AnthropicChatResponse) HasToolUse() bool {
@@ -327,7 +422,12 @@ func (c *AnthropicClient) Chat(ctx context.Context, req *AnthropicChatRequest) (
 		return nil, fmt.Errorf("failed to chat: %w", err)
 	}
 
-	return parseAnthropicResponse[AnthropicChatResponse](resp)
+	result, err := parseAnthropicResponse[AnthropicChatResponse](resp)
+	if err != nil {
+		return nil, err
+	}
+	result.estimateThinkingTokens()
+	return result, nil
 }
 
 // Complete sends a single-turn completion request to the Anthropic API
@@ -369,6 +469,7 @@ type streamRequest struct {
 	Messages  []AnthropicMessage `json:"messages"`
 	System    string             `json:"system,omitempty"`
 	Tools     []AnthropicTool    `json:"tools,omitempty"`
+	Thinking  *AnthropicThinking `json:"thinking,omitempty"`
 	Stream    bool               `json:"stream"`
 }
 
@@ -395,6 +496,7 @@ func (c *AnthropicClient) ChatStream(ctx context.Context, req *AnthropicChatRequ
 		Messages:  req.Messages,
 		System:    req.System,
 		Tools:     req.Tools,
+		Thinking:  req.Thinking,
 		Stream:    true,
 	}
 
@@ -413,7 +515,7 @@ func (c *AnthropicClient) ChatStream(ctx context.Context, req *AnthropicChatRequ
 	httpReq.Header.Set("Accept", "text/event-stream")
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, _, err := c.send(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -472,6 +574,7 @@ func (c *AnthropicClient) ChatWithStreaming(ctx context.Context, req *AnthropicC
 		Messages:  req.Messages,
 		System:    req.System,
 		Tools:     req.Tools,
+		Thinking:  req.Thinking,
 		Stream:    true,
 	}
 
@@ -490,7 +593,7 @@ func (c *AnthropicClient) ChatWithStreaming(ctx context.Context, req *AnthropicC
 	httpReq.Header.Set("Accept", "text/event-stream")
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, queueWait, err := c.send(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -514,7 +617,12 @@ func (c *AnthropicClient) ChatWithStreaming(ctx context.Context, req *AnthropicC
 	}
 
 	// Read and process SSE events, building the complete response
-	return c.readSSEAndBuildResponse(ctx, resp.Body, onDelta)
+	response, err := c.readSSEAndBuildResponse(ctx, resp.Body, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	response.QueueWait = queueWait
+	return response, nil
 }
 
 // readSSEAndBuildResponse reads SSE events and constructs a complete response
@@ -531,6 +639,7 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 	// Track current content blocks being built
 	var currentBlocks []AnthropicContentBlock
 	var textBuilder strings.Builder
+	var thinkingBuilder strings.Builder
 	var currentToolInput strings.Builder
 	var currentBlockIndex int
 
@@ -587,11 +696,14 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 				Type         string `json:"type"`
 				Index        int    `json:"index"`
 				ContentBlock struct {
-					Type  string `json:"type"`
-					ID    string `json:"id,omitempty"`
-					Name  string `json:"name,omitempty"`
-					Text  string `json:"text,omitempty"`
-					Input any    `json:"input,omitempty"`
+					Type      string `json:"type"`
+					ID        string `json:"id,omitempty"`
+					Name      string `json:"name,omitempty"`
+					Text      string `json:"text,omitempty"`
+					Input     any    `json:"input,omitempty"`
+					Thinking  string `json:"thinking,omitempty"`
+					Signature string `json:"signature,omitempty"`
+					Data      string `json:"data,omitempty"`
 				} `json:"content_block"`
 			}
 			if err := json.Unmarshal([]byte(data), &blockStart); err == nil {
@@ -603,10 +715,12 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 				}
 
 				currentBlocks[currentBlockIndex] = AnthropicContentBlock{
-					Type: blockStart.ContentBlock.Type,
-					ID:   blockStart.ContentBlock.ID,
-					Name: blockStart.ContentBlock.Name,
-					Text: blockStart.ContentBlock.Text,
+					Type:      blockStart.ContentBlock.Type,
+					ID:        blockStart.ContentBlock.ID,
+					Name:      blockStart.ContentBlock.Name,
+					Text:      blockStart.ContentBlock.Text,
+					Signature: blockStart.ContentBlock.Signature,
+					Data:      blockStart.ContentBlock.Data,
 				}
 
 				// Reset builders for new block
@@ -615,6 +729,9 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 					textBuilder.WriteString(blockStart.ContentBlock.Text)
 				} else if blockStart.ContentBlock.Type == "tool_use" {
 					currentToolInput.Reset()
+				} else if blockStart.ContentBlock.Type == "thinking" {
+					thinkingBuilder.Reset()
+					thinkingBuilder.WriteString(blockStart.ContentBlock.Thinking)
 				}
 			}
 
@@ -626,6 +743,8 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 					Type        string `json:"type"`
 					Text        string `json:"text,omitempty"`
 					PartialJSON string `json:"partial_json,omitempty"`
+					Thinking    string `json:"thinking,omitempty"`
+					Signature   string `json:"signature,omitempty"`
 				} `json:"delta"`
 			}
 			if err := json.Unmarshal([]byte(data), &delta); err == nil {
@@ -637,6 +756,10 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 					}
 				} else if delta.Delta.Type == "input_json_delta" && delta.Delta.PartialJSON != "" {
 					currentToolInput.WriteString(delta.Delta.PartialJSON)
+				} else if delta.Delta.Type == "thinking_delta" {
+					thinkingBuilder.WriteString(delta.Delta.Thinking)
+				} else if delta.Delta.Type == "signature_delta" && delta.Index < len(currentBlocks) {
+					currentBlocks[delta.Index].Signature += delta.Delta.Signature
 				}
 			}
 
@@ -650,6 +773,8 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 				if idx < len(currentBlocks) {
 					if currentBlocks[idx].Type == "text" {
 						currentBlocks[idx].Text = textBuilder.String()
+					} else if currentBlocks[idx].Type == "thinking" {
+						currentBlocks[idx].Thinking = thinkingBuilder.String()
 					} else if currentBlocks[idx].Type == "tool_use" {
 						// Parse accumulated JSON input
 						inputStr := currentToolInput.String()
@@ -686,6 +811,7 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 		case "message_stop":
 			// Copy accumulated blocks to response
 			response.Content = currentBlocks
+			response.estimateThinkingTokens()
 			return response, nil
 
 		case "error":
@@ -709,6 +835,7 @@ func (c *AnthropicClient) readSSEAndBuildResponse(ctx context.Context, body io.R
 
 	// If we get here, stream ended without message_stop
 	response.Content = currentBlocks
+	response.estimateThinkingTokens()
 	return response, nil
 }
 
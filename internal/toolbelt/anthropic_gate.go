@@ -0,0 +1,255 @@
+package toolbelt
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultAnthropicMaxConcurrent is how many Anthropic requests may be in
+// flight at once across all sessions unless configured otherwise (0 =
+// unlimited). A streaming request holds its slot for the whole stream, so any
+// cap also caps how many sessions make progress at once.
+const DefaultAnthropicMaxConcurrent = 0
+
+// RequestPriority orders queued requests at a RequestGate: a free slot goes
+// to a waiting request of the highest priority first
+type RequestPriority int
+
+const (
+	RequestPriorityLow    RequestPriority = -1 // Background and batch work
+	RequestPriorityNormal RequestPriority = 0  // Default
+	RequestPriorityHigh   RequestPriority = 1  // Someone is watching and waiting
+)
+
+// String returns the priority's name
+func (p RequestPriority) String() string {
+	switch {
+	case p < RequestPriorityNormal:
+		return "low"
+	case p > RequestPriorityNormal:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+type requestPriorityKey struct{}
+
+// WithRequestPriority returns a context whose Anthropic requests queue at
+// priority p behind the shared request gate
+func WithRequestPriority(ctx context.Context, p RequestPriority) context.Context {
+	return context.WithValue(ctx, requestPriorityKey{}, p)
+}
+
+// requestPriority returns the priority set on ctx, or RequestPriorityNormal
+func requestPriority(ctx context.Context) RequestPriority {
+	if p, ok := ctx.Value(requestPriorityKey{}).(RequestPriority); ok {
+		return p
+	}
+	return RequestPriorityNormal
+}
+
+// RequestGate bounds Anthropic requests across every client in the process:
+// how many are in flight at once, and how many start in any one minute.
+// Requests over either limit queue until a slot frees up; higher-priority
+// requests take freed slots first.
+type RequestGate struct {
+	mu            sync.Mutex
+	maxConcurrent int         // 0 = unlimited
+	perMinute     int         // 0 = unlimited
+	inFlight      int         // Requests sent whose response body is still open
+	started       []time.Time // Start times within the last minute, oldest first
+	waiting       map[RequestPriority]int
+	changed       chan struct{} // Closed and replaced whenever a slot frees up
+	now           func() time.Time
+}
+
+// RequestGateStatus is a snapshot of a RequestGate
+type RequestGateStatus struct {
+	MaxConcurrent int `json:"max_concurrent"`      // 0 = unlimited
+	PerMinute     int `json:"requests_per_minute"` // 0 = unlimited
+	InFlight      int `json:"in_flight"`
+	LastMinute    int `json:"started_last_minute"`
+	Waiting       int `json:"waiting"`
+
+	// Waiting requests by priority name, omitted when none are waiting
+	WaitingByPriority map[string]int `json:"waiting_by_priority,omitempty"`
+}
+
+// NewRequestGate creates a gate with the given limits (0 leaves a limit off)
+func NewRequestGate(maxConcurrent, perMinute int) *RequestGate {
+	return &RequestGate{
+		maxConcurrent: maxConcurrent,
+		perMinute:     perMinute,
+		waiting:       make(map[RequestPriority]int),
+		changed:       make(chan struct{}),
+		now:           time.Now,
+	}
+}
+
+// anthropicGate is shared by every AnthropicClient, so parallel sessions,
+// planning, and quests queue behind the same limits
+var anthropicGate = NewRequestGate(DefaultAnthropicMaxConcurrent, 0)
+
+// SetAnthropicRequestLimits sets the limits on Anthropic requests in flight at
+// once and started per minute across all clients (0 leaves a limit off)
+func SetAnthropicRequestLimits(maxConcurrent, perMinute int) {
+	anthropicGate.SetLimits(maxConcurrent, perMinute)
+}
+
+// AnthropicRequestGateStatus returns the shared gate's limits and queue
+func AnthropicRequestGateStatus() RequestGateStatus {
+	return anthropicGate.Status()
+}
+
+// SetLimits changes the gate's limits, letting queued requests through if they raised
+func (g *RequestGate) SetLimits(maxConcurrent, perMinute int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.maxConcurrent = maxConcurrent
+	g.perMinute = perMinute
+	g.notifyLocked()
+}
+
+// Acquire waits for a free slot and claims it. The returned release frees the
+// slot and must be called once the request is done. The request queues at the
+// priority set on ctx with WithRequestPriority, and doesn't take a slot while
+// a higher-priority request is waiting for one.
+func (g *RequestGate) Acquire(ctx context.Context) (release func(), err error) {
+	priority := requestPriority(ctx)
+	queued := false
+	defer func() {
+		if queued {
+			g.mu.Lock()
+			g.dequeueLocked(priority)
+			g.mu.Unlock()
+		}
+	}()
+
+	for {
+		g.mu.Lock()
+		now := g.now()
+		g.pruneLocked(now)
+
+		concurrencyOK := g.maxConcurrent <= 0 || g.inFlight < g.maxConcurrent
+		rateOK := g.perMinute <= 0 || len(g.started) < g.perMinute
+		if concurrencyOK && rateOK && !g.higherWaitingLocked(priority) {
+			g.inFlight++
+			g.started = append(g.started, now)
+			if queued {
+				queued = false
+				g.dequeueLocked(priority)
+			}
+			g.mu.Unlock()
+
+			var once sync.Once
+			return func() { once.Do(g.release) }, nil
+		}
+
+		if !queued {
+			queued = true
+			g.waiting[priority]++
+		}
+		changed := g.changed
+		var expiry *time.Timer
+		var expired <-chan time.Time
+		if !rateOK {
+			expiry = time.NewTimer(g.started[0].Add(time.Minute).Sub(now))
+			expired = expiry.C
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-expired:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if expiry != nil {
+			expiry.Stop()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// release frees an in-flight slot
+func (g *RequestGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+	g.notifyLocked()
+}
+
+// higherWaitingLocked reports whether a request above priority is queued
+func (g *RequestGate) higherWaitingLocked(priority RequestPriority) bool {
+	for p, n := range g.waiting {
+		if p > priority && n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dequeueLocked removes a request from the queue. Lower-priority requests may
+// have been held back by it, so they're woken to retry.
+func (g *RequestGate) dequeueLocked(priority RequestPriority) {
+	g.waiting[priority]--
+	if g.waiting[priority] <= 0 {
+		delete(g.waiting, priority)
+	}
+	g.notifyLocked()
+}
+
+// notifyLocked wakes every queued request to retry
+func (g *RequestGate) notifyLocked() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// pruneLocked forgets request starts older than a minute
+func (g *RequestGate) pruneLocked(now time.Time) {
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(g.started) && !g.started[i].After(cutoff) {
+		i++
+	}
+	g.started = g.started[i:]
+}
+
+// Status returns the gate's limits and current load
+func (g *RequestGate) Status() RequestGateStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pruneLocked(g.now())
+	status := RequestGateStatus{
+		MaxConcurrent: g.maxConcurrent,
+		PerMinute:     g.perMinute,
+		InFlight:      g.inFlight,
+		LastMinute:    len(g.started),
+	}
+	for p, n := range g.waiting {
+		if status.WaitingByPriority == nil {
+			status.WaitingByPriority = make(map[string]int)
+		}
+		status.WaitingByPriority[p.String()] += n
+		status.Waiting += n
+	}
+	return status
+}
+
+// gatedBody releases a gate slot when the response body is closed, so a
+// streaming request holds its slot until the stream is done
+type gatedBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *gatedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
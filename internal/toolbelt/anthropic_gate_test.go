@@ -0,0 +1,196 @@
+package toolbelt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// acquireAsync starts an Acquire and returns a channel that receives its
+// release once it gets a slot
+func acquireAsync(t *testing.T, g *RequestGate, ctx context.Context) <-chan func() {
+	t.Helper()
+	acquired := make(chan func(), 1)
+	go func() {
+		release, err := g.Acquire(ctx)
+		if err == nil {
+			acquired <- release
+		}
+	}()
+	return acquired
+}
+
+// waitForQueued waits until n requests are queued at the gate
+func waitForQueued(t *testing.T, g *RequestGate, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for g.Status().Waiting != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting = %d, want %d", g.Status().Waiting, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestGate_Concurrency(t *testing.T) {
+	g := NewRequestGate(2, 0)
+	ctx := context.Background()
+
+	first, err := g.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	third := acquireAsync(t, g, ctx)
+	waitForQueued(t, g, 1)
+	select {
+	case <-third:
+		t.Fatal("third request got a slot while two were in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first()
+	first() // Releasing twice frees only one slot
+	select {
+	case <-third:
+	case <-time.After(2 * time.Second):
+		t.Fatal("third request didn't get the freed slot")
+	}
+	if status := g.Status(); status.InFlight != 2 || status.Waiting != 0 {
+		t.Errorf("status = %+v, want 2 in flight and none waiting", status)
+	}
+}
+
+func TestRequestGate_Unlimited(t *testing.T) {
+	g := NewRequestGate(0, 0)
+	for range 50 {
+		if _, err := g.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if status := g.Status(); status.InFlight != 50 {
+		t.Errorf("in flight = %d, want 50", status.InFlight)
+	}
+}
+
+func TestRequestGate_PerMinute(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	g := NewRequestGate(0, 2)
+	g.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	for range 2 {
+		release, err := g.Acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release() // Finished requests still count against the minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third request in the minute = %v, want it held until ctx expired", err)
+	}
+
+	mu.Lock()
+	now = now.Add(time.Minute + time.Second)
+	mu.Unlock()
+	if _, err := g.Acquire(context.Background()); err != nil {
+		t.Fatalf("request after the minute passed: %v", err)
+	}
+	if status := g.Status(); status.LastMinute != 1 {
+		t.Errorf("started last minute = %d, want 1", status.LastMinute)
+	}
+}
+
+func TestRequestGate_ContextCancel(t *testing.T) {
+	g := NewRequestGate(1, 0)
+	if _, err := g.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := g.Acquire(ctx)
+		errs <- err
+	}()
+	waitForQueued(t, g, 1)
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled Acquire = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled Acquire didn't return")
+	}
+	if status := g.Status(); status.Waiting != 0 || status.InFlight != 1 {
+		t.Errorf("status = %+v, want the cancelled request dequeued without a slot", status)
+	}
+}
+
+func TestRequestGate_PriorityOrder(t *testing.T) {
+	g := NewRequestGate(1, 0)
+	release, err := g.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	low := acquireAsync(t, g, WithRequestPriority(context.Background(), RequestPriorityLow))
+	waitForQueued(t, g, 1)
+	high := acquireAsync(t, g, WithRequestPriority(context.Background(), RequestPriorityHigh))
+	waitForQueued(t, g, 2)
+
+	release()
+	select {
+	case next := <-high:
+		next()
+	case <-low:
+		t.Fatal("low-priority request took the slot ahead of a high-priority one")
+	case <-time.After(2 * time.Second):
+		t.Fatal("no request got the freed slot")
+	}
+	select {
+	case <-low:
+	case <-time.After(2 * time.Second):
+		t.Fatal("low-priority request never got a slot")
+	}
+}
+
+func TestGatedBody_ReleasesOnClose(t *testing.T) {
+	g := NewRequestGate(1, 0)
+	release, err := g.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := &gatedBody{ReadCloser: io.NopCloser(strings.NewReader("data: {}\n\n")), release: release}
+
+	// Reading the stream keeps the slot
+	if _, err := io.ReadAll(body); err != nil {
+		t.Fatal(err)
+	}
+	if status := g.Status(); status.InFlight != 1 {
+		t.Fatalf("in flight while the body is open = %d, want 1", status.InFlight)
+	}
+
+	if err := body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if status := g.Status(); status.InFlight != 0 {
+		t.Errorf("in flight after close = %d, want 0", status.InFlight)
+	}
+}
@@ -0,0 +1,238 @@
+package toolbelt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitLowWater is the share of a limit below which requests are paced
+// out over the time left until it resets, instead of sent as fast as they come
+const rateLimitLowWater = 0.1
+
+// rateLimitRetrySpacing is the least time between requests held by a 429's
+// retry-after, so they don't all go out together and hit another one
+const rateLimitRetrySpacing = 250 * time.Millisecond
+
+// RateLimitWindow is one of the API's rate limits as of the latest response
+type RateLimitWindow struct {
+	Limit     int        `json:"limit"`
+	Remaining int        `json:"remaining"`
+	Reset     *time.Time `json:"reset,omitempty"` // When Remaining is back at Limit
+}
+
+// active reports whether the window was reported and hasn't reset yet
+func (w RateLimitWindow) active(now time.Time) bool {
+	return w.Limit > 0 && w.Reset != nil && w.Reset.After(now)
+}
+
+// RateLimitStatus is what the Anthropic API last reported about the org's
+// rate limits, and how the client is throttling because of it
+type RateLimitStatus struct {
+	Requests     RateLimitWindow `json:"requests"`
+	Tokens       RateLimitWindow `json:"tokens"`
+	InputTokens  RateLimitWindow `json:"input_tokens"`
+	OutputTokens RateLimitWindow `json:"output_tokens"`
+
+	RetryAfter *time.Time `json:"retry_after,omitempty"` // Set by a 429; no requests are sent before it
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`  // Last response with rate-limit headers
+
+	Throttling bool  `json:"throttling"` // Requests are being delayed right now
+	Waiting    int   `json:"waiting"`    // Requests waiting for their turn
+	Delayed    int64 `json:"delayed"`    // Requests delayed since the server started
+}
+
+// RateLimiter paces requests to the Anthropic API across every session sharing
+// an API key. It reads the rate-limit headers on each response and, when the
+// org is close to a limit, spreads the remaining requests over the time left
+// until it resets, so many parallel sessions don't all hit 429s at once.
+type RateLimiter struct {
+	mu      sync.Mutex
+	status  RateLimitStatus
+	next    time.Time // Earliest time the next paced request may go out
+	waiting int
+	delayed int64
+	now     func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter with no limits known yet
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{now: time.Now}
+}
+
+var (
+	rateLimitersMu sync.Mutex
+	rateLimiters   = make(map[string]*RateLimiter)
+)
+
+// rateLimiterFor returns the rate limiter shared by every client using an API
+// key, since limits apply to the org rather than to one client
+func rateLimiterFor(apiKey string) *RateLimiter {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	limiter := rateLimiters[apiKey]
+	if limiter == nil {
+		limiter = NewRateLimiter()
+		rateLimiters[apiKey] = limiter
+	}
+	return limiter
+}
+
+// Wait blocks until the next request may be sent, or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	l.waiting++
+	l.delayed++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+	}()
+
+	if delay >= time.Second {
+		fmt.Printf("Anthropic rate limit: delaying request %s\n", delay.Round(time.Second))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve claims the next send slot and returns how long to wait for it
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	start := now
+	var interval time.Duration
+	if l.status.RetryAfter != nil && l.status.RetryAfter.After(start) {
+		// Let held requests out at the rate the requests window refills, or
+		// at least rateLimitRetrySpacing apart, like an exhausted window
+		start = *l.status.RetryAfter
+		interval = rateLimitRetrySpacing
+		if w := l.status.Requests; w.active(now) {
+			interval = max(interval, w.Reset.Sub(now)/time.Duration(w.Limit))
+		}
+	}
+
+	// A window with nothing left holds requests until it resets, then lets
+	// them out at the rate it refills rather than all at once; one that's
+	// running low spaces them out so the rest last until then
+	for _, w := range l.windows() {
+		if !w.active(now) {
+			continue
+		}
+		if w.Remaining <= 0 {
+			if w.Reset.After(start) {
+				start = *w.Reset
+			}
+			interval = max(interval, w.Reset.Sub(now)/time.Duration(w.Limit))
+			continue
+		}
+		if float64(w.Remaining) < float64(w.Limit)*rateLimitLowWater {
+			interval = max(interval, w.Reset.Sub(now)/time.Duration(w.Remaining))
+		}
+	}
+
+	if interval > 0 && l.next.After(start) {
+		start = l.next
+	}
+	l.next = start.Add(interval)
+
+	// Count this request against the requests window until the next response says otherwise
+	if l.status.Requests.active(now) && l.status.Requests.Remaining > 0 {
+		l.status.Requests.Remaining--
+	}
+
+	return start.Sub(now)
+}
+
+// windows returns the limit windows to check before sending
+func (l *RateLimiter) windows() []RateLimitWindow {
+	return []RateLimitWindow{l.status.Requests, l.status.Tokens, l.status.InputTokens, l.status.OutputTokens}
+}
+
+// Observe records the rate-limit headers on a response
+func (l *RateLimiter) Observe(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	updated := false
+	for prefix, window := range map[string]*RateLimitWindow{
+		"requests":      &l.status.Requests,
+		"tokens":        &l.status.Tokens,
+		"input-tokens":  &l.status.InputTokens,
+		"output-tokens": &l.status.OutputTokens,
+	} {
+		if parseRateLimitWindow(resp.Header, "anthropic-ratelimit-"+prefix, window) {
+			updated = true
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := now.Add(time.Second)
+		if seconds, err := strconv.Atoi(resp.Header.Get("retry-after")); err == nil && seconds > 0 {
+			retryAfter = now.Add(time.Duration(seconds) * time.Second)
+		}
+		l.status.RetryAfter = &retryAfter
+		updated = true
+	}
+
+	if updated {
+		l.status.UpdatedAt = &now
+	}
+}
+
+// parseRateLimitWindow reads a limit's -limit, -remaining, and -reset headers
+// into w, reporting whether the response had them
+func parseRateLimitWindow(h http.Header, prefix string, w *RateLimitWindow) bool {
+	limit, err := strconv.Atoi(h.Get(prefix + "-limit"))
+	if err != nil {
+		return false
+	}
+	remaining, err := strconv.Atoi(h.Get(prefix + "-remaining"))
+	if err != nil {
+		return false
+	}
+
+	w.Limit = limit
+	w.Remaining = remaining
+	w.Reset = nil
+	if reset, err := time.Parse(time.RFC3339, h.Get(prefix+"-reset")); err == nil {
+		w.Reset = &reset
+	}
+	return true
+}
+
+// Status returns the latest rate limits and how requests are being throttled
+func (l *RateLimiter) Status() RateLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := l.status
+	now := l.now()
+	if status.RetryAfter != nil && !status.RetryAfter.After(now) {
+		status.RetryAfter = nil
+	}
+	status.Waiting = l.waiting
+	status.Delayed = l.delayed
+	status.Throttling = l.waiting > 0 || status.RetryAfter != nil || l.next.After(now)
+	return status
+}
@@ -0,0 +1,111 @@
+package toolbelt
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+
+	tests := []struct {
+		name   string
+		status RateLimitStatus
+		want   []time.Duration
+	}{
+		{
+			name: "no limits known",
+			want: []time.Duration{0, 0, 0},
+		},
+		{
+			name:   "plenty left",
+			status: RateLimitStatus{Requests: RateLimitWindow{Limit: 100, Remaining: 90, Reset: at(10 * time.Second)}},
+			want:   []time.Duration{0, 0, 0},
+		},
+		{
+			name:   "running low spreads the rest until reset",
+			status: RateLimitStatus{Tokens: RateLimitWindow{Limit: 1000, Remaining: 50, Reset: at(10 * time.Second)}},
+			want:   []time.Duration{0, 200 * time.Millisecond, 400 * time.Millisecond},
+		},
+		{
+			name:   "exhausted spreads waiters from reset",
+			status: RateLimitStatus{Requests: RateLimitWindow{Limit: 60, Remaining: 0, Reset: at(30 * time.Second)}},
+			want:   []time.Duration{30 * time.Second, 30*time.Second + 500*time.Millisecond, 31 * time.Second},
+		},
+		{
+			name: "slowest window wins",
+			status: RateLimitStatus{
+				Requests: RateLimitWindow{Limit: 60, Remaining: 0, Reset: at(30 * time.Second)},
+				Tokens:   RateLimitWindow{Limit: 1000, Remaining: 10, Reset: at(20 * time.Second)},
+			},
+			want: []time.Duration{30 * time.Second, 32 * time.Second, 34 * time.Second},
+		},
+		{
+			name:   "window already reset",
+			status: RateLimitStatus{Requests: RateLimitWindow{Limit: 60, Remaining: 0, Reset: at(-time.Second)}},
+			want:   []time.Duration{0, 0, 0},
+		},
+		{
+			name:   "retry-after holds requests and spreads them",
+			status: RateLimitStatus{RetryAfter: at(5 * time.Second)},
+			want:   []time.Duration{5 * time.Second, 5*time.Second + rateLimitRetrySpacing, 5*time.Second + 2*rateLimitRetrySpacing},
+		},
+		{
+			name: "retry-after spreads at the requests refill rate",
+			status: RateLimitStatus{
+				Requests:   RateLimitWindow{Limit: 60, Remaining: 30, Reset: at(60 * time.Second)},
+				RetryAfter: at(5 * time.Second),
+			},
+			want: []time.Duration{5 * time.Second, 6 * time.Second, 7 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter()
+			l.now = func() time.Time { return now }
+			l.status = tt.status
+
+			var got []time.Duration
+			for range tt.want {
+				got = append(got, l.reserve())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Observe(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter()
+	l.now = func() time.Time { return now }
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("anthropic-ratelimit-requests-limit", "50")
+	resp.Header.Set("anthropic-ratelimit-requests-remaining", "0")
+	resp.Header.Set("anthropic-ratelimit-requests-reset", now.Add(20*time.Second).Format(time.RFC3339))
+	resp.Header.Set("retry-after", "7")
+	l.Observe(resp)
+
+	status := l.Status()
+	if status.Requests.Limit != 50 || status.Requests.Remaining != 0 || status.Requests.Reset == nil {
+		t.Errorf("requests window = %+v, want the reported limit", status.Requests)
+	}
+	if status.RetryAfter == nil || !status.RetryAfter.Equal(now.Add(7*time.Second)) {
+		t.Errorf("retry after = %v, want 7s from now", status.RetryAfter)
+	}
+	if !status.Throttling {
+		t.Error("expected throttling after a 429")
+	}
+	if delay := l.reserve(); delay != 20*time.Second {
+		t.Errorf("delay = %v, want until the requests window resets", delay)
+	}
+}
@@ -181,3 +181,49 @@ func NewFromSecrets(secretsPath string) (*Toolbelt, error) {
 	}
 	return New(config)
 }
+
+// Environment variables read by LoadFromEnv
+const (
+	EnvGitHubToken     = "GITHUB_TOKEN"
+	EnvAnthropicAPIKey = "ANTHROPIC_API_KEY"
+)
+
+// HasEnvConfig reports whether any toolbelt credentials are set in the environment
+func HasEnvConfig() bool {
+	return os.Getenv(EnvGitHubToken) != "" || os.Getenv(EnvAnthropicAPIKey) != ""
+}
+
+// LoadFromEnv loads toolbelt configuration from environment variables
+// This is used for container and CI deployments that have no secrets file or onboarding
+func LoadFromEnv() *Config {
+	config := &Config{}
+
+	if token := os.Getenv(EnvGitHubToken); token != "" {
+		config.GitHub = &GitHubConfig{Token: token}
+	}
+	if key := os.Getenv(EnvAnthropicAPIKey); key != "" {
+		config.Anthropic = &AnthropicConfig{APIKey: key}
+	}
+
+	return config
+}
+
+// ResolveConfig builds the toolbelt configuration from the credentials in the
+// environment, falling back to the stored ones for any the environment doesn't
+// set. Env-only deployments never store credentials, so everything that needs
+// them resolves them here.
+func ResolveConfig(storedAnthropicKey, storedGitHubToken string) *Config {
+	config := LoadFromEnv()
+	if config.Anthropic == nil && storedAnthropicKey != "" {
+		config.Anthropic = &AnthropicConfig{APIKey: storedAnthropicKey}
+	}
+	if config.GitHub == nil && storedGitHubToken != "" {
+		config.GitHub = &GitHubConfig{Token: storedGitHubToken}
+	}
+	return config
+}
+
+// NewFromEnv creates a Toolbelt from environment variables
+func NewFromEnv() (*Toolbelt, error) {
+	return New(LoadFromEnv())
+}
@@ -0,0 +1,56 @@
+package toolbelt
+
+import "testing"
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv(EnvAnthropicAPIKey, "")
+	t.Setenv(EnvGitHubToken, "")
+	if HasEnvConfig() {
+		t.Error("HasEnvConfig() = true with nothing set")
+	}
+	if config := LoadFromEnv(); config.Anthropic != nil || config.GitHub != nil {
+		t.Errorf("LoadFromEnv() = %+v, want no credentials", config)
+	}
+
+	t.Setenv(EnvAnthropicAPIKey, "sk-ant-env")
+	if !HasEnvConfig() {
+		t.Error("HasEnvConfig() = false with an Anthropic key set")
+	}
+	config := LoadFromEnv()
+	if config.Anthropic == nil || config.Anthropic.APIKey != "sk-ant-env" || config.GitHub != nil {
+		t.Errorf("LoadFromEnv() = %+v, want only the Anthropic key", config)
+	}
+
+	t.Setenv(EnvAnthropicAPIKey, "")
+	t.Setenv(EnvGitHubToken, "ghp_env")
+	if !HasEnvConfig() {
+		t.Error("HasEnvConfig() = false with a GitHub token set")
+	}
+	if config := LoadFromEnv(); config.GitHub == nil || config.GitHub.Token != "ghp_env" || config.Anthropic != nil {
+		t.Errorf("LoadFromEnv() = %+v, want only the GitHub token", config)
+	}
+}
+
+func TestResolveConfig_PrefersEnv(t *testing.T) {
+	t.Setenv(EnvAnthropicAPIKey, "sk-ant-env")
+	t.Setenv(EnvGitHubToken, "")
+
+	// The environment's key wins; the stored token fills in what it doesn't set
+	config := ResolveConfig("sk-ant-stored", "ghp_stored")
+	if config.Anthropic == nil || config.Anthropic.APIKey != "sk-ant-env" {
+		t.Errorf("Anthropic = %+v, want the environment's key", config.Anthropic)
+	}
+	if config.GitHub == nil || config.GitHub.Token != "ghp_stored" {
+		t.Errorf("GitHub = %+v, want the stored token", config.GitHub)
+	}
+
+	// Env-only: nothing stored
+	if config := ResolveConfig("", ""); config.Anthropic == nil || config.GitHub != nil {
+		t.Errorf("ResolveConfig() = %+v, want only the environment's key", config)
+	}
+
+	t.Setenv(EnvAnthropicAPIKey, "")
+	if config := ResolveConfig("", ""); config.Anthropic != nil || config.GitHub != nil {
+		t.Errorf("ResolveConfig() = %+v, want no credentials", config)
+	}
+}
@@ -146,6 +146,37 @@ func (g *GitHubClient) CreatePR(ctx context.Context, opts CreatePROptions) (*git
 	return pr, nil
 }
 
+// CreateIssueOptions specifies options for creating an issue
+type CreateIssueOptions struct {
+	Owner  string
+	Repo   string
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// CreateIssue opens a new issue
+func (g *GitHubClient) CreateIssue(ctx context.Context, opts CreateIssueOptions) (*github.Issue, error) {
+	owner := opts.Owner
+	if owner == "" {
+		owner = g.defaultOrg
+	}
+
+	issueReq := &github.IssueRequest{
+		Title: github.Ptr(opts.Title),
+		Body:  github.Ptr(opts.Body),
+	}
+	if len(opts.Labels) > 0 {
+		issueReq.Labels = &opts.Labels
+	}
+
+	issue, _, err := g.client.Issues.Create(ctx, owner, opts.Repo, issueReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	return issue, nil
+}
+
 // GetAuthenticatedUser returns the authenticated user's information.
 func (g *GitHubClient) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
 	user, _, err := g.client.Users.Get(ctx, "")
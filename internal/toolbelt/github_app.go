@@ -0,0 +1,55 @@
+// Package toolbelt provides clients for external services used to build projects
+package toolbelt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-github/v68/github"
+)
+
+// NewGitHubAppInstallationClient creates a GitHubClient authenticated as the
+// GitHub App's installation on the given user or org account. Installation
+// tokens expire after an hour, so create a new client for each session rather
+// than keeping one around.
+func NewGitHubAppInstallationClient(ctx context.Context, appID int64, privateKeyPEM, login string) (*GitHubClient, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+
+	// Backdate the issue time to allow for clock drift, as GitHub recommends
+	now := time.Now()
+	appJWT, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    fmt.Sprintf("%d", appID),
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}).SignedString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	appClient := github.NewClient(nil).WithAuthToken(appJWT)
+
+	accountType := "Organization"
+	installation, resp, err := appClient.Apps.FindOrganizationInstallation(ctx, login)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		accountType = "User"
+		installation, _, err = appClient.Apps.FindUserInstallation(ctx, login)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find GitHub App installation for %s: %w", login, err)
+	}
+
+	token, _, err := appClient.Apps.CreateInstallationToken(ctx, installation.GetID(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installation token for %s: %w", login, err)
+	}
+
+	client := NewGitHubClient(&GitHubConfig{Token: token.GetToken(), DefaultOrg: login})
+	client.accountType = accountType
+	return client, nil
+}
@@ -271,7 +271,7 @@ func GitInitTool() Tool {
 func GitCommitTool() Tool {
 	return Tool{
 		Name:        "git_commit",
-		Description: "Stage files and create a git commit. Use git_status first to see what needs to be committed.",
+		Description: "Stage files and create a git commit. Use git_status first to see what needs to be committed. Commits whose staged changes contain secrets (API keys, private keys, tokens) are rejected.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -291,6 +291,25 @@ func GitCommitTool() Tool {
 	}
 }
 
+func GitUndoCommitTool() Tool {
+	return Tool{
+		Name:        "git_undo_commit",
+		Description: "Undo your last commit when you realize it was a mistake. 'soft' (default) removes the commit but keeps its changes staged so you can fix and recommit; it's refused for commits already pushed. 'revert' adds a new commit reversing it and is safe after pushing. Use this instead of running git reset through bash.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"mode": map[string]any{
+					"type":        "string",
+					"enum":        []string{"soft", "revert"},
+					"description": "How to undo the commit (default: soft)",
+				},
+			},
+			"required": []string{},
+		},
+		ReadOnly: false,
+	}
+}
+
 func GitRemoteAddTool() Tool {
 	return Tool{
 		Name:        "git_remote_add",
@@ -715,7 +734,7 @@ Event routing:
 				},
 				"payload": map[string]any{
 					"type":        "object",
-					"description": "Optional event payload (e.g., {\"reason\": \"...\"} for task.blocked)",
+					"description": "Optional event payload (e.g., {\"category\": \"missing_credential\", \"reason\": \"...\"} for task.blocked; category is missing_credential, ambiguous_requirements, external_dependency, test_environment, or other)",
 				},
 				"acknowledge_failures": map[string]any{
 					"type":        "boolean",
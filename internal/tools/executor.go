@@ -17,9 +17,11 @@ import (
 
 // Executor executes tools in the context of a working directory
 type Executor struct {
-	workDir  string
-	toolSet  *Set
-	readOnly bool // If true, only read-only tools are allowed
+	workDir       string
+	toolSet       *Set
+	readOnly      bool                 // If true, only read-only tools are allowed
+	commandPrefix []string             // Prepended to bash commands, e.g. to sandbox them
+	sensitivity   security.Sensitivity // How file contents are sanitized ("" = normal)
 }
 
 // NewExecutor creates a new Executor
@@ -41,6 +43,18 @@ func (e *Executor) ToolSet() *Set {
 	return e.toolSet
 }
 
+// SetSensitivity sets how file contents are sanitized before they're returned.
+// Off returns them exactly as read.
+func (e *Executor) SetSensitivity(level security.Sensitivity) {
+	e.sensitivity = level
+}
+
+// SetCommandPrefix sets a command that bash commands are run through, e.g.
+// []string{"unshare", "--net", "--"} to run them without network access
+func (e *Executor) SetCommandPrefix(prefix []string) {
+	e.commandPrefix = prefix
+}
+
 // Execute runs a tool with the given input and returns the result
 func (e *Executor) Execute(ctx context.Context, toolName string, input map[string]any) Result {
 	start := time.Now()
@@ -119,7 +133,7 @@ func (e *Executor) Execute(ctx context.Context, toolName string, input map[strin
 
 // Command blocklist patterns for security
 var dangerousPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`(?i)rm\s+(-[rf]+\s+)?/`),           // rm -rf /
+	regexp.MustCompile(`(?i)rm\s+(-[rf]+\s+)?/`),            // rm -rf /
 	regexp.MustCompile(`(?i)>\s*/dev/`),                     // redirect to /dev/
 	regexp.MustCompile(`(?i)sudo\s`),                        // sudo commands
 	regexp.MustCompile(`(?i)chmod\s+777`),                   // chmod 777
@@ -185,6 +199,10 @@ func (e *Executor) executeReadFile(input map[string]any) Result {
 		}
 	}
 
+	if e.sensitivity == security.SensitivityOff {
+		return Result{Output: string(content), IsError: false}
+	}
+
 	// Sanitize file content to prevent prompt injection via invisible unicode
 	sanitized := security.SanitizeForPrompt(string(content))
 
@@ -713,10 +731,11 @@ func (e *Executor) executeBash(ctx context.Context, input map[string]any) Result
 	execCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSecs)*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(execCtx, "bash", "-c", command)
+	args := append(append([]string(nil), e.commandPrefix...), "bash", "-c", command)
+	cmd := exec.CommandContext(execCtx, args[0], args[1:]...)
 	cmd.Dir = e.workDir
 
-	output, err := cmd.CombinedOutput()
+	output, err := CombinedOutput(ctx, cmd)
 	if err != nil {
 		if execCtx.Err() == context.DeadlineExceeded {
 			return Result{
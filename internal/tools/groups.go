@@ -38,6 +38,7 @@ var ToolGroups = map[ToolGroup][]string{
 	GroupGitWrite: {
 		"git_init",
 		"git_commit",
+		"git_undo_commit",
 		"git_remote_add",
 		"git_push",
 	},
@@ -79,6 +80,24 @@ var ToolGroups = map[ToolGroup][]string{
 	},
 }
 
+// untrustedContentGroups are the groups whose tools return content from outside
+// the conversation (files, command output, web pages, mail), which may carry
+// injected instructions
+var untrustedContentGroups = []ToolGroup{
+	GroupFSRead, GroupGitRead, GroupWeb, GroupRuntime, GroupQuality, GroupMail, GroupCalendar,
+}
+
+// ReturnsUntrustedContent reports whether a tool's output comes from outside
+// the conversation and should be treated as untrusted
+func ReturnsUntrustedContent(toolName string) bool {
+	for _, group := range untrustedContentGroups {
+		if slices.Contains(ToolGroups[group], toolName) {
+			return true
+		}
+	}
+	return false
+}
+
 // ToolProfile defines a named set of tool capabilities
 type ToolProfile string
 
@@ -226,3 +226,16 @@ func TestAllToolGroupsCoverAllTools(t *testing.T) {
 		}
 	}
 }
+
+func TestReturnsUntrustedContent(t *testing.T) {
+	for _, name := range []string{"read_file", "grep", "git_diff", "web_fetch", "bash", "run_tests", "mail_read"} {
+		if !ReturnsUntrustedContent(name) {
+			t.Errorf("expected %s output to be untrusted", name)
+		}
+	}
+	for _, name := range []string{"write_file", "git_commit", "task_complete", "store_memory", "nonexistent_tool"} {
+		if ReturnsUntrustedContent(name) {
+			t.Errorf("expected %s output not to be untrusted", name)
+		}
+	}
+}
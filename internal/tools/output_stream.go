@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// OutputFunc receives a running tool's output as it's produced
+type OutputFunc func(chunk string)
+
+// Limits on output streamed while a tool runs. The tool's result is unaffected.
+const (
+	StreamChunkSize     = 4 << 10                // Buffered output sent at once
+	StreamFlushInterval = 500 * time.Millisecond // Longest buffered output waits before it is sent
+	MaxStreamedOutput   = 256 << 10              // Output streamed per tool call; the rest is only in the result
+)
+
+// streamCappedNotice ends the stream when a tool's output passes MaxStreamedOutput
+const streamCappedNotice = "\n... (output no longer streamed; the full result follows when the tool finishes)\n"
+
+type outputKey struct{}
+
+// WithOutput returns a context whose commands stream their output to
+// onOutput as they run, in addition to returning it
+func WithOutput(ctx context.Context, onOutput OutputFunc) context.Context {
+	return context.WithValue(ctx, outputKey{}, onOutput)
+}
+
+// outputFunc returns the OutputFunc set with WithOutput, or nil
+func outputFunc(ctx context.Context) OutputFunc {
+	onOutput, _ := ctx.Value(outputKey{}).(OutputFunc)
+	return onOutput
+}
+
+// OutputStream batches written output into chunks for an OutputFunc and
+// stops streaming after MaxStreamedOutput bytes. Chunks are sent in order,
+// never holding the lock writers take, so a slow OutputFunc doesn't block the
+// command and one that writes to the stream doesn't deadlock.
+type OutputStream struct {
+	onOutput OutputFunc
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	sent    int
+	capped  bool
+	closed  bool
+	timer   *time.Timer // Flushes output that has waited StreamFlushInterval
+	pending []string    // Flushed chunks not yet sent
+	sending bool        // Whether a goroutine is sending pending chunks
+	idle    *sync.Cond  // Signalled when sending stops
+}
+
+// NewOutputStream creates an OutputStream sending to onOutput
+func NewOutputStream(onOutput OutputFunc) *OutputStream {
+	s := &OutputStream{onOutput: onOutput}
+	s.idle = sync.NewCond(&s.mu)
+	return s
+}
+
+// Write buffers p, sending the buffer once it's a chunk or has waited
+// StreamFlushInterval, even if the command writes nothing more meanwhile.
+// It never fails, so it can't interrupt the command.
+func (s *OutputStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	if s.capped || s.closed {
+		s.mu.Unlock()
+		return len(p), nil
+	}
+	s.buf.Write(p)
+	if s.buf.Len() >= StreamChunkSize {
+		s.flush(false)
+	} else if s.timer == nil {
+		s.timer = time.AfterFunc(StreamFlushInterval, s.flushWaiting)
+	}
+	s.mu.Unlock()
+	s.send()
+	return len(p), nil
+}
+
+// Close sends whatever is still buffered and waits until it has been sent
+func (s *OutputStream) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	if !s.capped {
+		s.flush(true)
+	}
+	s.closed = true
+	s.mu.Unlock()
+	s.send()
+
+	s.mu.Lock()
+	for s.sending {
+		s.idle.Wait()
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// flushWaiting sends output that has waited StreamFlushInterval
+func (s *OutputStream) flushWaiting() {
+	s.mu.Lock()
+	s.timer = nil
+	if !s.capped && !s.closed {
+		s.flush(false)
+	}
+	s.mu.Unlock()
+	s.send()
+}
+
+// flush queues the buffer to be sent, cut off at MaxStreamedOutput. Unless
+// final, a rune split across writes stays buffered until the rest of it
+// arrives. Callers hold s.mu.
+func (s *OutputStream) flush(final bool) {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	data := s.buf.Bytes()
+	if !final {
+		data = data[:len(data)-partialRuneLen(data)]
+	}
+	if len(data) == 0 {
+		return
+	}
+	chunk := string(data)
+	rest := s.buf.Bytes()[len(data):]
+	s.buf.Reset()
+	s.buf.Write(rest)
+	if remaining := MaxStreamedOutput - s.sent; len(chunk) > remaining {
+		// Cut at a rune boundary so the last streamed chunk stays valid UTF-8
+		cut := remaining
+		for cut > 0 && !utf8.RuneStart(chunk[cut]) {
+			cut--
+		}
+		chunk = chunk[:cut] + streamCappedNotice
+		s.capped = true
+		s.buf.Reset()
+	}
+	s.sent += len(chunk)
+	s.pending = append(s.pending, chunk)
+}
+
+// send passes pending chunks to onOutput in order, without holding s.mu. If
+// another goroutine is already sending, it sends them instead.
+func (s *OutputStream) send() {
+	s.mu.Lock()
+	if s.sending {
+		s.mu.Unlock()
+		return
+	}
+	s.sending = true
+	for len(s.pending) > 0 {
+		chunk := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+		s.onOutput(chunk)
+		s.mu.Lock()
+	}
+	s.sending = false
+	s.idle.Broadcast()
+	s.mu.Unlock()
+}
+
+// partialRuneLen returns the length of the incomplete rune ending b, or 0 if
+// b ends on a rune boundary
+func partialRuneLen(b []byte) int {
+	for n := 1; n < utf8.UTFMax && n <= len(b); n++ {
+		if utf8.RuneStart(b[len(b)-n]) {
+			if utf8.FullRune(b[len(b)-n:]) {
+				return 0
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// CombinedOutput runs cmd and returns its combined stdout and stderr, like
+// cmd.CombinedOutput, streaming it as it's produced if ctx was made by
+// WithOutput
+func CombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	onOutput := outputFunc(ctx)
+	if onOutput == nil {
+		return cmd.CombinedOutput()
+	}
+
+	var output bytes.Buffer
+	stream := NewOutputStream(onOutput)
+	w := io.MultiWriter(&output, stream)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
+	_ = stream.Close()
+	return output.Bytes(), err
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCombinedOutput_Streams(t *testing.T) {
+	var streamed strings.Builder
+	ctx := WithOutput(context.Background(), func(chunk string) {
+		streamed.WriteString(chunk)
+	})
+
+	cmd := exec.Command("bash", "-c", "echo building; echo warning >&2; echo done")
+	output, err := CombinedOutput(ctx, cmd)
+	if err != nil {
+		t.Fatalf("CombinedOutput() error = %v", err)
+	}
+	if want := "building\nwarning\ndone\n"; string(output) != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+	if streamed.String() != string(output) {
+		t.Errorf("streamed %q, want the output %q", streamed.String(), output)
+	}
+
+	// Without WithOutput nothing streams
+	output, err = CombinedOutput(context.Background(), exec.Command("bash", "-c", "echo quiet"))
+	if err != nil || string(output) != "quiet\n" {
+		t.Errorf("CombinedOutput() = %q, %v", output, err)
+	}
+}
+
+func TestOutputStream_Cap(t *testing.T) {
+	var chunks []string
+	stream := NewOutputStream(func(chunk string) { chunks = append(chunks, chunk) })
+
+	line := strings.Repeat("x", 1023) + "\n"
+	for range 2 * MaxStreamedOutput / len(line) {
+		_, _ = stream.Write([]byte(line))
+	}
+	_ = stream.Close()
+
+	streamed := strings.Join(chunks, "")
+	if got := len(streamed) - len(streamCappedNotice); got != MaxStreamedOutput {
+		t.Errorf("streamed %d bytes of output, want %d", got, MaxStreamedOutput)
+	}
+	if !strings.HasSuffix(streamed, streamCappedNotice) {
+		t.Error("capped stream doesn't end with the notice")
+	}
+	for _, chunk := range chunks[:len(chunks)-1] {
+		if len(chunk) < StreamChunkSize {
+			t.Errorf("sent a %d byte chunk before the stream closed, want at least %d", len(chunk), StreamChunkSize)
+		}
+	}
+}
+
+func TestOutputStream_FlushesWaitingOutput(t *testing.T) {
+	chunks := make(chan string, 1)
+	stream := NewOutputStream(func(chunk string) { chunks <- chunk })
+	defer stream.Close()
+
+	// A short line with nothing after it is still sent once it has waited
+	_, _ = stream.Write([]byte("compiling...\n"))
+	select {
+	case chunk := <-chunks:
+		if chunk != "compiling...\n" {
+			t.Errorf("streamed %q, want the waiting line", chunk)
+		}
+	case <-time.After(5 * StreamFlushInterval):
+		t.Fatal("waiting output wasn't flushed without further writes")
+	}
+}
+
+func TestOutputStream_CapKeepsRunes(t *testing.T) {
+	var streamed strings.Builder
+	stream := NewOutputStream(func(chunk string) { streamed.WriteString(chunk) })
+
+	// "é" is two bytes; an odd prefix puts the cap in the middle of one
+	_, _ = stream.Write([]byte("x"))
+	_, _ = stream.Write([]byte(strings.Repeat("é", MaxStreamedOutput)))
+	_ = stream.Close()
+
+	if !utf8.ValidString(streamed.String()) {
+		t.Error("capped stream splits a rune")
+	}
+	if got := len(streamed.String()) - len(streamCappedNotice); got != MaxStreamedOutput-1 {
+		t.Errorf("streamed %d bytes of output, want %d", got, MaxStreamedOutput-1)
+	}
+}
+
+func TestOutputStream_FlushKeepsRunes(t *testing.T) {
+	var chunks []string
+	stream := NewOutputStream(func(chunk string) { chunks = append(chunks, chunk) })
+
+	// "€" is three bytes; an odd prefix puts the chunk size inside one, and
+	// writing a byte at a time splits every rune across writes
+	output := "x" + strings.Repeat("€", 3*StreamChunkSize)
+	for i := range len(output) {
+		_, _ = stream.Write([]byte{output[i]})
+	}
+	_ = stream.Close()
+
+	if len(chunks) < 2 {
+		t.Fatalf("sent %d chunks, want the output split across several", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %d splits a rune", i)
+		}
+	}
+	if streamed := strings.Join(chunks, ""); streamed != output {
+		t.Errorf("streamed %d bytes, want the %d byte output", len(streamed), len(output))
+	}
+}
+
+func TestOutputStream_CallbackOutsideLock(t *testing.T) {
+	var stream *OutputStream
+	var streamed strings.Builder
+	stream = NewOutputStream(func(chunk string) {
+		// Writing back to the stream from the callback mustn't deadlock
+		if strings.HasPrefix(chunk, "first") {
+			_, _ = stream.Write([]byte(strings.Repeat("y", StreamChunkSize)))
+		}
+		streamed.WriteString(chunk)
+	})
+
+	first := "first" + strings.Repeat("x", StreamChunkSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = stream.Write([]byte(first))
+		_ = stream.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writing from the callback deadlocked")
+	}
+
+	if want := first + strings.Repeat("y", StreamChunkSize); streamed.String() != want {
+		t.Errorf("streamed %d bytes, want %d in order", streamed.Len(), len(want))
+	}
+}
@@ -19,10 +19,11 @@ var toolRegistry = map[string]func() Tool{
 	"git_log":    GitLogTool,
 
 	// Git write
-	"git_init":       GitInitTool,
-	"git_commit":     GitCommitTool,
-	"git_remote_add": GitRemoteAddTool,
-	"git_push":       GitPushTool,
+	"git_init":        GitInitTool,
+	"git_commit":      GitCommitTool,
+	"git_undo_commit": GitUndoCommitTool,
+	"git_remote_add":  GitRemoteAddTool,
+	"git_push":        GitPushTool,
 
 	// GitHub
 	"github_create_repo": GitHubCreateRepoTool,
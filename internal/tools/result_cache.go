@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"container/list"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MaxResultCacheSize is the most entries a tool result cache may hold
+const MaxResultCacheSize = 1000
+
+// CacheHitNote is appended to results served from a ResultCache
+const CacheHitNote = "\n\n[Cached result: nothing has been written since an identical call earlier in this session]"
+
+// cacheableTools are the read-only tools whose results depend only on the
+// worktree contents, so they can be reused until something writes to it
+var cacheableTools = map[string]bool{
+	"read_file":  true,
+	"list_files": true,
+	"glob":       true,
+	"grep":       true,
+}
+
+// IsCacheable reports whether a tool's results can be served from a ResultCache
+func IsCacheable(toolName string) bool {
+	return cacheableTools[toolName]
+}
+
+// ResultCache is an LRU cache of read-only tool results within a session.
+// Results are keyed by tool name and normalized input, and dropped when a
+// write tool may have changed what they saw: write_file drops reads of its
+// path and any listing or search that could include it; other write tools
+// (bash, git, quality gates) can touch anything, so they clear the cache.
+type ResultCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Front is most recently used
+	hits       int
+	misses     int
+}
+
+type cacheEntry struct {
+	key    string
+	tool   string
+	path   string // Cleaned path input, "." for the worktree root
+	output string
+}
+
+// NewResultCache creates a cache holding up to maxEntries results, capped at
+// MaxResultCacheSize. It returns nil if maxEntries isn't positive.
+func NewResultCache(maxEntries int) *ResultCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	return &ResultCache{
+		maxEntries: min(maxEntries, MaxResultCacheSize),
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the cached output of an identical earlier call
+func (c *ResultCache) Get(toolName string, input map[string]any) (string, bool) {
+	if !IsCacheable(toolName) {
+		return "", false
+	}
+	key := cacheKey(toolName, input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return "", false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).output, true
+}
+
+// Put caches a successful result, evicting the least recently used entry if full
+func (c *ResultCache) Put(toolName string, input map[string]any, output string) {
+	if !IsCacheable(toolName) {
+		return
+	}
+	key := cacheKey(toolName, input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).output = output
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{
+		key:    key,
+		tool:   toolName,
+		path:   inputPath(input),
+		output: output,
+	})
+	for c.order.Len() > c.maxEntries {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// Invalidate drops results a call to toolName may have made stale. Call it
+// for every tool that ran, whether or not it succeeded.
+func (c *ResultCache) Invalidate(toolName string, input map[string]any) {
+	if IsCacheable(toolName) {
+		return
+	}
+	if tool := GetToolByName(toolName); tool != nil && tool.ReadOnly {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if toolName != "write_file" {
+		c.entries = make(map[string]*list.Element)
+		c.order.Init()
+		return
+	}
+
+	written := inputPath(input)
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*cacheEntry)
+		switch entry.tool {
+		case "read_file":
+			if entry.path == written {
+				c.removeLocked(elem)
+			}
+		case "list_files":
+			if pathWithin(written, entry.path) {
+				c.removeLocked(elem)
+			}
+		default:
+			// Searches can match the written file anywhere
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+}
+
+// Stats returns how many lookups hit and missed the cache
+func (c *ResultCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Len returns the number of cached results
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *ResultCache) removeLocked(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+	c.order.Remove(elem)
+}
+
+// cacheKey identifies a call by tool name and input, with the path cleaned so
+// "./a.go" and "a.go" share an entry. encoding/json sorts map keys, so equal
+// inputs encode identically.
+func cacheKey(toolName string, input map[string]any) string {
+	normalized := make(map[string]any, len(input))
+	for k, v := range input {
+		normalized[k] = v
+	}
+	if _, ok := input["path"]; ok {
+		normalized["path"] = inputPath(input)
+	}
+	data, _ := json.Marshal(normalized)
+	return toolName + "\x00" + string(data)
+}
+
+// inputPath returns a tool input's cleaned path, "." for the worktree root
+func inputPath(input map[string]any) string {
+	path, _ := input["path"].(string)
+	return filepath.Clean(path)
+}
+
+// pathWithin reports whether path is dir or inside it
+func pathWithin(path, dir string) bool {
+	if dir == "." {
+		return true
+	}
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,86 @@
+package tools
+
+import "testing"
+
+func TestResultCache_HitsNormalizedInput(t *testing.T) {
+	c := NewResultCache(10)
+	c.Put("read_file", map[string]any{"path": "./src/main.go"}, "package main")
+
+	output, ok := c.Get("read_file", map[string]any{"path": "src/main.go"})
+	if !ok || output != "package main" {
+		t.Fatalf("Get = %q, %v; want cached result", output, ok)
+	}
+	if _, ok := c.Get("read_file", map[string]any{"path": "src/other.go"}); ok {
+		t.Error("different path should miss")
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Stats = %d hits, %d misses; want 1, 1", hits, misses)
+	}
+
+	// Only read-only tools are cached
+	c.Put("bash", map[string]any{"command": "ls"}, "main.go")
+	if _, ok := c.Get("bash", map[string]any{"command": "ls"}); ok {
+		t.Error("bash results should not be cached")
+	}
+}
+
+func TestResultCache_WriteFileInvalidatesAffectedResults(t *testing.T) {
+	c := NewResultCache(10)
+	c.Put("read_file", map[string]any{"path": "src/main.go"}, "old")
+	c.Put("read_file", map[string]any{"path": "README.md"}, "readme")
+	c.Put("list_files", map[string]any{"path": "src"}, "main.go")
+	c.Put("list_files", map[string]any{"path": "docs"}, "guide.md")
+	c.Put("grep", map[string]any{"pattern": "func"}, "src/main.go:1")
+
+	// Reads don't invalidate anything
+	c.Invalidate("git_status", nil)
+	if c.Len() != 5 {
+		t.Fatalf("Len = %d after read-only tool, want 5", c.Len())
+	}
+
+	c.Invalidate("write_file", map[string]any{"path": "src/main.go", "content": "new"})
+
+	for _, tc := range []struct {
+		tool   string
+		input  map[string]any
+		cached bool
+	}{
+		{"read_file", map[string]any{"path": "src/main.go"}, false},
+		{"read_file", map[string]any{"path": "README.md"}, true},
+		{"list_files", map[string]any{"path": "src"}, false},
+		{"list_files", map[string]any{"path": "docs"}, true},
+		{"grep", map[string]any{"pattern": "func"}, false},
+	} {
+		if _, ok := c.Get(tc.tool, tc.input); ok != tc.cached {
+			t.Errorf("%s %v: cached = %v, want %v", tc.tool, tc.input, ok, tc.cached)
+		}
+	}
+
+	// Other write tools could touch anything
+	c.Invalidate("bash", map[string]any{"command": "go generate ./..."})
+	if c.Len() != 0 {
+		t.Errorf("Len = %d after bash, want 0", c.Len())
+	}
+}
+
+func TestResultCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewResultCache(2)
+	c.Put("read_file", map[string]any{"path": "a"}, "a")
+	c.Put("read_file", map[string]any{"path": "b"}, "b")
+	c.Get("read_file", map[string]any{"path": "a"})
+	c.Put("read_file", map[string]any{"path": "c"}, "c")
+
+	if _, ok := c.Get("read_file", map[string]any{"path": "b"}); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	if _, ok := c.Get("read_file", map[string]any{"path": "a"}); !ok {
+		t.Error("recently used entry should be kept")
+	}
+
+	if NewResultCache(0) != nil {
+		t.Error("NewResultCache(0) should disable caching")
+	}
+	if c := NewResultCache(MaxResultCacheSize + 1); c.maxEntries != MaxResultCacheSize {
+		t.Errorf("maxEntries = %d, want cap %d", c.maxEntries, MaxResultCacheSize)
+	}
+}
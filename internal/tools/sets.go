@@ -92,6 +92,7 @@ func ReadWriteTools() *Set {
 		WriteFileTool(),
 		GitInitTool(),
 		GitCommitTool(),
+		GitUndoCommitTool(),
 		GitRemoteAddTool(),
 		GitPushTool(),
 		GitHubCreateRepoTool(),
@@ -137,6 +138,7 @@ func TaskExecutionTools() *Set {
 		WriteFileTool(),
 		GitInitTool(),
 		GitCommitTool(),
+		GitUndoCommitTool(),
 		GitRemoteAddTool(),
 		GitPushTool(),
 		GitHubCreateRepoTool(),
@@ -186,6 +188,7 @@ func AllTools() []Tool {
 		WriteFileTool(),
 		GitInitTool(),
 		GitCommitTool(),
+		GitUndoCommitTool(),
 		GitRemoteAddTool(),
 		GitPushTool(),
 		GitHubCreateRepoTool(),
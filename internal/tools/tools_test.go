@@ -53,8 +53,8 @@ func TestReadWriteToolSet(t *testing.T) {
 
 	// Count total tools
 	all := set.All()
-	if len(all) != 34 { // 10 read-only + 8 write + 4 quality gate + 12 mail/calendar tools
-		t.Errorf("Expected 34 tools, got %d", len(all))
+	if len(all) != 35 { // 10 read-only + 9 write + 4 quality gate + 12 mail/calendar tools
+		t.Errorf("Expected 35 tools, got %d", len(all))
 	}
 }
 
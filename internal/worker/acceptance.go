@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// runtimeCheckTimeout bounds how long a runtime may take to print its version
+const runtimeCheckTimeout = 10 * time.Second
+
+// AcceptanceCriteria are what a worker must have to take an objective. The
+// worker checks them before accepting, so an objective that needs, say,
+// Docker is rejected up front rather than failing partway through.
+type AcceptanceCriteria struct {
+	Tools     []string `json:"tools,omitempty"`       // Executables that must be on PATH (e.g. "docker")
+	Runtimes  []string `json:"runtimes,omitempty"`    // Language runtimes that must run (e.g. "go", "node")
+	MinDiskMB int      `json:"min_disk_mb,omitempty"` // Free space needed where projects are cloned
+}
+
+// runtimeVersionArgs makes runtimes that don't take --version print their version
+var runtimeVersionArgs = map[string][]string{
+	"go":     {"version"},
+	"java":   {"-version"},
+	"kotlin": {"-version"},
+	"scala":  {"-version"},
+	"zig":    {"version"},
+}
+
+// IsZero reports whether the criteria require nothing.
+func (c AcceptanceCriteria) IsZero() bool {
+	return len(c.Tools) == 0 && len(c.Runtimes) == 0 && c.MinDiskMB == 0
+}
+
+// Validate checks that tool and runtime names are plain executable names.
+func (c AcceptanceCriteria) Validate() error {
+	if c.MinDiskMB < 0 {
+		return fmt.Errorf("acceptance min_disk_mb must not be negative")
+	}
+	for _, name := range slices.Concat(c.Tools, c.Runtimes) {
+		if name == "" || strings.ContainsAny(name, "/\\ \t\n") {
+			return fmt.Errorf("invalid acceptance tool or runtime %q (must be an executable name)", name)
+		}
+	}
+	return nil
+}
+
+// Check returns a description of each criterion this machine doesn't meet,
+// with dataDir being where projects are cloned. It returns nil if all are met.
+func (c AcceptanceCriteria) Check(ctx context.Context, dataDir string) []string {
+	var unmet []string
+
+	for _, tool := range c.Tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			unmet = append(unmet, fmt.Sprintf("tool %s not found on PATH", tool))
+		}
+	}
+
+	for _, runtime := range c.Runtimes {
+		if err := checkRuntime(ctx, runtime); err != nil {
+			unmet = append(unmet, fmt.Sprintf("runtime %s: %v", runtime, err))
+		}
+	}
+
+	if c.MinDiskMB > 0 {
+		free, err := FreeDiskSpace(dataDir)
+		switch {
+		case err != nil:
+			unmet = append(unmet, fmt.Sprintf("free disk space unknown: %v", err))
+		case free>>20 < uint64(c.MinDiskMB):
+			unmet = append(unmet, fmt.Sprintf("needs %d MB free disk, has %d MB", c.MinDiskMB, free>>20))
+		}
+	}
+
+	return unmet
+}
+
+// checkRuntime verifies a runtime is installed and runs
+func checkRuntime(ctx context.Context, name string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("not installed")
+	}
+
+	args, ok := runtimeVersionArgs[name]
+	if !ok {
+		args = []string{"--version"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runtimeCheckTimeout)
+	defer cancel()
+	if output, err := exec.CommandContext(ctx, name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run: %s", firstLine(string(output), err))
+	}
+	return nil
+}
+
+// firstLine returns the first line of a command's output, or err if there is none
+func firstLine(output string, err error) string {
+	if line, _, _ := strings.Cut(strings.TrimSpace(output), "\n"); line != "" {
+		return line
+	}
+	return err.Error()
+}
+
+// handleObjectiveRejected re-queues an objective a worker rejected for unmet
+// acceptance criteria on another worker, or reports it if none is left. The
+// rejected dispatch doesn't count against the objective's attempts.
+func (m *Manager) handleObjectiveRejected(workerID string, payload *RejectedPayload) {
+	objectiveID := payload.ObjectiveID
+
+	m.mu.Lock()
+	obj, ok := m.inflight[objectiveID]
+	if ok && obj.workerID != workerID {
+		// Already re-queued elsewhere; this worker's report is stale
+		m.mu.Unlock()
+		return
+	}
+	onRejected := m.onRejected
+	if ok {
+		delete(m.inflight, objectiveID)
+	}
+	m.mu.Unlock()
+
+	fmt.Printf("Objective %s rejected by worker %s: %s\n", objectiveID, workerID, payload.Reason)
+
+	if !ok {
+		// Not tracked (e.g. dispatched before HQ restarted): nothing to re-queue
+		m.recordState(objectiveID, db.DispatchStateFailed, payload.Reason)
+		if onRejected != nil {
+			onRejected(objectiveID, workerID, payload.Unmet, false)
+		}
+		return
+	}
+
+	if onRejected != nil {
+		onRejected(objectiveID, workerID, payload.Unmet, true)
+	}
+	m.requeue(&dispatchRequest{
+		payload:    obj.payload,
+		secrets:    obj.secrets,
+		response:   make(chan error, 1),
+		attempts:   max(obj.attempts-1, 0),
+		exclude:    obj.timedOutOn,
+		rejectedBy: append(slices.Clone(obj.rejectedBy), workerID),
+	}, func(err error) {
+		fmt.Printf("Objective %s: no worker meets its acceptance criteria: %v\n", objectiveID, err)
+		if onRejected != nil {
+			onRejected(objectiveID, workerID, payload.Unmet, false)
+		}
+	})
+}
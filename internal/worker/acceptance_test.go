@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeExecutable writes a shell script named name into dir
+func fakeExecutable(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcceptanceCriteria_Check(t *testing.T) {
+	bin := t.TempDir()
+	fakeExecutable(t, bin, "fake-docker", "exit 0")
+	fakeExecutable(t, bin, "fake-node", "echo v20.0.0")
+	fakeExecutable(t, bin, "broken-ruby", "echo 'shim: ruby is not installed' >&2; exit 1")
+	t.Setenv("PATH", bin)
+
+	met := AcceptanceCriteria{Tools: []string{"fake-docker"}, Runtimes: []string{"fake-node"}}
+	if unmet := met.Check(context.Background(), t.TempDir()); len(unmet) != 0 {
+		t.Errorf("expected criteria to be met, got %v", unmet)
+	}
+
+	criteria := AcceptanceCriteria{
+		Tools:     []string{"fake-docker", "kubectl"},
+		Runtimes:  []string{"broken-ruby", "python3"},
+		MinDiskMB: 1 << 40,
+	}
+	unmet := criteria.Check(context.Background(), t.TempDir())
+	want := []string{
+		"tool kubectl not found on PATH",
+		"runtime broken-ruby: failed to run: shim: ruby is not installed",
+		"runtime python3: not installed",
+		"needs 1099511627776 MB free disk",
+	}
+	if len(unmet) != len(want) {
+		t.Fatalf("unmet = %v, want %d entries", unmet, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(unmet[i], w) && !strings.HasPrefix(unmet[i], "free disk space unknown") {
+			t.Errorf("unmet[%d] = %q, want %q", i, unmet[i], w)
+		}
+	}
+}
+
+func TestAcceptanceCriteria_Validate(t *testing.T) {
+	if err := (AcceptanceCriteria{Tools: []string{"docker"}, MinDiskMB: 100}).Validate(); err != nil {
+		t.Errorf("valid criteria rejected: %v", err)
+	}
+	for _, c := range []AcceptanceCriteria{
+		{MinDiskMB: -1},
+		{Tools: []string{"/usr/bin/docker"}},
+		{Runtimes: []string{"node; rm -rf /"}},
+		{Tools: []string{""}},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+}
+
+type rejectedEvent struct {
+	objectiveID, workerID string
+	unmet                 []string
+	requeued              bool
+}
+
+func TestObjectiveRejected_RequeuesOnAnotherWorker(t *testing.T) {
+	a, b := newPipeWorker(t, "worker-a"), newPipeWorker(t, "worker-b")
+	m, _ := newTimeoutTestManager(t, a, b)
+	events := make(chan rejectedEvent, 10)
+	m.SetOnRejected(func(objectiveID, workerID string, unmet []string, requeued bool) {
+		events <- rejectedEvent{objectiveID, workerID, unmet, requeued}
+	})
+
+	payload := &ObjectivePayload{Objective: Objective{
+		ID:         "obj-1",
+		Acceptance: AcceptanceCriteria{Tools: []string{"docker"}},
+	}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if len(a.waitFor(MsgTypeDispatch, 1)) != 1 {
+		t.Fatalf("expected worker-a to receive the objective")
+	}
+
+	rejected, _ := json.Marshal(RejectedPayload{ObjectiveID: "obj-1", Reason: "unmet", Unmet: []string{"tool docker not found on PATH"}})
+	m.processWorkerMessage("worker-a", &Message{Type: MsgTypeRejected, Payload: rejected})
+
+	ev := <-events
+	if ev.workerID != "worker-a" || !ev.requeued || len(ev.unmet) != 1 {
+		t.Errorf("unexpected rejected event: %+v", ev)
+	}
+
+	// Re-queued away from worker-a, without using up an attempt
+	a.setState(WorkerStateIdle)
+	req := <-m.queue
+	if req.attempts != 0 {
+		t.Errorf("attempts = %d, want 0", req.attempts)
+	}
+	if err := m.dispatch(req); err != nil {
+		t.Fatalf("re-dispatch failed: %v", err)
+	}
+	if len(b.waitFor(MsgTypeDispatch, 1)) != 1 {
+		t.Fatalf("expected re-queued objective on worker-b")
+	}
+
+	// With no other worker left, the objective is reported as not taken
+	b.setState(WorkerStateIdle)
+	m.processWorkerMessage("worker-b", &Message{Type: MsgTypeRejected, Payload: rejected})
+	if ev := <-events; !ev.requeued || ev.workerID != "worker-b" {
+		t.Errorf("unexpected rejected event: %+v", ev)
+	}
+	req = <-m.queue
+	err := m.dispatch(req)
+	if err == nil {
+		t.Fatal("expected dispatch with every worker excluded to fail")
+	}
+	req.response <- err
+	if ev := <-events; ev.requeued {
+		t.Errorf("expected final rejected event, got %+v", ev)
+	}
+}
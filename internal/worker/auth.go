@@ -0,0 +1,200 @@
+package worker
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/crypto"
+)
+
+// Mutual authentication handshake
+//
+// Every connection starts with a three-message exchange:
+//
+//  1. Worker -> HQ  ready:              signed by the worker, carries its public key,
+//     signing key, and a fresh challenge nonce.
+//  2. HQ -> Worker  challenge:          signed by HQ, echoes the worker's nonce and carries
+//     a fresh nonce encrypted to the worker's public key.
+//  3. Worker -> HQ  challenge_response: signed by the worker, carries the decrypted nonce.
+//
+// After the exchange both sides require every message to be signed by the peer.
+// From the challenge on, signatures also cover both nonces (handshakeBinding),
+// so a message captured on one connection doesn't verify on any other, even
+// within MaxMessageSkew and with a fresh sequence number.
+// HQ knows the worker owns the advertised public key (it could decrypt the nonce)
+// and signing key (it signed the response); the worker knows it is talking to the
+// HQ whose signing key it was configured with.
+
+// challengeNonceSize is the number of random bytes in a handshake nonce.
+const challengeNonceSize = 32
+
+// newChallengeNonce returns a random base64-encoded nonce.
+func newChallengeNonce() (string, error) {
+	b := make([]byte, challengeNonceSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// handshakeBinding returns the binding signatures on a connection carry once
+// its handshake has exchanged both nonces.
+func handshakeBinding(workerChallenge, hqNonce string) string {
+	return workerChallenge + "\n" + hqNonce
+}
+
+// noncesEqual compares two nonces in constant time.
+func noncesEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// SendAuthenticatedReady signs all further messages with the worker's identity
+// and sends a ready message carrying a fresh challenge for HQ, along with the
+// objective and session the worker is running, if any.
+// Returns the challenge, which must be passed to AwaitHQChallenge.
+func (c *Conn) SendAuthenticatedReady(identity *crypto.WorkerIdentity, version, objectiveID, sessionID string) (string, error) {
+	kp := identity.ToKeyPair()
+	if kp == nil {
+		return "", fmt.Errorf("worker identity has no private key")
+	}
+
+	challenge, err := newChallengeNonce()
+	if err != nil {
+		return "", err
+	}
+
+	c.SetSigner(kp)
+	if err := c.Send(MsgTypeReady, &ReadyPayload{
+		WorkerID:   identity.ID,
+		Version:    version,
+		PublicKey:  identity.PublicKey(),
+		SigningKey: kp.SigningPublicKey(),
+		Challenge:  challenge,
+
+		ObjectiveID:      objectiveID,
+		SessionID:        sessionID,
+		ReportsObjective: true,
+	}); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// AwaitHQChallenge reads HQ's challenge, verifies that it is signed by
+// hqSigningKey and echoes the worker's challenge, then answers it.
+// On success the connection rejects any further message not signed by HQ.
+//
+// If hqSigningKey is empty HQ's signature cannot be checked; the challenge is
+// still answered so HQ can authenticate the worker.
+func (c *Conn) AwaitHQChallenge(identity *crypto.WorkerIdentity, hqSigningKey, challenge string) error {
+	msg, err := c.Receive()
+	if err != nil {
+		return err
+	}
+	if msg.Type != MsgTypeChallenge {
+		return fmt.Errorf("expected challenge message, got %s", msg.Type)
+	}
+
+	payload, err := ParsePayload[ChallengePayload](msg)
+	if err != nil {
+		return fmt.Errorf("failed to parse challenge payload: %w", err)
+	}
+	if !noncesEqual(payload.WorkerChallenge, challenge) {
+		return fmt.Errorf("HQ authentication failed: challenge mismatch")
+	}
+
+	nonce, err := identity.Decrypt(payload.Nonce)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt HQ challenge: %w", err)
+	}
+
+	// HQ signed the challenge for this connection's nonces
+	binding := handshakeBinding(challenge, string(nonce))
+	if hqSigningKey != "" {
+		if err := verifyBoundMessage(msg, hqSigningKey, binding); err != nil {
+			return fmt.Errorf("HQ authentication failed: %w", err)
+		}
+		c.SetPeerSigningKey(hqSigningKey)
+	}
+	c.setBinding(binding)
+
+	return c.Send(MsgTypeChallengeResponse, &ChallengeResponsePayload{
+		Nonce: string(nonce),
+	})
+}
+
+// HQAuthenticator runs HQ's side of the handshake for a single connection.
+type HQAuthenticator struct {
+	hqKeyPair *crypto.KeyPair
+	ready     *ReadyPayload
+	nonce     string
+}
+
+// NewHQAuthenticator verifies a worker's ready message and prepares HQ's
+// challenge. The ready message must be signed by the signing key it advertises.
+func NewHQAuthenticator(hqKeyPair *crypto.KeyPair, readyMsg *Message) (*HQAuthenticator, *ReadyPayload, error) {
+	if readyMsg.Type != MsgTypeReady {
+		return nil, nil, fmt.Errorf("expected ready message, got %s", readyMsg.Type)
+	}
+
+	ready, err := ParsePayload[ReadyPayload](readyMsg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse ready payload: %w", err)
+	}
+	if ready.SigningKey == "" || ready.Challenge == "" {
+		return nil, nil, fmt.Errorf("worker %s did not offer authentication", ready.WorkerID)
+	}
+	if err := VerifyMessage(readyMsg, ready.SigningKey); err != nil {
+		return nil, nil, fmt.Errorf("worker %s authentication failed: %w", ready.WorkerID, err)
+	}
+
+	return &HQAuthenticator{hqKeyPair: hqKeyPair, ready: ready}, ready, nil
+}
+
+// SendChallenge signs all further messages on conn with HQ's key and sends
+// the challenge. Worker messages must be signed by the worker from now on.
+func (a *HQAuthenticator) SendChallenge(conn *Conn) error {
+	nonce, err := newChallengeNonce()
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := a.hqKeyPair.EncryptForRecipient([]byte(nonce), a.ready.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt challenge for worker %s: %w", a.ready.WorkerID, err)
+	}
+	a.nonce = nonce
+
+	conn.SetSigner(a.hqKeyPair)
+	conn.SetPeerSigningKey(a.ready.SigningKey)
+	conn.setBinding(handshakeBinding(a.ready.Challenge, nonce))
+
+	return conn.Send(MsgTypeChallenge, &ChallengePayload{
+		WorkerChallenge: a.ready.Challenge,
+		Nonce:           encrypted,
+	})
+}
+
+// VerifyResponse checks the worker's challenge response.
+func (a *HQAuthenticator) VerifyResponse(msg *Message) error {
+	if msg.Type != MsgTypeChallengeResponse {
+		return fmt.Errorf("expected challenge response, got %s", msg.Type)
+	}
+	if a.nonce == "" {
+		return fmt.Errorf("challenge not sent")
+	}
+	if err := verifyBoundMessage(msg, a.ready.SigningKey, handshakeBinding(a.ready.Challenge, a.nonce)); err != nil {
+		return fmt.Errorf("worker %s authentication failed: %w", a.ready.WorkerID, err)
+	}
+
+	payload, err := ParsePayload[ChallengeResponsePayload](msg)
+	if err != nil {
+		return fmt.Errorf("failed to parse challenge response: %w", err)
+	}
+	if !noncesEqual(payload.Nonce, a.nonce) {
+		return fmt.Errorf("worker %s authentication failed: challenge mismatch", a.ready.WorkerID)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/crypto"
+)
+
+// newConnPair returns two connected Conns (hq <-> worker) over in-memory pipes.
+func newConnPair() (hq *Conn, wk *Conn) {
+	hqToWorkerR, hqToWorkerW := io.Pipe()
+	workerToHQR, workerToHQW := io.Pipe()
+	return NewConn(workerToHQR, hqToWorkerW), NewConn(hqToWorkerR, workerToHQW)
+}
+
+func TestHandshake_MutualAuthentication(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	identity, _ := crypto.NewWorkerIdentity("worker-1")
+	hqConn, workerConn := newConnPair()
+
+	workerErr := make(chan error, 1)
+	go func() {
+		challenge, err := workerConn.SendAuthenticatedReady(identity, "1.0.0", "", "")
+		if err != nil {
+			workerErr <- err
+			return
+		}
+		workerErr <- workerConn.AwaitHQChallenge(identity, hqKeys.SigningPublicKey(), challenge)
+	}()
+
+	readyMsg, err := hqConn.Receive()
+	if err != nil {
+		t.Fatalf("Receive ready failed: %v", err)
+	}
+
+	authn, ready, err := NewHQAuthenticator(hqKeys, readyMsg)
+	if err != nil {
+		t.Fatalf("NewHQAuthenticator failed: %v", err)
+	}
+	if ready.WorkerID != "worker-1" || ready.PublicKey != identity.PublicKey() {
+		t.Errorf("Unexpected ready payload: %+v", ready)
+	}
+
+	if err := authn.SendChallenge(hqConn); err != nil {
+		t.Fatalf("SendChallenge failed: %v", err)
+	}
+
+	resp, err := hqConn.Receive()
+	if err != nil {
+		t.Fatalf("Receive response failed: %v", err)
+	}
+	if err := authn.VerifyResponse(resp); err != nil {
+		t.Fatalf("VerifyResponse failed: %v", err)
+	}
+	if err := <-workerErr; err != nil {
+		t.Fatalf("Worker side of handshake failed: %v", err)
+	}
+
+	// After the handshake both directions carry signed messages
+	go func() { _ = hqConn.SendPing() }()
+	msg, err := workerConn.Receive()
+	if err != nil {
+		t.Fatalf("Worker failed to receive signed ping: %v", err)
+	}
+	if msg.Type != MsgTypePing {
+		t.Errorf("Expected ping, got %s", msg.Type)
+	}
+}
+
+func TestHandshake_WorkerRejectsImpostorHQ(t *testing.T) {
+	realHQ, _ := crypto.GenerateKeyPair()
+	impostor, _ := crypto.GenerateKeyPair()
+	identity, _ := crypto.NewWorkerIdentity("worker-1")
+	hqConn, workerConn := newConnPair()
+
+	workerErr := make(chan error, 1)
+	go func() {
+		challenge, err := workerConn.SendAuthenticatedReady(identity, "1.0.0", "", "")
+		if err != nil {
+			workerErr <- err
+			return
+		}
+		workerErr <- workerConn.AwaitHQChallenge(identity, realHQ.SigningPublicKey(), challenge)
+	}()
+
+	readyMsg, _ := hqConn.Receive()
+	authn, _, err := NewHQAuthenticator(impostor, readyMsg)
+	if err != nil {
+		t.Fatalf("NewHQAuthenticator failed: %v", err)
+	}
+	if err := authn.SendChallenge(hqConn); err != nil {
+		t.Fatalf("SendChallenge failed: %v", err)
+	}
+
+	err = <-workerErr
+	if err == nil || !strings.Contains(err.Error(), "HQ authentication failed") {
+		t.Errorf("Expected HQ authentication failure, got %v", err)
+	}
+}
+
+func TestHandshake_HQRejectsForgedReady(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	identity, _ := crypto.NewWorkerIdentity("worker-1")
+	hqConn, workerConn := newConnPair()
+
+	go func() { _, _ = workerConn.SendAuthenticatedReady(identity, "1.0.0", "", "") }()
+
+	readyMsg, err := hqConn.Receive()
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+
+	// Swap in another worker's signing key - the signature no longer matches
+	other, _ := crypto.GenerateKeyPair()
+	readyMsg.Payload = []byte(strings.Replace(string(readyMsg.Payload),
+		identity.SigningPublicKey(), other.SigningPublicKey(), 1))
+
+	if _, _, err := NewHQAuthenticator(hqKeys, readyMsg); err == nil {
+		t.Error("Expected forged ready message to be rejected")
+	}
+}
+
+func TestHandshake_HQRejectsUnauthenticatedReady(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	hqConn, workerConn := newConnPair()
+
+	go func() { _ = workerConn.SendReady("worker-1", "1.0.0", "age1pubkey") }()
+
+	readyMsg, _ := hqConn.Receive()
+	if _, _, err := NewHQAuthenticator(hqKeys, readyMsg); err == nil {
+		t.Error("Expected ready without signing key to be rejected")
+	}
+}
+
+func TestConn_RejectsUnsignedMessageFromPeer(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	hqConn, workerConn := newConnPair()
+	workerConn.SetPeerSigningKey(hqKeys.SigningPublicKey())
+
+	// HQ conn has no signer configured
+	go func() { _ = hqConn.SendCancel("obj-1", "forged") }()
+
+	if _, err := workerConn.Receive(); err == nil {
+		t.Error("Expected unsigned message to be rejected")
+	}
+}
+
+func TestConn_SignatureCoversSequence(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	hqConn, workerConn := newConnPair()
+	hqConn.SetSigner(hqKeys)
+
+	go func() { _ = hqConn.SendCancel("obj-1", "test") }()
+
+	msg, err := workerConn.Receive()
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if err := VerifyMessage(msg, hqKeys.SigningPublicKey()); err != nil {
+		t.Fatalf("VerifyMessage failed: %v", err)
+	}
+
+	// Bumping the sequence number to slip past replay protection breaks the signature
+	msg.Seq += 10
+	if err := VerifyMessage(msg, hqKeys.SigningPublicKey()); err == nil {
+		t.Error("Expected signature check to fail after changing seq")
+	}
+}
+
+// authenticatedConns runs the handshake between HQ and a worker over fresh
+// pipes. toHQ writes raw lines to HQ's side, as someone on the wire could.
+func authenticatedConns(t *testing.T, hqKeys *crypto.KeyPair, identity *crypto.WorkerIdentity) (hq, wk *Conn, toHQ io.Writer) {
+	t.Helper()
+	hqToWorkerR, hqToWorkerW := io.Pipe()
+	workerToHQR, workerToHQW := io.Pipe()
+	t.Cleanup(func() { _ = hqToWorkerW.Close(); _ = workerToHQW.Close() })
+	hq, wk = NewConn(workerToHQR, hqToWorkerW), NewConn(hqToWorkerR, workerToHQW)
+
+	workerErr := make(chan error, 1)
+	go func() {
+		challenge, err := wk.SendAuthenticatedReady(identity, "1.0.0", "", "")
+		if err != nil {
+			workerErr <- err
+			return
+		}
+		workerErr <- wk.AwaitHQChallenge(identity, hqKeys.SigningPublicKey(), challenge)
+	}()
+
+	readyMsg, err := hq.Receive()
+	if err != nil {
+		t.Fatalf("Receive ready failed: %v", err)
+	}
+	authn, _, err := NewHQAuthenticator(hqKeys, readyMsg)
+	if err != nil {
+		t.Fatalf("NewHQAuthenticator failed: %v", err)
+	}
+	if err := authn.SendChallenge(hq); err != nil {
+		t.Fatalf("SendChallenge failed: %v", err)
+	}
+	resp, err := hq.Receive()
+	if err != nil {
+		t.Fatalf("Receive response failed: %v", err)
+	}
+	if err := authn.VerifyResponse(resp); err != nil {
+		t.Fatalf("VerifyResponse failed: %v", err)
+	}
+	if err := <-workerErr; err != nil {
+		t.Fatalf("Worker side of handshake failed: %v", err)
+	}
+	return hq, wk, workerToHQW
+}
+
+func TestConn_RejectsMessageReplayedOnAnotherConnection(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	identity, _ := crypto.NewWorkerIdentity("worker-1")
+
+	// Capture a signed resume request on the first connection
+	hq1, wk1, _ := authenticatedConns(t, hqKeys, identity)
+	go func() { _ = wk1.SendResumeRequest("worker-1", "obj-1", "sess-1") }()
+	captured, err := hq1.Receive()
+	if err != nil {
+		t.Fatalf("Receive on the first connection failed: %v", err)
+	}
+	line, err := json.Marshal(captured)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Its sequence number is still ahead on a new connection from the same
+	// worker, and it's well within MaxMessageSkew, but it was signed for the
+	// first connection's handshake
+	hq2, _, toHQ2 := authenticatedConns(t, hqKeys, identity)
+	go func() { _, _ = toHQ2.Write(append(line, '\n')) }()
+	if msg, err := hq2.Receive(); err == nil {
+		t.Fatalf("replayed %s message was accepted on another connection", msg.Type)
+	}
+}
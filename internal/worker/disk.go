@@ -0,0 +1,17 @@
+//go:build linux || darwin
+
+package worker
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// FreeDiskSpace returns the bytes available to unprivileged users on the filesystem holding path
+func FreeDiskSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
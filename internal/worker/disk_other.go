@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package worker
+
+import "errors"
+
+// FreeDiskSpace is not implemented on this platform
+func FreeDiskSpace(path string) (uint64, error) {
+	return 0, errors.New("disk space check not supported on this platform")
+}
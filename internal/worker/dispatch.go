@@ -2,12 +2,24 @@ package worker
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/lirancohen/dex/internal/crypto"
 )
 
+var (
+	// ErrSecretsKeyMismatch is returned when secrets could not be decrypted with
+	// this worker's key. HQ may have encrypted them for a key that has since
+	// rotated, so asking HQ to encrypt them again can succeed.
+	ErrSecretsKeyMismatch = errors.New("secrets not encrypted for this worker's key")
+
+	// ErrSecretsCorrupt is returned when the secrets payload is malformed.
+	// Re-sending the same secrets will not help.
+	ErrSecretsCorrupt = errors.New("secrets payload is corrupt")
+)
+
 // Dispatcher handles encrypting and dispatching objectives to workers.
 // It runs on HQ and uses worker public keys to encrypt payloads.
 type Dispatcher struct {
@@ -107,16 +119,28 @@ func (r *Receiver) EncryptCompletionReport(report *CompletionReport, hqPublicKey
 }
 
 // DecryptSecrets decrypts an encrypted secrets string (used for resumption).
+// Failures wrap ErrSecretsKeyMismatch when the payload was not encrypted for
+// this worker's key, and ErrSecretsCorrupt when the payload is malformed.
 func (r *Receiver) DecryptSecrets(encryptedSecrets string) (*WorkerSecrets, error) {
 	var secrets WorkerSecrets
 
+	if encryptedSecrets == "" {
+		return nil, fmt.Errorf("failed to decrypt secrets: %w: empty payload", ErrSecretsCorrupt)
+	}
+
 	decrypted, err := r.workerIdentity.Decrypt(encryptedSecrets)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt secrets: %w", err)
+		if errors.Is(err, crypto.ErrDecryptionFailed) {
+			return nil, fmt.Errorf("failed to decrypt secrets: %w: %v", ErrSecretsKeyMismatch, err)
+		}
+		if r.workerIdentity.ToKeyPair() == nil {
+			return nil, fmt.Errorf("failed to decrypt secrets: %w", err)
+		}
+		return nil, fmt.Errorf("failed to decrypt secrets: %w: %v", ErrSecretsCorrupt, err)
 	}
 
 	if err := json.Unmarshal(decrypted, &secrets); err != nil {
-		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+		return nil, fmt.Errorf("failed to parse secrets: %w: %v", ErrSecretsCorrupt, err)
 	}
 
 	return &secrets, nil
@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// The dispatch queue persists which objectives HQ has handed to workers and
+// how far each got, so a restarted HQ can pick up where it left off. Workers
+// don't survive an HQ restart (local workers are its subprocesses, remote ones
+// reconnect), so objectives that were running are dispatched again.
+
+// SetSecretsSource sets where secrets come from when an objective recovered
+// from the dispatch queue is dispatched again, since secrets aren't persisted.
+// Without one, recovered objectives are dispatched without secrets.
+func (m *Manager) SetSecretsSource(source func() (*WorkerSecrets, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secretsSource = source
+}
+
+// recordQueued persists a new objective as queued. It returns false if the
+// objective is already queued or running, in which case it mustn't be
+// dispatched again.
+func (m *Manager) recordQueued(payload *ObjectivePayload) (bool, error) {
+	if m.db == nil {
+		return true, nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode objective payload: %w", err)
+	}
+	return m.db.EnqueueDispatch(payload.Objective.ID, string(data))
+}
+
+// recordRequeued persists that an objective is waiting for another worker.
+func (m *Manager) recordRequeued(objectiveID string, attempts int, exclude []string) {
+	if m.db == nil {
+		return
+	}
+	if err := m.db.RequeueDispatch(objectiveID, attempts, exclude); err != nil {
+		fmt.Printf("Objective %s: failed to persist re-queue: %v\n", objectiveID, err)
+	}
+}
+
+// recordDispatched persists that an objective was sent to a worker.
+func (m *Manager) recordDispatched(objectiveID, workerID string, attempts int) {
+	if m.db == nil {
+		return
+	}
+	if err := m.db.MarkDispatched(objectiveID, workerID, attempts); err != nil {
+		fmt.Printf("Objective %s: failed to persist dispatch: %v\n", objectiveID, err)
+	}
+}
+
+// recordState persists an objective's progress through the dispatch queue.
+func (m *Manager) recordState(objectiveID, state, lastError string) {
+	if m.db == nil || objectiveID == "" {
+		return
+	}
+	if err := m.db.UpdateDispatchState(objectiveID, state, lastError); err != nil {
+		fmt.Printf("Objective %s: failed to persist dispatch state %s: %v\n", objectiveID, state, err)
+	}
+}
+
+// inflightObjectiveOn returns the objective a worker is running, or "" if none.
+func (m *Manager) inflightObjectiveOn(workerID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for id, obj := range m.inflight {
+		if obj.workerID == workerID {
+			return id
+		}
+	}
+	return ""
+}
+
+// recoverDispatches loads objectives a previous HQ run left queued or running
+// and queues them for dispatch. Ones that were running lost their worker with
+// the restart; that dispatch doesn't count against their attempts.
+func (m *Manager) recoverDispatches() {
+	if m.db == nil {
+		return
+	}
+
+	entries, err := m.db.ListActiveDispatches()
+	if err != nil {
+		fmt.Printf("Warning: failed to load dispatch queue: %v\n", err)
+		return
+	}
+
+	var recovered []*dispatchRequest
+	for _, entry := range entries {
+		var payload ObjectivePayload
+		if err := json.Unmarshal([]byte(entry.Payload), &payload); err != nil {
+			fmt.Printf("Objective %s: dropping unreadable dispatch queue entry: %v\n", entry.ObjectiveID, err)
+			m.recordState(entry.ObjectiveID, db.DispatchStateFailed, "unreadable payload")
+			continue
+		}
+
+		attempts := entry.Attempts
+		if entry.State != db.DispatchStateQueued {
+			attempts = max(attempts-1, 0)
+			m.recordRequeued(entry.ObjectiveID, attempts, entry.ExcludedWorkers)
+		}
+		recovered = append(recovered, &dispatchRequest{
+			payload:  &payload,
+			attempts: attempts,
+			exclude:  entry.ExcludedWorkers,
+		})
+	}
+
+	if len(recovered) > 0 {
+		fmt.Printf("Recovered %d objectives from the dispatch queue\n", len(recovered))
+	}
+
+	m.mu.Lock()
+	m.recovered = recovered
+	m.mu.Unlock()
+}
+
+// dispatchRecovered dispatches recovered objectives while workers are idle.
+// Objectives that can't be dispatched yet are retried on the next call.
+func (m *Manager) dispatchRecovered() {
+	m.mu.Lock()
+	pending := m.recovered
+	m.recovered = nil
+	source := m.secretsSource
+	m.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	var secrets *WorkerSecrets
+	if source != nil {
+		var err error
+		if secrets, err = source(); err != nil {
+			fmt.Printf("Warning: failed to get secrets for recovered objectives: %v\n", err)
+			m.mu.Lock()
+			m.recovered = append(pending, m.recovered...)
+			m.mu.Unlock()
+			return
+		}
+	}
+
+	var waiting []*dispatchRequest
+	var lastErr error
+	for _, req := range pending {
+		req.secrets = secrets
+		if err := m.dispatch(req); err != nil {
+			waiting = append(waiting, req)
+			lastErr = err
+			continue
+		}
+		fmt.Printf("Objective %s: re-dispatched after HQ restart\n", req.payload.Objective.ID)
+	}
+
+	if len(waiting) > 0 {
+		fmt.Printf("%d recovered objectives waiting for a worker: %v\n", len(waiting), lastErr)
+		m.mu.Lock()
+		m.recovered = append(waiting, m.recovered...)
+		m.mu.Unlock()
+	}
+}
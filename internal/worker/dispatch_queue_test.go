@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+func openTestDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "hq.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func TestDispatchQueue_DuplicateDispatchIsNoOp(t *testing.T) {
+	a, b := newPipeWorker(t, "worker-a"), newPipeWorker(t, "worker-b")
+	m, _ := newTimeoutTestManager(t, a, b)
+	m.db = openTestDB(t)
+
+	payload := &ObjectivePayload{Objective: Objective{ID: "obj-1"}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("duplicate dispatch failed: %v", err)
+	}
+
+	if got := len(a.waitFor(MsgTypeDispatch, 1)) + len(b.waitFor(MsgTypeDispatch, 1)); got != 1 {
+		t.Errorf("objective dispatched %d times, want once", got)
+	}
+	entry, _ := m.db.GetDispatch("obj-1")
+	if entry == nil || entry.State != db.DispatchStateDispatched || entry.Attempts != 1 {
+		t.Errorf("unexpected dispatch entry: %+v", entry)
+	}
+
+	// The worker's reports move it through the queue
+	workerID := entry.WorkerID
+	accepted, _ := json.Marshal(&AcceptedPayload{ObjectiveID: "obj-1", SessionID: "sess-1"})
+	m.processWorkerMessage(workerID, &Message{Type: MsgTypeAccepted, Payload: accepted})
+	if entry, _ = m.db.GetDispatch("obj-1"); entry.State != db.DispatchStateAccepted {
+		t.Errorf("state = %q, want accepted", entry.State)
+	}
+
+	completed, _ := json.Marshal(&CompletedPayload{Report: &CompletionReport{ObjectiveID: "obj-1"}})
+	m.processWorkerMessage(workerID, &Message{Type: MsgTypeCompleted, Payload: completed})
+	if entry, _ = m.db.GetDispatch("obj-1"); entry.State != db.DispatchStateCompleted {
+		t.Errorf("state = %q, want completed", entry.State)
+	}
+}
+
+func TestDispatchQueue_RecoversAfterRestart(t *testing.T) {
+	database := openTestDB(t)
+
+	// A previous run left one objective running and one waiting
+	for _, id := range []string{"obj-running", "obj-waiting"} {
+		data, _ := json.Marshal(&ObjectivePayload{Objective: Objective{ID: id, Title: id}})
+		if _, err := database.EnqueueDispatch(id, string(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.MarkDispatched("obj-running", "local-old", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newPipeWorker(t, "worker-a")
+	m, _ := newTimeoutTestManager(t, a)
+	m.db = database
+	m.SetSecretsSource(func() (*WorkerSecrets, error) { return nil, nil })
+
+	m.recoverDispatches()
+	running, _ := database.GetDispatch("obj-running")
+	if running.State != db.DispatchStateQueued || running.Attempts != 0 {
+		t.Errorf("orphaned objective = %+v, want queued with the lost attempt uncounted", running)
+	}
+
+	// One idle worker: the first recovered objective goes out, the other waits
+	m.dispatchRecovered()
+	if len(a.waitFor(MsgTypeDispatch, 1)) != 1 {
+		t.Fatal("expected a recovered objective to be dispatched")
+	}
+	m.mu.RLock()
+	waiting := len(m.recovered)
+	m.mu.RUnlock()
+	if waiting != 1 {
+		t.Errorf("%d objectives waiting, want 1", waiting)
+	}
+
+	// Once the worker frees up, the rest follows
+	a.setState(WorkerStateIdle)
+	m.dispatchRecovered()
+	if len(a.waitFor(MsgTypeDispatch, 2)) != 2 {
+		t.Fatal("expected the waiting objective to be dispatched")
+	}
+	active, _ := database.ListActiveDispatches()
+	for _, entry := range active {
+		if entry.State != db.DispatchStateDispatched || entry.WorkerID != "worker-a" {
+			t.Errorf("unexpected entry after recovery: %+v", entry)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -492,6 +493,9 @@ func TestReceiver_DecryptSecrets_InvalidData(t *testing.T) {
 	if err == nil {
 		t.Error("DecryptSecrets should fail with invalid data")
 	}
+	if !errors.Is(err, ErrSecretsCorrupt) {
+		t.Errorf("invalid data should be reported as corrupt, got %v", err)
+	}
 }
 
 func TestReceiver_DecryptSecrets_WrongWorker(t *testing.T) {
@@ -520,4 +524,31 @@ func TestReceiver_DecryptSecrets_WrongWorker(t *testing.T) {
 	if err == nil {
 		t.Error("DecryptSecrets should fail when wrong worker tries to decrypt")
 	}
+	if !errors.Is(err, ErrSecretsKeyMismatch) {
+		t.Errorf("wrong worker should be reported as a key mismatch, got %v", err)
+	}
+	if errors.Is(err, ErrSecretsCorrupt) {
+		t.Errorf("wrong worker should not be reported as corrupt, got %v", err)
+	}
+}
+
+func TestReceiver_DecryptSecrets_CorruptPlaintext(t *testing.T) {
+	workerIdentity, _ := crypto.NewWorkerIdentity("worker-corrupt")
+	receiver := NewReceiver(workerIdentity)
+
+	// Valid encryption for this worker, but the plaintext isn't secrets JSON
+	encrypted, err := crypto.EncryptForRecipientStatic([]byte("not json"), workerIdentity.PublicKey())
+	if err != nil {
+		t.Fatalf("EncryptForRecipientStatic failed: %v", err)
+	}
+
+	_, err = receiver.DecryptSecrets(encrypted)
+	if !errors.Is(err, ErrSecretsCorrupt) {
+		t.Errorf("unparseable secrets should be reported as corrupt, got %v", err)
+	}
+
+	_, err = receiver.DecryptSecrets("")
+	if !errors.Is(err, ErrSecretsCorrupt) {
+		t.Errorf("empty secrets should be reported as corrupt, got %v", err)
+	}
 }
@@ -28,6 +28,9 @@ type WorkerToolExecutor struct {
 	workDir      string
 	qualityGate  *WorkerQualityGate
 	cmdRunner    CommandRunner
+	network      NetworkPolicy
+	gitHosts     []string // Hosts of the project's git remote, allowed by git-only and allowlist policies
+	baseBranch   string   // Objective's base branch; git_undo_commit won't reset commits already on it
 }
 
 // NewWorkerToolExecutor creates a new tool executor for the worker.
@@ -64,22 +67,104 @@ func (e *WorkerToolExecutor) SetGitHubClient(client *toolbelt.GitHubClient) {
 	e.githubClient = client
 }
 
+// SetBaseBranch sets the objective's base branch, whose commits git_undo_commit won't reset.
+func (e *WorkerToolExecutor) SetBaseBranch(branch string) {
+	e.baseBranch = branch
+}
+
 // SetQualityGate sets the quality gate for task completion validation.
 func (e *WorkerToolExecutor) SetQualityGate(qg *WorkerQualityGate) {
 	e.qualityGate = qg
 }
 
+// SetNetworkPolicy restricts the network access of tool execution. remoteURL
+// is the project's git remote; if empty, the origin remote is used. Restricted
+// policies fail if shell commands can't be isolated from the network.
+func (e *WorkerToolExecutor) SetNetworkPolicy(policy NetworkPolicy, remoteURL string) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if !policy.Restricted() {
+		e.network = policy
+		return nil
+	}
+	if err := checkNetworkIsolation(); err != nil {
+		return err
+	}
+
+	if remoteURL == "" {
+		remoteURL = e.originURL()
+	}
+	e.network = policy
+	e.gitHosts = gitRemoteHosts(remoteURL)
+
+	// Shell commands can reach anything, so they get no network at all
+	e.Executor.SetCommandPrefix(networkIsolationPrefix)
+	isolated := NewPrefixedCommandRunner(networkIsolationPrefix)
+	if e.qualityGate == nil {
+		e.qualityGate = NewWorkerQualityGateWithRunner(e.workDir, isolated)
+	} else {
+		e.qualityGate.SetCommandRunner(isolated)
+	}
+	return nil
+}
+
+// checkNetwork returns an error if the network policy blocks a tool call.
+func (e *WorkerToolExecutor) checkNetwork(toolName string, input map[string]any) error {
+	if !e.network.Restricted() {
+		return nil
+	}
+
+	switch toolName {
+	case "web_fetch":
+		rawURL, _ := input["url"].(string)
+		return e.network.CheckURL(rawURL, e.gitHosts)
+	case "web_search":
+		return e.network.CheckURL("https://lite.duckduckgo.com/", e.gitHosts)
+	case "git_remote_add":
+		rawURL, _ := input["url"].(string)
+		return e.network.CheckURL(rawURL, e.gitHosts)
+	case "git_push":
+		// Check where origin points now, since it can be changed locally
+		return e.network.CheckURL(e.originURL(), e.gitHosts)
+	case "github_create_pr":
+		// Creating a PR pushes the branch first
+		if err := e.network.CheckURL(e.originURL(), e.gitHosts); err != nil {
+			return err
+		}
+		return e.network.CheckURL("https://api.github.com/", e.gitHosts)
+	case "github_create_repo":
+		return e.network.CheckURL("https://api.github.com/", e.gitHosts)
+	}
+	return nil
+}
+
+// originURL returns the URL of the origin remote, or "" if there isn't one.
+func (e *WorkerToolExecutor) originURL() string {
+	result := e.cmdRunner.RunGit(context.Background(), e.workDir, "remote", "get-url", "origin")
+	if result.Err != nil {
+		return ""
+	}
+	return strings.TrimSpace(result.Output)
+}
+
 // Execute runs a tool with the given input and returns the result.
 // Overrides base executor for tools that need git.Operations or GitHub client.
 func (e *WorkerToolExecutor) Execute(ctx context.Context, toolName string, input map[string]any) ToolResult {
 	var result ToolResult
 
+	if err := e.checkNetwork(toolName, input); err != nil {
+		return ToolResult{Output: err.Error(), IsError: true}
+	}
+
 	switch toolName {
 	// Tools that need advanced git operations
 	case "git_diff":
 		result = e.executeGitDiff(input)
 	case "git_commit":
 		result = e.executeGitCommit(input)
+	case "git_undo_commit":
+		result = e.executeGitUndoCommit(input)
 	case "git_push":
 		result = e.executeGitPush(input)
 	case "git_remote_add":
@@ -188,6 +273,30 @@ func (e *WorkerToolExecutor) executeGitCommit(input map[string]any) ToolResult {
 	}
 }
 
+func (e *WorkerToolExecutor) executeGitUndoCommit(input map[string]any) ToolResult {
+	if e.gitOps == nil {
+		return ToolResult{Output: "Git operations not configured", IsError: true}
+	}
+
+	mode, _ := input["mode"].(string)
+	if mode != "" && !git.IsValidUndoMode(mode) {
+		return ToolResult{Output: "mode must be soft or revert", IsError: true}
+	}
+
+	result, err := e.gitOps.UndoLastCommit(e.workDir, git.UndoCommitOptions{
+		Mode:       git.UndoMode(mode),
+		ProtectRef: e.baseBranch,
+	})
+	if err != nil {
+		return ToolResult{
+			Output:  fmt.Sprintf("git undo commit failed: %v", err),
+			IsError: true,
+		}
+	}
+
+	return ToolResult{Output: result.Summary(), IsError: false}
+}
+
 func (e *WorkerToolExecutor) executeGitPush(input map[string]any) ToolResult {
 	if e.gitOps == nil {
 		return ToolResult{Output: "Git operations not configured", IsError: true}
@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"time"
 
+	"github.com/lirancohen/dex/internal/crypto"
 	"github.com/lirancohen/dex/internal/toolbelt"
 )
 
@@ -35,16 +36,26 @@ type CommandRunner interface {
 }
 
 // ExecCommandRunner is the default implementation using os/exec.
-type ExecCommandRunner struct{}
+type ExecCommandRunner struct {
+	prefix []string // Prepended to shell commands, e.g. to sandbox them
+}
 
 // NewExecCommandRunner creates a new command runner using os/exec.
 func NewExecCommandRunner() *ExecCommandRunner {
 	return &ExecCommandRunner{}
 }
 
+// NewPrefixedCommandRunner creates a command runner that runs shell commands
+// through prefix, e.g. []string{"unshare", "--net", "--"}. Git commands are
+// run directly.
+func NewPrefixedCommandRunner(prefix []string) *ExecCommandRunner {
+	return &ExecCommandRunner{prefix: prefix}
+}
+
 // Run executes a shell command using bash.
 func (r *ExecCommandRunner) Run(ctx context.Context, workDir, command string) *CommandResult {
-	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	args := append(append([]string(nil), r.prefix...), "bash", "-c", command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 	cmd.Dir = workDir
 
 	output, err := cmd.CombinedOutput()
@@ -159,6 +170,8 @@ type WorkerStatus struct {
 	StartedAt    time.Time   `json:"started_at,omitempty"`    // When worker started
 	Error        string      `json:"error,omitempty"`         // Error message if in error state
 	Version      string      `json:"version,omitempty"`       // Worker binary version
+	Timeouts     int         `json:"timeouts,omitempty"`      // Objectives that timed out on this worker
+	DBSizeBytes  int64       `json:"db_size_bytes,omitempty"` // Local database size from the last heartbeat
 }
 
 // WorkerConfig contains configuration for spawning a worker.
@@ -181,7 +194,8 @@ type WorkerConfig struct {
 	PublicKey string // Base64-encoded public key for encryption
 
 	// Common:
-	HQPublicKey string // HQ's public key for worker to encrypt responses
+	HQPublicKey string          // HQ's public key for worker to encrypt responses
+	HQKeyPair   *crypto.KeyPair // HQ's keypair for the authentication handshake (nil = no auth)
 }
 
 // ManagerConfig contains configuration for the WorkerManager.
@@ -210,10 +224,25 @@ type ManagerConfig struct {
 	// Default: 10 seconds
 	HealthCheckInterval time.Duration
 
+	// HeartbeatInterval is how often workers send heartbeats. The registry
+	// marks a worker offline after three intervals without one.
+	// Default: 10 seconds
+	HeartbeatInterval time.Duration
+
 	// StalledWorkerThreshold is how long without a heartbeat before a worker is considered stalled.
+	// A running objective whose worker is silent this long is treated as timed out.
 	// Default: 60 seconds
 	StalledWorkerThreshold time.Duration
 
+	// ObjectiveTimeout is the runtime limit for objectives that don't set their own.
+	// Default: 2 hours
+	ObjectiveTimeout time.Duration
+
+	// MaxObjectiveAttempts is how many workers an objective is tried on before it is
+	// marked timed out. Re-queued attempts prefer a worker that hasn't timed out on it.
+	// Default: 2
+	MaxObjectiveAttempts int
+
 	// HQKeyPair is HQ's keypair for encrypting payloads.
 	HQPublicKey string
 }
@@ -225,6 +254,9 @@ func DefaultManagerConfig() *ManagerConfig {
 		MaxRemoteWorkers:       0, // Unlimited
 		SpawnTimeout:           30 * time.Second,
 		HealthCheckInterval:    10 * time.Second,
+		HeartbeatInterval:      DefaultHeartbeatInterval,
 		StalledWorkerThreshold: 60 * time.Second,
+		ObjectiveTimeout:       2 * time.Hour,
+		MaxObjectiveAttempts:   2,
 	}
 }
@@ -30,6 +30,7 @@ type LocalWorker struct {
 	startedAt     time.Time
 	workerPubKey  string
 	version       string
+	dbSizeBytes   int64
 	err           error
 
 	mu        sync.RWMutex
@@ -66,6 +67,30 @@ func (w *LocalWorker) Type() WorkerType {
 
 // Start spawns the worker subprocess and waits for it to be ready.
 func (w *LocalWorker) Start(ctx context.Context) error {
+	if err := w.spawn(ctx); err != nil {
+		return err
+	}
+
+	// Wait for the handshake outside the lock: handleMessage needs it to
+	// forward the worker's messages to us.
+	if err := w.awaitReady(ctx); err != nil {
+		w.mu.Lock()
+		w.state = WorkerStateError
+		w.err = err
+		w.mu.Unlock()
+		_ = w.cmd.Process.Kill()
+		return err
+	}
+
+	w.mu.Lock()
+	w.state = WorkerStateIdle
+	w.lastActivity = time.Now()
+	w.mu.Unlock()
+	return nil
+}
+
+// spawn starts the worker subprocess and its receive loop.
+func (w *LocalWorker) spawn(ctx context.Context) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -98,6 +123,9 @@ func (w *LocalWorker) Start(ctx context.Context) error {
 	if w.config.HQPublicKey != "" {
 		args = append(args, fmt.Sprintf("--hq-public-key=%s", w.config.HQPublicKey))
 	}
+	if w.config.HQKeyPair != nil {
+		args = append(args, fmt.Sprintf("--hq-signing-key=%s", w.config.HQKeyPair.SigningPublicKey()))
+	}
 
 	w.cmd = exec.CommandContext(ctx, binaryPath, args...)
 
@@ -140,38 +168,65 @@ func (w *LocalWorker) Start(ctx context.Context) error {
 	// Start stderr logger goroutine
 	go w.logStderr()
 
-	// Wait for ready message
-	select {
-	case msg := <-w.eventChan:
+	return nil
+}
+
+// awaitReady waits for the worker's ready message and, when HQ has a keypair,
+// runs the mutual authentication handshake.
+func (w *LocalWorker) awaitReady(ctx context.Context) error {
+	timeout := time.After(30 * time.Second)
+
+	next := func() (*Message, error) {
+		select {
+		case msg, ok := <-w.eventChan:
+			if !ok {
+				return nil, fmt.Errorf("worker exited during handshake")
+			}
+			return msg, nil
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for worker ready")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	msg, err := next()
+	if err != nil {
+		return err
+	}
+
+	var ready *ReadyPayload
+	if w.config.HQKeyPair != nil {
+		authn, payload, err := NewHQAuthenticator(w.config.HQKeyPair, msg)
+		if err != nil {
+			return err
+		}
+		if err := authn.SendChallenge(w.conn); err != nil {
+			return fmt.Errorf("failed to send challenge: %w", err)
+		}
+		resp, err := next()
+		if err != nil {
+			return err
+		}
+		if err := authn.VerifyResponse(resp); err != nil {
+			return err
+		}
+		ready = payload
+	} else {
 		if msg.Type != MsgTypeReady {
-			w.state = WorkerStateError
-			w.err = fmt.Errorf("expected ready message, got %s", msg.Type)
-			return w.err
+			return fmt.Errorf("expected ready message, got %s", msg.Type)
 		}
-		ready, err := ParsePayload[ReadyPayload](msg)
+		ready, err = ParsePayload[ReadyPayload](msg)
 		if err != nil {
-			w.state = WorkerStateError
-			w.err = fmt.Errorf("failed to parse ready payload: %w", err)
-			return w.err
+			return fmt.Errorf("failed to parse ready payload: %w", err)
 		}
-		w.workerPubKey = ready.PublicKey
-		w.version = ready.Version
-		w.state = WorkerStateIdle
-		w.lastActivity = time.Now()
-		return nil
-
-	case <-time.After(30 * time.Second):
-		w.state = WorkerStateError
-		w.err = fmt.Errorf("timeout waiting for worker ready")
-		_ = w.cmd.Process.Kill()
-		return w.err
-
-	case <-ctx.Done():
-		w.state = WorkerStateError
-		w.err = ctx.Err()
-		_ = w.cmd.Process.Kill()
-		return w.err
 	}
+
+	w.mu.Lock()
+	w.workerPubKey = ready.PublicKey
+	w.version = ready.Version
+	w.mu.Unlock()
+	return nil
 }
 
 // receiveLoop continuously reads messages from the worker.
@@ -181,6 +236,10 @@ func (w *LocalWorker) receiveLoop() {
 
 	for {
 		msg, err := w.conn.Receive()
+		if IsRejectedMessage(err) {
+			fmt.Fprintf(os.Stderr, "[worker:%s] dropped message: %v\n", w.id, err)
+			continue
+		}
 		if err != nil {
 			// Check if we're shutting down
 			w.mu.RLock()
@@ -209,7 +268,7 @@ func (w *LocalWorker) handleMessage(msg *Message) {
 	w.lastActivity = time.Now()
 
 	switch msg.Type {
-	case MsgTypeReady, MsgTypePong:
+	case MsgTypeReady, MsgTypeChallengeResponse, MsgTypePong:
 		// Send to event channel for synchronous handling
 		select {
 		case w.eventChan <- msg:
@@ -223,6 +282,11 @@ func (w *LocalWorker) handleMessage(msg *Message) {
 			w.sessionID = payload.SessionID
 			w.state = WorkerStateRunning
 		}
+		// Forward so the manager can record the objective as accepted
+		select {
+		case w.eventChan <- msg:
+		default:
+		}
 
 	case MsgTypeProgress:
 		payload, _ := ParsePayload[ProgressPayload](msg)
@@ -243,6 +307,20 @@ func (w *LocalWorker) handleMessage(msg *Message) {
 		default:
 		}
 
+	case MsgTypeLog:
+		// Forward to event channel for manager to stream to operators
+		select {
+		case w.eventChan <- msg:
+		default:
+		}
+
+	case MsgTypeResumeRequest:
+		// Forward so the manager can send the secrets to resume with
+		select {
+		case w.eventChan <- msg:
+		default:
+		}
+
 	case MsgTypeCompleted:
 		w.state = WorkerStateIdle
 		w.objectiveID = ""
@@ -254,7 +332,7 @@ func (w *LocalWorker) handleMessage(msg *Message) {
 		default:
 		}
 
-	case MsgTypeFailed:
+	case MsgTypeFailed, MsgTypeRejected, MsgTypePlanPreview:
 		w.state = WorkerStateIdle
 		w.objectiveID = ""
 		w.sessionID = ""
@@ -282,6 +360,9 @@ func (w *LocalWorker) handleMessage(msg *Message) {
 	case MsgTypeHeartbeat:
 		// Update heartbeat timestamp
 		w.lastHeartbeat = time.Now()
+		if payload, _ := ParsePayload[HeartbeatPayload](msg); payload != nil {
+			w.dbSizeBytes = payload.DBSizeBytes
+		}
 		// Forward to event channel for manager
 		select {
 		case w.eventChan <- msg:
@@ -354,6 +435,7 @@ func (w *LocalWorker) Status() *WorkerStatus {
 		StartedAt:    w.startedAt,
 		Error:        errToString(w.err),
 		Version:      w.version,
+		DBSizeBytes:  w.dbSizeBytes,
 	}
 }
 
@@ -368,9 +450,19 @@ func (w *LocalWorker) Cancel(ctx context.Context) error {
 		return nil // Nothing to cancel
 	}
 
+	return w.cancelObjective(objectiveID)
+}
+
+// cancelObjective sends a cancel for the objective, whether or not it's the current one.
+func (w *LocalWorker) cancelObjective(objectiveID string) error {
 	return w.conn.SendCancel(objectiveID, "cancelled by HQ")
 }
 
+// sendResume answers the worker's resume request.
+func (w *LocalWorker) sendResume(payload *ResumePayload) error {
+	return w.conn.SendResume(payload)
+}
+
 // Stop gracefully stops the worker.
 func (w *LocalWorker) Stop(ctx context.Context) error {
 	w.mu.Lock()
@@ -64,6 +64,18 @@ func (ldb *LocalDB) Close() error {
 	return ldb.db.Close()
 }
 
+// IntegrityCheck runs SQLite's quick_check and returns an error if the database is damaged.
+func (ldb *LocalDB) IntegrityCheck() error {
+	var result string
+	if err := ldb.db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
 // migrate runs database migrations.
 func (ldb *LocalDB) migrate() error {
 	migrations := []string{
@@ -84,6 +96,9 @@ func (ldb *LocalDB) migrate() error {
 			started_at DATETIME,
 			completed_at DATETIME,
 			hq_public_key TEXT,
+			network_policy TEXT,
+			outputs TEXT,
+			model TEXT,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 		`CREATE TABLE IF NOT EXISTS sessions (
@@ -153,6 +168,15 @@ func (ldb *LocalDB) migrate() error {
 		}
 	}
 
+	optionalMigrations := []string{
+		"ALTER TABLE objectives ADD COLUMN network_policy TEXT",
+		"ALTER TABLE objectives ADD COLUMN outputs TEXT",
+		"ALTER TABLE objectives ADD COLUMN model TEXT",
+	}
+	for _, migration := range optionalMigrations {
+		_, _ = ldb.db.Exec(migration) // Ignore errors - column may already exist
+	}
+
 	return nil
 }
 
@@ -164,12 +188,22 @@ func (ldb *LocalDB) StoreObjective(payload *ObjectivePayload) error {
 		checklistJSON = string(data)
 	}
 
-	_, err := ldb.db.Exec(`
+	// Stored so a resumed session stays under the same policy
+	networkJSON, err := json.Marshal(payload.Objective.Network)
+	if err != nil {
+		return fmt.Errorf("failed to marshal network policy: %w", err)
+	}
+	outputsJSON, err := json.Marshal(payload.Objective.Outputs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outputs: %w", err)
+	}
+
+	_, err = ldb.db.Exec(`
 		INSERT INTO objectives (
 			id, title, description, hat, status, base_branch, token_budget,
 			project_id, project_name, github_owner, github_repo,
-			checklist, dispatched_at, hq_public_key, created_at
-		) VALUES (?, ?, ?, ?, 'pending', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			checklist, dispatched_at, hq_public_key, network_policy, outputs, model, created_at
+		) VALUES (?, ?, ?, ?, 'pending', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		payload.Objective.ID,
 		payload.Objective.Title,
@@ -184,6 +218,9 @@ func (ldb *LocalDB) StoreObjective(payload *ObjectivePayload) error {
 		checklistJSON,
 		payload.DispatchedAt,
 		payload.HQPublicKey,
+		string(networkJSON),
+		string(outputsJSON),
+		payload.Objective.Model,
 		time.Now(),
 	)
 	return err
@@ -193,11 +230,12 @@ func (ldb *LocalDB) StoreObjective(payload *ObjectivePayload) error {
 func (ldb *LocalDB) GetObjective(id string) (*Objective, error) {
 	var obj Objective
 	var checklistJSON string
+	var networkJSON, outputsJSON, model sql.NullString
 
 	err := ldb.db.QueryRow(`
-		SELECT id, title, description, hat, base_branch, token_budget, checklist
+		SELECT id, title, description, hat, base_branch, token_budget, checklist, network_policy, outputs, model
 		FROM objectives WHERE id = ?
-	`, id).Scan(&obj.ID, &obj.Title, &obj.Description, &obj.Hat, &obj.BaseBranch, &obj.TokenBudget, &checklistJSON)
+	`, id).Scan(&obj.ID, &obj.Title, &obj.Description, &obj.Hat, &obj.BaseBranch, &obj.TokenBudget, &checklistJSON, &networkJSON, &outputsJSON, &model)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -206,9 +244,20 @@ func (ldb *LocalDB) GetObjective(id string) (*Objective, error) {
 		return nil, err
 	}
 
+	obj.Model = model.String
 	if checklistJSON != "" {
 		_ = json.Unmarshal([]byte(checklistJSON), &obj.Checklist)
 	}
+	if networkJSON.Valid && networkJSON.String != "" {
+		if err := json.Unmarshal([]byte(networkJSON.String), &obj.Network); err != nil {
+			return nil, fmt.Errorf("failed to parse network policy: %w", err)
+		}
+	}
+	if outputsJSON.Valid && outputsJSON.String != "" {
+		if err := json.Unmarshal([]byte(outputsJSON.String), &obj.Outputs); err != nil {
+			return nil, fmt.Errorf("failed to parse outputs: %w", err)
+		}
+	}
 
 	return &obj, nil
 }
@@ -216,7 +265,7 @@ func (ldb *LocalDB) GetObjective(id string) (*Objective, error) {
 // UpdateObjectiveStatus updates an objective's status.
 func (ldb *LocalDB) UpdateObjectiveStatus(id, status string) error {
 	var completedAt interface{}
-	if status == "completed" || status == "failed" || status == "cancelled" {
+	if status == "completed" || status == "failed" || status == "cancelled" || status == "timed_out" {
 		completedAt = time.Now()
 	}
 
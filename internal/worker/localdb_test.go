@@ -57,6 +57,9 @@ func TestLocalDB_StoreAndGetObjective(t *testing.T) {
 			BaseBranch:  "main",
 			TokenBudget: 10000,
 			Checklist:   []string{"item1", "item2"},
+			Network:     NetworkPolicy{Mode: NetworkAllowlist, AllowedHosts: []string{"proxy.golang.org"}},
+			Outputs:     []OutputDestination{{Type: OutputWebhook, URL: "https://tickets.example.com/hook"}},
+			Model:       ModelOpus,
 		},
 		Project: Project{
 			ID:          "proj-456",
@@ -93,6 +96,15 @@ func TestLocalDB_StoreAndGetObjective(t *testing.T) {
 	if len(obj.Checklist) != 2 {
 		t.Errorf("expected 2 checklist items, got %d", len(obj.Checklist))
 	}
+	if obj.Network.Mode != NetworkAllowlist || len(obj.Network.AllowedHosts) != 1 {
+		t.Errorf("expected network policy to be stored, got %+v", obj.Network)
+	}
+	if len(obj.Outputs) != 1 || obj.Outputs[0].URL != "https://tickets.example.com/hook" {
+		t.Errorf("expected outputs to be stored, got %+v", obj.Outputs)
+	}
+	if obj.Model != ModelOpus {
+		t.Errorf("expected model %q to be stored, got %q", ModelOpus, obj.Model)
+	}
 }
 
 func TestLocalDB_GetNonexistentObjective(t *testing.T) {
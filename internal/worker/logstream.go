@@ -0,0 +1,172 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Defaults for streaming worker logs to HQ
+const (
+	DefaultLogStreamRate          = 50                     // Lines per second
+	DefaultLogStreamBuffer        = 500                    // Lines held between flushes
+	DefaultLogStreamFlushInterval = 500 * time.Millisecond // How often buffered lines are sent
+	maxLogLineLength              = 4096                   // Longer lines are truncated
+)
+
+// LogStreamConfig configures a LogStreamer.
+type LogStreamConfig struct {
+	RatePerSecond int           // Lines admitted per second, with bursts up to one second's worth
+	BufferLines   int           // Lines buffered while waiting to be sent; the oldest are dropped past this
+	FlushInterval time.Duration // How often buffered lines are sent
+}
+
+// DefaultLogStreamConfig returns the configuration used unless one is given.
+func DefaultLogStreamConfig() LogStreamConfig {
+	return LogStreamConfig{
+		RatePerSecond: DefaultLogStreamRate,
+		BufferLines:   DefaultLogStreamBuffer,
+		FlushInterval: DefaultLogStreamFlushInterval,
+	}
+}
+
+// LogStreamer is an io.Writer that forwards what is written to it, line by
+// line, to HQ as MsgTypeLog messages. It's meant to be teed with the worker's
+// stderr so operators can watch a remote worker without logging into it.
+//
+// Lines are rate limited and buffered; lines over the limit or pushed out of
+// a full buffer are dropped and counted, never blocking the writer. A batch
+// that fails to send is dropped too: the stream is best-effort, unlike activity.
+type LogStreamer struct {
+	conn     *Conn
+	workerID string
+	config   LogStreamConfig
+	limiter  *rate.Limiter
+
+	mu          sync.Mutex
+	partial     []byte // Unterminated tail of the last write
+	lines       []LogLine
+	dropped     int
+	objectiveID string
+}
+
+// NewLogStreamer creates a streamer that sends to conn. Zero config values
+// fall back to the defaults.
+func NewLogStreamer(conn *Conn, workerID string, config LogStreamConfig) *LogStreamer {
+	defaults := DefaultLogStreamConfig()
+	if config.RatePerSecond <= 0 {
+		config.RatePerSecond = defaults.RatePerSecond
+	}
+	if config.BufferLines <= 0 {
+		config.BufferLines = defaults.BufferLines
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+
+	return &LogStreamer{
+		conn:     conn,
+		workerID: workerID,
+		config:   config,
+		limiter:  rate.NewLimiter(rate.Limit(config.RatePerSecond), config.RatePerSecond),
+	}
+}
+
+// SetObjective tags lines written from now on with the objective the worker
+// is running ("" when idle). Lines already buffered are flushed first so they
+// keep the objective they were written under.
+func (s *LogStreamer) SetObjective(objectiveID string) {
+	s.Flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objectiveID = objectiveID
+}
+
+// Write buffers complete lines from p. It always reports success so that the
+// writer it's teed with is never affected.
+func (s *LogStreamer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		s.addLine(string(data[:i]))
+		data = data[i+1:]
+	}
+
+	if len(data) > maxLogLineLength {
+		s.addLine(string(data))
+		data = nil
+	}
+	s.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+// addLine queues a line if the rate limit and buffer allow. Caller holds s.mu.
+func (s *LogStreamer) addLine(text string) {
+	text = strings.TrimRight(text, "\r")
+	if text == "" {
+		return
+	}
+	if !s.limiter.Allow() {
+		s.dropped++
+		return
+	}
+	if len(text) > maxLogLineLength {
+		text = text[:maxLogLineLength] + "..."
+	}
+
+	s.lines = append(s.lines, LogLine{Time: time.Now(), Text: text})
+	if over := len(s.lines) - s.config.BufferLines; over > 0 {
+		s.lines = s.lines[over:]
+		s.dropped += over
+	}
+}
+
+// Run flushes buffered lines every FlushInterval until ctx is done.
+func (s *LogStreamer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Flush()
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+// Flush sends buffered lines to HQ. Nothing is sent if no lines were written
+// or dropped since the last flush.
+func (s *LogStreamer) Flush() {
+	s.mu.Lock()
+	if len(s.lines) == 0 && s.dropped == 0 {
+		s.mu.Unlock()
+		return
+	}
+	payload := &LogPayload{
+		WorkerID:    s.workerID,
+		ObjectiveID: s.objectiveID,
+		Lines:       s.lines,
+		Dropped:     s.dropped,
+	}
+	s.lines = nil
+	s.dropped = 0
+	s.mu.Unlock()
+
+	// Errors aren't reported: writing them to stderr would feed back into the stream
+	_ = s.conn.SendLog(payload)
+}
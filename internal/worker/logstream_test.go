@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// receiveLogs parses every MsgTypeLog message written to buf.
+func receiveLogs(t *testing.T, buf *bytes.Buffer) []*LogPayload {
+	t.Helper()
+	conn := NewConn(strings.NewReader(buf.String()), nil)
+	var payloads []*LogPayload
+	for {
+		msg, err := conn.Receive()
+		if err != nil {
+			return payloads
+		}
+		if msg.Type != MsgTypeLog {
+			t.Fatalf("unexpected message type %q", msg.Type)
+		}
+		payload, err := ParsePayload[LogPayload](msg)
+		if err != nil {
+			t.Fatalf("ParsePayload failed: %v", err)
+		}
+		payloads = append(payloads, payload)
+	}
+}
+
+func TestLogStreamer_SendsCompleteLines(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewLogStreamer(NewConn(nil, &buf), "worker-1", LogStreamConfig{})
+	s.SetObjective("obj-1")
+
+	_, _ = s.Write([]byte("first line\nsecond "))
+	_, _ = s.Write([]byte("line\npartial"))
+	s.Flush()
+
+	payloads := receiveLogs(t, &buf)
+	if len(payloads) != 1 {
+		t.Fatalf("got %d log messages, want 1", len(payloads))
+	}
+	p := payloads[0]
+	if p.WorkerID != "worker-1" || p.ObjectiveID != "obj-1" {
+		t.Errorf("got worker %q objective %q", p.WorkerID, p.ObjectiveID)
+	}
+	if len(p.Lines) != 2 || p.Lines[0].Text != "first line" || p.Lines[1].Text != "second line" {
+		t.Errorf("unexpected lines: %+v", p.Lines)
+	}
+
+	// Nothing new to send
+	buf.Reset()
+	s.Flush()
+	if buf.Len() != 0 {
+		t.Errorf("empty flush sent %q", buf.String())
+	}
+}
+
+func TestLogStreamer_SetObjectiveFlushesPrevious(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewLogStreamer(NewConn(nil, &buf), "worker-1", LogStreamConfig{})
+	s.SetObjective("obj-1")
+	_, _ = s.Write([]byte("working on obj-1\n"))
+	s.SetObjective("")
+	_, _ = s.Write([]byte("idle\n"))
+	s.Flush()
+
+	payloads := receiveLogs(t, &buf)
+	if len(payloads) != 2 {
+		t.Fatalf("got %d log messages, want 2", len(payloads))
+	}
+	if payloads[0].ObjectiveID != "obj-1" || payloads[1].ObjectiveID != "" {
+		t.Errorf("got objectives %q, %q", payloads[0].ObjectiveID, payloads[1].ObjectiveID)
+	}
+}
+
+func TestLogStreamer_RateLimitAndBufferDrop(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewLogStreamer(NewConn(nil, &buf), "worker-1", LogStreamConfig{RatePerSecond: 10, BufferLines: 5})
+
+	for i := range 20 {
+		_, _ = fmt.Fprintf(s, "line %d\n", i)
+	}
+	s.Flush()
+
+	payloads := receiveLogs(t, &buf)
+	if len(payloads) != 1 {
+		t.Fatalf("got %d log messages, want 1", len(payloads))
+	}
+	p := payloads[0]
+	// 10 admitted by the limiter's burst, of which the buffer keeps the newest 5
+	if len(p.Lines) != 5 || p.Lines[0].Text != "line 5" {
+		t.Errorf("unexpected lines: %+v", p.Lines)
+	}
+	if p.Dropped != 15 {
+		t.Errorf("Dropped = %d, want 15", p.Dropped)
+	}
+}
@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"slices"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/lirancohen/dex/internal/crypto"
 	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/prompts"
 )
 
 // Manager manages a pool of workers (both local and remote).
@@ -29,6 +31,22 @@ type Manager struct {
 	onActivity  func(events []*ActivityEvent)
 	onCompleted func(report *CompletionReport)
 	onFailed    func(objectiveID, sessionID, error string)
+	onTimedOut  func(objectiveID, workerID, reason string, requeued bool)
+	onRejected  func(objectiveID, workerID string, unmet []string, requeued bool)
+	onLog       func(workerID string, payload *LogPayload)
+
+	onPlanPreview func(workerID string, preview *PlanPreviewPayload)
+
+	registry *Registry                     // Fleet view from worker messages
+	inflight map[string]*inflightObjective // Dispatched objectives by objective ID
+	timeouts map[string]int                // Timed-out objectives per worker ID
+
+	prompts        *prompts.Set      // Prompt set workers must run (nil = their compiled-in prompts)
+	promptVersions map[string]string // Prompt set version last delivered, by worker ID
+	objectiveModel string            // Model every objective must run with (empty = each objective's own)
+
+	recovered     []*dispatchRequest             // Objectives recovered from the dispatch queue, awaiting a worker
+	secretsSource func() (*WorkerSecrets, error) // Secrets for recovered objectives (nil = none)
 
 	mu      sync.RWMutex
 	ctx     context.Context
@@ -38,9 +56,12 @@ type Manager struct {
 }
 
 type dispatchRequest struct {
-	payload  *ObjectivePayload
-	secrets  *WorkerSecrets // Unencrypted secrets (will be encrypted per-worker)
-	response chan error
+	payload    *ObjectivePayload
+	secrets    *WorkerSecrets // Unencrypted secrets (will be encrypted per-worker)
+	response   chan error
+	attempts   int      // Previous dispatches of this objective (re-queued after timeout)
+	exclude    []string // Workers to avoid, if another is idle
+	rejectedBy []string // Workers that rejected it for unmet acceptance criteria, never used
 }
 
 // NewManager creates a new worker manager.
@@ -55,6 +76,11 @@ func NewManager(database *db.DB, config *ManagerConfig, hqKeyPair *crypto.KeyPai
 		hqKeyPair: hqKeyPair,
 		workers:   make(map[string]Worker),
 		queue:     make(chan *dispatchRequest, 100),
+		registry:  NewRegistry(config.HeartbeatInterval),
+		inflight:  make(map[string]*inflightObjective),
+		timeouts:  make(map[string]int),
+
+		promptVersions: make(map[string]string),
 	}
 }
 
@@ -73,6 +99,53 @@ func (m *Manager) SetCallbacks(
 	m.onFailed = onFailed
 }
 
+// SetOnTimedOut sets the callback for objectives that time out on a worker.
+// requeued reports whether the objective was sent to another worker; when false
+// the objective has exhausted its attempts.
+func (m *Manager) SetOnTimedOut(onTimedOut func(objectiveID, workerID, reason string, requeued bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onTimedOut = onTimedOut
+}
+
+// SetOnRejected sets the callback for objectives a worker rejected because it
+// doesn't meet their acceptance criteria. requeued reports whether the
+// objective was sent to another worker; when false no worker took it.
+func (m *Manager) SetOnRejected(onRejected func(objectiveID, workerID string, unmet []string, requeued bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRejected = onRejected
+}
+
+// SetOnLog sets the callback for log lines streamed by workers.
+func (m *Manager) SetOnLog(onLog func(workerID string, payload *LogPayload)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onLog = onLog
+}
+
+// SetPromptSet sets the prompt set dispatched objectives must run with.
+// Each worker receives the set once and caches it by version.
+func (m *Manager) SetPromptSet(set *prompts.Set) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = set
+}
+
+// SetObjectiveModel requires every objective dispatched from now on to run
+// with model ("sonnet" or "opus"), overriding the model it was dispatched
+// with, so HQ decides centrally what runs remotely. Empty lets each
+// objective's own model stand.
+func (m *Manager) SetObjectiveModel(model string) error {
+	if err := ValidateModel(model); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objectiveModel = model
+	return nil
+}
+
 // Start initializes the worker pool and starts the dispatch loop.
 func (m *Manager) Start(ctx context.Context) error {
 	m.mu.Lock()
@@ -91,6 +164,9 @@ func (m *Manager) Start(ctx context.Context) error {
 		}
 	}
 
+	// Pick up objectives a previous run left queued or running
+	m.recoverDispatches()
+
 	// Start dispatch loop
 	m.wg.Add(1)
 	go m.dispatchLoop()
@@ -158,6 +234,7 @@ func (m *Manager) spawnLocalWorker() error {
 		BinaryPath:  m.config.WorkerBinaryPath,
 		DataDir:     dataDir,
 		HQPublicKey: m.config.HQPublicKey,
+		HQKeyPair:   m.hqKeyPair,
 	}
 
 	worker := NewLocalWorker(config)
@@ -170,6 +247,7 @@ func (m *Manager) spawnLocalWorker() error {
 	m.workers[workerID] = worker
 	m.localPool = append(m.localPool, worker)
 	m.mu.Unlock()
+	m.registry.Ready(workerID, &ReadyPayload{WorkerID: workerID, Version: worker.Status().Version})
 
 	// Start event handler for this worker
 	m.wg.Add(1)
@@ -199,8 +277,45 @@ func (m *Manager) handleWorkerEvents(worker *LocalWorker) {
 func (m *Manager) processWorkerMessage(workerID string, msg *Message) {
 	// Update last heartbeat time for any message
 	m.updateWorkerHeartbeat(workerID)
+	m.touchInflight(workerID)
+	if msg.Type != MsgTypeReady {
+		m.registry.Ingest(workerID, msg) // Ready is reconciled with HQ's view first
+	}
 
 	switch msg.Type {
+	case MsgTypeReady:
+		payload, err := ParsePayload[ReadyPayload](msg)
+		if err != nil {
+			fmt.Printf("Worker %s: failed to parse ready message: %v\n", workerID, err)
+			m.registry.Ingest(workerID, msg)
+			return
+		}
+		m.reconcileReady(workerID, payload)
+
+	case MsgTypeAccepted:
+		payload, err := ParsePayload[AcceptedPayload](msg)
+		if err != nil {
+			fmt.Printf("Worker %s: failed to parse accepted message: %v\n", workerID, err)
+			return
+		}
+		m.recordState(payload.ObjectiveID, db.DispatchStateAccepted, "")
+
+	case MsgTypeRejected:
+		payload, err := ParsePayload[RejectedPayload](msg)
+		if err != nil {
+			fmt.Printf("Worker %s: failed to parse rejected message: %v\n", workerID, err)
+			return
+		}
+		m.handleObjectiveRejected(workerID, payload)
+
+	case MsgTypePlanPreview:
+		payload, err := ParsePayload[PlanPreviewPayload](msg)
+		if err != nil {
+			fmt.Printf("Worker %s: failed to parse plan preview message: %v\n", workerID, err)
+			return
+		}
+		m.handlePlanPreview(workerID, payload)
+
 	case MsgTypeProgress:
 		payload, err := ParsePayload[ProgressPayload](msg)
 		if err != nil {
@@ -227,6 +342,11 @@ func (m *Manager) processWorkerMessage(workerID string, msg *Message) {
 			fmt.Printf("Worker %s: failed to parse completed message: %v\n", workerID, err)
 			return
 		}
+		if !m.finishInflight(workerID, payload.Report.ObjectiveID) {
+			fmt.Printf("Worker %s: ignoring completion of re-queued objective %s\n", workerID, payload.Report.ObjectiveID)
+			return
+		}
+		m.recordState(payload.Report.ObjectiveID, db.DispatchStateCompleted, "")
 		if m.onCompleted != nil {
 			m.onCompleted(payload.Report)
 		}
@@ -237,14 +357,50 @@ func (m *Manager) processWorkerMessage(workerID string, msg *Message) {
 			fmt.Printf("Worker %s: failed to parse failed message: %v\n", workerID, err)
 			return
 		}
+		if payload.TimedOut {
+			// Worker enforced the timeout itself; retry like an HQ-detected timeout
+			m.handleObjectiveTimeout(workerID, payload.ObjectiveID, TimeoutReasonSelfReported)
+			return
+		}
+		if !m.finishInflight(workerID, payload.ObjectiveID) {
+			fmt.Printf("Worker %s: ignoring failure of re-queued objective %s\n", workerID, payload.ObjectiveID)
+			return
+		}
+		m.recordState(payload.ObjectiveID, db.DispatchStateFailed, payload.Error)
 		if m.onFailed != nil {
 			m.onFailed(payload.ObjectiveID, payload.SessionID, payload.Error)
 		}
 
+	case MsgTypeCancelled:
+		objectiveID := m.inflightObjectiveOn(workerID)
+		if m.finishInflight(workerID, objectiveID) {
+			m.recordState(objectiveID, db.DispatchStateCancelled, "")
+		}
+
+	case MsgTypeResumeRequest:
+		payload, err := ParsePayload[ResumeRequestPayload](msg)
+		if err != nil {
+			fmt.Printf("Worker %s: failed to parse resume request: %v\n", workerID, err)
+			return
+		}
+		m.handleResumeRequest(workerID, payload)
+
 	case MsgTypeHeartbeat:
 		// Heartbeat processed above, nothing extra needed
 		// Could parse payload for detailed status if needed
 
+	case MsgTypeLog:
+		payload, err := ParsePayload[LogPayload](msg)
+		if err != nil {
+			fmt.Printf("Worker %s: failed to parse log message: %v\n", workerID, err)
+			return
+		}
+		// Trust the connection, not the payload, for which worker sent it
+		payload.WorkerID = workerID
+		if m.onLog != nil {
+			m.onLog(workerID, payload)
+		}
+
 	case MsgTypeError:
 		payload, err := ParsePayload[ErrorPayload](msg)
 		if err != nil {
@@ -279,7 +435,10 @@ func (m *Manager) dispatchLoop() {
 		case <-m.ctx.Done():
 			return
 		case req := <-m.queue:
-			err := m.dispatchToWorkerWithSecrets(req.payload, req.secrets)
+			err := m.dispatch(req)
+			if err != nil {
+				m.recordState(req.payload.Objective.ID, db.DispatchStateFailed, err.Error())
+			}
 			req.response <- err
 		}
 	}
@@ -287,12 +446,39 @@ func (m *Manager) dispatchLoop() {
 
 // dispatchToWorkerWithSecrets finds an available worker, encrypts secrets, and dispatches.
 func (m *Manager) dispatchToWorkerWithSecrets(payload *ObjectivePayload, secrets *WorkerSecrets) error {
+	if queued, err := m.recordQueued(payload); err != nil || !queued {
+		return err
+	}
+	if err := m.dispatch(&dispatchRequest{payload: payload, secrets: secrets}); err != nil {
+		m.recordState(payload.Objective.ID, db.DispatchStateFailed, err.Error())
+		return err
+	}
+	return nil
+}
+
+// dispatch sends a request to an idle worker and tracks it for timeout enforcement.
+func (m *Manager) dispatch(req *dispatchRequest) error {
 	// Find an idle worker
-	worker := m.getIdleWorker()
+	worker := m.getIdleWorker(req.rejectedBy, req.exclude...)
 	if worker == nil {
 		return fmt.Errorf("no idle workers available")
 	}
 
+	// Apply the default timeout so the worker self-enforces the same limit HQ does
+	if req.payload.Objective.TimeoutSec == 0 && m.config.ObjectiveTimeout > 0 {
+		req.payload.Objective.TimeoutSec = int(m.config.ObjectiveTimeout.Seconds())
+	}
+
+	// HQ's model policy wins over the model the objective was dispatched with
+	m.mu.RLock()
+	objectiveModel := m.objectiveModel
+	m.mu.RUnlock()
+	if objectiveModel != "" {
+		req.payload.Objective.Model = objectiveModel
+	}
+
+	payload, secrets := req.payload, req.secrets
+
 	// Encrypt secrets for the worker
 	if secrets != nil {
 		pubKey := worker.PublicKey()
@@ -313,35 +499,87 @@ func (m *Manager) dispatchToWorkerWithSecrets(payload *ObjectivePayload, secrets
 		payload = encPayload
 	}
 
-	return worker.Dispatch(m.ctx, payload)
+	payload = m.attachPrompts(payload, worker.ID())
+
+	if err := worker.Dispatch(m.ctx, payload); err != nil {
+		return err
+	}
+	if payload.Prompts != nil {
+		m.mu.Lock()
+		m.promptVersions[worker.ID()] = payload.PromptVersion
+		m.mu.Unlock()
+	}
+	m.trackDispatch(req, worker.ID())
+	m.recordDispatched(req.payload.Objective.ID, worker.ID(), req.attempts+1)
+	return nil
+}
+
+// attachPrompts returns a copy of payload naming the current prompt set, and
+// carrying the set itself unless it was already delivered to the worker.
+func (m *Manager) attachPrompts(payload *ObjectivePayload, workerID string) *ObjectivePayload {
+	m.mu.RLock()
+	set, delivered := m.prompts, m.promptVersions[workerID]
+	m.mu.RUnlock()
+
+	if set == nil {
+		return payload
+	}
+
+	withPrompts := *payload
+	withPrompts.PromptVersion = set.Version
+	withPrompts.Prompts = nil
+	if delivered != set.Version {
+		withPrompts.Prompts = set
+	}
+	return &withPrompts
 }
 
 // getIdleWorker returns an idle worker, preferring local workers.
-func (m *Manager) getIdleWorker() Worker {
+// Workers in avoid are only used if no other worker is idle.
+func (m *Manager) getIdleWorker(skip []string, avoid ...string) Worker {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	var fallback Worker
+	candidates := make([]Worker, 0, len(m.localPool)+len(m.remotePool))
 	// Check local workers first
 	for _, w := range m.localPool {
-		if w.Status().State == WorkerStateIdle {
-			return w
-		}
+		candidates = append(candidates, w)
 	}
-
-	// Check remote workers
+	// Then remote workers
 	for _, w := range m.remotePool {
-		if w.Status().State == WorkerStateIdle {
+		candidates = append(candidates, w)
+	}
+
+	for _, w := range candidates {
+		if w.Status().State != WorkerStateIdle || slices.Contains(skip, w.ID()) {
+			continue
+		}
+		if !slices.Contains(avoid, w.ID()) {
 			return w
 		}
+		if fallback == nil {
+			fallback = w
+		}
 	}
 
-	return nil
+	return fallback
 }
 
 // DispatchWithSecrets queues an objective with secrets for dispatch.
 // This is the main entry point for HQ to send work to workers.
 // Secrets are encrypted per-worker using their public key.
+// Dispatching an objective that is already queued or running is a no-op.
 func (m *Manager) DispatchWithSecrets(ctx context.Context, payload *ObjectivePayload, secrets *WorkerSecrets) error {
+	queued, err := m.recordQueued(payload)
+	if err != nil {
+		return err
+	}
+	if !queued {
+		fmt.Printf("Objective %s is already queued or running, not dispatching again\n", payload.Objective.ID)
+		return nil
+	}
+
 	req := &dispatchRequest{
 		payload:  payload,
 		secrets:  secrets,
@@ -351,6 +589,7 @@ func (m *Manager) DispatchWithSecrets(ctx context.Context, payload *ObjectivePay
 	select {
 	case m.queue <- req:
 	case <-ctx.Done():
+		m.recordState(payload.Objective.ID, db.DispatchStateFailed, ctx.Err().Error())
 		return ctx.Err()
 	}
 
@@ -380,12 +619,18 @@ func (m *Manager) healthCheckLoop() {
 	ticker := time.NewTicker(m.config.HealthCheckInterval)
 	defer ticker.Stop()
 
+	m.dispatchRecovered()
 	for {
 		select {
 		case <-m.ctx.Done():
 			return
 		case <-ticker.C:
+			for _, id := range m.registry.MarkStale() {
+				fmt.Printf("Worker %s is offline (no heartbeat for %v)\n", id, m.registry.StaleAfter())
+			}
 			m.checkWorkerHealth()
+			m.checkObjectiveTimeouts()
+			m.dispatchRecovered()
 		}
 	}
 }
@@ -430,6 +675,8 @@ func (m *Manager) checkWorkerHealth() {
 func (m *Manager) restartWorker(index int, w *LocalWorker) {
 	// Remove from pool
 	delete(m.workers, w.ID())
+	delete(m.promptVersions, w.ID())
+	m.registry.Remove(w.ID())
 	m.localPool = slices.Delete(m.localPool, index, index+1)
 
 	// Try to restart (outside lock)
@@ -447,11 +694,18 @@ func (m *Manager) Workers() []*WorkerStatus {
 
 	statuses := make([]*WorkerStatus, 0, len(m.workers))
 	for _, w := range m.workers {
-		statuses = append(statuses, w.Status())
+		status := w.Status()
+		status.Timeouts = m.timeouts[w.ID()]
+		statuses = append(statuses, status)
 	}
 	return statuses
 }
 
+// Registry returns the fleet view built from worker messages.
+func (m *Manager) Registry() *Registry {
+	return m.registry
+}
+
 // IdleWorkerCount returns the number of idle workers.
 func (m *Manager) IdleWorkerCount() int {
 	m.mu.RLock()
@@ -480,25 +734,94 @@ func (m *Manager) RunningWorkerCount() int {
 	return count
 }
 
-// RegisterRemoteWorker registers a remote worker that connected via mesh.
-func (m *Manager) RegisterRemoteWorker(worker *RemoteWorker) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if _, exists := m.workers[worker.ID()]; exists {
-		return fmt.Errorf("worker %s already registered", worker.ID())
+// RegisterRemoteWorker authenticates a remote worker that connected via mesh,
+// given the ready message it opened the connection with, then registers and
+// starts it. A worker that reconnects under an ID that is still registered
+// replaces its previous connection, which is closed, and is reconciled like a
+// re-sent ready.
+func (m *Manager) RegisterRemoteWorker(worker *RemoteWorker, readyMsg *Message) error {
+	ready, err := worker.Authenticate(m.hqKeyPair, readyMsg)
+	if err != nil {
+		return fmt.Errorf("remote worker %s: %w", worker.ID(), err)
 	}
 
+	m.mu.Lock()
+	var previous *RemoteWorker
+	if existing, exists := m.workers[worker.ID()]; exists {
+		rw, ok := existing.(*RemoteWorker)
+		if !ok {
+			m.mu.Unlock()
+			return fmt.Errorf("worker %s already registered", worker.ID())
+		}
+		previous = rw
+		m.remotePool = slices.DeleteFunc(m.remotePool, func(w *RemoteWorker) bool { return w == rw })
+	}
 	m.workers[worker.ID()] = worker
 	m.remotePool = append(m.remotePool, worker)
+	m.mu.Unlock()
+
+	if previous != nil {
+		fmt.Printf("Worker %s reconnected, replacing its previous connection\n", worker.ID())
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = previous.Stop(ctx)
+		}()
+	}
+
+	m.reconcileReady(worker.ID(), ready)
 
 	// Start event handler
+	if err := worker.Start(m.ctx); err != nil {
+		return err
+	}
 	m.wg.Add(1)
 	go m.handleRemoteWorkerEvents(worker)
 
 	return nil
 }
 
+// reconcileReady brings HQ's view of a worker in line with its ready message,
+// which a worker re-sends when it reconnects. The objective it reports still
+// running stays with it, unless HQ has since moved that objective to another
+// worker, in which case this worker is told to cancel it. Objectives HQ
+// thought it was running that it no longer reports are lost and re-queued,
+// provided the worker reports its objective at all; otherwise they are left
+// to the objective timeout.
+func (m *Manager) reconcileReady(workerID string, payload *ReadyPayload) {
+	m.mu.RLock()
+	var lost []string
+	for id, obj := range m.inflight {
+		if payload.ReportsObjective && obj.workerID == workerID && id != payload.ObjectiveID {
+			lost = append(lost, id)
+		}
+	}
+	stale := false
+	if payload.ObjectiveID != "" {
+		obj, ok := m.inflight[payload.ObjectiveID]
+		stale = ok && obj.workerID != workerID
+	}
+	w := m.workers[workerID]
+	m.mu.RUnlock()
+
+	if stale {
+		fmt.Printf("Worker %s: objective %s it reports running was re-dispatched, cancelling it\n", workerID, payload.ObjectiveID)
+		if canceller, ok := w.(objectiveCanceller); ok {
+			if err := canceller.cancelObjective(payload.ObjectiveID); err != nil {
+				fmt.Printf("Worker %s: failed to cancel stale objective %s: %v\n", workerID, payload.ObjectiveID, err)
+			}
+		}
+		reconciled := *payload
+		reconciled.ObjectiveID, reconciled.SessionID = "", ""
+		payload = &reconciled
+	}
+	m.registry.Ready(workerID, payload)
+
+	for _, id := range lost {
+		m.handleObjectiveTimeout(workerID, id, TimeoutReasonWorkerReset)
+	}
+}
+
 // handleRemoteWorkerEvents processes events from a remote worker.
 func (m *Manager) handleRemoteWorkerEvents(worker *RemoteWorker) {
 	defer m.wg.Done()
@@ -510,7 +833,7 @@ func (m *Manager) handleRemoteWorkerEvents(worker *RemoteWorker) {
 		case msg, ok := <-worker.Events():
 			if !ok {
 				// Worker disconnected
-				m.unregisterRemoteWorker(worker.ID())
+				m.unregisterRemoteWorker(worker)
 				return
 			}
 			m.processWorkerMessage(worker.ID(), msg)
@@ -518,15 +841,22 @@ func (m *Manager) handleRemoteWorkerEvents(worker *RemoteWorker) {
 	}
 }
 
-// unregisterRemoteWorker removes a remote worker from the pool.
-func (m *Manager) unregisterRemoteWorker(id string) {
+// unregisterRemoteWorker removes a disconnected remote worker from the pool,
+// unless a newer connection from the same worker has replaced it.
+func (m *Manager) unregisterRemoteWorker(worker *RemoteWorker) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	id := worker.ID()
+	if current, ok := m.workers[id]; !ok || current != Worker(worker) {
+		return
+	}
 	delete(m.workers, id)
+	delete(m.promptVersions, id)
+	m.registry.Remove(id)
 
 	for i, w := range m.remotePool {
-		if w.ID() == id {
+		if w == worker {
 			m.remotePool = slices.Delete(m.remotePool, i, i+1)
 			break
 		}
@@ -547,3 +877,58 @@ func (m *Manager) CancelObjective(ctx context.Context, objectiveID string) error
 
 	return fmt.Errorf("objective %s not found on any worker", objectiveID)
 }
+
+// ErrWorkerNotFound is returned for a worker ID HQ has no connection to.
+var ErrWorkerNotFound = errors.New("worker not found")
+
+// objectiveCanceller is a worker that can cancel an objective by ID.
+type objectiveCanceller interface {
+	cancelObjective(objectiveID string) error
+}
+
+// CancelWorkerObjectives sends a cancel for every objective the worker is
+// running, as reported by the worker and by the fleet registry, e.g. before
+// taking the worker out of service. Returns the objective IDs it cancelled.
+func (m *Manager) CancelWorkerObjectives(ctx context.Context, workerID string) ([]string, error) {
+	m.mu.RLock()
+	w, ok := m.workers[workerID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrWorkerNotFound, workerID)
+	}
+
+	var objectiveIDs []string
+	if status := w.Status(); status.State == WorkerStateRunning && status.ObjectiveID != "" {
+		objectiveIDs = append(objectiveIDs, status.ObjectiveID)
+	}
+	if snapshot, ok := m.registry.Worker(workerID); ok {
+		for _, id := range snapshot.ObjectiveIDs {
+			if !slices.Contains(objectiveIDs, id) {
+				objectiveIDs = append(objectiveIDs, id)
+			}
+		}
+	}
+
+	canceller, ok := w.(objectiveCanceller)
+	if !ok {
+		// Only the current objective can be reached
+		if len(objectiveIDs) == 0 {
+			return nil, nil
+		}
+		if err := w.Cancel(ctx); err != nil {
+			return nil, err
+		}
+		return objectiveIDs[:1], nil
+	}
+
+	cancelled := make([]string, 0, len(objectiveIDs))
+	var errs []error
+	for _, id := range objectiveIDs {
+		if err := canceller.cancelObjective(id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel objective %s: %w", id, err))
+			continue
+		}
+		cancelled = append(cancelled, id)
+	}
+	return cancelled, errors.Join(errs...)
+}
@@ -0,0 +1,199 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/crypto"
+)
+
+func TestCancelWorkerObjectives(t *testing.T) {
+	a, b := newPipeWorker(t, "worker-a"), newPipeWorker(t, "worker-b")
+	m, _ := newTimeoutTestManager(t, a, b)
+
+	// worker-a runs obj-1 and the registry also saw it accept obj-2
+	a.RemoteWorker.mu.Lock()
+	a.objectiveID = "obj-1"
+	a.state = WorkerStateRunning
+	a.RemoteWorker.mu.Unlock()
+	m.registry.Accepted("worker-a", &AcceptedPayload{ObjectiveID: "obj-1"})
+	m.registry.Accepted("worker-a", &AcceptedPayload{ObjectiveID: "obj-2"})
+	m.registry.Accepted("worker-b", &AcceptedPayload{ObjectiveID: "obj-3"})
+
+	cancelled, err := m.CancelWorkerObjectives(context.Background(), "worker-a")
+	if err != nil {
+		t.Fatalf("CancelWorkerObjectives() error = %v", err)
+	}
+	slices.Sort(cancelled)
+	if !slices.Equal(cancelled, []string{"obj-1", "obj-2"}) {
+		t.Errorf("cancelled = %v, want [obj-1 obj-2]", cancelled)
+	}
+
+	var got []string
+	for _, msg := range a.waitFor(MsgTypeCancel, 2) {
+		payload, err := ParsePayload[CancelPayload](msg)
+		if err != nil {
+			t.Fatalf("invalid cancel payload: %v", err)
+		}
+		got = append(got, payload.ObjectiveID)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"obj-1", "obj-2"}) {
+		t.Errorf("worker-a received cancels for %v, want [obj-1 obj-2]", got)
+	}
+	if len(b.waitFor(MsgTypeCancel, 1)) != 0 {
+		t.Error("expected worker-b to receive no cancels")
+	}
+
+	if _, err := m.CancelWorkerObjectives(context.Background(), "worker-c"); !errors.Is(err, ErrWorkerNotFound) {
+		t.Errorf("CancelWorkerObjectives() on an unknown worker error = %v, want ErrWorkerNotFound", err)
+	}
+}
+
+func TestRegisterRemoteWorker_ReplacesReconnectedWorker(t *testing.T) {
+	m, _ := newTimeoutTestManager(t)
+
+	first, second := newPipeWorker(t, "worker-a"), newPipeWorker(t, "worker-a")
+	ready := readyMessage(t, &ReadyPayload{WorkerID: "worker-a"})
+	if err := m.RegisterRemoteWorker(first.RemoteWorker, ready); err != nil {
+		t.Fatalf("RegisterRemoteWorker() error = %v", err)
+	}
+	if err := m.RegisterRemoteWorker(second.RemoteWorker, ready); err != nil {
+		t.Fatalf("RegisterRemoteWorker() on reconnect error = %v", err)
+	}
+	if len(m.Workers()) != 1 || len(m.Registry().Workers()) != 1 {
+		t.Fatalf("workers = %d, registry = %d, want 1 each", len(m.Workers()), len(m.Registry().Workers()))
+	}
+	if len(first.waitFor(MsgTypeShutdown, 1)) != 1 {
+		t.Error("expected the previous connection to be shut down")
+	}
+
+	// The old connection closing must not unregister the new one
+	m.unregisterRemoteWorker(first.RemoteWorker)
+	m.mu.RLock()
+	current := m.workers["worker-a"]
+	pool := len(m.remotePool)
+	m.mu.RUnlock()
+	if current != Worker(second.RemoteWorker) || pool != 1 {
+		t.Errorf("after the old connection closed: current=%v pool=%d, want the new connection", current, pool)
+	}
+}
+
+// readyMessage returns an unsigned ready message carrying payload
+func readyMessage(t *testing.T, payload *ReadyPayload) *Message {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Message{Type: MsgTypeReady, Seq: 1, Payload: data}
+}
+
+func TestRegisterRemoteWorker_Authenticates(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	identity, _ := crypto.NewWorkerIdentity("worker-1")
+
+	// connect starts a worker's side of the handshake and returns HQ's side
+	// of the connection, enrolled with pubKey, and the worker's ready message
+	connect := func(pubKey string) (*RemoteWorker, *Message, chan error) {
+		hqSide, workerSide := net.Pipe()
+		t.Cleanup(func() { _ = hqSide.Close(); _ = workerSide.Close() })
+		rw := NewRemoteWorker(identity.ID, "host", "", pubKey, hqSide)
+
+		workerErr := make(chan error, 1)
+		go func() {
+			conn := NewConn(workerSide, workerSide)
+			challenge, err := conn.SendAuthenticatedReady(identity, "1.0.0", "", "")
+			if err != nil {
+				workerErr <- err
+				return
+			}
+			workerErr <- conn.AwaitHQChallenge(identity, hqKeys.SigningPublicKey(), challenge)
+		}()
+		readyMsg, err := rw.protocol.Receive()
+		if err != nil {
+			t.Fatalf("Receive ready failed: %v", err)
+		}
+		return rw, readyMsg, workerErr
+	}
+
+	m, _ := newTimeoutTestManager(t)
+	m.hqKeyPair = hqKeys
+
+	// A worker advertising a key other than the one it enrolled with is refused
+	impostor, readyMsg, _ := connect("age1notthisworker")
+	if err := m.RegisterRemoteWorker(impostor, readyMsg); err == nil {
+		t.Error("RegisterRemoteWorker() accepted a worker whose key doesn't match its enrollment")
+	}
+	if len(m.Workers()) != 0 {
+		t.Fatalf("workers = %d after a refused registration, want 0", len(m.Workers()))
+	}
+
+	rw, readyMsg, workerErr := connect(identity.PublicKey())
+	if err := m.RegisterRemoteWorker(rw, readyMsg); err != nil {
+		t.Fatalf("RegisterRemoteWorker() error = %v", err)
+	}
+	if err := <-workerErr; err != nil {
+		t.Fatalf("worker side of the handshake failed: %v", err)
+	}
+	if len(m.Workers()) != 1 || rw.Status().Version != "1.0.0" {
+		t.Errorf("workers = %d, version = %q, want the authenticated worker registered", len(m.Workers()), rw.Status().Version)
+	}
+}
+
+func TestReconcileReady(t *testing.T) {
+	a, b := newPipeWorker(t, "worker-a"), newPipeWorker(t, "worker-b")
+	m, events := newTimeoutTestManager(t, a, b)
+	m.config.MaxObjectiveAttempts = 2
+
+	for _, id := range []string{"obj-kept", "obj-lost"} {
+		m.trackDispatch(&dispatchRequest{payload: &ObjectivePayload{Objective: Objective{ID: id}}}, "worker-a")
+	}
+	m.trackDispatch(&dispatchRequest{payload: &ObjectivePayload{Objective: Objective{ID: "obj-moved"}}}, "worker-b")
+
+	// worker-a reconnects still running obj-kept
+	m.reconcileReady("worker-a", &ReadyPayload{WorkerID: "worker-a", ObjectiveID: "obj-kept", ReportsObjective: true})
+	if ev := <-events; ev.objectiveID != "obj-lost" || ev.reason != TimeoutReasonWorkerReset || !ev.requeued {
+		t.Errorf("unexpected event for the lost objective: %+v", ev)
+	}
+	if len(a.waitFor(MsgTypeCancel, 1)) != 0 {
+		t.Error("expected no cancel for an objective the worker still runs")
+	}
+	m.mu.RLock()
+	kept := m.inflight["obj-kept"]
+	m.mu.RUnlock()
+	if kept == nil || kept.workerID != "worker-a" {
+		t.Errorf("obj-kept = %+v, want still in flight on worker-a", kept)
+	}
+
+	// A worker that doesn't report its objective loses nothing
+	m.reconcileReady("worker-a", &ReadyPayload{WorkerID: "worker-a"})
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event for a worker that doesn't report its objective: %+v", ev)
+	default:
+	}
+	m.mu.RLock()
+	kept = m.inflight["obj-kept"]
+	m.mu.RUnlock()
+	if kept == nil {
+		t.Error("obj-kept should stay in flight when the worker doesn't report its objective")
+	}
+
+	// worker-a reports an objective HQ has since moved to worker-b
+	m.reconcileReady("worker-a", &ReadyPayload{WorkerID: "worker-a", ObjectiveID: "obj-moved", ReportsObjective: true})
+	cancels := a.waitFor(MsgTypeCancel, 1)
+	if len(cancels) != 1 {
+		t.Fatal("expected worker-a to be told to cancel the moved objective")
+	}
+	if payload, _ := ParsePayload[CancelPayload](cancels[0]); payload == nil || payload.ObjectiveID != "obj-moved" {
+		t.Errorf("cancel payload = %+v, want obj-moved", payload)
+	}
+	if snapshot, _ := m.Registry().Worker("worker-a"); len(snapshot.ObjectiveIDs) != 0 {
+		t.Errorf("registry objectives for worker-a = %v, want none", snapshot.ObjectiveIDs)
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Models HQ can dispatch an objective with
+const (
+	ModelSonnet = "sonnet"
+	ModelOpus   = "opus"
+)
+
+// DefaultModel runs objectives dispatched without a model
+const DefaultModel = ModelSonnet
+
+// modelIDs maps each dispatchable model to its Anthropic model ID
+var modelIDs = map[string]string{
+	ModelSonnet: "claude-sonnet-4-5-20250929",
+	ModelOpus:   "claude-opus-4-5-20251101",
+}
+
+// ValidateModel checks that model can be dispatched. Empty is allowed and
+// means DefaultModel.
+func ValidateModel(model string) error {
+	if model == "" {
+		return nil
+	}
+	if _, ok := modelIDs[model]; !ok {
+		models := make([]string, 0, len(modelIDs))
+		for name := range modelIDs {
+			models = append(models, name)
+		}
+		slices.Sort(models)
+		return fmt.Errorf("unknown model %q (must be one of %s)", model, strings.Join(models, ", "))
+	}
+	return nil
+}
+
+// ModelID returns the Anthropic model ID the objective runs with: the model
+// HQ dispatched it with, or DefaultModel's.
+func (o *Objective) ModelID() string {
+	if id, ok := modelIDs[o.Model]; ok {
+		return id
+	}
+	return modelIDs[DefaultModel]
+}
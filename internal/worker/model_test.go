@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateModel(t *testing.T) {
+	for _, model := range []string{"", ModelSonnet, ModelOpus} {
+		if err := ValidateModel(model); err != nil {
+			t.Errorf("ValidateModel(%q) error = %v", model, err)
+		}
+	}
+	for _, model := range []string{"haiku", "Opus", "claude-opus-4-5-20251101"} {
+		if err := ValidateModel(model); err == nil {
+			t.Errorf("ValidateModel(%q) expected error", model)
+		}
+	}
+
+	if got := (&Objective{Model: ModelOpus}).ModelID(); got != modelIDs[ModelOpus] {
+		t.Errorf("opus ModelID() = %q", got)
+	}
+	if got := (&Objective{}).ModelID(); got != modelIDs[DefaultModel] {
+		t.Errorf("default ModelID() = %q, want %q", got, modelIDs[DefaultModel])
+	}
+}
+
+func TestSetObjectiveModel_OverridesDispatch(t *testing.T) {
+	w := newPipeWorker(t, "worker-a")
+	m, _ := newTimeoutTestManager(t, w)
+
+	if err := m.SetObjectiveModel("haiku"); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+	if err := m.SetObjectiveModel(ModelSonnet); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := &ObjectivePayload{Objective: Objective{ID: "obj-1", Model: ModelOpus}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	dispatched := w.waitFor(MsgTypeDispatch, 1)
+	if len(dispatched) != 1 {
+		t.Fatal("expected worker-a to receive the objective")
+	}
+	sent, _ := ParsePayload[DispatchPayload](dispatched[0])
+	if sent == nil || sent.Objective.Objective.Model != ModelSonnet {
+		t.Errorf("dispatched payload = %+v, want the objective forced to sonnet", sent)
+	}
+}
@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// NetworkMode controls what an objective's tools may reach over the network.
+type NetworkMode string
+
+const (
+	NetworkOpen      NetworkMode = ""          // No restrictions (default)
+	NetworkOffline   NetworkMode = "offline"   // Nothing; only the pre-cloned repo is available
+	NetworkGitOnly   NetworkMode = "git-only"  // Only the project's git host, via git_push and github_* tools
+	NetworkAllowlist NetworkMode = "allowlist" // The git host plus AllowedHosts, via Dex's own tools
+)
+
+// NetworkPolicy restricts network access for an objective's tool execution.
+// The project is cloned before the policy takes effect. Under any restricted
+// mode, shell commands (bash, tests, lint, build) run in a network namespace
+// with no connectivity; allowed hosts are only reachable through tools that
+// Dex can check, such as web_fetch and git_push.
+type NetworkPolicy struct {
+	Mode NetworkMode `json:"mode,omitempty"`
+
+	// AllowedHosts are reachable in allowlist mode. "*.example.com" matches
+	// any subdomain of example.com.
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+}
+
+// Validate checks that the mode is known and an allowlist has hosts.
+func (p NetworkPolicy) Validate() error {
+	switch p.Mode {
+	case NetworkOpen, NetworkOffline, NetworkGitOnly:
+		return nil
+	case NetworkAllowlist:
+		if len(p.AllowedHosts) == 0 {
+			return fmt.Errorf("network policy allowlist requires allowed_hosts")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown network policy mode %q", p.Mode)
+	}
+}
+
+// Restricted reports whether the policy limits network access at all.
+func (p NetworkPolicy) Restricted() bool {
+	return p.Mode != NetworkOpen
+}
+
+// AllowsHost reports whether host may be contacted. gitHosts are the hosts of
+// the project's git remote, allowed in git-only and allowlist modes.
+func (p NetworkPolicy) AllowsHost(host string, gitHosts []string) bool {
+	host = strings.ToLower(host)
+
+	switch p.Mode {
+	case NetworkOpen:
+		return true
+	case NetworkGitOnly, NetworkAllowlist:
+		for _, h := range gitHosts {
+			if host == h {
+				return true
+			}
+		}
+	}
+
+	if p.Mode == NetworkAllowlist {
+		for _, allowed := range p.AllowedHosts {
+			allowed = strings.ToLower(allowed)
+			if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckURL returns an error if the policy doesn't allow contacting rawURL.
+func (p NetworkPolicy) CheckURL(rawURL string, gitHosts []string) error {
+	if !p.Restricted() {
+		return nil
+	}
+	host := urlHost(rawURL)
+	if host == "" {
+		return fmt.Errorf("blocked by network policy (%s): can't determine host of %q", p.Mode, rawURL)
+	}
+	if !p.AllowsHost(host, gitHosts) {
+		return fmt.Errorf("blocked by network policy (%s): %s is not an allowed host", p.Mode, host)
+	}
+	return nil
+}
+
+// gitRemoteHosts returns the hosts a git remote's tools talk to: the remote's
+// own host, and for GitHub its API host.
+func gitRemoteHosts(remoteURL string) []string {
+	host := urlHost(remoteURL)
+	if host == "" {
+		return nil
+	}
+	if host == "github.com" {
+		return []string{host, "api.github.com"}
+	}
+	return []string{host}
+}
+
+// urlHost extracts the lowercased host from a URL or scp-style git remote
+// (git@github.com:owner/repo.git), without port or credentials.
+func urlHost(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		// scp-style: [user@]host:path
+		hostPart, _, ok := strings.Cut(rawURL, ":")
+		if !ok {
+			return ""
+		}
+		if _, after, found := strings.Cut(hostPart, "@"); found {
+			hostPart = after
+		}
+		return strings.ToLower(hostPart)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// networkIsolationPrefix runs a command in a new network namespace that has
+// only a loopback interface, using an unprivileged user namespace.
+var networkIsolationPrefix = []string{"unshare", "--net", "--map-root-user", "--"}
+
+// checkNetworkIsolation verifies commands can be isolated on this machine, so
+// a restricted objective fails up front instead of running with network access.
+func checkNetworkIsolation() error {
+	args := append(append([]string(nil), networkIsolationPrefix[1:]...), "true")
+	if output, err := exec.Command(networkIsolationPrefix[0], args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("network isolation unavailable (requires unshare and unprivileged user namespaces): %s: %w",
+			strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// OutputType selects where an objective's result is delivered.
+type OutputType string
+
+const (
+	OutputFile        OutputType = "file"         // Written as JSON under the worker's outputs directory
+	OutputWebhook     OutputType = "webhook"      // POSTed as JSON to a URL
+	OutputGitHubIssue OutputType = "github_issue" // Opened as a GitHub issue
+)
+
+const (
+	// MaxOutputDestinations caps how many destinations one objective may have
+	MaxOutputDestinations = 5
+
+	// outputDeliveryTimeout bounds each webhook or issue delivery
+	outputDeliveryTimeout = 30 * time.Second
+)
+
+// OutputDestination is somewhere an objective's result is delivered in
+// addition to the completion report sent to HQ.
+type OutputDestination struct {
+	Type OutputType `json:"type"`
+
+	// Path is the file to write, relative to the worker's outputs directory (file)
+	Path string `json:"path,omitempty"`
+
+	// URL receives the result as a JSON POST (webhook)
+	URL string `json:"url,omitempty"`
+
+	// Owner and Repo are where the issue is opened (github_issue). HQ fills
+	// them in from the objective's project when both are empty.
+	Owner  string   `json:"owner,omitempty"`
+	Repo   string   `json:"repo,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Validate checks that the destination has what its type needs.
+func (d OutputDestination) Validate() error {
+	switch d.Type {
+	case OutputFile:
+		if d.Path == "" {
+			return fmt.Errorf("file output requires path")
+		}
+		if filepath.IsAbs(d.Path) || !filepath.IsLocal(d.Path) {
+			return fmt.Errorf("file output path %q must be relative and stay inside the outputs directory", d.Path)
+		}
+	case OutputWebhook:
+		u, err := url.Parse(d.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook output requires an http(s) url, got %q", d.URL)
+		}
+	case OutputGitHubIssue:
+		if (d.Owner == "") != (d.Repo == "") {
+			return fmt.Errorf("github_issue output requires both owner and repo, or neither")
+		}
+	default:
+		return fmt.Errorf("unknown output type %q", d.Type)
+	}
+	return nil
+}
+
+// ValidateOutputs checks every destination and the destination count.
+func ValidateOutputs(outputs []OutputDestination) error {
+	if len(outputs) > MaxOutputDestinations {
+		return fmt.Errorf("at most %d output destinations are allowed", MaxOutputDestinations)
+	}
+	for i, d := range outputs {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("output %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// OutputResult is what gets delivered to each destination: the completion
+// report without its activity batch, plus the objective's title.
+type OutputResult struct {
+	ObjectiveID   string    `json:"objective_id"`
+	Title         string    `json:"title"`
+	SessionID     string    `json:"session_id"`
+	Status        string    `json:"status"`
+	Summary       string    `json:"summary"`
+	PRNumber      int       `json:"pr_number,omitempty"`
+	PRURL         string    `json:"pr_url,omitempty"`
+	BranchName    string    `json:"branch_name,omitempty"`
+	TotalTokens   int       `json:"total_tokens"`
+	Iterations    int       `json:"iterations"`
+	ChecklistDone []string  `json:"checklist_done,omitempty"`
+	Errors        []string  `json:"errors,omitempty"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+// NewOutputResult builds the delivered result for a completed objective.
+func NewOutputResult(objective *Objective, report *CompletionReport) *OutputResult {
+	return &OutputResult{
+		ObjectiveID:   report.ObjectiveID,
+		Title:         objective.Title,
+		SessionID:     report.SessionID,
+		Status:        report.Status,
+		Summary:       report.Summary,
+		PRNumber:      report.PRNumber,
+		PRURL:         report.PRURL,
+		BranchName:    report.BranchName,
+		TotalTokens:   report.TotalTokens,
+		Iterations:    report.Iterations,
+		ChecklistDone: report.ChecklistDone,
+		Errors:        report.Errors,
+		CompletedAt:   report.CompletedAt,
+	}
+}
+
+// OutputDeliverer delivers objective results to their output destinations.
+type OutputDeliverer struct {
+	outputDir   string
+	githubToken string
+	httpClient  *http.Client
+}
+
+// NewOutputDeliverer creates a deliverer that writes files under outputDir and
+// opens issues with githubToken.
+func NewOutputDeliverer(outputDir, githubToken string) *OutputDeliverer {
+	return &OutputDeliverer{
+		outputDir:   outputDir,
+		githubToken: githubToken,
+		httpClient:  &http.Client{Timeout: outputDeliveryTimeout},
+	}
+}
+
+// Deliver sends the result to every destination, returning one error string
+// per destination that failed. A failed delivery doesn't stop the others.
+func (d *OutputDeliverer) Deliver(ctx context.Context, outputs []OutputDestination, result *OutputResult) []string {
+	var failures []string
+	for _, output := range outputs {
+		if err := d.deliver(ctx, output, result); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", output.Type, err))
+		}
+	}
+	return failures
+}
+
+// deliver sends the result to a single destination.
+func (d *OutputDeliverer) deliver(ctx context.Context, output OutputDestination, result *OutputResult) error {
+	if err := output.Validate(); err != nil {
+		return err
+	}
+
+	switch output.Type {
+	case OutputFile:
+		return d.writeFile(output, result)
+	case OutputWebhook:
+		return d.postWebhook(ctx, output, result)
+	case OutputGitHubIssue:
+		return d.openIssue(ctx, output, result)
+	}
+	return nil
+}
+
+// writeFile writes the result as JSON to the destination path.
+func (d *OutputDeliverer) writeFile(output OutputDestination, result *OutputResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	path := filepath.Join(d.outputDir, output.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// postWebhook POSTs the result as JSON, treating any non-2xx response as a failure.
+func (d *OutputDeliverer) postWebhook(ctx context.Context, output OutputDestination, result *OutputResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, output.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// openIssue opens a GitHub issue describing the result.
+func (d *OutputDeliverer) openIssue(ctx context.Context, output OutputDestination, result *OutputResult) error {
+	if output.Owner == "" || output.Repo == "" {
+		return fmt.Errorf("no repository to open the issue in")
+	}
+	client := toolbelt.NewGitHubClient(&toolbelt.GitHubConfig{Token: d.githubToken})
+	if client == nil {
+		return fmt.Errorf("no GitHub token available")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outputDeliveryTimeout)
+	defer cancel()
+
+	_, err := client.CreateIssue(ctx, toolbelt.CreateIssueOptions{
+		Owner:  output.Owner,
+		Repo:   output.Repo,
+		Title:  fmt.Sprintf("Objective %s: %s", result.Status, result.Title),
+		Body:   issueBody(result),
+		Labels: output.Labels,
+	})
+	return err
+}
+
+// issueBody renders the result as a markdown issue body.
+func issueBody(result *OutputResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", result.Summary)
+	if result.PRURL != "" {
+		fmt.Fprintf(&b, "**Pull request:** %s\n", result.PRURL)
+	}
+	if result.BranchName != "" {
+		fmt.Fprintf(&b, "**Branch:** `%s`\n", result.BranchName)
+	}
+	fmt.Fprintf(&b, "**Iterations:** %d, **Tokens:** %d\n", result.Iterations, result.TotalTokens)
+	if len(result.ChecklistDone) > 0 {
+		b.WriteString("\n### Completed\n")
+		for _, item := range result.ChecklistDone {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	if len(result.Errors) > 0 {
+		b.WriteString("\n### Errors\n")
+		for _, e := range result.Errors {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	fmt.Fprintf(&b, "\n_Objective %s, session %s_\n", result.ObjectiveID, result.SessionID)
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputDestination_Validate(t *testing.T) {
+	valid := []OutputDestination{
+		{Type: OutputFile, Path: "reports/latest.json"},
+		{Type: OutputWebhook, URL: "https://tickets.example.com/hook"},
+		{Type: OutputGitHubIssue},
+		{Type: OutputGitHubIssue, Owner: "acme", Repo: "tickets", Labels: []string{"dex"}},
+	}
+	for _, d := range valid {
+		if err := d.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", d, err)
+		}
+	}
+
+	invalid := []OutputDestination{
+		{Type: "email"},
+		{Type: OutputFile},
+		{Type: OutputFile, Path: "/etc/passwd"},
+		{Type: OutputFile, Path: "../outside.json"},
+		{Type: OutputWebhook},
+		{Type: OutputWebhook, URL: "ftp://example.com/hook"},
+		{Type: OutputGitHubIssue, Owner: "acme"},
+	}
+	for _, d := range invalid {
+		if err := d.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", d)
+		}
+	}
+}
+
+func TestValidateOutputs_Limit(t *testing.T) {
+	outputs := make([]OutputDestination, MaxOutputDestinations+1)
+	for i := range outputs {
+		outputs[i] = OutputDestination{Type: OutputGitHubIssue}
+	}
+	if err := ValidateOutputs(outputs); err == nil {
+		t.Error("expected too many outputs to be rejected")
+	}
+	if err := ValidateOutputs(outputs[:MaxOutputDestinations]); err != nil {
+		t.Errorf("expected %d outputs to be allowed, got %v", MaxOutputDestinations, err)
+	}
+}
+
+func TestOutputDeliverer_FileAndWebhook(t *testing.T) {
+	var received OutputResult
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	deliverer := NewOutputDeliverer(dir, "")
+	result := NewOutputResult(
+		&Objective{ID: "obj-1", Title: "Fix the login bug"},
+		&CompletionReport{ObjectiveID: "obj-1", SessionID: "sess-1", Status: "completed", Summary: "Fixed it", PRURL: "https://github.com/acme/app/pull/7"},
+	)
+
+	failures := deliverer.Deliver(context.Background(), []OutputDestination{
+		{Type: OutputFile, Path: "reports/obj-1.json"},
+		{Type: OutputWebhook, URL: server.URL},
+	}, result)
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, got %v", failures)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "reports", "obj-1.json"))
+	if err != nil {
+		t.Fatalf("expected output file to be written: %v", err)
+	}
+	var written OutputResult
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("output file isn't valid JSON: %v", err)
+	}
+	if written.Title != "Fix the login bug" || written.PRURL != "https://github.com/acme/app/pull/7" {
+		t.Errorf("unexpected file contents: %+v", written)
+	}
+
+	if received.ObjectiveID != "obj-1" || received.Summary != "Fixed it" {
+		t.Errorf("unexpected webhook payload: %+v", received)
+	}
+}
+
+func TestOutputDeliverer_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	deliverer := NewOutputDeliverer(t.TempDir(), "")
+	result := NewOutputResult(&Objective{ID: "obj-1"}, &CompletionReport{ObjectiveID: "obj-1", Status: "completed"})
+
+	failures := deliverer.Deliver(context.Background(), []OutputDestination{
+		{Type: OutputWebhook, URL: server.URL},
+		{Type: OutputGitHubIssue, Owner: "acme", Repo: "tickets"},
+		{Type: OutputFile, Path: "ok.json"},
+	}, result)
+	if len(failures) != 2 {
+		t.Fatalf("expected webhook and issue failures, got %v", failures)
+	}
+	if !strings.HasPrefix(failures[0], "webhook:") || !strings.Contains(failures[0], "500") {
+		t.Errorf("expected webhook status in failure, got %q", failures[0])
+	}
+	if !strings.HasPrefix(failures[1], "github_issue:") {
+		t.Errorf("expected issue failure without a token, got %q", failures[1])
+	}
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+// planPreviewMaxTokens bounds the plan a worker sends for approval
+const planPreviewMaxTokens = 1024
+
+// planPreviewMaxEntries caps how much of the repo's top level goes into the prompt
+const planPreviewMaxEntries = 50
+
+// PreviewPlan asks the model how it intends to carry out an objective, without
+// running any tools, so HQ can approve the approach before any changes are made.
+func PreviewPlan(ctx context.Context, client ChatClient, objective *Objective, project *Project, workDir string) (*PlanPreviewPayload, error) {
+	if client == nil {
+		return nil, ErrNoAnthropicClient
+	}
+
+	resp, err := client.ChatWithStreaming(ctx, &toolbelt.AnthropicChatRequest{
+		Model:     objective.ModelID(),
+		MaxTokens: planPreviewMaxTokens,
+		System:    "You are a senior engineer about to work on a task in an existing repository. Before touching any code, describe your plan so a reviewer can approve it.",
+		Messages: []toolbelt.AnthropicMessage{
+			{Role: "user", Content: planPreviewPrompt(objective, project, workDir)},
+		},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate plan: %w", err)
+	}
+
+	plan := strings.TrimSpace(resp.Text())
+	if plan == "" {
+		return nil, fmt.Errorf("model returned an empty plan")
+	}
+
+	return &PlanPreviewPayload{
+		ObjectiveID:  objective.ID,
+		Plan:         plan,
+		TokensInput:  resp.Usage.InputTokens,
+		TokensOutput: resp.Usage.OutputTokens,
+	}, nil
+}
+
+// planPreviewPrompt describes the objective and the repo's layout, and asks for
+// a short plan rather than an implementation
+func planPreviewPrompt(objective *Objective, project *Project, workDir string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Task: %s\n\n", objective.Title)
+	if objective.Description != "" {
+		fmt.Fprintf(&sb, "%s\n\n", objective.Description)
+	}
+	if len(objective.Checklist) > 0 {
+		sb.WriteString("## Checklist\n\n")
+		for i, item := range objective.Checklist {
+			fmt.Fprintf(&sb, "%d. %s\n", i+1, item)
+		}
+		sb.WriteString("\n")
+	}
+
+	if project != nil && project.GitHubOwner != "" {
+		fmt.Fprintf(&sb, "## Repository: %s/%s\n\n", project.GitHubOwner, project.GitHubRepo)
+	}
+	if entries := topLevelEntries(workDir); len(entries) > 0 {
+		sb.WriteString("Top-level files and directories:\n\n")
+		for _, e := range entries {
+			fmt.Fprintf(&sb, "- %s\n", e)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("---\n\n")
+	sb.WriteString("Reply with your plan only, as a numbered list of at most 10 steps: the approach you'll take, ")
+	sb.WriteString("the files or areas you expect to change, and how you'll verify the result. ")
+	sb.WriteString("Call out anything risky or irreversible. Don't write code.")
+	return sb.String()
+}
+
+// topLevelEntries lists a directory's visible entries, directories marked with a trailing slash
+func topLevelEntries(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+		if len(names) == planPreviewMaxEntries {
+			break
+		}
+	}
+	return names
+}
+
+// approvedPlanMessage tells the loop to follow the plan HQ approved
+func approvedPlanMessage(plan string) string {
+	return fmt.Sprintf("## Approved Plan\n\nThis plan was reviewed and approved before you started. Follow it, and explain any deviation you have to make.\n\n%s\n\n---\n\n", plan)
+}
+
+// SetOnPlanPreview sets the callback for plans workers send for objectives
+// dispatched with PreviewPlan. The objective stays parked until the plan is
+// approved with DispatchApprovedPlan or rejected with RejectPlan.
+func (m *Manager) SetOnPlanPreview(onPlanPreview func(workerID string, preview *PlanPreviewPayload)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onPlanPreview = onPlanPreview
+}
+
+// handlePlanPreview parks an objective whose worker sent its plan for approval.
+// The worker is idle again; the objective waits outside the queue.
+func (m *Manager) handlePlanPreview(workerID string, payload *PlanPreviewPayload) {
+	if !m.finishInflight(workerID, payload.ObjectiveID) {
+		fmt.Printf("Worker %s: ignoring plan for re-queued objective %s\n", workerID, payload.ObjectiveID)
+		return
+	}
+	m.recordState(payload.ObjectiveID, db.DispatchStatePlanPending, "")
+
+	m.mu.RLock()
+	onPlanPreview := m.onPlanPreview
+	m.mu.RUnlock()
+	if onPlanPreview != nil {
+		onPlanPreview(workerID, payload)
+	}
+}
+
+// DispatchApprovedPlan dispatches an objective whose plan was approved, so a
+// worker runs it following that plan.
+func (m *Manager) DispatchApprovedPlan(ctx context.Context, objectiveID, plan string, secrets *WorkerSecrets) error {
+	if m.db == nil {
+		return fmt.Errorf("no dispatch queue to find objective %s in", objectiveID)
+	}
+	entry, err := m.db.GetDispatch(objectiveID)
+	if err != nil {
+		return err
+	}
+	if entry == nil || entry.State != db.DispatchStatePlanPending {
+		return fmt.Errorf("objective %s has no plan awaiting approval", objectiveID)
+	}
+
+	var payload ObjectivePayload
+	if err := json.Unmarshal([]byte(entry.Payload), &payload); err != nil {
+		return fmt.Errorf("failed to decode objective payload: %w", err)
+	}
+	payload.Objective.PreviewPlan = false
+	payload.Objective.ApprovedPlan = plan
+
+	return m.DispatchWithSecrets(ctx, &payload, secrets)
+}
+
+// RejectPlan drops an objective whose plan was rejected.
+func (m *Manager) RejectPlan(objectiveID, reason string) {
+	m.recordState(objectiveID, db.DispatchStateCancelled, reason)
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/db"
+	"github.com/lirancohen/dex/internal/toolbelt"
+)
+
+func TestPreviewPlan(t *testing.T) {
+	client := &MockChatClient{responses: []*toolbelt.AnthropicChatResponse{{
+		Content: []toolbelt.AnthropicContentBlock{{Type: "text", Text: "\n1. Add the flag\n2. Run the tests\n"}},
+		Usage:   toolbelt.AnthropicUsage{InputTokens: 200, OutputTokens: 40},
+	}}}
+	objective := &Objective{ID: "obj-1", Title: "Add a flag"}
+
+	preview, err := PreviewPlan(context.Background(), client, objective, &Project{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ObjectiveID != "obj-1" || preview.Plan != "1. Add the flag\n2. Run the tests" {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	if preview.TokensInput != 200 || preview.TokensOutput != 40 {
+		t.Errorf("tokens = %d/%d, want 200/40", preview.TokensInput, preview.TokensOutput)
+	}
+
+	empty := &MockChatClient{responses: []*toolbelt.AnthropicChatResponse{{}}}
+	if _, err := PreviewPlan(context.Background(), empty, objective, &Project{}, ""); err == nil {
+		t.Error("expected an error for an empty plan")
+	}
+}
+
+func TestPlanPreviewPrompt(t *testing.T) {
+	workDir := t.TempDir()
+	for _, dir := range []string{".git", "internal"} {
+		if err := os.Mkdir(filepath.Join(workDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "main.go"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := planPreviewPrompt(&Objective{
+		Title:     "Add a flag",
+		Checklist: []string{"Parse it", "Document it"},
+	}, &Project{GitHubOwner: "acme", GitHubRepo: "app"}, workDir)
+
+	for _, want := range []string{"## Task: Add a flag", "2. Document it", "acme/app", "- internal/", "- main.go"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, ".git") {
+		t.Errorf("prompt lists hidden entries:\n%s", prompt)
+	}
+}
+
+func TestPlanPreview_ApprovalDispatchesWithPlan(t *testing.T) {
+	w := newPipeWorker(t, "worker-a")
+	m, _ := newTimeoutTestManager(t, w)
+	m.db = openTestDB(t)
+	m.wg.Add(1)
+	go m.dispatchLoop()
+
+	var previewed *PlanPreviewPayload
+	m.SetOnPlanPreview(func(workerID string, preview *PlanPreviewPayload) {
+		previewed = preview
+	})
+
+	payload := &ObjectivePayload{Objective: Objective{ID: "obj-1", PreviewPlan: true}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if len(w.waitFor(MsgTypeDispatch, 1)) != 1 {
+		t.Fatal("expected the worker to receive the objective")
+	}
+
+	// The worker sends its plan instead of running the objective
+	plan, _ := json.Marshal(&PlanPreviewPayload{ObjectiveID: "obj-1", Plan: "1. Do it"})
+	m.processWorkerMessage("worker-a", &Message{Type: MsgTypePlanPreview, Payload: plan})
+	if previewed == nil || previewed.Plan != "1. Do it" {
+		t.Fatalf("plan preview callback got %+v", previewed)
+	}
+	if entry, _ := m.db.GetDispatch("obj-1"); entry.State != db.DispatchStatePlanPending {
+		t.Errorf("state = %q, want plan_pending", entry.State)
+	}
+	if m.inflightObjectiveOn("worker-a") != "" {
+		t.Error("objective still tracked as running while its plan awaits approval")
+	}
+
+	w.setState(WorkerStateIdle)
+	if err := m.DispatchApprovedPlan(context.Background(), "obj-1", "1. Do it", nil); err != nil {
+		t.Fatalf("DispatchApprovedPlan: %v", err)
+	}
+	dispatches := w.waitFor(MsgTypeDispatch, 2)
+	if len(dispatches) != 2 {
+		t.Fatalf("expected the approved objective to be dispatched again")
+	}
+	dispatch, err := ParsePayload[DispatchPayload](dispatches[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dispatch.Objective.Objective.PreviewPlan || dispatch.Objective.Objective.ApprovedPlan != "1. Do it" {
+		t.Errorf("re-dispatched objective = %+v, want the approved plan without a preview", dispatch.Objective.Objective)
+	}
+
+	// Only a plan awaiting approval can be approved
+	if err := m.DispatchApprovedPlan(context.Background(), "obj-1", "1. Do it", nil); err == nil {
+		t.Error("expected approving a running objective's plan to fail")
+	}
+}
+
+func TestPlanPreview_RejectCancels(t *testing.T) {
+	m := NewManager(openTestDB(t), DefaultManagerConfig(), nil)
+
+	if _, err := m.db.EnqueueDispatch("obj-1", `{"objective":{"id":"obj-1"}}`); err != nil {
+		t.Fatal(err)
+	}
+	m.handlePlanPreview("worker-a", &PlanPreviewPayload{ObjectiveID: "obj-1", Plan: "1. Do it"})
+	m.RejectPlan("obj-1", "plan rejected")
+
+	entry, _ := m.db.GetDispatch("obj-1")
+	if entry.State != db.DispatchStateCancelled || entry.LastError != "plan rejected" {
+		t.Errorf("unexpected entry after rejection: %+v", entry)
+	}
+	if err := m.DispatchApprovedPlan(context.Background(), "obj-1", "1. Do it", nil); err == nil {
+		t.Error("expected a rejected plan not to be dispatchable")
+	}
+}
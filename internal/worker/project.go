@@ -1,17 +1,21 @@
 package worker
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // ProjectManager handles project setup for worker execution.
 // It clones projects and manages the working directory.
 type ProjectManager struct {
-	dataDir string // Base directory for worker data
+	dataDir  string    // Base directory for worker data
+	warmPool *WarmPool // Pre-cloned mirrors for hot repos (optional)
 }
 
 // NewProjectManager creates a new ProjectManager.
@@ -21,6 +25,11 @@ func NewProjectManager(dataDir string) *ProjectManager {
 	}
 }
 
+// SetWarmPool sets the pool of mirrors that new projects are cloned from when possible.
+func (pm *ProjectManager) SetWarmPool(pool *WarmPool) {
+	pm.warmPool = pool
+}
+
 // SetupProject clones or updates a project and returns the working directory.
 // Projects are cloned to: {dataDir}/projects/{owner}/{repo}/
 func (pm *ProjectManager) SetupProject(project Project, baseBranch string) (workDir string, err error) {
@@ -51,6 +60,8 @@ func (pm *ProjectManager) SetupProject(project Project, baseBranch string) (work
 				return "", err
 			}
 		}
+	} else if pm.cloneFromWarmPool(project, projectDir, baseBranch) {
+		fmt.Printf("ProjectManager: cloned project from warm pool to %s\n", projectDir)
 	} else {
 		// Clone new project
 		fmt.Printf("ProjectManager: cloning new project to %s\n", projectDir)
@@ -62,15 +73,44 @@ func (pm *ProjectManager) SetupProject(project Project, baseBranch string) (work
 	return projectDir, nil
 }
 
-// getProjectDir returns the directory path for a project.
-func (pm *ProjectManager) getProjectDir(project Project) string {
-	owner := project.GitHubOwner
-	repo := project.GitHubRepo
+// cloneFromWarmPool creates the project from its warm mirror, then catches up
+// with origin. Returns false if there's no mirror or it can't be used, leaving
+// the caller to clone over the network.
+func (pm *ProjectManager) cloneFromWarmPool(project Project, projectDir, baseBranch string) bool {
+	owner, repo := projectOwnerRepo(project)
+	if pm.warmPool == nil || !pm.warmPool.Has(owner, repo) {
+		return false
+	}
+
+	if err := pm.warmPool.CloneTo(owner, repo, project.CloneURL, projectDir, baseBranch); err != nil {
+		fmt.Printf("ProjectManager: warm clone failed, falling back to network clone: %v\n", err)
+		return false
+	}
+
+	// The mirror may be up to one refresh interval behind; fetching the
+	// difference is cheap compared to a full clone
+	if err := pm.updateProject(projectDir, baseBranch); err != nil {
+		fmt.Printf("ProjectManager: warm clone update failed, falling back to network clone: %v\n", err)
+		_ = os.RemoveAll(projectDir)
+		return false
+	}
+	return true
+}
 
-	// Fall back to parsing from clone URL if owner/repo not provided
+// projectOwnerRepo returns a project's owner and repo, parsing them from the
+// clone URL if they aren't set.
+func projectOwnerRepo(project Project) (owner, repo string) {
+	owner = project.GitHubOwner
+	repo = project.GitHubRepo
 	if owner == "" || repo == "" {
 		owner, repo = parseCloneURL(project.CloneURL)
 	}
+	return owner, repo
+}
+
+// getProjectDir returns the directory path for a project.
+func (pm *ProjectManager) getProjectDir(project Project) string {
+	owner, repo := projectOwnerRepo(project)
 
 	if owner == "" {
 		owner = "unknown"
@@ -232,15 +272,70 @@ func (pm *ProjectManager) Cleanup(workDir string) error {
 	return os.RemoveAll(workDir)
 }
 
-// parseCloneURL extracts owner/repo from a GitHub clone URL.
+// KeepForDebugging marks a project directory as kept after a failed or
+// cancelled objective, so PruneDebugDirs ages it from now.
+func (pm *ProjectManager) KeepForDebugging(workDir string) error {
+	now := time.Now()
+	return os.Chtimes(workDir, now, now)
+}
+
+// PruneDebugDirs removes project directories the retention policy no longer
+// keeps, newest first by when they were last kept or set up. It must not run
+// while an objective is using a project directory. Returns the removed paths.
+func (pm *ProjectManager) PruneDebugDirs(policy RetentionPolicy) ([]string, error) {
+	if policy.MaxDebugDirs <= 0 && policy.DebugDirMaxAge <= 0 {
+		return nil, nil
+	}
+
+	// Project directories are {dataDir}/projects/{owner}/{repo}
+	dirs, err := filepath.Glob(filepath.Join(pm.dataDir, "projects", "*", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project directories: %w", err)
+	}
+
+	type projectDir struct {
+		path    string
+		modTime time.Time
+	}
+	var projects []projectDir
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		projects = append(projects, projectDir{path: dir, modTime: info.ModTime()})
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].modTime.After(projects[j].modTime)
+	})
+
+	var removed []string
+	var errs []error
+	cutoff := time.Now().Add(-policy.DebugDirMaxAge)
+	for i, p := range projects {
+		expired := (policy.MaxDebugDirs > 0 && i >= policy.MaxDebugDirs) ||
+			(policy.DebugDirMaxAge > 0 && p.modTime.Before(cutoff))
+		if !expired {
+			continue
+		}
+		if err := pm.Cleanup(p.path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", p.path, err))
+			continue
+		}
+		removed = append(removed, p.path)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// parseCloneURL extracts owner/repo from a clone URL.
 func parseCloneURL(url string) (owner, repo string) {
-	// Handle HTTPS URLs: https://github.com/owner/repo.git
+	// Handle URLs with a scheme: https://github.com/owner/repo.git, http://forgejo:3000/owner/repo.git
 	// Handle SSH URLs: git@github.com:owner/repo.git
 
 	url = strings.TrimSuffix(url, ".git")
 
-	if strings.HasPrefix(url, "https://") {
-		// HTTPS format
+	if strings.Contains(url, "://") {
+		// URL format
 		parts := strings.Split(url, "/")
 		if len(parts) >= 2 {
 			return parts[len(parts)-2], parts[len(parts)-1]
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestParseCloneURL(t *testing.T) {
@@ -37,6 +38,11 @@ func TestParseCloneURL(t *testing.T) {
 			expectedOwner: "org",
 			expectedRepo:  "repo-name",
 		},
+		{
+			url:           "http://127.0.0.1:3000/org/repo.git",
+			expectedOwner: "org",
+			expectedRepo:  "repo",
+		},
 		{
 			url:           "",
 			expectedOwner: "",
@@ -226,3 +232,53 @@ func TestProjectManager_Cleanup_Safety(t *testing.T) {
 		t.Errorf("expected cleanup of empty path to succeed: %v", err)
 	}
 }
+
+func TestProjectManager_PruneDebugDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm := NewProjectManager(tmpDir)
+
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"new":    time.Hour,
+		"recent": 2 * time.Hour,
+		"older":  3 * time.Hour,
+		"stale":  10 * 24 * time.Hour,
+	}
+	for repo, age := range ages {
+		dir := filepath.Join(tmpDir, "projects", "owner", repo)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create project dir: %v", err)
+		}
+		if err := os.Chtimes(dir, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatalf("failed to set project dir time: %v", err)
+		}
+	}
+
+	// Age removes "stale", the count limit removes "older"
+	removed, err := pm.PruneDebugDirs(RetentionPolicy{MaxDebugDirs: 3, DebugDirMaxAge: 7 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("PruneDebugDirs() error = %v", err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("removed %v, want only the stale directory", removed)
+	}
+
+	removed, err = pm.PruneDebugDirs(RetentionPolicy{MaxDebugDirs: 2})
+	if err != nil {
+		t.Fatalf("PruneDebugDirs() error = %v", err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "older" {
+		t.Errorf("removed %v, want the older directory", removed)
+	}
+
+	for _, repo := range []string{"new", "recent"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "projects", "owner", repo)); err != nil {
+			t.Errorf("expected %s to be kept: %v", repo, err)
+		}
+	}
+
+	// A policy without limits keeps everything
+	if removed, _ := pm.PruneDebugDirs(RetentionPolicy{}); len(removed) != 0 {
+		t.Errorf("removed %v with no limits, want none", removed)
+	}
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lirancohen/dex/prompts"
+)
+
+// PromptCache resolves the prompt set HQ expects for an objective. Sets shipped
+// by HQ are cached on disk by version, so later dispatches only carry the version.
+type PromptCache struct {
+	dir      string
+	embedded *WorkerPromptLoader // Compiled-in prompts
+
+	mu      sync.Mutex
+	loaders map[string]*WorkerPromptLoader // Loaded sets by version
+}
+
+// NewPromptCache creates a prompt cache rooted at dir. embedded must already be loaded.
+func NewPromptCache(dir string, embedded *WorkerPromptLoader) *PromptCache {
+	return &PromptCache{
+		dir:      dir,
+		embedded: embedded,
+		loaders:  make(map[string]*WorkerPromptLoader),
+	}
+}
+
+// Resolve returns a loader for the given prompt set version, caching set first
+// if HQ included it. An empty version selects the compiled-in prompts.
+func (c *PromptCache) Resolve(version string, set *prompts.Set) (*WorkerPromptLoader, error) {
+	if version == "" || version == c.embedded.Version() {
+		return c.embedded, nil
+	}
+	if _, err := hex.DecodeString(version); err != nil {
+		return nil, fmt.Errorf("invalid prompt set version: %q", version)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if loader, ok := c.loaders[version]; ok {
+		return loader, nil
+	}
+
+	dir := filepath.Join(c.dir, version)
+	if set != nil {
+		if set.Version != version {
+			return nil, fmt.Errorf("prompt set version mismatch: dispatch expects %s, got %s", version, set.Version)
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			if err := set.WriteDir(dir); err != nil {
+				return nil, fmt.Errorf("failed to cache prompt set %s: %w", version, err)
+			}
+		}
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("prompt set %s is not cached and was not included in the dispatch", version)
+	}
+
+	// Re-verify what's on disk: a damaged cache entry must not silently run other prompts
+	cached, err := prompts.Load(os.DirFS(dir))
+	if err == nil && cached.Version != version {
+		err = fmt.Errorf("contents hash to %s", cached.Version)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("cached prompt set %s is invalid: %w", version, err)
+	}
+
+	loader := NewWorkerPromptLoaderFS(os.DirFS(dir))
+	if err := loader.LoadAll(); err != nil {
+		return nil, fmt.Errorf("failed to load prompt set %s: %w", version, err)
+	}
+	c.loaders[version] = loader
+	return loader, nil
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lirancohen/dex/prompts"
+)
+
+// modifiedPromptSet returns the embedded set with one component changed, as HQ
+// would ship after editing its prompts
+func modifiedPromptSet(t *testing.T) *prompts.Set {
+	t.Helper()
+	set, err := prompts.Embedded()
+	if err != nil {
+		t.Fatalf("Embedded() error = %v", err)
+	}
+	set.Files["components/environment.yaml"] += "\n# edited on HQ\n"
+	set.Version = prompts.ComputeVersion(set.Files)
+	return set
+}
+
+func newTestPromptCache(t *testing.T) *PromptCache {
+	t.Helper()
+	embedded := NewWorkerPromptLoader()
+	if err := embedded.LoadAll(); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	return NewPromptCache(t.TempDir(), embedded)
+}
+
+func TestPromptCache_EmbeddedVersion(t *testing.T) {
+	cache := newTestPromptCache(t)
+
+	for _, version := range []string{"", cache.embedded.Version()} {
+		loader, err := cache.Resolve(version, nil)
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", version, err)
+		}
+		if loader != cache.embedded {
+			t.Errorf("Resolve(%q) did not return the embedded loader", version)
+		}
+	}
+}
+
+func TestPromptCache_CachesShippedSet(t *testing.T) {
+	cache := newTestPromptCache(t)
+	set := modifiedPromptSet(t)
+
+	loader, err := cache.Resolve(set.Version, set)
+	if err != nil {
+		t.Fatalf("Resolve() with set error = %v", err)
+	}
+	if loader.Version() != set.Version {
+		t.Errorf("loader version = %s, want %s", loader.Version(), set.Version)
+	}
+
+	// A fresh cache over the same directory finds the set on disk
+	reopened := NewPromptCache(cache.dir, cache.embedded)
+	loader, err = reopened.Resolve(set.Version, nil)
+	if err != nil {
+		t.Fatalf("Resolve() from disk error = %v", err)
+	}
+	if loader.Version() != set.Version {
+		t.Errorf("cached loader version = %s, want %s", loader.Version(), set.Version)
+	}
+}
+
+func TestPromptCache_Errors(t *testing.T) {
+	cache := newTestPromptCache(t)
+	set := modifiedPromptSet(t)
+
+	if _, err := cache.Resolve(set.Version, nil); err == nil || !strings.Contains(err.Error(), "not cached") {
+		t.Errorf("Resolve() of unknown version error = %v, want not cached", err)
+	}
+
+	if _, err := cache.Resolve("0123456789abcdef", set); err == nil {
+		t.Error("Resolve() accepted a set for a different version")
+	}
+
+	if _, err := cache.Resolve("../escape", nil); err == nil {
+		t.Error("Resolve() accepted a non-hex version")
+	}
+}
@@ -1,25 +1,22 @@
 package worker
 
 import (
-	"embed"
 	"fmt"
 	"io/fs"
 	"slices"
 	"strings"
 
 	"github.com/lirancohen/dex/internal/tools"
+	"github.com/lirancohen/dex/prompts"
 	"github.com/lirancohen/promptloom"
 	"gopkg.in/yaml.v3"
 )
 
-// Embedded prompts directory for self-contained worker binary
-//
-//go:embed prompts/components/*.yaml prompts/profiles/*.yaml prompts/languages/*.yaml
-var promptsFS embed.FS
-
-// WorkerPromptLoader loads prompts embedded in the worker binary.
-// It uses PromptLoom for composition and templating.
+// WorkerPromptLoader loads prompts embedded in the worker binary, or a prompt
+// set shipped by HQ. It uses PromptLoom for composition and templating.
 type WorkerPromptLoader struct {
+	fsys               fs.FS
+	version            string
 	registry           *promptloom.Registry
 	assembler          *promptloom.Assembler
 	languageGuidelines map[string]string
@@ -65,22 +62,29 @@ type languageFile struct {
 
 // NewWorkerPromptLoader creates a new prompt loader using embedded prompts.
 func NewWorkerPromptLoader() *WorkerPromptLoader {
+	return NewWorkerPromptLoaderFS(prompts.FS)
+}
+
+// NewWorkerPromptLoaderFS creates a new prompt loader for a prompts directory
+// laid out like the embedded one (components/, profiles/, languages/).
+func NewWorkerPromptLoaderFS(fsys fs.FS) *WorkerPromptLoader {
 	return &WorkerPromptLoader{
+		fsys:               fsys,
 		registry:           promptloom.NewRegistry(),
 		languageGuidelines: make(map[string]string),
 	}
 }
 
-// LoadAll loads all prompt components and profiles from the embedded filesystem.
+// LoadAll loads all prompt components and profiles from the loader's filesystem.
 func (p *WorkerPromptLoader) LoadAll() error {
-	// Get the prompts subdirectory
-	promptsDir, err := fs.Sub(promptsFS, "prompts")
+	set, err := prompts.Load(p.fsys)
 	if err != nil {
-		return fmt.Errorf("failed to get prompts subdirectory: %w", err)
+		return fmt.Errorf("failed to load prompts: %w", err)
 	}
+	p.version = set.Version
 
-	// Load components and profiles from the embedded filesystem
-	if err := p.registry.LoadFromFS(promptsDir, "."); err != nil {
+	// Load components and profiles from the filesystem
+	if err := p.registry.LoadFromFS(p.fsys, "."); err != nil {
 		return fmt.Errorf("failed to load prompts: %w", err)
 	}
 
@@ -109,13 +113,18 @@ func (p *WorkerPromptLoader) LoadAll() error {
 		}
 	}
 
-	fmt.Printf("WorkerPromptLoader: all required hats validated\n")
+	fmt.Printf("WorkerPromptLoader: all required hats validated (prompt set %s)\n", p.version)
 	return nil
 }
 
-// loadLanguageGuidelines loads language-specific guidelines from embedded files.
+// Version returns the prompt set version, or "" if LoadAll hasn't succeeded.
+func (p *WorkerPromptLoader) Version() string {
+	return p.version
+}
+
+// loadLanguageGuidelines loads language-specific guidelines.
 func (p *WorkerPromptLoader) loadLanguageGuidelines() error {
-	entries, err := fs.ReadDir(promptsFS, "prompts/languages")
+	entries, err := fs.ReadDir(p.fsys, "languages")
 	if err != nil {
 		return nil // No languages directory is OK
 	}
@@ -125,7 +134,7 @@ func (p *WorkerPromptLoader) loadLanguageGuidelines() error {
 			continue
 		}
 
-		data, err := fs.ReadFile(promptsFS, "prompts/languages/"+entry.Name())
+		data, err := fs.ReadFile(p.fsys, "languages/"+entry.Name())
 		if err != nil {
 			fmt.Printf("WorkerPromptLoader: warning: failed to read %s: %v\n", entry.Name(), err)
 			continue
@@ -3,10 +3,14 @@ package worker
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/lirancohen/dex/internal/crypto"
 )
 
 // Protocol defines the message types exchanged between HQ and workers.
@@ -25,6 +29,7 @@ const (
 	// Worker -> HQ messages
 	MsgTypeReady         MessageType = "ready"          // Worker is ready to receive work
 	MsgTypeAccepted      MessageType = "accepted"       // Objective accepted, starting execution
+	MsgTypeRejected      MessageType = "rejected"       // Objective rejected, worker can't meet its acceptance criteria
 	MsgTypeProgress      MessageType = "progress"       // Progress update (iteration complete)
 	MsgTypeActivity      MessageType = "activity"       // Activity events to sync
 	MsgTypeCompleted     MessageType = "completed"      // Objective completed
@@ -36,17 +41,55 @@ const (
 	MsgTypeResumeRequest MessageType = "resume_request" // Request to resume a crashed session
 	MsgTypeError         MessageType = "error"          // Protocol or worker error
 	MsgTypeShutdownAck   MessageType = "shutdown_ack"   // Acknowledging shutdown
+	MsgTypeLog           MessageType = "log"            // Worker stderr lines for live debugging
+	MsgTypePlanPreview   MessageType = "plan_preview"   // Intended plan for an objective, awaiting HQ approval
 
 	// HQ -> Worker messages (for resumption)
 	MsgTypeResume MessageType = "resume" // Resume a crashed session with secrets
+
+	// Handshake messages (mutual authentication)
+	MsgTypeChallenge         MessageType = "challenge"          // HQ -> Worker: prove identity, HQ's answer to ready challenge
+	MsgTypeChallengeResponse MessageType = "challenge_response" // Worker -> HQ: answer to HQ's challenge
+)
+
+// MaxMessageSkew is how far a message timestamp may be from the receiver's
+// clock before the message is rejected as stale.
+const MaxMessageSkew = 5 * time.Minute
+
+var (
+	// ErrReplayedMessage is returned by Receive for a message whose sequence
+	// number is not greater than the last one accepted on the connection.
+	ErrReplayedMessage = errors.New("replayed message")
+
+	// ErrStaleMessage is returned by Receive for a message whose timestamp is
+	// outside MaxMessageSkew.
+	ErrStaleMessage = errors.New("stale message")
 )
 
 // Message is the envelope for all protocol messages.
 type Message struct {
 	Type      MessageType     `json:"type"`
 	ID        string          `json:"id,omitempty"` // Message ID for correlation
+	Seq       uint64          `json:"seq"`          // Per-connection sequence number, starts at 1
 	Timestamp time.Time       `json:"timestamp"`
 	Payload   json.RawMessage `json:"payload,omitempty"`
+	Signature string          `json:"signature,omitempty"` // ed25519 signature over SigningBytes()
+}
+
+// SigningBytes returns the canonical bytes covered by the message signature.
+// binding ties the signature to the connection whose handshake produced it
+// (see handshakeBinding), so a captured message can't be replayed on another
+// connection; it is empty only for the ready message that opens one.
+func (m *Message) SigningBytes(binding string) []byte {
+	header := fmt.Sprintf("%s\n%s\n%s\n%d\n%d\n", binding, m.Type, m.ID, m.Seq, m.Timestamp.UnixNano())
+	return append([]byte(header), m.Payload...)
+}
+
+// IsRejectedMessage reports whether a Receive error was caused by replay
+// protection. The offending message has been discarded and the connection
+// can keep reading.
+func IsRejectedMessage(err error) bool {
+	return errors.Is(err, ErrReplayedMessage) || errors.Is(err, ErrStaleMessage)
 }
 
 // DispatchPayload is the payload for MsgTypeDispatch.
@@ -62,9 +105,33 @@ type CancelPayload struct {
 
 // ReadyPayload is the payload for MsgTypeReady.
 type ReadyPayload struct {
-	WorkerID  string `json:"worker_id"`
-	Version   string `json:"version"`
-	PublicKey string `json:"public_key"` // Worker's public key for encryption
+	WorkerID   string `json:"worker_id"`
+	Version    string `json:"version"`
+	PublicKey  string `json:"public_key"`            // Worker's public key for encryption
+	SigningKey string `json:"signing_key,omitempty"` // Worker's ed25519 key for message signatures
+	Challenge  string `json:"challenge,omitempty"`   // Nonce HQ must echo in its signed challenge
+
+	// Objective the worker is still running when it reconnects mid-objective
+	ObjectiveID string `json:"objective_id,omitempty"`
+	SessionID   string `json:"session_id,omitempty"`
+	// ReportsObjective is set by workers that fill in ObjectiveID, so an empty
+	// one means nothing is running. Without it HQ can't tell an idle worker
+	// from one that doesn't report, and leaves its objectives in flight.
+	ReportsObjective bool `json:"reports_objective,omitempty"`
+}
+
+// ChallengePayload is the payload for MsgTypeChallenge.
+// The message itself is signed by HQ, proving HQ's identity to the worker.
+type ChallengePayload struct {
+	WorkerChallenge string `json:"worker_challenge"` // Echo of ReadyPayload.Challenge
+	Nonce           string `json:"nonce"`            // Nonce encrypted to the worker's public key
+}
+
+// ChallengeResponsePayload is the payload for MsgTypeChallengeResponse.
+// Echoing the decrypted nonce in a signed message proves the worker holds
+// the private keys behind both its advertised public key and signing key.
+type ChallengeResponsePayload struct {
+	Nonce string `json:"nonce"`
 }
 
 // AcceptedPayload is the payload for MsgTypeAccepted.
@@ -73,6 +140,13 @@ type AcceptedPayload struct {
 	SessionID   string `json:"session_id"`
 }
 
+// RejectedPayload is the payload for MsgTypeRejected.
+type RejectedPayload struct {
+	ObjectiveID string   `json:"objective_id"`
+	Reason      string   `json:"reason"`
+	Unmet       []string `json:"unmet,omitempty"` // Each acceptance criterion the worker doesn't meet
+}
+
 // ProgressPayload is the payload for MsgTypeProgress.
 type ProgressPayload struct {
 	ObjectiveID  string `json:"objective_id"`
@@ -103,6 +177,7 @@ type FailedPayload struct {
 	SessionID   string `json:"session_id"`
 	Error       string `json:"error"`
 	Iteration   int    `json:"iteration"`
+	TimedOut    bool   `json:"timed_out,omitempty"` // Worker stopped at the objective timeout
 }
 
 // ErrorPayload is the payload for MsgTypeError.
@@ -129,7 +204,8 @@ type HeartbeatPayload struct {
 	Iteration    int         `json:"iteration,omitempty"`
 	TokensInput  int         `json:"tokens_input,omitempty"`
 	TokensOutput int         `json:"tokens_output,omitempty"`
-	Uptime       int64       `json:"uptime_sec"` // Seconds since worker started
+	Uptime       int64       `json:"uptime_sec"`              // Seconds since worker started
+	DBSizeBytes  int64       `json:"db_size_bytes,omitempty"` // Size of the worker's local database
 }
 
 // CrashReportPayload is the payload for MsgTypeCrashReport.
@@ -149,12 +225,20 @@ type CrashReportPayload struct {
 
 // ResumeRequestPayload is the payload for MsgTypeResumeRequest.
 // Sent when worker wants to resume a crashed session (needs secrets from HQ).
+// A request with Reason ResumeReasonDecryptFailed asks HQ to encrypt the
+// secrets again for the worker's current key.
 type ResumeRequestPayload struct {
 	WorkerID    string `json:"worker_id"`
 	ObjectiveID string `json:"objective_id"`
 	SessionID   string `json:"session_id"`
+	Reason      string `json:"reason,omitempty"`  // Why the request was sent (empty = first request)
+	Attempt     int    `json:"attempt,omitempty"` // Re-send attempt number, starting at 1
 }
 
+// ResumeReasonDecryptFailed marks a resume request sent because the secrets in
+// a Resume message could not be decrypted with the worker's key.
+const ResumeReasonDecryptFailed = "decrypt_failed"
+
 // ResumePayload is the payload for MsgTypeResume.
 // Sent by HQ to authorize and provide secrets for session resumption.
 type ResumePayload struct {
@@ -165,6 +249,30 @@ type ResumePayload struct {
 	Reason           string `json:"reason,omitempty"`  // Reason if not approved
 }
 
+// PlanPreviewPayload is the payload for MsgTypePlanPreview.
+// Sent instead of running an objective dispatched with PreviewPlan set.
+type PlanPreviewPayload struct {
+	ObjectiveID  string `json:"objective_id"`
+	Plan         string `json:"plan"`
+	TokensInput  int    `json:"tokens_input,omitempty"`
+	TokensOutput int    `json:"tokens_output,omitempty"`
+}
+
+// LogPayload is the payload for MsgTypeLog.
+// Lines are the worker's raw stderr output, streamed to HQ for debugging.
+type LogPayload struct {
+	WorkerID    string    `json:"worker_id"`
+	ObjectiveID string    `json:"objective_id,omitempty"` // Objective running when the lines were written
+	Lines       []LogLine `json:"lines"`
+	Dropped     int       `json:"dropped,omitempty"` // Lines dropped by the rate limit or buffer since the last batch
+}
+
+// LogLine is a single line of worker log output.
+type LogLine struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
 // Conn wraps a reader/writer pair for protocol communication.
 // It's safe for concurrent use - reads and writes are serialized.
 type Conn struct {
@@ -172,6 +280,16 @@ type Conn struct {
 	writer  io.Writer
 	readMu  sync.Mutex
 	writeMu sync.Mutex
+
+	// Replay protection: sendSeq is guarded by writeMu, recvSeq by readMu
+	sendSeq uint64
+	recvSeq uint64
+
+	// Message authentication (see SetSigner / SetPeerSigningKey)
+	authMu         sync.RWMutex
+	signer         *crypto.KeyPair
+	peerSigningKey string
+	binding        string // Covered by every signature once the handshake sets it
 }
 
 // NewConn creates a new protocol connection.
@@ -182,6 +300,29 @@ func NewConn(r io.Reader, w io.Writer) *Conn {
 	}
 }
 
+// SetSigner sets the keypair used to sign every outgoing message.
+func (c *Conn) SetSigner(kp *crypto.KeyPair) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.signer = kp
+}
+
+// SetPeerSigningKey sets the signing key that every incoming message must be
+// signed with. Once set, unsigned or mis-signed messages are rejected by Receive.
+func (c *Conn) SetPeerSigningKey(signingKey string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.peerSigningKey = signingKey
+}
+
+// setBinding binds every signature sent or checked from now on to this
+// connection's handshake.
+func (c *Conn) setBinding(binding string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.binding = binding
+}
+
 // Send sends a message with the given type and payload.
 func (c *Conn) Send(msgType MessageType, payload interface{}) error {
 	var payloadBytes json.RawMessage
@@ -193,14 +334,22 @@ func (c *Conn) Send(msgType MessageType, payload interface{}) error {
 		}
 	}
 
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.sendSeq++
 	msg := Message{
 		Type:      msgType,
+		Seq:       c.sendSeq,
 		Timestamp: time.Now(),
 		Payload:   payloadBytes,
 	}
 
-	c.writeMu.Lock()
-	defer c.writeMu.Unlock()
+	c.authMu.RLock()
+	if c.signer != nil {
+		msg.Signature = c.signer.Sign(msg.SigningBytes(c.binding))
+	}
+	c.authMu.RUnlock()
 
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -217,6 +366,8 @@ func (c *Conn) Send(msgType MessageType, payload interface{}) error {
 
 // Receive reads and returns the next message.
 // Blocks until a message is available or an error occurs.
+// Replayed or stale messages are discarded and reported with an error for
+// which IsRejectedMessage returns true.
 func (c *Conn) Receive() (*Message, error) {
 	c.readMu.Lock()
 	defer c.readMu.Unlock()
@@ -231,9 +382,46 @@ func (c *Conn) Receive() (*Message, error) {
 		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 
+	c.authMu.RLock()
+	peerSigningKey, binding := c.peerSigningKey, c.binding
+	c.authMu.RUnlock()
+	if peerSigningKey != "" {
+		if err := verifyBoundMessage(&msg, peerSigningKey, binding); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check replay protection only after the signature so a forged message
+	// can't advance the sequence number.
+	if msg.Seq <= c.recvSeq {
+		return nil, fmt.Errorf("%w: %s message seq %d (last %d)", ErrReplayedMessage, msg.Type, msg.Seq, c.recvSeq)
+	}
+	if skew := time.Since(msg.Timestamp); skew > MaxMessageSkew || skew < -MaxMessageSkew {
+		return nil, fmt.Errorf("%w: %s message timestamp %s", ErrStaleMessage, msg.Type, msg.Timestamp.Format(time.RFC3339))
+	}
+	c.recvSeq = msg.Seq
+
 	return &msg, nil
 }
 
+// VerifyMessage checks that a message sent before any handshake, such as a
+// ready message, is signed by the given signing key.
+func VerifyMessage(msg *Message, signingKey string) error {
+	return verifyBoundMessage(msg, signingKey, "")
+}
+
+// verifyBoundMessage checks that a message is signed by the given signing key
+// for the connection with the given handshake binding.
+func verifyBoundMessage(msg *Message, signingKey, binding string) error {
+	if msg.Signature == "" {
+		return fmt.Errorf("unsigned %s message", msg.Type)
+	}
+	if err := crypto.VerifySignature(signingKey, msg.SigningBytes(binding), msg.Signature); err != nil {
+		return fmt.Errorf("%s message: %w", msg.Type, err)
+	}
+	return nil
+}
+
 // ParsePayload unmarshals the message payload into the given type.
 func ParsePayload[T any](msg *Message) (*T, error) {
 	if msg.Payload == nil {
@@ -288,6 +476,15 @@ func (c *Conn) SendAccepted(objectiveID, sessionID string) error {
 	})
 }
 
+// SendRejected is a helper to send a rejected message.
+func (c *Conn) SendRejected(objectiveID string, unmet []string) error {
+	return c.Send(MsgTypeRejected, &RejectedPayload{
+		ObjectiveID: objectiveID,
+		Reason:      "unmet acceptance criteria: " + strings.Join(unmet, "; "),
+		Unmet:       unmet,
+	})
+}
+
 // SendProgress is a helper to send a progress message.
 func (c *Conn) SendProgress(progress *ProgressPayload) error {
 	return c.Send(MsgTypeProgress, progress)
@@ -317,6 +514,17 @@ func (c *Conn) SendFailed(objectiveID, sessionID, errorMsg string, iteration int
 	})
 }
 
+// SendTimedOut is a helper to report that an objective hit its timeout.
+func (c *Conn) SendTimedOut(objectiveID, sessionID string, timeout time.Duration, iteration int) error {
+	return c.Send(MsgTypeFailed, &FailedPayload{
+		ObjectiveID: objectiveID,
+		SessionID:   sessionID,
+		Error:       fmt.Sprintf("objective timed out after %s", timeout),
+		Iteration:   iteration,
+		TimedOut:    true,
+	})
+}
+
 // SendPong is a helper to send a pong message.
 func (c *Conn) SendPong(status *PongPayload) error {
 	return c.Send(MsgTypePong, status)
@@ -341,11 +549,33 @@ func (c *Conn) SendResumeRequest(workerID, objectiveID, sessionID string) error
 	})
 }
 
+// SendSecretsResendRequest asks HQ to re-send a crashed session's secrets
+// encrypted for the worker's current key.
+func (c *Conn) SendSecretsResendRequest(workerID, objectiveID, sessionID string, attempt int) error {
+	return c.Send(MsgTypeResumeRequest, &ResumeRequestPayload{
+		WorkerID:    workerID,
+		ObjectiveID: objectiveID,
+		SessionID:   sessionID,
+		Reason:      ResumeReasonDecryptFailed,
+		Attempt:     attempt,
+	})
+}
+
 // SendResume is a helper to send a resume authorization message.
 func (c *Conn) SendResume(payload *ResumePayload) error {
 	return c.Send(MsgTypeResume, payload)
 }
 
+// SendPlanPreview is a helper to send an objective's plan for approval.
+func (c *Conn) SendPlanPreview(payload *PlanPreviewPayload) error {
+	return c.Send(MsgTypePlanPreview, payload)
+}
+
+// SendLog is a helper to send a batch of log lines.
+func (c *Conn) SendLog(payload *LogPayload) error {
+	return c.Send(MsgTypeLog, payload)
+}
+
 // SendError is a helper to send an error message.
 func (c *Conn) SendError(code, message string) error {
 	return c.Send(MsgTypeError, &ErrorPayload{
@@ -3,6 +3,7 @@ package worker
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"strings"
 	"testing"
@@ -540,3 +541,76 @@ func TestMessageTypes(t *testing.T) {
 		seen[mt] = true
 	}
 }
+
+func TestConn_SequenceNumbers(t *testing.T) {
+	var buf bytes.Buffer
+	conn := NewConn(nil, &buf)
+
+	for range 3 {
+		if err := conn.SendPing(); err != nil {
+			t.Fatalf("SendPing failed: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, line := range lines {
+		var msg Message
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if msg.Seq != uint64(i+1) {
+			t.Errorf("Message %d: expected seq %d, got %d", i, i+1, msg.Seq)
+		}
+	}
+}
+
+func TestConn_RejectsReplayedMessage(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewConn(nil, &buf)
+	_ = sender.SendCancel("obj-1", "first")
+	_ = sender.SendCancel("obj-1", "second")
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	// Deliver: first, second, first again (replay), second again (replay)
+	stream := lines[0] + lines[1] + lines[0] + lines[1]
+	receiver := NewConn(strings.NewReader(stream), nil)
+
+	for i := range 2 {
+		if _, err := receiver.Receive(); err != nil {
+			t.Fatalf("Receive %d failed: %v", i, err)
+		}
+	}
+	for i := range 2 {
+		_, err := receiver.Receive()
+		if !errors.Is(err, ErrReplayedMessage) {
+			t.Errorf("Replay %d: expected ErrReplayedMessage, got %v", i, err)
+		}
+		if !IsRejectedMessage(err) {
+			t.Errorf("Replay %d: IsRejectedMessage should be true", i)
+		}
+	}
+}
+
+func TestConn_RejectsStaleMessage(t *testing.T) {
+	stale := Message{
+		Type:      MsgTypeCancel,
+		Seq:       1,
+		Timestamp: time.Now().Add(-2 * MaxMessageSkew),
+	}
+	data, _ := json.Marshal(stale)
+
+	receiver := NewConn(strings.NewReader(string(data)+"\n"), nil)
+	if _, err := receiver.Receive(); !errors.Is(err, ErrStaleMessage) {
+		t.Errorf("Expected ErrStaleMessage, got %v", err)
+	}
+}
+
+func TestConn_RejectsMissingSequence(t *testing.T) {
+	msg := Message{Type: MsgTypePing, Timestamp: time.Now()}
+	data, _ := json.Marshal(msg)
+
+	receiver := NewConn(strings.NewReader(string(data)+"\n"), nil)
+	if _, err := receiver.Receive(); !errors.Is(err, ErrReplayedMessage) {
+		t.Errorf("Expected ErrReplayedMessage for seq 0, got %v", err)
+	}
+}
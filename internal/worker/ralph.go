@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/lirancohen/dex/internal/hints"
+	"github.com/lirancohen/dex/internal/markdown"
 	"github.com/lirancohen/dex/internal/toolbelt"
 	"github.com/lirancohen/dex/internal/tools"
 )
@@ -98,8 +99,8 @@ func NewWorkerRalphLoop(
 		githubToken:        githubToken,
 		messages:           make([]toolbelt.AnthropicMessage, 0),
 		tools:              getToolDefinitionsForHat(session.Hat),
-		model:              "claude-sonnet-4-5-20250929", // Default to Sonnet
-		checkpointInterval: 5,                            // Save state every 5 iterations
+		model:              objective.ModelID(), // The model HQ dispatched the objective with
+		checkpointInterval: 5,                   // Save state every 5 iterations
 	}
 }
 
@@ -108,13 +109,10 @@ func (r *WorkerRalphLoop) SetLocalDB(db *LocalDB) {
 	r.localDB = db
 }
 
-// SetModel sets the AI model to use (sonnet or opus).
+// SetModel overrides the model the objective was dispatched with (sonnet or
+// opus; anything else is DefaultModel).
 func (r *WorkerRalphLoop) SetModel(model string) {
-	if model == "opus" {
-		r.model = "claude-opus-4-5-20251101"
-	} else {
-		r.model = "claude-sonnet-4-5-20250929"
-	}
+	r.model = (&Objective{Model: model}).ModelID()
 }
 
 // SetProgressCallback sets a callback for progress updates after each iteration.
@@ -379,6 +377,10 @@ func (r *WorkerRalphLoop) setupInitialConversation() {
 			r.objective.Title, r.objective.Description)
 	}
 
+	if r.objective.ApprovedPlan != "" {
+		initialMessage = approvedPlanMessage(r.objective.ApprovedPlan) + initialMessage
+	}
+
 	r.messages = append(r.messages, toolbelt.AnthropicMessage{
 		Role:    "user",
 		Content: initialMessage,
@@ -492,18 +494,20 @@ func (r *WorkerRalphLoop) processChecklistSignals(response string) {
 
 // processScratchpadSignal extracts and saves scratchpad content.
 func (r *WorkerRalphLoop) processScratchpadSignal(response string) {
-	idx := strings.Index(response, SignalScratchpad)
+	masked := markdown.MaskCode(response)
+	idx := strings.Index(masked, SignalScratchpad)
 	if idx == -1 {
 		return
 	}
 
 	content := response[idx+len(SignalScratchpad):]
+	maskedContent := masked[idx+len(SignalScratchpad):]
 
 	// Find end of scratchpad
 	endSignals := []string{SignalEvent, SignalChecklistDone, SignalChecklistFailed}
 	endIdx := len(content)
 	for _, sig := range endSignals {
-		if sigIdx := strings.Index(content, sig); sigIdx != -1 && sigIdx < endIdx {
+		if sigIdx := strings.Index(maskedContent, sig); sigIdx != -1 && sigIdx < endIdx {
 			endIdx = sigIdx
 		}
 	}
@@ -517,12 +521,12 @@ func (r *WorkerRalphLoop) processScratchpadSignal(response string) {
 
 // detectCompletion checks if the response indicates task completion.
 func (r *WorkerRalphLoop) detectCompletion(response string) bool {
-	return strings.Contains(response, "EVENT:task.complete")
+	return strings.Contains(markdown.MaskCode(response), SignalEvent+"task.complete")
 }
 
-// detectEvent extracts an EVENT signal from the response.
+// detectEvent extracts an EVENT signal from the response, ignoring code.
 func (r *WorkerRalphLoop) detectEvent(response string) string {
-	idx := strings.Index(response, SignalEvent)
+	idx := strings.Index(markdown.MaskCode(response), SignalEvent)
 	if idx == -1 {
 		return ""
 	}
@@ -855,19 +859,20 @@ func getToolDefinitionsForHat(hat string) []toolbelt.AnthropicTool {
 	return result
 }
 
-// findAllSignals finds all instances of a signal and extracts their content.
+// findAllSignals finds all instances of a signal outside code and extracts their content.
 func findAllSignals(content, signal string) []string {
 	var results []string
-	remaining := content
+	masked := markdown.MaskCode(content)
+	offset := 0
 
 	for {
-		idx := strings.Index(remaining, signal)
+		idx := strings.Index(masked[offset:], signal)
 		if idx == -1 {
 			break
 		}
 
-		start := idx + len(signal)
-		contentAfter := remaining[start:]
+		start := offset + idx + len(signal)
+		contentAfter := content[start:]
 
 		endIdx := strings.IndexAny(contentAfter, "\n\r")
 		if endIdx == -1 {
@@ -879,7 +884,7 @@ func findAllSignals(content, signal string) []string {
 			results = append(results, signalContent)
 		}
 
-		remaining = remaining[start+endIdx:]
+		offset = start + endIdx
 	}
 
 	return results
@@ -181,6 +181,11 @@ func TestWorkerRalphLoop_DetectCompletion(t *testing.T) {
 			response: "EVENT:plan.complete",
 			expected: false,
 		},
+		{
+			name:     "task.complete in code",
+			response: "Docs example:\n```\nEVENT:task.complete\n```\nand `EVENT:task.complete` inline",
+			expected: false,
+		},
 		{
 			name:     "Empty response",
 			response: "",
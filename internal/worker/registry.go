@@ -0,0 +1,427 @@
+package worker
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatInterval is how often workers send heartbeats.
+const DefaultHeartbeatInterval = 10 * time.Second
+
+// staleHeartbeats is how many heartbeat intervals a worker may miss before
+// the registry marks it offline.
+const staleHeartbeats = 3
+
+// Registry aggregates what workers report (ready, heartbeat, acceptance, and
+// completion messages) into a fleet view. A worker may run several objectives
+// at once. It is safe for concurrent use: messages from every worker's
+// connection are ingested while the API reads snapshots.
+type Registry struct {
+	mu         sync.RWMutex
+	interval   time.Duration
+	now        func() time.Time
+	workers    map[string]*registryWorker
+	objectives map[string]*ObjectiveSnapshot // Active objectives by ID
+}
+
+type registryWorker struct {
+	snapshot   WorkerSnapshot
+	objectives map[string]bool // Active objective IDs
+}
+
+// WorkerSnapshot is a worker's state as of its last message.
+type WorkerSnapshot struct {
+	ID            string      `json:"id"`
+	Version       string      `json:"version,omitempty"`
+	State         WorkerState `json:"state"`
+	Online        bool        `json:"online"` // Heard from within 3 heartbeat intervals
+	ObjectiveIDs  []string    `json:"objective_ids"`
+	RegisteredAt  time.Time   `json:"registered_at"`
+	LastHeartbeat time.Time   `json:"last_heartbeat,omitempty"`
+	LastSeen      time.Time   `json:"last_seen"` // Last message of any kind
+	UptimeSec     int64       `json:"uptime_sec,omitempty"`
+	DBSizeBytes   int64       `json:"db_size_bytes,omitempty"`
+	Heartbeats    int64       `json:"heartbeats"`
+	Completed     int64       `json:"completed"`
+	Failed        int64       `json:"failed"`
+	TokensUsed    int64       `json:"tokens_used"` // Across finished objectives
+}
+
+// ObjectiveSnapshot is a running objective's state on its worker.
+type ObjectiveSnapshot struct {
+	ObjectiveID  string    `json:"objective_id"`
+	WorkerID     string    `json:"worker_id"`
+	SessionID    string    `json:"session_id,omitempty"`
+	Iteration    int       `json:"iteration"`
+	TokensInput  int       `json:"tokens_input"`
+	TokensOutput int       `json:"tokens_output"`
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FleetSnapshot summarizes every worker the registry knows.
+type FleetSnapshot struct {
+	Workers    []WorkerSnapshot    `json:"workers"`
+	Objectives []ObjectiveSnapshot `json:"objectives"` // Active objectives
+	Online     int                 `json:"online"`
+	Offline    int                 `json:"offline"`
+	Idle       int                 `json:"idle"`    // Online and idle
+	Running    int                 `json:"running"` // Online and running
+	Completed  int64               `json:"completed"`
+	Failed     int64               `json:"failed"`
+	TokensUsed int64               `json:"tokens_used"` // Finished objectives plus active ones so far
+	CapturedAt time.Time           `json:"captured_at"`
+}
+
+// NewRegistry creates a registry for workers that heartbeat every interval.
+// An interval <= 0 uses DefaultHeartbeatInterval.
+func NewRegistry(interval time.Duration) *Registry {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	return &Registry{
+		interval:   interval,
+		now:        time.Now,
+		workers:    make(map[string]*registryWorker),
+		objectives: make(map[string]*ObjectiveSnapshot),
+	}
+}
+
+// StaleAfter is how long a worker may be silent before it is offline.
+func (r *Registry) StaleAfter() time.Duration {
+	return staleHeartbeats * r.interval
+}
+
+// Ingest records a message from a worker. Message types the registry doesn't
+// track, and payloads that don't parse, only count as signs of life.
+func (r *Registry) Ingest(workerID string, msg *Message) {
+	switch msg.Type {
+	case MsgTypeReady:
+		if payload, err := ParsePayload[ReadyPayload](msg); err == nil {
+			r.Ready(workerID, payload)
+			return
+		}
+	case MsgTypeHeartbeat:
+		if payload, err := ParsePayload[HeartbeatPayload](msg); err == nil {
+			r.Heartbeat(workerID, payload)
+			return
+		}
+	case MsgTypeAccepted:
+		if payload, err := ParsePayload[AcceptedPayload](msg); err == nil {
+			r.Accepted(workerID, payload)
+			return
+		}
+	case MsgTypeCompleted:
+		if payload, err := ParsePayload[CompletedPayload](msg); err == nil && payload.Report != nil {
+			r.Completed(workerID, payload.Report)
+			return
+		}
+	case MsgTypeFailed:
+		if payload, err := ParsePayload[FailedPayload](msg); err == nil {
+			r.Failed(workerID, payload.ObjectiveID)
+			return
+		}
+	case MsgTypeCancelled:
+		r.Cancelled(workerID)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touchLocked(workerID)
+}
+
+// Ready registers a worker that has connected. A worker reconnecting keeps
+// its counters and the objective it reports still running, but drops any
+// other objectives from its previous connection. Workers that don't report
+// their objective keep all of them.
+func (r *Registry) Ready(workerID string, payload *ReadyPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.touchLocked(workerID)
+	w.snapshot.Version = payload.Version
+	if !payload.ReportsObjective && len(w.objectives) > 0 {
+		return
+	}
+	w.snapshot.State = WorkerStateIdle
+	for id := range w.objectives {
+		if id != payload.ObjectiveID {
+			r.finishObjectiveLocked(w, id)
+		}
+	}
+
+	if payload.ObjectiveID == "" {
+		return
+	}
+	w.snapshot.State = WorkerStateRunning
+	obj := r.startObjectiveLocked(w, payload.ObjectiveID, w.snapshot.LastSeen)
+	if payload.SessionID != "" {
+		obj.SessionID = payload.SessionID
+	}
+}
+
+// Heartbeat records a worker's periodic status, including the objective it
+// reports running.
+func (r *Registry) Heartbeat(workerID string, payload *HeartbeatPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.touchLocked(workerID)
+	now := w.snapshot.LastSeen
+	w.snapshot.LastHeartbeat = now
+	w.snapshot.Heartbeats++
+	w.snapshot.State = payload.State
+	w.snapshot.UptimeSec = payload.Uptime
+	w.snapshot.DBSizeBytes = payload.DBSizeBytes
+
+	if payload.ObjectiveID == "" {
+		return
+	}
+	obj := r.startObjectiveLocked(w, payload.ObjectiveID, now)
+	if payload.SessionID != "" {
+		obj.SessionID = payload.SessionID
+	}
+	obj.Iteration = payload.Iteration
+	obj.TokensInput = payload.TokensInput
+	obj.TokensOutput = payload.TokensOutput
+	obj.UpdatedAt = now
+}
+
+// Accepted records that a worker started an objective.
+func (r *Registry) Accepted(workerID string, payload *AcceptedPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.touchLocked(workerID)
+	obj := r.startObjectiveLocked(w, payload.ObjectiveID, w.snapshot.LastSeen)
+	obj.SessionID = payload.SessionID
+}
+
+// Completed records an objective a worker finished.
+func (r *Registry) Completed(workerID string, report *CompletionReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.touchLocked(workerID)
+	tokens := int64(report.TotalTokens)
+	if obj := r.objectives[report.ObjectiveID]; tokens == 0 && obj != nil {
+		tokens = int64(obj.TokensInput + obj.TokensOutput)
+	}
+	w.snapshot.TokensUsed += tokens
+	switch report.Status {
+	case "failed":
+		w.snapshot.Failed++
+	case "cancelled":
+	default:
+		w.snapshot.Completed++
+	}
+	r.finishObjectiveLocked(w, report.ObjectiveID)
+}
+
+// Failed records an objective that failed on a worker.
+func (r *Registry) Failed(workerID, objectiveID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.touchLocked(workerID)
+	if obj := r.objectives[objectiveID]; obj != nil {
+		w.snapshot.TokensUsed += int64(obj.TokensInput + obj.TokensOutput)
+	}
+	w.snapshot.Failed++
+	r.finishObjectiveLocked(w, objectiveID)
+}
+
+// Cancelled records that a worker stopped its objectives on request. The
+// message names no objective, so every objective on the worker is finished.
+func (r *Registry) Cancelled(workerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.touchLocked(workerID)
+	for id := range w.objectives {
+		if obj := r.objectives[id]; obj != nil {
+			w.snapshot.TokensUsed += int64(obj.TokensInput + obj.TokensOutput)
+		}
+		r.finishObjectiveLocked(w, id)
+	}
+	w.snapshot.State = WorkerStateIdle
+}
+
+// Remove forgets a worker and its active objectives.
+func (r *Registry) Remove(workerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.workers[workerID]
+	if !ok {
+		return
+	}
+	for id := range w.objectives {
+		delete(r.objectives, id)
+	}
+	delete(r.workers, workerID)
+}
+
+// MarkStale marks workers silent for longer than StaleAfter offline and
+// returns the IDs of those that just went offline. Any message brings a
+// worker back online.
+func (r *Registry) MarkStale() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := r.now().Add(-r.StaleAfter())
+	var stale []string
+	for id, w := range r.workers {
+		if w.snapshot.Online && w.snapshot.LastSeen.Before(cutoff) {
+			w.snapshot.Online = false
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// Worker returns a snapshot of one worker.
+func (r *Registry) Worker(workerID string) (WorkerSnapshot, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.workers[workerID]
+	if !ok {
+		return WorkerSnapshot{}, false
+	}
+	return r.snapshotLocked(w), true
+}
+
+// Workers returns snapshots of every worker, ordered by ID.
+func (r *Registry) Workers() []WorkerSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.workersLocked()
+}
+
+// Objectives returns snapshots of active objectives, ordered by ID.
+func (r *Registry) Objectives() []ObjectiveSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.objectivesLocked()
+}
+
+// Fleet returns a consistent snapshot of every worker and active objective
+// with fleet-wide totals.
+func (r *Registry) Fleet() FleetSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fleet := FleetSnapshot{
+		Workers:    r.workersLocked(),
+		Objectives: r.objectivesLocked(),
+		CapturedAt: r.now(),
+	}
+	for _, w := range fleet.Workers {
+		if !w.Online {
+			fleet.Offline++
+		} else {
+			fleet.Online++
+			switch w.State {
+			case WorkerStateIdle:
+				fleet.Idle++
+			case WorkerStateRunning:
+				fleet.Running++
+			}
+		}
+		fleet.Completed += w.Completed
+		fleet.Failed += w.Failed
+		fleet.TokensUsed += w.TokensUsed
+	}
+	for _, obj := range fleet.Objectives {
+		fleet.TokensUsed += int64(obj.TokensInput + obj.TokensOutput)
+	}
+	return fleet
+}
+
+// touchLocked returns a worker's entry, creating it if needed, and records
+// that it was just heard from.
+func (r *Registry) touchLocked(workerID string) *registryWorker {
+	now := r.now()
+	w, ok := r.workers[workerID]
+	if !ok {
+		w = &registryWorker{
+			snapshot: WorkerSnapshot{
+				ID:           workerID,
+				State:        WorkerStateStarting,
+				RegisteredAt: now,
+			},
+			objectives: make(map[string]bool),
+		}
+		r.workers[workerID] = w
+	}
+	w.snapshot.LastSeen = now
+	w.snapshot.Online = true
+	return w
+}
+
+// startObjectiveLocked returns an objective's entry on a worker, creating it
+// if needed. An objective re-dispatched elsewhere moves to the new worker.
+func (r *Registry) startObjectiveLocked(w *registryWorker, objectiveID string, now time.Time) *ObjectiveSnapshot {
+	obj, ok := r.objectives[objectiveID]
+	if ok && obj.WorkerID != w.snapshot.ID {
+		if prev := r.workers[obj.WorkerID]; prev != nil {
+			delete(prev.objectives, objectiveID)
+		}
+		ok = false
+	}
+	if !ok {
+		obj = &ObjectiveSnapshot{
+			ObjectiveID: objectiveID,
+			WorkerID:    w.snapshot.ID,
+			StartedAt:   now,
+			UpdatedAt:   now,
+		}
+		r.objectives[objectiveID] = obj
+	}
+	w.objectives[objectiveID] = true
+	return obj
+}
+
+// finishObjectiveLocked drops a finished objective from the active set
+func (r *Registry) finishObjectiveLocked(w *registryWorker, objectiveID string) {
+	delete(w.objectives, objectiveID)
+	if obj := r.objectives[objectiveID]; obj != nil && obj.WorkerID == w.snapshot.ID {
+		delete(r.objectives, objectiveID)
+	}
+}
+
+// snapshotLocked copies a worker's state. It is offline once stale even if
+// MarkStale hasn't run since.
+func (r *Registry) snapshotLocked(w *registryWorker) WorkerSnapshot {
+	s := w.snapshot
+	s.Online = s.Online && !s.LastSeen.Before(r.now().Add(-r.StaleAfter()))
+	s.ObjectiveIDs = make([]string, 0, len(w.objectives))
+	for id := range w.objectives {
+		s.ObjectiveIDs = append(s.ObjectiveIDs, id)
+	}
+	slices.Sort(s.ObjectiveIDs)
+	return s
+}
+
+func (r *Registry) workersLocked() []WorkerSnapshot {
+	snapshots := make([]WorkerSnapshot, 0, len(r.workers))
+	for _, w := range r.workers {
+		snapshots = append(snapshots, r.snapshotLocked(w))
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].ID < snapshots[j].ID })
+	return snapshots
+}
+
+func (r *Registry) objectivesLocked() []ObjectiveSnapshot {
+	snapshots := make([]ObjectiveSnapshot, 0, len(r.objectives))
+	for _, obj := range r.objectives {
+		snapshots = append(snapshots, *obj)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].ObjectiveID < snapshots[j].ObjectiveID })
+	return snapshots
+}
@@ -0,0 +1,177 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistry_TracksObjectivesAcrossMessages(t *testing.T) {
+	r := NewRegistry(time.Second)
+
+	r.Ready("w1", &ReadyPayload{WorkerID: "w1", Version: "1.2.3"})
+	r.Accepted("w1", &AcceptedPayload{ObjectiveID: "obj-a", SessionID: "sess-a"})
+	r.Heartbeat("w1", &HeartbeatPayload{
+		WorkerID: "w1", State: WorkerStateRunning, ObjectiveID: "obj-b",
+		Iteration: 3, TokensInput: 100, TokensOutput: 50, DBSizeBytes: 4096,
+	})
+
+	w, ok := r.Worker("w1")
+	if !ok {
+		t.Fatal("worker w1 not registered")
+	}
+	if w.Version != "1.2.3" || w.State != WorkerStateRunning || !w.Online || w.DBSizeBytes != 4096 {
+		t.Errorf("unexpected worker snapshot: %+v", w)
+	}
+	if !slices.Equal(w.ObjectiveIDs, []string{"obj-a", "obj-b"}) {
+		t.Errorf("ObjectiveIDs = %v, want both objectives", w.ObjectiveIDs)
+	}
+
+	r.Completed("w1", &CompletionReport{ObjectiveID: "obj-a", Status: "completed", TotalTokens: 1000})
+	r.Failed("w1", "obj-b")
+
+	fleet := r.Fleet()
+	if len(fleet.Objectives) != 0 {
+		t.Errorf("finished objectives still active: %+v", fleet.Objectives)
+	}
+	if fleet.Completed != 1 || fleet.Failed != 1 {
+		t.Errorf("completed=%d failed=%d, want 1 and 1", fleet.Completed, fleet.Failed)
+	}
+	if fleet.TokensUsed != 1150 {
+		t.Errorf("TokensUsed = %d, want 1150", fleet.TokensUsed)
+	}
+}
+
+func TestRegistry_CancelledFinishesObjectives(t *testing.T) {
+	r := NewRegistry(time.Second)
+
+	r.Accepted("w1", &AcceptedPayload{ObjectiveID: "obj-a", SessionID: "sess-a"})
+	r.Heartbeat("w1", &HeartbeatPayload{
+		WorkerID: "w1", State: WorkerStateRunning, ObjectiveID: "obj-a", TokensInput: 70, TokensOutput: 30,
+	})
+	r.Accepted("w2", &AcceptedPayload{ObjectiveID: "obj-b"})
+
+	r.Ingest("w1", &Message{Type: MsgTypeCancelled})
+
+	w, _ := r.Worker("w1")
+	if w.State != WorkerStateIdle || len(w.ObjectiveIDs) != 0 {
+		t.Errorf("after cancel: state=%s objectives=%v, want idle with none", w.State, w.ObjectiveIDs)
+	}
+	if w.Completed != 0 || w.Failed != 0 || w.TokensUsed != 100 {
+		t.Errorf("after cancel: completed=%d failed=%d tokens=%d, want 0, 0, 100", w.Completed, w.Failed, w.TokensUsed)
+	}
+	if objectives := r.Objectives(); len(objectives) != 1 || objectives[0].ObjectiveID != "obj-b" {
+		t.Errorf("active objectives = %+v, want only the other worker's", objectives)
+	}
+}
+
+func TestRegistry_ReadyKeepsReportedObjective(t *testing.T) {
+	r := NewRegistry(time.Second)
+
+	r.Ready("w1", &ReadyPayload{WorkerID: "w1"})
+	r.Accepted("w1", &AcceptedPayload{ObjectiveID: "obj-a"})
+	r.Accepted("w1", &AcceptedPayload{ObjectiveID: "obj-b"})
+
+	// Reconnecting while still running obj-b drops obj-a only
+	r.Ready("w1", &ReadyPayload{WorkerID: "w1", ObjectiveID: "obj-b", SessionID: "sess-b", ReportsObjective: true})
+
+	w, _ := r.Worker("w1")
+	if w.State != WorkerStateRunning || !slices.Equal(w.ObjectiveIDs, []string{"obj-b"}) {
+		t.Errorf("after reconnect: state=%s objectives=%v, want running [obj-b]", w.State, w.ObjectiveIDs)
+	}
+	objectives := r.Objectives()
+	if len(objectives) != 1 || objectives[0].SessionID != "sess-b" {
+		t.Errorf("active objectives = %+v, want obj-b with its session", objectives)
+	}
+	if len(r.Workers()) != 1 {
+		t.Errorf("workers = %d, want 1 after reconnect", len(r.Workers()))
+	}
+}
+
+func TestRegistry_MovesRedispatchedObjective(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Accepted("w1", &AcceptedPayload{ObjectiveID: "obj"})
+	r.Accepted("w2", &AcceptedPayload{ObjectiveID: "obj"})
+
+	// A late failure from the first worker must not drop the new attempt
+	r.Failed("w1", "obj")
+
+	objectives := r.Objectives()
+	if len(objectives) != 1 || objectives[0].WorkerID != "w2" {
+		t.Fatalf("objectives = %+v, want obj on w2", objectives)
+	}
+	if w1, _ := r.Worker("w1"); len(w1.ObjectiveIDs) != 0 {
+		t.Errorf("w1 still lists %v", w1.ObjectiveIDs)
+	}
+}
+
+func TestRegistry_MarksSilentWorkersOffline(t *testing.T) {
+	now := time.Now()
+	r := NewRegistry(10 * time.Second)
+	r.now = func() time.Time { return now }
+
+	r.Ready("quiet", &ReadyPayload{})
+	r.Ready("chatty", &ReadyPayload{})
+
+	now = now.Add(25 * time.Second)
+	r.Heartbeat("chatty", &HeartbeatPayload{State: WorkerStateIdle})
+	if stale := r.MarkStale(); len(stale) != 0 {
+		t.Fatalf("marked %v offline before 3 intervals", stale)
+	}
+
+	now = now.Add(10 * time.Second)
+	if stale := r.MarkStale(); !slices.Equal(stale, []string{"quiet"}) {
+		t.Fatalf("MarkStale() = %v, want [quiet]", stale)
+	}
+	if stale := r.MarkStale(); len(stale) != 0 {
+		t.Errorf("MarkStale() reported %v again", stale)
+	}
+
+	fleet := r.Fleet()
+	if fleet.Online != 1 || fleet.Offline != 1 || fleet.Idle != 1 {
+		t.Errorf("online=%d offline=%d idle=%d, want 1, 1, 1", fleet.Online, fleet.Offline, fleet.Idle)
+	}
+
+	// Snapshots see staleness even before the next sweep
+	now = now.Add(time.Minute)
+	if w, _ := r.Worker("chatty"); w.Online {
+		t.Error("chatty still online after a minute of silence")
+	}
+
+	r.Heartbeat("quiet", &HeartbeatPayload{State: WorkerStateIdle})
+	if w, _ := r.Worker("quiet"); !w.Online {
+		t.Error("heartbeat didn't bring quiet back online")
+	}
+}
+
+func TestRegistry_ConcurrentIngest(t *testing.T) {
+	r := NewRegistry(time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		workerID := fmt.Sprintf("w%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				objectiveID := fmt.Sprintf("%s-obj%d", workerID, j)
+				payload, _ := json.Marshal(&HeartbeatPayload{
+					WorkerID: workerID, State: WorkerStateRunning, ObjectiveID: objectiveID, TokensInput: 1,
+				})
+				r.Ingest(workerID, &Message{Type: MsgTypeHeartbeat, Payload: payload})
+				r.Completed(workerID, &CompletionReport{ObjectiveID: objectiveID, Status: "completed"})
+				_ = r.Fleet()
+			}
+		}()
+	}
+	wg.Wait()
+
+	fleet := r.Fleet()
+	if len(fleet.Workers) != 8 || fleet.Completed != 400 || fleet.TokensUsed != 400 {
+		t.Errorf("workers=%d completed=%d tokens=%d, want 8, 400, 400",
+			len(fleet.Workers), fleet.Completed, fleet.TokensUsed)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"net"
 	"sync"
 	"time"
+
+	"github.com/lirancohen/dex/internal/crypto"
 )
 
 // RemoteWorker manages a worker connected via mesh network.
@@ -25,6 +27,7 @@ type RemoteWorker struct {
 	lastActivity time.Time
 	connectedAt  time.Time
 	version      string
+	dbSizeBytes  int64
 	err          error
 
 	mu        sync.RWMutex
@@ -66,6 +69,59 @@ func (w *RemoteWorker) Start(ctx context.Context) error {
 	return nil
 }
 
+// Authenticate runs HQ's side of the mutual authentication handshake and
+// returns the worker's ready payload. readyMsg is the worker's ready message;
+// its advertised public key must match the key this worker was enrolled with.
+// Without an HQ keypair the ready message is accepted as is, as for local
+// workers. Must be called before Start.
+func (w *RemoteWorker) Authenticate(hqKeyPair *crypto.KeyPair, readyMsg *Message) (*ReadyPayload, error) {
+	var ready *ReadyPayload
+	if hqKeyPair != nil {
+		authn, payload, err := NewHQAuthenticator(hqKeyPair, readyMsg)
+		if err != nil {
+			return nil, err
+		}
+		if payload.WorkerID != w.id || payload.PublicKey != w.pubKey {
+			return nil, fmt.Errorf("worker %s authentication failed: identity does not match enrollment", payload.WorkerID)
+		}
+
+		if err := authn.SendChallenge(w.protocol); err != nil {
+			return nil, fmt.Errorf("failed to send challenge: %w", err)
+		}
+
+		resp, err := w.protocol.Receive()
+		if err != nil {
+			return nil, err
+		}
+		if err := authn.VerifyResponse(resp); err != nil {
+			return nil, err
+		}
+		ready = payload
+	} else {
+		if readyMsg.Type != MsgTypeReady {
+			return nil, fmt.Errorf("expected ready message, got %s", readyMsg.Type)
+		}
+		payload, err := ParsePayload[ReadyPayload](readyMsg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ready payload: %w", err)
+		}
+		if payload.WorkerID != w.id {
+			return nil, fmt.Errorf("ready from worker %s on the connection for %s", payload.WorkerID, w.id)
+		}
+		ready = payload
+	}
+
+	w.mu.Lock()
+	w.version = ready.Version
+	w.objectiveID = ready.ObjectiveID
+	w.sessionID = ready.SessionID
+	if ready.ObjectiveID != "" {
+		w.state = WorkerStateRunning
+	}
+	w.mu.Unlock()
+	return ready, nil
+}
+
 // receiveLoop continuously reads messages from the remote worker.
 func (w *RemoteWorker) receiveLoop(ctx context.Context) {
 	defer close(w.done)
@@ -79,6 +135,10 @@ func (w *RemoteWorker) receiveLoop(ctx context.Context) {
 		}
 
 		msg, err := w.protocol.Receive()
+		if IsRejectedMessage(err) {
+			fmt.Printf("Remote worker %s: dropped message: %v\n", w.id, err)
+			continue
+		}
 		if err != nil {
 			w.mu.Lock()
 			w.state = WorkerStateError
@@ -105,6 +165,11 @@ func (w *RemoteWorker) handleMessage(msg *Message) {
 			w.sessionID = payload.SessionID
 			w.state = WorkerStateRunning
 		}
+		// Forward so the manager can record the objective as accepted
+		select {
+		case w.eventChan <- msg:
+		default:
+		}
 
 	case MsgTypeProgress:
 		payload, _ := ParsePayload[ProgressPayload](msg)
@@ -117,7 +182,23 @@ func (w *RemoteWorker) handleMessage(msg *Message) {
 		default:
 		}
 
-	case MsgTypeActivity:
+	case MsgTypeReady:
+		// A re-sent ready means the worker reconnected; it reports the
+		// objective it is still running, if any
+		if payload, _ := ParsePayload[ReadyPayload](msg); payload != nil {
+			w.objectiveID = payload.ObjectiveID
+			w.sessionID = payload.SessionID
+			w.state = WorkerStateIdle
+			if payload.ObjectiveID != "" {
+				w.state = WorkerStateRunning
+			}
+		}
+		select {
+		case w.eventChan <- msg:
+		default:
+		}
+
+	case MsgTypeActivity, MsgTypeLog, MsgTypeResumeRequest:
 		select {
 		case w.eventChan <- msg:
 		default:
@@ -134,7 +215,7 @@ func (w *RemoteWorker) handleMessage(msg *Message) {
 		default:
 		}
 
-	case MsgTypeFailed:
+	case MsgTypeFailed, MsgTypeRejected, MsgTypePlanPreview:
 		w.state = WorkerStateIdle
 		w.objectiveID = ""
 		w.sessionID = ""
@@ -152,6 +233,16 @@ func (w *RemoteWorker) handleMessage(msg *Message) {
 		default:
 		}
 
+	case MsgTypeHeartbeat:
+		if payload, _ := ParsePayload[HeartbeatPayload](msg); payload != nil {
+			w.dbSizeBytes = payload.DBSizeBytes
+		}
+		// Forward so the manager sees the objective is still alive
+		select {
+		case w.eventChan <- msg:
+		default:
+		}
+
 	case MsgTypePong:
 		select {
 		case w.eventChan <- msg:
@@ -217,6 +308,7 @@ func (w *RemoteWorker) Status() *WorkerStatus {
 		StartedAt:    w.connectedAt,
 		Error:        errStr,
 		Version:      w.version,
+		DBSizeBytes:  w.dbSizeBytes,
 	}
 }
 
@@ -231,9 +323,19 @@ func (w *RemoteWorker) Cancel(ctx context.Context) error {
 		return nil
 	}
 
+	return w.cancelObjective(objectiveID)
+}
+
+// cancelObjective sends a cancel for the objective, whether or not it's the current one.
+func (w *RemoteWorker) cancelObjective(objectiveID string) error {
 	return w.protocol.SendCancel(objectiveID, "cancelled by HQ")
 }
 
+// sendResume answers the worker's resume request.
+func (w *RemoteWorker) sendResume(payload *ResumePayload) error {
+	return w.protocol.SendResume(payload)
+}
+
 // Stop gracefully disconnects from the remote worker.
 func (w *RemoteWorker) Stop(ctx context.Context) error {
 	w.mu.Lock()
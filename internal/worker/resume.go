@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// resumeSender is a worker HQ can answer a resume request on.
+type resumeSender interface {
+	sendResume(payload *ResumePayload) error
+}
+
+// handleResumeRequest answers a worker asking for the secrets to resume a
+// crashed session, or asking again because it couldn't decrypt the ones it
+// was sent: the secrets are encrypted for the worker's current key and sent
+// back. When there are none to send, the resume is declined, so the worker
+// fails the session instead of waiting for an answer.
+func (m *Manager) handleResumeRequest(workerID string, req *ResumeRequestPayload) {
+	m.mu.RLock()
+	w, ok := m.workers[workerID]
+	source := m.secretsSource
+	m.mu.RUnlock()
+	if !ok {
+		fmt.Printf("Worker %s: resume request from unknown worker\n", workerID)
+		return
+	}
+	sender, ok := w.(resumeSender)
+	if !ok {
+		fmt.Printf("Worker %s: can't answer resume requests\n", workerID)
+		return
+	}
+
+	reply := &ResumePayload{ObjectiveID: req.ObjectiveID, SessionID: req.SessionID}
+	encrypted, err := m.encryptResumeSecrets(w, source)
+	if err != nil {
+		reply.Reason = err.Error()
+		fmt.Printf("Worker %s: declining resume of session %s: %v\n", workerID, req.SessionID, err)
+	} else {
+		reply.Approved = true
+		reply.EncryptedSecrets = encrypted
+		if req.Reason == ResumeReasonDecryptFailed {
+			fmt.Printf("Worker %s: re-sending secrets for session %s (attempt %d)\n", workerID, req.SessionID, req.Attempt)
+		}
+	}
+
+	if err := sender.sendResume(reply); err != nil {
+		fmt.Printf("Worker %s: failed to answer resume request: %v\n", workerID, err)
+	}
+}
+
+// encryptResumeSecrets encrypts the secrets from source for the worker's current key
+func (m *Manager) encryptResumeSecrets(w Worker, source func() (*WorkerSecrets, error)) (string, error) {
+	if source == nil {
+		return "", fmt.Errorf("HQ has no secrets source")
+	}
+	if m.hqKeyPair == nil {
+		return "", fmt.Errorf("HQ has no keypair")
+	}
+	pubKey := w.PublicKey()
+	if pubKey == "" {
+		return "", fmt.Errorf("worker has no public key")
+	}
+
+	secrets, err := source()
+	if err != nil {
+		return "", fmt.Errorf("failed to get secrets: %w", err)
+	}
+	if secrets == nil {
+		return "", fmt.Errorf("no secrets available")
+	}
+	secretsJSON, err := json.Marshal(secrets)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+	encrypted, err := m.hqKeyPair.EncryptForRecipient(secretsJSON, pubKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	return encrypted, nil
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/lirancohen/dex/internal/crypto"
+)
+
+func TestHandleResumeRequest_ResendsSecrets(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	workerKeys, _ := crypto.GenerateKeyPair()
+
+	w := newPipeWorker(t, "worker-a")
+	w.pubKey = workerKeys.PublicKey()
+	m, _ := newTimeoutTestManager(t, w)
+	m.hqKeyPair = hqKeys
+	m.SetSecretsSource(func() (*WorkerSecrets, error) {
+		return &WorkerSecrets{AnthropicKey: "sk-ant-test"}, nil
+	})
+
+	request, _ := json.Marshal(&ResumeRequestPayload{
+		WorkerID:    "worker-a",
+		ObjectiveID: "obj-1",
+		SessionID:   "sess-1",
+		Reason:      ResumeReasonDecryptFailed,
+		Attempt:     1,
+	})
+	m.processWorkerMessage("worker-a", &Message{Type: MsgTypeResumeRequest, Payload: request})
+
+	replies := w.waitFor(MsgTypeResume, 1)
+	if len(replies) != 1 {
+		t.Fatal("expected HQ to answer the resume request")
+	}
+	reply, _ := ParsePayload[ResumePayload](replies[0])
+	if reply == nil || !reply.Approved || reply.SessionID != "sess-1" || reply.ObjectiveID != "obj-1" {
+		t.Fatalf("resume reply = %+v, want the session approved", reply)
+	}
+
+	// Encrypted for the worker's key
+	decrypted, err := workerKeys.Decrypt(reply.EncryptedSecrets)
+	if err != nil {
+		t.Fatalf("worker can't decrypt the re-sent secrets: %v", err)
+	}
+	var secrets WorkerSecrets
+	if err := json.Unmarshal(decrypted, &secrets); err != nil || secrets.AnthropicKey != "sk-ant-test" {
+		t.Errorf("re-sent secrets = %+v, %v", secrets, err)
+	}
+}
+
+func TestHandleResumeRequest_DeclinesWithoutSecrets(t *testing.T) {
+	hqKeys, _ := crypto.GenerateKeyPair()
+	workerKeys, _ := crypto.GenerateKeyPair()
+
+	w := newPipeWorker(t, "worker-a")
+	w.pubKey = workerKeys.PublicKey()
+	m, _ := newTimeoutTestManager(t, w)
+	m.hqKeyPair = hqKeys
+	m.SetSecretsSource(func() (*WorkerSecrets, error) {
+		return nil, errors.New("secrets store locked")
+	})
+
+	request, _ := json.Marshal(&ResumeRequestPayload{WorkerID: "worker-a", ObjectiveID: "obj-1", SessionID: "sess-1"})
+	m.processWorkerMessage("worker-a", &Message{Type: MsgTypeResumeRequest, Payload: request})
+
+	replies := w.waitFor(MsgTypeResume, 1)
+	if len(replies) != 1 {
+		t.Fatal("expected HQ to answer the resume request")
+	}
+	if reply, _ := ParsePayload[ResumePayload](replies[0]); reply == nil || reply.Approved || reply.Reason == "" {
+		t.Errorf("resume reply = %+v, want a declined resume with a reason", reply)
+	}
+}
@@ -0,0 +1,213 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy controls how much history a worker's local database keeps.
+// Only data already synced to HQ is ever pruned.
+type RetentionPolicy struct {
+	// ActivityMaxAge is how long synced activity is kept (0 = forever).
+	ActivityMaxAge time.Duration
+
+	// MaxFinishedObjectives is how many finished objectives are kept, newest
+	// first, along with their sessions and activity (0 = unlimited).
+	MaxFinishedObjectives int
+
+	// ObjectiveMaxAge is how long finished objectives are kept, with their
+	// sessions and activity, after they finish (0 = forever).
+	ObjectiveMaxAge time.Duration
+
+	// MaxSizeBytes is the target database size (0 = unlimited). When the database
+	// reaches AggressivePruneRatio of it, all synced history is pruned.
+	MaxSizeBytes int64
+
+	// MaxDebugDirs is how many project directories kept after failed or
+	// cancelled objectives are left for debugging, newest first (0 = unlimited).
+	MaxDebugDirs int
+
+	// DebugDirMaxAge is how long those directories are kept (0 = forever).
+	DebugDirMaxAge time.Duration
+}
+
+// AggressivePruneRatio is the fraction of MaxSizeBytes at which compaction
+// switches to pruning all synced history.
+const AggressivePruneRatio = 0.8
+
+// DefaultRetentionPolicy returns the retention policy used by dex-worker.
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		ActivityMaxAge:        7 * 24 * time.Hour,
+		MaxFinishedObjectives: 100,
+		ObjectiveMaxAge:       30 * 24 * time.Hour,
+		MaxSizeBytes:          512 << 20,
+		MaxDebugDirs:          10,
+		DebugDirMaxAge:        3 * 24 * time.Hour,
+	}
+}
+
+// CompactionResult summarizes what a compaction removed.
+type CompactionResult struct {
+	ActivityPruned   int64
+	ObjectivesPruned int64
+	Aggressive       bool // All synced history was pruned because of the size limit
+	SizeBefore       int64
+	SizeAfter        int64
+}
+
+// finishedObjectiveStatuses are objective statuses that will never run again.
+var finishedObjectiveStatuses = []string{"completed", "failed", "cancelled", "timed_out"}
+
+// Size returns the on-disk size of the database, including its WAL file.
+func (ldb *LocalDB) Size() (int64, error) {
+	var total int64
+	for _, path := range []string{ldb.dbPath, ldb.dbPath + "-wal"} {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat database: %w", err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// Compact prunes synced history according to the policy and vacuums the database.
+// Objectives with unsynced activity and sessions that may still be resumed are kept.
+func (ldb *LocalDB) Compact(policy RetentionPolicy) (*CompactionResult, error) {
+	result := &CompactionResult{}
+
+	sizeBefore, err := ldb.Size()
+	if err != nil {
+		return nil, err
+	}
+	result.SizeBefore = sizeBefore
+	result.Aggressive = policy.MaxSizeBytes > 0 &&
+		float64(sizeBefore) >= float64(policy.MaxSizeBytes)*AggressivePruneRatio
+
+	activityCutoff := time.Time{}
+	objectiveCutoff := time.Time{}
+	keepObjectives := policy.MaxFinishedObjectives
+	if keepObjectives == 0 {
+		keepObjectives = -1 // No count limit
+	}
+	if policy.ObjectiveMaxAge > 0 {
+		objectiveCutoff = time.Now().Add(-policy.ObjectiveMaxAge)
+	}
+	switch {
+	case result.Aggressive:
+		activityCutoff = time.Now()
+		keepObjectives = 0
+	case policy.ActivityMaxAge > 0:
+		activityCutoff = time.Now().Add(-policy.ActivityMaxAge)
+	}
+
+	if !activityCutoff.IsZero() {
+		res, err := ldb.db.Exec(`DELETE FROM activity WHERE synced = 1 AND created_at < ?`, activityCutoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune activity: %w", err)
+		}
+		result.ActivityPruned, _ = res.RowsAffected()
+
+		if _, err := ldb.db.Exec(`DELETE FROM sync_log WHERE synced_at < ?`, activityCutoff); err != nil {
+			return nil, fmt.Errorf("failed to prune sync log: %w", err)
+		}
+	}
+
+	if keepObjectives >= 0 || !objectiveCutoff.IsZero() {
+		pruned, err := ldb.pruneFinishedObjectives(keepObjectives, objectiveCutoff)
+		if err != nil {
+			return nil, err
+		}
+		result.ObjectivesPruned = pruned
+	}
+
+	// Fold the WAL back into the main file so VACUUM can shrink it
+	if _, err := ldb.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return nil, fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	if _, err := ldb.db.Exec(`VACUUM`); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	if result.SizeAfter, err = ldb.Size(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pruneFinishedObjectives deletes finished objectives beyond the newest keep
+// (negative = no count limit) and ones that finished before cutoff (zero = no
+// age limit), with their sessions, session state, and activity. Objectives with
+// unsynced activity or a running session are skipped.
+func (ldb *LocalDB) pruneFinishedObjectives(keep int, cutoff time.Time) (int64, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(finishedObjectiveStatuses)), ",")
+	var cutoffArg any // NULL never compares as expired
+	if !cutoff.IsZero() {
+		cutoffArg = cutoff
+	}
+	args := []any{cutoffArg}
+	for _, s := range finishedObjectiveStatuses {
+		args = append(args, s)
+	}
+
+	// Newest first, so an objective's row number is how many newer ones are kept ahead of it
+	rows, err := ldb.db.Query(`
+		SELECT id, COALESCE(COALESCE(completed_at, created_at) < ?, 0)
+		FROM objectives
+		WHERE status IN (`+placeholders+`)
+		  AND NOT EXISTS (SELECT 1 FROM activity WHERE activity.objective_id = objectives.id AND synced = 0)
+		  AND NOT EXISTS (SELECT 1 FROM session_state WHERE session_state.objective_id = objectives.id AND status = 'running')
+		ORDER BY COALESCE(completed_at, created_at) DESC
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find objectives to prune: %w", err)
+	}
+	var ids []string
+	for rank := 0; rows.Next(); rank++ {
+		var id string
+		var expired bool
+		if err := rows.Scan(&id, &expired); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan objective: %w", err)
+		}
+		if (keep >= 0 && rank >= keep) || expired {
+			ids = append(ids, id)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to find objectives to prune: %w", err)
+	}
+
+	tx, err := ldb.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Children first: foreign keys are enforced
+	statements := []string{
+		`DELETE FROM activity WHERE objective_id = ?`,
+		`DELETE FROM session_state WHERE objective_id = ?`,
+		`DELETE FROM sessions WHERE objective_id = ?`,
+		`DELETE FROM objectives WHERE id = ?`,
+	}
+	for _, id := range ids {
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt, id); err != nil {
+				return 0, fmt.Errorf("failed to prune objective %s: %w", id, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit pruning: %w", err)
+	}
+	return int64(len(ids)), nil
+}
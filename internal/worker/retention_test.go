@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// seedObjective stores a finished objective with one session and one activity event.
+func seedObjective(t *testing.T, db *LocalDB, id, status string, activityAge time.Duration, synced bool) {
+	t.Helper()
+	payload := &ObjectivePayload{Objective: Objective{ID: id, Title: id, Hat: "explorer"}}
+	if err := db.StoreObjective(payload); err != nil {
+		t.Fatalf("failed to store objective: %v", err)
+	}
+	sessionID := "sess-" + id
+	if err := db.CreateSession(sessionID, id, "explorer"); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	activityID := "act-" + id
+	if err := db.RecordActivity(&ActivityEvent{
+		ID: activityID, SessionID: sessionID, ObjectiveID: id,
+		EventType: "assistant_response", CreatedAt: time.Now().Add(-activityAge),
+	}); err != nil {
+		t.Fatalf("failed to record activity: %v", err)
+	}
+	if synced {
+		if err := db.MarkActivitySynced([]string{activityID}); err != nil {
+			t.Fatalf("failed to mark synced: %v", err)
+		}
+	}
+	if status != "" {
+		if err := db.UpdateObjectiveStatus(id, status); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+	}
+}
+
+func countRows(t *testing.T, db *LocalDB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestLocalDB_Compact(t *testing.T) {
+	db, err := OpenLocalDB(filepath.Join(t.TempDir(), "test.db"), nil)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for i := range 3 {
+		seedObjective(t, db, fmt.Sprintf("done-%d", i), "completed", time.Hour, true)
+	}
+	seedObjective(t, db, "old-activity", "", 30*24*time.Hour, true)    // Still running, old synced activity
+	seedObjective(t, db, "unsynced", "failed", 30*24*time.Hour, false) // Finished but not yet synced
+
+	result, err := db.Compact(RetentionPolicy{
+		ActivityMaxAge:        7 * 24 * time.Hour,
+		MaxFinishedObjectives: 1,
+	})
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+
+	if result.Aggressive {
+		t.Error("expected non-aggressive compaction with no size limit")
+	}
+	if result.ActivityPruned != 1 {
+		t.Errorf("ActivityPruned = %d, want 1 (old synced activity only)", result.ActivityPruned)
+	}
+	// Newest completed objective is kept; the unsynced one is never pruned
+	if result.ObjectivesPruned != 2 {
+		t.Errorf("ObjectivesPruned = %d, want 2", result.ObjectivesPruned)
+	}
+	if got, _ := db.GetObjective("unsynced"); got == nil {
+		t.Error("objective with unsynced activity was pruned")
+	}
+	if got, _ := db.GetObjective("old-activity"); got == nil {
+		t.Error("running objective was pruned")
+	}
+	if n := countRows(t, db, "objectives"); n != 3 {
+		t.Errorf("objectives remaining = %d, want 3", n)
+	}
+	if n := countRows(t, db, "sessions"); n != 3 {
+		t.Errorf("sessions remaining = %d, want 3", n)
+	}
+}
+
+func TestLocalDB_CompactObjectiveMaxAge(t *testing.T) {
+	db, err := OpenLocalDB(filepath.Join(t.TempDir(), "test.db"), nil)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	seedObjective(t, db, "old", "completed", time.Hour, true)
+	seedObjective(t, db, "recent", "completed", time.Hour, true)
+	seedObjective(t, db, "old-unsynced", "failed", time.Hour, false)
+	seedObjective(t, db, "running", "", time.Hour, true)
+	for _, id := range []string{"old", "old-unsynced"} {
+		if _, err := db.db.Exec(`UPDATE objectives SET completed_at = ? WHERE id = ?`, time.Now().Add(-60*24*time.Hour), id); err != nil {
+			t.Fatalf("failed to backdate objective: %v", err)
+		}
+	}
+
+	result, err := db.Compact(RetentionPolicy{ObjectiveMaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+
+	if result.ObjectivesPruned != 1 {
+		t.Errorf("ObjectivesPruned = %d, want 1", result.ObjectivesPruned)
+	}
+	if got, _ := db.GetObjective("old"); got != nil {
+		t.Error("expired objective was kept")
+	}
+	for _, id := range []string{"recent", "old-unsynced", "running"} {
+		if got, _ := db.GetObjective(id); got == nil {
+			t.Errorf("objective %s was pruned", id)
+		}
+	}
+}
+
+func TestLocalDB_CompactAggressiveNearSizeLimit(t *testing.T) {
+	db, err := OpenLocalDB(filepath.Join(t.TempDir(), "test.db"), nil)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	seedObjective(t, db, "recent", "completed", time.Minute, true)
+	seedObjective(t, db, "unsynced", "completed", time.Minute, false)
+
+	// Any real database exceeds a 1-byte limit
+	result, err := db.Compact(RetentionPolicy{
+		ActivityMaxAge:        7 * 24 * time.Hour,
+		MaxFinishedObjectives: 100,
+		MaxSizeBytes:          1,
+	})
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+
+	if !result.Aggressive {
+		t.Error("expected aggressive compaction")
+	}
+	if result.ObjectivesPruned != 1 || result.ActivityPruned != 1 {
+		t.Errorf("pruned %d objectives, %d activity; want 1 and 1", result.ObjectivesPruned, result.ActivityPruned)
+	}
+	if events, _ := db.GetUnsyncedActivity(10); len(events) != 1 {
+		t.Errorf("unsynced activity = %d events, want 1 kept", len(events))
+	}
+	if result.SizeAfter <= 0 {
+		t.Errorf("SizeAfter = %d, want > 0", result.SizeAfter)
+	}
+}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lirancohen/dex/internal/db"
+)
+
+// objectiveTimeoutGrace is how long HQ waits past an objective's timeout before
+// enforcing it, giving the worker a chance to stop itself and report first.
+const objectiveTimeoutGrace = time.Minute
+
+// Objective timeout reasons
+const (
+	TimeoutReasonDeadline     = "deadline_exceeded" // Ran past its timeout
+	TimeoutReasonWorkerSilent = "worker_silent"     // Worker stopped sending messages
+	TimeoutReasonSelfReported = "self_reported"     // Worker stopped itself at the timeout
+	TimeoutReasonWorkerReset  = "worker_reset"      // Worker reconnected without the objective
+)
+
+// inflightObjective tracks a dispatched objective until it completes or times out.
+type inflightObjective struct {
+	payload      *ObjectivePayload // Unencrypted payload, re-encrypted when re-queued
+	secrets      *WorkerSecrets
+	workerID     string
+	dispatchedAt time.Time
+	lastSeen     time.Time // Last message from the worker running it
+	timeout      time.Duration
+	attempts     int      // Dispatches so far, including this one
+	timedOutOn   []string // Workers this objective already timed out on
+	rejectedBy   []string // Workers that rejected it for unmet acceptance criteria
+}
+
+// trackDispatch records an objective that was just dispatched to a worker.
+func (m *Manager) trackDispatch(req *dispatchRequest, workerID string) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inflight[req.payload.Objective.ID] = &inflightObjective{
+		payload:      req.payload,
+		secrets:      req.secrets,
+		workerID:     workerID,
+		dispatchedAt: now,
+		lastSeen:     now,
+		timeout:      req.payload.Objective.Timeout(),
+		attempts:     req.attempts + 1,
+		timedOutOn:   req.exclude,
+		rejectedBy:   req.rejectedBy,
+	}
+}
+
+// touchInflight records activity from a worker against the objective it is running.
+func (m *Manager) touchInflight(workerID string) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, obj := range m.inflight {
+		if obj.workerID == workerID {
+			obj.lastSeen = now
+		}
+	}
+}
+
+// finishInflight stops tracking an objective once its worker reports an outcome.
+// Returns false if the objective was re-queued away from this worker, in which case
+// the report is stale and should be ignored.
+func (m *Manager) finishInflight(workerID, objectiveID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if objectiveID == "" {
+		// Cancelled messages carry no objective; match on the worker
+		for id, obj := range m.inflight {
+			if obj.workerID == workerID {
+				objectiveID = id
+				break
+			}
+		}
+	}
+
+	obj, ok := m.inflight[objectiveID]
+	if !ok {
+		return true // Not tracked (e.g. dispatched before HQ restarted)
+	}
+	if obj.workerID != workerID {
+		return false
+	}
+	delete(m.inflight, objectiveID)
+	return true
+}
+
+// checkObjectiveTimeouts cancels objectives that ran past their timeout or whose
+// worker went silent, re-queueing them while attempts remain.
+func (m *Manager) checkObjectiveTimeouts() {
+	silentThreshold := m.config.StalledWorkerThreshold
+	if silentThreshold == 0 {
+		silentThreshold = 60 * time.Second
+	}
+	now := time.Now()
+
+	type expiredObjective struct {
+		workerID    string
+		objectiveID string
+		reason      string
+	}
+	var expired []expiredObjective
+
+	m.mu.RLock()
+	for id, obj := range m.inflight {
+		switch {
+		case obj.timeout > 0 && now.Sub(obj.dispatchedAt) > obj.timeout+objectiveTimeoutGrace:
+			expired = append(expired, expiredObjective{obj.workerID, id, TimeoutReasonDeadline})
+		case now.Sub(obj.lastSeen) > silentThreshold:
+			expired = append(expired, expiredObjective{obj.workerID, id, TimeoutReasonWorkerSilent})
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, e := range expired {
+		m.handleObjectiveTimeout(e.workerID, e.objectiveID, e.reason)
+	}
+}
+
+// handleObjectiveTimeout cancels an objective that timed out on a worker and either
+// re-queues it on another worker or reports it as timed out.
+func (m *Manager) handleObjectiveTimeout(workerID, objectiveID, reason string) {
+	m.mu.Lock()
+	obj, ok := m.inflight[objectiveID]
+	if ok && obj.workerID != workerID {
+		// Already re-queued elsewhere; this worker's report is stale
+		m.mu.Unlock()
+		return
+	}
+	m.timeouts[workerID]++
+	onTimedOut := m.onTimedOut
+	if !ok {
+		// Not tracked (e.g. dispatched before HQ restarted): nothing to re-queue
+		m.mu.Unlock()
+		m.recordState(objectiveID, db.DispatchStateFailed, "timed out: "+reason)
+		if onTimedOut != nil {
+			onTimedOut(objectiveID, workerID, reason, false)
+		}
+		return
+	}
+	delete(m.inflight, objectiveID)
+	w := m.workers[workerID]
+	m.mu.Unlock()
+
+	fmt.Printf("Objective %s timed out on worker %s (%s, attempt %d)\n", objectiveID, obj.workerID, reason, obj.attempts)
+
+	// Best effort: a silent worker may never acknowledge. A worker that stopped
+	// itself or lost the objective has nothing to cancel.
+	if w != nil && reason != TimeoutReasonSelfReported && reason != TimeoutReasonWorkerReset {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = w.Cancel(ctx)
+		}()
+	}
+
+	maxAttempts := m.config.MaxObjectiveAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+	requeued := obj.attempts < maxAttempts
+	if onTimedOut != nil {
+		onTimedOut(objectiveID, obj.workerID, reason, requeued)
+	}
+	if !requeued {
+		m.recordState(objectiveID, db.DispatchStateFailed, "timed out: "+reason)
+		return
+	}
+
+	m.requeue(&dispatchRequest{
+		payload:    obj.payload,
+		secrets:    obj.secrets,
+		response:   make(chan error, 1),
+		attempts:   obj.attempts,
+		exclude:    append(slices.Clone(obj.timedOutOn), obj.workerID),
+		rejectedBy: obj.rejectedBy,
+	}, func(err error) {
+		fmt.Printf("Objective %s: re-queue failed: %v\n", objectiveID, err)
+		if onTimedOut != nil {
+			onTimedOut(objectiveID, obj.workerID, reason, false)
+		}
+	})
+}
+
+// requeue queues an objective taken away from a worker for dispatch to another
+// one, calling onFailure if it can't be dispatched.
+func (m *Manager) requeue(req *dispatchRequest, onFailure func(err error)) {
+	m.recordRequeued(req.payload.Objective.ID, req.attempts, req.exclude)
+	go func() {
+		select {
+		case m.queue <- req:
+		case <-m.ctx.Done():
+			return
+		}
+		select {
+		case err := <-req.response:
+			if err != nil {
+				onFailure(err)
+			}
+		case <-m.ctx.Done():
+		}
+	}()
+}
@@ -0,0 +1,198 @@
+package worker
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipeWorker is a RemoteWorker over an in-memory pipe that records what HQ sends it.
+type pipeWorker struct {
+	*RemoteWorker
+
+	mu       sync.Mutex
+	received []*Message
+}
+
+func newPipeWorker(t *testing.T, id string) *pipeWorker {
+	t.Helper()
+	hqSide, workerSide := net.Pipe()
+	t.Cleanup(func() { _ = hqSide.Close(); _ = workerSide.Close() })
+
+	pw := &pipeWorker{RemoteWorker: NewRemoteWorker(id, id, "", "", hqSide)}
+	conn := NewConn(workerSide, workerSide)
+	go func() {
+		for {
+			msg, err := conn.Receive()
+			if err != nil {
+				return
+			}
+			pw.mu.Lock()
+			pw.received = append(pw.received, msg)
+			pw.mu.Unlock()
+		}
+	}()
+	return pw
+}
+
+// waitFor polls until n messages of the given type have been received.
+func (pw *pipeWorker) waitFor(msgType MessageType, n int) []*Message {
+	deadline := time.Now().Add(time.Second)
+	for {
+		pw.mu.Lock()
+		var matched []*Message
+		for _, m := range pw.received {
+			if m.Type == msgType {
+				matched = append(matched, m)
+			}
+		}
+		pw.mu.Unlock()
+		if len(matched) >= n || time.Now().After(deadline) {
+			return matched
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (pw *pipeWorker) setState(state WorkerState) {
+	pw.RemoteWorker.mu.Lock()
+	pw.state = state
+	pw.RemoteWorker.mu.Unlock()
+}
+
+type timeoutEvent struct {
+	objectiveID, workerID, reason string
+	requeued                      bool
+}
+
+// newTimeoutTestManager returns a manager whose pool holds the given workers,
+// without spawning subprocesses or running the dispatch loop.
+func newTimeoutTestManager(t *testing.T, workers ...*pipeWorker) (*Manager, chan timeoutEvent) {
+	t.Helper()
+	m := NewManager(nil, DefaultManagerConfig(), nil)
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	t.Cleanup(m.cancel)
+
+	for _, w := range workers {
+		m.workers[w.ID()] = w.RemoteWorker
+		m.remotePool = append(m.remotePool, w.RemoteWorker)
+	}
+
+	events := make(chan timeoutEvent, 10)
+	m.SetOnTimedOut(func(objectiveID, workerID, reason string, requeued bool) {
+		events <- timeoutEvent{objectiveID, workerID, reason, requeued}
+	})
+	return m, events
+}
+
+func TestObjectiveTimeout_RequeuesOnAnotherWorker(t *testing.T) {
+	a, b := newPipeWorker(t, "worker-a"), newPipeWorker(t, "worker-b")
+	m, events := newTimeoutTestManager(t, a, b)
+
+	payload := &ObjectivePayload{Objective: Objective{ID: "obj-1", TimeoutSec: 60}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if len(a.waitFor(MsgTypeDispatch, 1)) != 1 {
+		t.Fatalf("expected worker-a to receive the objective")
+	}
+
+	// Push the dispatch time past timeout + grace
+	m.mu.Lock()
+	m.inflight["obj-1"].dispatchedAt = time.Now().Add(-time.Minute - objectiveTimeoutGrace - time.Second)
+	m.mu.Unlock()
+
+	m.checkObjectiveTimeouts()
+
+	ev := <-events
+	if ev.workerID != "worker-a" || ev.reason != TimeoutReasonDeadline || !ev.requeued {
+		t.Errorf("unexpected timeout event: %+v", ev)
+	}
+
+	if len(a.waitFor(MsgTypeCancel, 1)) != 1 {
+		t.Error("expected timed-out worker to be cancelled")
+	}
+
+	// Both idle: the re-queue must still prefer worker-b
+	a.setState(WorkerStateIdle)
+	req := <-m.queue
+	if err := m.dispatch(req); err != nil {
+		t.Fatalf("re-dispatch failed: %v", err)
+	}
+	dispatched := b.waitFor(MsgTypeDispatch, 1)
+	if len(dispatched) != 1 {
+		t.Fatalf("expected re-queued objective on worker-b")
+	}
+	retry, _ := ParsePayload[DispatchPayload](dispatched[0])
+	if retry == nil || retry.Objective.Objective.TimeoutSec != 60 {
+		t.Errorf("retry payload = %+v, want TimeoutSec 60 carried over", retry)
+	}
+
+	// worker-a's late completion is stale and must not finish the re-queued objective
+	if m.finishInflight("worker-a", "obj-1") {
+		t.Error("expected stale completion from worker-a to be rejected")
+	}
+
+	for _, s := range m.Workers() {
+		if s.ID == "worker-a" && s.Timeouts != 1 {
+			t.Errorf("worker-a Timeouts = %d, want 1", s.Timeouts)
+		}
+	}
+}
+
+func TestObjectiveTimeout_ExhaustsAttempts(t *testing.T) {
+	a := newPipeWorker(t, "worker-a")
+	m, events := newTimeoutTestManager(t, a)
+	m.config.MaxObjectiveAttempts = 1
+
+	payload := &ObjectivePayload{Objective: Objective{ID: "obj-1"}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	sent, _ := ParsePayload[DispatchPayload](a.waitFor(MsgTypeDispatch, 1)[0])
+	if sent == nil || sent.Objective.Objective.TimeoutSec != int(m.config.ObjectiveTimeout.Seconds()) {
+		t.Errorf("dispatched payload = %+v, want default timeout %v", sent, m.config.ObjectiveTimeout)
+	}
+
+	// Silent worker: no messages since dispatch
+	m.mu.Lock()
+	m.inflight["obj-1"].lastSeen = time.Now().Add(-2 * m.config.StalledWorkerThreshold)
+	m.mu.Unlock()
+
+	m.checkObjectiveTimeouts()
+
+	ev := <-events
+	if ev.reason != TimeoutReasonWorkerSilent || ev.requeued {
+		t.Errorf("unexpected timeout event: %+v", ev)
+	}
+	select {
+	case <-m.queue:
+		t.Error("objective should not be re-queued after its last attempt")
+	default:
+	}
+}
+
+func TestObjectiveTimeout_HeartbeatKeepsObjectiveAlive(t *testing.T) {
+	a := newPipeWorker(t, "worker-a")
+	m, events := newTimeoutTestManager(t, a)
+
+	payload := &ObjectivePayload{Objective: Objective{ID: "obj-1"}}
+	if err := m.DispatchImmediate(context.Background(), payload); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+
+	m.mu.Lock()
+	m.inflight["obj-1"].lastSeen = time.Now().Add(-2 * m.config.StalledWorkerThreshold)
+	m.mu.Unlock()
+
+	m.processWorkerMessage("worker-a", &Message{Type: MsgTypeHeartbeat})
+	m.checkObjectiveTimeouts()
+
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected timeout after heartbeat: %+v", ev)
+	default:
+	}
+}
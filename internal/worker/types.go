@@ -5,6 +5,8 @@ package worker
 
 import (
 	"time"
+
+	"github.com/lirancohen/dex/prompts"
 )
 
 // ObjectivePayload is the data structure sent from HQ to workers.
@@ -28,6 +30,12 @@ type ObjectivePayload struct {
 
 	// HQPublicKey is HQ's public key for the worker to encrypt responses
 	HQPublicKey string `json:"hq_public_key"`
+
+	// PromptVersion is the prompt set the worker must run (empty = its compiled-in prompts)
+	PromptVersion string `json:"prompt_version,omitempty"`
+
+	// Prompts is the prompt set itself, included unless HQ knows the worker has it cached
+	Prompts *prompts.Set `json:"prompts,omitempty"`
 }
 
 // Objective represents a task to be executed by the worker.
@@ -39,6 +47,37 @@ type Objective struct {
 	BaseBranch  string   `json:"base_branch"`
 	TokenBudget int      `json:"token_budget,omitempty"`
 	Checklist   []string `json:"checklist,omitempty"`
+
+	// Model is the model HQ requires the objective to run with ("sonnet" or
+	// "opus"), whatever the worker's own settings. Empty means DefaultModel.
+	Model string `json:"model,omitempty"`
+
+	// TimeoutSec is the maximum wall-clock runtime. The worker stops itself when it
+	// elapses and HQ cancels and re-queues the objective if the worker doesn't.
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+
+	// Network restricts what the objective's tools may reach once the project is cloned.
+	Network NetworkPolicy `json:"network"`
+
+	// Acceptance lists what the worker must have to take the objective. Workers
+	// that don't meet it reject the objective instead of accepting it.
+	Acceptance AcceptanceCriteria `json:"acceptance,omitempty"`
+
+	// PreviewPlan asks the worker to send a short plan for approval instead of
+	// running the objective. HQ re-dispatches it with ApprovedPlan once approved.
+	PreviewPlan bool `json:"preview_plan,omitempty"`
+
+	// ApprovedPlan is the plan HQ approved, which the worker is told to follow
+	ApprovedPlan string `json:"approved_plan,omitempty"`
+
+	// Outputs are extra destinations the worker delivers the result to once the
+	// objective completes, on top of the completion report.
+	Outputs []OutputDestination `json:"outputs,omitempty"`
+}
+
+// Timeout returns the objective's runtime limit, or 0 if unlimited.
+func (o *Objective) Timeout() time.Duration {
+	return time.Duration(o.TimeoutSec) * time.Second
 }
 
 // Project contains project metadata needed for execution.
@@ -110,7 +149,8 @@ type CompletionReport struct {
 	Iterations    int             `json:"iterations"`
 	ChecklistDone []string        `json:"checklist_done,omitempty"`
 	Errors        []string        `json:"errors,omitempty"`
-	Activities    []ActivityEvent `json:"activities"` // Final batch of unsynced activities
+	OutputErrors  []string        `json:"output_errors,omitempty"` // Output destinations that couldn't be delivered to
+	Activities    []ActivityEvent `json:"activities"`              // Final batch of unsynced activities
 	CompletedAt   time.Time       `json:"completed_at"`
 }
 
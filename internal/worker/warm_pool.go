@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWarmRefreshInterval is how often warm mirrors are fetched from their remotes.
+const DefaultWarmRefreshInterval = 10 * time.Minute
+
+// WarmPool keeps bare mirrors of a configured set of repos so that SetupProject
+// can create a working copy with a local clone instead of a network clone.
+// Mirrors live in {dataDir}/warm/{owner}/{repo}.git and are refreshed periodically.
+type WarmPool struct {
+	dir   string
+	repos map[string]string // owner/repo -> clone URL
+}
+
+// NewWarmPool creates a pool for the given clone URLs. URLs whose owner/repo
+// can't be determined are skipped. Private repos need credentials git can use
+// non-interactively, e.g. a token embedded in the URL.
+func NewWarmPool(dataDir string, cloneURLs []string) *WarmPool {
+	pool := &WarmPool{
+		dir:   filepath.Join(dataDir, "warm"),
+		repos: make(map[string]string),
+	}
+	for _, cloneURL := range cloneURLs {
+		owner, repo := parseCloneURL(cloneURL)
+		if owner == "" || repo == "" {
+			fmt.Fprintf(os.Stderr, "WarmPool: skipping %s: can't determine owner/repo\n", cloneURL)
+			continue
+		}
+		pool.repos[owner+"/"+repo] = cloneURL
+	}
+	return pool
+}
+
+// Run refreshes the pool immediately and then every interval until ctx is done.
+func (p *WarmPool) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWarmRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Refresh(); err != nil {
+			fmt.Fprintf(os.Stderr, "WarmPool: refresh failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh creates missing mirrors and fetches existing ones. A failure on one
+// repo doesn't stop the others; all failures are returned together.
+func (p *WarmPool) Refresh() error {
+	var errs []error
+	for key, cloneURL := range p.repos {
+		mirrorDir := p.mirrorDir(key)
+		var err error
+		if mirrorExists(mirrorDir) {
+			err = runGit(mirrorDir, "fetch", "--prune", "origin")
+		} else {
+			err = p.createMirror(cloneURL, mirrorDir)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Has reports whether a ready mirror exists for owner/repo.
+func (p *WarmPool) Has(owner, repo string) bool {
+	if _, ok := p.repos[owner+"/"+repo]; !ok {
+		return false
+	}
+	return mirrorExists(p.mirrorDir(owner + "/" + repo))
+}
+
+// CloneTo creates a working copy of owner/repo at dir from its mirror. Objects
+// are hardlinked, so this doesn't touch the network; origin is then pointed at
+// cloneURL so later fetches and pushes go to the real remote.
+func (p *WarmPool) CloneTo(owner, repo, cloneURL, dir, baseBranch string) error {
+	if !p.Has(owner, repo) {
+		return fmt.Errorf("no warm mirror for %s/%s", owner, repo)
+	}
+
+	args := []string{"clone", "--local"}
+	if baseBranch != "" {
+		args = append(args, "--branch", baseBranch)
+	}
+	args = append(args, p.mirrorDir(owner+"/"+repo), dir)
+	if err := runGit("", args...); err != nil {
+		return err
+	}
+
+	if err := runGit(dir, "remote", "set-url", "origin", cloneURL); err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// createMirror clones into a temporary directory and renames it into place,
+// so a half-finished mirror is never used.
+func (p *WarmPool) createMirror(cloneURL, mirrorDir string) error {
+	if err := os.MkdirAll(filepath.Dir(mirrorDir), 0755); err != nil {
+		return fmt.Errorf("failed to create mirror parent directory: %w", err)
+	}
+
+	tmpDir := mirrorDir + ".tmp"
+	_ = os.RemoveAll(tmpDir)
+	if err := runGit("", "clone", "--mirror", cloneURL, tmpDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return err
+	}
+	if err := os.Rename(tmpDir, mirrorDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to move mirror into place: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "WarmPool: created mirror at %s\n", mirrorDir)
+	return nil
+}
+
+func (p *WarmPool) mirrorDir(key string) string {
+	return filepath.Join(p.dir, key+".git")
+}
+
+// mirrorExists checks for the HEAD file every bare repository has.
+func mirrorExists(mirrorDir string) bool {
+	_, err := os.Stat(filepath.Join(mirrorDir, "HEAD"))
+	return err == nil
+}
+
+// runGit runs a non-interactive git command, including its output in errors.
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitCmd runs git in dir, failing the test on error
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %s: %v", args, output, err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// setupOriginRepo creates a repo with one commit on main at {root}/acme/widgets
+func setupOriginRepo(t *testing.T) (dir, cloneURL string) {
+	t.Helper()
+	dir = filepath.Join(t.TempDir(), "acme", "widgets")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "init", "-b", "main")
+	gitCmd(t, dir, "config", "user.email", "test@test.com")
+	gitCmd(t, dir, "config", "user.name", "Test User")
+	gitCmd(t, dir, "config", "commit.gpgsign", "false")
+	gitCmd(t, dir, "commit", "--allow-empty", "-m", "initial")
+	return dir, "file://" + dir
+}
+
+func TestWarmPool_SetupProjectUsesMirror(t *testing.T) {
+	originDir, cloneURL := setupOriginRepo(t)
+	dataDir := t.TempDir()
+
+	pool := NewWarmPool(dataDir, []string{cloneURL})
+	if err := pool.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if !pool.Has("acme", "widgets") {
+		t.Fatal("expected a warm mirror for acme/widgets")
+	}
+
+	// Commits made after the last refresh are still picked up
+	gitCmd(t, originDir, "commit", "--allow-empty", "-m", "after refresh")
+	head := gitCmd(t, originDir, "rev-parse", "HEAD")
+
+	pm := NewProjectManager(dataDir)
+	pm.SetWarmPool(pool)
+	workDir, err := pm.SetupProject(Project{ID: "proj-1", CloneURL: cloneURL}, "main")
+	if err != nil {
+		t.Fatalf("SetupProject failed: %v", err)
+	}
+
+	if got := gitCmd(t, workDir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("expected working copy at %s, got %s", head, got)
+	}
+	if got := gitCmd(t, workDir, "remote", "get-url", "origin"); got != cloneURL {
+		t.Errorf("expected origin %s, got %s", cloneURL, got)
+	}
+}
+
+func TestWarmPool_Refresh(t *testing.T) {
+	originDir, cloneURL := setupOriginRepo(t)
+	pool := NewWarmPool(t.TempDir(), []string{cloneURL, "not-a-url"})
+
+	if len(pool.repos) != 1 {
+		t.Fatalf("expected unparseable URL to be skipped, got %v", pool.repos)
+	}
+	if err := pool.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	gitCmd(t, originDir, "commit", "--allow-empty", "-m", "second")
+	if err := pool.Refresh(); err != nil {
+		t.Fatalf("second Refresh failed: %v", err)
+	}
+
+	mirror := pool.mirrorDir("acme/widgets")
+	if got, want := gitCmd(t, mirror, "rev-parse", "main"), gitCmd(t, originDir, "rev-parse", "HEAD"); got != want {
+		t.Errorf("expected mirror main at %s, got %s", want, got)
+	}
+}
+
+func TestWarmPool_CloneToWithoutMirror(t *testing.T) {
+	pool := NewWarmPool(t.TempDir(), []string{"https://github.com/acme/widgets.git"})
+
+	if pool.Has("acme", "widgets") {
+		t.Error("expected no mirror before the first refresh")
+	}
+	if err := pool.CloneTo("acme", "widgets", "https://github.com/acme/widgets.git", t.TempDir(), "main"); err == nil {
+		t.Error("expected error cloning without a mirror")
+	}
+}
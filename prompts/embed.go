@@ -0,0 +1,15 @@
+// Package prompts provides the hat prompt set shared by HQ and workers.
+//
+// The YAML files in this directory are compiled into both binaries as the
+// fallback prompt set. HQ may load an override from disk, and ships whichever
+// set it loaded to workers at dispatch time, identified by Set.Version, so a
+// worker always runs the prompts HQ expects.
+package prompts
+
+import "embed"
+
+// FS contains the compiled-in prompt components, profiles, language guidelines,
+// and task type kickoffs.
+//
+//go:embed components/*.yaml profiles/*.yaml languages/*.yaml kickoffs/*.yaml
+var FS embed.FS
@@ -0,0 +1,136 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// setDirs are the prompt directories that make up a set
+var setDirs = []string{"components", "profiles", "languages", "kickoffs"}
+
+// Set is a versioned snapshot of a prompt directory.
+type Set struct {
+	// Version identifies the contents of Files (see ComputeVersion)
+	Version string `json:"version"`
+
+	// Files maps slash-separated paths relative to the prompts root to file contents
+	Files map[string]string `json:"files"`
+}
+
+// Load reads every prompt YAML file from fsys into a set.
+// Missing directories are skipped; the caller validates the prompts themselves.
+func Load(fsys fs.FS) (*Set, error) {
+	files := make(map[string]string)
+
+	for _, dir := range setDirs {
+		entries, err := fs.ReadDir(fsys, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+				continue
+			}
+			name := path.Join(dir, entry.Name())
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			files[name] = string(data)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no prompt files found")
+	}
+
+	return &Set{Version: ComputeVersion(files), Files: files}, nil
+}
+
+// Embedded returns the compiled-in prompt set.
+func Embedded() (*Set, error) {
+	return Load(FS)
+}
+
+// ComputeVersion returns a content hash of the files. Two sets with the same
+// version contain identical prompts.
+func ComputeVersion(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(files[name]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Verify checks that every file path is a valid prompt path and that the
+// version matches the contents.
+func (s *Set) Verify() error {
+	if len(s.Files) == 0 {
+		return fmt.Errorf("prompt set %s is empty", s.Version)
+	}
+	for name := range s.Files {
+		dir, file := path.Split(name)
+		if !fs.ValidPath(name) || !slices.Contains(setDirs, strings.TrimSuffix(dir, "/")) ||
+			!strings.HasSuffix(file, ".yaml") {
+			return fmt.Errorf("invalid prompt path: %q", name)
+		}
+	}
+	if got := ComputeVersion(s.Files); got != s.Version {
+		return fmt.Errorf("prompt set version mismatch: expected %s, contents hash to %s", s.Version, got)
+	}
+	return nil
+}
+
+// WriteDir verifies the set and writes it to dir, which must not exist yet.
+// Files are written to a temporary sibling first so dir is never left partial.
+func (s *Set) WriteDir(dir string) error {
+	if err := s.Verify(); err != nil {
+		return err
+	}
+
+	parent := filepath.Dir(dir)
+	if err := os.MkdirAll(parent, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+	tmp, err := os.MkdirTemp(parent, ".prompts-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	for name, content := range s.Files {
+		target := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return fmt.Errorf("failed to create prompt dir: %w", err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("failed to install prompt set: %w", err)
+	}
+	return nil
+}
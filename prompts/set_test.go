@@ -0,0 +1,83 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbedded(t *testing.T) {
+	set, err := Embedded()
+	if err != nil {
+		t.Fatalf("Embedded() error = %v", err)
+	}
+	if _, ok := set.Files["profiles/creator.yaml"]; !ok {
+		t.Error("embedded set missing profiles/creator.yaml")
+	}
+	if err := set.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestComputeVersion_ContentSensitive(t *testing.T) {
+	a := map[string]string{"components/a.yaml": "one", "profiles/b.yaml": "two"}
+	b := map[string]string{"profiles/b.yaml": "two", "components/a.yaml": "one"}
+	c := map[string]string{"components/a.yaml": "one", "profiles/b.yaml": "three"}
+
+	if ComputeVersion(a) != ComputeVersion(b) {
+		t.Error("version depends on map order")
+	}
+	if ComputeVersion(a) == ComputeVersion(c) {
+		t.Error("different contents produced the same version")
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"escaping path", map[string]string{"../components/a.yaml": "x"}},
+		{"unknown directory", map[string]string{"other/a.yaml": "x"}},
+		{"non-yaml file", map[string]string{"components/a.sh": "x"}},
+		{"empty", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := &Set{Version: ComputeVersion(tt.files), Files: tt.files}
+			if err := set.Verify(); err == nil {
+				t.Error("Verify() = nil, want error")
+			}
+		})
+	}
+
+	tampered := &Set{Version: "0000000000000000", Files: map[string]string{"components/a.yaml": "x"}}
+	if err := tampered.Verify(); err == nil {
+		t.Error("Verify() accepted a version that doesn't match the contents")
+	}
+}
+
+func TestWriteDir_RoundTrip(t *testing.T) {
+	set, err := Embedded()
+	if err != nil {
+		t.Fatalf("Embedded() error = %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "cache", set.Version)
+	if err := set.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+
+	loaded, err := Load(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Version != set.Version {
+		t.Errorf("round-trip version = %s, want %s", loaded.Version, set.Version)
+	}
+
+	if err := set.WriteDir(dir); err == nil {
+		t.Error("WriteDir() over an existing set = nil, want error")
+	}
+}